@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Aggregation options
+	indexPattern string
+	field        string
+	top          int
+	cardinality  bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_agg",
+		Short: "Show the top values or distinct count of a field",
+		Long: `Run a terms or cardinality aggregation on a field and tabulate the result, for everyday
+questions such as "which users log in most" or "how many hosts are sending data".
+
+By default the most common values of the field are listed with their document counts and share
+of the documents. With --cardinality the approximate number of distinct values is shown instead.
+
+The field must be aggregatable: keyword, numeric, date, ip or boolean fields, or the .keyword
+subfield of a text field.
+
+Example usage:
+  es_agg --index="logs-*" --field=user.name
+  es_agg --index="logs-*" --field=host.name --top=50
+  es_agg --index="logs-*" --field=source.ip --cardinality`,
+		Example: `es_agg --index="logs-*" --field=user.name --top=20
+es_agg --index="logs-*" --field=http.response.status_code
+es_agg --index="logs-*" --field=source.ip --cardinality`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&indexPattern, "index", "i", "", "Index, alias, data stream or pattern to aggregate (required)")
+	rootCmd.Flags().StringVar(&field, "field", "", "Field to aggregate on (required)")
+	rootCmd.Flags().IntVar(&top, "top", 10, "Number of most common values to show")
+	rootCmd.Flags().BoolVar(&cardinality, "cardinality", false, "Show the approximate number of distinct values instead of the top values")
+	rootCmd.MarkFlagRequired("index")
+	rootCmd.MarkFlagRequired("field")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if top < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	if cardinality {
+		distinct, total, err := esClient.CardinalityAggregation(indexPattern, field)
+		if err != nil {
+			return fmt.Errorf("failed to count distinct values: %w", err)
+		}
+		header := []string{"Field", "Distinct Values", "Documents"}
+		rows := [][]string{{field, fmt.Sprintf("%d", distinct), fmt.Sprintf("%d", total)}}
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		return nil
+	}
+
+	result, err := esClient.TermsAggregation(indexPattern, field, top)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate: %w", err)
+	}
+	if len(result.Buckets) == 0 {
+		fmt.Printf("No values of '%s' in %d documents\n", field, result.TotalDocs)
+		return nil
+	}
+
+	header := []string{"Value", "Docs", "Share"}
+	rows := make([][]string, 0, len(result.Buckets)+1)
+	for _, b := range result.Buckets {
+		rows = append(rows, []string{b.Key, fmt.Sprintf("%d", b.DocCount), share(b.DocCount, result.TotalDocs)})
+	}
+	if result.OtherDocs > 0 {
+		rows = append(rows, []string{"(other)", fmt.Sprintf("%d", result.OtherDocs), share(result.OtherDocs, result.TotalDocs)})
+	}
+
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if result.ErrorBound > 0 {
+		fmt.Printf("\nCounts may be low by up to %d documents, as each shard returns only its own top values\n", result.ErrorBound)
+	}
+	return nil
+}
+
+// share formats a document count as a percentage of the total
+func share(docs, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(docs)*100/float64(total))
+}
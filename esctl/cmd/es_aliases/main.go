@@ -0,0 +1,516 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Alias options
+	indexPattern string
+	aliasName    string
+	indexName    string
+	filterJSON   string
+	writeIndex   bool
+	fromIndices  []string
+	toIndex      string
+	dryRun       bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_aliases",
+		Short: "Manage Elasticsearch aliases",
+		Long: `Manage Elasticsearch index aliases.
+
+The list subcommand shows each alias with the indices it points to, its write index and whether
+it is filtered. add and remove change a single index, and swap moves an alias from its current
+indices to another index, such as a reindexed copy, in one step so that searches never see the
+alias missing or on both.
+
+The bulk-add and bulk-remove subcommands roll an alias out to (or back from) every index matching
+a pattern. All changes are sent as a single _aliases request, so they are applied atomically: either
+every index gains or loses the alias, or none do.
+
+Use --dry-run to preview the indices affected and the exact actions payload without changing anything.
+
+Example usage:
+  es_aliases list
+  es_aliases swap --alias products --to products-v2
+  es_aliases bulk-add --pattern 'logs-2024.*' --alias logs-read --dry-run
+  es_aliases bulk-add --pattern 'logs-2024.*' --alias logs-read
+  es_aliases bulk-remove --pattern 'logs-2023.*' --alias logs-read`,
+		Example: `es_aliases list --alias 'logs-*'
+es_aliases add --index logs-2024.06 --alias logs-write --write-index
+es_aliases add --index orders --alias orders-eu --filter '{"term": {"region": "eu"}}'
+es_aliases remove --index logs-2023.01 --alias logs-read
+es_aliases swap --alias products --to products-v2 --dry-run
+es_aliases swap --alias products --from products-v1 --to products-v2
+es_aliases bulk-add --pattern 'logs-2024.*' --alias logs-read --dry-run
+es_aliases bulk-add --pattern 'logs-2024.*' --alias logs-read
+es_aliases bulk-remove --pattern 'logs-2023.*' --alias logs-read`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// List subcommand
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List aliases and the indices they point to",
+		Long: `List each alias with the indices it points to. The write index is the index that receives
+writes through the alias: the one marked as write index, or the only index of the alias.`,
+		Args: cobra.NoArgs,
+		RunE: runList,
+	}
+
+	// Add subcommand
+	var addCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Add an alias to an index",
+		Long: `Add an alias to an index. --filter limits the documents seen through the alias to those
+matching a query, and --write-index makes the index the one that receives writes through the
+alias.`,
+		Args: cobra.NoArgs,
+		RunE: runAdd,
+	}
+
+	// Remove subcommand
+	var removeCmd = &cobra.Command{
+		Use:   "remove",
+		Short: "Remove an alias from an index",
+		Args:  cobra.NoArgs,
+		RunE:  runRemove,
+	}
+
+	// Swap subcommand
+	var swapCmd = &cobra.Command{
+		Use:   "swap",
+		Short: "Move an alias to another index atomically",
+		Long: `Move an alias to another index in a single atomic _aliases request: the alias is removed from
+the --from indices, by default every index it points to now, and added to the --to index.
+--filter and --write-index apply to the alias on the new index.`,
+		Args: cobra.NoArgs,
+		RunE: runSwap,
+	}
+
+	// Bulk add subcommand
+	var bulkAddCmd = &cobra.Command{
+		Use:   "bulk-add",
+		Short: "Add an alias to every index matching a pattern",
+		Long:  `Add an alias to every index matching a pattern in a single atomic _aliases request.`,
+		RunE:  runBulkAdd,
+	}
+
+	// Bulk remove subcommand
+	var bulkRemoveCmd = &cobra.Command{
+		Use:   "bulk-remove",
+		Short: "Remove an alias from every index matching a pattern",
+		Long: `Remove an alias from every index matching a pattern in a single atomic _aliases request.
+Matching indices that do not currently hold the alias are skipped.`,
+		RunE: runBulkRemove,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Bulk command flags
+	for _, bulkCmd := range []*cobra.Command{bulkAddCmd, bulkRemoveCmd} {
+		bulkCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern selecting the indices (e.g., 'logs-2024.*') (required)")
+		bulkCmd.Flags().StringVarP(&aliasName, "alias", "a", "", "Name of the alias (required)")
+		bulkCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview the actions payload without applying it")
+		bulkCmd.MarkFlagRequired("pattern")
+		bulkCmd.MarkFlagRequired("alias")
+	}
+
+	bulkAddCmd.Flags().Bool("enforce", false, "Fail instead of warning when a name breaks the naming policy")
+
+	// List command flags
+	listCmd.Flags().StringVarP(&aliasName, "alias", "a", "", "Only list aliases matching this name, wildcards allowed")
+
+	// Add, remove and swap command flags
+	for _, aliasCmd := range []*cobra.Command{addCmd, removeCmd, swapCmd} {
+		aliasCmd.Flags().StringVarP(&aliasName, "alias", "a", "", "Name of the alias (required)")
+		aliasCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview the actions payload without applying it")
+		aliasCmd.MarkFlagRequired("alias")
+	}
+	for _, indexCmd := range []*cobra.Command{addCmd, removeCmd} {
+		indexCmd.Flags().StringVarP(&indexName, "index", "i", "", "Name of the index (required)")
+		indexCmd.MarkFlagRequired("index")
+	}
+	for _, newAliasCmd := range []*cobra.Command{addCmd, swapCmd} {
+		newAliasCmd.Flags().StringVar(&filterJSON, "filter", "", "Query DSL filter for the alias as JSON, e.g. '{\"term\": {\"region\": \"eu\"}}'")
+		newAliasCmd.Flags().BoolVar(&writeIndex, "write-index", false, "Make the index the write index of the alias")
+	}
+	addCmd.Flags().Bool("enforce", false, "Fail instead of warning when a name breaks the naming policy")
+	swapCmd.Flags().StringSliceVar(&fromIndices, "from", nil, "Indices to remove the alias from (default is every index it points to)")
+	swapCmd.Flags().StringVar(&toIndex, "to", "", "Index to move the alias to (required)")
+	swapCmd.MarkFlagRequired("to")
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, addCmd, removeCmd, swapCmd, bulkAddCmd, bulkRemoveCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runList handles the list command
+func runList(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	aliases, err := esClient.GetAliases(aliasName)
+	if err != nil {
+		return fmt.Errorf("failed to get aliases: %w", err)
+	}
+
+	// Group the alias to index associations by alias
+	byAlias := make(map[string][]client.AliasInfo)
+	for _, alias := range aliases {
+		byAlias[alias.Alias] = append(byAlias[alias.Alias], alias)
+	}
+	names := make([]string, 0, len(byAlias))
+	for name := range byAlias {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeIndices := client.AliasWriteIndices(aliases)
+
+	header := []string{"Alias", "Indices", "Write Index", "Filtered", "Routing"}
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		entries := byAlias[name]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Index < entries[j].Index })
+
+		var indices, filtered, routing []string
+		for _, entry := range entries {
+			indices = append(indices, entry.Index)
+			if entry.Filter != "" && entry.Filter != "-" {
+				filtered = append(filtered, entry.Index)
+			}
+			if entry.RoutingIndex != "" && entry.RoutingIndex != "-" {
+				routing = append(routing, entry.Index+"="+entry.RoutingIndex)
+			}
+		}
+		rows = append(rows, []string{
+			name,
+			strings.Join(indices, ", "),
+			valueOrDash(writeIndices[name]),
+			valueOrDash(strings.Join(filtered, ", ")),
+			valueOrDash(strings.Join(routing, ", ")),
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No aliases found")
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runAdd handles the add command
+func runAdd(cmd *cobra.Command, args []string) error {
+	action := client.AliasAction{Type: "add", Index: indexName, Alias: aliasName}
+	if err := setAliasOptions(cmd, &action); err != nil {
+		return err
+	}
+	return applyActions(cmd, []client.AliasAction{action})
+}
+
+// runRemove handles the remove command
+func runRemove(cmd *cobra.Command, args []string) error {
+	return applyActions(cmd, []client.AliasAction{{Type: "remove", Index: indexName, Alias: aliasName}})
+}
+
+// runSwap handles the swap command
+func runSwap(cmd *cobra.Command, args []string) error {
+	from := fromIndices
+	if len(from) == 0 {
+		// Load configuration with context containing viper instance
+		cfg, err := config.Load(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		// Initialize client
+		esClient, err := client.New(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+		}
+
+		existing, err := esClient.GetAliases(aliasName)
+		if err != nil {
+			return fmt.Errorf("failed to get aliases: %w", err)
+		}
+		for _, alias := range existing {
+			if alias.Alias == aliasName && alias.Index != toIndex {
+				from = append(from, alias.Index)
+			}
+		}
+		if len(from) == 0 {
+			return fmt.Errorf("alias '%s' does not point to any index other than '%s', use add to create it", aliasName, toIndex)
+		}
+	}
+
+	var actions []client.AliasAction
+	for _, index := range from {
+		if index == toIndex {
+			return fmt.Errorf("index '%s' is both a --from and the --to index", index)
+		}
+		actions = append(actions, client.AliasAction{Type: "remove", Index: index, Alias: aliasName})
+	}
+	add := client.AliasAction{Type: "add", Index: toIndex, Alias: aliasName}
+	if err := setAliasOptions(cmd, &add); err != nil {
+		return err
+	}
+	return applyActions(cmd, append(actions, add))
+}
+
+// setAliasOptions sets the filter and write index of an add action from the command line
+func setAliasOptions(cmd *cobra.Command, action *client.AliasAction) error {
+	if filterJSON != "" {
+		if err := json.Unmarshal([]byte(filterJSON), &action.Filter); err != nil {
+			return fmt.Errorf("invalid --filter JSON: %w", err)
+		}
+	}
+	if cmd.Flags().Changed("write-index") {
+		action.IsWriteIndex = &writeIndex
+	}
+	return nil
+}
+
+// applyActions applies (or previews) the actions in a single _aliases request
+func applyActions(cmd *cobra.Command, actions []client.AliasAction) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// New alias names are subject to the naming policy
+	if actions[len(actions)-1].Type == "add" {
+		if err := client.CheckNames(cfg, client.NamingKindAlias, aliasName); err != nil {
+			return err
+		}
+	}
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Prepare table data
+	header := []string{"Action", "Index", "Alias", "Options"}
+	rows := [][]string{}
+	for _, action := range actions {
+		var options []string
+		if action.Filter != nil {
+			options = append(options, "filtered")
+		}
+		if action.IsWriteIndex != nil {
+			options = append(options, fmt.Sprintf("write index: %t", *action.IsWriteIndex))
+		}
+		rows = append(rows, []string{action.Type, action.Index, action.Alias, strings.Join(options, ", ")})
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d actions would be applied atomically\n", len(actions))
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+
+		payload, err := json.MarshalIndent(client.AliasActionsPayload(actions), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format payload: %w", err)
+		}
+		fmt.Printf("\nPOST _aliases\n%s\n", string(payload))
+		return nil
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Apply all actions in one request
+	if err := esClient.UpdateAliases(actions); err != nil {
+		return fmt.Errorf("failed to update aliases: %w", err)
+	}
+
+	fmt.Printf("Applied %d alias actions atomically\n", len(actions))
+	return formatter.Write(header, rows)
+}
+
+// runBulkAdd handles the bulk-add command
+func runBulkAdd(cmd *cobra.Command, args []string) error {
+	return runBulk(cmd, "add")
+}
+
+// runBulkRemove handles the bulk-remove command
+func runBulkRemove(cmd *cobra.Command, args []string) error {
+	return runBulk(cmd, "remove")
+}
+
+// runBulk builds and applies (or previews) a single _aliases request for every matching index
+func runBulk(cmd *cobra.Command, actionType string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// New alias names are subject to the naming policy
+	if actionType == "add" {
+		if err := client.CheckNames(cfg, client.NamingKindAlias, aliasName); err != nil {
+			return err
+		}
+	}
+
+	// Resolve the pattern to concrete indices
+	indices, err := esClient.GetIndices(indexPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get indices: %w", err)
+	}
+
+	// Find indices that already hold the alias
+	existing, err := esClient.GetAliases(aliasName)
+	if err != nil {
+		return fmt.Errorf("failed to get aliases: %w", err)
+	}
+	hasAlias := make(map[string]bool, len(existing))
+	for _, alias := range existing {
+		if alias.Alias == aliasName {
+			hasAlias[alias.Index] = true
+		}
+	}
+
+	// Build the actions, skipping indices that are already in the desired state
+	var actions []client.AliasAction
+	skipped := 0
+	for _, idx := range indices {
+		if (actionType == "add") == hasAlias[idx.Name] {
+			skipped++
+			continue
+		}
+		actions = append(actions, client.AliasAction{Type: actionType, Index: idx.Name, Alias: aliasName})
+	}
+
+	if len(actions) == 0 {
+		fmt.Printf("No changes needed for alias '%s': %d indices match pattern '%s'\n", aliasName, len(indices), indexPattern)
+		return nil
+	}
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Prepare table data
+	header := []string{"Action", "Index", "Alias"}
+	rows := [][]string{}
+	for _, action := range actions {
+		rows = append(rows, []string{action.Type, action.Index, action.Alias})
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d actions would be applied atomically (%d matching indices already up to date)\n", len(actions), skipped)
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+
+		payload, err := json.MarshalIndent(client.AliasActionsPayload(actions), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format payload: %w", err)
+		}
+		fmt.Printf("\nPOST _aliases\n%s\n", string(payload))
+		return nil
+	}
+
+	// Apply all actions in one request
+	if err := esClient.UpdateAliases(actions); err != nil {
+		return fmt.Errorf("failed to update aliases: %w", err)
+	}
+
+	fmt.Printf("Applied %d alias actions atomically (%d matching indices already up to date)\n", len(actions), skipped)
+	return formatter.Write(header, rows)
+}
+
+// valueOrDash returns the value, or "-" when it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -29,6 +32,13 @@ var (
 	indexName   string
 	shardID     string
 	primaryFlag bool
+	allShards   bool
+	outputFile  string
+
+	// Flood stage options
+	floodPattern string
+	listBlocked  bool
+	ignoreDisk   bool
 
 	// Output
 	outputFormat string
@@ -59,11 +69,16 @@ Example usage:
   es_allocation status
   es_allocation enable
   es_allocation disable
-  es_allocation explain --index=my-index --shard=0 --primary`,
+  es_allocation explain --index=my-index --shard=0 --primary
+  es_allocation explain --all --redact --output-file=allocation-explain.json
+  es_allocation unblock-flood --pattern='*'`,
 		Example:          `es_allocation status
 es_allocation enable
 es_allocation disable
-es_allocation explain --index=my-index --shard=0 --primary`,
+es_allocation explain --index=my-index --shard=0 --primary
+es_allocation explain --all --redact --output-file=allocation-explain.json
+es_allocation unblock-flood --list
+es_allocation unblock-flood --pattern='logs-*'`,
 		PersistentPreRunE: initConfig,
 		RunE:              getStatus, // Default action is to get status
 	}
@@ -90,12 +105,35 @@ es_allocation explain --index=my-index --shard=0 --primary`,
 	var explainCmd = &cobra.Command{
 		Use:   "explain",
 		Short: "Get allocation explanation",
-		Long:  `Get detailed explanation of shard allocations, optionally for a specific shard.`,
-		RunE:  explainAllocation,
+		Long: `Get detailed explanation of shard allocations, optionally for a specific shard.
+
+Use --all to explain every unassigned shard, and --output-file to write the full output to a file
+that can be attached to a support ticket. With --redact, node names, node IDs, IP addresses and
+hostnames are replaced by tokens numbered for the run, so the file can be shared without exposing
+infrastructure details while still showing which decisions relate to the same node.`,
+		RunE: explainAllocation,
+	}
+
+	// Unblock flood subcommand
+	var unblockFloodCmd = &cobra.Command{
+		Use:   "unblock-flood",
+		Short: "Clear the read-only blocks set at the flood stage disk watermark",
+		Long: `Find the indices carrying the read_only_allow_delete block, which Elasticsearch sets when a
+node holding one of their shards goes over the flood stage disk watermark, and clear it.
+
+An index is only unblocked once every node holding its shards is back under the high disk
+watermark; otherwise the block would be set again as soon as the node crossed the flood stage.
+Indices on nodes that are still full are listed as DISK FULL and left blocked, unless
+--ignore-disk is given. Use --list to only show the blocked indices.
+
+Elasticsearch 7.4 and later clear the block themselves when the disk recovers, so blocks left
+behind usually come from an older version, a manual setting or a node that has not recovered.`,
+		RunE: unblockFlood,
 	}
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -106,8 +144,18 @@ es_allocation explain --index=my-index --shard=0 --primary`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
 
 	// Set status command flags
 	setStatusCmd.Flags().StringVarP(&status, "status", "s", "", "Allocation status to set (required, one of: all, primaries, new_primaries, none)")
@@ -117,13 +165,21 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	explainCmd.Flags().StringVarP(&indexName, "index", "i", "", "Index name (optional)")
 	explainCmd.Flags().StringVarP(&shardID, "shard", "s", "", "Shard ID (optional, requires index)")
 	explainCmd.Flags().BoolVarP(&primaryFlag, "primary", "p", false, "Whether the shard is primary (only used with index and shard)")
+	explainCmd.Flags().BoolVar(&allShards, "all", false, "Explain every unassigned shard")
+	explainCmd.Flags().StringVarP(&outputFile, "output-file", "o", "", "Write the explanation to a file instead of stdout")
+
+	// Unblock flood command flags
+	unblockFloodCmd.Flags().StringVar(&floodPattern, "pattern", "*", "Index pattern to check for blocks")
+	unblockFloodCmd.Flags().BoolVar(&listBlocked, "list", false, "Only list the blocked indices, do not clear any block")
+	unblockFloodCmd.Flags().BoolVar(&ignoreDisk, "ignore-disk", false, "Clear blocks even on indices with shards on nodes over the high watermark")
 
 	// Add subcommands
-	rootCmd.AddCommand(getStatusCmd, setStatusCmd, explainCmd)
+	rootCmd.AddCommand(getStatusCmd, setStatusCmd, explainCmd, unblockFloodCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -206,10 +262,35 @@ func explainAllocation(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("shard ID requires an index name")
 	}
 
+	if allShards && indexName != "" {
+		return fmt.Errorf("--all cannot be combined with --index")
+	}
+
 	// Get allocation explanation
-	explanation, err := esClient.GetAllocationExplain(indexName, shardID, primaryFlag)
-	if err != nil {
-		return fmt.Errorf("failed to get allocation explanation: %w", err)
+	var explanation interface{}
+	if allShards {
+		explanations, err := esClient.GetAllocationExplainAll()
+		if err != nil {
+			return fmt.Errorf("failed to get allocation explanations: %w", err)
+		}
+		// Convert to a generic document so it can be redacted like a single explanation
+		all := make([]interface{}, 0, len(explanations))
+		for _, e := range explanations {
+			all = append(all, e)
+		}
+		explanation = all
+	} else {
+		single, err := esClient.GetAllocationExplain(indexName, shardID, primaryFlag)
+		if err != nil {
+			return fmt.Errorf("failed to get allocation explanation: %w", err)
+		}
+		explanation = single
+	}
+
+	// Node identities are replaced by key first, then other sensitive values wherever they appear
+	redactor := format.DefaultRedactor()
+	if redactor != nil {
+		explanation = client.RedactAllocationExplain(explanation, redactor.Token)
 	}
 
 	// Format and print explanation
@@ -217,7 +298,91 @@ func explainAllocation(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to format explanation: %w", err)
 	}
+	if redactor != nil {
+		explanationJSON = []byte(redactor.String(string(explanationJSON)))
+	}
+
+	// Write to file if requested
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, append(explanationJSON, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		fmt.Printf("Allocation explanation written to %s\n", outputFile)
+		return nil
+	}
 
 	fmt.Println(string(explanationJSON))
 	return nil
 }
+
+// unblockFlood handles the unblock-flood command
+func unblockFlood(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	blocked, watermark, err := esClient.GetFloodBlockedIndices(floodPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get blocked indices: %w", err)
+	}
+	if len(blocked) == 0 {
+		fmt.Printf("No indices matching '%s' carry the read_only_allow_delete block\n", floodPattern)
+		return nil
+	}
+
+	// Unblock the indices whose nodes have recovered
+	var unblock []string
+	for _, index := range blocked {
+		if ignoreDisk || index.Recovered() {
+			unblock = append(unblock, index.Index)
+		}
+	}
+	if !listBlocked && len(unblock) > 0 {
+		if err := esClient.ClearFloodBlocks(unblock); err != nil {
+			return fmt.Errorf("failed to clear blocks: %w", err)
+		}
+	}
+
+	// Prepare table data
+	header := []string{"Index", "Nodes", "Over High Watermark", "Status"}
+	rows := [][]string{}
+	for _, index := range blocked {
+		status := "READY"
+		if !index.Recovered() {
+			status = "DISK FULL"
+		}
+		if !listBlocked && (ignoreDisk || index.Recovered()) {
+			status = "UNBLOCKED"
+		}
+		rows = append(rows, []string{
+			index.Index,
+			strings.Join(index.Nodes, ", "),
+			strings.Join(index.FullNodes, ", "),
+			status,
+		})
+	}
+
+	// Format and display output
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return err
+	}
+
+	switch {
+	case listBlocked:
+		fmt.Printf("\n%d blocked indices, %d ready to unblock (high watermark %s)\n", len(blocked), len(unblock), watermark)
+	case len(unblock) < len(blocked):
+		fmt.Printf("\nUnblocked %d of %d indices, %d left blocked on nodes over the high watermark (%s)\n", len(unblock), len(blocked), len(blocked)-len(unblock), watermark)
+	default:
+		fmt.Printf("\nUnblocked %d indices\n", len(unblock))
+	}
+	return nil
+}
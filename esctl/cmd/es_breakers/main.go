@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Breaker options
+	breakers        []string
+	allBreakers     bool
+	warnPercent     float64
+	criticalPercent float64
+	flaggedOnly     bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_breakers",
+		Short: "Display circuit breaker usage per node",
+		Long: `Display the usage of the circuit breakers on every node, to spot nodes close to rejecting
+requests with circuit_breaking_exception (HTTP 429).
+
+For each breaker the estimated memory it accounts for is shown against its limit, with the
+number of times it has tripped since the node started. Breakers at or above --warn percent of
+their limit are flagged WARNING, those at or above --critical percent CRITICAL.
+
+The breakers shown by default are:
+- parent: total memory of all child breakers, or of the whole heap with real memory accounting
+- fielddata: fielddata loaded for sorting and aggregating on text fields
+- request: per-request structures such as aggregation buckets
+- inflight_requests: bytes of requests being received or sent over transport and HTTP
+
+Use --all to include every breaker the nodes report, such as accounting and eql_sequence.
+
+Example usage:
+  es_breakers
+  es_breakers --flagged
+  es_breakers --all --warn=60 --critical=85`,
+		Example: `es_breakers
+es_breakers --flagged
+es_breakers --breakers=parent,fielddata
+es_breakers --all --format=json`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Breaker flags
+	rootCmd.Flags().StringSliceVar(&breakers, "breakers", client.DefaultBreakers, "Circuit breakers to show")
+	rootCmd.Flags().BoolVar(&allBreakers, "all", false, "Show every circuit breaker the nodes report")
+	rootCmd.Flags().Float64Var(&warnPercent, "warn", 75, "Flag breakers at or above this percentage of their limit as WARNING")
+	rootCmd.Flags().Float64Var(&criticalPercent, "critical", 90, "Flag breakers at or above this percentage of their limit as CRITICAL")
+	rootCmd.Flags().BoolVar(&flaggedOnly, "flagged", false, "Only show breakers that are flagged or have tripped")
+	rootCmd.MarkFlagsMutuallyExclusive("breakers", "all")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// run executes the command
+func run(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create client
+	c, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating client: %w", err)
+	}
+
+	names := breakers
+	if allBreakers {
+		names = nil
+	}
+
+	// Get node breaker stats
+	nodeBreakers, err := c.GetNodeBreakers(names)
+	var partial *client.PartialNodeError
+	if err != nil && !errors.As(err, &partial) {
+		return fmt.Errorf("error getting node breaker stats: %w", err)
+	}
+
+	// Prepare data for output
+	header := []string{"Node", "Breaker", "Estimated", "Limit", "Used %", "Overhead", "Tripped", "Status"}
+	var rows [][]string
+	flagged, tripped := 0, 0
+
+	for _, b := range nodeBreakers {
+		status := b.Status(warnPercent, criticalPercent)
+		if status != "OK" {
+			flagged++
+		}
+		if b.Tripped > 0 {
+			tripped++
+		}
+		if flaggedOnly && status == "OK" && b.Tripped == 0 {
+			continue
+		}
+
+		rows = append(rows, []string{
+			b.Node,
+			b.Breaker,
+			client.ByteCountSI(b.Estimated),
+			client.ByteCountSI(b.Limit),
+			fmt.Sprintf("%.1f%%", b.UsedPercent()),
+			fmt.Sprintf("%g", b.Overhead),
+			fmt.Sprintf("%d", b.Tripped),
+			status,
+		})
+	}
+
+	// Create formatter and output
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	fmt.Printf("\n%d breakers at or above %.0f%% of their limit, %d have tripped since their node started\n", flagged, warnPercent, tripped)
+
+	if partial != nil {
+		client.PrintNodeErrors(partial)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Cache options
+	indexPattern string
+	top          int
+	cacheTypes   []string
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_cache",
+		Short: "Report and clear Elasticsearch caches",
+		Long: `Report fielddata, query cache and request cache usage, and clear caches.
+
+The report subcommand shows the memory used by each cache, with evictions and hit rates, for
+every node and for the indices using the most cache memory. High fielddata usage usually means
+aggregations or sorting on text fields; frequent evictions mean a cache is too small for the
+workload.
+
+The clear subcommand clears one or more cache types for the indices matching a pattern.
+
+Example usage:
+  es_cache report
+  es_cache report --pattern="logs-*" --top=20
+  es_cache clear --pattern="logs-*" --type=fielddata`,
+		Example: `es_cache report
+es_cache report --top=20
+es_cache clear --pattern="logs-*" --type=fielddata,request`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Report subcommand
+	var reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Report cache usage per node and per index",
+		Long:  `Report fielddata, query cache and request cache usage per node and for the indices using the most cache memory.`,
+		RunE:  runReport,
+	}
+
+	// Clear subcommand
+	var clearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Clear caches",
+		Long:  `Clear the fielddata, query or request cache, or all of them, for the indices matching a pattern.`,
+		RunE:  runClear,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Report command flags
+	reportCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern to report on (e.g., 'logs-*')")
+	reportCmd.Flags().IntVarP(&top, "top", "n", 10, "Number of indices to list, largest cache users first (0 for all)")
+
+	// Clear command flags
+	clearCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern to clear caches for (default is all indices)")
+	clearCmd.Flags().StringSliceVarP(&cacheTypes, "type", "t", nil, "Cache types to clear: "+strings.Join(client.CacheTypes, ", ")+" (default is all)")
+
+	// Add subcommands
+	rootCmd.AddCommand(reportCmd, clearCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runReport handles the report command
+func runReport(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	nodes, err := esClient.GetNodeCacheUsage()
+	var partial *client.PartialNodeError
+	if err != nil && !errors.As(err, &partial) {
+		return fmt.Errorf("failed to get node cache usage: %w", err)
+	}
+
+	indices, err := esClient.GetIndexCacheUsage(indexPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get index cache usage: %w", err)
+	}
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	header := []string{"Name", "Fielddata", "FD Evictions", "Query Cache", "QC Hit %", "QC Evictions", "Request Cache", "RC Hit %", "RC Evictions", "Total"}
+
+	fmt.Printf("\nNodes:\n")
+	if err := formatter.Write(header, cacheRows(nodes)); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	shown := indices
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+	fmt.Printf("\nIndices (top %d of %d by cache memory):\n", len(shown), len(indices))
+	if len(shown) == 0 {
+		fmt.Println("No indices found")
+	} else if err := formatter.Write(header, cacheRows(shown)); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if partial != nil {
+		client.PrintNodeErrors(partial)
+	}
+	return nil
+}
+
+// runClear handles the clear command
+func runClear(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	if err := esClient.ClearCache(indexPattern, cacheTypes); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	target := "all indices"
+	if indexPattern != "" {
+		target = fmt.Sprintf("indices matching '%s'", indexPattern)
+	}
+	types := "all caches"
+	if len(cacheTypes) > 0 {
+		types = strings.Join(cacheTypes, ", ") + " cache"
+	}
+	fmt.Printf("Cleared %s for %s\n", types, target)
+	return nil
+}
+
+// cacheRows formats cache usage as table rows
+func cacheRows(usage []client.CacheUsage) [][]string {
+	rows := make([][]string, 0, len(usage))
+	for _, u := range usage {
+		rows = append(rows, []string{
+			u.Name,
+			client.ByteCountSI(u.FielddataBytes),
+			fmt.Sprintf("%d", u.FielddataEvictions),
+			client.ByteCountSI(u.QueryCacheBytes),
+			hitRate(u.QueryCacheHits, u.QueryCacheMisses),
+			fmt.Sprintf("%d", u.QueryCacheEvictions),
+			client.ByteCountSI(u.RequestCacheBytes),
+			hitRate(u.RequestCacheHits, u.RequestCacheMisses),
+			fmt.Sprintf("%d", u.RequestCacheEvictions),
+			client.ByteCountSI(u.TotalBytes()),
+		})
+	}
+	return rows
+}
+
+// hitRate formats the percentage of cache lookups that were hits
+func hitRate(hits, misses int64) string {
+	if hits+misses == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(hits)/float64(hits+misses)*100)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for certificate findings. 1 is left for errors, as in the other commands.
+const (
+	exitExpiring = 2
+	exitExpired  = 3
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Kibana endpoints
+	kbAddresses []string
+	kbCaCert    string
+
+	// Certificate options
+	warnDays   int
+	skipProbe  bool
+	skipNodeCA bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_certs",
+		Short: "Check TLS certificate expiry for Elasticsearch and Kibana",
+		Long: `List the TLS certificates in use and when they expire.
+
+Two sources are checked:
+- The certificates Elasticsearch has loaded (keystores, truststores and PEM files for the HTTP and
+  transport layers), from the SSL certificates API. The API reports on the node that handles
+  the request only.
+- The certificates each Elasticsearch and Kibana HTTPS address presents in its TLS handshake,
+  including the chain, which is also verified against the configured CA certificate.
+
+The exit code reflects the worst finding, for use in monitoring:
+  0  every certificate is valid for more than --warn-days
+  2  a certificate expires within --warn-days, a presented chain does not verify, or an HTTPS
+     address could not be checked
+  3  a certificate has expired
+
+Example usage:
+  es_certs
+  es_certs --warn-days=60
+  es_certs --kb-addresses=https://kibana:5601`,
+		Example: `es_certs
+es_certs --warn-days=14 --format=json
+es_certs --kb-addresses=https://kibana:5601 --kb-ca-cert=/path/to/ca.crt`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Kibana endpoint flags
+	rootCmd.PersistentFlags().StringSliceVar(&kbAddresses, "kb-addresses", nil, "Kibana addresses to probe (default is kibana.addresses from the config file)")
+	rootCmd.PersistentFlags().StringVar(&kbCaCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+
+	// Command specific flags
+	rootCmd.Flags().IntVar(&warnDays, "warn-days", 30, "Warn about certificates expiring within this many days")
+	rootCmd.Flags().BoolVar(&skipProbe, "no-probe", false, "Do not probe the HTTPS addresses, only list the certificates loaded by Elasticsearch")
+	rootCmd.Flags().BoolVar(&skipNodeCA, "no-node-certs", false, "Do not list the certificates loaded by Elasticsearch, only probe the HTTPS addresses")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if skipProbe && skipNodeCA {
+		return fmt.Errorf("--no-probe and --no-node-certs together leave nothing to check")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cmd.Flags().Changed("kb-addresses") {
+		cfg.Kibana.Addresses = kbAddresses
+	}
+	if cmd.Flags().Changed("kb-ca-cert") {
+		cfg.Kibana.CACert = kbCaCert
+	}
+
+	now := time.Now()
+	header := []string{"Source", "Certificate", "Subject", "Issuer", "Expires", "Days Left", "Status"}
+	var rows [][]string
+	worst := 0
+
+	if !skipNodeCA {
+		// Initialize client
+		esClient, err := client.New(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		certs, err := esClient.GetNodeCertificates()
+		if err != nil {
+			return fmt.Errorf("failed to get certificates: %w", err)
+		}
+		for _, cert := range certs {
+			status, code := expiryStatus(cert.Expiry, now)
+			worst = maxCode(worst, code)
+			name := cert.Path
+			if cert.Alias != "" {
+				name = fmt.Sprintf("%s (%s)", cert.Path, cert.Alias)
+			}
+			rows = append(rows, []string{"elasticsearch", name, cert.Subject, cert.Issuer, cert.Expiry.Format(time.RFC3339), daysLeft(cert.Expiry, now), status})
+		}
+	}
+
+	if !skipProbe {
+		for _, address := range cfg.Elasticsearch.Addresses {
+			probe := client.ProbeEndpointCertificates(address, cfg.Elasticsearch.CACert, 10*time.Second)
+			worst = maxCode(worst, addProbeRows(&rows, "elasticsearch endpoint", probe, now))
+		}
+		for _, address := range cfg.Kibana.Addresses {
+			// The default Kibana address is plain HTTP, only report Kibana addresses using TLS
+			if !strings.HasPrefix(address, "https://") {
+				continue
+			}
+			probe := client.ProbeEndpointCertificates(address, cfg.Kibana.CACert, 10*time.Second)
+			worst = maxCode(worst, addProbeRows(&rows, "kibana endpoint", probe, now))
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if worst != 0 {
+		os.Exit(worst)
+	}
+	return nil
+}
+
+// addProbeRows adds the certificates an endpoint presented to the rows and returns the exit code
+// of the worst finding
+func addProbeRows(rows *[][]string, source string, probe client.EndpointProbe, now time.Time) int {
+	if probe.Err != nil {
+		*rows = append(*rows, []string{source, probe.Address, "-", "-", "-", "-", probe.Err.Error()})
+		// Plain HTTP addresses have nothing to check, but an HTTPS address that cannot be checked is a warning
+		if !strings.HasPrefix(probe.Address, "https://") {
+			return 0
+		}
+		return exitExpiring
+	}
+
+	worst := 0
+	for _, cert := range probe.Certificates {
+		status, code := expiryStatus(cert.NotAfter, now)
+		name := probe.Address
+		if cert.Position > 0 {
+			name = fmt.Sprintf("%s (chain %d)", probe.Address, cert.Position)
+		}
+		// Verification covers the whole chain, report it on the server certificate
+		if cert.Position == 0 && probe.VerifyError != "" {
+			status = fmt.Sprintf("%s, untrusted: %s", status, probe.VerifyError)
+			code = maxCode(code, exitExpiring)
+		}
+		worst = maxCode(worst, code)
+		*rows = append(*rows, []string{source, name, cert.Subject, cert.Issuer, cert.NotAfter.Format(time.RFC3339), daysLeft(cert.NotAfter, now), status})
+	}
+	return worst
+}
+
+// expiryStatus describes how close a certificate is to expiry, with the matching exit code
+func expiryStatus(expiry, now time.Time) (string, int) {
+	switch {
+	case !expiry.After(now):
+		return "EXPIRED", exitExpired
+	case expiry.Sub(now) < time.Duration(warnDays)*24*time.Hour:
+		return "expiring", exitExpiring
+	}
+	return "ok", 0
+}
+
+// daysLeft formats the whole days until expiry, negative once expired
+func daysLeft(expiry, now time.Time) string {
+	return fmt.Sprintf("%d", int(math.Floor(expiry.Sub(now).Hours()/24)))
+}
+
+// maxCode returns the more severe of two exit codes
+func maxCode(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Compare options
+	contexts []string
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_compare",
+		Short: "Compare the configuration of two clusters",
+		Long: `Report the differences between two clusters named in the contexts section of the config
+file, for example before a failover exercise.
+
+The clusters are compared on:
+
+  version             Elasticsearch version of the nodes
+  plugin              plugins installed on any node, with their versions
+  setting             persistent and transient cluster settings
+  index template      index template names and a hash of their definitions
+  component template  component template names and a hash of their definitions
+  ilm policy          ILM policy names and a hash of their definitions
+
+Only items that differ are listed. A value of "-" means the item only exists on the other
+cluster; use es_dump_config on both clusters to see how two definitions differ.
+
+Example usage:
+  es_compare --contexts prod-a,prod-b
+  es_compare --contexts prod-a,prod-b --format=csv`,
+		Example: `es_compare --contexts prod-a,prod-b
+es_compare --contexts prod-a,prod-b --format=json`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+
+	// Command specific flags
+	rootCmd.Flags().StringSliceVar(&contexts, "contexts", nil, "The two clusters to compare, by their name in the contexts section of the config file (required)")
+	rootCmd.MarkFlagRequired("contexts")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, nil, "", "", "", false, false, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if len(contexts) != 2 {
+		return fmt.Errorf("--contexts takes exactly two context names, got %d", len(contexts))
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Read both clusters before reporting, so a failure on the second does not leave half a report
+	profiles := make([]*client.ClusterProfile, 0, len(contexts))
+	for _, name := range contexts {
+		contextCfg, err := cfg.ForContext(name)
+		if err != nil {
+			return err
+		}
+		esClient, err := client.New(contextCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client for %s: %w", name, err)
+		}
+		profile, err := esClient.GetClusterProfile()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	diffs := client.CompareClusterProfiles(profiles[0], profiles[1])
+	if len(diffs) == 0 {
+		fmt.Printf("No differences between %s and %s\n", contexts[0], contexts[1])
+		return nil
+	}
+
+	header := []string{"Category", "Name", contexts[0], contexts[1]}
+	rows := make([][]string, 0, len(diffs))
+	for _, d := range diffs {
+		rows = append(rows, []string{d.Category, d.Name, valueOrMissing(d.A), valueOrMissing(d.B)})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// valueOrMissing shows a value, or "-" for an item the cluster does not have
+func valueOrMissing(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// maxHistorySnapshots is the number of runs kept in the history file per cluster and pattern
+const maxHistorySnapshots = 200
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Count options
+	indexPattern string
+	since        string
+	historyFile  string
+	noSave       bool
+
+	// Output
+	outputFormat string
+)
+
+// countSnapshot is the document counts recorded by one run
+type countSnapshot struct {
+	Time   time.Time        `json:"time"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// countHistory is the history file, with the snapshots of each cluster and pattern
+type countHistory struct {
+	Entries map[string][]countSnapshot `json:"entries"`
+}
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_count",
+		Short: "Report document counts and growth",
+		Long: `Report the document count of every index matching a pattern, and how it changed.
+
+Each run records the counts in a local history file. When the history has an earlier run for the
+same cluster and pattern, the change in documents and the ingestion rate since that run are
+shown, which makes it easy to check that data is flowing again after maintenance.
+
+By default the counts are compared with the previous run. With --since they are compared with the
+most recent run at least that long ago, or the oldest recorded run if there is none.
+
+Example usage:
+  es_count --index="logs-*"
+  es_count --index="logs-*" --since=24h
+  es_count --index="metrics-*" --no-save`,
+		Example: `es_count --index="logs-*"
+es_count --index="logs-*" --since=24h
+es_count --index="logs-*" --format=json`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&indexPattern, "index", "i", "*", "Index pattern to count")
+	rootCmd.Flags().StringVar(&since, "since", "", "Compare with the most recent run at least this long ago (e.g. 1h, 24h, 7d) instead of the previous run")
+	rootCmd.Flags().StringVar(&historyFile, "history-file", "", "History file (default is es_count_history.json in the user cache directory)")
+	rootCmd.Flags().BoolVar(&noSave, "no-save", false, "Do not record this run in the history file")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	var sinceDuration time.Duration
+	if since != "" {
+		d, err := client.ParseTimeValue(since)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		sinceDuration = d
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	counts, err := esClient.GetDocCounts(indexPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get document counts: %w", err)
+	}
+	if len(counts) == 0 {
+		fmt.Printf("No open indices match '%s'\n", indexPattern)
+		return nil
+	}
+
+	now := time.Now()
+	current := countSnapshot{Time: now, Counts: make(map[string]int64, len(counts))}
+	for _, c := range counts {
+		current.Counts[c.Index] = c.Docs
+	}
+
+	// Find the run to compare with
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	history, err := loadHistory(path)
+	if err != nil {
+		return err
+	}
+	key := strings.Join(cfg.Elasticsearch.Addresses, ",") + "|" + indexPattern
+	baseline := findBaseline(history.Entries[key], now, sinceDuration)
+
+	// Output results
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(countTable(counts, baseline, now)); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if baseline != nil {
+		fmt.Printf("\nCompared with the run at %s (%s ago)\n", baseline.Time.Format(time.RFC3339), now.Sub(baseline.Time).Round(time.Second))
+	} else if !noSave {
+		fmt.Println("\nNo earlier run recorded, run again later to see the change")
+	}
+
+	// Record this run
+	if noSave {
+		return nil
+	}
+	snapshots := append(history.Entries[key], current)
+	if len(snapshots) > maxHistorySnapshots {
+		snapshots = snapshots[len(snapshots)-maxHistorySnapshots:]
+	}
+	history.Entries[key] = snapshots
+	return saveHistory(path, history)
+}
+
+// countTable builds the output table, with change and rate columns when there is a baseline
+func countTable(counts []client.IndexDocCount, baseline *countSnapshot, now time.Time) ([]string, [][]string) {
+	if baseline == nil {
+		var total int64
+		rows := make([][]string, 0, len(counts)+1)
+		for _, c := range counts {
+			total += c.Docs
+			rows = append(rows, []string{c.Index, fmt.Sprintf("%d", c.Docs)})
+		}
+		rows = append(rows, []string{"TOTAL", fmt.Sprintf("%d", total)})
+		return []string{"Index", "Docs"}, rows
+	}
+
+	elapsed := now.Sub(baseline.Time).Seconds()
+	var total, previousTotal int64
+	rows := make([][]string, 0, len(counts)+1)
+	for _, c := range counts {
+		total += c.Docs
+		previous, ok := baseline.Counts[c.Index]
+		if !ok {
+			rows = append(rows, []string{c.Index, fmt.Sprintf("%d", c.Docs), "-", "new", "-"})
+			continue
+		}
+		previousTotal += previous
+		rows = append(rows, countRow(c.Index, c.Docs, previous, elapsed))
+	}
+	rows = append(rows, countRow("TOTAL", total, previousTotal, elapsed))
+
+	return []string{"Index", "Docs", "Previous", "Change", "Rate (docs/s)"}, rows
+}
+
+// countRow formats the counts of an index and its change since the baseline
+func countRow(index string, docs, previous int64, elapsed float64) []string {
+	delta := docs - previous
+	rate := "-"
+	if elapsed > 0 {
+		rate = fmt.Sprintf("%.1f", float64(delta)/elapsed)
+	}
+	return []string{index, fmt.Sprintf("%d", docs), fmt.Sprintf("%d", previous), fmt.Sprintf("%+d", delta), rate}
+}
+
+// findBaseline returns the previous snapshot, or with a since duration the most recent snapshot
+// at least that old, falling back to the oldest one
+func findBaseline(snapshots []countSnapshot, now time.Time, since time.Duration) *countSnapshot {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	if since <= 0 {
+		return &snapshots[len(snapshots)-1]
+	}
+
+	cutoff := now.Add(-since)
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !snapshots[i].Time.After(cutoff) {
+			return &snapshots[i]
+		}
+	}
+	return &snapshots[0]
+}
+
+// historyPath returns the history file path
+func historyPath() (string, error) {
+	if historyFile != "" {
+		return historyFile, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "esctl", "es_count_history.json"), nil
+}
+
+// loadHistory reads the history file, returning an empty history if it does not exist
+func loadHistory(path string) (*countHistory, error) {
+	history := &countHistory{Entries: make(map[string][]countSnapshot)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("failed to parse history file %s: %w", path, err)
+	}
+	if history.Entries == nil {
+		history.Entries = make(map[string][]countSnapshot)
+	}
+	return history, nil
+}
+
+// saveHistory writes the history file
+func saveHistory(path string, history *countHistory) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
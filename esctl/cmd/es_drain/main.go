@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
@@ -28,6 +29,7 @@ var (
 	// Server drain options
 	nodeName string
 	stopDrain bool
+	force bool
 
 	// Output
 	outputFormat string
@@ -60,6 +62,7 @@ Example usage:
   es_drain stop --node=node-1`,
 		Example: `es_drain start --node=node-1
 es_drain status
+es_drain simulate --name=node-1
 es_drain stop --node=node-1`,
 		PersistentPreRunE: initConfig,
 	}
@@ -70,7 +73,11 @@ es_drain stop --node=node-1`,
 	var serverCmd = &cobra.Command{
 		Use:   "server",
 		Short: "Drain a server by excluding shards from it",
-		Long:  `This command will set the shard allocation rules to exclude the given server name. This will cause shards to be moved away from this server, draining the data away.`,
+		Long:  `This command will set the shard allocation rules to exclude the given server name. This will cause shards to be moved away from this server, draining the data away.
+
+Before the exclusion is applied, the remaining data nodes are checked for enough free space below
+the high disk watermark to absorb the drained node's shards. The drain is refused if they don't,
+unless --force is given.`,
 		RunE:  runServerDrain,
 	}
 
@@ -82,8 +89,19 @@ es_drain stop --node=node-1`,
 		RunE:  runDrainStatus,
 	}
 
+	// Simulate subcommand
+	var simulateCmd = &cobra.Command{
+		Use:   "simulate",
+		Short: "Show the impact of removing a server without changing anything",
+		Long: `This command will report how many shards and bytes would need to move if the given server was
+drained or lost, which indices would temporarily lose replica redundancy (or become unavailable),
+and the projected disk usage of each remaining data node. No settings are changed.`,
+		RunE: runDrainSimulate,
+	}
+
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -94,20 +112,36 @@ es_drain stop --node=node-1`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
 
 	// Server drain flags
 	serverCmd.Flags().StringVarP(&nodeName, "name", "n", "", "Elasticsearch node name to drain (required)")
 	serverCmd.Flags().BoolVarP(&stopDrain, "stop", "s", false, "Stop draining the node instead of starting it")
+	serverCmd.Flags().BoolVar(&force, "force", false, "Drain even if the remaining nodes lack disk headroom below the high watermark")
 	serverCmd.MarkFlagRequired("name")
 
+	// Simulate flags
+	simulateCmd.Flags().StringVarP(&nodeName, "name", "n", "", "Elasticsearch node name to simulate removing (required)")
+	simulateCmd.MarkFlagRequired("name")
+
 	// Add subcommands
-	rootCmd.AddCommand(serverCmd, statusCmd)
+	rootCmd.AddCommand(serverCmd, statusCmd, simulateCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -140,6 +174,23 @@ func runServerDrain(cmd *cobra.Command, args []string) error {
 		excludedNodes, err = esClient.StopDrainServer(nodeName)
 	} else {
 		action = "draining"
+
+		// Make sure the rest of the cluster can take the data before excluding the node
+		if !force {
+			headroom, err := esClient.CheckDrainHeadroom(nodeName)
+			if err != nil {
+				return fmt.Errorf("failed to check disk headroom (use --force to skip): %w", err)
+			}
+
+			if !headroom.Sufficient() {
+				return fmt.Errorf("refusing to drain node %s: %s of shard data would need to move but the remaining nodes only have %s below the high watermark (%s); use --force to override",
+					nodeName, client.ByteCountSI(headroom.BytesToMove), client.ByteCountSI(headroom.Headroom), headroom.HighWatermark)
+			}
+
+			fmt.Fprintf(os.Stderr, "Disk headroom check passed: %s to move, %s available below the high watermark (%s)\n",
+				client.ByteCountSI(headroom.BytesToMove), client.ByteCountSI(headroom.Headroom), headroom.HighWatermark)
+		}
+
 		excludedNodes, err = esClient.DrainServer(nodeName)
 	}
 
@@ -224,3 +275,71 @@ func runDrainStatus(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runDrainSimulate handles the drain simulate command
+func runDrainSimulate(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Simulate removing the node
+	impact, err := esClient.SimulateNodeRemoval(nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to simulate removal of node %s: %w", nodeName, err)
+	}
+
+	fmt.Printf("Removing node %s would move %d shards (%s)\n", nodeName, impact.ShardsToMove, client.ByteCountSI(impact.BytesToMove))
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Indices losing redundancy
+	if len(impact.Indices) > 0 {
+		fmt.Println("\nIndices affected:")
+		header := []string{"Index", "Shards On Node", "Reduced Redundancy", "Unavailable"}
+		rows := [][]string{}
+		for _, idx := range impact.Indices {
+			rows = append(rows, []string{
+				idx.Index,
+				fmt.Sprintf("%d", idx.ShardsOnNode),
+				fmt.Sprintf("%d", idx.ReducedShards),
+				fmt.Sprintf("%d", idx.UnavailableShards),
+			})
+		}
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	}
+
+	// Projected disk usage
+	if len(impact.Nodes) > 0 {
+		fmt.Println("\nProjected disk usage on remaining nodes:")
+		header := []string{"Node", "Disk Total", "Disk Used", "Incoming", "Projected Used", "Projected %"}
+		rows := [][]string{}
+		for _, node := range impact.Nodes {
+			rows = append(rows, []string{
+				node.Name,
+				client.ByteCountSI(node.DiskTotal),
+				client.ByteCountSI(node.DiskUsed),
+				client.ByteCountSI(node.ProjectedBytes),
+				client.ByteCountSI(node.ProjectedUsed),
+				fmt.Sprintf("%.1f%%", node.ProjectedPercent()),
+			})
+		}
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	} else {
+		fmt.Println("\nNo remaining data nodes are available to receive shards")
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// clusterSettingsFile is the file the persistent and transient cluster settings are written to
+const clusterSettingsFile = "cluster_settings.json"
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Dump options
+	outputDir      string
+	includeManaged bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_dump_config",
+		Short: "Dump cluster configuration to a directory for version control",
+		Long: `Export the configuration of a cluster as individual pretty-printed JSON files, so changes
+can be tracked and diffed in version control.
+
+The output directory holds one directory per kind of object, with a file per object:
+
+  index_templates/<name>.json
+  component_templates/<name>.json
+  ilm_policies/<name>.json
+  ingest_pipelines/<name>.json
+  cluster_settings.json          persistent and transient settings, flattened
+
+Keys are written in sorted order and fields that change on their own, such as ILM policy
+versions and modification dates, are left out, so a dump only differs from the last one when the
+configuration did. Files left in these directories by an earlier dump are removed when the
+object no longer exists. Objects marked as managed by Elastic are skipped unless
+--include-managed is given.
+
+Example usage:
+  es_dump_config --output=./cluster-config
+  es_dump_config --output=./cluster-config --include-managed`,
+		Example: `es_dump_config --output=./cluster-config
+es_dump_config --output=./cluster-config --include-managed
+cd cluster-config && git add -A && git commit -m "cluster config $(date +%F)"`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&outputDir, "output", "o", "", "Directory to write the configuration to (required)")
+	rootCmd.Flags().BoolVar(&includeManaged, "include-managed", false, "Also dump the templates, policies and pipelines managed by Elastic")
+	rootCmd.MarkFlagRequired("output")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Read everything before writing, so a failure does not leave a partial dump
+	kinds, err := esClient.GetClusterConfig(includeManaged)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster configuration: %w", err)
+	}
+	settings, err := esClient.GetClusterSettings(false)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster settings: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	header := []string{"Kind", "Objects", "Removed", "Directory"}
+	rows := make([][]string, 0, len(kinds)+1)
+	for _, kind := range kinds {
+		removed, err := writeKind(kind)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{kind.Kind, fmt.Sprintf("%d", len(kind.Objects)), fmt.Sprintf("%d", removed), filepath.Join(outputDir, kind.Kind)})
+	}
+
+	// Only the settings that were set, not defaults
+	clusterSettings := map[string]interface{}{
+		"persistent": settings["persistent"],
+		"transient":  settings["transient"],
+	}
+	if err := writeJSON(filepath.Join(outputDir, clusterSettingsFile), clusterSettings); err != nil {
+		return err
+	}
+	rows = append(rows, []string{"cluster_settings", fmt.Sprintf("%d", len(settings["persistent"])+len(settings["transient"])), "-", filepath.Join(outputDir, clusterSettingsFile)})
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// writeKind writes each object of a kind to its own file and removes the files of objects that no
+// longer exist. It returns the number of files removed.
+func writeKind(kind client.ConfigObjects) (int, error) {
+	dir := filepath.Join(outputDir, kind.Kind)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(kind.Objects))
+	wanted := make(map[string]bool, len(kind.Objects))
+	for name := range kind.Objects {
+		names = append(names, name)
+		wanted[objectFilename(name)] = true
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := writeJSON(filepath.Join(dir, objectFilename(name)), kind.Objects[name]); err != nil {
+			return 0, err
+		}
+	}
+
+	// Remove the files of deleted objects, so the dump mirrors the cluster
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	removed := 0
+	for _, path := range existing {
+		if wanted[filepath.Base(path)] {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// objectFilename returns the file name of an object, replacing characters not allowed in paths
+func objectFilename(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name) + ".json"
+}
+
+// writeJSON writes a value as pretty-printed JSON. Map keys are sorted, so the same value always
+// produces the same file.
+func writeJSON(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
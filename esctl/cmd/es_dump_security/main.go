@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Dump options
+	outputDir       string
+	includeReserved bool
+	restore         bool
+	dryRun          bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_dump_security",
+		Short: "Dump security configuration to a directory for audit and re-creation",
+		Long: `Export the security configuration of a cluster as individual pretty-printed JSON files, for
+audit and to re-create roles and role mappings on a rebuilt cluster.
+
+The output directory holds one directory per kind of object, with a file per object:
+
+  roles/<name>.json
+  role_mappings/<name>.json
+  users/<username>.json          native users, without passwords or password hashes
+  api_keys/<id>.json             metadata only, API key secrets cannot be read back
+
+Keys are written in sorted order, so a dump only differs from the last one when the
+configuration did. Files left in these directories by an earlier dump are removed when the
+object no longer exists. Built-in users and roles are skipped unless --include-reserved is given.
+
+With --restore, the roles and role mappings of the directory are created or replaced on the
+cluster instead, roles first so the mappings can refer to them. Users and API keys are not
+restored: users need new passwords and API keys new secrets.
+
+Example usage:
+  es_dump_security --output=./security
+  es_dump_security --output=./security --restore --dry-run
+  es_dump_security --output=./security --restore`,
+		Example: `es_dump_security --output=./security
+es_dump_security --output=./security --include-reserved
+es_dump_security --output=./security --restore --dry-run`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&outputDir, "output", "o", "", "Directory to write the security configuration to, or to restore it from (required)")
+	rootCmd.Flags().BoolVar(&includeReserved, "include-reserved", false, "Also dump the built-in users and roles")
+	rootCmd.Flags().BoolVar(&restore, "restore", false, "Create or replace the roles and role mappings of the directory on the cluster")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --restore, show what would be restored without changing anything")
+	rootCmd.MarkFlagRequired("output")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if dryRun && !restore {
+		return fmt.Errorf("--dry-run only applies to --restore")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if restore {
+		return runRestore(cfg, esClient)
+	}
+
+	// Read everything before writing, so a failure does not leave a partial dump
+	kinds, err := esClient.GetSecurityConfig(includeReserved)
+	if err != nil {
+		return fmt.Errorf("failed to get security configuration: %w", err)
+	}
+
+	// Role mappings and API key metadata name users and realms, so only the user can read the dump
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	header := []string{"Kind", "Objects", "Removed", "Directory"}
+	rows := make([][]string, 0, len(kinds))
+	for _, kind := range kinds {
+		removed, err := writeKind(kind)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{kind.Kind, fmt.Sprintf("%d", len(kind.Objects)), fmt.Sprintf("%d", removed), filepath.Join(outputDir, kind.Kind)})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// runRestore creates or replaces the roles and then the role mappings of the output directory,
+// continuing past failures and returning an error if any object could not be restored
+func runRestore(cfg *config.Config, esClient *client.Client) error {
+	roles, err := readKind(client.SecurityKindRoles)
+	if err != nil {
+		return err
+	}
+	mappings, err := readKind(client.SecurityKindRoleMappings)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 && len(mappings) == 0 {
+		return fmt.Errorf("no roles or role mappings found in %s", outputDir)
+	}
+
+	header := []string{"Kind", "Name", "Status"}
+	rows := make([][]string, 0, len(roles)+len(mappings))
+	failed := 0
+	for _, step := range []struct {
+		kind    string
+		objects map[string]interface{}
+		put     func(name string, definition interface{}) error
+	}{
+		{client.SecurityKindRoles, roles, esClient.PutRole},
+		{client.SecurityKindRoleMappings, mappings, esClient.PutRoleMapping},
+	} {
+		for _, name := range sortedNames(step.objects) {
+			status := "restored"
+			if dryRun {
+				status = "would be restored"
+			} else if err := step.put(name, step.objects[name]); err != nil {
+				status = err.Error()
+				failed++
+			}
+			rows = append(rows, []string{step.kind, name, status})
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d roles and %d role mappings would be restored\n", len(roles), len(mappings))
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Restored %d of %d roles and role mappings\n", len(roles)+len(mappings)-failed, len(roles)+len(mappings))
+	if failed > 0 {
+		return fmt.Errorf("failed to restore %d roles and role mappings", failed)
+	}
+	return nil
+}
+
+// writeKind writes each object of a kind to its own file and removes the files of objects that no
+// longer exist. It returns the number of files removed.
+func writeKind(kind client.ConfigObjects) (int, error) {
+	dir := filepath.Join(outputDir, kind.Kind)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	wanted := make(map[string]bool, len(kind.Objects))
+	for name := range kind.Objects {
+		wanted[objectFilename(name)] = true
+	}
+
+	for _, name := range sortedNames(kind.Objects) {
+		if err := writeJSON(filepath.Join(dir, objectFilename(name)), kind.Objects[name]); err != nil {
+			return 0, err
+		}
+	}
+
+	// Remove the files of deleted objects, so the dump mirrors the cluster
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	removed := 0
+	for _, path := range existing {
+		if wanted[filepath.Base(path)] {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// readKind reads the objects of a kind from the output directory, named after their files
+func readKind(kind string) (map[string]interface{}, error) {
+	paths, err := filepath.Glob(filepath.Join(outputDir, kind, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", filepath.Join(outputDir, kind), err)
+	}
+
+	objects := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var definition interface{}
+		if err := json.Unmarshal(data, &definition); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		objects[strings.TrimSuffix(filepath.Base(path), ".json")] = definition
+	}
+	return objects, nil
+}
+
+// sortedNames returns the names of the objects in sorted order
+func sortedNames(objects map[string]interface{}) []string {
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// objectFilename returns the file name of an object, replacing characters not allowed in paths
+func objectFilename(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name) + ".json"
+}
+
+// writeJSON writes a value as pretty-printed JSON, readable only by the user. Map keys are sorted,
+// so the same value always produces the same file.
+func writeJSON(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Feature options
+	showIndices bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_features",
+		Short: "List feature states and their system indices",
+		Long: `List the feature states of the cluster, the system indices belonging to each and their size.
+
+A feature state is the set of system indices and data streams a feature such as security,
+watcher or machine learning keeps its state in. Snapshots store and restore feature states as
+a unit, and which ones are included depends on the snapshot request:
+
+  include_global_state: true       every feature state is included (the default for snapshots)
+  include_global_state: false      no feature state is included
+  feature_states: ["a", "b"]       only the named feature states, whatever include_global_state says
+  feature_states: ["none"]         no feature state, even with include_global_state: true
+
+The size column shows how much each feature state adds to a snapshot of the global state.
+
+Example usage:
+  es_features
+  es_features --indices`,
+		Example: `es_features
+es_features --indices
+es_features --format=json`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Command specific flags
+	rootCmd.Flags().BoolVar(&showIndices, "indices", false, "List each system index on its own row instead of one row per feature")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	features, err := esClient.GetFeatureStates()
+	if err != nil {
+		return fmt.Errorf("failed to get feature states: %w", err)
+	}
+
+	var header []string
+	var rows [][]string
+	if showIndices {
+		header = []string{"Feature", "Index", "Docs", "Size", "Migration Status"}
+		for _, feature := range features {
+			if len(feature.Indices) == 0 {
+				rows = append(rows, []string{feature.Name, "-", "-", "-", feature.MigrationStatus})
+				continue
+			}
+			for _, index := range feature.Indices {
+				docs, size := "-", "-"
+				if index.Exists {
+					docs = fmt.Sprintf("%d", index.Docs)
+					size = client.ByteCountSI(index.SizeBytes)
+				}
+				rows = append(rows, []string{feature.Name, index.Name, docs, size, feature.MigrationStatus})
+			}
+		}
+	} else {
+		header = []string{"Feature", "Description", "System Indices", "Size", "Migration Status"}
+		for _, feature := range features {
+			names := make([]string, 0, len(feature.Indices))
+			for _, index := range feature.Indices {
+				names = append(names, index.Name)
+			}
+			indices := strings.Join(names, ", ")
+			if indices == "" {
+				indices = "-"
+			}
+			rows = append(rows, []string{feature.Name, feature.Description, indices, client.ByteCountSI(feature.SizeBytes()), feature.MigrationStatus})
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
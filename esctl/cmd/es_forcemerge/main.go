@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Force merge options
+	indexPattern   string
+	maxNumSegments int
+	force          bool
+	dryRun         bool
+	activityWindow time.Duration
+	pollInterval   time.Duration
+	mergeTimeout   time.Duration
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_forcemerge",
+		Short: "Force merge the segments of Elasticsearch indices",
+		Long: `Force merge the indices matching a pattern down to --max-num-segments segments per shard,
+and show their segment counts from _cat/segments before and after.
+
+Force merging an index that is still written to makes large segments that later merges cannot
+reclaim, so the merge is refused when a matching index is write-active: the write index of an
+alias or data stream, or an index documents were indexed into within --activity-window. Narrow
+the pattern to leave them out, or give --force to merge them anyway. Without write-active
+indices, the matching indices are listed and confirmation is asked for unless --force is given.
+
+The merge runs as a task on the cluster and the command polls it until it completes or --timeout
+passes. The merge carries on if the command stops first; run the command again with --dry-run
+to see the segment counts.`,
+		Example: `es_forcemerge --pattern='logs-2024.01.*' --max-num-segments=1 --dry-run
+es_forcemerge --pattern='logs-2024.01.*' --max-num-segments=1
+es_forcemerge --pattern='.ds-metrics-app-2024.01.*' --max-num-segments=1 --force --timeout=2h`,
+		PersistentPreRunE: initConfig,
+		RunE:              runForceMerge,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern selecting the indices (e.g., 'logs-2024.01.*') (required)")
+	rootCmd.Flags().IntVar(&maxNumSegments, "max-num-segments", 1, "Segments to merge each shard down to, 0 to let Elasticsearch decide")
+	rootCmd.Flags().BoolVar(&force, "force", false, "Merge write-active indices too, and skip confirmation")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the indices with their segments and write activity without merging them")
+	rootCmd.Flags().DurationVar(&activityWindow, "activity-window", 10*time.Second, "How long to watch the indices for indexing before merging, 0 to only check for write indices")
+	rootCmd.Flags().DurationVar(&pollInterval, "interval", 10*time.Second, "How often to check whether the merge has completed")
+	rootCmd.Flags().DurationVar(&mergeTimeout, "timeout", 0, "How long to wait for the merge to complete (default is no limit)")
+	rootCmd.MarkFlagRequired("pattern")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runForceMerge handles the force merge command
+func runForceMerge(cmd *cobra.Command, args []string) error {
+	if maxNumSegments < 0 {
+		return fmt.Errorf("--max-num-segments must not be negative")
+	}
+	if pollInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	before, err := esClient.GetIndexSegments(indexPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get segments: %w", err)
+	}
+	if len(before) == 0 {
+		fmt.Printf("No indices with segments match '%s'\n", indexPattern)
+		return nil
+	}
+
+	if activityWindow > 0 {
+		fmt.Fprintf(os.Stderr, "Watching %d indices for indexing for %s\n", len(before), activityWindow)
+	}
+	active, err := esClient.GetWriteActiveIndices(indexPattern, activityWindow)
+	if err != nil {
+		return fmt.Errorf("failed to check for write-active indices: %w", err)
+	}
+	var activeIndices []string
+	for _, segments := range before {
+		if _, ok := active[segments.Index]; ok {
+			activeIndices = append(activeIndices, segments.Index)
+		}
+	}
+
+	if dryRun {
+		return writeSegments(cfg, before, nil, active)
+	}
+
+	if len(activeIndices) > 0 && !force {
+		if err := writeSegments(cfg, before, nil, active); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d indices matching '%s' are write-active: %s; narrow the pattern or use --force to merge them anyway",
+			len(activeIndices), indexPattern, strings.Join(activeIndices, ", "))
+	}
+
+	// Confirm if not forced
+	if !force {
+		if err := writeSegments(cfg, before, nil, active); err != nil {
+			return err
+		}
+		fmt.Printf("Force merge these %d indices down to %s? [y/N] ", len(before), segmentTarget())
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	} else if len(activeIndices) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: merging %d write-active indices: %s\n", len(activeIndices), strings.Join(activeIndices, ", "))
+	}
+
+	taskID, err := esClient.ForceMerge(indexPattern, maxNumSegments)
+	if err != nil {
+		return fmt.Errorf("failed to start force merge: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Force merge started as task %s\n", taskID)
+
+	result, err := waitForMerge(esClient, taskID)
+	if err != nil {
+		return err
+	}
+
+	after, err := esClient.GetIndexSegments(indexPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get segments: %w", err)
+	}
+	if err := writeSegments(cfg, before, after, active); err != nil {
+		return err
+	}
+
+	switch {
+	case !result.Completed:
+		fmt.Fprintf(os.Stderr, "\nForce merge still running after %s, check task %s for progress\n", result.Running.Round(time.Second), taskID)
+	case result.Error != "":
+		return fmt.Errorf("force merge failed: %s", result.Error)
+	case result.Failed > 0:
+		return fmt.Errorf("force merge failed on %d shards", result.Failed)
+	default:
+		fmt.Fprintf(os.Stderr, "\nForce merge completed in %s\n", result.Running.Round(time.Second))
+	}
+	return nil
+}
+
+// waitForMerge polls the force merge task until it completes or --timeout passes
+func waitForMerge(esClient *client.Client, taskID string) (*client.ForceMergeResult, error) {
+	start := time.Now()
+	for {
+		result, err := esClient.GetForceMergeResult(taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get force merge task: %w", err)
+		}
+		if result.Completed || (mergeTimeout > 0 && time.Since(start) >= mergeTimeout) {
+			return result, nil
+		}
+		fmt.Fprintf(os.Stderr, "Force merge running for %s\n", result.Running.Round(time.Second))
+		time.Sleep(pollInterval)
+	}
+}
+
+// writeSegments lists the segments of each index, with the segments after the merge when after
+// is given
+func writeSegments(cfg *config.Config, before, after []client.IndexSegments, active map[string]string) error {
+	header := []string{"Index", "Shard Copies", "Segments", "Max Per Shard", "Size", "Write Active"}
+	if after != nil {
+		header = []string{"Index", "Shard Copies", "Segments Before", "Segments After", "Max Per Shard After", "Size Before", "Size After", "Write Active"}
+	}
+
+	merged := make(map[string]client.IndexSegments, len(after))
+	for _, segments := range after {
+		merged[segments.Index] = segments
+	}
+
+	rows := make([][]string, 0, len(before))
+	for _, segments := range before {
+		writeActive := "-"
+		if reason, ok := active[segments.Index]; ok {
+			writeActive = reason
+		}
+		if after == nil {
+			rows = append(rows, []string{
+				segments.Index,
+				fmt.Sprint(segments.ShardCopies),
+				fmt.Sprint(segments.Segments),
+				fmt.Sprint(segments.MaxPerShard),
+				client.ByteCountSI(segments.Size),
+				writeActive,
+			})
+			continue
+		}
+		merge := merged[segments.Index]
+		rows = append(rows, []string{
+			segments.Index,
+			fmt.Sprint(segments.ShardCopies),
+			fmt.Sprint(segments.Segments),
+			fmt.Sprint(merge.Segments),
+			fmt.Sprint(merge.MaxPerShard),
+			client.ByteCountSI(segments.Size),
+			client.ByteCountSI(merge.Size),
+			writeActive,
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// segmentTarget describes the segments the merge aims for
+func segmentTarget() string {
+	if maxNumSegments == 0 {
+		return "the segment count Elasticsearch chooses"
+	}
+	if maxNumSegments == 1 {
+		return "1 segment per shard"
+	}
+	return fmt.Sprintf("%d segments per shard", maxNumSegments)
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"sort"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
@@ -25,6 +27,9 @@ var (
 	insecure     bool
 	disableRetry bool
 
+	// Watch mode
+	watcher = format.NewWatcher()
+
 	// Output
 	outputFormat string
 )
@@ -52,18 +57,20 @@ or to verify heap settings across your cluster.
 Example usage:
   es_heap --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_heap --format=json
-  es_heap --style=blue`,
+  es_heap --style=blue
+es_heap --watch --interval=10s`,
 		Example:          `es_heap
 es_heap --format=json
 es_heap --style=blue`,
 		PersistentPreRunE: initConfig,
-		RunE:              run,
+		RunE:              watcher.Wrap(run),
 	}
 	// Disable the auto-generated completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -74,11 +81,25 @@ es_heap --style=blue`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Watch flags
+	watcher.AddFlags(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -103,7 +124,8 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Get node JVM stats
 	nodeStats, err := c.GetNodeJVMStats()
-	if err != nil {
+	var partial *client.PartialNodeError
+	if err != nil && !errors.As(err, &partial) {
 		return fmt.Errorf("error getting node JVM stats: %w", err)
 	}
 
@@ -131,5 +153,12 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Create formatter and output
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
-	return formatter.Write(header, rows)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	if partial != nil {
+		client.PrintNodeErrors(partial)
+	}
+	return nil
 }
@@ -3,9 +3,13 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +29,10 @@ var (
 
 	// Command specific
 	nodesToGetHotThreads []string
+	threadsOutputFormat  string
+	samples              int
+	sampleInterval       time.Duration
+	svgFile              string
 
 	// Output
 	outputFormat string
@@ -49,13 +57,20 @@ The output includes:
 You can target specific nodes or examine the entire cluster. This command is invaluable for
 performance troubleshooting, identifying bottlenecks, and resolving thread contention issues.
 
+With --output-format=folded the stacks are converted to the folded-stack format read by
+flamegraph.pl, speedscope and similar tools, one line per stack rooted at the node and thread
+pool and weighted by the number of snapshots that shared it. Use --samples to take several
+captures and add them together, and --svg to also render a flame graph directly.
+
 Example usage:
   es_hotthreads --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_hotthreads --nodes=node1,node2
-  es_hotthreads --format=json`,
+  es_hotthreads --output-format=folded --samples=10 --sample-interval=2s > stacks.folded
+  es_hotthreads --output-format=folded --samples=5 --svg=hotthreads.svg`,
 		Example:          `es_hotthreads
 es_hotthreads --nodes=node1,node2
-es_hotthreads --format=json`,
+es_hotthreads --output-format=folded --samples=10 > stacks.folded
+es_hotthreads --output-format=folded --svg=hotthreads.svg`,
 		PersistentPreRunE: initConfig,
 		RunE:              run,
 	}
@@ -64,6 +79,7 @@ es_hotthreads --format=json`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -75,13 +91,28 @@ es_hotthreads --format=json`,
 
 	// Command specific flags
 	rootCmd.Flags().StringArrayVarP(&nodesToGetHotThreads, "nodes", "n", []string{}, "Elasticsearch nodes to get hot threads for (optional, omitted will include all nodes)")
+	rootCmd.Flags().StringVar(&threadsOutputFormat, "output-format", "text", "Hot threads output format: text (as returned by Elasticsearch) or folded (folded stacks for flamegraph tools)")
+	rootCmd.Flags().IntVar(&samples, "samples", 1, "Number of hot threads captures to take and combine")
+	rootCmd.Flags().DurationVar(&sampleInterval, "sample-interval", time.Second, "Time to wait between captures when taking several samples")
+	rootCmd.Flags().StringVar(&svgFile, "svg", "", "Also render the folded stacks as an SVG flame graph to this file (requires --output-format=folded)")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -104,22 +135,85 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error creating client: %w", err)
 	}
 
-	var threads string
-	if len(nodesToGetHotThreads) == 0 {
-		// Get hot threads for all nodes
-		threads, err = c.GetHotThreads()
-		if err != nil {
-			return fmt.Errorf("error getting hot threads: %w", err)
+	switch threadsOutputFormat {
+	case "text", "folded":
+	default:
+		return fmt.Errorf("invalid output format '%s', must be text or folded", threadsOutputFormat)
+	}
+	if samples < 1 {
+		return fmt.Errorf("--samples must be at least 1")
+	}
+	if svgFile != "" && threadsOutputFormat != "folded" {
+		return fmt.Errorf("--svg requires --output-format=folded")
+	}
+
+	stacks := make(map[string]int)
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			time.Sleep(sampleInterval)
 		}
-	} else {
-		// Get hot threads for specific nodes
-		threads, err = c.GetNodesHotThreads(nodesToGetHotThreads)
+
+		threads, err := getHotThreads(c)
 		if err != nil {
-			return fmt.Errorf("error getting hot threads for nodes %v: %w", nodesToGetHotThreads, err)
+			return err
 		}
+
+		if threadsOutputFormat == "text" {
+			// Output the hot threads
+			fmt.Fprintln(cmd.OutOrStdout(), threads)
+			continue
+		}
+		client.FoldHotThreads(threads, stacks)
+		if samples > 1 {
+			fmt.Fprintf(os.Stderr, "Captured sample %d of %d\n", i+1, samples)
+		}
+	}
+
+	if threadsOutputFormat == "text" {
+		return nil
+	}
+
+	if len(stacks) == 0 {
+		fmt.Fprintln(os.Stderr, "No hot threads were captured")
+	}
+	if err := client.WriteFoldedStacks(cmd.OutOrStdout(), stacks); err != nil {
+		return fmt.Errorf("error writing folded stacks: %w", err)
 	}
 
-	// Output the hot threads
-	fmt.Fprintln(cmd.OutOrStdout(), threads)
+	if svgFile != "" {
+		f, err := os.Create(svgFile)
+		if err != nil {
+			return fmt.Errorf("error creating flame graph file: %w", err)
+		}
+		defer f.Close()
+
+		title := fmt.Sprintf("Hot threads (%d samples)", samples)
+		if len(nodesToGetHotThreads) > 0 {
+			title = fmt.Sprintf("Hot threads on %s (%d samples)", strings.Join(nodesToGetHotThreads, ", "), samples)
+		}
+		if err := format.WriteFlameGraph(f, stacks, title); err != nil {
+			return fmt.Errorf("error writing flame graph: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Flame graph written to %s\n", svgFile)
+	}
 	return nil
 }
+
+// getHotThreads captures hot threads for the selected nodes, or for all nodes if none were given
+func getHotThreads(c *client.Client) (string, error) {
+	if len(nodesToGetHotThreads) == 0 {
+		// Get hot threads for all nodes
+		threads, err := c.GetHotThreads()
+		if err != nil {
+			return "", fmt.Errorf("error getting hot threads: %w", err)
+		}
+		return threads, nil
+	}
+
+	// Get hot threads for specific nodes
+	threads, err := c.GetNodesHotThreads(nodesToGetHotThreads)
+	if err != nil {
+		return "", fmt.Errorf("error getting hot threads for nodes %v: %w", nodesToGetHotThreads, err)
+	}
+	return threads, nil
+}
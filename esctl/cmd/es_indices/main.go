@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -27,10 +30,26 @@ var (
 	disableRetry bool
 
 	// Index options
-	indexPattern string
-	indexName    string
-	settingsJSON string
-	force        bool
+	indexPattern  string
+	indexName     string
+	settingsJSON  string
+	force         bool
+	dryRun        bool
+	concurrency   int
+	blockType     string
+	allowMultiple bool
+	targetTier    string
+	waitForMove   bool
+	waitTimeout   time.Duration
+
+	// Drift options
+	includeDynamic bool
+	driftSummary   bool
+
+	// Throttle options
+	refreshInterval string
+	replicas        int
+	asyncTranslog   bool
 
 	// Output
 	outputFormat string
@@ -50,8 +69,15 @@ filter indices using patterns to focus on specific index groups.
 The command supports multiple operations through subcommands:
 - list: Display all indices matching a pattern (default action)
 - delete: Remove indices from the cluster
-- open/close: Control index state to optimize resource usage
+- open/close: Control index state to optimize resource usage, for one index or every index
+  matching a pattern
 - settings: View or update index configuration
+- block/unblock: Set or remove write, read-only and metadata blocks
+- blocks: List the indices that currently carry blocks
+- move-tier: Move the indices matching a pattern to another data tier
+- throttle/restore-defaults: Apply bulk-load settings before a large load and put the previous
+  settings back afterwards
+- drift: Compare indices with what their index templates would give them today
 
 Use this command for index maintenance, monitoring storage usage, or applying configuration
 changes across your indices.
@@ -59,10 +85,12 @@ changes across your indices.
 Example usage:
   es_indices --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_indices --index-pattern="logstash-*" --format=json
-  es_indices delete --index-name="old-index" --force`,
+  es_indices delete --index-name="old-index" --force
+  es_indices close --pattern="logs-2023.*" --dry-run`,
 		Example: `es_indices
 es_indices --index-pattern="logstash-*"
-es_indices delete --index-name="old-index" --force`,
+es_indices delete --index-name="old-index" --force
+es_indices close --pattern="logs-2023.*" --force`,
 		PersistentPreRunE: initConfig,
 		RunE:  listIndices, // Default action is to list indices
 	}
@@ -81,24 +109,38 @@ es_indices delete --index-name="old-index" --force`,
 	var deleteCmd = &cobra.Command{
 		Use:   "delete",
 		Short: "Delete an index",
-		Long:  `Delete an index from the Elasticsearch cluster. This operation is irreversible.`,
-		RunE:  deleteIndex,
+		Long: `Delete an index from the Elasticsearch cluster. This operation is irreversible.
+
+The name may be an alias, data stream or wildcard pattern, in which case the concrete indices it
+refers to are listed. Data streams are deleted as a whole, with their backing indices. A pattern
+only covers the indices and data streams whose names match it, not hidden indices or the
+indices of matching aliases. When the name refers to more than one index, confirmation is asked
+for even with --force, unless --allow-multiple is also given.`,
+		RunE: deleteIndex,
 	}
 
 	// Open subcommand
 	var openCmd = &cobra.Command{
 		Use:   "open",
-		Short: "Open a closed index",
-		Long:  `Open a closed index to make it available for search and indexing operations.`,
-		RunE:  openIndex,
+		Short: "Open closed indices",
+		Long: `Open a closed index, or every closed index matching a pattern, to make them available for
+search and indexing operations.
+
+With --pattern the matching indices are listed and confirmation is asked for before they are
+opened in parallel; the result for each index is reported.`,
+		RunE: openIndex,
 	}
 
 	// Close subcommand
 	var closeCmd = &cobra.Command{
 		Use:   "close",
-		Short: "Close an open index",
-		Long:  `Close an open index to reduce resource usage. Closed indices cannot be searched or indexed.`,
-		RunE:  closeIndex,
+		Short: "Close open indices",
+		Long: `Close an open index, or every open index matching a pattern, to reduce resource usage.
+Closed indices cannot be searched or indexed.
+
+With --pattern the matching indices are listed and confirmation is asked for before they are
+closed in parallel; the result for each index is reported.`,
+		RunE: closeIndex,
 	}
 
 	// Settings subcommand
@@ -109,8 +151,112 @@ es_indices delete --index-name="old-index" --force`,
 		RunE:  getIndexSettings,
 	}
 
+	// Block subcommand
+	var blockCmd = &cobra.Command{
+		Use:   "block",
+		Short: "Set a block on an index",
+		Long: `Set a block on an index. Block types are write, read, read_only, read_only_allow_delete
+and metadata.`,
+		Example: `es_indices block --name="logs-2024.01" --type=write`,
+		RunE:    blockIndex,
+	}
+
+	// Unblock subcommand
+	var unblockCmd = &cobra.Command{
+		Use:   "unblock",
+		Short: "Remove a block from an index",
+		Long: `Remove a block from an index, for example the read_only_allow_delete block left behind
+after the flood stage disk watermark was exceeded.`,
+		Example: `es_indices unblock --name="logs-2024.01" --type=read_only_allow_delete`,
+		RunE:    unblockIndex,
+	}
+
+	// Blocks subcommand
+	var blocksCmd = &cobra.Command{
+		Use:     "blocks",
+		Short:   "List indices with blocks",
+		Long:    `List the indices, including closed and hidden ones, that currently carry one or more blocks.`,
+		Example: `es_indices blocks --pattern="logs-*"`,
+		RunE:    listBlocks,
+	}
+
+	// Move tier subcommand
+	var moveTierCmd = &cobra.Command{
+		Use:   "move-tier",
+		Short: "Move indices to another data tier",
+		Long: `Move the indices matching a pattern to another data tier by setting their
+index.routing.allocation.include._tier_preference, the setting ILM changes when it migrates an
+index between phases.
+
+The tier preference falls back to warmer tiers the way ILM's does: warm is "data_warm,data_hot",
+cold is "data_cold,data_warm,data_hot". Indices already on the requested tier preference are
+left alone. Moving an index managed by ILM only lasts until its next phase migrates it again.
+
+The matching indices are listed and confirmation is asked for before any change. With --wait the
+command then polls the shards until every started copy is on a node of the target tier and
+reports the bytes moved by the relocations.`,
+		Example: `es_indices move-tier --pattern='logs-2024.01.*' --to=warm --dry-run
+es_indices move-tier --pattern='logs-2024.01.*' --to=warm --wait
+es_indices move-tier --pattern='metrics-2023.*' --to=cold --force`,
+		RunE: moveTier,
+	}
+
+	// Throttle subcommand
+	var throttleCmd = &cobra.Command{
+		Use:   "throttle",
+		Short: "Apply bulk-load settings to indices",
+		Long: `Speed up a bulk load into an index by applying the standard bulk-load settings: a longer
+refresh interval, no replicas and, with --async-translog, an asynchronous translog.
+
+The settings the index had are recorded in its mapping _meta first, so restore-defaults can put
+them back exactly, including resetting settings that were not set to their defaults. Throttling
+an index again keeps the values recorded the first time. The name may be a pattern, alias or
+data stream, each of its indices is throttled.
+
+Without replicas the data loaded since throttling is lost if a node holding it fails, so run
+restore-defaults as soon as the load is done.`,
+		Example: `es_indices throttle --name=logs-import --refresh-interval=30s --replicas=0
+es_indices throttle --name='logs-import-*' --refresh-interval=-1 --async-translog --dry-run
+es_indices restore-defaults --name=logs-import`,
+		RunE: throttleIndex,
+	}
+
+	// Restore defaults subcommand
+	var restoreDefaultsCmd = &cobra.Command{
+		Use:   "restore-defaults",
+		Short: "Restore the settings indices had before they were throttled",
+		Long: `Put back the refresh interval, replicas and translog durability recorded when the indices
+were throttled, and remove the record. Indices that were not throttled are left alone.`,
+		Example: `es_indices restore-defaults --name=logs-import
+es_indices restore-defaults --name='logs-import-*' --dry-run`,
+		RunE: restoreIndexDefaults,
+	}
+
+	// Drift subcommand
+	var driftCmd = &cobra.Command{
+		Use:   "drift",
+		Short: "Compare indices with their index templates",
+		Long: `Compare the settings and mappings of each index matching a pattern with what its index
+templates would give it if it were created today, to find drift introduced by manual changes or
+by template updates since the index was created. Backing indices of data streams are compared
+with the templates of their data stream.
+
+Settings Elasticsearch sets on each index, such as its uuid, creation date and tier preference,
+are not compared. Mapping fields only on the index are usually added by dynamic mapping and are
+left out unless --include-dynamic is given. In the Change column, added fields are only on the
+index and removed ones only in the template.
+
+With --summary each drifted field is listed once, with the number of indices it differs on.
+The exit code is 1 when any index has drifted.`,
+		Example: `es_indices drift --pattern='metrics-*'
+es_indices drift --pattern='metrics-*' --summary
+es_indices drift --pattern='logs-app-*' --include-dynamic --format=json`,
+		RunE: indexDrift,
+	}
+
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -121,8 +267,18 @@ es_indices delete --index-name="old-index" --force`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
 
 	// List command flags
 	rootCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern to filter indices (e.g., 'logs-*')")
@@ -131,27 +287,67 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	// Delete command flags
 	deleteCmd.Flags().StringVarP(&indexName, "name", "n", "", "Name of the index to delete (required)")
 	deleteCmd.Flags().BoolVarP(&force, "force", "", false, "Force deletion without confirmation")
+	deleteCmd.Flags().BoolVar(&allowMultiple, "allow-multiple", false, "With --force, also skip confirmation when the name resolves to more than one index")
 	deleteCmd.MarkFlagRequired("name")
 
-	// Open command flags
-	openCmd.Flags().StringVarP(&indexName, "name", "n", "", "Name of the index to open (required)")
-	openCmd.MarkFlagRequired("name")
-
-	// Close command flags
-	closeCmd.Flags().StringVarP(&indexName, "name", "n", "", "Name of the index to close (required)")
-	closeCmd.MarkFlagRequired("name")
+	// Open and close command flags
+	for _, c := range []*cobra.Command{openCmd, closeCmd} {
+		c.Flags().StringVarP(&indexName, "name", "n", "", "Name of the index")
+		c.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern selecting the indices (e.g., 'logs-2023.*')")
+		c.Flags().BoolVarP(&force, "force", "", false, "Skip confirmation when using --pattern or a --name that resolves to several indices")
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "List the indices matching --pattern without changing them")
+		c.Flags().IntVar(&concurrency, "concurrency", 4, "Number of indices changed in parallel when using --pattern")
+	}
 
 	// Settings command flags
 	settingsCmd.Flags().StringVarP(&indexName, "name", "n", "", "Name of the index to get/update settings for (required)")
 	settingsCmd.Flags().StringVarP(&settingsJSON, "settings", "s", "", "JSON string with settings to update (if not provided, current settings will be displayed)")
 	settingsCmd.MarkFlagRequired("name")
 
+	// Block and unblock command flags
+	for _, c := range []*cobra.Command{blockCmd, unblockCmd} {
+		c.Flags().StringVarP(&indexName, "name", "n", "", "Name of the index (required)")
+		c.Flags().StringVarP(&blockType, "type", "t", "", "Block type: "+strings.Join(client.IndexBlockTypes, ", ")+" (required)")
+		c.MarkFlagRequired("name")
+		c.MarkFlagRequired("type")
+	}
+
+	// Blocks command flags
+	blocksCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern to filter indices (e.g., 'logs-*')")
+
+	// Move tier command flags
+	moveTierCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern selecting the indices (e.g., 'logs-2024.01.*') (required)")
+	moveTierCmd.Flags().StringVar(&targetTier, "to", "", "Tier to move the indices to: "+strings.Join(client.DataTiers, ", ")+" (required)")
+	moveTierCmd.Flags().BoolVarP(&force, "force", "", false, "Skip confirmation")
+	moveTierCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the indices that would be moved without changing them")
+	moveTierCmd.Flags().BoolVar(&waitForMove, "wait", false, "Wait for the shards to relocate and report the bytes moved")
+	moveTierCmd.Flags().DurationVar(&waitTimeout, "timeout", time.Hour, "How long to wait for the shards to relocate with --wait")
+	moveTierCmd.MarkFlagRequired("pattern")
+	moveTierCmd.MarkFlagRequired("to")
+
+	// Throttle and restore defaults command flags
+	for _, c := range []*cobra.Command{throttleCmd, restoreDefaultsCmd} {
+		c.Flags().StringVarP(&indexName, "name", "n", "", "Name or pattern of the indices (required)")
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "Show the settings that would change without changing them")
+		c.MarkFlagRequired("name")
+	}
+	throttleCmd.Flags().StringVar(&refreshInterval, "refresh-interval", "30s", "Refresh interval during the load, -1 to disable refreshes")
+	throttleCmd.Flags().IntVar(&replicas, "replicas", 0, "Number of replicas during the load")
+	throttleCmd.Flags().BoolVar(&asyncTranslog, "async-translog", false, "Also fsync the translog in the background instead of on every request")
+
+	// Drift command flags
+	driftCmd.Flags().StringVarP(&indexPattern, "pattern", "p", "", "Index pattern selecting the indices (e.g., 'metrics-*') (required)")
+	driftCmd.Flags().BoolVar(&includeDynamic, "include-dynamic", false, "Also report mapping fields only on the index, usually added by dynamic mapping")
+	driftCmd.Flags().BoolVar(&driftSummary, "summary", false, "List each drifted field once with the number of indices it differs on")
+	driftCmd.MarkFlagRequired("pattern")
+
 	// Add subcommands
-	rootCmd.AddCommand(listCmd, deleteCmd, openCmd, closeCmd, settingsCmd)
+	rootCmd.AddCommand(listCmd, deleteCmd, openCmd, closeCmd, settingsCmd, blockCmd, unblockCmd, blocksCmd, moveTierCmd, throttleCmd, restoreDefaultsCmd, driftCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -224,9 +420,25 @@ func deleteIndex(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
-	// Confirm deletion if not forced
-	if !force {
-		fmt.Printf("Are you sure you want to delete index '%s'? This operation cannot be undone. [y/N] ", indexName)
+	// Aliases are deleted through their concrete indices, data streams as a whole
+	resolved, err := resolveIndex(esClient, indexName)
+	if err != nil {
+		return err
+	}
+	multiple := len(resolved.Indices) > 1
+	standalone := resolved.StandaloneIndices()
+
+	// Confirm deletion if not forced, or if the name expands to several indices
+	if !force || (multiple && !allowMultiple) {
+		switch {
+		case len(resolved.DataStreams) > 0:
+			fmt.Printf("Are you sure you want to delete all %d indices that '%s' refers to, including data streams %s? This operation cannot be undone. [y/N] ",
+				len(resolved.Indices), indexName, strings.Join(resolved.DataStreams, ", "))
+		case multiple:
+			fmt.Printf("Are you sure you want to delete all %d indices that '%s' refers to? This operation cannot be undone. [y/N] ", len(resolved.Indices), indexName)
+		default:
+			fmt.Printf("Are you sure you want to delete index '%s'? This operation cannot be undone. [y/N] ", resolved.Indices[0])
+		}
 		var confirm string
 		fmt.Scanln(&confirm)
 		if strings.ToLower(confirm) != "y" {
@@ -235,17 +447,50 @@ func deleteIndex(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Delete data streams, whose write index cannot be deleted on its own
+	for _, dataStream := range resolved.DataStreams {
+		if err := esClient.DeleteDataStream(dataStream); err != nil {
+			return fmt.Errorf("failed to delete data stream %s: %w", dataStream, err)
+		}
+		fmt.Printf("Data stream '%s' deleted\n", dataStream)
+	}
+
 	// Delete index
-	if err := esClient.DeleteIndex(indexName); err != nil {
-		return fmt.Errorf("failed to delete index: %w", err)
+	if len(standalone) > 0 {
+		if err := esClient.DeleteIndex(strings.Join(standalone, ",")); err != nil {
+			return fmt.Errorf("failed to delete index: %w", err)
+		}
 	}
 
-	fmt.Printf("Index '%s' deleted successfully\n", indexName)
+	if multiple {
+		fmt.Printf("%d indices deleted successfully\n", len(resolved.Indices))
+	} else {
+		fmt.Printf("Index '%s' deleted successfully\n", resolved.Indices[0])
+	}
 	return nil
 }
 
 // openIndex handles the open index command
 func openIndex(cmd *cobra.Command, args []string) error {
+	return changeIndexState(cmd, true)
+}
+
+// closeIndex handles the close index command
+func closeIndex(cmd *cobra.Command, args []string) error {
+	return changeIndexState(cmd, false)
+}
+
+// changeIndexState opens or closes the index given by --name or the indices matching --pattern
+func changeIndexState(cmd *cobra.Command, open bool) error {
+	if (indexName == "") == (indexPattern == "") {
+		return fmt.Errorf("exactly one of --name or --pattern is required")
+	}
+
+	action, done := "close", "closed"
+	if open {
+		action, done = "open", "opened"
+	}
+
 	// Load configuration with context containing viper instance
 	cfg, err := config.Load(cmd.Context())
 	if err != nil {
@@ -258,17 +503,111 @@ func openIndex(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
-	// Open index
-	if err := esClient.OpenIndex(indexName); err != nil {
-		return fmt.Errorf("failed to open index: %w", err)
+	// Single index, alias or data stream
+	if indexName != "" {
+		resolved, err := resolveIndex(esClient, indexName)
+		if err != nil {
+			return err
+		}
+
+		// Closing every index behind an alias is rarely intended
+		if !open && len(resolved.Indices) > 1 && !force {
+			fmt.Printf("Are you sure you want to close all %d indices that '%s' refers to? [y/N] ", len(resolved.Indices), indexName)
+			var confirm string
+			fmt.Scanln(&confirm)
+			if strings.ToLower(confirm) != "y" {
+				fmt.Println("Operation cancelled")
+				return nil
+			}
+		}
+
+		if open {
+			err = esClient.OpenIndex(resolved.Target())
+		} else {
+			err = esClient.CloseIndex(resolved.Target())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s index: %w", action, err)
+		}
+
+		fmt.Printf("Index '%s' %s successfully\n", indexName, done)
+		return nil
+	}
+
+	// Preview the matching indices
+	indices, err := esClient.GetIndicesToChangeState(indexPattern, open)
+	if err != nil {
+		return fmt.Errorf("failed to get indices: %w", err)
+	}
+	if len(indices) == 0 {
+		fmt.Printf("No indices matching '%s' need to be %s\n", indexPattern, done)
+		return nil
+	}
+
+	fmt.Printf("The following %d indices will be %s:\n", len(indices), done)
+	for _, index := range indices {
+		fmt.Printf("  %s\n", index)
+	}
+
+	if dryRun {
+		fmt.Println("\nDry run: no indices were changed")
+		return nil
+	}
+
+	// Confirm if not forced
+	if !force {
+		fmt.Printf("\nAre you sure you want to %s %d indices? [y/N] ", action, len(indices))
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	// Change the indices in parallel and report each result
+	results := esClient.SetIndicesState(indices, open, concurrency)
+
+	header := []string{"Index", "Result", "Error"}
+	rows := make([][]string, 0, len(results))
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			rows = append(rows, []string{r.Index, "failed", r.Err.Error()})
+			continue
+		}
+		rows = append(rows, []string{r.Index, done, ""})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
 	}
 
-	fmt.Printf("Index '%s' opened successfully\n", indexName)
+	if failed > 0 {
+		return fmt.Errorf("failed to %s %d of %d indices", action, failed, len(results))
+	}
+	fmt.Printf("\n%d indices %s successfully\n", len(results), done)
 	return nil
 }
 
-// closeIndex handles the close index command
-func closeIndex(cmd *cobra.Command, args []string) error {
+// blockIndex handles the block command
+func blockIndex(cmd *cobra.Command, args []string) error {
+	return setIndexBlock(cmd, true)
+}
+
+// unblockIndex handles the unblock command
+func unblockIndex(cmd *cobra.Command, args []string) error {
+	return setIndexBlock(cmd, false)
+}
+
+// setIndexBlock sets or removes the block given by --type on the index given by --name
+func setIndexBlock(cmd *cobra.Command, enabled bool) error {
+	if err := client.ValidateBlockType(blockType); err != nil {
+		return err
+	}
+
 	// Load configuration with context containing viper instance
 	cfg, err := config.Load(cmd.Context())
 	if err != nil {
@@ -281,15 +620,59 @@ func closeIndex(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
-	// Close index
-	if err := esClient.CloseIndex(indexName); err != nil {
-		return fmt.Errorf("failed to close index: %w", err)
+	if _, err := resolveIndex(esClient, indexName); err != nil {
+		return err
 	}
 
-	fmt.Printf("Index '%s' closed successfully\n", indexName)
+	if err := esClient.SetIndexBlock(indexName, blockType, enabled); err != nil {
+		if enabled {
+			return fmt.Errorf("failed to set %s block: %w", blockType, err)
+		}
+		return fmt.Errorf("failed to remove %s block: %w", blockType, err)
+	}
+
+	if enabled {
+		fmt.Printf("Block '%s' set on index '%s'\n", blockType, indexName)
+	} else {
+		fmt.Printf("Block '%s' removed from index '%s'\n", blockType, indexName)
+	}
 	return nil
 }
 
+// listBlocks handles the blocks command
+func listBlocks(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	blocked, err := esClient.GetIndexBlocks(indexPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get index blocks: %w", err)
+	}
+
+	if len(blocked) == 0 {
+		fmt.Println("No indices have blocks")
+		return nil
+	}
+
+	header := []string{"Index", "Blocks"}
+	rows := make([][]string, 0, len(blocked))
+	for _, b := range blocked {
+		rows = append(rows, []string{b.Index, strings.Join(b.Blocks, ", ")})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
 // getIndexSettings handles the get/update index settings command
 func getIndexSettings(cmd *cobra.Command, args []string) error {
 	// Load configuration with context containing viper instance
@@ -304,6 +687,11 @@ func getIndexSettings(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
+	// Report which indices an alias or data stream covers
+	if _, err := resolveIndex(esClient, indexName); err != nil {
+		return err
+	}
+
 	// If settings are provided, update them
 	if settingsJSON != "" {
 		// Parse settings JSON
@@ -336,3 +724,323 @@ func getIndexSettings(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Settings for index '%s':\n%s\n", indexName, string(settingsJSON))
 	return nil
 }
+
+// moveTier handles the move-tier command
+func moveTier(cmd *cobra.Command, args []string) error {
+	if _, err := client.TierPreference(targetTier); err != nil {
+		return err
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Preview the matching indices
+	indices, err := esClient.GetTierMoveIndices(indexPattern, targetTier)
+	if err != nil {
+		return fmt.Errorf("failed to get indices: %w", err)
+	}
+	if len(indices) == 0 {
+		fmt.Printf("No indices matching '%s' need to be moved to the %s tier\n", indexPattern, targetTier)
+		return nil
+	}
+
+	header := []string{"Index", "Current Tier Preference", "Size"}
+	rows := make([][]string, 0, len(indices))
+	names := make([]string, 0, len(indices))
+	var totalBytes int64
+	for _, index := range indices {
+		current := index.CurrentPreference
+		if current == "" {
+			current = "-"
+		}
+		rows = append(rows, []string{index.Name, current, client.ByteCountSI(index.StoreBytes)})
+		names = append(names, index.Name)
+		totalBytes += index.StoreBytes
+	}
+
+	fmt.Printf("The following %d indices (%s) will be moved to the %s tier:\n", len(indices), client.ByteCountSI(totalBytes), targetTier)
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if dryRun {
+		fmt.Println("\nDry run: no indices were changed")
+		return nil
+	}
+
+	// Confirm if not forced
+	if !force {
+		fmt.Printf("\nAre you sure you want to move %d indices to the %s tier? [y/N] ", len(indices), targetTier)
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	started := time.Now()
+	if err := esClient.SetTierPreference(names, targetTier); err != nil {
+		return fmt.Errorf("failed to move indices: %w", err)
+	}
+	fmt.Printf("\nTier preference of %d indices set for the %s tier\n", len(indices), targetTier)
+
+	if !waitForMove {
+		return nil
+	}
+
+	// Poll until the shards have relocated
+	deadline := started.Add(waitTimeout)
+	for {
+		progress, err := esClient.GetTierMoveProgress(names, targetTier, started)
+		if err != nil {
+			return fmt.Errorf("failed to check relocation: %w", err)
+		}
+		if progress.Done() {
+			fmt.Printf("All %d started shard copies are on the %s tier, %s moved in %s\n", progress.Shards, targetTier, client.ByteCountSI(progress.MovedBytes), time.Since(started).Round(time.Second))
+			if progress.Unassigned > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: %d shard copies are unassigned\n", progress.Unassigned)
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d of %d shard copies on the %s tier after %s, %s moved", progress.OnTier, progress.Shards, targetTier, waitTimeout, client.ByteCountSI(progress.MovedBytes))
+		}
+		fmt.Fprintf(os.Stderr, "%d of %d shard copies on the %s tier, %d moving, %s moved\n", progress.OnTier, progress.Shards, targetTier, progress.Moving, client.ByteCountSI(progress.MovedBytes))
+		time.Sleep(10 * time.Second)
+	}
+}
+
+// throttleIndex handles the throttle command
+func throttleIndex(cmd *cobra.Command, args []string) error {
+	if replicas < 0 {
+		return fmt.Errorf("--replicas must not be negative")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	settings := map[string]interface{}{
+		"index.refresh_interval":   refreshInterval,
+		"index.number_of_replicas": replicas,
+	}
+	if asyncTranslog {
+		settings["index.translog.durability"] = "async"
+	}
+
+	changes, err := esClient.ThrottleIndices(indexName, settings, dryRun)
+	// Report the indices changed before any failure
+	if writeErr := writeThrottleChanges(cfg, changes); writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to throttle indices: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run: %d indices would be throttled\n", len(changes))
+		return nil
+	}
+	fmt.Printf("\n%d indices throttled, run restore-defaults when the load is done\n", len(changes))
+	if replicas == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: without replicas, data loaded into these indices is lost if a node holding it fails")
+	}
+	return nil
+}
+
+// restoreIndexDefaults handles the restore-defaults command
+func restoreIndexDefaults(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	changes, err := esClient.RestoreThrottledIndices(indexName, dryRun)
+	// Report the indices changed before any failure
+	if writeErr := writeThrottleChanges(cfg, changes); writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore index settings: %w", err)
+	}
+
+	restored := 0
+	for _, change := range changes {
+		if change.Skipped == "" {
+			restored++
+		}
+	}
+	if dryRun {
+		fmt.Printf("\nDry run: settings of %d indices would be restored\n", restored)
+		return nil
+	}
+	fmt.Printf("\nSettings of %d indices restored\n", restored)
+	return nil
+}
+
+// writeThrottleChanges lists the settings changed on each index, unset settings shown as default
+func writeThrottleChanges(cfg *config.Config, changes []client.IndexThrottle) error {
+	header := []string{"Index", "Setting", "Current", "New"}
+	var rows [][]string
+	for _, change := range changes {
+		if change.Skipped != "" {
+			rows = append(rows, []string{change.Index, "-", "-", change.Skipped})
+			continue
+		}
+		settings := make([]string, 0, len(change.Settings))
+		for setting := range change.Settings {
+			settings = append(settings, setting)
+		}
+		sort.Strings(settings)
+		for _, setting := range settings {
+			rows = append(rows, []string{change.Index, setting, settingValue(change.Previous[setting]), settingValue(change.Settings[setting])})
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// indexDrift handles the drift command
+func indexDrift(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	drifts, err := esClient.GetIndexDrift(indexPattern, includeDynamic)
+	if err != nil {
+		return fmt.Errorf("failed to compare indices with their templates: %w", err)
+	}
+	if len(drifts) == 0 {
+		fmt.Printf("No indices match '%s'\n", indexPattern)
+		return nil
+	}
+
+	var drifted, untemplated []string
+	for _, drift := range drifts {
+		switch {
+		case drift.Template == "":
+			untemplated = append(untemplated, drift.Index)
+		case len(drift.Changes) > 0:
+			drifted = append(drifted, drift.Index)
+		}
+	}
+	if len(untemplated) > 0 {
+		fmt.Fprintf(os.Stderr, "No index template matches %d indices: %s\n", len(untemplated), strings.Join(untemplated, ", "))
+	}
+	if len(drifted) == 0 {
+		fmt.Printf("%d indices match their templates\n", len(drifts)-len(untemplated))
+		return nil
+	}
+
+	var header []string
+	var rows [][]string
+	if driftSummary {
+		header = []string{"Field", "Change", "Indices", "First Index"}
+		type fieldDrift struct {
+			field, change, first string
+			indices              int
+		}
+		byField := make(map[string]*fieldDrift)
+		var order []string
+		for _, drift := range drifts {
+			for _, change := range drift.Changes {
+				key := change.Field + "\x00" + change.Change
+				if entry, ok := byField[key]; ok {
+					entry.indices++
+					continue
+				}
+				byField[key] = &fieldDrift{field: change.Field, change: change.Change, first: drift.Index, indices: 1}
+				order = append(order, key)
+			}
+		}
+		sort.Strings(order)
+		for _, key := range order {
+			entry := byField[key]
+			rows = append(rows, []string{entry.field, entry.change, fmt.Sprint(entry.indices), entry.first})
+		}
+	} else {
+		header = []string{"Index", "Template", "Field", "Change", "Template Value", "Index Value"}
+		for _, drift := range drifts {
+			for _, change := range drift.Changes {
+				rows = append(rows, []string{drift.Index, drift.Template, change.Field, change.Change, valueOrDash(change.Before), valueOrDash(change.After)})
+			}
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d of %d indices differ from their templates\n", len(drifted), len(drifts)-len(untemplated))
+	os.Exit(1)
+	return nil
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// settingValue renders a setting value, or "default" when it is not set
+func settingValue(value interface{}) string {
+	if value == nil {
+		return "default"
+	}
+	return fmt.Sprint(value)
+}
+
+// resolveIndex resolves an index name, alias or data stream to its concrete indices, and reports
+// on stderr which indices were resolved when the name is not a single index
+func resolveIndex(esClient *client.Client, name string) (*client.ResolvedIndex, error) {
+	resolved, err := esClient.ResolveIndexName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", name, err)
+	}
+
+	if resolved.Indirect() {
+		fmt.Fprintf(os.Stderr, "Resolved %s '%s' to %d indices: %s\n", resolved.Kind, name, len(resolved.Indices), strings.Join(resolved.Indices, ", "))
+	}
+	return resolved, nil
+}
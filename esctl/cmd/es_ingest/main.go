@@ -0,0 +1,523 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Usage options
+	unusedOnly bool
+
+	// GeoIP options
+	enableDownloader  bool
+	disableDownloader bool
+
+	// Test options
+	pipelineName string
+	sampleIndex  string
+	sampleSize   int
+	randomSample bool
+	perDocument  bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_ingest",
+		Short: "Inspect Elasticsearch ingest pipelines",
+		Long: `Inspect Elasticsearch ingest pipelines and how they are used.
+
+The usage subcommand cross-references every ingest pipeline against the index settings
+(index.default_pipeline and index.final_pipeline), index and component templates (which
+also covers data streams) and pipeline processors in other pipelines. It lists which
+pipelines are unused and which indices or templates reference pipelines that do not exist.
+
+The geoip-stats subcommand shows the GeoIP database downloader statistics, the age of each
+database and which nodes have loaded it. Databases not updated for 30 days expire and geoip
+processors stop enriching documents. geoip-downloader turns the downloader on or off.
+
+The test subcommand runs documents sampled from an index through a pipeline with the simulate
+API and reports the fields the pipeline adds, removes and changes, and the documents it fails on.
+
+Example usage:
+  es_ingest usage
+  es_ingest usage --unused
+  es_ingest usage --format=json
+  es_ingest geoip-stats
+  es_ingest geoip-downloader --enable
+  es_ingest test --pipeline logs-parse --sample-from-index raw-logs --size 50`,
+		Example: `es_ingest usage
+es_ingest usage --unused
+es_ingest geoip-stats
+es_ingest test --pipeline logs-parse --sample-from-index raw-logs --size 50`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Usage subcommand
+	var usageCmd = &cobra.Command{
+		Use:   "usage",
+		Short: "Report which pipelines are used, unused or missing",
+		Long:  `Cross-reference ingest pipelines against indices, templates and other pipelines.`,
+		RunE:  runUsage,
+	}
+
+	// GeoIP stats subcommand
+	var geoipStatsCmd = &cobra.Command{
+		Use:   "geoip-stats",
+		Short: "Show GeoIP downloader status and database ages",
+		Long: `Show the GeoIP database downloader statistics, when each database was last updated and
+checked, and which databases each node has loaded. Databases older than 30 days are reported
+as expired.`,
+		RunE: runGeoIPStats,
+	}
+
+	// GeoIP downloader subcommand
+	var geoipDownloaderCmd = &cobra.Command{
+		Use:   "geoip-downloader",
+		Short: "Turn the GeoIP database downloader on or off",
+		Long: `Set ingest.geoip.downloader.enabled as a persistent cluster setting. Disabling the
+downloader removes the downloaded databases, so geoip processors fall back to no enrichment.`,
+		Example: `es_ingest geoip-downloader --enable
+es_ingest geoip-downloader --disable`,
+		RunE: runGeoIPDownloader,
+	}
+
+	// Test subcommand
+	var testCmd = &cobra.Command{
+		Use:   "test",
+		Short: "Run sampled documents through a pipeline and report the differences",
+		Long: `Sample real documents from an index, run them through an ingest pipeline with the simulate
+API and report what the pipeline did to them. Nothing is indexed.
+
+By default the changes are summarised per field: how many documents the pipeline added, removed
+or changed the field in, with an example of the value before and after. --per-document lists
+every change of every document instead. Documents the pipeline failed on are listed with the
+error, and the command exits with status 1 when there are any, so it can check a pipeline
+change in a script.
+
+Without --random the first documents in index order are sampled, which is the cheapest search
+but may not cover the variety of the data.`,
+		Example: `es_ingest test --pipeline logs-parse --sample-from-index raw-logs
+es_ingest test --pipeline logs-parse --sample-from-index raw-logs --size 200 --random
+es_ingest test --pipeline logs-parse --sample-from-index raw-logs --per-document --format=json`,
+		RunE: runTest,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Usage command flags
+	usageCmd.Flags().BoolVar(&unusedOnly, "unused", false, "Only list pipelines that nothing references")
+
+	// GeoIP downloader command flags
+	geoipDownloaderCmd.Flags().BoolVar(&enableDownloader, "enable", false, "Turn the downloader on")
+	geoipDownloaderCmd.Flags().BoolVar(&disableDownloader, "disable", false, "Turn the downloader off")
+	geoipDownloaderCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+	geoipDownloaderCmd.MarkFlagsOneRequired("enable", "disable")
+
+	// Test command flags
+	testCmd.Flags().StringVar(&pipelineName, "pipeline", "", "Ingest pipeline to test (required)")
+	testCmd.Flags().StringVar(&sampleIndex, "sample-from-index", "", "Index, data stream or pattern to sample documents from (required)")
+	testCmd.Flags().IntVar(&sampleSize, "size", 50, "Number of documents to sample")
+	testCmd.Flags().BoolVar(&randomSample, "random", false, "Sample random documents instead of the first ones")
+	testCmd.Flags().BoolVar(&perDocument, "per-document", false, "List the changes of each document instead of a summary per field")
+	testCmd.MarkFlagRequired("pipeline")
+	testCmd.MarkFlagRequired("sample-from-index")
+
+	// Add subcommands
+	rootCmd.AddCommand(usageCmd, geoipStatsCmd, geoipDownloaderCmd, testCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runUsage handles the usage command
+func runUsage(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Build the usage report
+	report, err := esClient.GetIngestUsage()
+	if err != nil {
+		return fmt.Errorf("failed to get ingest pipeline usage: %w", err)
+	}
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Prepare pipeline table data
+	header := []string{"Pipeline", "Status", "Indices", "Templates", "Pipelines"}
+	rows := [][]string{}
+
+	for _, pipeline := range report.Pipelines {
+		status := "used"
+		if len(pipeline.References) == 0 {
+			status = "unused"
+		} else if unusedOnly {
+			continue
+		}
+
+		var indices, templates, pipelines []string
+		for _, ref := range pipeline.References {
+			switch ref.SourceType {
+			case "index":
+				indices = append(indices, ref.Source)
+			case "pipeline":
+				pipelines = append(pipelines, ref.Source)
+			default:
+				templates = append(templates, ref.Source)
+			}
+		}
+
+		rows = append(rows, []string{
+			pipeline.Name,
+			status,
+			summarise(indices),
+			summarise(templates),
+			summarise(pipelines),
+		})
+	}
+
+	fmt.Printf("\nPipelines:\n")
+	if len(rows) == 0 {
+		fmt.Println("No pipelines found")
+	} else if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	// Print references to missing pipelines if any
+	if len(report.Missing) > 0 {
+		fmt.Printf("\nMissing Pipelines:\n")
+
+		header := []string{"Pipeline", "Referenced By", "Name", "Setting"}
+		rows := [][]string{}
+		for _, ref := range report.Missing {
+			rows = append(rows, []string{ref.Pipeline, ref.SourceType, ref.Source, ref.Setting})
+		}
+
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runGeoIPStats handles the geoip-stats command
+func runGeoIPStats(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	status, err := esClient.GetGeoIPStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get GeoIP status: %w", err)
+	}
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Downloader summary
+	enabled := status.Enabled
+	if enabled == "" {
+		enabled = "unknown"
+	}
+	fmt.Printf("\nDownloader:\n")
+	rows := [][]string{
+		{"Enabled", enabled},
+		{"Successful downloads", fmt.Sprintf("%d", status.SuccessfulDownloads)},
+		{"Failed downloads", fmt.Sprintf("%d", status.FailedDownloads)},
+		{"Skipped updates", fmt.Sprintf("%d", status.SkippedUpdates)},
+		{"Total download time", status.TotalDownloadTime.String()},
+		{"Databases", fmt.Sprintf("%d", status.DatabasesCount)},
+		{"Expired databases", fmt.Sprintf("%d", status.ExpiredDatabases)},
+	}
+	if err := formatter.Write([]string{"Property", "Value"}, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	// Databases and their age
+	fmt.Printf("\nDatabases:\n")
+	if len(status.Databases) == 0 {
+		fmt.Println("No GeoIP databases downloaded")
+	} else {
+		rows = [][]string{}
+		for _, db := range status.Databases {
+			lastUpdate, age, lastCheck, state := "-", "-", "-", "loaded"
+			if !db.LastUpdate.IsZero() {
+				lastUpdate = db.LastUpdate.Format(time.RFC3339)
+				age = formatAge(db.Age())
+			}
+			if !db.LastCheck.IsZero() {
+				lastCheck = db.LastCheck.Format(time.RFC3339)
+			}
+			if db.Expired() {
+				state = "EXPIRED"
+			} else if db.Nodes == 0 {
+				state = "not loaded"
+			}
+			rows = append(rows, []string{db.Name, lastUpdate, age, lastCheck, fmt.Sprintf("%d/%d", db.Nodes, len(status.Nodes)), state})
+		}
+		if err := formatter.Write([]string{"Database", "Last Update", "Age", "Last Check", "Nodes", "Status"}, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	}
+
+	// Databases on each node
+	fmt.Printf("\nNodes:\n")
+	rows = [][]string{}
+	for _, node := range status.Nodes {
+		rows = append(rows, []string{node.Name, summarise(node.Databases), summarise(node.FilesInTemp)})
+	}
+	if err := formatter.Write([]string{"Node", "Databases", "Files In Temp"}, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if status.ExpiredDatabases > 0 {
+		fmt.Fprintf(os.Stderr, "\nWarning: %d GeoIP databases have expired and are no longer used for enrichment\n", status.ExpiredDatabases)
+	}
+	return nil
+}
+
+// runGeoIPDownloader handles the geoip-downloader command
+func runGeoIPDownloader(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	if err := esClient.SetGeoIPDownloader(enableDownloader); err != nil {
+		return fmt.Errorf("failed to update %s: %w", client.GeoIPDownloaderSetting, err)
+	}
+
+	if enableDownloader {
+		fmt.Println("GeoIP database downloader enabled")
+	} else {
+		fmt.Println("GeoIP database downloader disabled")
+	}
+	return nil
+}
+
+// runTest handles the test command
+func runTest(cmd *cobra.Command, args []string) error {
+	if sampleSize <= 0 {
+		return fmt.Errorf("--size must be greater than 0")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	docs, err := esClient.SampleDocuments(sampleIndex, sampleSize, randomSample, nil)
+	if err != nil {
+		return fmt.Errorf("failed to sample documents from %s: %w", sampleIndex, err)
+	}
+	if len(docs) == 0 {
+		fmt.Printf("No documents found in %s\n", sampleIndex)
+		return nil
+	}
+
+	results, err := esClient.SimulatePipeline(pipelineName, docs)
+	if err != nil {
+		return fmt.Errorf("failed to simulate pipeline %s: %w", pipelineName, err)
+	}
+
+	// Create formatter
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	var failed [][]string
+	var dropped, changed int
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			failed = append(failed, []string{result.Index, result.ID, result.Error})
+		case result.Dropped:
+			dropped++
+		case len(result.Changes) > 0:
+			changed++
+		}
+	}
+
+	var header []string
+	var rows [][]string
+	if perDocument {
+		header = []string{"Index", "ID", "Field", "Change", "Before", "After"}
+		for _, result := range results {
+			for _, change := range result.Changes {
+				rows = append(rows, []string{result.Index, result.ID, change.Field, change.Change, change.Before, change.After})
+			}
+		}
+	} else {
+		header = []string{"Field", "Change", "Documents", "Example Before", "Example After"}
+		rows = summariseFieldChanges(results)
+	}
+
+	fmt.Printf("\nField Changes:\n")
+	if len(rows) == 0 {
+		fmt.Println("The pipeline changed no fields")
+	} else if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if len(failed) > 0 {
+		fmt.Printf("\nFailed Documents:\n")
+		if err := formatter.Write([]string{"Index", "ID", "Error"}, failed); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	}
+
+	fmt.Printf("\n%d documents sampled from %s: %d changed, %d unchanged, %d dropped, %d failed\n",
+		len(results), sampleIndex, changed, len(results)-changed-dropped-len(failed), dropped, len(failed))
+
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// summariseFieldChanges counts the documents each field was added, removed or changed in, with
+// the first document's values as an example, in field order
+func summariseFieldChanges(results []client.SimulatedDocument) [][]string {
+	type fieldChange struct {
+		field, change string
+	}
+	counts := make(map[fieldChange]int)
+	examples := make(map[fieldChange]client.FieldChange)
+	var order []fieldChange
+
+	for _, result := range results {
+		for _, change := range result.Changes {
+			key := fieldChange{change.Field, change.Change}
+			if counts[key] == 0 {
+				examples[key] = change
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].field != order[j].field {
+			return order[i].field < order[j].field
+		}
+		return order[i].change < order[j].change
+	})
+
+	rows := make([][]string, 0, len(order))
+	for _, key := range order {
+		example := examples[key]
+		rows = append(rows, []string{key.field, key.change, fmt.Sprintf("%d", counts[key]), example.Before, example.After})
+	}
+	return rows
+}
+
+// formatAge formats a duration in days, or hours when under a day
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%.0fh", d.Hours())
+	}
+	return fmt.Sprintf("%.0fd", d.Hours()/24)
+}
+
+// summarise joins up to three names and counts the rest
+func summarise(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	if len(names) <= 3 {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(names[:3], ", "), len(names)-3)
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// List options
+	threshold    string
+	indexPattern string
+	maxResults   int
+
+	// User options
+	showUser      bool
+	hideSensitive bool
+
+	// History and analyze options
+	historyLast       string
+	historyMaxResults int
+	analyzeLast       string
+	analyzeMaxResults int
+	sampleSize        int
+	fromTime          string
+	toTime            string
+	slowlogIndex      string
+
+	// Kill options
+	queryID string
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_long_queries",
+		Short: "Manage long-running Elasticsearch queries",
+		Long: `Manage long-running Elasticsearch queries.
+
+Running searches are read from the Tasks API. A search runs as one task on the node coordinating it and
+one child task per shard it queries; the child tasks are counted in the search rather than
+listed, and cancelling any of them cancels the whole search.
+
+Past slow searches are read from the search slow log, as shipped to the cluster by the
+Elasticsearch integration of Elastic Agent or the Filebeat elasticsearch module.
+
+Example usage:
+  es_long_queries list --threshold 10s
+  es_long_queries list --index logs-*
+  es_long_queries history --last 24h
+  es_long_queries kill --query-id oTUltX4IQMOUUVeiohTt8A:12345
+  es_long_queries analyze --last 7d`,
+		Example: `es_long_queries list
+es_long_queries list --threshold 1m --index logs-*
+es_long_queries history --last 24h --show-user --hide-sensitive
+es_long_queries kill --query-id oTUltX4IQMOUUVeiohTt8A:12345
+es_long_queries analyze --last 7d --index logs-*`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// List subcommand
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List currently running long queries",
+		Long: `List the searches that have been running for at least --threshold, longest first.
+
+The threshold takes a time value such as 30s or 1m30s; a bare number is seconds. Children is the
+number of shard-level tasks the search has started and Nodes the number of nodes they run on.
+Opaque ID is the X-Opaque-Id header the client sent, which often identifies the application or
+user behind the search; it is shown with --show-user.`,
+		Example: `  # List searches running for 10 seconds or more
+  es_long_queries list
+  # List searches on logs indices running for over a minute
+  es_long_queries list --threshold 1m --index logs-*
+  # List searches with the opaque ID of their client, masked
+  es_long_queries list --show-user --hide-sensitive`,
+		RunE: runList,
+	}
+
+	// Kill subcommand
+	var killCmd = &cobra.Command{
+		Use:   "kill",
+		Short: "Cancel a running query",
+		Long: `Cancel a running search by the task ID shown by list.
+
+When the ID is of a shard-level task, the search that started it is cancelled, which cancels
+all of its shard-level tasks. The search stops at the next cancellation check of each shard, so
+it can take a moment to disappear from list.`,
+		Example: `  # Kill a specific query by ID
+  es_long_queries kill --query-id oTUltX4IQMOUUVeiohTt8A:12345`,
+		RunE: runKill,
+	}
+
+	// History subcommand
+	var historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "Show slow queries from the slow log",
+		Long: `Show the searches recorded in the search slow log over a period, most recent first.
+
+Searches are only logged when they cross a slow log threshold of their index, such as
+index.search.slowlog.threshold.query.warn, and the user is only recorded when
+index.search.slowlog.include.user is set. --slowlog-index is where the slow log is shipped to.`,
+		Example: `  # Show slow queries from the last hour
+  es_long_queries history --last 1h
+  # Show slow queries from the last 24 hours on specific indices
+  es_long_queries history --last 24h --index logs-*
+  # Show slow queries with username information
+  es_long_queries history --last 12h --show-user
+  # Limit the number of results
+  es_long_queries history --last 24h --max-results 50`,
+		RunE: runHistory,
+	}
+
+	// Analyze subcommand
+	var analyzeCmd = &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze slow query patterns",
+		Long: `Group the slowest searches in the slow log over a period by index and query shape, with
+suggestions for making each pattern faster.
+
+Searches with the same shape differ only in the values they look for. Up to --sample of the
+slowest searches are analyzed, patterns are listed by the total time spent on them.`,
+		Example: `  # Analyze slow queries from the last 24 hours
+  es_long_queries analyze --last 24h
+  # Analyze slow queries on specific indices
+  es_long_queries analyze --last 7d --index logs-*
+  # Include username information in analysis
+  es_long_queries analyze --last 3d --show-user`,
+		RunE: runAnalyze,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// List command flags
+	listCmd.Flags().StringVar(&threshold, "threshold", "10s", "Minimum running time of the queries to list, e.g. 30s or 1m30s")
+	listCmd.Flags().StringVar(&indexPattern, "index", "", "Only list queries on indices matching this pattern, e.g. logs-*")
+	listCmd.Flags().IntVar(&maxResults, "max-results", 0, "Maximum number of results to display (default is all)")
+
+	// History command flags
+	historyCmd.Flags().StringVar(&historyLast, "last", "1h", "Period to show, counting back from now (e.g. 1h, 24h, 7d)")
+	historyCmd.Flags().IntVar(&historyMaxResults, "max-results", 100, "Maximum number of results to display")
+
+	// Analyze command flags
+	analyzeCmd.Flags().StringVar(&analyzeLast, "last", "24h", "Period to analyze, counting back from now (e.g. 24h, 7d)")
+	analyzeCmd.Flags().IntVar(&sampleSize, "sample", 1000, "Number of the slowest queries to analyze")
+	analyzeCmd.Flags().IntVar(&analyzeMaxResults, "max-results", 20, "Maximum number of patterns to display (0 for all)")
+
+	for _, c := range []*cobra.Command{historyCmd, analyzeCmd} {
+		c.Flags().StringVar(&fromTime, "from", "", "Start of the period instead of --last (e.g. 2024-05-01T00:00, now-30d)")
+		c.Flags().StringVar(&toTime, "to", "", "End of the period (default is now)")
+		c.Flags().StringVar(&indexPattern, "index", "", "Only include queries on indices matching this pattern, e.g. logs-*")
+		c.Flags().StringVar(&slowlogIndex, "slowlog-index", client.DefaultSlowlogIndexPattern, "Index pattern of the shipped slow log")
+	}
+
+	for _, c := range []*cobra.Command{listCmd, historyCmd, analyzeCmd} {
+		c.Flags().BoolVar(&showUser, "show-user", false, "Show who ran each query: the user from the slow log, or the client's opaque ID for list")
+		c.Flags().BoolVar(&hideSensitive, "hide-sensitive", false, "Replace usernames, opaque IDs and IP addresses with stable tokens")
+	}
+
+	// Kill command flags
+	killCmd.Flags().StringVar(&queryID, "query-id", "", "Task ID of the query to cancel, as node_id:task_number")
+	killCmd.MarkFlagRequired("query-id")
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, historyCmd, killCmd, analyzeCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runList executes the list command
+func runList(cmd *cobra.Command, args []string) error {
+	minRunning, err := client.ParseTimeValue(threshold)
+	if err != nil {
+		return fmt.Errorf("invalid --threshold: %w", err)
+	}
+
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Create client
+	c, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	searches, err := c.GetSearchTasks(minRunning)
+	if err != nil {
+		return fmt.Errorf("failed to get running queries: %w", err)
+	}
+
+	// Prepare data for output
+	header := []string{"Task ID", "Node", "Action", "Indices", "Started", "Running", "Children", "Nodes", "Cancellable"}
+	if showUser {
+		header = append(header, "Opaque ID")
+	}
+	var rows [][]string
+
+	for _, search := range searches {
+		if indexPattern != "" && !matchesAnyIndex(search.Indices, indexPattern) {
+			continue
+		}
+		if maxResults > 0 && len(rows) >= maxResults {
+			break
+		}
+
+		cancellable := "yes"
+		if search.Cancelled {
+			cancellable = "cancelling"
+		} else if !search.Cancellable {
+			cancellable = "no"
+		}
+
+		row := []string{
+			search.ID,
+			search.Node,
+			search.Action,
+			strings.Join(search.Indices, ","),
+			search.Started.Format(time.RFC3339),
+			search.Running.Round(time.Millisecond).String(),
+			strconv.Itoa(search.Children),
+			strconv.Itoa(search.ChildNodes),
+			cancellable,
+		}
+		if showUser {
+			row = append(row, search.OpaqueID)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf("No queries running for %s or longer\n", minRunning)
+		return nil
+	}
+
+	formatter, err := newFormatter(cfg)
+	if err != nil {
+		return err
+	}
+	return formatter.Write(header, rows)
+}
+
+// runHistory executes the history command
+func runHistory(cmd *cobra.Command, args []string) error {
+	period, err := client.ParseTimeRange(historyLast, fromTime, toTime, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Create client
+	c, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	queries, total, err := c.GetSlowQueries(slowlogIndex, period, indexPattern, historyMaxResults, false)
+	if err != nil {
+		return fmt.Errorf("failed to get slow queries: %w", err)
+	}
+	if total == 0 {
+		fmt.Printf("No slow queries found in '%s' from %s. Check that the search slow log is enabled and shipped to the cluster.\n", slowlogIndex, period)
+		return nil
+	}
+
+	// Prepare data for output
+	header := []string{"Time", "Node", "Index", "Took", "Level", "Shards", "Query"}
+	if showUser {
+		header = append(header, "User", "Opaque ID")
+	}
+	rows := make([][]string, 0, len(queries))
+	for _, q := range queries {
+		row := []string{
+			q.Timestamp.Format(time.RFC3339),
+			q.Node,
+			q.Index,
+			q.Took.Round(time.Millisecond).String(),
+			q.Level,
+			strconv.Itoa(q.TotalShards),
+			q.Source,
+		}
+		if showUser {
+			row = append(row, q.User, q.OpaqueID)
+		}
+		rows = append(rows, row)
+	}
+
+	formatter, err := newFormatter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := formatter.Write(header, rows); err != nil {
+		return err
+	}
+	if total > int64(len(queries)) {
+		fmt.Fprintf(os.Stderr, "Showing the %d most recent of %d slow queries (use --max-results to show more)\n", len(queries), total)
+	}
+	return nil
+}
+
+// runAnalyze executes the analyze command
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	period, err := client.ParseTimeRange(analyzeLast, fromTime, toTime, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Create client
+	c, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	queries, total, err := c.GetSlowQueries(slowlogIndex, period, indexPattern, sampleSize, true)
+	if err != nil {
+		return fmt.Errorf("failed to get slow queries: %w", err)
+	}
+	if total == 0 {
+		fmt.Printf("No slow queries found in '%s' from %s. Check that the search slow log is enabled and shipped to the cluster.\n", slowlogIndex, period)
+		return nil
+	}
+
+	patterns := client.AnalyzeSlowQueries(queries)
+	if analyzeMaxResults > 0 && len(patterns) > analyzeMaxResults {
+		patterns = patterns[:analyzeMaxResults]
+	}
+
+	// Prepare data for output
+	header := []string{"Index", "Count", "Total", "Avg", "Max", "Query Shape", "Suggestions"}
+	if showUser {
+		header = append(header, "Users")
+	}
+	rows := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		suggestions := strings.Join(p.Suggestions, "; ")
+		if suggestions == "" {
+			suggestions = "-"
+		}
+		row := []string{
+			p.Index,
+			strconv.Itoa(p.Count),
+			p.TotalTook.Round(time.Millisecond).String(),
+			p.AvgTook().Round(time.Millisecond).String(),
+			p.MaxTook.Round(time.Millisecond).String(),
+			p.Shape,
+			suggestions,
+		}
+		if showUser {
+			row = append(row, strings.Join(p.Users, ", "))
+		}
+		rows = append(rows, row)
+	}
+
+	formatter, err := newFormatter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := formatter.Write(header, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Analyzed the %d slowest of %d slow queries from %s\n", len(queries), total, period)
+	return nil
+}
+
+// newFormatter creates the output formatter, which replaces usernames, opaque IDs and IP
+// addresses with stable tokens with --hide-sensitive, as --redact does
+func newFormatter(cfg *config.Config) (*format.Formatter, error) {
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if hideSensitive {
+		redactor, err := format.NewRedactor(cfg.Output.RedactPatterns)
+		if err != nil {
+			return nil, err
+		}
+		formatter.SetRedactor(redactor)
+	}
+	return formatter, nil
+}
+
+// runKill executes the kill command
+func runKill(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Create client
+	c, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	cancelled, err := c.CancelSearchTask(queryID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel query: %w", err)
+	}
+
+	if cancelled != queryID {
+		fmt.Printf("Task %s is part of search %s, cancelled the search and all of its tasks\n", queryID, cancelled)
+		return nil
+	}
+	fmt.Printf("Cancelled query %s\n", cancelled)
+	return nil
+}
+
+// matchesAnyIndex reports whether any of the indices a query searches matches pattern
+func matchesAnyIndex(indices []string, pattern string) bool {
+	for _, index := range indices {
+		if client.MatchIndexPatterns(index, []string{pattern}) {
+			return true
+		}
+	}
+	return false
+}
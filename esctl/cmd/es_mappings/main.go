@@ -1,11 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -26,6 +30,12 @@ var (
 	// Command specific
 	indexName string
 
+	// Infer command
+	sampleFile   string
+	alignECS     bool
+	indexPattern string
+	showSummary  bool
+
 	// Output
 	outputFormat string
 )
@@ -52,9 +62,11 @@ and ensuring your data is correctly interpreted by Elasticsearch.
 Example usage:
   es_mappings --index=my-index
   es_mappings --index=my-index --format=json
-  es_mappings --index=my-index --style=blue`,
+  es_mappings --index=my-index --style=blue
+  es_mappings infer --file=samples.ndjson --ecs`,
 		Example:          `es_mappings --index=my-index
-es_mappings --index=my-index --format=json`,
+es_mappings --index=my-index --format=json
+es_mappings infer --file=samples.ndjson --index-pattern="logs-myapp-*"`,
 		PersistentPreRunE: initConfig,
 		RunE:             run,
 	}
@@ -63,6 +75,7 @@ es_mappings --index=my-index --format=json`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -77,11 +90,50 @@ es_mappings --index=my-index --format=json`,
 	rootCmd.MarkFlagRequired("index")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Infer command
+	var inferCmd = &cobra.Command{
+		Use:   "infer",
+		Short: "Propose a mapping from sample documents",
+		Long: `Analyse sample documents and propose a mapping, as a starting point for a new index template.
+
+Samples are read from an NDJSON file, one document per line; search hits are unwrapped to their
+_source. Objects are mapped as object fields and arrays as multiple values of one field.
+
+- Strings are keyword fields, or text with a keyword subfield when values are long or read like prose
+- Strings that are all dates or all IP addresses become date (with the format seen) or ip fields
+- Whole numbers become integer or long by the range seen, decimals float or double
+- With --ecs, common field names (timestamp, msg, level, host, src_ip, status, ...) are renamed
+  to their ECS fields and given the ECS type, where the values seen fit that type
+
+Fields seen with more than one type are reported on stderr. No cluster connection is needed.`,
+		Example: `es_mappings infer --file=samples.ndjson
+es_mappings infer --file=samples.ndjson --ecs --index-pattern="logs-myapp-*"
+es_mappings infer --file=samples.ndjson --summary`,
+		RunE: runInfer,
+	}
+	inferCmd.Flags().StringVar(&sampleFile, "file", "", "NDJSON file of sample documents (required)")
+	inferCmd.Flags().BoolVar(&alignECS, "ecs", false, "Rename well-known fields to their ECS names and types")
+	inferCmd.Flags().StringVar(&indexPattern, "index-pattern", "", "Emit an index template body for this index pattern instead of a bare mapping")
+	inferCmd.Flags().BoolVar(&showSummary, "summary", false, "Show a table of the fields and why each type was chosen instead of the mapping")
+	inferCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(inferCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -104,6 +156,15 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error creating client: %w", err)
 	}
 
+	// Report which indices an alias or data stream covers
+	resolved, err := c.ResolveIndexName(indexName)
+	if err != nil {
+		return fmt.Errorf("error resolving '%s': %w", indexName, err)
+	}
+	if resolved.Indirect() {
+		fmt.Fprintf(os.Stderr, "Resolved %s '%s' to %d indices: %s\n", resolved.Kind, indexName, len(resolved.Indices), strings.Join(resolved.Indices, ", "))
+	}
+
 	// Get mappings
 	mappings, err := c.GetPrettyIndexMappings(indexName)
 	if err != nil {
@@ -115,3 +176,68 @@ func run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runInfer proposes a mapping from sample documents
+func runInfer(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	file, err := os.Open(sampleFile)
+	if err != nil {
+		return fmt.Errorf("error opening sample file: %w", err)
+	}
+	defer file.Close()
+
+	docs, err := client.ReadSampleDocuments(file)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", sampleFile, err)
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("no documents in %s", sampleFile)
+	}
+
+	inferred := client.InferMapping(docs, alignECS)
+	for _, conflict := range inferred.Conflicts {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", conflict)
+	}
+
+	if showSummary {
+		header := []string{"Field", "Sample Field", "Type", "Seen", "Distinct", "Range", "Reason"}
+		rows := make([][]string, 0, len(inferred.Fields))
+		for _, field := range inferred.Fields {
+			rows = append(rows, []string{
+				field.Name,
+				field.SourceName,
+				fmt.Sprintf("%v", field.Mapping["type"]),
+				fmt.Sprintf("%d/%d", field.Seen, inferred.Documents),
+				fmt.Sprintf("%d", field.Distinct),
+				field.Range,
+				field.Reason,
+			})
+		}
+		formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+		return formatter.Write(header, rows)
+	}
+
+	var body interface{} = map[string]interface{}{
+		"mappings": map[string]interface{}{"properties": inferred.Properties()},
+	}
+	if indexPattern != "" {
+		body = map[string]interface{}{
+			"index_patterns": []string{indexPattern},
+			"template":       body,
+		}
+	}
+
+	prettyJSON, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting mapping: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(prettyJSON))
+	fmt.Fprintf(os.Stderr, "Proposed mapping for %d fields from %d documents\n", len(inferred.Fields), inferred.Documents)
+
+	return nil
+}
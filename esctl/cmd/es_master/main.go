@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_master",
+		Short: "Show master election and cluster coordination status",
+		Long: `Show the elected master and the state of cluster coordination.
+
+The summary shows the elected master, the cluster state version and UUID, and the current term.
+The term increases with every election, so a term that keeps climbing between runs means the
+master is changing. On clusters that support the master_is_stable health indicator (8.4 and
+later), its status and the recently elected masters are shown as well.
+
+The master-eligible nodes are listed with whether they are in the voting configuration, which
+helps diagnose flapping masters and lost quorum during network issues.
+
+Example usage:
+  es_master
+  es_master --format=json`,
+		Example: `es_master
+es_master --format=json`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	status, err := esClient.GetMasterStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get master status: %w", err)
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Summary
+	master := status.MasterName
+	if master == "" {
+		master = "none elected"
+	}
+	rows := [][]string{
+		{"Elected master", master},
+		{"Master node ID", status.MasterID},
+		{"Cluster state version", fmt.Sprintf("%d", status.StateVersion)},
+		{"Cluster state UUID", status.StateUUID},
+		{"Term", fmt.Sprintf("%d", status.Term)},
+		{"Voting configuration", fmt.Sprintf("%d nodes", len(status.VotingConfig))},
+	}
+	if status.Stability != "" {
+		rows = append(rows, []string{"Master stability", status.Stability})
+		if status.StabilityInfo != "" {
+			rows = append(rows, []string{"Stability detail", status.StabilityInfo})
+		}
+		recent := "-"
+		if len(status.RecentMasters) > 0 {
+			recent = strings.Join(status.RecentMasters, ", ")
+		}
+		rows = append(rows, []string{"Recent masters", recent})
+	} else {
+		rows = append(rows, []string{"Master stability", "not available on this cluster"})
+	}
+	if err := formatter.Write([]string{"Property", "Value"}, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	// Master-eligible nodes
+	fmt.Println()
+	rows = make([][]string, 0, len(status.Eligible))
+	for _, node := range status.Eligible {
+		rows = append(rows, []string{
+			node.Name,
+			node.ID,
+			node.Address,
+			yesNo(node.Elected),
+			yesNo(node.Voting),
+			yesNo(node.VotingOnly),
+		})
+	}
+	if err := formatter.Write([]string{"Name", "ID", "Address", "Elected", "Voting", "Voting Only"}, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	return nil
+}
+
+// yesNo formats a boolean for display
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"sort"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
@@ -27,6 +28,11 @@ var (
 
 	// Command specific
 	shortOutput bool
+	groupBy     string
+	warnPercent float64
+
+	// Watch mode
+	watcher = format.NewWatcher()
 
 	// Output
 	outputFormat string
@@ -55,18 +61,22 @@ distribution, and troubleshoot allocation issues related to disk space constrain
 Example usage:
   es_nodeallocations --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_nodeallocations --short
-  es_nodeallocations --format=json`,
+  es_nodeallocations --format=json
+  es_nodeallocations --group-by=tier`,
 		Example:          `es_nodeallocations
 es_nodeallocations --short
-es_nodeallocations --format=json`,
+es_nodeallocations --format=json
+es_nodeallocations --group-by=tier --warn-percent=80
+es_nodeallocations --watch --interval=30s`,
 		PersistentPreRunE: initConfig,
-		RunE:              run,
+		RunE:              watcher.Wrap(run),
 	}
 	// Disable the auto-generated completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -78,13 +88,29 @@ es_nodeallocations --format=json`,
 
 	// Command specific flags
 	rootCmd.Flags().BoolVarP(&shortOutput, "short", "s", false, "Shorter, more compact table output")
+	rootCmd.Flags().StringVar(&groupBy, "group-by", "", "Aggregate disk and shard counts by group (tier)")
+	rootCmd.Flags().Float64Var(&warnPercent, "warn-percent", 85, "Flag groups whose disk usage is at or above this percentage")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Watch flags
+	watcher.AddFlags(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -113,6 +139,15 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error getting node allocations: %w", err)
 	}
 
+	// Aggregate per tier if requested
+	switch groupBy {
+	case "":
+	case "tier":
+		return runGroupByTier(cfg, nodes)
+	default:
+		return fmt.Errorf("invalid --group-by value %q (supported: tier)", groupBy)
+	}
+
 	// Sort nodes by name
 	sort.Slice(nodes, func(i, j int) bool {
 		return nodes[i].Name < nodes[j].Name
@@ -164,3 +199,31 @@ func run(cmd *cobra.Command, args []string) error {
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
 	return formatter.Write(header, rows)
 }
+
+// runGroupByTier outputs disk and shard usage aggregated per data tier
+func runGroupByTier(cfg *config.Config, nodes []client.NodeAllocation) error {
+	header := []string{"Tier", "Nodes", "Shards", "Disk Indices", "Disk Used", "Disk Total", "Disk Percent", "Status"}
+	var rows [][]string
+
+	for _, tier := range client.GroupAllocationsByTier(nodes) {
+		status := "OK"
+		if tier.UsedPercent() >= warnPercent {
+			status = "NEARING CAPACITY"
+		}
+
+		rows = append(rows, []string{
+			tier.Tier,
+			fmt.Sprintf("%d", tier.Nodes),
+			fmt.Sprintf("%d", tier.Shards),
+			client.ByteCountSI(tier.DiskIndices),
+			client.ByteCountSI(tier.DiskUsed),
+			client.ByteCountSI(tier.DiskTotal),
+			fmt.Sprintf("%.1f%%", tier.UsedPercent()),
+			status,
+		})
+	}
+
+	// Create formatter and output
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
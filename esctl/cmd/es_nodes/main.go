@@ -2,8 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -27,6 +33,20 @@ var (
 
 	// Node options
 	nodeID string
+	wide   bool
+
+	// Secure settings options
+	promptPassword bool
+
+	// Capture options
+	captureNode     string
+	captureType     string
+	captureDuration time.Duration
+	captureDir      string
+	force           bool
+
+	// Watch mode
+	watcher = format.NewWatcher()
 
 	// Output
 	outputFormat string
@@ -49,12 +69,15 @@ performance issues across your Elasticsearch deployment.
 Example usage:
   es_nodes --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_nodes --node-id=node1 --format=json
+  es_nodes --wide
   es_nodes --style=blue`,
 		Example: `es_nodes
+es_nodes --wide
 es_nodes --node-id=node1
-es_nodes --format=json`,
+es_nodes --format=json
+es_nodes --watch --interval=10s`,
 		PersistentPreRunE: initConfig,
-		RunE:  listNodes, // Default action is to list nodes
+		RunE:  watcher.Wrap(listNodes), // Default action is to list nodes
 	}
 	// Disable the auto-generated completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
@@ -63,8 +86,11 @@ es_nodes --format=json`,
 	var listCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List nodes in the cluster",
-		Long:  `List all nodes in the Elasticsearch cluster with their resource usage information.`,
-		RunE:  listNodes,
+		Long: `List all nodes in the Elasticsearch cluster with their resource usage information.
+
+With --wide the Elasticsearch version, JDK, operating system and total memory of each node are
+also shown, followed by a legend of the role letters, which makes mixed-version clusters visible.`,
+		RunE: watcher.Wrap(listNodes),
 	}
 
 	// Stats subcommand
@@ -83,8 +109,84 @@ es_nodes --format=json`,
 		RunE:  getHotThreads,
 	}
 
+	// Secure settings subcommand
+	var secureSettingsCmd = &cobra.Command{
+		Use:   "secure-settings",
+		Short: "Show which keystore-backed clients are configured on each node",
+		Long: `Show the clients, accounts, realms and exporters that read credentials from the keystore,
+and the nodes each is configured on.
+
+Elasticsearch never returns secure settings, so the keystore entries themselves cannot be
+listed. Instead the node settings of the components that use them are checked: repository
+clients (s3.client.*, azure.client.*, gcs.client.*), notification accounts, security realms and
+monitoring exporters. A component configured on only some nodes usually means a keystore or
+elasticsearch.yml that was not updated everywhere.`,
+		RunE: listSecureSettings,
+	}
+
+	// Reload secure settings subcommand
+	var reloadCmd = &cobra.Command{
+		Use:   "reload",
+		Short: "Reload the keystore on every node",
+		Long: `Reload the reloadable secure settings, such as repository client credentials, from the
+keystore on every node, without restarting them. Add or change the entries with
+elasticsearch-keystore on each node first.
+
+If the keystore is password protected, use --password-prompt to enter the password. The
+password is only accepted over HTTPS.`,
+		Example: `es_nodes secure-settings reload
+es_nodes secure-settings reload --password-prompt`,
+		RunE: reloadSecureSettings,
+	}
+	reloadCmd.Flags().BoolVar(&promptPassword, "password-prompt", false, "Prompt for the keystore password")
+	secureSettingsCmd.AddCommand(reloadCmd)
+
+	// Capture subcommand
+	var captureCmd = &cobra.Command{
+		Use:   "capture",
+		Short: "Capture a heap dump or flight recording from the JVM of a node",
+		Long: `Capture low-level JVM diagnostics from a node into a local directory, for severe incidents
+where the node stats and hot threads do not explain what the JVM is doing.
+
+  heap-dump   a dump of the live objects on the heap, for memory leaks and circuit breaker trips
+  jfr         a Java Flight Recorder recording of --duration, for CPU, allocation, lock and GC
+              behaviour
+
+Elasticsearch has no API for these, so jcmd is run on the host of the node through the executor
+set in the diagnostics section of the configuration:
+
+  diagnostics:
+    executor: ssh          # ssh to the publish host of the node
+    ssh_user: admin
+    run_as: elasticsearch  # jcmd must run as the user of the JVM
+
+  diagnostics:
+    executor: kubectl      # kubectl exec into the ECK pod named after the node
+    namespace: elastic
+
+  diagnostics:
+    executor: command      # any remote shell, {node}, {host} and {ip} are replaced
+    command: ["gcloud", "compute", "ssh", "{node}", "--command"]
+
+The file is written to the temporary directory of the node (diagnostics.temp_dir, default /tmp)
+and copied back through the executor, so the node needs free disk space for it. A heap dump
+pauses the node while it is written and is about as large as the used heap, so it asks for
+confirmation unless --force is given.`,
+		Example: `es_nodes capture --node es-data-3 --type jfr --duration 60s
+es_nodes capture --node es-data-3 --type heap-dump --output-dir ./incident-42`,
+		RunE: captureJVM,
+	}
+	captureCmd.Flags().StringVarP(&captureNode, "node", "n", "", "ID or name of the node to capture from (required)")
+	captureCmd.Flags().StringVarP(&captureType, "type", "t", "", "What to capture: heap-dump or jfr (required)")
+	captureCmd.Flags().DurationVar(&captureDuration, "duration", time.Minute, "How long to record with jfr")
+	captureCmd.Flags().StringVarP(&captureDir, "output-dir", "o", ".", "Local directory to write the capture to")
+	captureCmd.Flags().BoolVar(&force, "force", false, "Take a heap dump without asking for confirmation")
+	captureCmd.MarkFlagRequired("node")
+	captureCmd.MarkFlagRequired("type")
+
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -95,8 +197,25 @@ es_nodes --format=json`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Watch flags
+	watcher.AddFlags(rootCmd, listCmd)
+
+	// List command flags
+	rootCmd.Flags().BoolVarP(&wide, "wide", "w", false, "Show version, JDK, OS and memory columns and a role legend")
+	listCmd.Flags().BoolVarP(&wide, "wide", "w", false, "Show version, JDK, OS and memory columns and a role legend")
 
 	// Stats command flags
 	statsCmd.Flags().StringVarP(&nodeID, "id", "i", "", "Node ID to get stats for (required)")
@@ -106,11 +225,12 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	hotThreadsCmd.Flags().StringVarP(&nodeID, "id", "i", "", "Node ID to get hot threads for (optional, if not provided, gets hot threads for all nodes)")
 
 	// Add subcommands
-	rootCmd.AddCommand(listCmd, statsCmd, hotThreadsCmd)
+	rootCmd.AddCommand(listCmd, statsCmd, hotThreadsCmd, secureSettingsCmd, captureCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -136,7 +256,8 @@ func listNodes(cmd *cobra.Command, args []string) error {
 
 	// Get nodes
 	nodes, err := esClient.GetNodes()
-	if err != nil {
+	var partial *client.PartialNodeError
+	if err != nil && !errors.As(err, &partial) {
 		return fmt.Errorf("failed to get nodes: %w", err)
 	}
 
@@ -145,11 +266,21 @@ func listNodes(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Static node details are only needed for the wide view
+	if wide {
+		if err := esClient.FillNodeDetails(nodes); err != nil {
+			return fmt.Errorf("failed to get node details: %w", err)
+		}
+	}
+
 	// Create formatter
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
 
 	// Prepare table data
 	header := []string{"ID", "Name", "IP", "Role", "CPU", "Load (1m/5m/15m)", "RAM %", "Heap %", "Disk Used %", "Disk Avail", "Uptime"}
+	if wide {
+		header = append(header, "Version", "JDK", "OS", "RAM Total")
+	}
 	rows := [][]string{}
 
 	for _, node := range nodes {
@@ -167,11 +298,26 @@ func listNodes(cmd *cobra.Command, args []string) error {
 			node.DiskAvailable,
 			node.Uptime,
 		}
+		if wide {
+			row = append(row, node.Version, node.JDK, node.OS, node.RAMTotal)
+		}
 		rows = append(rows, row)
 	}
 
 	// Print table
-	return formatter.Write(header, rows)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	// The legend and version summary would break machine readable output
+	if wide && cfg.Output.Format != "json" && cfg.Output.Format != "csv" {
+		printWideFooter(nodes)
+	}
+
+	if partial != nil {
+		client.PrintNodeErrors(partial)
+	}
+	return nil
 }
 
 // getNodeStats handles the node stats command
@@ -190,7 +336,8 @@ func getNodeStats(cmd *cobra.Command, args []string) error {
 
 	// Get node stats
 	stats, err := esClient.GetNodeStats(nodeID)
-	if err != nil {
+	var partial *client.PartialNodeError
+	if err != nil && !errors.As(err, &partial) {
 		return fmt.Errorf("failed to get node stats: %w", err)
 	}
 
@@ -201,6 +348,10 @@ func getNodeStats(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Stats for node '%s':\n%s\n", nodeID, string(statsJSON))
+
+	if partial != nil {
+		client.PrintNodeErrors(partial)
+	}
 	return nil
 }
 
@@ -228,3 +379,198 @@ func getHotThreads(cmd *cobra.Command, args []string) error {
 	fmt.Println(hotThreads)
 	return nil
 }
+
+// printWideFooter prints the role legend and warns when nodes run different versions
+func printWideFooter(nodes []client.NodeInfo) {
+	if legend := client.NodeRoleLegend(nodes); len(legend) > 0 {
+		fmt.Printf("\nRoles: %s\n", strings.Join(legend, ", "))
+	}
+
+	versions := make(map[string]int)
+	for _, node := range nodes {
+		if node.Version != "" {
+			versions[node.Version]++
+		}
+	}
+	if len(versions) > 1 {
+		var counts []string
+		for version, count := range versions {
+			counts = append(counts, fmt.Sprintf("%s (%d nodes)", version, count))
+		}
+		sort.Strings(counts)
+		fmt.Printf("\nWarning: nodes are running %d different versions: %s\n", len(versions), strings.Join(counts, ", "))
+	}
+}
+
+// listSecureSettings shows the keystore-backed components configured on each node
+func listSecureSettings(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	groups, totalNodes, err := esClient.GetSecureSettingGroups()
+	if err != nil {
+		return fmt.Errorf("failed to get node settings: %w", err)
+	}
+	if len(groups) == 0 {
+		fmt.Println("No keystore-backed clients, accounts, realms or exporters are configured")
+		return nil
+	}
+
+	header := []string{"Component", "Nodes", "Missing On"}
+	rows := make([][]string, 0, len(groups))
+	inconsistent := 0
+	for _, group := range groups {
+		missing := "-"
+		if len(group.MissingNodes) > 0 {
+			missing = strings.Join(group.MissingNodes, ", ")
+			inconsistent++
+		}
+		rows = append(rows, []string{group.Name, fmt.Sprintf("%d/%d", len(group.Nodes), totalNodes), missing})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if inconsistent > 0 {
+		fmt.Printf("\n%d components are not configured on every node\n", inconsistent)
+	}
+	return nil
+}
+
+// reloadSecureSettings reloads the keystore on every node and reports the nodes that failed
+func reloadSecureSettings(cmd *cobra.Command, args []string) error {
+	var keystorePassword string
+	if promptPassword {
+		var err error
+		keystorePassword, err = config.ReadPassword("Keystore password: ")
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	results, err := esClient.ReloadSecureSettings(keystorePassword)
+	if err != nil {
+		return fmt.Errorf("failed to reload secure settings: %w", err)
+	}
+
+	header := []string{"Node", "ID", "Result"}
+	rows := make([][]string, 0, len(results))
+	failed := 0
+	for _, result := range results {
+		status := "reloaded"
+		if result.Error != "" {
+			status = result.Error
+			failed++
+		}
+		rows = append(rows, []string{result.Name, result.NodeID, status})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("secure settings could not be reloaded on %d of %d nodes", failed, len(results))
+	}
+	return nil
+}
+
+// captureJVM handles the capture command
+func captureJVM(cmd *cobra.Command, args []string) error {
+	extension := map[string]string{client.CaptureHeapDump: "hprof", client.CaptureJFR: "jfr"}[captureType]
+	if extension == "" {
+		return fmt.Errorf("invalid --type %q, expected %s or %s", captureType, client.CaptureHeapDump, client.CaptureJFR)
+	}
+	if captureType == client.CaptureJFR && captureDuration <= 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	capture, err := client.NewJVMCapture(cfg.Diagnostics)
+	if err != nil {
+		return err
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	node, err := esClient.GetNodeProcess(captureNode)
+	if err != nil {
+		return fmt.Errorf("failed to find node %s: %w", captureNode, err)
+	}
+
+	// Confirm heap dumps if not forced, they stop the node while they are written
+	if captureType == client.CaptureHeapDump && !force {
+		fmt.Printf("A heap dump pauses node %s while it is written. Continue? [y/N] ", node.Name)
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(captureDir, 0700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	// Heap dumps hold the documents and credentials the node had in memory
+	filename := filepath.Join(captureDir, fmt.Sprintf("%s-%s-%s.%s", node.Name, captureType, time.Now().UTC().Format("20060102T150405Z"), extension))
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filename, err)
+	}
+
+	if captureType == client.CaptureJFR {
+		fmt.Fprintf(os.Stderr, "Recording node %s (pid %d) for %s\n", node.Name, node.PID, captureDuration)
+	} else {
+		fmt.Fprintf(os.Stderr, "Taking heap dump of node %s (pid %d)\n", node.Name, node.PID)
+	}
+
+	err = capture.Capture(*node, captureType, captureDuration, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return fmt.Errorf("failed to capture from node %s: %w", node.Name, err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	fmt.Printf("Wrote %s (%d bytes)\n", filename, info.Size())
+	return nil
+}
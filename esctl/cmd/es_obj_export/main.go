@@ -68,6 +68,7 @@ es_obj_export --id my-dashboard-id --type dashboard --output-dir ./exports --fil
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -75,6 +76,10 @@ es_obj_export --id my-dashboard-id --type dashboard --output-dir ./exports --fil
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
 
 	// Command specific flags
 	rootCmd.Flags().StringVarP(&objectID, "id", "i", "", "ID of the object to export")
@@ -88,7 +93,8 @@ es_obj_export --id my-dashboard-id --type dashboard --output-dir ./exports --fil
 	rootCmd.Flags().StringVarP(&outputFilename, "filename", "f", "", "Custom filename for the exported file (without extension)")
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
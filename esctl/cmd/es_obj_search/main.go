@@ -32,6 +32,7 @@ var (
 	includeDependencies bool
 	perPage             int
 	page                int
+	allPages            bool
 
 	// Output
 	outputFormat string
@@ -53,7 +54,8 @@ to other saved objects. You can paginate through results and include dependencie
 Example usage:
   es_obj_search --search "dashboard" --type dashboard,visualization
   es_obj_search --search "logs" --include-dependencies
-  es_obj_search --per-page 50 --page 2`,
+  es_obj_search --per-page 50 --page 2
+  es_obj_search --type dashboard --all`,
 		Example: `es_obj_search --search "dashboard"
 es_obj_search --type dashboard,visualization
 es_obj_search --search "logs" --include-dependencies --per-page 50`,
@@ -65,6 +67,7 @@ es_obj_search --search "logs" --include-dependencies --per-page 50`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -72,6 +75,7 @@ es_obj_search --search "logs" --include-dependencies --per-page 50`,
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "kb-disable-retry", false, "Disable retry on Kibana connection failure")
 
 	// Command specific flags
@@ -80,12 +84,22 @@ es_obj_search --search "logs" --include-dependencies --per-page 50`,
 	rootCmd.Flags().BoolVarP(&includeDependencies, "include-dependencies", "d", false, "Include objects that the discovered objects depend on")
 	rootCmd.Flags().IntVar(&perPage, "per-page", 20, "Number of results per page")
 	rootCmd.Flags().IntVar(&page, "page", 1, "Page number")
+	rootCmd.Flags().BoolVar(&allPages, "all", false, "Read every page of results instead of a single page")
 
 	// Output format flag
 	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -119,8 +133,17 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if allPages && cmd.Flags().Changed("page") {
+		return fmt.Errorf("--all and --page cannot be used together")
+	}
+
 	// Search for saved objects
-	response, err := c.SearchSavedObjects(searchTerm, objectTypes, includeDependencies, perPage, page)
+	var response *client.SavedObjectSearchResponse
+	if allPages {
+		response, err = c.SearchAllSavedObjects(searchTerm, objectTypes, includeDependencies, perPage)
+	} else {
+		response, err = c.SearchSavedObjects(searchTerm, objectTypes, includeDependencies, perPage, page)
+	}
 	if err != nil {
 		return fmt.Errorf("error searching for saved objects: %w", err)
 	}
@@ -132,11 +155,15 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	// Print pagination info
-	fmt.Printf("Page %d of %d (showing %d of %d results)\n\n",
-		response.Page,
-		(response.Total+response.PerPage-1)/response.PerPage,
-		len(response.SavedObjects),
-		response.Total)
+	if allPages {
+		fmt.Printf("Showing %d of %d results\n\n", len(response.SavedObjects), response.Total)
+	} else {
+		fmt.Printf("Page %d of %d (showing %d of %d results)\n\n",
+			response.Page,
+			(response.Total+response.PerPage-1)/response.PerPage,
+			len(response.SavedObjects),
+			response.Total)
+	}
 
 	// Create table headers and rows
 	headers := []string{"ID", "Type", "Title", "Updated", "References"}
@@ -162,9 +189,6 @@ func runSearch(cmd *cobra.Command, args []string) error {
 				refStrings = append(refStrings, fmt.Sprintf("%s:%s", ref.Type, ref.ID))
 			}
 			references = strings.Join(refStrings, ", ")
-			if len(references) > 50 {
-				references = references[:47] + "..."
-			}
 		}
 
 		// Add row
@@ -179,5 +203,6 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	// Create formatter and write output
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	formatter.SetColumnWidth("References", 50)
 	return formatter.Write(headers, rows)
 }
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -24,6 +26,17 @@ var (
 	insecure    bool
 	disableRetry bool
 
+	// Latency measurement
+	measure bool
+	samples int
+
+	// Availability probe
+	probe      bool
+	probeIndex string
+
+	// Watch mode
+	watcher = format.NewWatcher()
+
 	// Output
 	outputFormat string
 )
@@ -41,21 +54,41 @@ quickly verify cluster availability and health state.
 The command performs a lightweight health check that doesn't impact cluster performance,
 making it ideal for monitoring scripts, connectivity testing, and troubleshooting.
 
+With --measure, the health check is replaced by a shallow check of every configured address:
+several lightweight requests are made over fresh connections and the connection establishment,
+TLS handshake and round-trip latencies are reported per address. This helps tell a slow cluster
+apart from a slow network.
+
+With --probe, the cluster is checked end to end instead: a document is written to --index, the
+index is refreshed, the document is searched for and deleted again, and the latency of each step
+is reported. The exit code is 1 when any step fails, so a cluster that reports green but cannot
+take writes or serve searches is caught. The index defaults to healthcheck-probe, prefixed with
+tenant_prefix when one is set. It is created on the first probe and left in place afterwards, only
+the probe document is deleted; use a regular index rather than one matching a data stream
+template.
+
 Example usage:
   es_ping --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_ping --format=json
-  es_ping --style=blue`,
+  es_ping --style=blue
+  es_ping --measure --samples=10
+  es_ping --probe --index healthcheck-probe`,
 		Example: `es_ping
 es_ping --format=json
-es_ping --style=blue`,
+es_ping --style=blue
+es_ping --measure
+es_ping --watch
+es_ping --probe
+es_ping --probe --index healthcheck-probe --format=json`,
 		PersistentPreRunE: initConfig,
-		RunE:  run,
+		RunE:  watcher.Wrap(run),
 	}
 	// Disable the auto-generated completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -66,12 +99,35 @@ es_ping --style=blue`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Watch flags
+	watcher.AddFlags(rootCmd)
+
+	// Latency measurement flags
+	rootCmd.Flags().BoolVar(&measure, "measure", false, "Measure connection, TLS handshake and round-trip latency per address instead of reporting cluster health")
+	rootCmd.Flags().IntVar(&samples, "samples", 5, "Number of requests per address when using --measure")
+
+	// Availability probe flags
+	rootCmd.Flags().BoolVar(&probe, "probe", false, "Write, refresh, search for and delete a document, timing each step, instead of reporting cluster health")
+	rootCmd.Flags().StringVar(&probeIndex, "index", "", "Index the --probe document is written to, created if needed and left in place (default is healthcheck-probe under tenant_prefix)")
+	rootCmd.MarkFlagsMutuallyExclusive("measure", "probe")
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -90,6 +146,13 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Flag overrides are now handled in initConfig
 
+	if measure {
+		return runMeasure(cfg)
+	}
+	if probe {
+		return runProbe(cfg)
+	}
+
 	// Initialize client
 	client, err := client.New(cfg)
 	if err != nil {
@@ -106,3 +169,87 @@ func run(cmd *cobra.Command, args []string) error {
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
 	return formatter.Write(rows[0], rows[1:])
 }
+
+// runMeasure reports per address latencies
+func runMeasure(cfg *config.Config) error {
+	if samples < 1 {
+		return fmt.Errorf("--samples must be at least 1")
+	}
+
+	results, err := client.MeasureLatency(cfg.Elasticsearch, samples)
+	if err != nil {
+		return fmt.Errorf("failed to measure latency: %w", err)
+	}
+
+	headers := []string{"Address", "Samples", "Errors", "Connect Avg", "TLS Avg", "Round Trip Min", "Round Trip Avg", "Round Trip Max", "Status"}
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		_, connectAvg, _ := r.Stats(func(s client.LatencySample) time.Duration { return s.Connect })
+		_, tlsAvg, _ := r.Stats(func(s client.LatencySample) time.Duration { return s.TLS })
+		rtMin, rtAvg, rtMax := r.Stats(func(s client.LatencySample) time.Duration { return s.RoundTrip })
+
+		rows = append(rows, []string{
+			r.Address,
+			fmt.Sprintf("%d", len(r.Samples)),
+			fmt.Sprintf("%d", r.Errors()),
+			formatLatency(connectAvg),
+			formatLatency(tlsAvg),
+			formatLatency(rtMin),
+			formatLatency(rtAvg),
+			formatLatency(rtMax),
+			r.LastStatus(),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(headers, rows)
+}
+
+// runProbe reports the latency of each step of a write and read probe, returning a
+// client.ProbeError when a step fails
+func runProbe(cfg *config.Config) error {
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	index := probeIndex
+	if index == "" {
+		index = client.DefaultProbeIndex(cfg)
+	}
+	steps := esClient.ProbeAvailability(index)
+
+	headers := []string{"Step", "Latency", "Status"}
+	rows := make([][]string, 0, len(steps)+1)
+	var total time.Duration
+	failed := false
+	for _, step := range steps {
+		switch {
+		case step.Skipped:
+			rows = append(rows, []string{step.Step, "-", "skipped"})
+			continue
+		case step.Err != nil:
+			failed = true
+			rows = append(rows, []string{step.Step, formatLatency(step.Duration), step.Err.Error()})
+		default:
+			rows = append(rows, []string{step.Step, formatLatency(step.Duration), "ok"})
+		}
+		total += step.Duration
+	}
+	status := "ok"
+	if failed {
+		status = "failed"
+	}
+	rows = append(rows, []string{"total", formatLatency(total), status})
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(headers, rows); err != nil {
+		return err
+	}
+	return client.NewProbeError(steps)
+}
+
+// formatLatency renders a duration in milliseconds
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
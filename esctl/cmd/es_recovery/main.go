@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Throttle options
+	maxBytesPerSec        string
+	maxFileChunks         string
+	maxOperations         string
+	concurrentRecoveries  string
+	concurrentIncoming    string
+	concurrentOutgoing    string
+	initialPrimaries      string
+	transient             bool
+	resetRecoverySettings bool
+
+	// Output
+	outputFormat string
+)
+
+// throttleFlags maps throttle flags to the cluster setting they control
+var throttleFlags = []struct {
+	flag    string
+	setting string
+	value   *string
+}{
+	{"max-bytes-per-sec", "indices.recovery.max_bytes_per_sec", &maxBytesPerSec},
+	{"max-file-chunks", "indices.recovery.max_concurrent_file_chunks", &maxFileChunks},
+	{"max-operations", "indices.recovery.max_concurrent_operations", &maxOperations},
+	{"concurrent-recoveries", "cluster.routing.allocation.node_concurrent_recoveries", &concurrentRecoveries},
+	{"concurrent-incoming", "cluster.routing.allocation.node_concurrent_incoming_recoveries", &concurrentIncoming},
+	{"concurrent-outgoing", "cluster.routing.allocation.node_concurrent_outgoing_recoveries", &concurrentOutgoing},
+	{"initial-primaries", "cluster.routing.allocation.node_initial_primaries_recoveries", &initialPrimaries},
+}
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_recovery",
+		Short: "View and adjust shard recovery throttling",
+		Long: `View and adjust the settings that throttle shard recoveries.
+
+Recovery speed is controlled by the indices.recovery.max_bytes_per_sec setting together with the
+per node concurrency limits for incoming, outgoing and initial primary recoveries. These settings
+are typically raised during large restores or node replacements and lowered again afterwards.
+
+This command shows the effective value of each setting (and whether it is transient, persistent
+or a default) and updates them together in a single cluster settings request.
+
+Example usage:
+  es_recovery show
+  es_recovery throttle --max-bytes-per-sec=200mb
+  es_recovery throttle --max-bytes-per-sec=500mb --concurrent-recoveries=4
+  es_recovery throttle --reset`,
+		Example: `es_recovery show
+es_recovery throttle --max-bytes-per-sec=200mb
+es_recovery throttle --max-bytes-per-sec=500mb --concurrent-recoveries=4
+es_recovery throttle --reset`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Show subcommand
+	var showCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show recovery throttling settings",
+		Long:  `This command will display the effective value and source of every recovery throttling setting.`,
+		RunE:  runShow,
+	}
+
+	// Throttle subcommand
+	var throttleCmd = &cobra.Command{
+		Use:   "throttle",
+		Short: "Adjust recovery throttling settings",
+		Long: `This command will update the given recovery throttling settings together. Settings are stored as
+persistent settings unless --transient is given. Use --reset to return every recovery setting to
+its default.`,
+		RunE: runThrottle,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Throttle flags
+	throttleCmd.Flags().StringVar(&maxBytesPerSec, "max-bytes-per-sec", "", "Maximum recovery bandwidth per node (e.g. 40mb, 200mb)")
+	throttleCmd.Flags().StringVar(&maxFileChunks, "max-file-chunks", "", "Maximum number of file chunks sent in parallel per recovery")
+	throttleCmd.Flags().StringVar(&maxOperations, "max-operations", "", "Maximum number of operations sent in parallel per recovery")
+	throttleCmd.Flags().StringVar(&concurrentRecoveries, "concurrent-recoveries", "", "Concurrent incoming and outgoing recoveries per node")
+	throttleCmd.Flags().StringVar(&concurrentIncoming, "concurrent-incoming", "", "Concurrent incoming recoveries per node")
+	throttleCmd.Flags().StringVar(&concurrentOutgoing, "concurrent-outgoing", "", "Concurrent outgoing recoveries per node")
+	throttleCmd.Flags().StringVar(&initialPrimaries, "initial-primaries", "", "Concurrent initial primary recoveries per node")
+	throttleCmd.Flags().BoolVar(&transient, "transient", false, "Store the settings as transient instead of persistent")
+	throttleCmd.Flags().BoolVar(&resetRecoverySettings, "reset", false, "Reset all recovery settings to their defaults")
+
+	// Add subcommands
+	rootCmd.AddCommand(showCmd, throttleCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runShow handles the show command
+func runShow(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Get recovery settings
+	settings, err := esClient.GetRecoverySettings()
+	if err != nil {
+		return fmt.Errorf("failed to get recovery settings: %w", err)
+	}
+
+	// Prepare table data
+	header := []string{"Setting", "Value", "Source"}
+	rows := make([][]string, 0, len(settings))
+	for _, setting := range settings {
+		rows = append(rows, []string{setting.Name, setting.Value, setting.Source})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runThrottle handles the throttle command
+func runThrottle(cmd *cobra.Command, args []string) error {
+	// Collect the settings to change
+	values := make(map[string]*string)
+	for _, tf := range throttleFlags {
+		if resetRecoverySettings {
+			values[tf.setting] = nil
+			continue
+		}
+		if cmd.Flags().Changed(tf.flag) {
+			values[tf.setting] = tf.value
+		}
+	}
+
+	if len(values) == 0 {
+		return fmt.Errorf("no recovery settings given; use --max-bytes-per-sec, a concurrency flag or --reset")
+	}
+
+	// Validate the bandwidth value before sending it to the cluster
+	if !resetRecoverySettings && cmd.Flags().Changed("max-bytes-per-sec") {
+		if _, err := client.ParseByteSize(maxBytesPerSec); err != nil {
+			return fmt.Errorf("invalid --max-bytes-per-sec: %w", err)
+		}
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	settingType := "persistent"
+	if transient {
+		settingType = "transient"
+	}
+
+	// Apply settings
+	if err := esClient.SetRecoverySettings(settingType, values); err != nil {
+		return fmt.Errorf("failed to update recovery settings: %w", err)
+	}
+
+	if resetRecoverySettings {
+		fmt.Printf("Recovery settings reset to defaults (%s)\n", settingType)
+	} else {
+		fmt.Printf("Recovery settings updated (%s)\n", settingType)
+	}
+
+	return runShow(cmd, args)
+}
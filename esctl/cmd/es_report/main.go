@@ -0,0 +1,633 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Limits options
+	thresholdPercent float64
+	maxIndices       int64
+	flaggedOnly      bool
+
+	// Rollover options
+	problemsOnly bool
+
+	// User activity options
+	activityPeriod string
+	activityFrom   string
+	activityTo     string
+	auditIndex     string
+	topUsers       int
+
+	// Shard size options
+	shardPattern    string
+	maxShardSize    string
+	minShardSize    string
+	targetShardSize string
+
+	// Traffic options
+	trafficPeriod  string
+	trafficPattern string
+	topIndices     int
+
+	// Coverage options
+	coveragePattern string
+	includeHidden   bool
+	showAll         bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_report",
+		Short: "Generate Elasticsearch cluster reports",
+		Long: `Generate reports that combine several Elasticsearch APIs to answer operational questions.
+
+Available reports:
+- limits: Resources that are close to or over their cluster limits
+- rollover: Write indices compared against their ILM rollover conditions
+- user-activity: Security audit events per user and type of action
+- shard-sizes: Indices with primary shards outside the recommended size range
+- traffic: Indexing and search rates per index over a sampling interval
+- coverage: Indices and data streams without a template, ILM policy or replicas
+
+Example usage:
+  es_report limits
+  es_report limits --threshold=20 --flagged
+  es_report rollover --problems
+  es_report user-activity --last=7d --redact
+  es_report shard-sizes --max-size=50gb --min-size=100mb
+  es_report traffic --last=15m
+  es_report coverage`,
+		Example: `es_report limits
+es_report limits --threshold=20 --flagged
+es_report rollover --problems
+es_report user-activity --last=7d --redact
+es_report user-activity --from=2024-05-01T00:00 --to=2024-05-08T00:00
+es_report shard-sizes --pattern="logs-*"
+es_report traffic --last=1m --top=10
+es_report coverage --pattern="logs-*" --all`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Limits subcommand
+	var limitsCmd = &cobra.Command{
+		Use:   "limits",
+		Short: "Report resources close to their limits",
+		Long: `Compare actual resource usage against cluster guardrails:
+- Shards on each node versus cluster.max_shards_per_node
+- Total shards versus cluster.max_shards_per_node times the number of data nodes
+- Mapped fields per index versus index.mapping.total_fields.limit
+- Index count versus a recommended upper bound for cluster metadata (--max-indices)
+
+Resources within --threshold percent of their limit are flagged WARNING, those at or over the
+limit are flagged EXCEEDED.`,
+		RunE: runLimits,
+	}
+
+	// Rollover subcommand
+	var rolloverCmd = &cobra.Command{
+		Use:   "rollover",
+		Short: "Report rollover readiness of write indices",
+		Long: `List the write indices behind aliases and data streams with their age, document count and
+primary size against the rollover conditions of their ILM policy.
+
+Statuses:
+- OVERDUE: the index already exceeds a rollover condition, so ILM is stuck or slow
+- ILM ERROR: ILM has failed a step for the index
+- NO ROLLOVER: the index is managed by a policy without a hot phase rollover action
+- NOT MANAGED: the index is not managed by ILM
+- OK: no condition has been reached yet`,
+		RunE: runRollover,
+	}
+
+	// User activity subcommand
+	var userActivityCmd = &cobra.Command{
+		Use:   "user-activity",
+		Short: "Report security audit events per user",
+		Long: `Count the security audit events of each user over a period, by type of action:
+- Searches: granted read actions (indices:data/read/*)
+- Writes: granted write actions (indices:data/write/*)
+- Admin: granted index and cluster administration actions (indices:admin/*, cluster:admin/*)
+- Denied: denied actions, denied run as and failed authentications
+
+Elasticsearch writes its audit log to files only, so the report needs audit logging enabled
+(xpack.security.audit.enabled) and the audit log shipped to the cluster by the Elasticsearch
+integration of Elastic Agent or the Filebeat elasticsearch module.
+
+The period is --last counting back from now, or --from to --to, which take absolute times such
+as 2024-05-01T00:00, date math such as now-30d, or time values such as 7d meaning that long ago.
+
+Use --redact to replace the usernames with stable tokens before sharing the report.`,
+		RunE: runUserActivity,
+	}
+
+	// Shard sizes subcommand
+	var shardSizesCmd = &cobra.Command{
+		Use:   "shard-sizes",
+		Short: "Report indices with shards outside the recommended size range",
+		Long: `List the indices with primary shards larger than --max-size or smaller than --min-size,
+grouped by index, with the primary shard count that would bring each shard to --target-size or
+below. Use it to plan resharding with shrink, split or a reindex, or to change the number of
+shards in the template of a data stream.
+
+Statuses:
+- TOO LARGE: primary shards are over --max-size, split or use more shards
+- TOO SMALL: primary shards are under --min-size, shrink or use fewer shards
+- UNEVEN: the index has both, usually a skewed routing key
+
+Indices with a single small shard are left out, they cannot be resharded to fewer shards.`,
+		RunE: runShardSizes,
+	}
+
+	// Traffic subcommand
+	var trafficCmd = &cobra.Command{
+		Use:   "traffic",
+		Short: "Report indexing and search rates per index",
+		Long: `Sample the index stats, wait for --last, sample them again and list the indices with the
+highest indexing and search rates over the interval, to see what is loading the cluster right
+now without external monitoring.
+
+Index/s counts documents indexed into primary shards, so replicas do not double it. Search/s
+counts search queries on any shard copy. Rates can be understated for indices whose shards moved
+during the interval, as their counters restart on the new node.`,
+		RunE: runTraffic,
+	}
+
+	// Coverage subcommand
+	var coverageCmd = &cobra.Command{
+		Use:   "coverage",
+		Short: "Report indices and data streams that are not managed",
+		Long: `List the indices and data streams that match no index template, have no ILM policy or data
+stream lifecycle, or have no replicas. These unmanaged indices are the ones that grow without
+bound, lose data with a node or come back with the wrong mappings after an incident.
+
+Problems:
+- NO TEMPLATE: no composable or legacy index template matches the name
+- NO ILM: no ILM policy and no data stream lifecycle manages it
+- NO REPLICAS: number_of_replicas is 0, so losing a node loses data
+
+Backing indices are reported through their data stream, whose replicas are those of its write
+index. Searchable snapshots are not flagged for having no replicas. Hidden and system indices are
+left out unless --include-hidden is given.`,
+		RunE: runCoverage,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Limits command flags
+	limitsCmd.Flags().Float64Var(&thresholdPercent, "threshold", 10, "Flag resources within this percentage of their limit")
+	limitsCmd.Flags().Int64Var(&maxIndices, "max-indices", 10000, "Recommended maximum number of indices for the cluster metadata size")
+	limitsCmd.Flags().BoolVar(&flaggedOnly, "flagged", false, "Only show resources flagged WARNING or EXCEEDED")
+
+	// Rollover command flags
+	rolloverCmd.Flags().BoolVar(&problemsOnly, "problems", false, "Only show write indices that are not OK")
+
+	// User activity command flags
+	userActivityCmd.Flags().StringVar(&activityPeriod, "last", "7d", "Period to report on, counting back from now (e.g. 24h, 7d, 1h30m)")
+	userActivityCmd.Flags().StringVar(&activityFrom, "from", "", "Start of the period instead of --last (e.g. 2024-05-01T00:00, now-30d)")
+	userActivityCmd.Flags().StringVar(&activityTo, "to", "", "End of the period (default is now)")
+	userActivityCmd.Flags().StringVar(&auditIndex, "audit-index", client.DefaultAuditIndexPattern, "Index pattern of the shipped audit log")
+	userActivityCmd.Flags().IntVar(&topUsers, "top", 50, "Number of most active users to list")
+
+	// Shard sizes flags
+	shardSizesCmd.Flags().StringVar(&shardPattern, "pattern", "*", "Index pattern to check")
+	shardSizesCmd.Flags().StringVar(&maxShardSize, "max-size", "50gb", "Flag primary shards larger than this size")
+	shardSizesCmd.Flags().StringVar(&minShardSize, "min-size", "100mb", "Flag primary shards smaller than this size")
+	shardSizesCmd.Flags().StringVar(&targetShardSize, "target-size", "30gb", "Shard size the suggested shard counts aim for")
+
+	// Traffic flags
+	trafficCmd.Flags().StringVar(&trafficPeriod, "last", "1m", "Interval between the two samples (e.g. 30s, 5m, 15m)")
+	trafficCmd.Flags().StringVar(&trafficPattern, "pattern", "*", "Index pattern to sample")
+	trafficCmd.Flags().IntVar(&topIndices, "top", 20, "Number of busiest indices to list (0 for all)")
+
+	// Coverage flags
+	coverageCmd.Flags().StringVar(&coveragePattern, "pattern", "*", "Index pattern to check")
+	coverageCmd.Flags().BoolVar(&includeHidden, "include-hidden", false, "Include hidden and system indices and data streams")
+	coverageCmd.Flags().BoolVar(&showAll, "all", false, "List every index and data stream, not only those with problems")
+
+	// Add subcommands
+	rootCmd.AddCommand(limitsCmd, rolloverCmd, userActivityCmd, shardSizesCmd, trafficCmd, coverageCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runLimits handles the limits command
+func runLimits(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Collect limit checks
+	checks, err := esClient.GetLimitChecks(maxIndices)
+	if err != nil {
+		return fmt.Errorf("failed to check limits: %w", err)
+	}
+
+	// Prepare table data
+	header := []string{"Resource", "Name", "Actual", "Limit", "Used %", "Status"}
+	rows := [][]string{}
+	flagged := 0
+
+	for _, check := range checks {
+		status := check.Status(thresholdPercent)
+		if status != "OK" {
+			flagged++
+		} else if flaggedOnly {
+			continue
+		}
+
+		rows = append(rows, []string{
+			check.Resource,
+			check.Name,
+			fmt.Sprintf("%d", check.Actual),
+			fmt.Sprintf("%d", check.Limit),
+			fmt.Sprintf("%.1f", check.UsedPercent()),
+			status,
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf("No resources within %.0f%% of their limit\n", thresholdPercent)
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if flagged > 0 {
+		fmt.Printf("\n%d resources within %.0f%% of their limit\n", flagged, thresholdPercent)
+	}
+
+	return nil
+}
+
+// runRollover handles the rollover command
+func runRollover(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Collect rollover status
+	statuses, err := esClient.GetRolloverStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get rollover status: %w", err)
+	}
+
+	// Prepare table data
+	header := []string{"Target", "Type", "Write Index", "Policy", "Phase", "Age", "Docs", "Primary Size", "Conditions", "Status", "Reason"}
+	rows := [][]string{}
+
+	for _, s := range statuses {
+		if problemsOnly && s.Status == "OK" {
+			continue
+		}
+
+		rows = append(rows, []string{
+			s.Target,
+			s.TargetType,
+			s.Index,
+			valueOrDash(s.Policy),
+			valueOrDash(s.Phase),
+			formatAge(s.Age),
+			fmt.Sprintf("%d", s.Docs),
+			client.ByteCountSI(s.Size),
+			s.Conditions.String(),
+			s.Status,
+			valueOrDash(s.Reason),
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No write indices found")
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runUserActivity handles the user-activity command
+func runUserActivity(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	period, err := client.ParseTimeRange(activityPeriod, activityFrom, activityTo, now)
+	if err != nil {
+		return err
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	activity, total, err := esClient.GetUserActivity(auditIndex, period, topUsers)
+	if err != nil {
+		return fmt.Errorf("failed to get user activity: %w", err)
+	}
+	if total == 0 {
+		fmt.Printf("No audit events found in '%s' from %s. Check that audit logging is enabled and shipped to the cluster.\n", auditIndex, period)
+		return nil
+	}
+
+	// Prepare table data
+	header := []string{"User", "Events", "Searches", "Writes", "Admin", "Denied", "Last Seen"}
+	rows := make([][]string, 0, len(activity))
+	for _, a := range activity {
+		rows = append(rows, []string{
+			a.User,
+			fmt.Sprintf("%d", a.Events),
+			fmt.Sprintf("%d", a.Searches),
+			fmt.Sprintf("%d", a.Writes),
+			fmt.Sprintf("%d", a.Admin),
+			fmt.Sprintf("%d", a.Denied),
+			formatAge(now.Sub(a.LastSeen)) + " ago",
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runShardSizes handles the shard-sizes command
+func runShardSizes(cmd *cobra.Command, args []string) error {
+	var thresholds client.ShardSizeThresholds
+	for _, size := range []struct {
+		flag  string
+		value string
+		bytes *int64
+	}{
+		{"--max-size", maxShardSize, &thresholds.Max},
+		{"--min-size", minShardSize, &thresholds.Min},
+		{"--target-size", targetShardSize, &thresholds.Target},
+	} {
+		bytes, err := client.ParseByteSize(size.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", size.flag, err)
+		}
+		*size.bytes = bytes
+	}
+	if thresholds.Min >= thresholds.Max {
+		return fmt.Errorf("--min-size must be smaller than --max-size")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	indices, err := esClient.GetShardSizeOutliers(shardPattern, thresholds)
+	if err != nil {
+		return fmt.Errorf("failed to get shard sizes: %w", err)
+	}
+	if len(indices) == 0 {
+		fmt.Printf("No primary shards outside %s to %s\n", minShardSize, maxShardSize)
+		return nil
+	}
+
+	// Prepare table data
+	header := []string{"Index", "Primaries", "Primary Size", "Smallest", "Largest", "Too Large", "Too Small", "Suggested Primaries", "Status"}
+	rows := make([][]string, 0, len(indices))
+	for _, index := range indices {
+		rows = append(rows, []string{
+			index.Index,
+			fmt.Sprintf("%d", index.Primaries),
+			client.ByteCountSI(index.TotalBytes),
+			client.ByteCountSI(index.Smallest),
+			client.ByteCountSI(index.Largest),
+			fmt.Sprintf("%d", index.TooLarge),
+			fmt.Sprintf("%d", index.TooSmall),
+			fmt.Sprintf("%d", index.Suggested),
+			index.Status(),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runTraffic handles the traffic command
+func runTraffic(cmd *cobra.Command, args []string) error {
+	interval, err := client.ParseTimeValue(trafficPeriod)
+	if err != nil {
+		return fmt.Errorf("invalid --last: %w", err)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("--last must be greater than zero")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Sampling index stats over %s...\n", trafficPeriod)
+	traffic, err := esClient.GetIndexTraffic(trafficPattern, interval)
+	if err != nil {
+		return fmt.Errorf("failed to get index traffic: %w", err)
+	}
+	if len(traffic) == 0 {
+		fmt.Printf("No indexing or search activity on '%s' in the last %s\n", trafficPattern, trafficPeriod)
+		return nil
+	}
+	if topIndices > 0 && len(traffic) > topIndices {
+		traffic = traffic[:topIndices]
+	}
+
+	// Prepare table data
+	header := []string{"Index", "Index/s", "Search/s", "Indexed", "Searches"}
+	rows := make([][]string, 0, len(traffic))
+	for _, t := range traffic {
+		rows = append(rows, []string{
+			t.Index,
+			fmt.Sprintf("%.1f", t.IndexRate),
+			fmt.Sprintf("%.1f", t.SearchRate),
+			fmt.Sprintf("%d", t.Indexed),
+			fmt.Sprintf("%d", t.Searches),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runCoverage handles the coverage command
+func runCoverage(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	coverage, err := esClient.GetIndexCoverage(coveragePattern, includeHidden, showAll)
+	if err != nil {
+		return fmt.Errorf("failed to get index coverage: %w", err)
+	}
+
+	// Prepare table data
+	header := []string{"Name", "Type", "Template", "Lifecycle", "Replicas", "Problems"}
+	rows := make([][]string, 0, len(coverage))
+	counts := make(map[string]int)
+	flagged := 0
+	for _, c := range coverage {
+		replicas := "-"
+		if c.Replicas >= 0 {
+			replicas = fmt.Sprintf("%d", c.Replicas)
+		}
+		for _, problem := range c.Problems {
+			counts[problem]++
+		}
+		if len(c.Problems) > 0 {
+			flagged++
+		}
+		rows = append(rows, []string{
+			c.Name,
+			c.Type,
+			valueOrDash(c.Template),
+			valueOrDash(c.Lifecycle),
+			replicas,
+			valueOrDash(strings.Join(c.Problems, ", ")),
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf("All indices and data streams matching '%s' have a template, a lifecycle and replicas\n", coveragePattern)
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Printf("\n%d indices and data streams with problems: %d without a template, %d without a lifecycle, %d without replicas\n",
+		flagged, counts[client.CoverageNoTemplate], counts[client.CoverageNoILM], counts[client.CoverageNoReplicas])
+	return nil
+}
+
+// formatAge formats a duration in days and hours
+func formatAge(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd%dh", days, hours)
+	}
+	return fmt.Sprintf("%dh%dm", hours, int(d.Minutes())%60)
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
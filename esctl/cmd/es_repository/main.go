@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -12,6 +14,28 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// typedSetting maps a type specific flag to the repository setting it populates
+type typedSetting struct {
+	flag     string
+	repoType string
+	setting  string
+	value    *string
+}
+
+// typedSettings lists the type specific register flags
+var typedSettings = []typedSetting{
+	{flag: "fs-location", repoType: "fs", setting: "location", value: &fsLocation},
+	{flag: "s3-bucket", repoType: "s3", setting: "bucket", value: &s3Bucket},
+	{flag: "s3-base-path", repoType: "s3", setting: "base_path", value: &s3BasePath},
+	{flag: "s3-client", repoType: "s3", setting: "client", value: &s3Client},
+	{flag: "azure-container", repoType: "azure", setting: "container", value: &azureContainer},
+	{flag: "azure-base-path", repoType: "azure", setting: "base_path", value: &azureBasePath},
+	{flag: "azure-client", repoType: "azure", setting: "client", value: &azureClient},
+	{flag: "gcs-bucket", repoType: "gcs", setting: "bucket", value: &gcsBucket},
+	{flag: "gcs-base-path", repoType: "gcs", setting: "base_path", value: &gcsBasePath},
+	{flag: "gcs-client", repoType: "gcs", setting: "client", value: &gcsClient},
+}
+
 // Command line flags
 var (
 	outputStyle string
@@ -31,6 +55,24 @@ var (
 	repositoryType string
 	settings       map[string]string
 
+	// Typed repository settings
+	fsLocation     string
+	s3Bucket       string
+	s3BasePath     string
+	s3Client       string
+	azureContainer string
+	azureBasePath  string
+	azureClient    string
+	gcsBucket      string
+	gcsBasePath    string
+	gcsClient      string
+
+	// Register options
+	skipClientCheck bool
+
+	// List options
+	showStats bool
+
 	// Output
 	outputFormat string
 )
@@ -60,10 +102,14 @@ Example usage:
   es_repository list
   es_repository verify --name=my_backups
   es_repository register --name=my_backups --type=fs --settings=location=/backups
+  es_repository register --name=s3_backups --type=s3 --s3-bucket=my-bucket --s3-base-path=prod
   es_repository remove --name=old_backups`,
 		Example:          `es_repository list
+es_repository list --stats
 es_repository verify --name=my_backups
 es_repository register --name=my_backups --type=fs --settings=location=/backups
+es_repository register --name=s3_backups --type=s3 --s3-bucket=my-bucket --s3-base-path=prod
+es_repository register --name=s3_backups --type=s3 --s3-client=prod-backups
 es_repository remove --name=old_backups`,
 		PersistentPreRunE: initConfig,
 	}
@@ -72,6 +118,7 @@ es_repository remove --name=old_backups`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -82,16 +129,31 @@ es_repository remove --name=old_backups`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
 
 	// Create list command
 	var listCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List configured snapshot repositories",
-		Long:  `This command will list all the snapshot repositories on the cluster.`,
-		RunE:  runList,
+		Long: `This command will list all the snapshot repositories on the cluster.
+
+With --stats, each repository also shows its snapshot count, the oldest and newest snapshot
+dates, and the total data size (the sum of each snapshot's incremental size). Gathering these
+stats reads the status of every snapshot and can be slow on large repositories.`,
+		RunE: runList,
 	}
+	listCmd.Flags().BoolVar(&showStats, "stats", false, "Include snapshot count, oldest/newest snapshot and total size per repository")
 
 	// Create verify command
 	var verifyCmd = &cobra.Command{
@@ -107,14 +169,38 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	var registerCmd = &cobra.Command{
 		Use:   "register",
 		Short: "Register a snapshot repository",
-		Long:  `This command will register a new snapshot repository.`,
-		RunE:  runRegister,
+		Long: `This command will register a new snapshot repository.
+
+Settings can be given as key value pairs with --settings, or with the type specific flags
+(--fs-location, --s3-bucket, --azure-container, --gcs-bucket, ...). Type specific flags take
+precedence over --settings. Required settings for the chosen type are checked before the
+repository is registered.
+
+The client flags (--s3-client, --azure-client, --gcs-client) also accept the name of a client
+profile from the repository.clients section of the config file. A profile names the client
+configured on the nodes and supplies repository settings such as the bucket, which flags and
+--settings override. Before registering, the nodes info API is used to check that every node
+has settings for the client (e.g. s3.client.<name>.endpoint) and, if not, the keystore and
+elasticsearch.yml changes needed are printed. Credentials held only in the keystore are not
+visible through the API; use --skip-client-check for clients configured that way.`,
+		RunE: runRegister,
 	}
 	registerCmd.Flags().StringVarP(&repositoryName, "repository", "r", "", "Snapshot repository name to register (required)")
 	registerCmd.MarkFlagRequired("repository")
 	registerCmd.Flags().StringVarP(&repositoryType, "type", "t", "", "Type of snapshot repository to register (required)")
 	registerCmd.MarkFlagRequired("type")
 	registerCmd.Flags().StringToStringVarP(&settings, "settings", "s", map[string]string{}, "Settings of the repository to register in key value pairs, i.e. location=/backups,compress=true")
+	registerCmd.Flags().StringVar(&fsLocation, "fs-location", "", "Shared filesystem location (fs repositories)")
+	registerCmd.Flags().StringVar(&s3Bucket, "s3-bucket", "", "S3 bucket name (s3 repositories)")
+	registerCmd.Flags().StringVar(&s3BasePath, "s3-base-path", "", "Path within the S3 bucket (s3 repositories)")
+	registerCmd.Flags().StringVar(&s3Client, "s3-client", "", "Name of the S3 client configured on the nodes (s3 repositories)")
+	registerCmd.Flags().StringVar(&azureContainer, "azure-container", "", "Azure container name (azure repositories)")
+	registerCmd.Flags().StringVar(&azureBasePath, "azure-base-path", "", "Path within the Azure container (azure repositories)")
+	registerCmd.Flags().StringVar(&azureClient, "azure-client", "", "Name of the Azure client configured on the nodes (azure repositories)")
+	registerCmd.Flags().StringVar(&gcsBucket, "gcs-bucket", "", "GCS bucket name (gcs repositories)")
+	registerCmd.Flags().StringVar(&gcsBasePath, "gcs-base-path", "", "Path within the GCS bucket (gcs repositories)")
+	registerCmd.Flags().StringVar(&gcsClient, "gcs-client", "", "Name of the GCS client configured on the nodes (gcs repositories)")
+	registerCmd.Flags().BoolVar(&skipClientCheck, "skip-client-check", false, "Do not check that the repository client is configured on every node")
 
 	// Create remove command
 	var removeCmd = &cobra.Command{
@@ -133,7 +219,8 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	rootCmd.AddCommand(removeCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -164,9 +251,20 @@ func runList(cmd *cobra.Command, args []string) error {
 
 	// Prepare data for output
 	header := []string{"Name", "Type", "Settings"}
+	if showStats {
+		header = append(header, "Snapshots", "Oldest", "Newest", "Total Size")
+	}
 	var rows [][]string
 
-	for name, repo := range repos {
+	// Sort repository names for stable output
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		repo := repos[name]
 		// Format settings as a string
 		var settingsStrings []string
 		for k, v := range repo.Settings {
@@ -178,6 +276,27 @@ func runList(cmd *cobra.Command, args []string) error {
 			repo.Type,
 			strings.Join(settingsStrings, "\n"),
 		}
+
+		if showStats {
+			usage, err := c.GetRepositoryUsage(name)
+			if err != nil {
+				return fmt.Errorf("error getting usage for repository %s: %w", name, err)
+			}
+
+			oldest, newest := "-", "-"
+			if usage.SnapshotCount > 0 {
+				oldest = usage.Oldest.UTC().Format(time.RFC3339)
+				newest = usage.Newest.UTC().Format(time.RFC3339)
+			}
+
+			row = append(row,
+				fmt.Sprintf("%d", usage.SnapshotCount),
+				oldest,
+				newest,
+				client.ByteCountSI(usage.SizeInBytes),
+			)
+		}
+
 		rows = append(rows, row)
 	}
 
@@ -237,6 +356,36 @@ func runRegister(cmd *cobra.Command, args []string) error {
 		settingsInterface[k] = v
 	}
 
+	// Apply type specific flags, rejecting flags that belong to another repository type
+	for _, ts := range typedSettings {
+		if !cmd.Flags().Changed(ts.flag) {
+			continue
+		}
+		if ts.repoType != repositoryType {
+			return fmt.Errorf("flag --%s can only be used with --type=%s", ts.flag, ts.repoType)
+		}
+		settingsInterface[ts.setting] = *ts.value
+	}
+
+	// Resolve a client profile from the config file
+	if name, ok := settingsInterface["client"].(string); ok && name != "" {
+		if err := applyClientProfile(cfg, name, settingsInterface); err != nil {
+			return err
+		}
+	}
+
+	// Validate required settings before calling the cluster
+	if err := client.ValidateRepositorySettings(repositoryType, settingsInterface); err != nil {
+		return err
+	}
+
+	// Check the client exists on every node, the repository cannot be verified otherwise
+	if name, ok := settingsInterface["client"].(string); ok && !skipClientCheck {
+		if err := c.CheckRepositoryClient(repositoryType, name); err != nil {
+			return err
+		}
+	}
+
 	// Create repository
 	err = c.CreateRepository(repositoryName, repositoryType, settingsInterface, true)
 	if err != nil {
@@ -247,6 +396,36 @@ func runRegister(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// applyClientProfile replaces a client profile name with the client it names on the nodes and
+// adds the profile's settings that were not given on the command line
+func applyClientProfile(cfg *config.Config, name string, settings map[string]interface{}) error {
+	profile, ok := cfg.Repository.Clients[name]
+	if !ok {
+		// Viper lower cases map keys read from the config file
+		profile, ok = cfg.Repository.Clients[strings.ToLower(name)]
+	}
+	if !ok {
+		return nil
+	}
+
+	if profile.Type != "" && profile.Type != repositoryType {
+		return fmt.Errorf("client profile '%s' is for %s repositories, not %s", name, profile.Type, repositoryType)
+	}
+
+	for k, v := range profile.Settings {
+		if _, set := settings[k]; !set {
+			settings[k] = v
+		}
+	}
+
+	settings["client"] = name
+	if profile.Client != "" {
+		settings["client"] = profile.Client
+	}
+	fmt.Fprintf(os.Stderr, "Using client profile '%s' (%s client '%s')\n", name, repositoryType, settings["client"])
+	return nil
+}
+
 // runRemove removes a repository
 func runRemove(cmd *cobra.Command, args []string) error {
 	// Get config from context
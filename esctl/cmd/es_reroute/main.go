@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Reroute options
+	indexName      string
+	shardNumber    int
+	nodeName       string
+	fromNode       string
+	toNode         string
+	allowPrimary   bool
+	acceptDataLoss bool
+	dryRun         bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_reroute",
+		Short: "Move, cancel and force-allocate shards by hand",
+		Long: `Change shard allocation by hand with the cluster reroute API: move a shard copy between nodes,
+cancel a recovery or relocation, or force a primary onto a node when no valid copy is left.
+
+Every command is sent with explain, so the decision of each allocation decider is shown. A
+command a decider refuses is not applied and the exit code is 1. With --dry-run the command is
+only simulated, and the copies of the shard are shown where they would end up.
+
+Allocating a stale or empty primary loses data: a stale primary is missing the writes since it
+was in sync, an empty primary holds no documents at all. Both need --accept-data-loss.
+
+Example usage:
+  es_reroute move --index logs-2024.06 --shard 0 --from-node node-1 --to-node node-2 --dry-run
+  es_reroute cancel --index logs-2024.06 --shard 0 --node node-2
+  es_reroute allocate-stale-primary --index logs-2024.06 --shard 3 --node node-4 --accept-data-loss`,
+		Example: `es_reroute move --index logs-2024.06 --shard 0 --from-node node-1 --to-node node-2 --dry-run
+es_reroute move --index logs-2024.06 --shard 0 --from-node node-1 --to-node node-2
+es_reroute cancel --index logs-2024.06 --shard 0 --node node-2
+es_reroute cancel --index logs-2024.06 --shard 0 --node node-2 --allow-primary
+es_reroute allocate-stale-primary --index logs-2024.06 --shard 3 --node node-4 --accept-data-loss --dry-run
+es_reroute allocate-empty-primary --index logs-2024.06 --shard 3 --node node-4 --accept-data-loss`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Move subcommand
+	var moveCmd = &cobra.Command{
+		Use:   "move",
+		Short: "Move a started shard copy to another node",
+		Long: `Move a started shard copy from one node to another. The copy keeps serving from the old node
+until the relocation completes. The balancer may move shards back unless allocation settings
+or filters keep them on the new node.`,
+		Args: cobra.NoArgs,
+		RunE: runMove,
+	}
+
+	// Cancel subcommand
+	var cancelCmd = &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel the recovery or relocation of a shard copy",
+		Long: `Cancel the allocation of a shard copy on a node: a relocation to the node is stopped and the
+copy stays where it was, a recovering replica is removed and allocated again. Cancelling a
+primary that is recovering needs --allow-primary.`,
+		Args: cobra.NoArgs,
+		RunE: runCancel,
+	}
+
+	// Allocate stale primary subcommand
+	var allocateStaleCmd = &cobra.Command{
+		Use:   "allocate-stale-primary",
+		Short: "Promote an out of date shard copy to primary",
+		Long: `Allocate a primary using a stale copy of the shard found on a node, when no in-sync copy is left.
+Writes acknowledged since the copy fell out of sync are lost, and if an in-sync copy comes back
+later it is discarded. Needs --accept-data-loss.`,
+		Args: cobra.NoArgs,
+		RunE: runAllocateStale,
+	}
+
+	// Allocate empty primary subcommand
+	var allocateEmptyCmd = &cobra.Command{
+		Use:   "allocate-empty-primary",
+		Short: "Allocate an empty primary when no copy of the shard is left",
+		Long: `Allocate an empty primary on a node, when no copy of the shard is left at all. Every document in
+the shard is lost, and any copy that comes back later is discarded. Needs --accept-data-loss.`,
+		Args: cobra.NoArgs,
+		RunE: runAllocateEmpty,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Reroute flags common to every command
+	rootCmd.PersistentFlags().StringVarP(&indexName, "index", "i", "", "Index of the shard (required)")
+	rootCmd.PersistentFlags().IntVarP(&shardNumber, "shard", "s", 0, "Shard number")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Simulate the command and show where the shard copies would end up, without applying it")
+	rootCmd.MarkPersistentFlagRequired("index")
+
+	// Move command flags
+	moveCmd.Flags().StringVar(&fromNode, "from-node", "", "Name or ID of the node holding the shard copy (required)")
+	moveCmd.Flags().StringVar(&toNode, "to-node", "", "Name or ID of the node to move the shard copy to (required)")
+	moveCmd.MarkFlagRequired("from-node")
+	moveCmd.MarkFlagRequired("to-node")
+
+	// Cancel command flags
+	cancelCmd.Flags().StringVar(&nodeName, "node", "", "Name or ID of the node the shard copy is allocated to (required)")
+	cancelCmd.Flags().BoolVar(&allowPrimary, "allow-primary", false, "Allow cancelling the allocation of a primary")
+	cancelCmd.MarkFlagRequired("node")
+
+	// Allocate primary command flags
+	for _, allocateCmd := range []*cobra.Command{allocateStaleCmd, allocateEmptyCmd} {
+		allocateCmd.Flags().StringVar(&nodeName, "node", "", "Name or ID of the node to allocate the primary to (required)")
+		allocateCmd.Flags().BoolVar(&acceptDataLoss, "accept-data-loss", false, "Acknowledge that documents in the shard may be lost")
+		allocateCmd.MarkFlagRequired("node")
+	}
+
+	// Add subcommands
+	rootCmd.AddCommand(moveCmd, cancelCmd, allocateStaleCmd, allocateEmptyCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// runMove handles the move command
+func runMove(cmd *cobra.Command, args []string) error {
+	return reroute(cmd, client.RerouteCommand{Type: client.RerouteMove, FromNode: fromNode, Node: toNode})
+}
+
+// runCancel handles the cancel command
+func runCancel(cmd *cobra.Command, args []string) error {
+	return reroute(cmd, client.RerouteCommand{Type: client.RerouteCancel, Node: nodeName, AllowPrimary: allowPrimary})
+}
+
+// runAllocateStale handles the allocate-stale-primary command
+func runAllocateStale(cmd *cobra.Command, args []string) error {
+	return reroute(cmd, client.RerouteCommand{Type: client.RerouteAllocateStalePrimary, Node: nodeName, AcceptDataLoss: acceptDataLoss})
+}
+
+// runAllocateEmpty handles the allocate-empty-primary command
+func runAllocateEmpty(cmd *cobra.Command, args []string) error {
+	return reroute(cmd, client.RerouteCommand{Type: client.RerouteAllocateEmptyPrimary, Node: nodeName, AcceptDataLoss: acceptDataLoss})
+}
+
+// reroute runs a reroute command for the shard given on the command line and shows the decider
+// decisions and the resulting copies of the shard
+func reroute(cmd *cobra.Command, command client.RerouteCommand) error {
+	if shardNumber < 0 {
+		return fmt.Errorf("--shard must not be negative")
+	}
+	command.Index = indexName
+	command.Shard = shardNumber
+
+	// Elasticsearch refuses these without the flag, fail before asking it
+	if (command.Type == client.RerouteAllocateStalePrimary || command.Type == client.RerouteAllocateEmptyPrimary) && !command.AcceptDataLoss {
+		return fmt.Errorf("%s can lose data, give --accept-data-loss to go ahead", cmd.Name())
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	result, err := esClient.Reroute(command, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to reroute shard: %w", err)
+	}
+
+	shard := fmt.Sprintf("%s[%d]", indexName, shardNumber)
+	switch {
+	case result.Rejected() && dryRun:
+		fmt.Printf("Dry run: %s of shard %s would be refused\n", cmd.Name(), shard)
+	case result.Rejected():
+		fmt.Printf("%s of shard %s was refused and not applied\n", cmd.Name(), shard)
+	case dryRun:
+		fmt.Printf("Dry run: %s of shard %s would be applied\n", cmd.Name(), shard)
+	default:
+		fmt.Printf("%s of shard %s applied\n", cmd.Name(), shard)
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Decider decisions
+	header := []string{"Decider", "Decision", "Explanation"}
+	rows := make([][]string, 0, len(result.Decisions))
+	for _, d := range result.Decisions {
+		rows = append(rows, []string{d.Decider, d.Decision, d.Explanation})
+	}
+	if len(rows) > 0 {
+		fmt.Println()
+		if err := formatter.Write(header, rows); err != nil {
+			return err
+		}
+	}
+
+	// Copies of the shard after the command
+	if len(result.Copies) > 0 {
+		header = []string{"Copy", "State", "Node", "Relocating To"}
+		rows = make([][]string, 0, len(result.Copies))
+		for _, c := range result.Copies {
+			kind := "replica"
+			if c.Primary {
+				kind = "primary"
+			}
+			rows = append(rows, []string{kind, c.State, valueOrDash(c.Node), valueOrDash(c.RelocatingNode)})
+		}
+		fmt.Println()
+		if err := formatter.Write(header, rows); err != nil {
+			return err
+		}
+	}
+
+	if result.Rejected() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// valueOrDash returns the value, or "-" when it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Rollup options
+	jobID     string
+	waitStop  bool
+	stopFirst bool
+	force     bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_rollup",
+		Short: "Manage legacy rollup jobs and plan their move to downsampling",
+		Long: `List, stop and delete legacy rollup jobs, and suggest the downsampling setup that replaces
+each one. Rollups are deprecated in favour of downsampling time series data streams with ILM.
+
+A migration usually goes: suggest, create the suggested ILM policy and index template, roll the
+data stream over so new data is a time series, then stop and delete the rollup job once the
+downsampled data covers the period needed. Deleting a job keeps its rollup index.
+
+Example usage:
+  es_rollup list
+  es_rollup suggest --job-id=sensor
+  es_rollup stop --job-id=sensor --wait
+  es_rollup delete --job-id=sensor --stop`,
+		Example: `es_rollup list
+es_rollup suggest
+es_rollup suggest --job-id=sensor
+es_rollup stop --job-id=sensor --wait
+es_rollup delete --job-id=sensor --stop --force`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// List subcommand
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List rollup jobs with their status",
+		Long: `List every rollup job with its source pattern, rollup index, date histogram interval, state
+and the documents it has processed and rollup documents it has indexed.`,
+		RunE: runList,
+	}
+
+	// Stop subcommand
+	var stopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop a rollup job",
+		RunE:  runStop,
+	}
+
+	// Delete subcommand
+	var deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a rollup job",
+		Long: `Delete a rollup job. The job must be stopped first, use --stop to stop it before deleting.
+The rollup index and the data in it are kept.`,
+		RunE: runDelete,
+	}
+
+	// Suggest subcommand
+	var suggestCmd = &cobra.Command{
+		Use:   "suggest",
+		Short: "Suggest the downsampling setup that replaces rollup jobs",
+		Long: `Print, for each rollup job or the one given with --job-id, the ILM policy and index template
+that downsample the job's source data at the same interval, as requests that can be pasted into
+Kibana Dev Tools, followed by notes on what needs a decision.
+
+The terms fields of the job become the dimensions of a time series data stream and its metric
+fields become gauges. Calendar intervals are mapped to fixed ones, and the job delay becomes the
+age at which ILM downsamples.`,
+		RunE: runSuggest,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Stop command flags
+	stopCmd.Flags().StringVar(&jobID, "job-id", "", "ID of the rollup job (required)")
+	stopCmd.Flags().BoolVar(&waitStop, "wait", false, "Wait until the job has stopped")
+	stopCmd.MarkFlagRequired("job-id")
+
+	// Delete command flags
+	deleteCmd.Flags().StringVar(&jobID, "job-id", "", "ID of the rollup job (required)")
+	deleteCmd.Flags().BoolVar(&stopFirst, "stop", false, "Stop the job and wait for it to stop before deleting it")
+	deleteCmd.Flags().BoolVar(&force, "force", false, "Delete without asking for confirmation")
+	deleteCmd.MarkFlagRequired("job-id")
+
+	// Suggest command flags
+	suggestCmd.Flags().StringVar(&jobID, "job-id", "", "ID of the rollup job (default is every job)")
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, stopCmd, deleteCmd, suggestCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// newClient loads the configuration and creates an Elasticsearch client
+func newClient(cmd *cobra.Command) (*client.Client, *config.Config, error) {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+	return esClient, cfg, nil
+}
+
+// runList handles the list command
+func runList(cmd *cobra.Command, args []string) error {
+	esClient, cfg, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := esClient.GetRollupJobs()
+	if err != nil {
+		return fmt.Errorf("failed to get rollup jobs: %w", err)
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No rollup jobs found")
+		return nil
+	}
+
+	// Prepare table data
+	header := []string{"ID", "Index Pattern", "Rollup Index", "Interval", "Terms", "Metrics", "State", "Documents", "Rollups"}
+	rows := [][]string{}
+	for _, job := range jobs {
+		interval := job.Interval
+		if job.Calendar {
+			interval += " (calendar)"
+		}
+		rows = append(rows, []string{
+			job.ID,
+			job.IndexPattern,
+			job.RollupIndex,
+			interval,
+			strings.Join(job.Terms, ", "),
+			metricsSummary(job.Metrics),
+			job.State,
+			fmt.Sprintf("%d", job.Documents),
+			fmt.Sprintf("%d", job.Rollups),
+		})
+	}
+
+	// Format and display output
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runStop handles the stop command
+func runStop(cmd *cobra.Command, args []string) error {
+	esClient, _, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := esClient.StopRollupJob(jobID, waitStop); err != nil {
+		return fmt.Errorf("failed to stop rollup job %s: %w", jobID, err)
+	}
+
+	if waitStop {
+		fmt.Printf("Rollup job '%s' stopped\n", jobID)
+	} else {
+		fmt.Printf("Rollup job '%s' is stopping\n", jobID)
+	}
+	return nil
+}
+
+// runDelete handles the delete command
+func runDelete(cmd *cobra.Command, args []string) error {
+	esClient, _, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Confirm deletion if not forced
+	if !force {
+		fmt.Printf("Are you sure you want to delete rollup job '%s'? Its rollup index is kept. [y/N] ", jobID)
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	if stopFirst {
+		if err := esClient.StopRollupJob(jobID, true); err != nil {
+			return fmt.Errorf("failed to stop rollup job %s: %w", jobID, err)
+		}
+	}
+
+	if err := esClient.DeleteRollupJob(jobID); err != nil {
+		return fmt.Errorf("failed to delete rollup job %s: %w", jobID, err)
+	}
+
+	fmt.Printf("Rollup job '%s' deleted successfully\n", jobID)
+	return nil
+}
+
+// runSuggest handles the suggest command
+func runSuggest(cmd *cobra.Command, args []string) error {
+	esClient, _, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := esClient.GetRollupJobs()
+	if err != nil {
+		return fmt.Errorf("failed to get rollup jobs: %w", err)
+	}
+
+	var selected []client.RollupJob
+	for _, job := range jobs {
+		if jobID == "" || job.ID == jobID {
+			selected = append(selected, job)
+		}
+	}
+	if len(selected) == 0 {
+		if jobID != "" {
+			return fmt.Errorf("rollup job %s not found", jobID)
+		}
+		fmt.Println("No rollup jobs found")
+		return nil
+	}
+
+	for i, job := range selected {
+		if i > 0 {
+			fmt.Println()
+		}
+		suggestion := client.SuggestDownsampling(job)
+
+		policy, err := json.MarshalIndent(suggestion.Policy, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting policy: %w", err)
+		}
+		template, err := json.MarshalIndent(suggestion.Template, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting template: %w", err)
+		}
+
+		fmt.Printf("# Rollup job %s: %s every %s into %s\n", job.ID, job.IndexPattern, job.Interval, job.RollupIndex)
+		fmt.Printf("PUT _ilm/policy/%s\n%s\n\n", suggestion.PolicyName, policy)
+		fmt.Printf("PUT _index_template/%s\n%s\n\n", suggestion.TemplateName, template)
+		fmt.Println("Notes:")
+		for _, note := range suggestion.Notes {
+			fmt.Printf("- %s\n", note)
+		}
+	}
+	return nil
+}
+
+// metricsSummary lists the metric fields of a job with the metrics kept for each
+func metricsSummary(metrics map[string][]string) string {
+	fields := make([]string, 0, len(metrics))
+	for field := range metrics {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s (%s)", field, strings.Join(metrics[field], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Sample options
+	indexPattern string
+	sampleSize   int
+	random       bool
+	fields       []string
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_sample",
+		Short: "Show a handful of documents from an index",
+		Long: `Pull a few documents from an index and pretty-print them, the quickest way to see what the
+data in an index actually looks like.
+
+By default the first documents in index order are returned, which is cheap even on large
+indices. With --random the documents are picked with random_score, so each run shows a different
+sample. With --fields only those fields are returned, and the documents are shown as a table
+with one column per field.
+
+Example usage:
+  es_sample --index=logs-nginx-*
+  es_sample --index=logs-nginx-* --size=5 --random
+  es_sample --index=logs-nginx-* --fields=@timestamp,source.ip,http.response.status_code`,
+		Example: `es_sample --index=my-index
+es_sample --index="logs-*" --size=20 --random
+es_sample --index="logs-*" --fields=@timestamp,message --format=csv`,
+		PersistentPreRunE: initConfig,
+		RunE:              run,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&indexPattern, "index", "i", "", "Index, alias, data stream or pattern to sample (required)")
+	rootCmd.Flags().IntVarP(&sampleSize, "size", "n", 10, "Number of documents to show")
+	rootCmd.Flags().BoolVar(&random, "random", false, "Pick documents at random instead of the first in index order")
+	rootCmd.Flags().StringSliceVar(&fields, "fields", nil, "Only show these fields, as a table (comma-separated)")
+	rootCmd.MarkFlagRequired("index")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if sampleSize < 1 {
+		return fmt.Errorf("--size must be at least 1")
+	}
+
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	docs, err := esClient.SampleDocuments(indexPattern, sampleSize, random, fields)
+	if err != nil {
+		return fmt.Errorf("failed to sample documents: %w", err)
+	}
+	if len(docs) == 0 {
+		fmt.Printf("No documents in '%s'\n", indexPattern)
+		return nil
+	}
+
+	// Selected fields are shown as a table, in any output format
+	if len(fields) > 0 {
+		header := append([]string{"Index", "ID"}, fields...)
+		rows := make([][]string, 0, len(docs))
+		for _, doc := range docs {
+			row := []string{doc.Index, doc.ID}
+			for _, field := range fields {
+				row = append(row, formatValue(doc.Source, field))
+			}
+			rows = append(rows, row)
+		}
+
+		formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		return nil
+	}
+
+	// Whole documents are printed as JSON
+	if cfg.Output.Format == "json" {
+		data, err := json.MarshalIndent(docs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for i, doc := range docs {
+		if i > 0 {
+			fmt.Println()
+		}
+		source, err := json.MarshalIndent(doc.Source, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format document %s: %w", doc.ID, err)
+		}
+		fmt.Printf("# %s/%s\n%s\n", doc.Index, doc.ID, source)
+	}
+	return nil
+}
+
+// formatValue formats a source field as a table cell, with objects and arrays as JSON
+func formatValue(source map[string]interface{}, field string) string {
+	value, ok := client.SourceValue(source, field)
+	if !ok || value == nil {
+		return "-"
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	// Sandbox settings
+	stackVersion string
+	name         string
+	esPort       int
+	kbPort       int
+	password     string
+	noKibana     bool
+	timeout      time.Duration
+
+	// Config file
+	configFile string
+)
+
+// contextName is the context of the config file that points at the sandbox
+const contextName = "sandbox"
+
+// encryptionKey lets Kibana store encrypted saved objects, which Fleet and alerting need
+const encryptionKey = "esctl-sandbox-encryption-key-0123456789"
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "es_sandbox",
+		Short: "Start a disposable local Elasticsearch and Kibana",
+		Long: `Start and stop a disposable single-node Elasticsearch and Kibana in Docker.
+
+The sandbox is meant for trying the commands without access to a real cluster. Security is
+enabled with a password for the elastic user, TLS is disabled, and data is lost when the
+sandbox is taken down.
+
+"up" starts the containers, waits until both are ready, adds a "sandbox" context pointing at
+them to the config file and makes it the current context. "down" removes the containers, their
+network and the context.
+
+Example usage:
+  es_sandbox up
+  es_sandbox up --version=8.15.0 --no-kibana
+  es_ping --context sandbox
+  es_sandbox down`,
+		Example: `es_sandbox up
+es_sandbox up --version=8.15.0 --es-port=9201 --kb-port=5602
+es_sandbox down`,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Sandbox flags shared by up and down
+	rootCmd.PersistentFlags().StringVar(&name, "name", "esctl-sandbox", "Name prefix for the sandbox containers and network")
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file to add the sandbox context to (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+
+	// Up command
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "Start the sandbox",
+		Long:  "Start a single-node Elasticsearch and Kibana in Docker, wait for them to be ready and add a sandbox context for them to the config file",
+		RunE:  runUp,
+	}
+	upCmd.Flags().StringVar(&stackVersion, "version", "9.0.0", "Elastic Stack version to run")
+	upCmd.Flags().IntVar(&esPort, "es-port", 9200, "Local port for Elasticsearch")
+	upCmd.Flags().IntVar(&kbPort, "kb-port", 5601, "Local port for Kibana")
+	upCmd.Flags().StringVar(&password, "password", "changeme", "Password for the elastic user")
+	upCmd.Flags().BoolVar(&noKibana, "no-kibana", false, "Start Elasticsearch only")
+	upCmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "How long to wait for each service to become ready")
+	rootCmd.AddCommand(upCmd)
+
+	// Down command
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "Remove the sandbox",
+		Long:  "Remove the sandbox containers and network, and the sandbox context of the config file. All data in the sandbox is lost.",
+		RunE:  runDown,
+	}
+	rootCmd.AddCommand(downCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// runUp starts the sandbox containers and switches the config file to the sandbox context
+func runUp(cmd *cobra.Command, args []string) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("docker is required to run the sandbox: %w", err)
+	}
+
+	esName, kbName, network := containerNames()
+
+	// Network for Kibana to reach Elasticsearch by container name
+	if err := docker("network", "create", network); err != nil && !strings.Contains(err.Error(), "already exists") {
+		return err
+	}
+
+	// Elasticsearch
+	fmt.Printf("Starting Elasticsearch %s as %s...\n", stackVersion, esName)
+	err := docker("run", "-d",
+		"--name", esName,
+		"--network", network,
+		"-p", fmt.Sprintf("%d:9200", esPort),
+		"-e", "discovery.type=single-node",
+		"-e", "xpack.security.enabled=true",
+		"-e", "xpack.security.http.ssl.enabled=false",
+		"-e", "xpack.license.self_generated.type=trial",
+		"-e", "ES_JAVA_OPTS=-Xms1g -Xmx1g",
+		"-e", "ELASTIC_PASSWORD="+password,
+		"docker.elastic.co/elasticsearch/elasticsearch:"+stackVersion,
+	)
+	if err != nil {
+		return err
+	}
+
+	cfg := &config.Config{
+		Elasticsearch: config.ElasticsearchConfig{
+			Addresses: []string{fmt.Sprintf("http://localhost:%d", esPort)},
+			Username:  "elastic",
+			Password:  password,
+		},
+		Kibana: config.KibanaConfig{
+			Addresses: []string{fmt.Sprintf("http://localhost:%d", kbPort)},
+			Username:  "elastic",
+			Password:  password,
+		},
+	}
+
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := waitFor("Elasticsearch", func() error {
+		_, err := esClient.Ping()
+		return err
+	}); err != nil {
+		return err
+	}
+
+	// Kibana
+	if !noKibana {
+		// Kibana connects as kibana_system, which has no password until one is set
+		if err := esClient.SetUserPassword("kibana_system", password); err != nil {
+			return fmt.Errorf("failed to set kibana_system password: %w", err)
+		}
+
+		fmt.Printf("Starting Kibana %s as %s...\n", stackVersion, kbName)
+		err := docker("run", "-d",
+			"--name", kbName,
+			"--network", network,
+			"-p", fmt.Sprintf("%d:5601", kbPort),
+			"-e", fmt.Sprintf("ELASTICSEARCH_HOSTS=http://%s:9200", esName),
+			"-e", "ELASTICSEARCH_USERNAME=kibana_system",
+			"-e", "ELASTICSEARCH_PASSWORD="+password,
+			"-e", "XPACK_ENCRYPTEDSAVEDOBJECTS_ENCRYPTIONKEY="+encryptionKey,
+			"docker.elastic.co/kibana/kibana:"+stackVersion,
+		)
+		if err != nil {
+			return err
+		}
+
+		kbClient, err := client.NewKibana(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create Kibana client: %w", err)
+		}
+		if err := waitFor("Kibana", func() error {
+			_, err := kbClient.Ping()
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Context for the other commands
+	ctxCfg := config.ContextConfig{Elasticsearch: cfg.Elasticsearch}
+	if !noKibana {
+		ctxCfg.Kibana = cfg.Kibana
+	}
+	if err := config.SaveContext(configFile, contextName, ctxCfg); err != nil {
+		return fmt.Errorf("failed to add sandbox context: %w", err)
+	}
+	previous, _ := config.CurrentContext(configFile)
+	if err := config.SetContext(configFile, contextName); err != nil {
+		return fmt.Errorf("failed to switch to sandbox context: %w", err)
+	}
+
+	fmt.Printf("\nSandbox is ready.\n")
+	fmt.Printf("  Elasticsearch: %s\n", cfg.Elasticsearch.Addresses[0])
+	if !noKibana {
+		fmt.Printf("  Kibana:        %s\n", cfg.Kibana.Addresses[0])
+	}
+	fmt.Printf("  User:          elastic / %s\n", password)
+	fmt.Printf("  Context:       %s\n\n", contextName)
+	if previous != "" && previous != contextName {
+		fmt.Printf("Switched from context %q, switch back with: esctl_config use-context %s\n", previous, previous)
+	}
+	fmt.Println("Try: es_ping")
+	return nil
+}
+
+// runDown removes the sandbox containers, network and context
+func runDown(cmd *cobra.Command, args []string) error {
+	esName, kbName, network := containerNames()
+
+	for _, container := range []string{kbName, esName} {
+		if err := docker("rm", "-f", "-v", container); err != nil && !strings.Contains(err.Error(), "No such container") {
+			return err
+		}
+		fmt.Printf("Removed %s\n", container)
+	}
+
+	if err := docker("network", "rm", network); err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+
+	removed, err := config.RemoveContext(configFile, contextName)
+	if err != nil {
+		return fmt.Errorf("failed to remove sandbox context: %w", err)
+	}
+	if removed {
+		fmt.Printf("Removed context %q\n", contextName)
+	}
+
+	fmt.Println("Sandbox removed")
+	return nil
+}
+
+// containerNames returns the Elasticsearch and Kibana container names and the network name
+func containerNames() (string, string, string) {
+	return name + "-es", name + "-kb", name
+}
+
+// docker runs a docker command and returns its output as the error if it fails
+func docker(args ...string) error {
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker %s failed: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// waitFor calls check until it succeeds or the timeout passes
+func waitFor(service string, check func() error) error {
+	fmt.Printf("Waiting for %s to be ready", service)
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			fmt.Println(" done")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			fmt.Println()
+			return fmt.Errorf("%s was not ready after %s: %w", service, timeout, err)
+		}
+		fmt.Print(".")
+		time.Sleep(5 * time.Second)
+	}
+}
@@ -0,0 +1,874 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Security object options
+	name            string
+	roles           []string
+	fullName        string
+	email           string
+	enabled         bool
+	includeReserved bool
+	definitionFile  string
+	clusterPrivs    []string
+	indexPatterns   []string
+	indexPrivs      []string
+	runAs           []string
+	rules           string
+	force           bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_security",
+		Short: "Manage Elasticsearch users, roles and role mappings",
+		Long: `Manage the users of the native realm, roles and role mappings through the security APIs.
+
+Passwords are never taken as flags, where they would be kept in the shell history and shown in
+the process list. They are read from the terminal, or from the first line of standard input when
+it is not a terminal, as in: vault read -field=password secret/app | es_security user create ...
+
+Example usage:
+  es_security user list
+  es_security user create --name=jdoe --roles=viewer --full-name="Jane Doe"
+  es_security role create --name=logs_reader --indices='logs-*' --privileges=read,view_index_metadata
+  es_security role-mapping create --name=ldap_admins --roles=superuser --rules='{"field":{"groups":"cn=admins,dc=example,dc=com"}}'`,
+		Example: `es_security user list --include-reserved
+es_security user change-password --name=jdoe
+es_security role list
+es_security role-mapping disable --name=ldap_admins`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// User commands
+	var userCmd = &cobra.Command{
+		Use:   "user",
+		Short: "Manage users of the native realm",
+		Long:  `List, create, update, delete, enable and disable users of the native realm, and change their passwords.`,
+	}
+
+	var listUsersCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		Long:  `List the users of the native realm. Built-in users are left out unless --include-reserved is given.`,
+		RunE:  listUsers,
+	}
+
+	var createUserCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		Long: `Create a user of the native realm. The password is read from the terminal, twice, or from
+standard input. Creating a user that already exists fails, use update to change it.`,
+		Example: `es_security user create --name=jdoe --roles=viewer,logs_reader --full-name="Jane Doe" --email=jdoe@example.com
+echo "$PASSWORD" | es_security user create --name=ingest --roles=ingest_writer`,
+		RunE: createUser,
+	}
+
+	var updateUserCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Update a user",
+		Long: `Change the roles, full name or email of a user of the native realm. Only the given flags are
+changed, and the password is kept. --roles replaces the roles of the user.`,
+		Example: `es_security user update --name=jdoe --roles=viewer,logs_reader,metrics_reader`,
+		RunE:    updateUser,
+	}
+
+	var deleteUserCmd = &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete a user",
+		Long:    `Delete a user of the native realm. Confirmation is asked for unless --force is given.`,
+		Example: `es_security user delete --name=jdoe --force`,
+		RunE:    deleteUser,
+	}
+
+	var enableUserCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Enable a user",
+		Long:  `Enable a user that was disabled, so it can log in again.`,
+		RunE:  setUserEnabled(true),
+	}
+
+	var disableUserCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable a user",
+		Long: `Disable a user, so it can no longer log in. The user keeps its password and roles, and can be
+enabled again.`,
+		RunE: setUserEnabled(false),
+	}
+
+	var changePasswordCmd = &cobra.Command{
+		Use:   "change-password",
+		Short: "Change the password of a user",
+		Long: `Change the password of a native or built-in user. The password is read from the terminal,
+twice, or from standard input.`,
+		Example: `es_security user change-password --name=jdoe
+es_security user change-password --name=kibana_system < password.txt`,
+		RunE: changePassword,
+	}
+
+	// Role commands
+	var roleCmd = &cobra.Command{
+		Use:   "role",
+		Short: "Manage roles",
+		Long: `List, create, update and delete roles.
+
+A role is given either as a JSON file with the full role definition, as the role API takes it,
+or with flags for its cluster privileges, one set of index privileges and run-as users.`,
+	}
+
+	var listRolesCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List roles",
+		Long:  `List roles with their cluster and index privileges. Built-in roles are left out unless --include-reserved is given.`,
+		RunE:  listRoles,
+	}
+
+	var createRoleCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a role",
+		Long: `Create a role from a JSON definition file, or from flags. Creating a role that already exists
+fails, use update to change it.`,
+		Example: `es_security role create --name=logs_reader --indices='logs-*' --privileges=read,view_index_metadata
+es_security role create --name=monitoring --cluster=monitor --indices='metrics-*,.monitoring-*' --privileges=read
+es_security role create --name=app_admin --file=app_admin.json`,
+		RunE: createRole,
+	}
+
+	var updateRoleCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Update a role",
+		Long: `Update a role. --file replaces the whole definition. Otherwise only the given flags are
+changed: --cluster replaces the cluster privileges, --indices with --privileges replaces the index
+privileges, and --run-as replaces the run-as users.`,
+		Example: `es_security role update --name=logs_reader --indices='logs-*,traces-*' --privileges=read
+es_security role update --name=app_admin --file=app_admin.json`,
+		RunE: updateRole,
+	}
+
+	var deleteRoleCmd = &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete a role",
+		Long:    `Delete a role. Users and role mappings that grant it keep its name, which grants nothing until a role of that name is created again. Confirmation is asked for unless --force is given.`,
+		Example: `es_security role delete --name=logs_reader --force`,
+		RunE:    deleteRole,
+	}
+
+	// Role mapping commands
+	var mappingCmd = &cobra.Command{
+		Use:   "role-mapping",
+		Short: "Manage role mappings",
+		Long: `List, create, update, delete, enable and disable role mappings, which grant roles to users of
+external realms such as LDAP, SAML or PKI by rules on their attributes.
+
+A role mapping is given either as a JSON file with the full definition, as the role mapping API
+takes it, or with --roles and --rules.`,
+	}
+
+	var listMappingsCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List role mappings",
+		Long:  `List role mappings with the roles they grant and their rules.`,
+		RunE:  listRoleMappings,
+	}
+
+	var createMappingCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a role mapping",
+		Long: `Create a role mapping from a JSON definition file, or from --roles and --rules. Creating a role
+mapping that already exists fails, use update to change it.`,
+		Example: `es_security role-mapping create --name=ldap_admins --roles=superuser --rules='{"field":{"groups":"cn=admins,dc=example,dc=com"}}'
+es_security role-mapping create --name=saml_users --file=saml_users.json`,
+		RunE: createRoleMapping,
+	}
+
+	var updateMappingCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Update a role mapping",
+		Long: `Update a role mapping. --file replaces the whole definition. Otherwise only the given flags are
+changed: --roles replaces the roles and --rules the rules.`,
+		Example: `es_security role-mapping update --name=ldap_admins --roles=superuser,kibana_admin`,
+		RunE:    updateRoleMapping,
+	}
+
+	var deleteMappingCmd = &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete a role mapping",
+		Long:    `Delete a role mapping. Confirmation is asked for unless --force is given.`,
+		Example: `es_security role-mapping delete --name=ldap_admins --force`,
+		RunE:    deleteRoleMapping,
+	}
+
+	var enableMappingCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Enable a role mapping",
+		Long:  `Enable a role mapping, so it grants its roles again.`,
+		RunE:  setRoleMappingEnabled(true),
+	}
+
+	var disableMappingCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable a role mapping",
+		Long:  `Disable a role mapping, so it grants no roles until it is enabled again.`,
+		RunE:  setRoleMappingEnabled(false),
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// User command flags
+	listUsersCmd.Flags().BoolVar(&includeReserved, "include-reserved", false, "Also list the built-in users")
+
+	for _, cmd := range []*cobra.Command{createUserCmd, updateUserCmd, deleteUserCmd, enableUserCmd, disableUserCmd, changePasswordCmd} {
+		cmd.Flags().StringVarP(&name, "name", "n", "", "Username (required)")
+		cmd.MarkFlagRequired("name")
+	}
+	for _, cmd := range []*cobra.Command{createUserCmd, updateUserCmd} {
+		cmd.Flags().StringSliceVar(&roles, "roles", nil, "Roles of the user (comma-separated list)")
+		cmd.Flags().StringVar(&fullName, "full-name", "", "Full name of the user")
+		cmd.Flags().StringVar(&email, "email", "", "Email address of the user")
+	}
+	createUserCmd.Flags().BoolVar(&enabled, "enabled", true, "Create the user enabled, so it can log in")
+	createUserCmd.MarkFlagRequired("roles")
+	deleteUserCmd.Flags().BoolVar(&force, "force", false, "Delete without confirmation")
+
+	// Role command flags
+	listRolesCmd.Flags().BoolVar(&includeReserved, "include-reserved", false, "Also list the built-in roles")
+
+	for _, cmd := range []*cobra.Command{createRoleCmd, updateRoleCmd, deleteRoleCmd} {
+		cmd.Flags().StringVarP(&name, "name", "n", "", "Role name (required)")
+		cmd.MarkFlagRequired("name")
+	}
+	for _, cmd := range []*cobra.Command{createRoleCmd, updateRoleCmd} {
+		cmd.Flags().StringVar(&definitionFile, "file", "", "JSON file with the role definition")
+		cmd.Flags().StringSliceVar(&clusterPrivs, "cluster", nil, "Cluster privileges, such as monitor or manage_ilm (comma-separated list)")
+		cmd.Flags().StringSliceVar(&indexPatterns, "indices", nil, "Index names or patterns the index privileges apply to (comma-separated list)")
+		cmd.Flags().StringSliceVar(&indexPrivs, "privileges", nil, "Index privileges, such as read or write (comma-separated list)")
+		cmd.Flags().StringSliceVar(&runAs, "run-as", nil, "Users the role can run requests as (comma-separated list)")
+		cmd.MarkFlagsMutuallyExclusive("file", "cluster")
+		cmd.MarkFlagsMutuallyExclusive("file", "indices")
+		cmd.MarkFlagsMutuallyExclusive("file", "privileges")
+		cmd.MarkFlagsMutuallyExclusive("file", "run-as")
+		cmd.MarkFlagsRequiredTogether("indices", "privileges")
+	}
+	deleteRoleCmd.Flags().BoolVar(&force, "force", false, "Delete without confirmation")
+
+	// Role mapping command flags
+	for _, cmd := range []*cobra.Command{createMappingCmd, updateMappingCmd, deleteMappingCmd, enableMappingCmd, disableMappingCmd} {
+		cmd.Flags().StringVarP(&name, "name", "n", "", "Role mapping name (required)")
+		cmd.MarkFlagRequired("name")
+	}
+	for _, cmd := range []*cobra.Command{createMappingCmd, updateMappingCmd} {
+		cmd.Flags().StringVar(&definitionFile, "file", "", "JSON file with the role mapping definition")
+		cmd.Flags().StringSliceVar(&roles, "roles", nil, "Roles granted by the mapping (comma-separated list)")
+		cmd.Flags().StringVar(&rules, "rules", "", "Rules selecting the users, as JSON, such as {\"field\":{\"realm.name\":\"ldap1\"}}")
+		cmd.MarkFlagsMutuallyExclusive("file", "roles")
+		cmd.MarkFlagsMutuallyExclusive("file", "rules")
+	}
+	createMappingCmd.Flags().BoolVar(&enabled, "enabled", true, "Create the role mapping enabled")
+	deleteMappingCmd.Flags().BoolVar(&force, "force", false, "Delete without confirmation")
+
+	// Add subcommands
+	userCmd.AddCommand(listUsersCmd, createUserCmd, updateUserCmd, deleteUserCmd, enableUserCmd, disableUserCmd, changePasswordCmd)
+	roleCmd.AddCommand(listRolesCmd, createRoleCmd, updateRoleCmd, deleteRoleCmd)
+	mappingCmd.AddCommand(listMappingsCmd, createMappingCmd, updateMappingCmd, deleteMappingCmd, enableMappingCmd, disableMappingCmd)
+	rootCmd.AddCommand(userCmd, roleCmd, mappingCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// newClient loads the configuration and creates the Elasticsearch client
+func newClient(cmd *cobra.Command) (*config.Config, *client.Client, error) {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+	return cfg, esClient, nil
+}
+
+// listUsers handles the user list command
+func listUsers(cmd *cobra.Command, args []string) error {
+	cfg, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	users, err := esClient.GetUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+
+	header := []string{"Username", "Full Name", "Email", "Roles", "Enabled", "Reserved"}
+	rows := [][]string{}
+	for _, user := range users {
+		if user.Reserved() && !includeReserved {
+			continue
+		}
+		rows = append(rows, []string{
+			user.Username,
+			valueOrDash(user.FullName),
+			valueOrDash(user.Email),
+			valueOrDash(strings.Join(user.Roles, ", ")),
+			fmt.Sprintf("%t", user.Enabled),
+			fmt.Sprintf("%t", user.Reserved()),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// createUser handles the user create command
+func createUser(cmd *cobra.Command, args []string) error {
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The put user API updates existing users, check first so create does not
+	if _, err := esClient.GetUser(name); err == nil {
+		return fmt.Errorf("user %s already exists, use update to change it", name)
+	} else if !client.IsNotFound(err) {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	newPassword, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+
+	user := client.SecurityUser{Username: name, Roles: roles, FullName: fullName, Email: email, Enabled: enabled}
+	if err := esClient.PutUser(user, newPassword); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	fmt.Printf("User %s created\n", name)
+	return nil
+}
+
+// updateUser handles the user update command
+func updateUser(cmd *cobra.Command, args []string) error {
+	if !cmd.Flags().Changed("roles") && !cmd.Flags().Changed("full-name") && !cmd.Flags().Changed("email") {
+		return fmt.Errorf("nothing to update, give --roles, --full-name or --email")
+	}
+
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	user, err := esClient.GetUser(name)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if cmd.Flags().Changed("roles") {
+		user.Roles = roles
+	}
+	if cmd.Flags().Changed("full-name") {
+		user.FullName = fullName
+	}
+	if cmd.Flags().Changed("email") {
+		user.Email = email
+	}
+
+	if err := esClient.PutUser(*user, ""); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	fmt.Printf("User %s updated\n", name)
+	return nil
+}
+
+// deleteUser handles the user delete command
+func deleteUser(cmd *cobra.Command, args []string) error {
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Are you sure you want to delete user '%s'? This operation cannot be undone.", name)) {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
+
+	if err := esClient.DeleteUser(name); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	fmt.Printf("User %s deleted\n", name)
+	return nil
+}
+
+// setUserEnabled returns the handler of the user enable or disable command
+func setUserEnabled(enable bool) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		_, esClient, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := esClient.SetUserEnabled(name, enable); err != nil {
+			return fmt.Errorf("failed to change user: %w", err)
+		}
+
+		if enable {
+			fmt.Printf("User %s enabled\n", name)
+		} else {
+			fmt.Printf("User %s disabled\n", name)
+		}
+		return nil
+	}
+}
+
+// changePassword handles the user change-password command
+func changePassword(cmd *cobra.Command, args []string) error {
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+
+	if err := esClient.SetUserPassword(name, newPassword); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	fmt.Printf("Password of user %s changed\n", name)
+	return nil
+}
+
+// listRoles handles the role list command
+func listRoles(cmd *cobra.Command, args []string) error {
+	cfg, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	roleList, err := esClient.GetRoles()
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	header := []string{"Role", "Cluster", "Indices", "Run As", "Reserved"}
+	rows := [][]string{}
+	for _, role := range roleList {
+		if role.Reserved && !includeReserved {
+			continue
+		}
+		var indices []string
+		for _, entry := range role.Indices {
+			indices = append(indices, fmt.Sprintf("%s: %s", strings.Join(entry.Names, ","), strings.Join(entry.Privileges, ",")))
+		}
+		rows = append(rows, []string{
+			role.Name,
+			valueOrDash(strings.Join(role.Cluster, ", ")),
+			valueOrDash(strings.Join(indices, "; ")),
+			valueOrDash(strings.Join(role.RunAs, ", ")),
+			fmt.Sprintf("%t", role.Reserved),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// createRole handles the role create command
+func createRole(cmd *cobra.Command, args []string) error {
+	if definitionFile == "" && len(clusterPrivs) == 0 && len(indexPatterns) == 0 && len(runAs) == 0 {
+		return fmt.Errorf("give the role with --file, or with --cluster, --indices and --privileges, or --run-as")
+	}
+
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The put role API updates existing roles, check first so create does not
+	if _, err := esClient.GetRole(name); err == nil {
+		return fmt.Errorf("role %s already exists, use update to change it", name)
+	} else if !client.IsNotFound(err) {
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+
+	definition, err := roleDefinition(cmd, map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if err := esClient.PutRole(name, definition); err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	fmt.Printf("Role %s created\n", name)
+	return nil
+}
+
+// updateRole handles the role update command
+func updateRole(cmd *cobra.Command, args []string) error {
+	if definitionFile == "" && !cmd.Flags().Changed("cluster") && !cmd.Flags().Changed("indices") && !cmd.Flags().Changed("run-as") {
+		return fmt.Errorf("nothing to update, give --file, --cluster, --indices and --privileges, or --run-as")
+	}
+
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	role, err := esClient.GetRole(name)
+	if err != nil {
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+	if role.Reserved {
+		return fmt.Errorf("role %s is built in and cannot be changed", name)
+	}
+
+	definition, err := roleDefinition(cmd, role.Definition)
+	if err != nil {
+		return err
+	}
+	if err := esClient.PutRole(name, definition); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	fmt.Printf("Role %s updated\n", name)
+	return nil
+}
+
+// roleDefinition returns the definition in --file, or the given definition with the parts set by
+// the flags replaced
+func roleDefinition(cmd *cobra.Command, definition map[string]interface{}) (map[string]interface{}, error) {
+	if definitionFile != "" {
+		return readDefinitionFile(definitionFile)
+	}
+	if cmd.Flags().Changed("cluster") {
+		definition["cluster"] = clusterPrivs
+	}
+	if cmd.Flags().Changed("indices") {
+		definition["indices"] = []client.RoleIndexPrivileges{{Names: indexPatterns, Privileges: indexPrivs}}
+	}
+	if cmd.Flags().Changed("run-as") {
+		definition["run_as"] = runAs
+	}
+	return definition, nil
+}
+
+// deleteRole handles the role delete command
+func deleteRole(cmd *cobra.Command, args []string) error {
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Are you sure you want to delete role '%s'? This operation cannot be undone.", name)) {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
+
+	if err := esClient.DeleteRole(name); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	fmt.Printf("Role %s deleted\n", name)
+	return nil
+}
+
+// listRoleMappings handles the role-mapping list command
+func listRoleMappings(cmd *cobra.Command, args []string) error {
+	cfg, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	mappings, err := esClient.GetRoleMappings()
+	if err != nil {
+		return fmt.Errorf("failed to get role mappings: %w", err)
+	}
+
+	header := []string{"Role Mapping", "Enabled", "Roles", "Rules"}
+	rows := [][]string{}
+	for _, mapping := range mappings {
+		granted := strings.Join(mapping.Roles, ", ")
+		if len(mapping.RoleTemplates) > 0 {
+			granted = fmt.Sprintf("%d role templates", len(mapping.RoleTemplates))
+		}
+		rulesJSON, err := json.Marshal(mapping.Rules)
+		if err != nil {
+			return fmt.Errorf("failed to encode rules of role mapping %s: %w", mapping.Name, err)
+		}
+		rows = append(rows, []string{mapping.Name, fmt.Sprintf("%t", mapping.Enabled), valueOrDash(granted), string(rulesJSON)})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// createRoleMapping handles the role-mapping create command
+func createRoleMapping(cmd *cobra.Command, args []string) error {
+	if definitionFile == "" && (len(roles) == 0 || rules == "") {
+		return fmt.Errorf("give the role mapping with --file, or with --roles and --rules")
+	}
+
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	// The put role mapping API updates existing mappings, check first so create does not
+	if _, err := esClient.GetRoleMapping(name); err == nil {
+		return fmt.Errorf("role mapping %s already exists, use update to change it", name)
+	} else if !client.IsNotFound(err) {
+		return fmt.Errorf("failed to get role mapping: %w", err)
+	}
+
+	var definition interface{}
+	if definitionFile != "" {
+		if definition, err = readDefinitionFile(definitionFile); err != nil {
+			return err
+		}
+	} else {
+		mapping := client.SecurityRoleMapping{Enabled: enabled, Roles: roles}
+		if err := json.Unmarshal([]byte(rules), &mapping.Rules); err != nil {
+			return fmt.Errorf("failed to parse --rules: %w", err)
+		}
+		definition = mapping
+	}
+
+	if err := esClient.PutRoleMapping(name, definition); err != nil {
+		return fmt.Errorf("failed to create role mapping: %w", err)
+	}
+
+	fmt.Printf("Role mapping %s created\n", name)
+	return nil
+}
+
+// updateRoleMapping handles the role-mapping update command
+func updateRoleMapping(cmd *cobra.Command, args []string) error {
+	if definitionFile == "" && !cmd.Flags().Changed("roles") && !cmd.Flags().Changed("rules") {
+		return fmt.Errorf("nothing to update, give --file, --roles or --rules")
+	}
+
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	mapping, err := esClient.GetRoleMapping(name)
+	if err != nil {
+		return fmt.Errorf("failed to get role mapping: %w", err)
+	}
+
+	var definition interface{}
+	if definitionFile != "" {
+		if definition, err = readDefinitionFile(definitionFile); err != nil {
+			return err
+		}
+	} else {
+		if cmd.Flags().Changed("roles") {
+			// Roles and role templates cannot be combined, roles given replace both
+			mapping.Roles = roles
+			mapping.RoleTemplates = nil
+		}
+		if cmd.Flags().Changed("rules") {
+			mapping.Rules = nil
+			if err := json.Unmarshal([]byte(rules), &mapping.Rules); err != nil {
+				return fmt.Errorf("failed to parse --rules: %w", err)
+			}
+		}
+		definition = mapping
+	}
+
+	if err := esClient.PutRoleMapping(name, definition); err != nil {
+		return fmt.Errorf("failed to update role mapping: %w", err)
+	}
+
+	fmt.Printf("Role mapping %s updated\n", name)
+	return nil
+}
+
+// deleteRoleMapping handles the role-mapping delete command
+func deleteRoleMapping(cmd *cobra.Command, args []string) error {
+	_, esClient, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Are you sure you want to delete role mapping '%s'? This operation cannot be undone.", name)) {
+		fmt.Println("Operation cancelled")
+		return nil
+	}
+
+	if err := esClient.DeleteRoleMapping(name); err != nil {
+		return fmt.Errorf("failed to delete role mapping: %w", err)
+	}
+
+	fmt.Printf("Role mapping %s deleted\n", name)
+	return nil
+}
+
+// setRoleMappingEnabled returns the handler of the role-mapping enable or disable command
+func setRoleMappingEnabled(enable bool) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		_, esClient, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		mapping, err := esClient.GetRoleMapping(name)
+		if err != nil {
+			return fmt.Errorf("failed to get role mapping: %w", err)
+		}
+		mapping.Enabled = enable
+		if err := esClient.PutRoleMapping(name, mapping); err != nil {
+			return fmt.Errorf("failed to change role mapping: %w", err)
+		}
+
+		if enable {
+			fmt.Printf("Role mapping %s enabled\n", name)
+		} else {
+			fmt.Printf("Role mapping %s disabled\n", name)
+		}
+		return nil
+	}
+}
+
+// readDefinitionFile reads a JSON object from a file
+func readDefinitionFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var definition map[string]interface{}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return definition, nil
+}
+
+// confirm asks the user to confirm an operation, unless --force is given
+func confirm(question string) bool {
+	if force {
+		return true
+	}
+	fmt.Printf("%s [y/N] ", question)
+	var answer string
+	fmt.Scanln(&answer)
+	return strings.ToLower(answer) == "y"
+}
+
+// readNewPassword reads a new password from the terminal, asking for it twice, or from the first
+// line of standard input when it is not a terminal
+func readNewPassword() (string, error) {
+	newPassword, err := config.ReadPassword("Password: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if newPassword == "" {
+		return "", fmt.Errorf("the password must not be empty")
+	}
+
+	if isTerminal() {
+		again, err := config.ReadPassword("Confirm password: ")
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		if again != newPassword {
+			return "", fmt.Errorf("the passwords do not match")
+		}
+	}
+	return newPassword, nil
+}
+
+// isTerminal reports whether standard input is a terminal
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
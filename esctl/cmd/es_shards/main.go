@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
@@ -31,6 +32,16 @@ var (
 	states      []string
 	primaryOnly bool
 
+	// Lag options
+	minLag int64
+
+	// Streaming options
+	streamOutput bool
+	headerEvery  int
+
+	// Watch mode
+	watcher = format.NewWatcher()
+
 	// Output
 	outputFormat string
 )
@@ -55,19 +66,45 @@ helps identify:
 Example usage:
   es_shards --es-addresses=https://elasticsearch:9200 --es-username=elastic --es-password=changeme
   es_shards --nodes=node1,node2 --format=json
-  es_shards --indices=logstash-* --primary-only --style=blue`,
+  es_shards --indices=logstash-* --primary-only --style=blue
+  es_shards --stream --header-every=100
+  es_shards lag --indices=logs- --min-lag=1000`,
 		Example:          `es_shards
 es_shards --nodes=node1,node2
 es_shards --indices=logstash-* --primary-only
-es_shards --states=UNASSIGNED`,
+es_shards --states=UNASSIGNED
+es_shards --stream
+es_shards --states=INITIALIZING,RELOCATING --watch
+es_shards lag --indices=logs-`,
 		PersistentPreRunE: initConfig,
-		RunE:             run,
+		RunE:             watcher.Wrap(run),
 	}
 	// Disable the auto-generated completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
+	// Lag subcommand
+	var lagCmd = &cobra.Command{
+		Use:   "lag",
+		Short: "Show checkpoint and translog lag per shard copy",
+		Long: `Show the global checkpoint, local checkpoint and max sequence number of every shard copy together
+with its translog size, to identify replicas that are falling behind their primary under heavy indexing.
+
+Behind Primary is the primary's max sequence number minus the replica's local checkpoint. Checkpoint Lag
+is the copy's max sequence number minus the global checkpoint. Rows are sorted with the copies furthest
+behind first.
+
+Example usage:
+  es_shards lag
+  es_shards lag --indices=logs- --min-lag=1000
+  es_shards lag --nodes=node1 --format=json`,
+		RunE: runLag,
+	}
+	lagCmd.Flags().Int64Var(&minLag, "min-lag", 0, "Only show shard copies at least this many operations behind their primary or global checkpoint")
+	rootCmd.AddCommand(lagCmd)
+
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -83,13 +120,31 @@ es_shards --states=UNASSIGNED`,
 	rootCmd.PersistentFlags().StringSliceVarP(&states, "states", "s", nil, "Filter by shard states (comma-separated list)")
 	rootCmd.PersistentFlags().BoolVarP(&primaryOnly, "primary", "p", false, "Show only primary shards")
 
+	// Streaming flags
+	rootCmd.Flags().BoolVar(&streamOutput, "stream", false, "Print shards as a single table while they are read instead of grouping them by node")
+	rootCmd.Flags().IntVar(&headerEvery, "header-every", format.DefaultHeaderEvery, "Repeat the table header every N rows when streaming")
+
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Watch flags
+	watcher.AddFlags(rootCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -112,6 +167,11 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
+	// Streaming prints shards as they are read, without grouping them by node
+	if streamOutput {
+		return runStream(cfg, esClient)
+	}
+
 	// Get shards by node
 	shardsByNode, unassignedShards, err := esClient.GetShardsByNode(nodes)
 	if err != nil {
@@ -195,6 +255,55 @@ func run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runStream prints every shard as one table, writing rows as they are decoded from the response
+func runStream(cfg *config.Config, esClient *client.Client) error {
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	stream := formatter.NewStream([]string{"Node", "Index", "Shard", "Type", "State", "Docs", "Store", "Unassigned Reason"}, headerEvery)
+
+	err := esClient.StreamShards(nodes, func(shard client.ShardInfo) error {
+		if len(filterShards([]client.ShardInfo{shard}, indices, states, primaryOnly)) == 0 {
+			return nil
+		}
+		if len(nodes) > 0 && !containsString(nodes, shard.Node) {
+			return nil
+		}
+
+		shardType := "replica"
+		if shard.PrimaryOrReplica == "p" {
+			shardType = "primary"
+		}
+
+		return stream.WriteRow([]string{
+			shard.Node,
+			shard.Index,
+			shard.Shard,
+			shardType,
+			shard.State,
+			shard.Docs,
+			shard.Store,
+			shard.UnassignedReason,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get shards: %w", err)
+	}
+
+	if err := stream.Close(); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// containsString reports whether a list contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // filterShards applies filters to the shard list
 func filterShards(shards []client.ShardInfo, indices, states []string, primaryOnly bool) []client.ShardInfo {
 	if len(indices) == 0 && len(states) == 0 && !primaryOnly {
@@ -241,3 +350,101 @@ func filterShards(shards []client.ShardInfo, indices, states []string, primaryOn
 
 	return filtered
 }
+
+// runLag handles the lag command
+func runLag(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Get checkpoint and translog state per shard copy
+	lags, err := esClient.GetShardLag(nil)
+	if err != nil {
+		return fmt.Errorf("failed to get shard lag: %w", err)
+	}
+
+	// Prepare table data
+	header := []string{"Index", "Shard", "Type", "Node", "Max Seq No", "Local Checkpoint", "Global Checkpoint", "Checkpoint Lag", "Behind Primary", "Translog Ops", "Translog Size", "Uncommitted Size"}
+	rows := [][]string{}
+
+	for _, lag := range lags {
+		if !matchesLagFilters(lag) {
+			continue
+		}
+
+		shardType := "replica"
+		if lag.Primary {
+			shardType = "primary"
+		}
+
+		rows = append(rows, []string{
+			lag.Index,
+			lag.Shard,
+			shardType,
+			lag.Node,
+			fmt.Sprintf("%d", lag.MaxSeqNo),
+			fmt.Sprintf("%d", lag.LocalCheckpoint),
+			fmt.Sprintf("%d", lag.GlobalCheckpoint),
+			fmt.Sprintf("%d", lag.CheckpointLag),
+			fmt.Sprintf("%d", lag.BehindPrimary),
+			fmt.Sprintf("%d", lag.TranslogOperations),
+			client.ByteCountSI(lag.TranslogSize),
+			client.ByteCountSI(lag.TranslogUncommitted),
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf("No shard copies match the given filters\n")
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// matchesLagFilters applies the node, index, primary and minimum lag filters to a shard copy
+func matchesLagFilters(lag client.ShardLag) bool {
+	if primaryOnly && !lag.Primary {
+		return false
+	}
+
+	if lag.BehindPrimary < minLag && lag.CheckpointLag < minLag {
+		return false
+	}
+
+	if len(nodes) > 0 {
+		matchNode := false
+		for _, node := range nodes {
+			if lag.Node == node {
+				matchNode = true
+				break
+			}
+		}
+		if !matchNode {
+			return false
+		}
+	}
+
+	if len(indices) > 0 {
+		matchIndex := false
+		for _, idx := range indices {
+			if strings.Contains(lag.Index, idx) {
+				matchIndex = true
+				break
+			}
+		}
+		if !matchIndex {
+			return false
+		}
+	}
+
+	return true
+}
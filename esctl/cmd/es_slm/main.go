@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for policy findings. 1 is left for errors, as in the other commands.
+const (
+	exitFailed = 2
+	exitMissed = 3
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Verify options
+	policyIDs []string
+	grace     time.Duration
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_slm",
+		Short: "Check Elasticsearch snapshot lifecycle policies",
+		Long: `List snapshot lifecycle (SLM) policies and check that they run as scheduled.
+
+Example usage:
+  es_slm list
+  es_slm verify-last-run
+  es_slm verify-last-run --grace=2h`,
+		Example: `es_slm list
+es_slm verify-last-run --format=json`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List snapshot lifecycle policies",
+		Long:  `List snapshot lifecycle policies with their schedule, repository, last runs and next run.`,
+		RunE:  listPolicies,
+	}
+
+	var verifyCmd = &cobra.Command{
+		Use:   "verify-last-run",
+		Short: "Check the last run of every snapshot lifecycle policy",
+		Long: `Check that every snapshot lifecycle policy succeeded at its last run and has not missed one.
+
+Each policy is checked against its schedule, a cron expression evaluated in UTC or an interval.
+Its last success must be no older than the last scheduled run, not counting runs scheduled
+within --grace of now, which are given time to complete, or runs scheduled before the policy was
+last changed. Cron expressions using the L, W or # forms are not evaluated; for those only the
+last run is checked, and the Detail column says so.
+
+The failing policies are named on standard error and the exit code reflects the worst finding,
+for use in monitoring:
+  0  every policy succeeded at its last run and has not missed a scheduled run
+  2  the last run of a policy failed
+  3  a policy has not succeeded since a scheduled run, whether it failed or did not run`,
+		Example: `es_slm verify-last-run
+es_slm verify-last-run --policy=nightly-snapshots --grace=3h
+es_slm verify-last-run --format=json`,
+		RunE: verifyLastRun,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Command specific flags
+	rootCmd.PersistentFlags().StringSliceVarP(&policyIDs, "policy", "p", nil, "Only these policies (comma-separated list, default is every policy)")
+	verifyCmd.Flags().DurationVar(&grace, "grace", time.Hour, "Time a scheduled run has to complete before it counts as missed")
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, verifyCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// getPolicies returns the policies selected with --policy, or every policy
+func getPolicies(cmd *cobra.Command) (*config.Config, []client.SLMPolicy, error) {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	policies, err := esClient.GetSLMPolicies()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get snapshot lifecycle policies: %w", err)
+	}
+	if len(policyIDs) == 0 {
+		return cfg, policies, nil
+	}
+
+	found := make(map[string]client.SLMPolicy, len(policies))
+	for _, policy := range policies {
+		found[policy.ID] = policy
+	}
+	var selected []client.SLMPolicy
+	for _, id := range policyIDs {
+		policy, ok := found[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("snapshot lifecycle policy %s not found", id)
+		}
+		selected = append(selected, policy)
+	}
+	return cfg, selected, nil
+}
+
+// listPolicies handles the list command
+func listPolicies(cmd *cobra.Command, args []string) error {
+	cfg, policies, err := getPolicies(cmd)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		fmt.Println("No snapshot lifecycle policies found")
+		return nil
+	}
+
+	header := []string{"Policy", "Schedule", "Repository", "Last Success Time", "Last Failure Time", "Next Run Time", "In Progress"}
+	rows := [][]string{}
+	for _, policy := range policies {
+		inProgress := "-"
+		if policy.InProgress != nil {
+			inProgress = fmt.Sprintf("%s (%s)", policy.InProgress.Name, policy.InProgress.State)
+		}
+		rows = append(rows, []string{
+			policy.ID,
+			policy.Policy.Schedule,
+			policy.Policy.Repository,
+			invocationTime(policy.LastSuccess),
+			invocationTime(policy.LastFailure),
+			millisTime(policy.NextExecutionMillis),
+			inProgress,
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// verifyLastRun handles the verify-last-run command
+func verifyLastRun(cmd *cobra.Command, args []string) error {
+	if grace < 0 {
+		return fmt.Errorf("--grace must not be negative")
+	}
+
+	cfg, policies, err := getPolicies(cmd)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		fmt.Println("No snapshot lifecycle policies found")
+		return nil
+	}
+
+	now := time.Now()
+	header := []string{"Policy", "Schedule", "Last Success Time", "Since Success", "Last Failure Time", "Status", "Detail"}
+	rows := [][]string{}
+	var failing []string
+	worst := 0
+
+	for _, policy := range policies {
+		verification := client.VerifySLMPolicy(policy, now, grace)
+		switch verification.Status {
+		case client.SLMStatusMissed:
+			worst = maxCode(worst, exitMissed)
+			failing = append(failing, policy.ID)
+		case client.SLMStatusFailed:
+			worst = maxCode(worst, exitFailed)
+			failing = append(failing, policy.ID)
+		}
+
+		sinceSuccess := "never"
+		if policy.LastSuccess != nil {
+			sinceSuccess = now.Sub(policy.LastSuccess.At()).Round(time.Minute).String()
+		}
+		rows = append(rows, []string{
+			policy.ID,
+			policy.Policy.Schedule,
+			invocationTime(policy.LastSuccess),
+			sinceSuccess,
+			invocationTime(policy.LastFailure),
+			verification.Status,
+			valueOrDash(verification.Detail),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if worst != 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d policies failing: %s\n", len(failing), len(policies), strings.Join(failing, ", "))
+		os.Exit(worst)
+	}
+	return nil
+}
+
+// invocationTime formats the time a policy run ended, "-" if there was none
+func invocationTime(invocation *client.SLMInvocation) string {
+	if invocation == nil {
+		return "-"
+	}
+	return invocation.At().Format(time.RFC3339)
+}
+
+// millisTime formats a time given in epoch milliseconds, "-" if it is zero
+func millisTime(millis int64) string {
+	if millis == 0 {
+		return "-"
+	}
+	return time.UnixMilli(millis).UTC().Format(time.RFC3339)
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// maxCode returns the more severe of two exit codes
+func maxCode(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -37,6 +40,17 @@ var (
 	waitForCompletion   bool
 	renamePattern       string
 	renameReplacement   string
+	previewRestore      bool
+	restoreGlobalState  bool
+	featureStates       []string
+	includeAliases      bool
+	indexSettingsFile   string
+	showIndices         []string
+	retryFailed         int
+	resultFile          string
+	followRestore       bool
+	followInterval      time.Duration
+	followTimeout       time.Duration
 
 	// Output
 	outputFormat string
@@ -66,6 +80,7 @@ Example usage:
   es_snapshot repo list
   es_snapshot repo create --repo-name=my_backups --repo-type=fs --repo-settings='{"location":"/backups"}'  
   es_snapshot create --repo-name=my_backups --snapshot-name=daily_backup
+  es_snapshot snapshot show --repo=my_backups --name=daily_backup --index="logs-*"
   es_snapshot restore --repo-name=my_backups --snapshot-name=daily_backup --indices=index1,index2`,
 		Example: `es_snapshot repo list
 es_snapshot create --repo-name=my_backups --snapshot-name=daily_backup
@@ -136,8 +151,23 @@ es_snapshot repo delete --repo-name=old_backups`,
 	var createSnapshotCmd = &cobra.Command{
 		Use:   "create",
 		Short: "Create a snapshot",
-		Long:  `Create a new snapshot in a repository.`,
-		RunE:  createSnapshot,
+		Long: `Create a new snapshot in a repository.
+
+With --wait, the shards that failed are listed with the reason given by Elasticsearch when the
+snapshot ends PARTIAL or FAILED. --retry-failed then takes new snapshots named
+<name>-retry-1, <name>-retry-2, ... of just the indices whose shards failed, until one succeeds
+or the retries run out. --result-file writes every attempt as JSON for backup job auditing.
+The command exits with an error if the last attempt did not succeed.`,
+		RunE: createSnapshot,
+	}
+
+	var showSnapshotCmd = &cobra.Command{
+		Use:   "show",
+		Short: "List the indices in a snapshot",
+		Long: `List the indices stored in a snapshot with their shard counts and sizes, and whether an
+index with the same name still exists in the cluster, so you can see what a snapshot contains
+before restoring from it. Use --index to limit the listing to matching indices.`,
+		RunE: showSnapshot,
 	}
 
 	var deleteSnapshotCmd = &cobra.Command{
@@ -150,12 +180,52 @@ es_snapshot repo delete --repo-name=old_backups`,
 	var restoreSnapshotCmd = &cobra.Command{
 		Use:   "restore",
 		Short: "Restore a snapshot",
-		Long:  `Restore a snapshot from a repository.`,
-		RunE:  restoreSnapshot,
+		Long: `Restore a snapshot from a repository.
+
+Use --preview to list the indices in the snapshot, which of them would be restored, and what they
+would be called after --rename-pattern and --rename-replacement are applied, without restoring
+anything. Targets that collide with existing indices are flagged: an existing open index makes the
+restore fail, while an existing closed index is silently overwritten.
+
+By default only indices and their aliases are restored. --include-global-state also restores the
+cluster state: templates, ingest pipelines, ILM policies and persistent settings, and with it every
+feature state in the snapshot. --feature-states picks the feature states to restore, such as
+security or kibana, with or without the global state; "none" restores none of them.
+--include-aliases=false leaves the aliases out, and --index-settings-file gives a JSON object of
+settings that override those of the restored indices, such as {"index.number_of_replicas": 0}.
+
+--follow prints the progress of the restore every --interval until every restored primary shard
+is done, as restore-status --follow does. A restore of feature states or the global state alone
+restores no shards, and following it ends once no shard has started after one interval.`,
+		Example: `es_snapshot snapshot restore --repo=my_backups --name=daily_backup --indices=logs-2024.06
+es_snapshot snapshot restore --repo=my_backups --name=daily_backup --include-global-state --feature-states=none
+es_snapshot snapshot restore --repo=my_backups --name=daily_backup --indices=-* --feature-states=security,kibana
+es_snapshot snapshot restore --repo=my_backups --name=daily_backup --include-aliases=false --index-settings-file=overrides.json
+es_snapshot snapshot restore --repo=my_backups --name=daily_backup --indices=logs-2024.06 --follow`,
+		RunE: restoreSnapshot,
+	}
+
+	var restoreStatusCmd = &cobra.Command{
+		Use:   "restore-status",
+		Short: "Show the progress of a snapshot restore",
+		Long: `Show the progress of restoring a snapshot, shard by shard.
+
+Every primary shard restored from the snapshot is listed with its recovery stage, the node it is
+restored to, the bytes to copy from the repository and the share copied so far. Files already on
+the node are reused and not counted. Primaries still waiting for a recovery slot are counted as
+pending. Replicas are copied from the restored primaries once they are done, and are not part of
+the restore.
+
+With --follow the progress is printed every --interval until every shard has been restored, and
+the table is shown at the end. --timeout gives up waiting after that long.`,
+		Example: `es_snapshot snapshot restore-status --repo=my_backups --name=daily_backup
+es_snapshot snapshot restore-status --repo=my_backups --name=daily_backup --follow --interval=30s`,
+		RunE: restoreStatus,
 	}
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Elasticsearch connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
@@ -166,8 +236,18 @@ es_snapshot repo delete --repo-name=old_backups`,
 	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
 
 	// Repository command flags
 	createRepoCmd.Flags().StringVarP(&repoName, "name", "n", "", "Repository name (required)")
@@ -189,9 +269,17 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	createSnapshotCmd.Flags().StringSliceVarP(&indices, "indices", "i", []string{"_all"}, "Indices to include in snapshot (comma-separated list)")
 	createSnapshotCmd.Flags().BoolVarP(&includeGlobalState, "include-global-state", "g", true, "Include global state in snapshot")
 	createSnapshotCmd.Flags().BoolVarP(&waitForCompletion, "wait", "w", false, "Wait for snapshot completion")
+	createSnapshotCmd.Flags().IntVar(&retryFailed, "retry-failed", 0, "Retry up to this many times for just the indices with failed shards (requires --wait)")
+	createSnapshotCmd.Flags().StringVar(&resultFile, "result-file", "", "Write the outcome of every attempt to this file as JSON (requires --wait)")
 	createSnapshotCmd.MarkFlagRequired("repo")
 	createSnapshotCmd.MarkFlagRequired("name")
 
+	showSnapshotCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Repository name (required)")
+	showSnapshotCmd.Flags().StringVarP(&snapshotName, "name", "n", "", "Snapshot name (required)")
+	showSnapshotCmd.Flags().StringSliceVarP(&showIndices, "index", "i", []string{}, "Only list indices matching these names or patterns (comma-separated list)")
+	showSnapshotCmd.MarkFlagRequired("repo")
+	showSnapshotCmd.MarkFlagRequired("name")
+
 	deleteSnapshotCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Repository name (required)")
 	deleteSnapshotCmd.Flags().StringVarP(&snapshotName, "name", "n", "", "Snapshot name (required)")
 	deleteSnapshotCmd.MarkFlagRequired("repo")
@@ -203,17 +291,37 @@ rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for
 	restoreSnapshotCmd.Flags().StringVar(&renamePattern, "rename-pattern", "", "Pattern for renaming indices during restore")
 	restoreSnapshotCmd.Flags().StringVar(&renameReplacement, "rename-replacement", "", "Replacement for renaming indices during restore")
 	restoreSnapshotCmd.Flags().BoolVarP(&waitForCompletion, "wait", "w", false, "Wait for restore completion")
+	restoreSnapshotCmd.Flags().BoolVar(&previewRestore, "preview", false, "Show which indices would be restored and their names after renaming, without restoring")
+	restoreSnapshotCmd.Flags().BoolVar(&restoreGlobalState, "include-global-state", false, "Restore the cluster state: templates, ingest pipelines, ILM policies and persistent settings")
+	restoreSnapshotCmd.Flags().StringSliceVar(&featureStates, "feature-states", nil, "Feature states to restore, such as security,kibana, or none (default is all with --include-global-state, otherwise none)")
+	restoreSnapshotCmd.Flags().BoolVar(&includeAliases, "include-aliases", true, "Restore the aliases of the restored indices")
+	restoreSnapshotCmd.Flags().StringVar(&indexSettingsFile, "index-settings-file", "", "JSON file of index settings overriding those of the restored indices")
+	restoreSnapshotCmd.Flags().Bool("enforce", false, "Fail instead of warning when a name breaks the naming policy")
+	restoreSnapshotCmd.Flags().BoolVar(&followRestore, "follow", false, "Print the progress of the restore until every shard has been restored")
+	restoreSnapshotCmd.Flags().DurationVar(&followInterval, "interval", 10*time.Second, "How often to print the progress with --follow")
+	restoreSnapshotCmd.Flags().DurationVar(&followTimeout, "timeout", 0, "How long to follow the restore before giving up (default is no limit)")
 	restoreSnapshotCmd.MarkFlagRequired("repo")
 	restoreSnapshotCmd.MarkFlagRequired("name")
+	restoreSnapshotCmd.MarkFlagsMutuallyExclusive("wait", "follow")
+	restoreSnapshotCmd.MarkFlagsMutuallyExclusive("preview", "follow")
+
+	restoreStatusCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Repository name (required)")
+	restoreStatusCmd.Flags().StringVarP(&snapshotName, "name", "n", "", "Snapshot name (required)")
+	restoreStatusCmd.Flags().BoolVar(&followRestore, "follow", false, "Print the progress until every shard has been restored")
+	restoreStatusCmd.Flags().DurationVar(&followInterval, "interval", 10*time.Second, "How often to print the progress with --follow")
+	restoreStatusCmd.Flags().DurationVar(&followTimeout, "timeout", 0, "How long to follow the restore before giving up (default is no limit)")
+	restoreStatusCmd.MarkFlagRequired("repo")
+	restoreStatusCmd.MarkFlagRequired("name")
 
 	// Add subcommands
 	repoCmd.AddCommand(listRepoCmd, createRepoCmd, deleteRepoCmd)
-	snapshotCmd.AddCommand(listSnapshotCmd, createSnapshotCmd, deleteSnapshotCmd, restoreSnapshotCmd)
+	snapshotCmd.AddCommand(listSnapshotCmd, showSnapshotCmd, createSnapshotCmd, deleteSnapshotCmd, restoreSnapshotCmd, restoreStatusCmd)
 	rootCmd.AddCommand(repoCmd, snapshotCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -335,6 +443,66 @@ func listSnapshots(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// showSnapshot handles the show snapshot command
+func showSnapshot(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	// Get snapshot contents
+	contents, err := esClient.GetSnapshotContents(repoName, snapshotName, showIndices)
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot contents: %w", err)
+	}
+
+	if len(contents) == 0 {
+		fmt.Printf("No matching indices in snapshot %s\n", snapshotName)
+		return nil
+	}
+
+	// Prepare table data
+	header := []string{"Index", "Shards", "Size", "Files", "In Cluster"}
+	rows := [][]string{}
+	var totalShards int
+	var totalSize int64
+	existing := 0
+
+	for _, index := range contents {
+		inCluster := "no"
+		if index.Exists() {
+			inCluster = "yes (" + index.Status + ")"
+			existing++
+		}
+		totalShards += index.Shards
+		totalSize += index.SizeInBytes
+
+		rows = append(rows, []string{
+			index.Name,
+			fmt.Sprintf("%d", index.Shards),
+			client.ByteCountSI(index.SizeInBytes),
+			fmt.Sprintf("%d", index.FileCount),
+			inCluster,
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Printf("\n%d indices, %d shards, %s in snapshot %s; %d still exist in the cluster\n",
+		len(contents), totalShards, client.ByteCountSI(totalSize), snapshotName, existing)
+	return nil
+}
+
 // createSnapshot handles the create snapshot command
 func createSnapshot(cmd *cobra.Command, args []string) error {
 	// Load configuration with context containing viper instance
@@ -349,26 +517,101 @@ func createSnapshot(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
-	// Create snapshot
-	snapshot, err := esClient.CreateSnapshot(repoName, snapshotName, indices, includeGlobalState, waitForCompletion)
-	if err != nil {
-		return fmt.Errorf("failed to create snapshot: %w", err)
+	if !waitForCompletion {
+		if retryFailed > 0 || resultFile != "" {
+			return fmt.Errorf("--retry-failed and --result-file require --wait")
+		}
+		if _, err := esClient.CreateSnapshot(repoName, snapshotName, indices, includeGlobalState, false); err != nil {
+			return fmt.Errorf("failed to create snapshot: %w", err)
+		}
+		fmt.Printf("Snapshot %s creation started in repository %s\n", snapshotName, repoName)
+		return nil
 	}
 
-	if waitForCompletion {
-		// Format and print snapshot info
-		snapshotJSON, err := json.MarshalIndent(snapshot, "", "  ")
+	// Create snapshot, then retry the indices with failed shards
+	result := snapshotResult{Repository: repoName}
+	name, snapshotIndices, globalState := snapshotName, indices, includeGlobalState
+	for attempt := 0; ; attempt++ {
+		snapshot, err := esClient.CreateSnapshot(repoName, name, snapshotIndices, globalState, true)
 		if err != nil {
-			return fmt.Errorf("failed to format snapshot info: %w", err)
+			if writeErr := writeSnapshotResult(result); writeErr != nil {
+				log.Printf("Warning: %v", writeErr)
+			}
+			return fmt.Errorf("failed to create snapshot %s: %w", name, err)
 		}
-		fmt.Println(string(snapshotJSON))
-	} else {
-		fmt.Printf("Snapshot %s creation started in repository %s\n", snapshotName, repoName)
+		result.Attempts = append(result.Attempts, snapshot)
+
+		fmt.Printf("Snapshot %s finished %s: %d of %d shards successful\n", snapshot.Snapshot, snapshot.State, snapshot.Shards["successful"], snapshot.Shards["total"])
+		if len(snapshot.Failures) == 0 && snapshot.State == "SUCCESS" {
+			result.Succeeded = true
+			break
+		}
+		if err := writeSnapshotFailures(cfg, snapshot); err != nil {
+			return err
+		}
+
+		failed := snapshot.FailedIndices()
+		if attempt >= retryFailed || len(failed) == 0 {
+			result.FailedIndices = failed
+			break
+		}
+
+		// The global state was saved by the first attempt, retries only copy the failed indices
+		name = fmt.Sprintf("%s-retry-%d", snapshotName, attempt+1)
+		snapshotIndices, globalState = failed, false
+		fmt.Printf("Retrying %d indices with failed shards as snapshot %s\n", len(failed), name)
+	}
+
+	if err := writeSnapshotResult(result); err != nil {
+		return err
+	}
+	if !result.Succeeded {
+		last := result.Attempts[len(result.Attempts)-1]
+		return fmt.Errorf("snapshot %s finished %s with %d failed shards", last.Snapshot, last.State, len(last.Failures))
 	}
 
 	return nil
 }
 
+// snapshotResult is the outcome of create --wait written to --result-file
+type snapshotResult struct {
+	Repository    string                 `json:"repository"`
+	Succeeded     bool                   `json:"succeeded"`
+	FailedIndices []string               `json:"failed_indices,omitempty"` // indices still failing after the last attempt
+	Attempts      []*client.SnapshotInfo `json:"attempts"`
+}
+
+// writeSnapshotFailures lists the failed shards of a snapshot
+func writeSnapshotFailures(cfg *config.Config, snapshot *client.SnapshotInfo) error {
+	header := []string{"Index", "Shard", "Node ID", "Status", "Reason"}
+	rows := make([][]string, 0, len(snapshot.Failures))
+	for _, f := range snapshot.Failures {
+		rows = append(rows, []string{f.Index, fmt.Sprintf("%d", f.ShardID), f.NodeID, f.Status, f.Reason})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// writeSnapshotResult writes the result to --result-file, if given
+func writeSnapshotResult(result snapshotResult) error {
+	if resultFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format snapshot result: %w", err)
+	}
+	if err := os.WriteFile(resultFile, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	return nil
+}
+
 // deleteSnapshot handles the delete snapshot command
 func deleteSnapshot(cmd *cobra.Command, args []string) error {
 	// Load configuration with context containing viper instance
@@ -406,8 +649,54 @@ func restoreSnapshot(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
 	}
 
+	// Renamed indices are new names, subject to the naming policy
+	if renamePattern != "" && cfg.Naming.PolicyFile != "" {
+		entries, err := esClient.PreviewRestore(repoName, snapshotName, indices, renamePattern, renameReplacement)
+		if err != nil {
+			return fmt.Errorf("failed to preview restore: %w", err)
+		}
+		var targets []string
+		for _, entry := range entries {
+			if entry.Selected && entry.Target != entry.Index {
+				targets = append(targets, entry.Target)
+			}
+		}
+		if err := client.CheckNames(cfg, client.NamingKindIndex, targets...); err != nil {
+			return err
+		}
+	}
+
+	// Preview only
+	if previewRestore {
+		return previewSnapshotRestore(cfg, esClient)
+	}
+
+	options := client.RestoreOptions{
+		Indices:            indices,
+		RenamePattern:      renamePattern,
+		RenameReplacement:  renameReplacement,
+		IncludeGlobalState: restoreGlobalState,
+		ExcludeAliases:     !includeAliases,
+		WaitForCompletion:  waitForCompletion,
+	}
+	if cmd.Flags().Changed("feature-states") {
+		options.FeatureStates = featureStates
+		if options.FeatureStates == nil {
+			options.FeatureStates = []string{}
+		}
+	}
+	if indexSettingsFile != "" {
+		data, err := os.ReadFile(indexSettingsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read index settings file: %w", err)
+		}
+		if err := json.Unmarshal(data, &options.IndexSettings); err != nil {
+			return fmt.Errorf("failed to parse index settings file %s: %w", indexSettingsFile, err)
+		}
+	}
+
 	// Restore snapshot
-	if err := esClient.RestoreSnapshot(repoName, snapshotName, indices, renamePattern, renameReplacement, waitForCompletion); err != nil {
+	if err := esClient.RestoreSnapshot(repoName, snapshotName, options); err != nil {
 		return fmt.Errorf("failed to restore snapshot: %w", err)
 	}
 
@@ -417,5 +706,146 @@ func restoreSnapshot(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Snapshot %s restore started from repository %s\n", snapshotName, repoName)
 	}
 
+	if followRestore {
+		return followSnapshotRestore(cfg, esClient)
+	}
+	return nil
+}
+
+// restoreStatus handles the restore-status command
+func restoreStatus(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+
+	if followRestore {
+		return followSnapshotRestore(cfg, esClient)
+	}
+
+	progress, err := esClient.GetRestoreProgress(repoName, snapshotName)
+	if err != nil {
+		return fmt.Errorf("failed to get restore progress: %w", err)
+	}
+	if len(progress.Shards) == 0 && progress.Pending == 0 {
+		fmt.Printf("No shards restored from snapshot %s of repository %s\n", snapshotName, repoName)
+		return nil
+	}
+	if err := writeRestoreProgress(cfg, progress); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, restoreSummary(progress))
+	return nil
+}
+
+// followSnapshotRestore prints the progress of the restore until every shard has been restored,
+// then the progress of each shard
+func followSnapshotRestore(cfg *config.Config, esClient *client.Client) error {
+	started := time.Now()
+	for {
+		progress, err := esClient.GetRestoreProgress(repoName, snapshotName)
+		if err != nil {
+			return fmt.Errorf("failed to get restore progress: %w", err)
+		}
+		if progress.Done() {
+			if err := writeRestoreProgress(cfg, progress); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Snapshot %s restored: %s\n", snapshotName, restoreSummary(progress))
+			return nil
+		}
+		if len(progress.Shards) == 0 && progress.Pending == 0 && time.Since(started) >= followInterval {
+			fmt.Printf("No shards restored from snapshot %s of repository %s\n", snapshotName, repoName)
+			return nil
+		}
+		if followTimeout > 0 && time.Since(started) > followTimeout {
+			return fmt.Errorf("snapshot %s not restored after %s: %s", snapshotName, followTimeout, restoreSummary(progress))
+		}
+		fmt.Fprintln(os.Stderr, restoreSummary(progress))
+		time.Sleep(followInterval)
+	}
+}
+
+// writeRestoreProgress writes the progress of each shard of a restore
+func writeRestoreProgress(cfg *config.Config, progress *client.RestoreProgress) error {
+	header := []string{"Index", "Shard", "Stage", "Node", "Size", "Recovered", "Percent", "Time"}
+	rows := [][]string{}
+	for _, shard := range progress.Shards {
+		rows = append(rows, []string{
+			shard.Index,
+			fmt.Sprintf("%d", shard.Shard),
+			shard.Stage,
+			shard.Node,
+			client.ByteCountSI(shard.TotalBytes),
+			client.ByteCountSI(shard.RecoveredBytes),
+			fmt.Sprintf("%.1f%%", shard.Percent()),
+			shard.Elapsed.Round(time.Second).String(),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return nil
+}
+
+// restoreSummary describes the progress of a restore in a line
+func restoreSummary(progress *client.RestoreProgress) string {
+	recovered, total := progress.Bytes()
+	percent := 0.0
+	if total > 0 {
+		percent = 100 * float64(recovered) / float64(total)
+	}
+	return fmt.Sprintf("%d of %d primary shards restored, %d pending, %s of %s copied (%.1f%%)",
+		progress.DoneShards(), len(progress.Shards)+progress.Pending, progress.Pending,
+		client.ByteCountSI(recovered), client.ByteCountSI(total), percent)
+}
+
+// previewSnapshotRestore lists what a restore with the current flags would do
+func previewSnapshotRestore(cfg *config.Config, esClient *client.Client) error {
+	entries, err := esClient.PreviewRestore(repoName, snapshotName, indices, renamePattern, renameReplacement)
+	if err != nil {
+		return fmt.Errorf("failed to preview restore: %w", err)
+	}
+
+	// Prepare table data
+	header := []string{"Snapshot Index", "Restore", "Restored As", "Status", "Detail"}
+	rows := [][]string{}
+	selected := 0
+	problems := 0
+
+	for _, entry := range entries {
+		restore := "no"
+		if entry.Selected {
+			restore = "yes"
+			selected++
+		}
+		switch entry.Status {
+		case "OVERWRITE", "CONFLICT", "DUPLICATE TARGET":
+			problems++
+		}
+
+		rows = append(rows, []string{entry.Index, restore, entry.Target, entry.Status, entry.Detail})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Printf("\n%d of %d indices would be restored", selected, len(entries))
+	if problems > 0 {
+		fmt.Printf(", %d with conflicts", problems)
+	}
+	fmt.Println(" (preview only, nothing was restored)")
+
 	return nil
 }
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Elasticsearch connection
+	addresses    []string
+	username     string
+	password     string
+	caCert       string
+	insecure     bool
+	disableRetry bool
+
+	// Template options
+	component    bool
+	namePattern  string
+	templateName string
+	templateFile string
+	indexName    string
+	replace      bool
+	force        bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "es_templates",
+		Short: "Manage Elasticsearch index and component templates",
+		Long: `List, inspect, create, simulate, compare and delete composable index templates, or component
+templates with --component.
+
+Templates are read and written in the form of the put template APIs, so the output of get can be
+kept in version control, changed, checked with simulate and diff, and put back with
+create-from-file.
+
+Example usage:
+  es_templates list
+  es_templates list --component
+  es_templates get --name logs-app > logs-app.json
+  es_templates diff --name logs-app --file logs-app.json
+  es_templates simulate --file logs-app.json
+  es_templates create-from-file --name logs-app --file logs-app.json --replace`,
+		Example: `es_templates list --name "logs-*"
+es_templates get --name logs-app > logs-app.json
+es_templates get --component --name logs-app@settings
+es_templates simulate --name logs-app
+es_templates simulate --index logs-app-default
+es_templates diff --name logs-app --file logs-app.json
+es_templates create-from-file --name logs-app --file logs-app.json --replace
+es_templates delete --component --name old-mappings`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// List subcommand
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List templates",
+		Long: `List the index templates with their index patterns, priority and component templates, or
+with --component the component templates and the index templates composed of them.
+
+Managed templates belong to Elasticsearch or an integration and are replaced on upgrade; change
+them through their @custom component templates instead.`,
+		RunE: runList,
+	}
+
+	// Get subcommand
+	var getCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Print a template as JSON",
+		Long:  `Print a template as the JSON body of its put API, ready for create-from-file.`,
+		RunE:  runGet,
+	}
+
+	// Create from file subcommand
+	var createCmd = &cobra.Command{
+		Use:   "create-from-file",
+		Short: "Create or replace a template from a JSON file",
+		Long: `Create a template from a JSON file holding the body of its put API. An existing template of
+the same name is only replaced with --replace. The output of get, and of the get template APIs,
+is accepted.`,
+		RunE: runCreate,
+	}
+
+	// Simulate subcommand
+	var simulateCmd = &cobra.Command{
+		Use:   "simulate",
+		Short: "Show the settings, mappings and aliases a template resolves to",
+		Long: `Resolve an index template with its component templates and print the settings, mappings
+and aliases it gives, with the templates it overlaps. Give an existing template with --name, a
+template not yet put with --file, or an index name with --index to see what the templates of the
+cluster would give an index of that name.`,
+		RunE: runSimulate,
+	}
+
+	// Diff subcommand
+	var diffCmd = &cobra.Command{
+		Use:   "diff",
+		Short: "Compare a template with a local JSON file",
+		Long: `List the fields that differ between a template in the cluster and a local JSON file, such as
+the copy kept in version control. Added fields are only in the file, removed ones only in the
+cluster. Settings are compared as Elasticsearch stores them, so number_of_shards: 1 and
+"index.number_of_shards": "1" are equal.
+
+The command exits with status 1 when there are differences.`,
+		RunE: runDiff,
+	}
+
+	// Delete subcommand
+	var deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a template",
+		Long: `Delete a template. Existing indices and data streams keep their settings and mappings.
+Elasticsearch refuses to delete a component template that an index template is composed of, or
+an index template that a data stream uses.`,
+		RunE: runDelete,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Elasticsearch connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "es-addresses", nil, "Elasticsearch addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "es-username", "", "Elasticsearch username")
+	rootCmd.PersistentFlags().StringVar(&password, "es-password", "", "Elasticsearch password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "es-ca-cert", "", "Path to CA certificate for Elasticsearch")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "es-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().BoolVar(&disableRetry, "es-disable-retry", false, "Disable retry on Elasticsearch connection failure")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print the Elasticsearch address that served each request, and skipped addresses that did not answer")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+	rootCmd.PersistentFlags().Bool("cross-tenant", false, "Allow requests to indices outside the tenant_prefix of the configuration")
+
+	// Template flags
+	rootCmd.PersistentFlags().BoolVar(&component, "component", false, "Work on component templates instead of index templates")
+
+	// List command flags
+	listCmd.Flags().StringVar(&namePattern, "name", "*", "Template name or wildcard pattern")
+
+	// Get command flags
+	getCmd.Flags().StringVar(&templateName, "name", "", "Name of the template (required)")
+	getCmd.MarkFlagRequired("name")
+
+	// Create from file command flags
+	createCmd.Flags().StringVar(&templateName, "name", "", "Name of the template (required)")
+	createCmd.Flags().StringVar(&templateFile, "file", "", "JSON file with the template (required)")
+	createCmd.Flags().BoolVar(&replace, "replace", false, "Replace an existing template of the same name")
+	createCmd.MarkFlagRequired("name")
+	createCmd.MarkFlagRequired("file")
+
+	// Simulate command flags
+	simulateCmd.Flags().StringVar(&templateName, "name", "", "Name of an existing index template")
+	simulateCmd.Flags().StringVar(&templateFile, "file", "", "JSON file with an index template to simulate before putting it")
+	simulateCmd.Flags().StringVar(&indexName, "index", "", "Index name to simulate the templates of the cluster for")
+	simulateCmd.MarkFlagsOneRequired("name", "file", "index")
+	simulateCmd.MarkFlagsMutuallyExclusive("name", "file", "index")
+
+	// Diff command flags
+	diffCmd.Flags().StringVar(&templateName, "name", "", "Name of the template in the cluster (required)")
+	diffCmd.Flags().StringVar(&templateFile, "file", "", "JSON file to compare the template with (required)")
+	diffCmd.MarkFlagRequired("name")
+	diffCmd.MarkFlagRequired("file")
+
+	// Delete command flags
+	deleteCmd.Flags().StringVar(&templateName, "name", "", "Name of the template (required)")
+	deleteCmd.Flags().BoolVar(&force, "force", false, "Delete without asking for confirmation")
+	deleteCmd.MarkFlagRequired("name")
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, getCmd, createCmd, simulateCmd, diffCmd, deleteCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	// Use the centralized config initialization function
+	return config.InitializeConfig(cmd, configFile, addresses, username, password, caCert, insecure, disableRetry, outputFormat)
+}
+
+// newClient loads the configuration and creates an Elasticsearch client
+func newClient(cmd *cobra.Command) (*client.Client, *config.Config, error) {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+	return esClient, cfg, nil
+}
+
+// templateKind returns the kind of template the command works on
+func templateKind() string {
+	if component {
+		return client.ComponentTemplateKind
+	}
+	return client.IndexTemplateKind
+}
+
+// getTemplate returns the template with the given name, or an error if there is none
+func getTemplate(esClient *client.Client, name string) (*client.Template, error) {
+	templates, err := esClient.GetTemplates(templateKind(), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s template %s: %w", templateKind(), name, err)
+	}
+	if len(templates) != 1 || templates[0].Name != name {
+		return nil, fmt.Errorf("%s template %s not found", templateKind(), name)
+	}
+	return &templates[0], nil
+}
+
+// readTemplateFile reads a template body from a JSON file. The responses of the get template
+// APIs, holding a single template, are unwrapped.
+func readTemplateFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template file: %w", err)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("error parsing template file %s: %w", path, err)
+	}
+
+	for _, key := range []string{"index_templates", "component_templates"} {
+		list, ok := body[key].([]interface{})
+		if !ok {
+			continue
+		}
+		if len(list) != 1 {
+			return nil, fmt.Errorf("template file %s holds %d templates, expected one", path, len(list))
+		}
+		body, _ = list[0].(map[string]interface{})
+	}
+	for _, key := range []string{"index_template", "component_template"} {
+		if inner, ok := body[key].(map[string]interface{}); ok {
+			body = inner
+		}
+	}
+	return body, nil
+}
+
+// runList handles the list command
+func runList(cmd *cobra.Command, args []string) error {
+	esClient, cfg, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	templates, err := esClient.GetTemplates(templateKind(), namePattern)
+	if err != nil {
+		return fmt.Errorf("failed to get %s templates: %w", templateKind(), err)
+	}
+	if len(templates) == 0 {
+		fmt.Printf("No %s templates found matching '%s'\n", templateKind(), namePattern)
+		return nil
+	}
+
+	var header []string
+	rows := [][]string{}
+	if component {
+		// Find the index templates composed of each component template
+		indexTemplates, err := esClient.GetTemplates(client.IndexTemplateKind, "*")
+		if err != nil {
+			return fmt.Errorf("failed to get index templates: %w", err)
+		}
+		usedBy := make(map[string][]string)
+		for _, t := range indexTemplates {
+			for _, name := range t.ComposedOf {
+				usedBy[name] = append(usedBy[name], t.Name)
+			}
+		}
+
+		header = []string{"Name", "Used By", "Version", "Managed"}
+		for _, t := range templates {
+			rows = append(rows, []string{
+				t.Name,
+				valueOrDash(strings.Join(usedBy[t.Name], ", ")),
+				versionString(t.Version),
+				yesNo(t.Managed),
+			})
+		}
+	} else {
+		header = []string{"Name", "Index Patterns", "Priority", "Composed Of", "Data Stream", "Version", "Managed"}
+		for _, t := range templates {
+			rows = append(rows, []string{
+				t.Name,
+				strings.Join(t.IndexPatterns, ", "),
+				fmt.Sprintf("%d", t.Priority),
+				valueOrDash(strings.Join(t.ComposedOf, ", ")),
+				yesNo(t.DataStream),
+				versionString(t.Version),
+				yesNo(t.Managed),
+			})
+		}
+	}
+
+	// Format and display output
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runGet handles the get command
+func runGet(cmd *cobra.Command, args []string) error {
+	esClient, _, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	t, err := getTemplate(esClient, templateName)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(t.Body, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting template: %w", err)
+	}
+	fmt.Println(string(body))
+	return nil
+}
+
+// runCreate handles the create-from-file command
+func runCreate(cmd *cobra.Command, args []string) error {
+	body, err := readTemplateFile(templateFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding template: %w", err)
+	}
+
+	esClient, cfg, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := client.CheckNames(cfg, client.NamingKindTemplate, templateName); err != nil {
+		return err
+	}
+
+	if err := esClient.PutTemplate(templateKind(), templateName, data, !replace); err != nil {
+		return fmt.Errorf("failed to put %s template %s: %w", templateKind(), templateName, err)
+	}
+
+	if replace {
+		fmt.Printf("%s template '%s' created or replaced from %s\n", capitalize(templateKind()), templateName, templateFile)
+	} else {
+		fmt.Printf("%s template '%s' created from %s\n", capitalize(templateKind()), templateName, templateFile)
+	}
+	return nil
+}
+
+// runSimulate handles the simulate command
+func runSimulate(cmd *cobra.Command, args []string) error {
+	if component {
+		return fmt.Errorf("simulate works on index templates, simulate an index template composed of the component template instead")
+	}
+
+	var data []byte
+	if templateFile != "" {
+		body, err := readTemplateFile(templateFile)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("error encoding template: %w", err)
+		}
+	}
+
+	esClient, _, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, err := esClient.SimulateTemplate(indexName, templateName, data)
+	if err != nil {
+		return fmt.Errorf("failed to simulate template: %w", err)
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting result: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+// runDiff handles the diff command
+func runDiff(cmd *cobra.Command, args []string) error {
+	local, err := readTemplateFile(templateFile)
+	if err != nil {
+		return err
+	}
+
+	esClient, cfg, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	t, err := getTemplate(esClient, templateName)
+	if err != nil {
+		return err
+	}
+
+	changes := client.DiffTemplate(t.Body, local)
+	if len(changes) == 0 {
+		fmt.Printf("%s template '%s' matches %s\n", capitalize(templateKind()), templateName, templateFile)
+		return nil
+	}
+
+	header := []string{"Field", "Change", "Cluster", "File"}
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		rows = append(rows, []string{change.Field, change.Change, valueOrDash(change.Before), valueOrDash(change.After)})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Printf("\n%d fields differ between %s template '%s' and %s\n", len(changes), templateKind(), templateName, templateFile)
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// runDelete handles the delete command
+func runDelete(cmd *cobra.Command, args []string) error {
+	esClient, _, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	t, err := getTemplate(esClient, templateName)
+	if err != nil {
+		return err
+	}
+
+	// Confirm deletion if not forced
+	if !force {
+		warning := ""
+		if t.Managed {
+			warning = " It is managed and may be recreated or needed by Elasticsearch or an integration."
+		}
+		fmt.Printf("Are you sure you want to delete %s template '%s'?%s [y/N] ", templateKind(), templateName, warning)
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	if err := esClient.DeleteTemplate(templateKind(), templateName); err != nil {
+		return fmt.Errorf("failed to delete %s template %s: %w", templateKind(), templateName, err)
+	}
+
+	fmt.Printf("%s template '%s' deleted successfully\n", capitalize(templateKind()), templateName)
+	return nil
+}
+
+// versionString formats a template version, which is optional
+func versionString(version int64) string {
+	if version == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", version)
+}
+
+// yesNo formats a boolean for a table cell
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// capitalize returns s with its first letter in upper case
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "esctl_config",
+		Short: "Manage the contexts of the esctl config file",
+		Long: `List the named clusters in the contexts section of the config file and choose the one
+commands connect to by default, like kubectl config.
+
+Each context has an elasticsearch and a kibana section taking the same settings as the top-level
+sections. Commands connect to the context given with --context, or to current_context when the
+flag is not passed; the ESCTL_CURRENT_CONTEXT environment variable overrides current_context.
+Connection flags such as --es-addresses still override the context.
+
+Example usage:
+  esctl_config get-contexts
+  esctl_config use-context staging
+  esctl_config current-context`,
+		Example: `esctl_config get-contexts
+esctl_config use-context staging
+esctl_config current-context
+es_nodes --context prod`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Use context subcommand
+	var useContextCmd = &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Set the current context in the config file",
+		Long: `Set current_context in the config file to a context of its contexts section. Only that line
+of the file is changed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUseContext,
+	}
+
+	// Current context subcommand
+	var currentContextCmd = &cobra.Command{
+		Use:   "current-context",
+		Short: "Print the current context",
+		Args:  cobra.NoArgs,
+		RunE:  runCurrentContext,
+	}
+
+	// Get contexts subcommand
+	var getContextsCmd = &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List the contexts in the config file",
+		Args:  cobra.NoArgs,
+		RunE:  runGetContexts,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+
+	// Add subcommands
+	rootCmd.AddCommand(useContextCmd, currentContextCmd, getContextsCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, nil, "", "", "", false, false, outputFormat)
+}
+
+// runUseContext handles the use-context command
+func runUseContext(cmd *cobra.Command, args []string) error {
+	if err := config.SetContext(configFile, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Switched to context %q\n", strings.ToLower(args[0]))
+	return nil
+}
+
+// runCurrentContext handles the current-context command
+func runCurrentContext(cmd *cobra.Command, args []string) error {
+	name, err := config.CurrentContext(configFile)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("current_context is not set")
+	}
+	fmt.Println(name)
+	return nil
+}
+
+// runGetContexts handles the get-contexts command
+func runGetContexts(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	contexts, err := config.ListContexts(configFile)
+	if err != nil {
+		return err
+	}
+	if len(contexts) == 0 {
+		fmt.Println("No contexts defined in the config file")
+		return nil
+	}
+
+	header := []string{"Current", "Name", "Elasticsearch", "Kibana"}
+	rows := make([][]string, 0, len(contexts))
+	for _, c := range contexts {
+		current := ""
+		if c.Current {
+			current = "*"
+		}
+		rows = append(rows, []string{current, c.Name, strings.Join(c.Elasticsearch, ", "), strings.Join(c.Kibana, ", ")})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Favorite options
+	force  bool
+	values map[string]string
+	dryRun bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	// Root command
+	var rootCmd = &cobra.Command{
+		Use:   "esctl_favorites",
+		Short: "Save command lines and run them again by name",
+		Long: `Save frequently used esctl command lines under a name and run them again by name.
+
+Favorites are kept in ~/.config/esctl/favorites.json together with the context they were saved
+in, which is passed as --context when they are run. Arguments may hold placeholders such as
+{{index}}; run asks for their values, or takes them from --set.
+
+Example usage:
+  esctl_favorites add big-shards -- es_shards --indices '{{index}}' --primary
+  esctl_favorites list
+  esctl_favorites run big-shards --set index=logs-*`,
+		Example: `esctl_favorites add unassigned -- es_shards --states UNASSIGNED
+esctl_favorites add big-shards --context prod -- es_shards --indices '{{index}}' --primary
+esctl_favorites run big-shards
+esctl_favorites run big-shards --set index=logs-* --context staging
+esctl_favorites remove unassigned`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Add subcommand
+	var addCmd = &cobra.Command{
+		Use:   "add NAME -- COMMAND [ARGS...]",
+		Short: "Save a command line as a favorite",
+		Long: `Save the command line after -- under NAME. The command is saved with the context given with
+--context, or the current context of the config file, unless it sets --context itself.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runAdd,
+	}
+
+	// List subcommand
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the saved favorites",
+		Args:  cobra.NoArgs,
+		RunE:  runList,
+	}
+
+	// Run subcommand
+	var runCmd = &cobra.Command{
+		Use:   "run NAME [-- EXTRA ARGS...]",
+		Short: "Run a saved favorite",
+		Long: `Run the favorite saved under NAME, asking for the value of each placeholder not given with
+--set. Arguments after -- are added to the command line. --context runs the favorite against
+another context than the one it was saved with.
+
+The exit code is that of the command.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runRun,
+	}
+
+	// Remove subcommand
+	var removeCmd = &cobra.Command{
+		Use:   "remove NAME",
+		Short: "Delete a saved favorite",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runRemove,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+
+	// Add command flags
+	addCmd.Flags().BoolVar(&force, "force", false, "Replace a favorite of the same name")
+
+	// Run command flags
+	runCmd.Flags().StringToStringVar(&values, "set", nil, "Placeholder values as name=value (comma-separated or repeated)")
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the command line instead of running it")
+
+	// Add subcommands
+	rootCmd.AddCommand(addCmd, listCmd, runCmd, removeCmd)
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeConfig(cmd, configFile, nil, "", "", "", false, false, outputFormat)
+}
+
+// runAdd handles the add command
+func runAdd(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() != 1 {
+		return fmt.Errorf("give the command to save after --, as in: esctl_favorites add NAME -- es_shards --states UNASSIGNED")
+	}
+
+	path, err := config.FavoritesPath()
+	if err != nil {
+		return err
+	}
+
+	favorite := config.Favorite{Name: args[0], Command: args[1:]}
+	favorite.Context, _ = cmd.Flags().GetString("context")
+	if favorite.Context == "" {
+		// Without a config file there is no current context to record
+		favorite.Context, _ = config.CurrentContext(configFile)
+	}
+
+	if err := config.SaveFavorite(path, favorite, force); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved favorite %q: %s\n", favorite.Name, quoteArgs(favorite.Command))
+	if placeholders := favorite.Placeholders(); len(placeholders) > 0 {
+		fmt.Printf("Placeholders: %s\n", strings.Join(placeholders, ", "))
+	}
+	return nil
+}
+
+// runList handles the list command
+func runList(cmd *cobra.Command, args []string) error {
+	// Load configuration with context containing viper instance
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	path, err := config.FavoritesPath()
+	if err != nil {
+		return err
+	}
+	favorites, err := config.LoadFavorites(path)
+	if err != nil {
+		return err
+	}
+	if len(favorites) == 0 {
+		fmt.Println("No favorites saved, add one with: esctl_favorites add NAME -- COMMAND [ARGS...]")
+		return nil
+	}
+
+	header := []string{"Name", "Command", "Context", "Placeholders"}
+	rows := make([][]string, 0, len(favorites))
+	for _, f := range favorites {
+		rows = append(rows, []string{f.Name, quoteArgs(f.Command), f.Context, strings.Join(f.Placeholders(), ", ")})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(header, rows)
+}
+
+// runRun handles the run command
+func runRun(cmd *cobra.Command, args []string) error {
+	if dash := cmd.ArgsLenAtDash(); (dash >= 0 && dash != 1) || (dash < 0 && len(args) > 1) {
+		return fmt.Errorf("give only the favorite name before --")
+	}
+
+	path, err := config.FavoritesPath()
+	if err != nil {
+		return err
+	}
+	favorite, err := config.GetFavorite(path, args[0])
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("context") {
+		favorite.Context, _ = cmd.Flags().GetString("context")
+	}
+
+	// Ask for the placeholders not given with --set
+	given := make(map[string]string, len(values))
+	for name, value := range values {
+		given[name] = value
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for _, name := range favorite.Placeholders() {
+		if _, ok := given[name]; ok {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: ", name)
+		value, err := reader.ReadString('\n')
+		if err != nil && value == "" {
+			return fmt.Errorf("no value for placeholder %s", name)
+		}
+		given[name] = strings.TrimRight(value, "\r\n")
+	}
+
+	commandLine, err := favorite.Expand(given)
+	if err != nil {
+		return err
+	}
+	commandLine = append(commandLine, args[1:]...)
+
+	if dryRun {
+		fmt.Println(quoteArgs(commandLine))
+		return nil
+	}
+
+	program, err := exec.LookPath(commandLine[0])
+	if err != nil {
+		return fmt.Errorf("error finding %s: %w", commandLine[0], err)
+	}
+
+	command := exec.Command(program, commandLine[1:]...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		// The command has reported its own error, pass on its exit code
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return fmt.Errorf("error running %s: %w", commandLine[0], err)
+	}
+	return nil
+}
+
+// runRemove handles the remove command
+func runRemove(cmd *cobra.Command, args []string) error {
+	path, err := config.FavoritesPath()
+	if err != nil {
+		return err
+	}
+	if err := config.RemoveFavorite(path, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Removed favorite %q\n", args[0])
+	return nil
+}
+
+// quoteArgs joins a command line for display, single quoting arguments the shell would split or
+// expand so it can be pasted into a shell
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$*?[]{}|&;<>()`!#~") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
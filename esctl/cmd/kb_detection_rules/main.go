@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Rule selection
+	ruleTags []string
+	allRules bool
+
+	// Command specific
+	exportFile string
+	importFile string
+	overwrite  bool
+	dryRun     bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_detection_rules",
+		Short: "Export, import, enable and disable Security detection rules",
+		Long: `Manage Elastic Security detection rules for detection-as-code workflows.
+
+Rules can be exported to NDJSON, kept in version control, and imported into the same or
+another Kibana. Rules carrying given tags can be enabled or disabled in bulk, and the failures
+subcommand summarises the rules whose last execution failed, with the error each reported.
+
+Example usage:
+  kb_detection_rules export --tag=custom --output=rules.ndjson
+  kb_detection_rules import --input=rules.ndjson --overwrite
+  kb_detection_rules disable --tag=noisy --dry-run
+  kb_detection_rules failures`,
+		Example: `kb_detection_rules export --output=rules.ndjson
+kb_detection_rules export --tag=custom,team-a --output=rules.ndjson
+kb_detection_rules import --input=rules.ndjson --overwrite
+kb_detection_rules enable --tag=custom
+kb_detection_rules failures --tag=custom`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	// Export command
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export detection rules to NDJSON",
+		Long: `Export detection rules, with the exception lists and connectors they use, to NDJSON.
+Without --tag every rule is exported. Without --output the export is written to stdout.`,
+		RunE: runExport,
+	}
+	exportCmd.Flags().StringSliceVar(&ruleTags, "tag", nil, "Export rules carrying any of these tags (comma-separated)")
+	exportCmd.Flags().StringVarP(&exportFile, "output", "o", "", "File to write the export to (default is stdout)")
+
+	// Import command
+	var importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import detection rules from NDJSON",
+		Long: `Import detection rules from an NDJSON export. Rules that already exist are reported as
+errors unless --overwrite is given, which also replaces their exception lists and connectors.`,
+		RunE: runImport,
+	}
+	importCmd.Flags().StringVarP(&importFile, "input", "i", "", "NDJSON file to import (required)")
+	importCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace rules that already exist")
+	importCmd.MarkFlagRequired("input")
+
+	// Enable and disable commands
+	var enableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Enable the detection rules carrying the given tags",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBulkAction(cmd, "enable")
+		},
+	}
+	var disableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable the detection rules carrying the given tags",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBulkAction(cmd, "disable")
+		},
+	}
+	for _, c := range []*cobra.Command{enableCmd, disableCmd} {
+		c.Flags().StringSliceVar(&ruleTags, "tag", nil, "Change rules carrying any of these tags (comma-separated)")
+		c.Flags().BoolVar(&allRules, "all", false, "Change every rule")
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without changing any rule")
+		c.MarkFlagsOneRequired("tag", "all")
+		c.MarkFlagsMutuallyExclusive("tag", "all")
+	}
+
+	// Failures command
+	var failuresCmd = &cobra.Command{
+		Use:   "failures",
+		Short: "List the rules whose last execution failed",
+		Long: `List the enabled detection rules whose last execution failed or partly failed, with the
+time and error message of that execution, followed by a count of failures per message.`,
+		RunE: runFailures,
+	}
+	failuresCmd.Flags().StringSliceVar(&ruleTags, "tag", nil, "Only check rules carrying any of these tags (comma-separated)")
+
+	// Add subcommands
+	rootCmd.AddCommand(exportCmd, importCmd, enableCmd, disableCmd, failuresCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// newClient loads the configuration and creates a Kibana client
+func newClient(cmd *cobra.Command) (*config.Config, *client.KibanaClient, error) {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	return cfg, c, nil
+}
+
+// runExport exports detection rules
+func runExport(cmd *cobra.Command, args []string) error {
+	_, c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Select the rules by tag, the export API only selects by rule_id
+	var ruleIDs []string
+	if len(ruleTags) > 0 {
+		rules, err := c.FindDetectionRules(ruleTags)
+		if err != nil {
+			return fmt.Errorf("error finding rules: %w", err)
+		}
+		if len(rules) == 0 {
+			return fmt.Errorf("no rules carry the tags %s", strings.Join(ruleTags, ", "))
+		}
+		for _, rule := range rules {
+			ruleIDs = append(ruleIDs, rule.RuleID)
+		}
+	}
+
+	data, err := c.ExportDetectionRules(ruleIDs)
+	if err != nil {
+		return fmt.Errorf("error exporting rules: %w", err)
+	}
+
+	if exportFile == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(exportFile, data, 0644); err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported rules to %s\n", exportFile)
+	return nil
+}
+
+// runImport imports detection rules
+func runImport(cmd *cobra.Command, args []string) error {
+	cfg, c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(importFile)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", importFile, err)
+	}
+
+	result, err := c.ImportDetectionRules(data, overwrite)
+	if err != nil {
+		return fmt.Errorf("error importing rules: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		header := []string{"Rule ID", "Status", "Error"}
+		rows := make([][]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			rows = append(rows, []string{e.RuleID, fmt.Sprintf("%d", e.StatusCode), e.Message})
+		}
+
+		formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("error formatting output: %w", err)
+		}
+	}
+
+	fmt.Printf("Imported %d rules", result.SuccessCount)
+	if len(result.Errors) > 0 {
+		fmt.Printf(", %d failed", len(result.Errors))
+	}
+	fmt.Println()
+
+	if !result.Success {
+		return fmt.Errorf("%d rules could not be imported", len(result.Errors))
+	}
+	return nil
+}
+
+// runBulkAction enables or disables the rules carrying the selected tags
+func runBulkAction(cmd *cobra.Command, action string) error {
+	_, c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.BulkDetectionRuleAction(action, ruleTags, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to %s rules: %w", action, err)
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  %s\n", e)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d of %d rules would be changed (%s), %d could not be\n", result.Succeeded, result.Total, action, result.Failed)
+		return nil
+	}
+
+	fmt.Printf("%d of %d rules changed (%s)", result.Succeeded, result.Total, action)
+	if result.Failed > 0 {
+		fmt.Printf(", %d failed", result.Failed)
+	}
+	fmt.Println()
+
+	if result.Failed > 0 {
+		return fmt.Errorf("failed to %s %d rules", action, result.Failed)
+	}
+	return nil
+}
+
+// runFailures lists the rules whose last execution failed
+func runFailures(cmd *cobra.Command, args []string) error {
+	cfg, c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	rules, err := c.FindDetectionRules(ruleTags)
+	if err != nil {
+		return fmt.Errorf("error finding rules: %w", err)
+	}
+
+	header := []string{"Name", "Rule ID", "Type", "Status", "Last Run", "Message"}
+	var rows [][]string
+	byMessage := make(map[string]int)
+	var messages []string
+	enabled := 0
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		enabled++
+		if !rule.Failed() {
+			continue
+		}
+
+		last := rule.ExecutionSummary.LastExecution
+		rows = append(rows, []string{rule.Name, rule.RuleID, rule.Type, last.Status, last.Date, last.Message})
+
+		// Group by the first line of the message, the rest usually names the rule's indices
+		message := strings.SplitN(last.Message, "\n", 2)[0]
+		if byMessage[message] == 0 {
+			messages = append(messages, message)
+		}
+		byMessage[message]++
+	}
+
+	if len(rows) == 0 {
+		fmt.Printf("No failures in the last execution of %d enabled rules\n", enabled)
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	fmt.Printf("\n%d of %d enabled rules failed their last execution:\n", len(rows), enabled)
+	summary := make([][]string, 0, len(messages))
+	for _, message := range messages {
+		summary = append(summary, []string{fmt.Sprintf("%d", byMessage[message]), message})
+	}
+	return formatter.Write([]string{"Rules", "Message"}, summary)
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // Command line flags
@@ -34,8 +40,27 @@ var (
 	policyNamespace   string
 	monitoringOptions []string
 
+	// Create-specific flags
+	specFile           string
+	fleetServerHostID  string
+	dataOutputID       string
+	monitoringOutputID string
+	unenrollTimeout    int
+	inactivityTimeout  int
+
 	// Delete-specific flags
-	forceDelete bool
+	forceDelete      bool
+	fallbackPolicyID string
+
+	// List-specific flags
+	limit    int
+	page     int
+	perPage  int
+	allPages bool
+
+	// History-specific flags
+	historyLast int
+	showDiff    bool
 )
 
 func main() {
@@ -51,7 +76,9 @@ full lifecycle management of agent policies.`,
 		Example: `kb_fleet_agent_policy list
 kb_fleet_agent_policy create --name="Production Servers" --description="Policy for production web servers"
 kb_fleet_agent_policy update --policy-id=123abc --name="Updated Name"
-kb_fleet_agent_policy delete --policy-id=123abc`,
+kb_fleet_agent_policy copy --policy-id=123abc --name="Copy of 123abc"
+kb_fleet_agent_policy delete --policy-id=123abc
+kb_fleet_agent_policy history --policy-id=123abc --diff`,
 		PersistentPreRunE: initConfig,
 	}
 
@@ -60,6 +87,7 @@ kb_fleet_agent_policy delete --policy-id=123abc`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -67,10 +95,19 @@ kb_fleet_agent_policy delete --policy-id=123abc`,
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
 
 	// List command
 	var listCmd = &cobra.Command{
@@ -80,16 +117,26 @@ kb_fleet_agent_policy delete --policy-id=123abc`,
 		Example: "kb_fleet_agent_policy list",
 		RunE:    listPolicies,
 	}
+	listCmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of agent policies to list (0 for all)")
+	listCmd.Flags().IntVar(&page, "page", 0, "Page of agent policies to list, numbered from 1 (default is every page)")
+	listCmd.Flags().IntVar(&perPage, "per-page", 0, "Number of agent policies requested per page (default is fleet.per_page from the config file, or 100)")
+	listCmd.Flags().BoolVar(&allPages, "all", false, "List every page of agent policies, the default unless --page is given")
 	rootCmd.AddCommand(listCmd)
 
 	// Create command
 	var createCmd = &cobra.Command{
 		Use:   "create",
 		Short: "Create a new agent policy",
-		Long:  "Create a new agent policy in Kibana Fleet",
+		Long: `Create a new agent policy in Kibana Fleet.
+
+For complete control over the policy, use --spec-file with a JSON or YAML file containing any agent
+policy fields (fleet_server_host_id, data_output_id, monitoring_output_id, unenroll_timeout,
+inactivity_timeout, agent_features, overrides, ...). Flags given on the command line override the
+values from the spec file.`,
 		Example: `kb_fleet_agent_policy create --name="Production Servers" --description="Policy for production web servers"
 kb_fleet_agent_policy create --name="Database Hosts" --namespace=prod --monitoring=logs,metrics
-kb_fleet_agent_policy create --id=my-custom-id-001 --name="Custom ID Policy"`,
+kb_fleet_agent_policy create --id=my-custom-id-001 --name="Custom ID Policy"
+kb_fleet_agent_policy create --spec-file=policy.yaml --name="Staging Servers"`,
 		RunE: createPolicy,
 	}
 	createCmd.Flags().StringVar(&customPolicyID, "id", "", "Custom ID for the agent policy (optional, auto-generated if not provided). Must be lowercase alphanumeric with hyphens/underscores, max 36 chars.")
@@ -97,7 +144,12 @@ kb_fleet_agent_policy create --id=my-custom-id-001 --name="Custom ID Policy"`,
 	createCmd.Flags().StringVar(&policyDescription, "description", "", "Description of the agent policy")
 	createCmd.Flags().StringVar(&policyNamespace, "namespace", "default", "Namespace for the agent policy")
 	createCmd.Flags().StringSliceVar(&monitoringOptions, "monitoring", nil, "Monitoring options to enable (logs, metrics, synthetics)")
-	createCmd.MarkFlagRequired("name")
+	createCmd.Flags().StringVar(&specFile, "spec-file", "", "Path to JSON or YAML file containing the full agent policy specification")
+	createCmd.Flags().StringVar(&fleetServerHostID, "fleet-server-host-id", "", "ID of the Fleet Server host to use")
+	createCmd.Flags().StringVar(&dataOutputID, "data-output-id", "", "ID of the output used for data")
+	createCmd.Flags().StringVar(&monitoringOutputID, "monitoring-output-id", "", "ID of the output used for monitoring data")
+	createCmd.Flags().IntVar(&unenrollTimeout, "unenroll-timeout", 0, "Seconds after which inactive agents are unenrolled")
+	createCmd.Flags().IntVar(&inactivityTimeout, "inactivity-timeout", 0, "Seconds after which agents are considered inactive")
 	rootCmd.AddCommand(createCmd)
 
 	// Update command
@@ -117,29 +169,74 @@ kb_fleet_agent_policy update --policy-id=123abc --description="New description"
 	updateCmd.MarkFlagRequired("policy-id")
 	rootCmd.AddCommand(updateCmd)
 
+	// Copy command
+	var copyCmd = &cobra.Command{
+		Use:   "copy",
+		Short: "Copy an agent policy",
+		Long:  "Copy an existing agent policy, including all of its integrations, to a new policy in Kibana Fleet",
+		Example: `kb_fleet_agent_policy copy --policy-id=123abc --name="Copy of 123abc"
+kb_fleet_agent_policy copy --policy-id=123abc --name="Staging Servers" --description="Cloned from production"`,
+		RunE: copyPolicy,
+	}
+	copyCmd.Flags().StringVar(&policyID, "policy-id", "", "ID of the agent policy to copy (required)")
+	copyCmd.Flags().StringVar(&policyName, "name", "", "Name of the new agent policy (required)")
+	copyCmd.Flags().StringVar(&policyDescription, "description", "", "Description of the new agent policy")
+	copyCmd.MarkFlagRequired("policy-id")
+	copyCmd.MarkFlagRequired("name")
+	rootCmd.AddCommand(copyCmd)
+
 	// Delete command
 	var deleteCmd = &cobra.Command{
 		Use:   "delete",
 		Short: "Delete an agent policy",
-		Long:  "Delete an agent policy from Kibana Fleet",
+		Long: `Delete an agent policy from Kibana Fleet.
+
+With --force, agents still assigned to the policy are moved to the fallback policy with the Fleet
+bulk reassign API before the policy is deleted. The fallback policy is taken from
+--fallback-policy-id, then fleet.fallback_policy_id in the config file, then the default policy.`,
 		Example: `kb_fleet_agent_policy delete --policy-id=123abc
-kb_fleet_agent_policy delete --policy-id=123abc --force`,
+kb_fleet_agent_policy delete --policy-id=123abc --force
+kb_fleet_agent_policy delete --policy-id=123abc --force --fallback-policy-id=456def`,
 		RunE: deletePolicy,
 	}
 	deleteCmd.Flags().StringVar(&policyID, "policy-id", "", "ID of the agent policy to delete (required)")
 	deleteCmd.Flags().BoolVar(&forceDelete, "force", false, "Force deletion even if agents are assigned to the policy")
+	deleteCmd.Flags().StringVar(&fallbackPolicyID, "fallback-policy-id", "", "ID of the agent policy to move agents to when forcing deletion (default is the default policy)")
 	deleteCmd.MarkFlagRequired("policy-id")
 	rootCmd.AddCommand(deleteCmd)
 
+	// History command
+	var historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "List the revisions of an agent policy",
+		Long: `List the latest revisions of an agent policy with when each was written and the number of
+settings that changed from the revision before, to answer who changed a policy and what.
+
+Fleet keeps the compiled policy of every revision in the .fleet-policies system index, so the
+revisions are read from Elasticsearch with the connection settings in the elasticsearch section
+of the config file, as a user allowed to read that index. Kibana only records who made the last
+change, so Updated By is only shown for the current revision. Use --diff to list the settings
+that changed in each revision.`,
+		Example: `kb_fleet_agent_policy history --policy-id=123abc
+kb_fleet_agent_policy history --policy-id=123abc --last=3 --diff`,
+		RunE: policyHistory,
+	}
+	historyCmd.Flags().StringVar(&policyID, "policy-id", "", "ID of the agent policy (required)")
+	historyCmd.Flags().IntVar(&historyLast, "last", 10, "Number of revisions to list")
+	historyCmd.Flags().BoolVar(&showDiff, "diff", false, "List the settings that changed in each revision")
+	historyCmd.MarkFlagRequired("policy-id")
+	rootCmd.AddCommand(historyCmd)
+
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
 // initConfig reads in config file and ENV variables if set
 func initConfig(cmd *cobra.Command, args []string) error {
-	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat, outputStyle)
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
 }
 
 // listPolicies handles listing agent policies
@@ -155,6 +252,14 @@ func listPolicies(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
+	fleetClient.SetLimit(limit)
+	if allPages && page > 0 {
+		return fmt.Errorf("--all and --page cannot be used together")
+	}
+	if perPage > 0 {
+		fleetClient.SetPerPage(perPage)
+	}
+	fleetClient.SetPage(page)
 
 	// Get and format agent policies
 	headers, rows, err := fleetClient.GetAgentPoliciesFormatted()
@@ -164,7 +269,11 @@ func listPolicies(cmd *cobra.Command, args []string) error {
 
 	// Output results
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
-	return formatter.Write(headers, rows)
+	if err := formatter.Write(headers, rows); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, fleetClient.PageSummary(len(rows), "agent policies"))
+	return nil
 }
 
 // createPolicy handles agent policy creation
@@ -181,13 +290,50 @@ func createPolicy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
 
-	// Create policy object
-	policy := client.AgentPolicy{
-		ID:                customPolicyID, // Will be empty if not provided
-		Name:              policyName,
-		Description:       policyDescription,
-		Namespace:         policyNamespace,
-		MonitoringEnabled: monitoringOptions,
+	// Start from the spec file if provided
+	var policy client.AgentPolicy
+	if specFile != "" {
+		policy, err = loadPolicySpec(specFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Command-line values take precedence over the spec file
+	flags := cmd.Flags()
+	if flags.Changed("id") {
+		policy.ID = customPolicyID
+	}
+	if flags.Changed("name") {
+		policy.Name = policyName
+	}
+	if flags.Changed("description") {
+		policy.Description = policyDescription
+	}
+	if flags.Changed("namespace") || policy.Namespace == "" {
+		policy.Namespace = policyNamespace
+	}
+	if flags.Changed("monitoring") {
+		policy.MonitoringEnabled = monitoringOptions
+	}
+	if flags.Changed("fleet-server-host-id") {
+		policy.FleetServerHostID = fleetServerHostID
+	}
+	if flags.Changed("data-output-id") {
+		policy.DataOutputID = dataOutputID
+	}
+	if flags.Changed("monitoring-output-id") {
+		policy.MonitoringOutputID = monitoringOutputID
+	}
+	if flags.Changed("unenroll-timeout") {
+		policy.UnenrollTimeout = unenrollTimeout
+	}
+	if flags.Changed("inactivity-timeout") {
+		policy.InactivityTimeout = inactivityTimeout
+	}
+
+	if policy.Name == "" {
+		return fmt.Errorf("policy name is required: use --name or set name in the spec file")
 	}
 
 	// Create the policy
@@ -202,6 +348,40 @@ func createPolicy(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// loadPolicySpec reads an agent policy specification from a JSON file, or a YAML file when its
+// extension is .yaml or .yml. Keys are kept as written, so free-form maps such as overrides
+// keep their case and dotted names.
+func loadPolicySpec(path string) (client.AgentPolicy, error) {
+	var policy client.AgentPolicy
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read spec file: %w", err)
+	}
+
+	// Convert YAML to JSON so the API field names apply
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var spec interface{}
+		if err := yaml.Unmarshal(data, &spec); err != nil {
+			return policy, fmt.Errorf("failed to parse spec file: %w", err)
+		}
+		data, err = json.Marshal(spec)
+		if err != nil {
+			return policy, fmt.Errorf("failed to convert spec file: %w", err)
+		}
+	}
+
+	// Reject unknown fields
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return policy, fmt.Errorf("failed to parse spec file: %w", err)
+	}
+
+	return policy, nil
+}
+
 // updatePolicy handles agent policy updates
 func updatePolicy(cmd *cobra.Command, args []string) error {
 	// Load configuration
@@ -260,6 +440,32 @@ func updatePolicy(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// copyPolicy handles agent policy copies
+func copyPolicy(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	// Copy the policy
+	copiedPolicy, err := fleetClient.CopyAgentPolicy(policyID, policyName, policyDescription)
+	if err != nil {
+		return fmt.Errorf("failed to copy agent policy: %w", err)
+	}
+
+	// Output success message with new policy info
+	fmt.Printf("Agent policy copied successfully\nSource ID: %s\nID: %s\nName: %s\n",
+		policyID, copiedPolicy.ID, copiedPolicy.Name)
+	return nil
+}
+
 // deletePolicy handles agent policy deletion
 func deletePolicy(cmd *cobra.Command, args []string) error {
 	// Load configuration
@@ -274,8 +480,17 @@ func deletePolicy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
 
+	// Fall back to the configured policy when none is given
+	fallback := fallbackPolicyID
+	if fallback == "" {
+		fallback = cfg.Fleet.FallbackPolicyID
+	}
+
 	// Delete the policy with force flag if specified
-	err = fleetClient.DeleteAgentPolicy(policyID, forceDelete)
+	progress := func(reassigned, total int) {
+		fmt.Printf("Reassigned %d/%d agents\n", reassigned, total)
+	}
+	err = fleetClient.DeleteAgentPolicy(policyID, forceDelete, fallback, progress)
 	if err != nil {
 		return fmt.Errorf("failed to delete agent policy: %w", err)
 	}
@@ -283,3 +498,96 @@ func deletePolicy(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Agent policy %s deleted successfully\n", policyID)
 	return nil
 }
+
+// policyHistory lists the revisions of an agent policy and what changed in each
+func policyHistory(cmd *cobra.Command, args []string) error {
+	if historyLast <= 0 {
+		return fmt.Errorf("--last must be greater than zero")
+	}
+
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// The current revision and who made it come from Kibana
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+	policies, err := fleetClient.GetAgentPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to get Fleet agent policies: %w", err)
+	}
+	var current *client.AgentPolicy
+	for i := range policies {
+		if policies[i].ID == policyID {
+			current = &policies[i]
+			break
+		}
+	}
+	if current == nil {
+		return fmt.Errorf("agent policy %s not found", policyID)
+	}
+
+	// Earlier revisions come from Elasticsearch, one more than listed to diff the oldest
+	esClient, err := client.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+	revisions, err := esClient.GetAgentPolicyRevisions(policyID, historyLast+1)
+	if err != nil {
+		return fmt.Errorf("failed to get revisions of agent policy %s: %w", policyID, err)
+	}
+	if len(revisions) == 0 {
+		fmt.Printf("No revisions of agent policy %s (%s) found in .fleet-policies, current revision is %d\n", current.Name, policyID, current.Revision)
+		return nil
+	}
+
+	header := []string{"Revision", "Updated At", "Updated By", "Changes"}
+	rows := make([][]string, 0, historyLast)
+	diffHeader := []string{"Revision", "Setting", "Old", "New"}
+	var diffRows [][]string
+	for i, revision := range revisions {
+		if i == historyLast {
+			break
+		}
+
+		updatedBy := "-"
+		if revision.Revision == current.Revision && current.UpdatedBy != "" {
+			updatedBy = current.UpdatedBy
+		}
+
+		changes := "-"
+		if i+1 < len(revisions) {
+			diff := client.DiffPolicyRevisions(revisions[i+1], revision)
+			changes = fmt.Sprintf("%d", len(diff))
+			for _, change := range diff {
+				diffRows = append(diffRows, []string{fmt.Sprintf("%d", revision.Revision), change.Path, valueOrDash(change.Old), valueOrDash(change.New)})
+			}
+		}
+
+		rows = append(rows, []string{fmt.Sprintf("%d", revision.Revision), revision.Timestamp, updatedBy, changes})
+	}
+
+	fmt.Printf("Agent policy %s (%s), current revision %d\n\n", current.Name, policyID, current.Revision)
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return err
+	}
+
+	if showDiff && len(diffRows) > 0 {
+		fmt.Println()
+		return formatter.Write(diffHeader, diffRows)
+	}
+	return nil
+}
+
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -31,12 +34,32 @@ var (
 	// Agent filtering
 	kuery string
 	agentID string
+	limit    int
+	page     int
+	perPage  int
+	allPages bool
 
 	// Agent operations
 	agentTags []string
 	policyID string
 	forceDelete bool
 	metadataFile string
+
+	// Tag operations
+	tagNames     []string
+	requiredTags []string
+	tagsDryRun   bool
+
+	// Cleanup operations
+	offlineLongerThan string
+	unenroll          bool
+	forceUnenroll     bool
+	cleanupDryRun     bool
+
+	// Find operations
+	findHostname string
+	findIP       string
+	findOS       string
 )
 
 func main() {
@@ -51,17 +74,28 @@ This command provides agent management capabilities aligned with the Fleet polic
 Operations include:
 - Listing all agents with filtering
 - Viewing detailed agent information
+- Finding agents by host name, IP address or OS
 - Updating agent metadata and tags
 - Reassigning agents between policies
 - Unenrolling/deleting agents
+- Auditing tags and adding/removing tags in bulk
+- Unenrolling agents that have been offline for a long time
 
 Example usage:
   kb_fleet_agents --kb-addresses=https://kibana:5601
   kb_fleet_agents --kuery="policy_id:default-policy"
-  kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012`,
+  kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012
+  kb_fleet_agents find --hostname=web-042 --ip=10.2.3.4
+  kb_fleet_agents tags missing
+  kb_fleet_agents tags add --tag=prod --kuery="policy_id:default-policy"`,
 		Example:           `kb_fleet_agents
 kb_fleet_agents --kuery="policy_id:default-policy"
-kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012`,
+kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012
+kb_fleet_agents find --hostname="web-*" --os=ubuntu
+kb_fleet_agents tags
+kb_fleet_agents tags missing --required-tags=env,team
+kb_fleet_agents tags remove --tag=legacy --kuery="tags:legacy" --dry-run
+kb_fleet_agents cleanup --offline-longer-than=30d --force-unenroll --dry-run`,
 		PersistentPreRunE: initConfig,
 		RunE:              listAgents, // Default action is to list agents
 	}
@@ -71,6 +105,7 @@ kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -78,13 +113,26 @@ kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012`,
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
 
 	// Agent filtering flag for root command (list)
 	rootCmd.Flags().StringVar(&kuery, "kuery", "", "Filter agents using KQL syntax (e.g. 'policy_id:\"default-policy\"')")
+	rootCmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of agents to list (0 for all)")
+	rootCmd.Flags().IntVar(&page, "page", 0, "Page of agents to list, numbered from 1 (default is every page)")
+	rootCmd.Flags().IntVar(&perPage, "per-page", 0, "Number of agents requested per page (default is fleet.per_page from the config file, or 100)")
+	rootCmd.Flags().BoolVar(&allPages, "all", false, "List every page of agents, the default unless --page is given")
 
 	// Get command
 	getCmd := &cobra.Command{
@@ -135,9 +183,93 @@ kb_fleet_agents get --agent-id=12345678-1234-1234-1234-123456789012`,
 	deleteCmd.MarkFlagRequired("agent-id")
 	rootCmd.AddCommand(deleteCmd)
 
+	// Tags command
+	tagsCmd := &cobra.Command{
+		Use:   "tags",
+		Short: "List tags in use across Fleet agents",
+		Long:  "List every tag in use across the selected agents with the number of agents carrying it",
+		RunE:  listTags,
+	}
+	tagsCmd.PersistentFlags().StringVar(&kuery, "kuery", "", "Select agents using KQL syntax (e.g. 'policy_id:\"default-policy\"')")
+
+	tagsMissingCmd := &cobra.Command{
+		Use:   "missing",
+		Short: "Find agents missing required tags",
+		Long: `Find agents that do not carry every required tag.
+
+The required tags are read from fleet.required_tags in the config file and can be
+overridden with --required-tags.`,
+		RunE: listMissingTags,
+	}
+	tagsMissingCmd.Flags().StringSliceVar(&requiredTags, "required-tags", nil, "Tags every agent must carry (comma-separated, defaults to fleet.required_tags)")
+
+	tagsAddCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add tags to every agent matching a kuery",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return bulkUpdateTags(cmd, tagNames, nil)
+		},
+	}
+
+	tagsRemoveCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove tags from every agent matching a kuery",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return bulkUpdateTags(cmd, nil, tagNames)
+		},
+	}
+
+	for _, c := range []*cobra.Command{tagsAddCmd, tagsRemoveCmd} {
+		c.Flags().StringSliceVar(&tagNames, "tag", nil, "Tags to add or remove (comma-separated, required)")
+		c.Flags().BoolVar(&tagsDryRun, "dry-run", false, "Show the agents that would change without updating them")
+		c.MarkFlagRequired("tag")
+	}
+	tagsCmd.AddCommand(tagsMissingCmd, tagsAddCmd, tagsRemoveCmd)
+	rootCmd.AddCommand(tagsCmd)
+
+	// Cleanup command
+	cleanupCmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Find and unenroll agents that have been offline for a long time",
+		Long: `Find agents that have not checked in for longer than a threshold and unenroll them in bulk.
+
+Without --unenroll or --force-unenroll the long-offline agents are only listed. --unenroll asks
+the agents to unenroll, which only completes if they come back online; --force-unenroll revokes
+their API keys and removes them from Fleet at once, which is usually what is wanted for agents
+that are gone for good. Use --dry-run to see which agents would be unenrolled, and --kuery to
+limit the cleanup to some agents.`,
+		RunE: cleanupAgents,
+	}
+	cleanupCmd.Flags().StringVar(&offlineLongerThan, "offline-longer-than", "", "Select agents whose last check-in is older than this, e.g. 30d or 12h (required)")
+	cleanupCmd.Flags().StringVar(&kuery, "kuery", "", "Only consider agents matching this KQL filter")
+	cleanupCmd.Flags().BoolVar(&unenroll, "unenroll", false, "Unenroll the selected agents")
+	cleanupCmd.Flags().BoolVar(&forceUnenroll, "force-unenroll", false, "Unenroll the selected agents and revoke their API keys immediately")
+	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "Show the agents that would be unenrolled without unenrolling them")
+	cleanupCmd.MarkFlagRequired("offline-longer-than")
+	cleanupCmd.MarkFlagsMutuallyExclusive("unenroll", "force-unenroll")
+	rootCmd.AddCommand(cleanupCmd)
+
+	// Find command
+	findCmd := &cobra.Command{
+		Use:   "find",
+		Short: "Find agents by host name, IP address or OS",
+		Long: `Find the agents running on a host, by the metadata each agent reports about its host.
+
+--hostname matches the host name (host.hostname or host.name) and accepts * wildcards, --ip
+matches any of the host's IP addresses and --os matches part of the OS name, platform or full
+description, ignoring case. Given together, an agent has to match all of them.`,
+		RunE: findAgents,
+	}
+	findCmd.Flags().StringVar(&findHostname, "hostname", "", "Host name of the agent, * matches any characters")
+	findCmd.Flags().StringVar(&findIP, "ip", "", "IP address of the agent's host")
+	findCmd.Flags().StringVar(&findOS, "os", "", "Part of the OS name, platform or description (e.g. ubuntu, windows)")
+	findCmd.MarkFlagsOneRequired("hostname", "ip", "os")
+	rootCmd.AddCommand(findCmd)
+
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -160,6 +292,14 @@ func listAgents(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
+	fleetClient.SetLimit(limit)
+	if allPages && page > 0 {
+		return fmt.Errorf("--all and --page cannot be used together")
+	}
+	if perPage > 0 {
+		fleetClient.SetPerPage(perPage)
+	}
+	fleetClient.SetPage(page)
 
 	// Get Fleet agents
 	headers, rows, err := fleetClient.GetAgentsFormatted(kuery)
@@ -168,6 +308,62 @@ func listAgents(cmd *cobra.Command, args []string) error {
 	}
 
 	// Output results
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(headers, rows); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, fleetClient.PageSummary(len(rows), "agents"))
+	return nil
+}
+
+// findAgents lists the agents whose host metadata matches the search flags
+func findAgents(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	agents, err := fleetClient.FindAgents(client.AgentSearch{Hostname: findHostname, IP: findIP, OS: findOS})
+	if err != nil {
+		return fmt.Errorf("failed to find Fleet agents: %w", err)
+	}
+	if len(agents) == 0 {
+		fmt.Println("No agents found")
+		return nil
+	}
+
+	// Show policy names next to their IDs
+	policyNames := make(map[string]string)
+	policies, err := fleetClient.GetAgentPolicies()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not get agent policy names: %v\n", err)
+	}
+	for _, policy := range policies {
+		policyNames[policy.ID] = policy.Name
+	}
+
+	headers := []string{"ID", "Hostname", "IP", "OS", "Policy", "Policy ID", "Status", "Last Check-in"}
+	rows := make([][]string, 0, len(agents))
+	for _, a := range agents {
+		rows = append(rows, []string{
+			a.ID,
+			client.AgentHostname(a),
+			strings.Join(client.AgentIPs(a), ", "),
+			client.AgentOS(a),
+			policyNames[a.PolicyID],
+			a.PolicyID,
+			a.Status,
+			a.LastCheckin,
+		})
+	}
+
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
 	return formatter.Write(headers, rows)
 }
@@ -281,3 +477,249 @@ func deleteAgent(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Agent %s deleted successfully\n", agentID)
 	return nil
 }
+
+// listTags lists the tags in use with the number of agents carrying each
+func listTags(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	// Get all selected agents
+	agents, err := fleetClient.GetAllAgents(kuery)
+	if err != nil {
+		return fmt.Errorf("failed to get Fleet agents: %w", err)
+	}
+
+	counts := client.CountAgentTags(agents)
+	if len(counts) == 0 {
+		fmt.Printf("No tags in use across %d agents\n", len(agents))
+		return nil
+	}
+
+	// Prepare table data
+	headers := []string{"Tag", "Agents"}
+	rows := make([][]string, 0, len(counts))
+	for _, count := range counts {
+		rows = append(rows, []string{count.Tag, fmt.Sprintf("%d", count.Agents)})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(headers, rows)
+}
+
+// listMissingTags lists agents that lack one or more required tags
+func listMissingTags(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Flags take precedence over the configured required tags
+	required := requiredTags
+	if len(required) == 0 {
+		required = cfg.Fleet.RequiredTags
+	}
+	if len(required) == 0 {
+		return fmt.Errorf("no required tags: set fleet.required_tags in the config file or use --required-tags")
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	// Get all selected agents
+	agents, err := fleetClient.GetAllAgents(kuery)
+	if err != nil {
+		return fmt.Errorf("failed to get Fleet agents: %w", err)
+	}
+
+	missing := client.FindAgentsMissingTags(agents, required)
+	if len(missing) == 0 {
+		fmt.Printf("All %d agents carry the required tags: %s\n", len(agents), strings.Join(required, ", "))
+		return nil
+	}
+
+	// Prepare table data
+	headers := []string{"ID", "Hostname", "Status", "Policy ID", "Tags", "Missing Tags"}
+	rows := make([][]string, 0, len(missing))
+	for _, m := range missing {
+		rows = append(rows, []string{
+			m.Agent.ID,
+			client.AgentHostname(m.Agent),
+			m.Agent.Status,
+			m.Agent.PolicyID,
+			strings.Join(m.Agent.Tags, ", "),
+			strings.Join(m.Missing, ", "),
+		})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(headers, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Printf("\n%d of %d agents are missing required tags\n", len(missing), len(agents))
+	return nil
+}
+
+// bulkUpdateTags adds or removes tags on every agent matching the kuery
+func bulkUpdateTags(cmd *cobra.Command, tagsToAdd, tagsToRemove []string) error {
+	// A bulk update without a kuery would touch every agent in Fleet
+	if kuery == "" {
+		return fmt.Errorf("--kuery is required to select the agents to update")
+	}
+
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	// Get the selected agents
+	agents, err := fleetClient.GetAllAgents(kuery)
+	if err != nil {
+		return fmt.Errorf("failed to get Fleet agents: %w", err)
+	}
+	if len(agents) == 0 {
+		fmt.Printf("No agents match %s\n", kuery)
+		return nil
+	}
+
+	if tagsDryRun {
+		headers := []string{"ID", "Hostname", "Current Tags", "New Tags"}
+		rows := make([][]string, 0, len(agents))
+		for _, agent := range agents {
+			rows = append(rows, []string{
+				agent.ID,
+				client.AgentHostname(agent),
+				strings.Join(agent.Tags, ", "),
+				strings.Join(applyTagChanges(agent.Tags, tagsToAdd, tagsToRemove), ", "),
+			})
+		}
+
+		formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+		if err := formatter.Write(headers, rows); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		fmt.Printf("\nDry run: %d agents would be updated\n", len(agents))
+		return nil
+	}
+
+	// Update tags in bulk
+	actionID, err := fleetClient.BulkUpdateAgentTags(kuery, tagsToAdd, tagsToRemove)
+	if err != nil {
+		return fmt.Errorf("failed to update agent tags: %w", err)
+	}
+
+	fmt.Printf("Tag update for %d agents submitted (action %s)\n", len(agents), actionID)
+	return nil
+}
+
+// applyTagChanges returns the tags an agent would carry after adding and removing tags
+func applyTagChanges(current, tagsToAdd, tagsToRemove []string) []string {
+	remove := make(map[string]bool, len(tagsToRemove))
+	for _, tag := range tagsToRemove {
+		remove[tag] = true
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range append(append([]string{}, current...), tagsToAdd...) {
+		if remove[tag] || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// cleanupAgents lists, and optionally unenrolls, agents that have been offline for longer than a threshold
+func cleanupAgents(cmd *cobra.Command, args []string) error {
+	threshold, err := client.ParseTimeValue(offlineLongerThan)
+	if err != nil {
+		return fmt.Errorf("invalid --offline-longer-than: %w", err)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	// Get the offline agents and keep those offline for longer than the threshold
+	agents, err := fleetClient.GetOfflineAgents(kuery)
+	if err != nil {
+		return fmt.Errorf("failed to get Fleet agents: %w", err)
+	}
+	inactive := client.FindInactiveAgents(agents, threshold, time.Now())
+	if len(inactive) == 0 {
+		fmt.Printf("No agents have been offline for longer than %s\n", offlineLongerThan)
+		return nil
+	}
+
+	headers := []string{"ID", "Hostname", "Status", "Policy ID", "Last Check-in", "Offline For"}
+	rows := make([][]string, 0, len(inactive))
+	ids := make([]string, 0, len(inactive))
+	for _, a := range inactive {
+		lastCheckin := a.LastSeen.UTC().Format(time.RFC3339)
+		if a.NeverCheckedIn {
+			lastCheckin = "never (enrolled " + lastCheckin + ")"
+		}
+		rows = append(rows, []string{
+			a.Agent.ID,
+			client.AgentHostname(a.Agent),
+			a.Agent.Status,
+			a.Agent.PolicyID,
+			lastCheckin,
+			fmt.Sprintf("%dd", int(a.OfflineFor.Hours()/24)),
+		})
+		ids = append(ids, a.Agent.ID)
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(headers, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if !unenroll && !forceUnenroll {
+		fmt.Printf("\n%d agents have been offline for longer than %s (use --unenroll or --force-unenroll to remove them)\n", len(inactive), offlineLongerThan)
+		return nil
+	}
+	if cleanupDryRun {
+		fmt.Printf("\nDry run: %d agents would be unenrolled\n", len(inactive))
+		return nil
+	}
+
+	// Unenroll in bulk
+	actionIDs, err := fleetClient.BulkUnenrollAgents(ids, forceUnenroll)
+	if err != nil {
+		return fmt.Errorf("failed to unenroll agents: %w", err)
+	}
+
+	fmt.Printf("\nUnenrollment of %d agents submitted (actions %s)\n", len(inactive), strings.Join(actionIDs, ", "))
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -39,6 +41,18 @@ var (
 	packageVersion       string
 	force                bool
 	jsonConfigFile       string
+	settings             []string
+
+	// List-specific flags
+	limit    int
+	page     int
+	perPage  int
+	allPages bool
+
+	// Namespace audit flags
+	allowedNamespaces []string
+	auditAllSpaces    bool
+	showAllPolicies   bool
 )
 
 func main() {
@@ -63,6 +77,7 @@ kb_fleet_package_policy delete --policy-id=xyz789`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -70,10 +85,19 @@ kb_fleet_package_policy delete --policy-id=xyz789`,
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
 
 	// List command
 	var listCmd = &cobra.Command{
@@ -83,6 +107,10 @@ kb_fleet_package_policy delete --policy-id=xyz789`,
 		Example: "kb_fleet_package_policy list",
 		RunE:    listPackagePolicies,
 	}
+	listCmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of package policies to list (0 for all)")
+	listCmd.Flags().IntVar(&page, "page", 0, "Page of package policies to list, numbered from 1 (default is every page)")
+	listCmd.Flags().IntVar(&perPage, "per-page", 0, "Number of package policies requested per page (default is fleet.per_page from the config file, or 100)")
+	listCmd.Flags().BoolVar(&allPages, "all", false, "List every page of package policies, the default unless --page is given")
 	rootCmd.AddCommand(listCmd)
 
 	// Create command
@@ -122,10 +150,23 @@ kb_fleet_package_policy create --name="elasticsearch-metrics" --agent-policy-id=
 	var updateCmd = &cobra.Command{
 		Use:   "update",
 		Short: "Update a package policy",
-		Long:  "Update an existing package policy in Kibana Fleet",
+		Long: `Update an existing package policy in Kibana Fleet.
+
+Single inputs and variables can be changed with --set path=value, which is merged into the
+existing policy instead of replacing its configuration as --config-json does. Paths follow
+the simplified policy format:
+- vars.NAME: a package variable
+- inputs.INPUT.enabled, inputs.INPUT.vars.NAME: an input and its variables
+- inputs.INPUT.streams.DATASET.enabled, inputs.INPUT.streams.DATASET.vars.NAME: a stream
+
+INPUT may be given without the policy template prefix (logfile for system-logfile), and
+DATASET without the package prefix (syslog for system.syslog). Values are read as JSON, so
+false, 30 and '["/var/log/*.log"]' keep their type; other values are strings.`,
 		Example: `kb_fleet_package_policy update --policy-id=xyz789 --name="updated-name"
 kb_fleet_package_policy update --policy-id=xyz789 --description="New description"
-kb_fleet_package_policy update --policy-id=xyz789 --config-json=updated-config.json`,
+kb_fleet_package_policy update --policy-id=xyz789 --config-json=updated-config.json
+kb_fleet_package_policy update --policy-id=xyz789 --set inputs.logfile.enabled=false
+kb_fleet_package_policy update --policy-id=xyz789 --set inputs.logfile.streams.syslog.vars.paths='["/var/log/app/*.log"]'`,
 		RunE: updatePackagePolicy,
 	}
 	updateCmd.Flags().StringVar(&packagePolicyID, "policy-id", "", "ID of the package policy to update (required)")
@@ -133,6 +174,7 @@ kb_fleet_package_policy update --policy-id=xyz789 --config-json=updated-config.j
 	updateCmd.Flags().StringVar(&description, "description", "", "New description for the package policy")
 	updateCmd.Flags().StringVar(&namespace, "namespace", "", "New namespace for the package policy")
 	updateCmd.Flags().StringVar(&jsonConfigFile, "config-json", "", "Path to JSON file containing updated integration configuration")
+	updateCmd.Flags().StringArrayVar(&settings, "set", nil, "Set an input or variable as path=value, such as inputs.logfile.enabled=false (repeatable)")
 	updateCmd.MarkFlagRequired("policy-id")
 	rootCmd.AddCommand(updateCmd)
 
@@ -150,9 +192,39 @@ kb_fleet_package_policy delete --policy-id=xyz789 --force`,
 	deleteCmd.MarkFlagRequired("policy-id")
 	rootCmd.AddCommand(deleteCmd)
 
+	// Audit namespaces command
+	var auditNamespacesCmd = &cobra.Command{
+		Use:   "audit-namespaces",
+		Short: "Report package policies writing into unexpected namespaces",
+		Long: `Check the namespace every package policy writes its data streams into against the allowed
+namespaces, and report those outside them. Data streams in an unexpected namespace miss the index
+templates and ILM policies set up for the expected ones.
+
+A package policy writes into its own namespace, or into the namespace of its agent policy when it
+sets none; a package policy shared by several agent policies is checked once for each of them.
+
+The allowed namespaces are fleet.allowed_namespaces from the config file, or --allowed-namespaces.
+They may use * wildcards. fleet.space_namespaces gives the allowed namespaces of the policies in
+a Kibana space, in place of fleet.allowed_namespaces. When Fleet keeps policies per space, each
+policy is checked against the spaces of its agent policy; otherwise every space lists every
+policy, and a namespace allowed in any of the spaces checked is accepted.
+
+Without --all-spaces the configured space, or --space, is checked. The exit code is 1 when a
+package policy writes into an unexpected namespace.`,
+		Example: `kb_fleet_package_policy audit-namespaces
+kb_fleet_package_policy audit-namespaces --allowed-namespaces=default,prod-*
+kb_fleet_package_policy audit-namespaces --all-spaces --all`,
+		RunE: auditNamespaces,
+	}
+	auditNamespacesCmd.Flags().StringSliceVar(&allowedNamespaces, "allowed-namespaces", nil, "Allowed namespaces, * wildcards allowed (default is fleet.allowed_namespaces and fleet.space_namespaces from the config file)")
+	auditNamespacesCmd.Flags().BoolVar(&auditAllSpaces, "all-spaces", false, "Check the policies of every space the user can see")
+	auditNamespacesCmd.Flags().BoolVar(&showAllPolicies, "all", false, "List every package policy, not only those writing into unexpected namespaces")
+	rootCmd.AddCommand(auditNamespacesCmd)
+
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -174,6 +246,14 @@ func listPackagePolicies(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
+	fleetClient.SetLimit(limit)
+	if allPages && page > 0 {
+		return fmt.Errorf("--all and --page cannot be used together")
+	}
+	if perPage > 0 {
+		fleetClient.SetPerPage(perPage)
+	}
+	fleetClient.SetPage(page)
 
 	// Get package policies
 	policies, err := fleetClient.GetPackagePolicies()
@@ -188,6 +268,7 @@ func listPackagePolicies(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("error marshaling to JSON: %w", err)
 		}
 		fmt.Println(string(jsonOutput))
+		fmt.Fprintln(os.Stderr, fleetClient.PageSummary(len(policies), "package policies"))
 		return nil
 	}
 
@@ -199,7 +280,11 @@ func listPackagePolicies(cmd *cobra.Command, args []string) error {
 
 	// Output results
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
-	return formatter.Write(headers, rows)
+	if err := formatter.Write(headers, rows); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, fleetClient.PageSummary(len(rows), "package policies"))
+	return nil
 }
 
 // formatPackagePolicies converts package policies to tabular format
@@ -295,6 +380,13 @@ func updatePackagePolicy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
 
+	if len(settings) > 0 {
+		if jsonConfigFile != "" {
+			return fmt.Errorf("--set and --config-json cannot be used together")
+		}
+		return updatePackagePolicySettings(fleetClient)
+	}
+
 	// Get the existing policy
 	policies, err := fleetClient.GetPackagePolicies()
 	if err != nil {
@@ -352,6 +444,62 @@ func updatePackagePolicy(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// updatePackagePolicySettings merges the --set values into the existing package policy and
+// prints what changed
+func updatePackagePolicySettings(fleetClient *client.FleetClient) error {
+	policy, err := fleetClient.GetPackagePolicyConfig(packagePolicyID)
+	if err != nil {
+		return fmt.Errorf("failed to get package policy: %w", err)
+	}
+
+	// Keep a copy of the settings to show the changes
+	before := map[string]interface{}{"vars": policy["vars"], "inputs": policy["inputs"]}
+	data, err := json.Marshal(before)
+	if err != nil {
+		return fmt.Errorf("error marshaling package policy: %w", err)
+	}
+	if err := json.Unmarshal(data, &before); err != nil {
+		return fmt.Errorf("error copying package policy: %w", err)
+	}
+
+	if name != "" {
+		policy["name"] = name
+	}
+	if description != "" {
+		policy["description"] = description
+	}
+	if namespace != "" {
+		policy["namespace"] = namespace
+	}
+	for _, setting := range settings {
+		path, value, err := client.ParsePackagePolicySetting(setting)
+		if err != nil {
+			return err
+		}
+		if err := client.SetPackagePolicyValue(policy, path, value); err != nil {
+			return err
+		}
+	}
+
+	updatedPolicy, err := fleetClient.UpdatePackagePolicyConfig(packagePolicyID, policy)
+	if err != nil {
+		return fmt.Errorf("failed to update package policy: %w", err)
+	}
+
+	// Output success message
+	fmt.Printf("Package policy updated successfully\nID: %s\nName: %s\n",
+		updatedPolicy.ID, updatedPolicy.Name)
+	after := map[string]interface{}{"vars": policy["vars"], "inputs": policy["inputs"]}
+	for _, change := range client.DiffSource(before, after) {
+		before, after := change.Before, change.After
+		if before == "" {
+			before = "(unset)"
+		}
+		fmt.Printf("  %s: %s -> %s\n", change.Field, before, after)
+	}
+	return nil
+}
+
 // deletePackagePolicy deletes a package policy
 func deletePackagePolicy(cmd *cobra.Command, args []string) error {
 	// Load configuration
@@ -376,3 +524,107 @@ func deletePackagePolicy(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Package policy %s deleted successfully\n", packagePolicyID)
 	return nil
 }
+
+// auditNamespaces reports package policies writing into namespaces that are not allowed
+func auditNamespaces(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Flags take precedence over the configured namespaces
+	allowedIn := func(space string) []string {
+		if len(allowedNamespaces) > 0 {
+			return allowedNamespaces
+		}
+		if namespaces, ok := cfg.Fleet.SpaceNamespaces[space]; ok {
+			return namespaces
+		}
+		return cfg.Fleet.AllowedNamespaces
+	}
+	if len(allowedNamespaces) == 0 && len(cfg.Fleet.AllowedNamespaces) == 0 && len(cfg.Fleet.SpaceNamespaces) == 0 {
+		return fmt.Errorf("no allowed namespaces: set fleet.allowed_namespaces in the config file or use --allowed-namespaces")
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	spaces := []string{cfg.Kibana.Space}
+	if spaces[0] == "" {
+		spaces[0] = "default"
+	}
+	if auditAllSpaces {
+		list, err := fleetClient.ListSpaces()
+		if err != nil {
+			return fmt.Errorf("failed to list spaces: %w", err)
+		}
+		spaces = spaces[:0]
+		for _, space := range list {
+			spaces = append(spaces, space.ID)
+		}
+	}
+
+	entries, err := fleetClient.GetPackagePolicyNamespaces(spaces)
+	if err != nil {
+		return fmt.Errorf("failed to get package policies: %w", err)
+	}
+
+	headers := []string{"Package Policy", "Package", "Agent Policy", "Spaces", "Namespace", "Set On", "Status", "Allowed"}
+	rows := [][]string{}
+	// A package policy shared by several agent policies has an entry for each
+	policies := make(map[string]bool)
+	unexpected := make(map[string]bool)
+	for _, entry := range entries {
+		policies[entry.PackagePolicyID] = true
+		var allowed []string
+		for _, space := range entry.Spaces {
+			allowed = append(allowed, allowedIn(space)...)
+		}
+		status := "ok"
+		if !client.NamespaceAllowed(entry.Namespace, allowed) {
+			status = "UNEXPECTED"
+			unexpected[entry.PackagePolicyID] = true
+		} else if !showAllPolicies {
+			continue
+		}
+
+		setOn := "package policy"
+		if entry.Inherited {
+			setOn = "agent policy"
+		}
+		agentPolicy := entry.AgentPolicyName
+		if agentPolicy == "" {
+			agentPolicy = entry.AgentPolicyID
+		}
+		rows = append(rows, []string{
+			entry.PackagePolicyName,
+			entry.Package,
+			agentPolicy,
+			strings.Join(entry.Spaces, ", "),
+			entry.Namespace,
+			setOn,
+			status,
+			strings.Join(allowed, ", "),
+		})
+	}
+
+	if len(unexpected) == 0 && !showAllPolicies {
+		fmt.Printf("All %d package policies write into allowed namespaces\n", len(policies))
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(headers, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Printf("\n%d of %d package policies write into unexpected namespaces\n", len(unexpected), len(policies))
+	if len(unexpected) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -23,6 +24,12 @@ var (
 	caCert    string
 	insecure  bool
 
+	// Command specific
+	limit    int
+	page     int
+	perPage  int
+	allPages bool
+
 	// Output
 	outputFormat string
 )
@@ -50,6 +57,7 @@ kb_fleet_policies --style=blue`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -57,14 +65,30 @@ kb_fleet_policies --style=blue`,
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Command specific flags
+	rootCmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of agent policies to list (0 for all)")
+	rootCmd.Flags().IntVar(&page, "page", 0, "Page of agent policies to list, numbered from 1 (default is every page)")
+	rootCmd.Flags().IntVar(&perPage, "per-page", 0, "Number of agent policies requested per page (default is fleet.per_page from the config file, or 100)")
+	rootCmd.Flags().BoolVar(&allPages, "all", false, "List every page of agent policies, the default unless --page is given")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -86,6 +110,14 @@ func run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
+	fleetClient.SetLimit(limit)
+	if allPages && page > 0 {
+		return fmt.Errorf("--all and --page cannot be used together")
+	}
+	if perPage > 0 {
+		fleetClient.SetPerPage(perPage)
+	}
+	fleetClient.SetPage(page)
 
 	// Get Fleet agent policies
 	headers, rows, err := fleetClient.GetAgentPoliciesFormatted()
@@ -95,5 +127,9 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Output results
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
-	return formatter.Write(headers, rows)
+	if err := formatter.Write(headers, rows); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, fleetClient.PageSummary(len(rows), "agent policies"))
+	return nil
 }
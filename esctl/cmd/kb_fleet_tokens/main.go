@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -23,6 +25,18 @@ var (
 	caCert    string
 	insecure  bool
 
+	// Command specific
+	limit    int
+	page     int
+	perPage  int
+	allPages bool
+
+	// Rotate operations
+	policyID        string
+	tokenName       string
+	revokeOlderThan string
+	rotateDryRun    bool
+
 	// Output
 	outputFormat string
 )
@@ -30,7 +44,7 @@ var (
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:               "kb_fleet_tokens",
-		Short:             "List Kibana Fleet enrollment tokens",
+		Short:             "List and rotate Kibana Fleet enrollment tokens",
 		Long:              `List all enrollment tokens from Kibana Fleet.
 
 Enrollment tokens are used to securely enroll Elastic Agents with Fleet. Each token is associated with a specific agent policy and determines which policy is applied to the agent during enrollment. This command displays token details including ID, name, associated policy ID, active status, and creation time.
@@ -38,10 +52,13 @@ Enrollment tokens are used to securely enroll Elastic Agents with Fleet. Each to
 Example usage:
   kb_fleet_tokens --kb-addresses=https://kibana:5601 --kb-username=elastic --kb-password=changeme
   kb_fleet_tokens --format=json
-  kb_fleet_tokens --style=blue`,
+  kb_fleet_tokens --style=blue
+  kb_fleet_tokens rotate --policy-id=default-policy --revoke-older-than=90d`,
 		Example:           `kb_fleet_tokens
 kb_fleet_tokens --format=json
-kb_fleet_tokens --style=blue`,
+kb_fleet_tokens --style=blue
+kb_fleet_tokens rotate --policy-id=default-policy
+kb_fleet_tokens rotate --policy-id=default-policy --revoke-older-than=90d --dry-run`,
 		PersistentPreRunE: initConfig,
 		RunE:              run,
 	}
@@ -50,6 +67,7 @@ kb_fleet_tokens --style=blue`,
 
 	// Config file flag
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
 
 	// Kibana connection flags
 	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
@@ -57,14 +75,49 @@ kb_fleet_tokens --style=blue`,
 	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
 	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Command specific flags
+	rootCmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of enrollment tokens to list (0 for all)")
+	rootCmd.Flags().IntVar(&page, "page", 0, "Page of enrollment tokens to list, numbered from 1 (default is every page)")
+	rootCmd.Flags().IntVar(&perPage, "per-page", 0, "Number of enrollment tokens requested per page (default is fleet.per_page from the config file, or 100)")
+	rootCmd.Flags().BoolVar(&allPages, "all", false, "List every page of enrollment tokens, the default unless --page is given")
 
 	// Output flags
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
 	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	// Rotate command
+	rotateCmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Create a new enrollment token for a policy and revoke its old tokens",
+		Long: `Create a new enrollment token for an agent policy, show its secret once, and revoke the
+policy's active tokens created longer ago than --revoke-older-than.
+
+The secret of the new token is only shown by this command, so store it before the output is
+lost. Revoking a token stops new agents from enrolling with it; agents already enrolled with it
+keep working. Use --dry-run to see which tokens would be revoked without creating or revoking any.`,
+		RunE: rotateTokens,
+	}
+	rotateCmd.Flags().StringVar(&policyID, "policy-id", "", "ID of the agent policy whose tokens are rotated (required)")
+	rotateCmd.Flags().StringVar(&tokenName, "name", "", "Name of the new token, Fleet appends an ID to keep it unique (default is Fleet's default name)")
+	rotateCmd.Flags().StringVar(&revokeOlderThan, "revoke-older-than", "30d", "Revoke the policy's active tokens created longer ago than this, e.g. 30d or 12h")
+	rotateCmd.Flags().BoolVar(&rotateDryRun, "dry-run", false, "Show the tokens that would be revoked without creating or revoking any")
+	rotateCmd.MarkFlagRequired("policy-id")
+	rootCmd.AddCommand(rotateCmd)
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Printf("Error: %v", err)
+		os.Exit(client.ExitCode(err))
 	}
 }
 
@@ -86,6 +139,14 @@ func run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create Fleet client: %w", err)
 	}
+	fleetClient.SetLimit(limit)
+	if allPages && page > 0 {
+		return fmt.Errorf("--all and --page cannot be used together")
+	}
+	if perPage > 0 {
+		fleetClient.SetPerPage(perPage)
+	}
+	fleetClient.SetPage(page)
 
 	// Get Fleet enrollment tokens
 	headers, rows, err := fleetClient.GetEnrollmentTokensFormatted()
@@ -95,5 +156,104 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Output results
 	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
-	return formatter.Write(headers, rows)
+	if err := formatter.Write(headers, rows); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, fleetClient.PageSummary(len(rows), "enrollment tokens"))
+	return nil
+}
+
+// rotateTokens creates a new enrollment token for a policy and revokes the policy's old tokens
+func rotateTokens(cmd *cobra.Command, args []string) error {
+	maxAge, err := client.ParseTimeValue(revokeOlderThan)
+	if err != nil {
+		return fmt.Errorf("invalid --revoke-older-than: %w", err)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Initialize client
+	fleetClient, err := client.NewFleet(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Fleet client: %w", err)
+	}
+
+	// Find the old tokens before creating the new one
+	tokens, err := fleetClient.GetPolicyEnrollmentTokens(policyID)
+	if err != nil {
+		return fmt.Errorf("failed to get Fleet enrollment tokens: %w", err)
+	}
+	old := client.FindTokensToRevoke(tokens, maxAge, time.Now(), "")
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if rotateDryRun {
+		if len(old) == 0 {
+			fmt.Printf("Dry run: a new token would be created for policy %s and no tokens are older than %s\n", policyID, revokeOlderThan)
+			return nil
+		}
+		if err := formatter.Write(revokedTokenHeaders, revokedTokenRows(old, "would revoke")); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		fmt.Printf("\nDry run: a new token would be created for policy %s and %d tokens would be revoked\n", policyID, len(old))
+		return nil
+	}
+
+	// Create the new token first, so the policy is never left without an active token
+	token, err := fleetClient.CreateEnrollmentToken(policyID, tokenName)
+	if err != nil {
+		return fmt.Errorf("failed to create enrollment token: %w", err)
+	}
+
+	headers := []string{"ID", "Name", "Policy ID", "Created At", "Enrollment Token"}
+	rows := [][]string{{token.ID, token.Name, token.PolicyID, token.CreatedAt, token.APIKey}}
+	if err := formatter.Write(headers, rows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "The enrollment token is not shown again, store it now")
+
+	// Revoke the old tokens, carrying on past failures so one bad token does not block the rest
+	if len(old) == 0 {
+		fmt.Fprintf(os.Stderr, "No tokens for policy %s are older than %s\n", policyID, revokeOlderThan)
+		return nil
+	}
+	results := make([]string, len(old))
+	failed := 0
+	for i, t := range old {
+		results[i] = "revoked"
+		if err := fleetClient.RevokeEnrollmentToken(t.ID); err != nil {
+			results[i] = fmt.Sprintf("failed: %v", err)
+			failed++
+		}
+	}
+
+	revokedRows := revokedTokenRows(old, "")
+	for i := range revokedRows {
+		revokedRows[i][len(revokedRows[i])-1] = results[i]
+	}
+	fmt.Fprintln(os.Stderr)
+	if err := formatter.Write(revokedTokenHeaders, revokedRows); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to revoke %d of %d enrollment tokens", failed, len(old))
+	}
+	fmt.Fprintf(os.Stderr, "\nRevoked %d enrollment tokens older than %s\n", len(old), revokeOlderThan)
+	return nil
+}
+
+// revokedTokenHeaders are the columns of the table of tokens revoked by rotate
+var revokedTokenHeaders = []string{"ID", "Name", "Created At", "Result"}
+
+// revokedTokenRows returns a table row for each token, with result in the last column
+func revokedTokenRows(tokens []client.EnrollmentToken, result string) [][]string {
+	rows := make([][]string, 0, len(tokens))
+	for _, t := range tokens {
+		rows = append(rows, []string{t.ID, t.Name, t.CreatedAt, result})
+	}
+	return rows
 }
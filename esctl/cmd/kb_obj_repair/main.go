@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Command specific
+	objectType    string
+	brokenID      string
+	replacementID string
+	apply         bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_obj_repair",
+		Short: "Find and repair broken Kibana saved object references",
+		Long: `Find and repair broken references in Kibana saved objects.
+
+A reference is broken when the object it points at no longer exists, for example a dashboard
+that still references a deleted index pattern. Without --replacement-id the command lists every
+broken reference for objects of the given type.
+
+With --broken-id and --replacement-id every reference to the broken object is re-pointed to the
+replacement object, which must exist and have the same type. The changes are shown as a dry run
+first and are only written, with a saved objects bulk update, when --apply is given.
+
+Example usage:
+  kb_obj_repair --type dashboard
+  kb_obj_repair --type dashboard --broken-id old-pattern-id --replacement-id new-pattern-id
+  kb_obj_repair --type dashboard --broken-id old-pattern-id --replacement-id new-pattern-id --apply`,
+		Example: `kb_obj_repair --type dashboard
+kb_obj_repair --type visualization --broken-id old-pattern-id --replacement-id new-pattern-id
+kb_obj_repair --type dashboard --broken-id old-pattern-id --replacement-id new-pattern-id --apply`,
+		PersistentPreRunE: initConfig,
+		RunE:              runRepair,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&objectType, "type", "t", "dashboard", "Type of the saved objects to check")
+	rootCmd.Flags().StringVar(&brokenID, "broken-id", "", "ID of the missing object whose references should be re-pointed")
+	rootCmd.Flags().StringVar(&replacementID, "replacement-id", "", "ID of the object to re-point broken references to")
+	rootCmd.Flags().BoolVar(&apply, "apply", false, "Write the repaired references to Kibana instead of showing a dry run")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// runRepair executes the repair command
+func runRepair(cmd *cobra.Command, args []string) error {
+	// Both IDs are needed to repair, neither to report
+	if (brokenID == "") != (replacementID == "") {
+		return fmt.Errorf("--broken-id and --replacement-id must be used together")
+	}
+	if apply && replacementID == "" {
+		return fmt.Errorf("--apply requires --broken-id and --replacement-id")
+	}
+
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	// Find broken references
+	objects, err := c.GetAllSavedObjects(objectType)
+	if err != nil {
+		return fmt.Errorf("error retrieving %s objects: %w", objectType, err)
+	}
+
+	broken, err := c.FindBrokenReferences(objects)
+	if err != nil {
+		return fmt.Errorf("error checking references: %w", err)
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+
+	// Report only
+	if replacementID == "" {
+		if len(broken) == 0 {
+			fmt.Printf("No broken references found in %d %s objects\n", len(objects), objectType)
+			return nil
+		}
+
+		headers := []string{"ID", "Title", "Reference Name", "Missing Type", "Missing ID"}
+		rows := make([][]string, 0, len(broken))
+		for _, b := range broken {
+			rows = append(rows, []string{b.ObjectID, b.Title, b.Reference.Name, b.Reference.Type, b.Reference.ID})
+		}
+
+		if err := formatter.Write(headers, rows); err != nil {
+			return fmt.Errorf("error formatting output: %w", err)
+		}
+		fmt.Printf("\n%d broken references found in %d %s objects\n", len(broken), len(objects), objectType)
+		return nil
+	}
+
+	// The broken ID must actually be broken, otherwise a working reference would be rewritten
+	refType := ""
+	for _, b := range broken {
+		if b.Reference.ID == brokenID {
+			refType = b.Reference.Type
+			break
+		}
+	}
+	if refType == "" {
+		return fmt.Errorf("no %s object has a broken reference to %s", objectType, brokenID)
+	}
+
+	// The replacement must exist and be of the same type as the missing object
+	if _, err := c.GetSavedObject(replacementID, refType, false); err != nil {
+		return fmt.Errorf("replacement %s %s cannot be used: %w", refType, replacementID, err)
+	}
+
+	headers := []string{"ID", "Title", "Reference Name", "Type", "From", "To"}
+	rows := [][]string{}
+	for _, b := range broken {
+		if b.Reference.Type == refType && b.Reference.ID == brokenID {
+			rows = append(rows, []string{b.ObjectID, b.Title, b.Reference.Name, refType, brokenID, replacementID})
+		}
+	}
+
+	updated := client.RepointReferences(objects, refType, brokenID, replacementID)
+
+	if err := formatter.Write(headers, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	if !apply {
+		fmt.Printf("\nDry run: %d %s objects would be updated. Re-run with --apply to write the changes.\n", len(updated), objectType)
+		return nil
+	}
+
+	// Apply the fix
+	if err := c.BulkUpdateSavedObjectReferences(updated); err != nil {
+		return fmt.Errorf("error updating references: %w", err)
+	}
+
+	fmt.Printf("\nUpdated %d %s objects\n", len(updated), objectType)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Command specific
+	objectType  string
+	skipIndices bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_obj_validate",
+		Short: "Check Kibana dashboards for broken panels and data views",
+		Long: `Load every saved object of a type, dashboards by default, and report the problems found in
+each:
+
+  missing object                a panel or reference points at an object that no longer exists
+  deprecated visualization      a panel uses a legacy visualization type Kibana has deprecated
+  missing data view             a panel's visualization or search uses a deleted data view
+  data view matches no indices  a data view's index pattern no longer matches any index, alias
+                                or data stream
+  data view not checked         the index pattern could not be checked, e.g. a remote cluster
+
+Data view index patterns are resolved in Elasticsearch with the connection settings in the
+elasticsearch section of the config file; use --skip-indices to only check Kibana. Use --space to
+check the objects of another space.
+
+Example usage:
+  kb_obj_validate
+  kb_obj_validate --type dashboard --space ops
+  kb_obj_validate --skip-indices --format=csv`,
+		Example: `kb_obj_validate --type dashboard
+kb_obj_validate --type dashboard --space ops
+kb_obj_validate --type visualization --skip-indices`,
+		PersistentPreRunE: initConfig,
+		RunE:              runValidate,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Command specific flags
+	rootCmd.Flags().StringVarP(&objectType, "type", "t", "dashboard", "Type of the saved objects to check")
+	rootCmd.Flags().BoolVar(&skipIndices, "skip-indices", false, "Do not check that data views match indices in Elasticsearch")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// runValidate executes the validate command
+func runValidate(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	// Data view indices are resolved in Elasticsearch
+	var indexExists client.IndexExistsFunc
+	if !skipIndices {
+		esClient, err := client.New(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not checking data view indices, could not create Elasticsearch client: %v\n", err)
+		} else {
+			indexExists = func(pattern string) (bool, error) {
+				if _, err := esClient.ResolveIndexName(pattern); err != nil {
+					if client.IsNotFound(err) {
+						return false, nil
+					}
+					return false, err
+				}
+				return true, nil
+			}
+		}
+	}
+
+	objects, err := c.GetAllSavedObjects(objectType)
+	if err != nil {
+		return fmt.Errorf("error retrieving %s objects: %w", objectType, err)
+	}
+
+	problems, err := c.ValidateSavedObjects(objects, indexExists)
+	if err != nil {
+		return fmt.Errorf("error validating %s objects: %w", objectType, err)
+	}
+	if len(problems) == 0 {
+		fmt.Printf("No problems found in %d %s objects\n", len(objects), objectType)
+		return nil
+	}
+
+	headers := []string{"ID", "Title", "Problem", "Detail"}
+	rows := make([][]string, 0, len(problems))
+	affected := make(map[string]bool)
+	for _, p := range problems {
+		rows = append(rows, []string{p.ObjectID, p.Title, p.Problem, p.Detail})
+		affected[p.ObjectID] = true
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(headers, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+	fmt.Printf("\n%d problems found in %d of %d %s objects\n", len(problems), len(affected), len(objects), objectType)
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Command specific
+	dashboardID   string
+	savedSearchID string
+	reportFormat  string
+	reportTitle   string
+	timeFrom      string
+	timeTo        string
+	wait          bool
+	outputFile    string
+	pollInterval  time.Duration
+	waitTimeout   time.Duration
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_reports",
+		Short: "Generate Kibana reports",
+		Long: `Generate PDF, PNG and CSV reports with Kibana reporting, for example to send a dashboard
+out every week from a scheduled job.
+
+Example usage:
+  kb_reports generate --dashboard-id 7adfa750-4c81-11e8-b3d7-01146121b73d --format pdf --wait --output report.pdf
+  kb_reports generate --search-id 571aaf70-4c88-11e8-b3d7-01146121b73d --format csv --from now-7d --output weekly.csv`,
+		Example: `kb_reports generate --dashboard-id 7adfa750-4c81-11e8-b3d7-01146121b73d --format pdf --wait --output report.pdf
+kb_reports generate --dashboard-id 7adfa750-4c81-11e8-b3d7-01146121b73d --format png --space ops --from now-7d --output ops.png
+kb_reports generate --search-id 571aaf70-4c88-11e8-b3d7-01146121b73d --format csv --from now-7d --output weekly.csv`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Generate subcommand
+	var generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Submit a reporting job and optionally download the report",
+		Long: `Submit a reporting job for a dashboard (pdf or png) or a saved search (csv).
+
+Without --wait the job ID and download path are printed and the command returns; Kibana keeps
+the report for later download. With --wait the job is polled until it completes and the report
+is written to --output, which defaults to the job ID with the format as extension. --output
+implies --wait.
+
+The time range defaults to the one saved with the dashboard or search; --from and --to take
+absolute times or date math such as now-7d. CSV reports need Kibana 8.12 or later.
+
+Here --format selects the report format rather than the output format.`,
+		RunE: runGenerate,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	// Generate command flags
+	generateCmd.Flags().StringVar(&dashboardID, "dashboard-id", "", "ID of the dashboard to report on (pdf or png)")
+	generateCmd.Flags().StringVar(&savedSearchID, "search-id", "", "ID of the saved search to report on (csv)")
+	generateCmd.Flags().StringVarP(&reportFormat, "format", "f", "pdf", "Report format: pdf, png or csv")
+	generateCmd.Flags().StringVar(&reportTitle, "title", "", "Title of the report (default is the title of the dashboard or search)")
+	generateCmd.Flags().StringVar(&timeFrom, "from", "", "Start of the time range, e.g. now-7d or 2024-01-01T00:00:00Z")
+	generateCmd.Flags().StringVar(&timeTo, "to", "", "End of the time range (default is now when --from is given)")
+	generateCmd.Flags().BoolVar(&wait, "wait", false, "Wait for the report and download it")
+	generateCmd.Flags().StringVarP(&outputFile, "output", "o", "", "File to write the report to (implies --wait)")
+	generateCmd.Flags().DurationVar(&pollInterval, "poll-interval", 5*time.Second, "Time between checks of the job with --wait")
+	generateCmd.Flags().DurationVar(&waitTimeout, "timeout", 10*time.Minute, "How long to wait for the report with --wait")
+	generateCmd.MarkFlagsOneRequired("dashboard-id", "search-id")
+	generateCmd.MarkFlagsMutuallyExclusive("dashboard-id", "search-id")
+
+	// Add subcommands
+	rootCmd.AddCommand(generateCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// runGenerate executes the generate command
+func runGenerate(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	objectID, objectType := dashboardID, "dashboard"
+	if savedSearchID != "" {
+		objectID, objectType = savedSearchID, "search"
+	}
+
+	// Check the object exists before submitting, and title the report after it
+	obj, err := c.GetSavedObject(objectID, objectType, false)
+	if err != nil {
+		return fmt.Errorf("error getting %s %s: %w", objectType, objectID, err)
+	}
+	title := reportTitle
+	if title == "" {
+		title = client.SavedObjectTitle(*obj)
+	}
+
+	job, err := c.GenerateReport(client.ReportRequest{
+		Format:        reportFormat,
+		DashboardID:   dashboardID,
+		SavedSearchID: savedSearchID,
+		Title:         title,
+		From:          timeFrom,
+		To:            timeTo,
+	})
+	if err != nil {
+		return fmt.Errorf("error generating report: %w", err)
+	}
+
+	if !wait && outputFile == "" {
+		fmt.Printf("Submitted report job %s for %s %q\n", job.ID, objectType, title)
+		fmt.Printf("Download path: %s\n", job.DownloadPath)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Waiting for report job %s...\n", job.ID)
+	content, err := c.WaitForReport(job, pollInterval, waitTimeout)
+	if err != nil {
+		return err
+	}
+
+	file := outputFile
+	if file == "" {
+		file = job.ID + "." + reportFormat
+	}
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+
+	fmt.Printf("Wrote %s report %q to %s (%s)\n", reportFormat, title, file, client.ByteCountSI(int64(len(content))))
+	return nil
+}
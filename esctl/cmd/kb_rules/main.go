@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Rule selection
+	ruleTags  []string
+	ruleTypes []string
+	search    string
+	allRules  bool
+
+	// Rule operations
+	snoozeFor string
+	dryRun    bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_rules",
+		Short: "List, enable, disable and snooze Kibana alerting rules in bulk",
+		Long: `Manage Kibana alerting rules in bulk.
+
+Rules are selected by tag (--tag), rule type (--rule-type) and a search of rule names
+(--search). Selected rules can be listed, enabled, disabled, or snoozed for a maintenance
+window. A snoozed rule keeps running but its actions are not triggered until the snooze ends,
+which is usually what is wanted during planned downtime; disabling a rule stops it running.
+
+Changing rules needs at least one selection flag, or --all to change every rule.
+
+Example usage:
+  kb_rules list --tag=prod
+  kb_rules snooze --tag=prod --duration=2h
+  kb_rules unsnooze --tag=prod
+  kb_rules disable --rule-type=.index-threshold --search=disk --dry-run`,
+		Example: `kb_rules list
+kb_rules list --tag=prod --rule-type=.es-query
+kb_rules snooze --tag=prod --duration=2h
+kb_rules unsnooze --tag=prod
+kb_rules disable --search="disk usage" --dry-run
+kb_rules enable --tag=prod`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+	rootCmd.PersistentFlags().String("space", "", "Kibana space to work in (default is kibana.space from the config file, or the default space)")
+
+	// Rule selection flags
+	rootCmd.PersistentFlags().StringSliceVar(&ruleTags, "tag", nil, "Select rules carrying any of these tags (comma-separated)")
+	rootCmd.PersistentFlags().StringSliceVar(&ruleTypes, "rule-type", nil, "Select rules of any of these rule types, e.g. .es-query (comma-separated)")
+	rootCmd.PersistentFlags().StringVar(&search, "search", "", "Select rules whose name matches this search")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	// List command
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the selected rules",
+		Long:  `List the selected rules with their type, tags, and enabled, snooze and last run status.`,
+		RunE:  runList,
+	}
+
+	// Enable command
+	var enableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Enable the selected rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBulk(cmd, "enable", func(c *client.KibanaClient, rule client.AlertingRule) error {
+				return c.EnableAlertingRule(rule.ID)
+			})
+		},
+	}
+
+	// Disable command
+	var disableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable the selected rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBulk(cmd, "disable", func(c *client.KibanaClient, rule client.AlertingRule) error {
+				return c.DisableAlertingRule(rule.ID)
+			})
+		},
+	}
+
+	// Snooze command
+	var snoozeCmd = &cobra.Command{
+		Use:   "snooze",
+		Short: "Snooze the notifications of the selected rules for a duration",
+		Long: `Snooze the selected rules for a duration starting now, e.g. --duration=2h for a maintenance
+window. Snoozed rules keep running, but their actions are not triggered until the snooze ends.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			duration, err := client.ParseTimeValue(snoozeFor)
+			if err != nil {
+				return fmt.Errorf("invalid --duration: %w", err)
+			}
+			if duration < time.Minute {
+				return fmt.Errorf("--duration must be at least 1m")
+			}
+			return runBulk(cmd, "snooze", func(c *client.KibanaClient, rule client.AlertingRule) error {
+				return c.SnoozeAlertingRule(rule.ID, duration)
+			})
+		},
+	}
+	snoozeCmd.Flags().StringVar(&snoozeFor, "duration", "", "How long to snooze the rules for, e.g. 30m, 2h or 1d (required)")
+	snoozeCmd.MarkFlagRequired("duration")
+
+	// Unsnooze command
+	var unsnoozeCmd = &cobra.Command{
+		Use:   "unsnooze",
+		Short: "Remove the snoozes of the selected rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBulk(cmd, "unsnooze", func(c *client.KibanaClient, rule client.AlertingRule) error {
+				return c.UnsnoozeAlertingRule(rule)
+			})
+		},
+	}
+
+	for _, c := range []*cobra.Command{enableCmd, disableCmd, snoozeCmd, unsnoozeCmd} {
+		c.Flags().BoolVar(&allRules, "all", false, "Change every rule when no selection flag is given")
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "Show the rules that would change without changing them")
+	}
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, enableCmd, disableCmd, snoozeCmd, unsnoozeCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// ruleFilter returns the rule selection given on the command line
+func ruleFilter() client.AlertingRuleFilter {
+	return client.AlertingRuleFilter{Tags: ruleTags, RuleTypes: ruleTypes, Search: search}
+}
+
+// runList lists the selected rules
+func runList(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	rules, err := c.FindAlertingRules(ruleFilter())
+	if err != nil {
+		return fmt.Errorf("error finding rules: %w", err)
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	return formatter.Write(ruleHeader(), ruleRows(rules))
+}
+
+// runBulk applies an operation to every selected rule and reports the rules it failed on
+func runBulk(cmd *cobra.Command, action string, op func(*client.KibanaClient, client.AlertingRule) error) error {
+	// A change without a selection would touch every rule in the space
+	if len(ruleTags) == 0 && len(ruleTypes) == 0 && search == "" && !allRules {
+		return fmt.Errorf("select rules with --tag, --rule-type or --search, or use --all to %s every rule", action)
+	}
+
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	rules, err := c.FindAlertingRules(ruleFilter())
+	if err != nil {
+		return fmt.Errorf("error finding rules: %w", err)
+	}
+	if len(rules) == 0 {
+		fmt.Println("No rules match the selection")
+		return nil
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if dryRun {
+		if err := formatter.Write(ruleHeader(), ruleRows(rules)); err != nil {
+			return fmt.Errorf("error formatting output: %w", err)
+		}
+		fmt.Printf("\nDry run: %d rules would be changed (%s)\n", len(rules), action)
+		return nil
+	}
+
+	failures := client.ApplyToAlertingRules(rules, func(rule client.AlertingRule) error {
+		return op(c, rule)
+	})
+
+	if len(failures) > 0 {
+		header := []string{"ID", "Name", "Error"}
+		rows := make([][]string, 0, len(failures))
+		for _, f := range failures {
+			rows = append(rows, []string{f.Rule.ID, f.Rule.Name, f.Err.Error()})
+		}
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("error formatting output: %w", err)
+		}
+		return fmt.Errorf("failed to %s %d of %d rules", action, len(failures), len(rules))
+	}
+
+	fmt.Printf("%d rules changed (%s)\n", len(rules), action)
+	return nil
+}
+
+// ruleHeader returns the columns of a rule listing
+func ruleHeader() []string {
+	return []string{"ID", "Name", "Rule Type", "Tags", "Enabled", "Snoozed Until", "Last Run"}
+}
+
+// ruleRows formats rules as table rows
+func ruleRows(rules []client.AlertingRule) [][]string {
+	rows := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		enabled := "no"
+		if rule.Enabled {
+			enabled = "yes"
+		}
+		snoozed := "-"
+		switch {
+		case rule.MuteAll:
+			snoozed = "indefinitely"
+		case rule.IsSnoozedUntil != "":
+			snoozed = rule.IsSnoozedUntil
+		}
+
+		rows = append(rows, []string{
+			rule.ID,
+			rule.Name,
+			rule.RuleTypeID,
+			strings.Join(rule.Tags, ", "),
+			enabled,
+			snoozed,
+			rule.ExecutionStatus.Status,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Command specific
+	spaceIDs     []string
+	allSpaces    bool
+	dataViewRef  string
+	specFile     string
+	fieldNames   []string
+	removeFormat bool
+	dryRun       bool
+
+	// Output
+	outputFormat string
+)
+
+// fieldSpecFile is the YAML spec read by apply
+type fieldSpecFile struct {
+	DataViews []client.DataViewFieldSpec `yaml:"data_views"`
+}
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_runtime_fields",
+		Short: "Manage the runtime fields and field formats of Kibana data views",
+		Long: `List, apply and remove the runtime fields and field formats of Kibana data views, in one
+space or in many at once.
+
+The apply subcommand reads a YAML spec naming data views by ID, title or name, with the runtime
+fields and field formats each should have:
+
+  data_views:
+    - data_view: logs-*
+      runtime_fields:
+        day_of_week:
+          type: keyword
+          script: emit(doc['@timestamp'].value.dayOfWeekEnum.toString())
+      field_formats:
+        bytes:
+          id: bytes
+        url.full:
+          id: url
+          params:
+            labelTemplate: "{{value}}"
+
+Fields are created or replaced when they differ from the spec; fields the spec does not name
+are left alone. A data view usually has a different ID in each space, so naming it by title
+lets one spec apply to every space.
+
+Without --space the configured Kibana space is used. --space can be repeated, and --all-spaces
+covers every space the user can see.
+
+Example usage:
+  kb_runtime_fields list --space marketing
+  kb_runtime_fields apply --file runtime-fields.yaml --all-spaces --dry-run
+  kb_runtime_fields remove --data-view logs-* --field day_of_week --all-spaces`,
+		Example: `kb_runtime_fields list --space marketing --data-view logs-*
+kb_runtime_fields apply --file runtime-fields.yaml --space marketing --space sales
+kb_runtime_fields apply --file runtime-fields.yaml --all-spaces --dry-run
+kb_runtime_fields remove --data-view logs-* --field day_of_week --all-spaces
+kb_runtime_fields remove --data-view logs-* --field bytes --field-format`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// List subcommand
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the runtime fields and field formats of data views",
+		RunE:  runList,
+	}
+
+	// Apply subcommand
+	var applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Create or replace runtime fields and field formats from a YAML spec",
+		Long: `Make the runtime fields and field formats of the data views in a YAML spec match it, in each
+space. Each change is attempted even when an earlier one failed; the command fails if any did.`,
+		RunE: runApply,
+	}
+
+	// Remove subcommand
+	var removeCmd = &cobra.Command{
+		Use:   "remove",
+		Short: "Remove runtime fields, or the formats of fields, from a data view",
+		Long: `Remove runtime fields from a data view in each space. With --field-format the formatters of
+the fields are removed instead, so Kibana shows them with the default formatter again.`,
+		RunE: runRemove,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+
+	// Space flags
+	rootCmd.PersistentFlags().StringSliceVarP(&spaceIDs, "space", "s", nil, "ID of a space to work in, can be repeated (default is the configured space)")
+	rootCmd.PersistentFlags().BoolVar(&allSpaces, "all-spaces", false, "Work in every space the user can see")
+	rootCmd.MarkFlagsMutuallyExclusive("space", "all-spaces")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	// List command flags
+	listCmd.Flags().StringVarP(&dataViewRef, "data-view", "d", "", "ID, title or name of the data view to list (default is every data view)")
+
+	// Apply command flags
+	applyCmd.Flags().StringVar(&specFile, "file", "", "YAML spec of the runtime fields and field formats (required)")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without changing anything")
+	applyCmd.MarkFlagRequired("file")
+
+	// Remove command flags
+	removeCmd.Flags().StringVarP(&dataViewRef, "data-view", "d", "", "ID, title or name of the data view (required)")
+	removeCmd.Flags().StringSliceVar(&fieldNames, "field", nil, "Name of a field to remove, can be repeated (required)")
+	removeCmd.Flags().BoolVar(&removeFormat, "field-format", false, "Remove the formatters of the fields instead of runtime fields")
+	removeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed without changing anything")
+	removeCmd.MarkFlagRequired("data-view")
+	removeCmd.MarkFlagRequired("field")
+
+	// Add subcommands
+	rootCmd.AddCommand(listCmd, applyCmd, removeCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// setup loads the configuration and creates the Kibana client and the list of spaces to work in
+func setup(cmd *cobra.Command) (*config.Config, *client.KibanaClient, []string, error) {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	if !allSpaces {
+		if len(spaceIDs) > 0 {
+			return cfg, c, spaceIDs, nil
+		}
+		return cfg, c, []string{cfg.Kibana.Space}, nil
+	}
+
+	spaces, err := c.ListSpaces()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error listing spaces: %w", err)
+	}
+	ids := make([]string, 0, len(spaces))
+	for _, space := range spaces {
+		ids = append(ids, space.ID)
+	}
+	return cfg, c, ids, nil
+}
+
+// runList executes the list command
+func runList(cmd *cobra.Command, args []string) error {
+	cfg, c, spaces, err := setup(cmd)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"Space", "Data View", "Kind", "Field", "Type", "Definition"}
+	var rows [][]string
+	for _, space := range spaces {
+		views, err := listDataViews(c, space)
+		if err != nil {
+			return fmt.Errorf("error in space %s: %w", spaceName(space), err)
+		}
+		for _, view := range views {
+			for _, name := range sortedNames(view.RuntimeFieldMap) {
+				field := view.RuntimeFieldMap[name]
+				script := ""
+				if field.Script != nil {
+					script = field.Script.Source
+				}
+				rows = append(rows, []string{spaceName(space), view.Title, client.DataViewRuntimeField, name, field.Type, script})
+			}
+			for _, name := range sortedNames(view.FieldFormats) {
+				fieldFormat := view.FieldFormats[name]
+				params := ""
+				if len(fieldFormat.Params) > 0 {
+					data, err := json.Marshal(fieldFormat.Params)
+					if err != nil {
+						return fmt.Errorf("error encoding format of %s: %w", name, err)
+					}
+					params = string(data)
+				}
+				rows = append(rows, []string{spaceName(space), view.Title, client.DataViewFieldFormat, name, fieldFormat.ID, params})
+			}
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+	return nil
+}
+
+// listDataViews returns the data view named with --data-view, or every data view of the space
+func listDataViews(c *client.KibanaClient, space string) ([]*client.DataView, error) {
+	if dataViewRef != "" {
+		view, err := c.FindDataView(space, dataViewRef)
+		if err != nil {
+			return nil, err
+		}
+		return []*client.DataView{view}, nil
+	}
+
+	summaries, err := c.ListDataViews(space)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]*client.DataView, 0, len(summaries))
+	for _, summary := range summaries {
+		view, err := c.GetDataView(space, summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// runApply executes the apply command
+func runApply(cmd *cobra.Command, args []string) error {
+	specs, err := loadSpec(specFile)
+	if err != nil {
+		return err
+	}
+
+	cfg, c, spaces, err := setup(cmd)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"Space", "Data View", "Kind", "Field", "Action", "Status"}
+	var rows [][]string
+	changed, failed := 0, 0
+	for _, space := range spaces {
+		for _, spec := range specs {
+			changes, err := c.ApplyDataViewFields(space, spec, dryRun)
+			if err != nil {
+				rows = append(rows, []string{spaceName(space), spec.DataView, "", "", "", err.Error()})
+				failed++
+				continue
+			}
+			for _, change := range changes {
+				status := "ok"
+				switch {
+				case change.Err != nil:
+					status = change.Err.Error()
+					failed++
+				case change.Action == "unchanged":
+				case dryRun:
+					status = "would be applied"
+					changed++
+				default:
+					status = "applied"
+					changed++
+				}
+				rows = append(rows, []string{spaceName(space), change.DataView, change.Kind, change.Field, change.Action, status})
+			}
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d fields would be changed in %d spaces\n", changed, len(spaces))
+	} else {
+		fmt.Fprintf(os.Stderr, "Changed %d fields in %d spaces\n", changed, len(spaces))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d data views or fields could not be applied", failed)
+	}
+	return nil
+}
+
+// loadSpec reads the data views of a YAML spec. Field names often contain dots, so the file is
+// decoded as is rather than through viper, which would split them into nested keys.
+func loadSpec(path string) ([]client.DataViewFieldSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading spec file: %w", err)
+	}
+	defer file.Close()
+
+	var spec fieldSpecFile
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("error parsing spec file %s: %w", path, err)
+	}
+
+	if len(spec.DataViews) == 0 {
+		return nil, fmt.Errorf("spec file %s has no data_views", path)
+	}
+	for i, view := range spec.DataViews {
+		if view.DataView == "" {
+			return nil, fmt.Errorf("data view %d of %s has no data_view", i+1, path)
+		}
+		for name, field := range view.RuntimeFields {
+			if field.Type == "" {
+				return nil, fmt.Errorf("runtime field %s of %s has no type", name, view.DataView)
+			}
+		}
+		for name, fieldFormat := range view.FieldFormats {
+			if fieldFormat.ID == "" {
+				return nil, fmt.Errorf("format of %s in %s has no id", name, view.DataView)
+			}
+		}
+	}
+	return spec.DataViews, nil
+}
+
+// runRemove executes the remove command
+func runRemove(cmd *cobra.Command, args []string) error {
+	cfg, c, spaces, err := setup(cmd)
+	if err != nil {
+		return err
+	}
+
+	kind := client.DataViewRuntimeField
+	if removeFormat {
+		kind = client.DataViewFieldFormat
+	}
+
+	header := []string{"Space", "Data View", "Kind", "Field", "Status"}
+	var rows [][]string
+	removed, failed := 0, 0
+	for _, space := range spaces {
+		view, err := c.FindDataView(space, dataViewRef)
+		if err != nil {
+			rows = append(rows, []string{spaceName(space), dataViewRef, kind, "", err.Error()})
+			failed++
+			continue
+		}
+		for _, name := range fieldNames {
+			var exists bool
+			if removeFormat {
+				_, exists = view.FieldFormats[name]
+			} else {
+				_, exists = view.RuntimeFieldMap[name]
+			}
+
+			status := "removed"
+			switch {
+			case !exists:
+				status = "not found"
+			case dryRun:
+				status = "would be removed"
+				removed++
+			default:
+				if removeFormat {
+					err = c.SetFieldFormat(space, view.ID, name, nil)
+				} else {
+					err = c.DeleteRuntimeField(space, view.ID, name)
+				}
+				if err != nil {
+					status = err.Error()
+					failed++
+				} else {
+					removed++
+				}
+			}
+			rows = append(rows, []string{spaceName(space), view.Title, kind, name, status})
+		}
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d fields would be removed in %d spaces\n", removed, len(spaces))
+	} else {
+		fmt.Fprintf(os.Stderr, "Removed %d fields in %d spaces\n", removed, len(spaces))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d data views or fields could not be removed", failed)
+	}
+	return nil
+}
+
+// spaceName returns the ID of a space for display, naming the default space
+func spaceName(space string) string {
+	if space == "" {
+		return "default"
+	}
+	return space
+}
+
+// sortedNames returns the keys of a map in sorted order
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/client"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// Files written to and read from a dump directory
+const (
+	spaceFile   = "space.json"
+	objectsFile = "objects.ndjson"
+)
+
+// Command line flags
+var (
+	outputStyle string
+	// Config file
+	configFile string
+
+	// Kibana connection
+	addresses []string
+	username  string
+	password  string
+	caCert    string
+	insecure  bool
+
+	// Command specific
+	spaceID         string
+	dumpDir         string
+	objectTypes     []string
+	overwrite       bool
+	createNewCopies bool
+	createSpace     bool
+
+	// Output
+	outputFormat string
+)
+
+func main() {
+	var rootCmd = &cobra.Command{
+		Use:   "kb_space",
+		Short: "Dump and restore the saved objects of a Kibana space",
+		Long: `Back up and restore every saved object in a Kibana space.
+
+The dump subcommand exports every exportable saved object in a space, with the objects they
+reference, to a directory holding the space definition (space.json) and the objects
+(objects.ndjson). The restore subcommand imports a dump into a space, creating the space from
+the dump first if asked to. This gives a per-space backup that does not depend on Elasticsearch
+snapshots, and a way to copy a space to another Kibana.
+
+Kibana limits an export to savedObjects.maxImportExportSize objects (10000 by default).
+
+Example usage:
+  kb_space dump --space marketing --output ./backups/marketing
+  kb_space restore --space marketing --input ./backups/marketing --overwrite
+  kb_space restore --space marketing-copy --input ./backups/marketing --create-space`,
+		Example: `kb_space dump --space marketing --output ./backups/marketing
+kb_space dump --space marketing --output ./backups/marketing --type dashboard,lens,index-pattern
+kb_space restore --space marketing --input ./backups/marketing --overwrite
+kb_space restore --space marketing-copy --input ./backups/marketing --create-space`,
+		PersistentPreRunE: initConfig,
+	}
+	// Disable the auto-generated completion command
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Dump subcommand
+	var dumpCmd = &cobra.Command{
+		Use:   "dump",
+		Short: "Export every saved object in a space to a directory",
+		Long: `Export every saved object in a space, with the objects they reference, to a directory.
+
+Without --type every known exportable type is requested; types this Kibana version does not
+know or cannot export are skipped and listed.`,
+		RunE: runDump,
+	}
+
+	// Restore subcommand
+	var restoreCmd = &cobra.Command{
+		Use:   "restore",
+		Short: "Import a space dump into a space",
+		Long: `Import the saved objects from a dump directory into a space.
+
+Objects that already exist in the space are reported as conflicts unless --overwrite is given;
+--create-new-copies imports every object with a new ID instead. With --create-space a space
+that does not exist is created from the dumped space definition, under the name given with
+--space.`,
+		RunE: runRestore,
+	}
+
+	// Config file flag
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default is ./config.yaml, ~/.config/esctl/config.yaml, or /etc/esctl/config.yaml)")
+	rootCmd.PersistentFlags().String("context", "", "Named cluster from the contexts section of the config file (default is current_context)")
+
+	// Kibana connection flags
+	rootCmd.PersistentFlags().StringSliceVar(&addresses, "kb-addresses", nil, "Kibana addresses (comma-separated list)")
+	rootCmd.PersistentFlags().StringVar(&username, "kb-username", "", "Kibana username")
+	rootCmd.PersistentFlags().StringVar(&password, "kb-password", "", "Kibana password")
+	rootCmd.PersistentFlags().StringVar(&caCert, "kb-ca-cert", "", "Path to CA certificate for Kibana")
+	rootCmd.PersistentFlags().BoolVar(&insecure, "kb-insecure", false, "Skip TLS certificate validation (insecure)")
+
+	// Output flags
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "", "Output format (fancy, plain, json, csv, or go-template=TEMPLATE)")
+	rootCmd.PersistentFlags().StringVar(&outputStyle, "style", "", "Table style for fancy output (dark, light, bright, blue, double)")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace usernames, IP addresses, API key IDs and other sensitive values with stable tokens")
+	rootCmd.PersistentFlags().Int("max-col-width", 0, "Limit every table column to this many characters (default is no limit)")
+	rootCmd.PersistentFlags().Bool("truncate", true, "Shorten table cells wider than their column limit, IDs keep their start and end")
+	rootCmd.PersistentFlags().Bool("no-truncate", false, "Show table cells whole, overriding --truncate and output.truncate")
+	rootCmd.PersistentFlags().String("time-format", "", "Format for timestamp columns: iso8601, epoch, relative (\"5m ago\") or a strftime pattern such as \"%Y-%m-%d %H:%M\"")
+	rootCmd.PersistentFlags().Int("max-429-retries", 3, "Times to retry a request rejected with 429 Too Many Requests, waiting as long as Retry-After asks")
+	rootCmd.PersistentFlags().String("local-port-forward", "", "Connect through an SSH jump host, given as ssh://[user@]host[:port]")
+	rootCmd.PersistentFlags().Bool("timings", false, "Print each API call made, with its duration and payload sizes, when the command ends")
+
+	// Dump command flags
+	dumpCmd.Flags().StringVarP(&spaceID, "space", "s", "", "ID of the space to dump (required)")
+	dumpCmd.Flags().StringVarP(&dumpDir, "output", "o", "", "Directory to write the dump to (required)")
+	dumpCmd.Flags().StringSliceVarP(&objectTypes, "type", "t", client.SpaceExportTypes, "Saved object types to export")
+	dumpCmd.MarkFlagRequired("space")
+	dumpCmd.MarkFlagRequired("output")
+
+	// Restore command flags
+	restoreCmd.Flags().StringVarP(&spaceID, "space", "s", "", "ID of the space to restore into (required)")
+	restoreCmd.Flags().StringVarP(&dumpDir, "input", "i", "", "Directory holding the dump (required)")
+	restoreCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace objects that already exist in the space")
+	restoreCmd.Flags().BoolVar(&createNewCopies, "create-new-copies", false, "Import every object with a new ID instead of keeping the dumped IDs")
+	restoreCmd.Flags().BoolVar(&createSpace, "create-space", false, "Create the space from the dumped definition if it does not exist")
+	restoreCmd.MarkFlagRequired("space")
+	restoreCmd.MarkFlagRequired("input")
+	restoreCmd.MarkFlagsMutuallyExclusive("overwrite", "create-new-copies")
+
+	// Add subcommands
+	rootCmd.AddCommand(dumpCmd, restoreCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Print(err)
+		os.Exit(client.ExitCode(err))
+	}
+}
+
+// initConfig reads in config file and ENV variables if set
+func initConfig(cmd *cobra.Command, args []string) error {
+	return config.InitializeKibanaConfig(cmd, configFile, addresses, username, password, caCert, insecure, outputFormat)
+}
+
+// runDump executes the dump command
+func runDump(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	// The space definition is kept so restore can recreate the space
+	space, err := c.GetSpace(spaceID)
+	if err != nil {
+		return fmt.Errorf("error getting space %s: %w", spaceID, err)
+	}
+
+	export, err := c.ExportSpace(spaceID, objectTypes)
+	if err != nil {
+		return fmt.Errorf("error exporting space %s: %w", spaceID, err)
+	}
+
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(dumpDir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	spaceJSON, err := json.MarshalIndent(space, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding space: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dumpDir, spaceFile), append(spaceJSON, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dumpDir, objectsFile), export.NDJSON, 0644); err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+
+	// Objects per type
+	types := make([]string, 0, len(export.ObjectCounts))
+	for t := range export.ObjectCounts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	header := []string{"Type", "Objects"}
+	var rows [][]string
+	for _, t := range types {
+		rows = append(rows, []string{t, fmt.Sprintf("%d", export.ObjectCounts[t])})
+	}
+
+	formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+	if err := formatter.Write(header, rows); err != nil {
+		return fmt.Errorf("error formatting output: %w", err)
+	}
+
+	if len(export.SkippedTypes) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped types this Kibana cannot export: %s\n", strings.Join(export.SkippedTypes, ", "))
+	}
+	if len(export.MissingReferences) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d referenced objects are missing and were not exported:\n", len(export.MissingReferences))
+		for _, ref := range export.MissingReferences {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", ref.Type, ref.ID)
+		}
+	}
+
+	fmt.Printf("Dumped %d objects from space %s to %s\n", export.ExportedCount, spaceID, dumpDir)
+	return nil
+}
+
+// runRestore executes the restore command
+func runRestore(cmd *cobra.Command, args []string) error {
+	// Get config from context
+	cfg, err := config.Load(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// Create Kibana client
+	c, err := client.NewKibana(cfg)
+	if err != nil {
+		return fmt.Errorf("error creating Kibana client: %w", err)
+	}
+
+	ndjson, err := os.ReadFile(filepath.Join(dumpDir, objectsFile))
+	if err != nil {
+		return fmt.Errorf("error reading dump: %w", err)
+	}
+
+	// Make sure the target space exists, creating it from the dump if asked to
+	if _, err := c.GetSpace(spaceID); err != nil {
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("error getting space %s: %w", spaceID, err)
+		}
+		if !createSpace {
+			return fmt.Errorf("space %s does not exist, use --create-space to create it from the dump", spaceID)
+		}
+		if err := createSpaceFromDump(c); err != nil {
+			return err
+		}
+	}
+
+	result, err := c.ImportSpace(spaceID, ndjson, overwrite, createNewCopies)
+	if err != nil {
+		return fmt.Errorf("error importing into space %s: %w", spaceID, err)
+	}
+
+	if len(result.Errors) > 0 {
+		header := []string{"Type", "ID", "Title", "Error"}
+		var rows [][]string
+		for _, e := range result.Errors {
+			rows = append(rows, []string{e.Type, e.ID, e.Title, e.Error})
+		}
+
+		formatter := format.NewWithStyle(cfg.Output.Format, cfg.Output.Style)
+		if err := formatter.Write(header, rows); err != nil {
+			return fmt.Errorf("error formatting output: %w", err)
+		}
+	}
+
+	fmt.Printf("Restored %d objects into space %s", result.SuccessCount, spaceID)
+	if len(result.Errors) > 0 {
+		fmt.Printf(", %d failed", len(result.Errors))
+		if !overwrite && !createNewCopies {
+			fmt.Printf(" (use --overwrite to replace existing objects)")
+		}
+	}
+	fmt.Println()
+
+	if !result.Success {
+		return fmt.Errorf("%d objects could not be restored", len(result.Errors))
+	}
+	return nil
+}
+
+// createSpaceFromDump creates the target space from the space definition in the dump directory
+func createSpaceFromDump(c *client.KibanaClient) error {
+	data, err := os.ReadFile(filepath.Join(dumpDir, spaceFile))
+	if err != nil {
+		return fmt.Errorf("error reading dump: %w", err)
+	}
+
+	var space client.KibanaSpace
+	if err := json.Unmarshal(data, &space); err != nil {
+		return fmt.Errorf("error parsing %s: %w", spaceFile, err)
+	}
+
+	// A space restored under another ID is named after that ID, the dumped name belongs to the original
+	if space.ID != spaceID {
+		space.ID = spaceID
+		space.Name = spaceID
+	}
+
+	if err := c.CreateSpace(space); err != nil {
+		return fmt.Errorf("error creating space %s: %w", spaceID, err)
+	}
+	fmt.Printf("Created space %s\n", spaceID)
+	return nil
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TermBucket is a value of a field and the number of documents holding it
+type TermBucket struct {
+	Key      string
+	DocCount int64
+}
+
+// TermsResult is the outcome of a terms aggregation
+type TermsResult struct {
+	Buckets    []TermBucket
+	OtherDocs  int64 // documents holding values outside the top buckets
+	TotalDocs  int64 // documents searched
+	ErrorBound int64 // upper bound on the count error of a bucket, from shard-level sampling
+}
+
+// TermsAggregation returns the most common values of a field in the indices matching a pattern
+func (c *Client) TermsAggregation(pattern, field string, size int) (*TermsResult, error) {
+	body := map[string]interface{}{
+		"terms": map[string]interface{}{
+			"field":                     field,
+			"size":                      size,
+			"show_term_doc_count_error": true,
+		},
+	}
+
+	var agg struct {
+		DocCountErrorUpperBound int64 `json:"doc_count_error_upper_bound"`
+		SumOtherDocCount        int64 `json:"sum_other_doc_count"`
+		Buckets                 []struct {
+			Key         interface{} `json:"key"`
+			KeyAsString string      `json:"key_as_string"`
+			DocCount    int64       `json:"doc_count"`
+		} `json:"buckets"`
+	}
+	total, err := c.runAggregation(pattern, nil, body, &agg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TermsResult{OtherDocs: agg.SumOtherDocCount, TotalDocs: total, ErrorBound: agg.DocCountErrorUpperBound}
+	for _, b := range agg.Buckets {
+		// Dates and booleans come with a readable key_as_string
+		key := b.KeyAsString
+		if key == "" {
+			key = fmt.Sprintf("%v", b.Key)
+		}
+		result.Buckets = append(result.Buckets, TermBucket{Key: key, DocCount: b.DocCount})
+	}
+
+	return result, nil
+}
+
+// CardinalityAggregation returns the approximate number of distinct values of a field in the
+// indices matching a pattern, and the number of documents searched
+func (c *Client) CardinalityAggregation(pattern, field string) (int64, int64, error) {
+	body := map[string]interface{}{
+		"cardinality": map[string]interface{}{
+			"field": field,
+		},
+	}
+
+	var agg struct {
+		Value int64 `json:"value"`
+	}
+	total, err := c.runAggregation(pattern, nil, body, &agg)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return agg.Value, total, nil
+}
+
+// runAggregation runs a single aggregation over the documents matching a query, or every document
+// if the query is nil, in the indices matching a pattern, without returning any hits, and decodes
+// the aggregation result. It returns the number of documents searched.
+func (c *Client) runAggregation(pattern string, query, aggregation map[string]interface{}, result interface{}) (int64, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	body := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"aggs": map[string]interface{}{
+			"result": aggregation,
+		},
+	}
+	if query != nil {
+		body["query"] = query
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return 0, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithIndex(pattern),
+		c.es.Search.WithBody(&buf),
+		c.es.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error running aggregation: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			Result json.RawMessage `json:"result"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(response.Aggregations.Result) == 0 {
+		return response.Hits.Total.Value, nil
+	}
+	if err := json.Unmarshal(response.Aggregations.Result, result); err != nil {
+		return 0, fmt.Errorf("error parsing aggregation: %w", err)
+	}
+
+	return response.Hits.Total.Value, nil
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// alertingRulesPageSize is the number of rules requested per page from the rules find API
+const alertingRulesPageSize = 100
+
+// AlertingRule is a Kibana alerting rule
+type AlertingRule struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	RuleTypeID      string         `json:"rule_type_id"`
+	Consumer        string         `json:"consumer"`
+	Tags            []string       `json:"tags"`
+	Enabled         bool           `json:"enabled"`
+	MuteAll         bool           `json:"mute_all"`
+	IsSnoozedUntil  string         `json:"is_snoozed_until,omitempty"`
+	SnoozeSchedule  []RuleSnooze   `json:"snooze_schedule,omitempty"`
+	ExecutionStatus RuleExecStatus `json:"execution_status"`
+}
+
+// RuleSnooze is a snooze schedule on a rule
+type RuleSnooze struct {
+	ID       string `json:"id"`
+	Duration int64  `json:"duration"` // milliseconds
+}
+
+// RuleExecStatus is the outcome of a rule's last run
+type RuleExecStatus struct {
+	Status        string `json:"status"`
+	LastExecution string `json:"last_execution_date"`
+}
+
+// AlertingRuleFilter selects rules by tag, rule type and a search of rule names
+type AlertingRuleFilter struct {
+	Tags      []string
+	RuleTypes []string
+	Search    string
+}
+
+// RuleOperationError is a rule a bulk operation failed on
+type RuleOperationError struct {
+	Rule AlertingRule
+	Err  error
+}
+
+// kuery builds the KQL filter of the rules find API. Rule attributes are addressed as
+// alert.attributes.* in the filter.
+func (f AlertingRuleFilter) kuery() string {
+	var clauses []string
+	if len(f.Tags) > 0 {
+		var tags []string
+		for _, tag := range f.Tags {
+			tags = append(tags, "alert.attributes.tags:"+strconv.Quote(tag))
+		}
+		clauses = append(clauses, "("+strings.Join(tags, " or ")+")")
+	}
+	if len(f.RuleTypes) > 0 {
+		var types []string
+		for _, t := range f.RuleTypes {
+			types = append(types, "alert.attributes.alertTypeId:"+strconv.Quote(t))
+		}
+		clauses = append(clauses, "("+strings.Join(types, " or ")+")")
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// FindAlertingRules returns every rule matching the filter, following pages until all are read
+func (c *KibanaClient) FindAlertingRules(filter AlertingRuleFilter) ([]AlertingRule, error) {
+	params := url.Values{}
+	params.Set("per_page", strconv.Itoa(alertingRulesPageSize))
+	params.Set("sort_field", "name")
+	if kuery := filter.kuery(); kuery != "" {
+		params.Set("filter", kuery)
+	}
+	if filter.Search != "" {
+		params.Set("search", filter.Search)
+		params.Set("search_fields", "name")
+	}
+
+	var rules []AlertingRule
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var response struct {
+			Page    int            `json:"page"`
+			PerPage int            `json:"per_page"`
+			Total   int            `json:"total"`
+			Data    []AlertingRule `json:"data"`
+		}
+		if err := c.kibanaJSONRequest("GET", "/api/alerting/rules/_find?"+params.Encode(), nil, &response); err != nil {
+			return nil, err
+		}
+		rules = append(rules, response.Data...)
+
+		if len(response.Data) < alertingRulesPageSize || len(rules) >= response.Total {
+			return rules, nil
+		}
+	}
+}
+
+// EnableAlertingRule enables a rule
+func (c *KibanaClient) EnableAlertingRule(id string) error {
+	return c.kibanaJSONRequest("POST", fmt.Sprintf("/api/alerting/rule/%s/_enable", url.PathEscape(id)), nil, nil)
+}
+
+// DisableAlertingRule disables a rule
+func (c *KibanaClient) DisableAlertingRule(id string) error {
+	return c.kibanaJSONRequest("POST", fmt.Sprintf("/api/alerting/rule/%s/_disable", url.PathEscape(id)), nil, nil)
+}
+
+// SnoozeAlertingRule snoozes a rule's notifications for a duration starting now. The rule keeps
+// running; only its actions are suppressed.
+func (c *KibanaClient) SnoozeAlertingRule(id string, duration time.Duration) error {
+	body := map[string]interface{}{
+		"schedule": map[string]interface{}{
+			"custom": map[string]interface{}{
+				"start":    time.Now().UTC().Format(time.RFC3339),
+				"duration": fmt.Sprintf("%dm", int64(duration.Minutes())),
+			},
+		},
+	}
+	return c.kibanaJSONRequest("POST", fmt.Sprintf("/api/alerting/rule/%s/snooze_schedule", url.PathEscape(id)), body, nil)
+}
+
+// UnsnoozeAlertingRule removes every snooze schedule from a rule
+func (c *KibanaClient) UnsnoozeAlertingRule(rule AlertingRule) error {
+	for _, snooze := range rule.SnoozeSchedule {
+		path := fmt.Sprintf("/api/alerting/rule/%s/snooze_schedule/%s", url.PathEscape(rule.ID), url.PathEscape(snooze.ID))
+		if err := c.kibanaJSONRequest("DELETE", path, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyToAlertingRules runs an operation on each rule and returns the rules it failed on
+func ApplyToAlertingRules(rules []AlertingRule, op func(AlertingRule) error) []RuleOperationError {
+	var failures []RuleOperationError
+	for _, rule := range rules {
+		if err := op(rule); err != nil {
+			failures = append(failures, RuleOperationError{Rule: rule, Err: err})
+		}
+	}
+	return failures
+}
+
+// kibanaJSONRequest sends a JSON request to a Kibana API and decodes the response, if asked to
+func (c *KibanaClient) kibanaJSONRequest(method, path string, body interface{}, response interface{}) error {
+	return c.kibanaURLRequest(method, c.baseURL+path, body, response)
+}
+
+// kibanaSpaceRequest sends a JSON request to a Kibana API in the given space rather than the
+// configured one
+func (c *KibanaClient) kibanaSpaceRequest(method, space, path string, body interface{}, response interface{}) error {
+	return c.kibanaURLRequest(method, c.address+spacePath(space)+path, body, response)
+}
+
+// kibanaURLRequest sends a JSON request to a Kibana URL and decodes the response, if asked to
+func (c *KibanaClient) kibanaURLRequest(method, requestURL string, body interface{}, response interface{}) error {
+	var reqBody *bytes.Buffer
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshaling request body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(bodyBytes)
+	} else {
+		reqBody = bytes.NewBuffer(nil)
+	}
+
+	// Create the request
+	req, err := http.NewRequest(method, requestURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newHTTPError(resp)
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	// Parse the response
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return nil
+}
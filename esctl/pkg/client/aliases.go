@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// AliasInfo represents a single alias to index association
+type AliasInfo struct {
+	Alias         string `json:"alias"`
+	Index         string `json:"index"`
+	Filter        string `json:"filter"`
+	RoutingIndex  string `json:"routing.index"`
+	RoutingSearch string `json:"routing.search"`
+	IsWriteIndex  string `json:"is_write_index"`
+}
+
+// AliasAction represents a single add or remove action in an _aliases request
+type AliasAction struct {
+	Type         string // add or remove
+	Index        string
+	Alias        string
+	Filter       map[string]interface{} // query limiting what the alias sees, add only
+	IsWriteIndex *bool                  // make the index the write index of the alias, add only
+}
+
+// GetAliases returns alias to index associations, optionally limited to a single alias name
+func (c *Client) GetAliases(name string) ([]AliasInfo, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Prepare options for v9 API
+	opts := []func(*esapi.CatAliasesRequest){
+		c.es.Cat.Aliases.WithContext(ctx),
+		c.es.Cat.Aliases.WithFormat("json"),
+		c.es.Cat.Aliases.WithH("alias,index,filter,routing.index,routing.search,is_write_index"),
+	}
+
+	// Add alias name if specified
+	if name != "" {
+		opts = append(opts, c.es.Cat.Aliases.WithName(name))
+	}
+
+	// Execute request
+	res, err := c.es.Cat.Aliases(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting response: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var aliases []AliasInfo
+	if err := json.NewDecoder(res.Body).Decode(&aliases); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// AliasWriteIndices returns the write index of each alias, by alias name. An alias writes to the
+// index marked as its write index, or to its only index unless that is marked otherwise; aliases
+// of several indices with none marked cannot be written to and are left out.
+func AliasWriteIndices(aliases []AliasInfo) map[string]string {
+	entries := make(map[string][]AliasInfo)
+	for _, alias := range aliases {
+		entries[alias.Alias] = append(entries[alias.Alias], alias)
+	}
+
+	writeIndices := make(map[string]string)
+	for name, aliasEntries := range entries {
+		for _, entry := range aliasEntries {
+			if entry.IsWriteIndex == "true" {
+				writeIndices[name] = entry.Index
+			}
+		}
+		if _, ok := writeIndices[name]; !ok && len(aliasEntries) == 1 && aliasEntries[0].IsWriteIndex != "false" {
+			writeIndices[name] = aliasEntries[0].Index
+		}
+	}
+	return writeIndices
+}
+
+// AliasActionsPayload builds the body of an _aliases request from a list of actions
+func AliasActionsPayload(actions []AliasAction) map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(actions))
+	for _, action := range actions {
+		item := map[string]interface{}{
+			"index": action.Index,
+			"alias": action.Alias,
+		}
+		if action.Filter != nil {
+			item["filter"] = action.Filter
+		}
+		if action.IsWriteIndex != nil {
+			item["is_write_index"] = *action.IsWriteIndex
+		}
+		items = append(items, map[string]interface{}{action.Type: item})
+	}
+
+	return map[string]interface{}{
+		"actions": items,
+	}
+}
+
+// UpdateAliases applies all actions in a single atomic _aliases request
+func (c *Client) UpdateAliases(actions []AliasAction) error {
+	// Validate alias names before sending anything to the cluster
+	for _, action := range actions {
+		if action.Type == "add" {
+			if err := ValidateAliasName(action.Alias); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Prepare the request body
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(AliasActionsPayload(actions)); err != nil {
+		return fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Indices.UpdateAliases(
+		&buf,
+		c.es.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error updating aliases: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	return nil
+}
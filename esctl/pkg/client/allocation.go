@@ -33,7 +33,7 @@ func (c *Client) GetAllocationStatus() (string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return "", fmt.Errorf("error response: %s", res.String())
+		return "", newResponseError(res)
 	}
 
 	// Parse response
@@ -107,7 +107,7 @@ func (c *Client) SetAllocationStatus(status string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -156,7 +156,7 @@ func (c *Client) GetAllocationExplain(indexName, shardID string, primary bool) (
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"sort"
+	"strings"
+)
+
+// nodeIdentityKeys are the allocation explain fields that identify a node
+var nodeIdentityKeys = map[string]string{
+	"id":                "node",
+	"node_id":           "node",
+	"name":              "node",
+	"node_name":         "node",
+	"transport_address": "addr",
+	"ip":                "addr",
+	"host":              "addr",
+}
+
+// GetAllocationExplainAll returns an allocation explanation for every unassigned shard copy.
+// Copies of the same shard that share index, shard number and primary flag are explained once.
+func (c *Client) GetAllocationExplainAll() ([]map[string]interface{}, error) {
+	shards, err := c.GetShards(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var explanations []map[string]interface{}
+	for _, shard := range shards {
+		if shard.State != "UNASSIGNED" {
+			continue
+		}
+
+		key := shard.Index + "/" + shard.Shard + "/" + shard.PrimaryOrReplica
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		explanation, err := c.GetAllocationExplain(shard.Index, shard.Shard, shard.PrimaryOrReplica == "p")
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, explanation)
+	}
+
+	return explanations, nil
+}
+
+// RedactAllocationExplain replaces node names, node IDs, IP addresses and hostnames in an
+// allocation explain document with the tokens given by token, such as those of the output
+// redactor. The same value maps to the same token, so the relationships between nodes in the
+// output are preserved.
+func RedactAllocationExplain(doc interface{}, token func(prefix, value string) string) interface{} {
+	// Collect every identifying value first so they can also be replaced inside free text
+	replacements := make(map[string]string)
+	collectNodeIdentities(doc, replacements, token)
+
+	// Replace longer values first so that a name is not partially replaced by a shorter one
+	values := make([]string, 0, len(replacements))
+	for value := range replacements {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	return redactValue(doc, values, replacements)
+}
+
+// collectNodeIdentities records a token for every node identity value in the document
+func collectNodeIdentities(doc interface{}, replacements map[string]string, token func(prefix, value string) string) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if prefix, ok := nodeIdentityKeys[key]; ok {
+				if s, ok := value.(string); ok && s != "" {
+					replacements[s] = token(prefix, s)
+					// Transport addresses also leak the bare IP in explanation text
+					if host, _, found := strings.Cut(s, ":"); found && host != "" {
+						if _, exists := replacements[host]; !exists {
+							replacements[host] = token(prefix, host)
+						}
+					}
+				}
+			}
+			collectNodeIdentities(value, replacements, token)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectNodeIdentities(item, replacements, token)
+		}
+	}
+}
+
+// redactValue returns a copy of doc with every known identity replaced by its token
+func redactValue(doc interface{}, values []string, replacements map[string]string) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = redactValue(value, values, replacements)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactValue(item, values, replacements)
+		}
+		return result
+	case string:
+		if token, ok := replacements[v]; ok {
+			return token
+		}
+		for _, value := range values {
+			if strings.Contains(v, value) {
+				v = strings.ReplaceAll(v, value, replacements[value])
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexBlockTypes are the index blocks that can be set with SetIndexBlock
+var IndexBlockTypes = []string{"write", "read", "read_only", "read_only_allow_delete", "metadata"}
+
+// IndexBlocks lists the blocks set on an index
+type IndexBlocks struct {
+	Index  string
+	Blocks []string
+}
+
+// ValidateBlockType checks that a block type is one Elasticsearch supports
+func ValidateBlockType(blockType string) error {
+	for _, t := range IndexBlockTypes {
+		if blockType == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid block type '%s', must be one of: %s", blockType, strings.Join(IndexBlockTypes, ", "))
+}
+
+// SetIndexBlock sets or removes a block on an index. Removing a block resets its setting, so the
+// index falls back to the default of no block.
+func (c *Client) SetIndexBlock(indexName, blockType string, enabled bool) error {
+	if err := ValidateBlockType(blockType); err != nil {
+		return err
+	}
+
+	var value interface{}
+	if enabled {
+		value = true
+	}
+
+	return c.UpdateIndexSettings(indexName, map[string]interface{}{
+		"index.blocks." + blockType: value,
+	})
+}
+
+// GetIndexBlocks returns the indices matching a pattern that carry at least one block, sorted by name
+func (c *Client) GetIndexBlocks(pattern string) ([]IndexBlocks, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexPattern := "*"
+	if pattern != "" {
+		indexPattern = pattern
+	}
+
+	// Execute request, including closed and hidden indices
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex(indexPattern),
+		c.es.Indices.GetSettings.WithName("index.blocks.*"),
+		c.es.Indices.GetSettings.WithFlatSettings(true),
+		c.es.Indices.GetSettings.WithExpandWildcards("all"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	blocked := make([]IndexBlocks, 0)
+	for index, entry := range response {
+		var blocks []string
+		for key, value := range entry.Settings {
+			if fmt.Sprintf("%v", value) == "true" {
+				blocks = append(blocks, strings.TrimPrefix(key, "index.blocks."))
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		sort.Strings(blocks)
+		blocked = append(blocked, IndexBlocks{Index: index, Blocks: blocks})
+	}
+	sort.Slice(blocked, func(i, j int) bool {
+		return blocked[i].Index < blocked[j].Index
+	})
+
+	return blocked, nil
+}
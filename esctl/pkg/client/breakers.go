@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultBreakers are the circuit breakers that reject requests when a node runs short of heap
+var DefaultBreakers = []string{"parent", "fielddata", "request", "inflight_requests"}
+
+// NodeBreaker is the usage of one circuit breaker on a node
+type NodeBreaker struct {
+	Node      string
+	Breaker   string
+	Estimated int64 // bytes the breaker currently accounts for
+	Limit     int64 // bytes at which the breaker trips
+	Overhead  float64
+	Tripped   int64 // times the breaker has tripped since the node started
+}
+
+// UsedPercent returns the estimated size as a percentage of the limit
+func (b NodeBreaker) UsedPercent() float64 {
+	if b.Limit <= 0 {
+		return 0
+	}
+	return float64(b.Estimated) * 100 / float64(b.Limit)
+}
+
+// Status returns CRITICAL or WARNING when the breaker is at or above the given percentages of its
+// limit, and OK otherwise
+func (b NodeBreaker) Status(warnPercent, criticalPercent float64) string {
+	switch used := b.UsedPercent(); {
+	case used >= criticalPercent:
+		return "CRITICAL"
+	case used >= warnPercent:
+		return "WARNING"
+	}
+	return "OK"
+}
+
+// GetNodeBreakers returns the usage of the named circuit breakers on every node, sorted by node
+// and then in the order the breakers were given. All breakers a node reports are returned when
+// breakers is empty. Each node is asked for its stats concurrently; if some nodes fail, the
+// breakers of the others are returned together with a PartialNodeError.
+func (c *Client) GetNodeBreakers(breakers []string) ([]NodeBreaker, error) {
+	members, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, statsErr := c.getNodeStatsByNode(members, "breaker")
+	if stats == nil {
+		return nil, fmt.Errorf("error getting node breaker stats: %w", statsErr)
+	}
+
+	var result []NodeBreaker
+	for _, member := range members {
+		nodeInfo, ok := stats[member.ID]
+		if !ok {
+			continue
+		}
+
+		names := breakers
+		if len(names) == 0 {
+			reported, _ := nodeInfo["breakers"].(map[string]interface{})
+			for name := range reported {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+
+		for _, name := range names {
+			limit, ok := nodeStatFloat(nodeInfo, "breakers", name, "limit_size_in_bytes")
+			if !ok {
+				continue
+			}
+			estimated, _ := nodeStatFloat(nodeInfo, "breakers", name, "estimated_size_in_bytes")
+			overhead, _ := nodeStatFloat(nodeInfo, "breakers", name, "overhead")
+			tripped, _ := nodeStatFloat(nodeInfo, "breakers", name, "tripped")
+
+			result = append(result, NodeBreaker{
+				Node:      member.Name,
+				Breaker:   name,
+				Estimated: int64(estimated),
+				Limit:     int64(limit),
+				Overhead:  overhead,
+				Tripped:   int64(tripped),
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Node < result[j].Node
+	})
+
+	return result, statsErr
+}
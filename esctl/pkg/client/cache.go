@@ -0,0 +1,193 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+)
+
+// defaultCacheTTL is how long on-disk cache entries are used when no TTL is configured
+const defaultCacheTTL = time.Minute
+
+// lookupCache keeps the results of expensive metadata lookups, such as the node list or the
+// agent policies, so they are fetched once per command run. Entries are always kept in memory
+// for the life of the client; when enabled they are also written to disk and reused by later
+// runs until the TTL expires.
+type lookupCache struct {
+	mu        sync.Mutex
+	entries   map[string]interface{}
+	namespace string        // separates clusters and users sharing a cache directory
+	dir       string        // on-disk cache directory, empty when disabled
+	ttl       time.Duration // lifetime of on-disk entries
+}
+
+// diskCacheEntry is the on-disk form of a cache entry
+type diskCacheEntry struct {
+	Expires time.Time       `json:"expires"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// newLookupCache creates a cache for a client connecting to the given addresses as the given user
+func newLookupCache(cc config.CacheConfig, addresses []string, username string) (*lookupCache, error) {
+	cache := &lookupCache{
+		entries:   make(map[string]interface{}),
+		namespace: strings.Join(addresses, ",") + "|" + username,
+		ttl:       defaultCacheTTL,
+	}
+	if !cc.Enabled {
+		return cache, nil
+	}
+
+	if cc.TTL != "" {
+		ttl, err := time.ParseDuration(cc.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache ttl %q: %w", cc.TTL, err)
+		}
+		cache.ttl = ttl
+	}
+
+	cache.dir = cc.Dir
+	if cache.dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("finding cache directory: %w", err)
+		}
+		cache.dir = filepath.Join(userCacheDir, "esctl")
+	}
+
+	return cache, nil
+}
+
+// cachedLookup returns the cached value for key, calling load and caching its result on a miss.
+// Failures to read or write the on-disk cache are ignored and the value is loaded instead.
+func cachedLookup[T any](cache *lookupCache, key string, load func() (T, error)) (T, error) {
+	if cache == nil {
+		return load()
+	}
+
+	cache.mu.Lock()
+	if value, ok := cache.entries[key]; ok {
+		cache.mu.Unlock()
+		return value.(T), nil
+	}
+	cache.mu.Unlock()
+
+	if value, ok := readDiskCache[T](cache, key); ok {
+		cache.store(key, value)
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	cache.store(key, value)
+	cache.writeDisk(key, value)
+
+	return value, nil
+}
+
+// invalidate drops every entry of a kind, the part of the key before the first ':', in memory
+// and on disk
+func (c *lookupCache) invalidate(kind string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	for key := range c.entries {
+		if key == kind || strings.HasPrefix(key, kind+":") {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(c.dir, c.filePrefix(kind)+"-*.json"))
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// store keeps a value in memory
+func (c *lookupCache) store(key string, value interface{}) {
+	c.mu.Lock()
+	c.entries[key] = value
+	c.mu.Unlock()
+}
+
+// readDiskCache reads an unexpired entry from the on-disk cache
+func readDiskCache[T any](c *lookupCache, key string) (T, bool) {
+	var value T
+	if c.dir == "" {
+		return value, false
+	}
+
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return value, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Now().After(entry.Expires) {
+		return value, false
+	}
+	if err := json.Unmarshal(entry.Value, &value); err != nil {
+		return value, false
+	}
+
+	return value, true
+}
+
+// writeDisk writes an entry to the on-disk cache
+func (c *lookupCache) writeDisk(key string, value interface{}) {
+	if c.dir == "" {
+		return
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(diskCacheEntry{Expires: time.Now().Add(c.ttl), Value: raw})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return
+	}
+	ioutil.WriteFile(c.path(key), data, 0600)
+}
+
+// path returns the on-disk file for a key. The file name starts with a hash of the key's kind,
+// the part before the first ':', so that all entries of a kind can be invalidated together.
+func (c *lookupCache) path(key string) string {
+	kind := key
+	if i := strings.Index(key, ":"); i >= 0 {
+		kind = key[:i]
+	}
+	return filepath.Join(c.dir, c.filePrefix(kind)+"-"+hashString(c.namespace+"|"+key)+".json")
+}
+
+// filePrefix returns the file name prefix used for entries of a kind
+func (c *lookupCache) filePrefix(kind string) string {
+	return hashString(c.namespace + "|" + kind)
+}
+
+// hashString returns a short hex hash of a string for use in file names
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:16]
+}
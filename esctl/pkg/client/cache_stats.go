@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// CacheTypes are the caches that can be cleared with ClearCache
+var CacheTypes = []string{"fielddata", "query", "request"}
+
+// CacheUsage is the fielddata, query cache and request cache usage of a node or an index
+type CacheUsage struct {
+	Name string
+
+	FielddataBytes     int64
+	FielddataEvictions int64
+
+	QueryCacheBytes     int64
+	QueryCacheEvictions int64
+	QueryCacheHits      int64
+	QueryCacheMisses    int64
+
+	RequestCacheBytes     int64
+	RequestCacheEvictions int64
+	RequestCacheHits      int64
+	RequestCacheMisses    int64
+}
+
+// TotalBytes returns the memory used by all three caches
+func (u *CacheUsage) TotalBytes() int64 {
+	return u.FielddataBytes + u.QueryCacheBytes + u.RequestCacheBytes
+}
+
+// GetNodeCacheUsage returns the cache usage of every node, sorted by total cache memory, largest
+// first. Nodes are asked concurrently; if some fail, the others are returned with a PartialNodeError.
+func (c *Client) GetNodeCacheUsage() ([]CacheUsage, error) {
+	members, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, statsErr := c.getNodeStatsByNode(members, "indices")
+	if stats == nil {
+		return nil, fmt.Errorf("error getting node cache stats: %w", statsErr)
+	}
+
+	var usage []CacheUsage
+	for _, member := range members {
+		nodeStats, ok := stats[member.ID]
+		if !ok {
+			continue
+		}
+		indices, _ := nodeStats["indices"].(map[string]interface{})
+		usage = append(usage, cacheUsageFromStats(member.Name, indices))
+	}
+	sortCacheUsage(usage)
+
+	return usage, statsErr
+}
+
+// GetIndexCacheUsage returns the cache usage of every index matching a pattern, summed over all
+// shard copies and sorted by total cache memory, largest first
+func (c *Client) GetIndexCacheUsage(pattern string) ([]CacheUsage, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexPattern := "*"
+	if pattern != "" {
+		indexPattern = pattern
+	}
+
+	// Execute request
+	res, err := c.es.Indices.Stats(
+		c.es.Indices.Stats.WithContext(ctx),
+		c.es.Indices.Stats.WithIndex(indexPattern),
+		c.es.Indices.Stats.WithMetric("fielddata", "query_cache", "request_cache"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index cache stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Indices map[string]struct {
+			Total map[string]interface{} `json:"total"`
+		} `json:"indices"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	usage := make([]CacheUsage, 0, len(response.Indices))
+	for name, index := range response.Indices {
+		usage = append(usage, cacheUsageFromStats(name, index.Total))
+	}
+	sortCacheUsage(usage)
+
+	return usage, nil
+}
+
+// ClearCache clears the given cache types, or all of them if none are given, for the indices
+// matching a pattern, or for all indices if the pattern is empty
+func (c *Client) ClearCache(pattern string, types []string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Prepare options
+	opts := []func(*esapi.IndicesClearCacheRequest){
+		c.es.Indices.ClearCache.WithContext(ctx),
+	}
+	if pattern != "" {
+		opts = append(opts, c.es.Indices.ClearCache.WithIndex(pattern))
+	}
+	for _, t := range types {
+		switch t {
+		case "fielddata":
+			opts = append(opts, c.es.Indices.ClearCache.WithFielddata(true))
+		case "query":
+			opts = append(opts, c.es.Indices.ClearCache.WithQuery(true))
+		case "request":
+			opts = append(opts, c.es.Indices.ClearCache.WithRequest(true))
+		default:
+			return fmt.Errorf("invalid cache type '%s', must be one of: %s", t, strings.Join(CacheTypes, ", "))
+		}
+	}
+
+	// Execute request
+	res, err := c.es.Indices.ClearCache(opts...)
+	if err != nil {
+		return fmt.Errorf("error clearing cache: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	return nil
+}
+
+// cacheUsageFromStats reads cache usage from the indices section of node stats or the total
+// section of index stats, which share the same layout
+func cacheUsageFromStats(name string, stats map[string]interface{}) CacheUsage {
+	stat := func(path ...string) int64 {
+		value, _ := nodeStatFloat(stats, path...)
+		return int64(value)
+	}
+
+	return CacheUsage{
+		Name:                  name,
+		FielddataBytes:        stat("fielddata", "memory_size_in_bytes"),
+		FielddataEvictions:    stat("fielddata", "evictions"),
+		QueryCacheBytes:       stat("query_cache", "memory_size_in_bytes"),
+		QueryCacheEvictions:   stat("query_cache", "evictions"),
+		QueryCacheHits:        stat("query_cache", "hit_count"),
+		QueryCacheMisses:      stat("query_cache", "miss_count"),
+		RequestCacheBytes:     stat("request_cache", "memory_size_in_bytes"),
+		RequestCacheEvictions: stat("request_cache", "evictions"),
+		RequestCacheHits:      stat("request_cache", "hit_count"),
+		RequestCacheMisses:    stat("request_cache", "miss_count"),
+	}
+}
+
+// sortCacheUsage sorts by total cache memory, largest first, then by name
+func sortCacheUsage(usage []CacheUsage) {
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].TotalBytes() != usage[j].TotalBytes() {
+			return usage[i].TotalBytes() > usage[j].TotalBytes()
+		}
+		return usage[i].Name < usage[j].Name
+	})
+}
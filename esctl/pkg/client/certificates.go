@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// NodeCertificate is a certificate Elasticsearch has loaded for TLS
+type NodeCertificate struct {
+	Path          string    `json:"path"`
+	Format        string    `json:"format"`
+	Alias         string    `json:"alias"`
+	Subject       string    `json:"subject_dn"`
+	Issuer        string    `json:"issuer"`
+	SerialNumber  string    `json:"serial_number"`
+	HasPrivateKey bool      `json:"has_private_key"`
+	Expiry        time.Time `json:"expiry"`
+}
+
+// PresentedCertificate is a certificate an HTTPS endpoint presented in its TLS handshake
+type PresentedCertificate struct {
+	Address  string
+	Position int // 0 for the server certificate, then each certificate of the chain
+	Subject  string
+	Issuer   string
+	DNSNames []string
+	NotAfter time.Time
+}
+
+// EndpointProbe is the outcome of a TLS handshake with an HTTPS endpoint
+type EndpointProbe struct {
+	Address      string
+	Certificates []PresentedCertificate
+	VerifyError  string // why the chain does not verify against the configured CA, if it does not
+	Err          error  // the handshake failed or the address is not HTTPS
+}
+
+// GetNodeCertificates returns the certificates loaded by the node that handles the request, sorted
+// by expiry. The SSL certificates API only reports on that node.
+func (c *Client) GetNodeCertificates() ([]NodeCertificate, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.SSL.Certificates(
+		c.es.SSL.Certificates.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting certificates: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var certs []NodeCertificate
+	if err := json.NewDecoder(res.Body).Decode(&certs); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].Expiry.Before(certs[j].Expiry)
+	})
+
+	return certs, nil
+}
+
+// ProbeEndpointCertificates connects to an HTTPS address and returns the certificates it presents.
+// The chain is fetched even when it does not verify, and then verified against the CA
+// certificate, or the system roots if none is given.
+func ProbeEndpointCertificates(address, caCertPath string, timeout time.Duration) EndpointProbe {
+	probe := EndpointProbe{Address: address}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		probe.Err = fmt.Errorf("invalid address: %w", err)
+		return probe
+	}
+	if u.Scheme != "https" {
+		probe.Err = fmt.Errorf("not an HTTPS address")
+		return probe
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	// Skip verification in the handshake so expired or untrusted chains can still be inspected
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{InsecureSkipVerify: true, ServerName: u.Hostname()})
+	if err != nil {
+		probe.Err = fmt.Errorf("TLS handshake failed: %w", err)
+		return probe
+	}
+	defer conn.Close()
+
+	chain := conn.ConnectionState().PeerCertificates
+	for i, cert := range chain {
+		probe.Certificates = append(probe.Certificates, PresentedCertificate{
+			Address:  address,
+			Position: i,
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			DNSNames: cert.DNSNames,
+			NotAfter: cert.NotAfter,
+		})
+	}
+
+	if len(chain) > 0 {
+		if err := verifyPresentedChain(chain, u.Hostname(), caCertPath); err != nil {
+			probe.VerifyError = err.Error()
+		}
+	}
+
+	return probe
+}
+
+// verifyPresentedChain verifies a presented chain for a host name against the CA certificate, or
+// the system roots if none is given
+func verifyPresentedChain(chain []*x509.Certificate, hostname, caCertPath string) error {
+	tlsConfig, err := tlsConfigFor(caCertPath, false)
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		DNSName:       hostname,
+		Roots:         tlsConfig.RootCAs,
+		Intermediates: intermediates,
+	})
+	return err
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClusterProfile is the configuration of a cluster that is compared between clusters
+type ClusterProfile struct {
+	Versions           map[string]string // node count by Elasticsearch version
+	Plugins            map[string]string // plugin name to version, across all nodes
+	Settings           map[string]string // persistent and transient cluster settings, flattened
+	IndexTemplates     map[string]string // index template name to definition hash
+	ComponentTemplates map[string]string // component template name to definition hash
+	ILMPolicies        map[string]string // ILM policy name to definition hash
+}
+
+// ProfileDifference is one difference between the profiles of two clusters. A value is empty
+// when the item only exists on the other cluster.
+type ProfileDifference struct {
+	Category string
+	Name     string
+	A        string
+	B        string
+}
+
+// GetClusterProfile returns the versions, plugins, cluster settings, templates and ILM policies of
+// the cluster. Templates and policies are reduced to a hash of their definition, so clusters can
+// be compared without printing every definition.
+func (c *Client) GetClusterProfile() (*ClusterProfile, error) {
+	versions, plugins, err := c.getNodeVersionsAndPlugins()
+	if err != nil {
+		return nil, fmt.Errorf("error getting node versions: %w", err)
+	}
+
+	settings, err := c.GetClusterSettings(false)
+	if err != nil {
+		return nil, err
+	}
+	flatSettings := make(map[string]string)
+	for _, scope := range []string{"persistent", "transient"} {
+		for name, value := range settings[scope] {
+			flatSettings[scope+"."+name] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	indexTemplates, err := c.getIndexTemplateDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting index templates: %w", err)
+	}
+	componentTemplates, err := c.getComponentTemplateDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting component templates: %w", err)
+	}
+	policies, err := c.getILMPolicyDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting ILM policies: %w", err)
+	}
+
+	return &ClusterProfile{
+		Versions:           versions,
+		Plugins:            plugins,
+		Settings:           flatSettings,
+		IndexTemplates:     definitionHashes(indexTemplates),
+		ComponentTemplates: definitionHashes(componentTemplates),
+		ILMPolicies:        definitionHashes(policies),
+	}, nil
+}
+
+// getNodeVersionsAndPlugins returns the number of nodes on each version and the plugins installed
+// on any node. A plugin installed at different versions on different nodes lists each version.
+func (c *Client) getNodeVersionsAndPlugins() (map[string]string, map[string]string, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Nodes.Info(
+		c.es.Nodes.Info.WithContext(ctx),
+		c.es.Nodes.Info.WithMetric("plugins"),
+		c.es.Nodes.Info.WithFilterPath("nodes.*.version", "nodes.*.plugins.name", "nodes.*.plugins.version"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]struct {
+			Version string `json:"version"`
+			Plugins []struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"plugins"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	versionCounts := make(map[string]int)
+	pluginVersions := make(map[string]map[string]bool)
+	for _, node := range response.Nodes {
+		versionCounts[node.Version]++
+		for _, plugin := range node.Plugins {
+			if pluginVersions[plugin.Name] == nil {
+				pluginVersions[plugin.Name] = make(map[string]bool)
+			}
+			pluginVersions[plugin.Name][plugin.Version] = true
+		}
+	}
+
+	versions := make(map[string]string, len(versionCounts))
+	for version, count := range versionCounts {
+		versions[version] = fmt.Sprintf("%d nodes", count)
+	}
+	plugins := make(map[string]string, len(pluginVersions))
+	for name, set := range pluginVersions {
+		list := make([]string, 0, len(set))
+		for version := range set {
+			list = append(list, version)
+		}
+		sort.Strings(list)
+		plugins[name] = strings.Join(list, ", ")
+	}
+
+	return versions, plugins, nil
+}
+
+// definitionHashes returns a short hash of each definition. Maps are encoded with sorted keys, so
+// equal definitions hash the same on every cluster.
+func definitionHashes(definitions map[string]interface{}) map[string]string {
+	hashes := make(map[string]string, len(definitions))
+	for name, definition := range definitions {
+		data, err := json.Marshal(definition)
+		if err != nil {
+			hashes[name] = "unknown"
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[name] = hex.EncodeToString(sum[:])[:12]
+	}
+	return hashes
+}
+
+// CompareClusterProfiles returns the differences between two cluster profiles, sorted by
+// category and name
+func CompareClusterProfiles(a, b *ClusterProfile) []ProfileDifference {
+	var diffs []ProfileDifference
+	diffs = append(diffs, compareProfileMaps("version", a.Versions, b.Versions)...)
+	diffs = append(diffs, compareProfileMaps("plugin", a.Plugins, b.Plugins)...)
+	diffs = append(diffs, compareProfileMaps("setting", a.Settings, b.Settings)...)
+	diffs = append(diffs, compareProfileMaps("index template", a.IndexTemplates, b.IndexTemplates)...)
+	diffs = append(diffs, compareProfileMaps("component template", a.ComponentTemplates, b.ComponentTemplates)...)
+	diffs = append(diffs, compareProfileMaps("ilm policy", a.ILMPolicies, b.ILMPolicies)...)
+	return diffs
+}
+
+// compareProfileMaps returns the names whose value differs between a and b, or that only one has
+func compareProfileMaps(category string, a, b map[string]string) []ProfileDifference {
+	names := make(map[string]bool, len(a)+len(b))
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diffs []ProfileDifference
+	for _, name := range sorted {
+		if a[name] != b[name] {
+			diffs = append(diffs, ProfileDifference{Category: category, Name: name, A: a[name], B: b[name]})
+		}
+	}
+	return diffs
+}
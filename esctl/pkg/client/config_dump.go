@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// ConfigObjects is a kind of cluster configuration object, with the definition of each by name
+type ConfigObjects struct {
+	Kind    string // directory name of the kind, e.g. index_templates
+	Objects map[string]interface{}
+}
+
+// GetClusterConfig returns the index templates, component templates, ILM policies and ingest
+// pipelines of the cluster. Fields that change without the definition changing, such as ILM
+// policy versions and modification dates, are left out so dumps can be compared over time.
+// Without includeManaged, objects marked as managed by Elastic in their _meta are skipped.
+func (c *Client) GetClusterConfig(includeManaged bool) ([]ConfigObjects, error) {
+	indexTemplates, err := c.getIndexTemplateDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting index templates: %w", err)
+	}
+	componentTemplates, err := c.getComponentTemplateDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting component templates: %w", err)
+	}
+	policies, err := c.getILMPolicyDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting ILM policies: %w", err)
+	}
+	pipelines, err := c.GetPipelines()
+	if err != nil {
+		return nil, fmt.Errorf("error getting ingest pipelines: %w", err)
+	}
+	pipelineObjects := make(map[string]interface{}, len(pipelines))
+	for name, pipeline := range pipelines {
+		pipelineObjects[name] = pipeline
+	}
+
+	result := []ConfigObjects{
+		{Kind: "index_templates", Objects: indexTemplates},
+		{Kind: "component_templates", Objects: componentTemplates},
+		{Kind: "ilm_policies", Objects: policies},
+		{Kind: "ingest_pipelines", Objects: pipelineObjects},
+	}
+	if !includeManaged {
+		for _, objects := range result {
+			for name, definition := range objects.Objects {
+				if isManagedDefinition(definition) {
+					delete(objects.Objects, name)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// getIndexTemplateDefinitions returns the composable index templates by name
+func (c *Client) getIndexTemplateDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetIndexTemplate(
+		c.es.Indices.GetIndexTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		IndexTemplates []struct {
+			Name          string      `json:"name"`
+			IndexTemplate interface{} `json:"index_template"`
+		} `json:"index_templates"`
+	}
+	if err := decodeConfigResponse(res, &response); err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]interface{}, len(response.IndexTemplates))
+	for _, t := range response.IndexTemplates {
+		templates[t.Name] = t.IndexTemplate
+	}
+	return templates, nil
+}
+
+// getComponentTemplateDefinitions returns the component templates by name
+func (c *Client) getComponentTemplateDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cluster.GetComponentTemplate(
+		c.es.Cluster.GetComponentTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		ComponentTemplates []struct {
+			Name              string      `json:"name"`
+			ComponentTemplate interface{} `json:"component_template"`
+		} `json:"component_templates"`
+	}
+	if err := decodeConfigResponse(res, &response); err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]interface{}, len(response.ComponentTemplates))
+	for _, t := range response.ComponentTemplates {
+		templates[t.Name] = t.ComponentTemplate
+	}
+	return templates, nil
+}
+
+// getILMPolicyDefinitions returns the ILM policies by name, without their version, modification
+// date and the indices using them
+func (c *Client) getILMPolicyDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.ILM.GetLifecycle(
+		c.es.ILM.GetLifecycle.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var response map[string]struct {
+		Policy interface{} `json:"policy"`
+	}
+	if err := decodeConfigResponse(res, &response); err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string]interface{}, len(response))
+	for name, p := range response {
+		policies[name] = p.Policy
+	}
+	return policies, nil
+}
+
+// decodeConfigResponse decodes a configuration API response. A 404 means no objects of the kind
+// exist and leaves the result empty.
+func decodeConfigResponse(res *esapi.Response, result interface{}) error {
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil
+	}
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	return nil
+}
+
+// isManagedDefinition reports whether a definition is marked as managed in its _meta, as the
+// built-in templates, policies and pipelines are
+func isManagedDefinition(definition interface{}) bool {
+	object, ok := definition.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	meta, ok := object["_meta"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	managed, _ := meta["managed"].(bool)
+	return managed
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// IndexDocCount is the number of documents in an index
+type IndexDocCount struct {
+	Index string
+	Docs  int64
+}
+
+// GetDocCounts returns the document count of every index matching a pattern, sorted by index name.
+// Counts are taken from the primaries, so replicas are not counted twice.
+func (c *Client) GetDocCounts(pattern string) ([]IndexDocCount, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexPattern := "*"
+	if pattern != "" {
+		indexPattern = pattern
+	}
+
+	// Execute request
+	res, err := c.es.Cat.Indices(
+		c.es.Cat.Indices.WithContext(ctx),
+		c.es.Cat.Indices.WithIndex(indexPattern),
+		c.es.Cat.Indices.WithFormat("json"),
+		c.es.Cat.Indices.WithH("index,docs.count"),
+		c.es.Cat.Indices.WithPri(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting document counts: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var rows []struct {
+		Index     string `json:"index"`
+		DocsCount string `json:"docs.count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	counts := make([]IndexDocCount, 0, len(rows))
+	for _, row := range rows {
+		// Closed indices have no count
+		docs, err := strconv.ParseInt(row.DocsCount, 10, 64)
+		if err != nil {
+			continue
+		}
+		counts = append(counts, IndexDocCount{Index: row.Index, Docs: docs})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Index < counts[j].Index
+	})
+
+	return counts, nil
+}
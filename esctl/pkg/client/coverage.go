@@ -0,0 +1,334 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Coverage problems of an index or data stream
+const (
+	CoverageNoTemplate = "NO TEMPLATE"
+	CoverageNoILM      = "NO ILM"
+	CoverageNoReplicas = "NO REPLICAS"
+)
+
+// IndexCoverage is how well an index or data stream is managed: the template it was created
+// from, what manages its lifecycle and how many replicas it has
+type IndexCoverage struct {
+	Name      string
+	Type      string // index or data stream
+	Template  string // matching index template, empty when none matches
+	Lifecycle string // ILM policy, or "data stream lifecycle"
+	Replicas  int    // -1 when unknown
+	Problems  []string
+}
+
+// indexTemplatePattern is the index patterns and priority of an index template
+type indexTemplatePattern struct {
+	name     string
+	patterns []string
+	priority int
+}
+
+// indexCoverageSettings are the settings of an index that the coverage report looks at
+type indexCoverageSettings struct {
+	lifecycle string
+	replicas  int
+	snapshot  bool // a mounted searchable snapshot, which has no replicas by design
+}
+
+// GetIndexCoverage reports the indices and data streams matching pattern that match no index
+// template, have no ILM policy or data stream lifecycle, or have no replicas. Backing indices are
+// reported through their data stream. Searchable snapshots are not flagged for having no
+// replicas as the snapshot repository holds their data. Hidden and system indices are only
+// included with includeHidden. When all is set every index and data stream is returned,
+// including those without problems.
+func (c *Client) GetIndexCoverage(pattern string, includeHidden, all bool) ([]IndexCoverage, error) {
+	expandWildcards := "open,closed"
+	if includeHidden {
+		expandWildcards = "all"
+	}
+
+	templates, err := c.getIndexTemplatePatterns()
+	if err != nil {
+		return nil, err
+	}
+	dataStreams, err := c.getCoverageDataStreams(pattern, expandWildcards)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := c.getIndexCoverageSettings(pattern, expandWildcards)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []IndexCoverage
+	backingIndices := make(map[string]bool)
+
+	for _, ds := range dataStreams {
+		coverage := IndexCoverage{Name: ds.Name, Type: "data stream", Template: ds.Template, Replicas: -1}
+		switch {
+		case ds.ILMPolicy != "":
+			coverage.Lifecycle = ds.ILMPolicy
+		case ds.Lifecycle != nil && ds.Lifecycle.Enabled:
+			coverage.Lifecycle = "data stream lifecycle"
+		}
+		for _, index := range ds.Indices {
+			backingIndices[index.IndexName] = true
+		}
+		if len(ds.Indices) > 0 {
+			// The write index shows the replicas the template currently gives
+			if s, ok := settings[ds.Indices[len(ds.Indices)-1].IndexName]; ok {
+				coverage.Replicas = s.replicas
+				if coverage.Lifecycle == "" {
+					coverage.Lifecycle = s.lifecycle
+				}
+			}
+		}
+		result = append(result, coverage)
+	}
+
+	for name, s := range settings {
+		if backingIndices[name] {
+			continue
+		}
+		coverage := IndexCoverage{
+			Name:      name,
+			Type:      "index",
+			Template:  matchIndexTemplate(name, templates),
+			Lifecycle: s.lifecycle,
+			Replicas:  s.replicas,
+		}
+		if s.snapshot && coverage.Replicas == 0 {
+			coverage.Replicas = -1
+		}
+		result = append(result, coverage)
+	}
+
+	filtered := result[:0]
+	for _, coverage := range result {
+		if coverage.Template == "" {
+			coverage.Problems = append(coverage.Problems, CoverageNoTemplate)
+		}
+		if coverage.Lifecycle == "" {
+			coverage.Problems = append(coverage.Problems, CoverageNoILM)
+		}
+		if coverage.Replicas == 0 {
+			coverage.Problems = append(coverage.Problems, CoverageNoReplicas)
+		}
+		if all || len(coverage.Problems) > 0 {
+			filtered = append(filtered, coverage)
+		}
+	}
+
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Name < filtered[j].Name })
+	return filtered, nil
+}
+
+// coverageDataStream is a data stream in the get data stream response
+type coverageDataStream struct {
+	Name      string `json:"name"`
+	Template  string `json:"template"`
+	ILMPolicy string `json:"ilm_policy"`
+	Lifecycle *struct {
+		Enabled bool `json:"enabled"`
+	} `json:"lifecycle"`
+	Indices []struct {
+		IndexName string `json:"index_name"`
+	} `json:"indices"`
+}
+
+// getCoverageDataStreams returns the data streams matching pattern
+func (c *Client) getCoverageDataStreams(pattern, expandWildcards string) ([]coverageDataStream, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetDataStream(
+		c.es.Indices.GetDataStream.WithContext(ctx),
+		c.es.Indices.GetDataStream.WithName(pattern),
+		c.es.Indices.GetDataStream.WithExpandWildcards(expandWildcards),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting data streams: %w", err)
+	}
+	defer res.Body.Close()
+
+	// A pattern without wildcards that names no data stream
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		DataStreams []coverageDataStream `json:"data_streams"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return response.DataStreams, nil
+}
+
+// getIndexCoverageSettings returns the lifecycle, replica and store settings of the indices
+// matching pattern, including the backing indices of matching data streams
+func (c *Client) getIndexCoverageSettings(pattern, expandWildcards string) (map[string]indexCoverageSettings, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex(pattern),
+		c.es.Indices.GetSettings.WithExpandWildcards(expandWildcards),
+		c.es.Indices.GetSettings.WithIgnoreUnavailable(true),
+		c.es.Indices.GetSettings.WithFilterPath(
+			"*.settings.index.lifecycle.name",
+			"*.settings.index.number_of_replicas",
+			"*.settings.index.store.type",
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Settings struct {
+			Index struct {
+				Lifecycle struct {
+					Name string `json:"name"`
+				} `json:"lifecycle"`
+				NumberOfReplicas string `json:"number_of_replicas"`
+				Store            struct {
+					Type string `json:"type"`
+				} `json:"store"`
+			} `json:"index"`
+		} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	settings := make(map[string]indexCoverageSettings, len(response))
+	for name, index := range response {
+		replicas, err := strconv.Atoi(index.Settings.Index.NumberOfReplicas)
+		if err != nil {
+			replicas = -1
+		}
+		settings[name] = indexCoverageSettings{
+			lifecycle: index.Settings.Index.Lifecycle.Name,
+			replicas:  replicas,
+			snapshot:  index.Settings.Index.Store.Type == "snapshot",
+		}
+	}
+	return settings, nil
+}
+
+// getIndexTemplatePatterns returns the index patterns of the composable and legacy index
+// templates. Legacy templates are given priority -1 so that composable templates take
+// precedence, as they do when Elasticsearch creates an index.
+func (c *Client) getIndexTemplatePatterns() ([]indexTemplatePattern, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetIndexTemplate(
+		c.es.Indices.GetIndexTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index templates: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var indexTemplates struct {
+		IndexTemplates []struct {
+			Name          string `json:"name"`
+			IndexTemplate struct {
+				IndexPatterns []string `json:"index_patterns"`
+				Priority      int      `json:"priority"`
+			} `json:"index_template"`
+		} `json:"index_templates"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&indexTemplates); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var templates []indexTemplatePattern
+	for _, tmpl := range indexTemplates.IndexTemplates {
+		templates = append(templates, indexTemplatePattern{
+			name:     tmpl.Name,
+			patterns: tmpl.IndexTemplate.IndexPatterns,
+			priority: tmpl.IndexTemplate.Priority,
+		})
+	}
+
+	// Legacy templates
+	legacyRes, err := c.es.Indices.GetTemplate(
+		c.es.Indices.GetTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting legacy index templates: %w", err)
+	}
+	defer legacyRes.Body.Close()
+
+	if legacyRes.IsError() {
+		return nil, newResponseError(legacyRes)
+	}
+
+	var legacyTemplates map[string]struct {
+		IndexPatterns []string `json:"index_patterns"`
+	}
+	if err := json.NewDecoder(legacyRes.Body).Decode(&legacyTemplates); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	for name, tmpl := range legacyTemplates {
+		templates = append(templates, indexTemplatePattern{
+			name:     name + " (legacy)",
+			patterns: tmpl.IndexPatterns,
+			priority: -1,
+		})
+	}
+
+	return templates, nil
+}
+
+// matchIndexTemplate returns the name of the highest priority template with a pattern matching
+// the index, or "" if none does
+func matchIndexTemplate(index string, templates []indexTemplatePattern) string {
+	best := ""
+	bestPriority := 0
+	for _, tmpl := range templates {
+		for _, pattern := range tmpl.patterns {
+			if !wildcardMatch(pattern, index) {
+				continue
+			}
+			if best == "" || tmpl.priority > bestPriority || (tmpl.priority == bestPriority && tmpl.name < best) {
+				best, bestPriority = tmpl.name, tmpl.priority
+			}
+			break
+		}
+	}
+	return best
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of data view fields managed by ApplyDataViewFields
+const (
+	DataViewRuntimeField = "runtime field"
+	DataViewFieldFormat  = "format"
+)
+
+// DataView is a Kibana data view, with the runtime fields and field formats it defines
+type DataView struct {
+	ID              string                  `json:"id"`
+	Title           string                  `json:"title"`
+	Name            string                  `json:"name,omitempty"`
+	RuntimeFieldMap map[string]RuntimeField `json:"runtimeFieldMap,omitempty"`
+	FieldFormats    map[string]FieldFormat  `json:"fieldFormats,omitempty"`
+}
+
+// RuntimeField is a runtime field of a data view
+type RuntimeField struct {
+	Type   string              `json:"type" yaml:"type"`
+	Script *RuntimeFieldScript `json:"script,omitempty" yaml:"script,omitempty"`
+}
+
+// RuntimeFieldScript is the Painless script that emits the value of a runtime field
+type RuntimeFieldScript struct {
+	Source string `json:"source" yaml:"source"`
+}
+
+// UnmarshalYAML accepts a script given as a plain string as well as with a source key
+func (s *RuntimeFieldScript) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Source)
+	}
+	var script struct {
+		Source string `yaml:"source"`
+	}
+	if err := node.Decode(&script); err != nil {
+		return err
+	}
+	s.Source = script.Source
+	return nil
+}
+
+// FieldFormat is the formatter Kibana displays a field with, such as bytes, url or number
+type FieldFormat struct {
+	ID     string                 `json:"id" yaml:"id"`
+	Params map[string]interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// DataViewFieldSpec is the runtime fields and field formats a data view should have
+type DataViewFieldSpec struct {
+	DataView      string                  `yaml:"data_view"` // ID, title or name of the data view
+	RuntimeFields map[string]RuntimeField `yaml:"runtime_fields"`
+	FieldFormats  map[string]FieldFormat  `yaml:"field_formats"`
+}
+
+// DataViewFieldChange is the change of one runtime field or field format of a data view
+type DataViewFieldChange struct {
+	DataView string // title of the data view
+	Kind     string // DataViewRuntimeField or DataViewFieldFormat
+	Field    string
+	Action   string // create, update or unchanged
+	Err      error
+}
+
+// ListDataViews returns the data views of a space, without their fields
+func (c *KibanaClient) ListDataViews(space string) ([]DataView, error) {
+	var response struct {
+		DataViews []DataView `json:"data_view"`
+	}
+	if err := c.kibanaSpaceRequest("GET", space, "/api/data_views", nil, &response); err != nil {
+		return nil, fmt.Errorf("error listing data views: %w", err)
+	}
+	sort.Slice(response.DataViews, func(i, j int) bool {
+		return response.DataViews[i].Title < response.DataViews[j].Title
+	})
+	return response.DataViews, nil
+}
+
+// GetDataView returns a data view of a space by ID
+func (c *KibanaClient) GetDataView(space, id string) (*DataView, error) {
+	var response struct {
+		DataView DataView `json:"data_view"`
+	}
+	if err := c.kibanaSpaceRequest("GET", space, "/api/data_views/data_view/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, fmt.Errorf("error getting data view %s: %w", id, err)
+	}
+	return &response.DataView, nil
+}
+
+// FindDataView returns the data view of a space with the given ID, or else the given title or
+// name. The same data view usually has a different ID in each space, so a title lets one spec
+// apply to all of them.
+func (c *KibanaClient) FindDataView(space, ref string) (*DataView, error) {
+	views, err := c.ListDataViews(space)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []DataView
+	for _, view := range views {
+		if view.ID == ref {
+			return c.GetDataView(space, view.ID)
+		}
+		if view.Title == ref || view.Name == ref {
+			matches = append(matches, view)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("data view %s not found", ref)
+	case 1:
+		return c.GetDataView(space, matches[0].ID)
+	default:
+		return nil, fmt.Errorf("%d data views match %s, give the ID instead", len(matches), ref)
+	}
+}
+
+// PutRuntimeField creates a runtime field on a data view, or replaces it if it exists
+func (c *KibanaClient) PutRuntimeField(space, id, name string, field RuntimeField) error {
+	body := map[string]interface{}{
+		"name":         name,
+		"runtimeField": field,
+	}
+	path := "/api/data_views/data_view/" + url.PathEscape(id) + "/runtime_field"
+	if err := c.kibanaSpaceRequest("PUT", space, path, body, nil); err != nil {
+		return fmt.Errorf("error putting runtime field %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteRuntimeField removes a runtime field from a data view
+func (c *KibanaClient) DeleteRuntimeField(space, id, name string) error {
+	path := "/api/data_views/data_view/" + url.PathEscape(id) + "/runtime_field/" + url.PathEscape(name)
+	if err := c.kibanaSpaceRequest("DELETE", space, path, nil, nil); err != nil {
+		return fmt.Errorf("error deleting runtime field %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetFieldFormat sets the formatter of a field of a data view, or resets the field to the default
+// formatter when format is nil
+func (c *KibanaClient) SetFieldFormat(space, id, name string, format *FieldFormat) error {
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{
+			name: map[string]interface{}{"format": format},
+		},
+	}
+	path := "/api/data_views/data_view/" + url.PathEscape(id) + "/fields"
+	if err := c.kibanaSpaceRequest("POST", space, path, body, nil); err != nil {
+		return fmt.Errorf("error setting format of %s: %w", name, err)
+	}
+	return nil
+}
+
+// ApplyDataViewFields makes the runtime fields and field formats of a data view in a space match a
+// spec. Fields the spec does not name are left alone. Each change is attempted even when an
+// earlier one failed, and its error is kept in the change. With dryRun nothing is changed.
+func (c *KibanaClient) ApplyDataViewFields(space string, spec DataViewFieldSpec, dryRun bool) ([]DataViewFieldChange, error) {
+	view, err := c.FindDataView(space, spec.DataView)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]DataViewFieldChange, 0, len(spec.RuntimeFields)+len(spec.FieldFormats))
+	for _, name := range sortedFieldNames(spec.RuntimeFields) {
+		field := spec.RuntimeFields[name]
+		current, exists := view.RuntimeFieldMap[name]
+		change := DataViewFieldChange{DataView: view.Title, Kind: DataViewRuntimeField, Field: name, Action: fieldAction(exists, current, field)}
+		if change.Action != "unchanged" && !dryRun {
+			change.Err = c.PutRuntimeField(space, view.ID, name, field)
+		}
+		changes = append(changes, change)
+	}
+	for _, name := range sortedFieldNames(spec.FieldFormats) {
+		format := spec.FieldFormats[name]
+		current, exists := view.FieldFormats[name]
+		change := DataViewFieldChange{DataView: view.Title, Kind: DataViewFieldFormat, Field: name, Action: fieldAction(exists, current, format)}
+		if change.Action != "unchanged" && !dryRun {
+			change.Err = c.SetFieldFormat(space, view.ID, name, &format)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// fieldAction returns how a field changes from its current definition to the wanted one. The
+// definitions are compared as JSON, so numbers read from YAML equal those decoded from Kibana.
+func fieldAction(exists bool, current, wanted interface{}) string {
+	if !exists {
+		return "create"
+	}
+	a, errA := json.Marshal(current)
+	b, errB := json.Marshal(wanted)
+	if errA == nil && errB == nil && string(a) == string(b) {
+		return "unchanged"
+	}
+	return "update"
+}
+
+// sortedFieldNames returns the field names of a spec in sorted order
+func sortedFieldNames[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
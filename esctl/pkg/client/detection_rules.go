@@ -0,0 +1,293 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// detectionRulesPageSize is the number of rules requested per page from the detection rules find API
+const detectionRulesPageSize = 100
+
+// DetectionRule is a Security detection rule with the summary of its last execution
+type DetectionRule struct {
+	ID        string   `json:"id"`
+	RuleID    string   `json:"rule_id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Severity  string   `json:"severity"`
+	Tags      []string `json:"tags"`
+	Enabled   bool     `json:"enabled"`
+	Immutable bool     `json:"immutable"`
+
+	ExecutionSummary struct {
+		LastExecution struct {
+			Date    string `json:"date"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"last_execution"`
+	} `json:"execution_summary"`
+}
+
+// Failed reports whether the rule's last execution failed or partly failed
+func (r *DetectionRule) Failed() bool {
+	switch r.ExecutionSummary.LastExecution.Status {
+	case "failed", "partial failure":
+		return true
+	}
+	return false
+}
+
+// DetectionRuleImportResult is the outcome of a detection rule import
+type DetectionRuleImportResult struct {
+	Success      bool
+	SuccessCount int
+	Errors       []DetectionRuleImportError
+}
+
+// DetectionRuleImportError is a rule that could not be imported
+type DetectionRuleImportError struct {
+	RuleID     string
+	StatusCode int
+	Message    string
+}
+
+// DetectionRuleBulkResult is the outcome of a detection rules bulk action
+type DetectionRuleBulkResult struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Errors    []string
+}
+
+// detectionRuleTagsKuery builds a filter selecting rules that carry any of the tags
+func detectionRuleTagsKuery(tags []string) string {
+	var clauses []string
+	for _, tag := range tags {
+		clauses = append(clauses, "alert.attributes.tags:"+strconv.Quote(tag))
+	}
+	return strings.Join(clauses, " or ")
+}
+
+// FindDetectionRules returns every detection rule carrying any of the tags, or every rule if no
+// tags are given, following pages until all are read
+func (c *KibanaClient) FindDetectionRules(tags []string) ([]DetectionRule, error) {
+	params := url.Values{}
+	params.Set("per_page", strconv.Itoa(detectionRulesPageSize))
+	params.Set("sort_field", "name")
+	if len(tags) > 0 {
+		params.Set("filter", detectionRuleTagsKuery(tags))
+	}
+
+	var rules []DetectionRule
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var response struct {
+			Page    int             `json:"page"`
+			PerPage int             `json:"perPage"`
+			Total   int             `json:"total"`
+			Data    []DetectionRule `json:"data"`
+		}
+		if err := c.kibanaJSONRequest("GET", "/api/detection_engine/rules/_find?"+params.Encode(), nil, &response); err != nil {
+			return nil, err
+		}
+		rules = append(rules, response.Data...)
+
+		if len(response.Data) < detectionRulesPageSize || len(rules) >= response.Total {
+			return rules, nil
+		}
+	}
+}
+
+// ExportDetectionRules exports the given rules, by rule_id, or every rule if none are given, as
+// NDJSON followed by an export details line
+func (c *KibanaClient) ExportDetectionRules(ruleIDs []string) ([]byte, error) {
+	requestURL := fmt.Sprintf("%s/api/detection_engine/rules/_export?exclude_export_details=false", c.baseURL)
+
+	var body io.Reader = http.NoBody
+	if len(ruleIDs) > 0 {
+		objects := make([]map[string]string, 0, len(ruleIDs))
+		for _, id := range ruleIDs {
+			objects = append(objects, map[string]string{"rule_id": id})
+		}
+		bodyBytes, err := json.Marshal(map[string]interface{}{"objects": objects})
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling request body: %w", err)
+		}
+		body = bytes.NewBuffer(bodyBytes)
+	}
+
+	// Create the request
+	req, err := http.NewRequest("POST", requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	return data, nil
+}
+
+// ImportDetectionRules imports rules from an NDJSON export. With overwrite, rules with the same
+// rule_id are replaced, as are the exception lists and connectors exported with them.
+func (c *KibanaClient) ImportDetectionRules(ndjson []byte, overwrite bool) (*DetectionRuleImportResult, error) {
+	params := url.Values{}
+	if overwrite {
+		params.Set("overwrite", "true")
+		params.Set("overwrite_exceptions", "true")
+		params.Set("overwrite_action_connectors", "true")
+	}
+	requestURL := fmt.Sprintf("%s/api/detection_engine/rules/_import", c.baseURL)
+	if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+
+	// The import API takes the export as a multipart file upload
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "rules.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("error creating request body: %w", err)
+	}
+	if _, err := part.Write(ndjson); err != nil {
+		return nil, fmt.Errorf("error creating request body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error creating request body: %w", err)
+	}
+
+	// Create the request
+	req, err := http.NewRequest("POST", requestURL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse the response
+	var response struct {
+		Success      bool `json:"success"`
+		SuccessCount int  `json:"success_count"`
+		Errors       []struct {
+			RuleID string `json:"rule_id"`
+			ID     string `json:"id"`
+			Error  struct {
+				StatusCode int    `json:"status_code"`
+				Message    string `json:"message"`
+			} `json:"error"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	result := &DetectionRuleImportResult{Success: response.Success, SuccessCount: response.SuccessCount}
+	for _, e := range response.Errors {
+		ruleID := e.RuleID
+		if ruleID == "" {
+			ruleID = e.ID
+		}
+		result.Errors = append(result.Errors, DetectionRuleImportError{RuleID: ruleID, StatusCode: e.Error.StatusCode, Message: e.Error.Message})
+	}
+
+	return result, nil
+}
+
+// BulkDetectionRuleAction enables or disables every rule carrying any of the tags. With dryRun,
+// Kibana reports what the action would do without changing any rule.
+func (c *KibanaClient) BulkDetectionRuleAction(action string, tags []string, dryRun bool) (*DetectionRuleBulkResult, error) {
+	if action != "enable" && action != "disable" {
+		return nil, fmt.Errorf("invalid bulk action '%s', must be enable or disable", action)
+	}
+
+	path := "/api/detection_engine/rules/_bulk_action"
+	if dryRun {
+		path += "?dry_run=true"
+	}
+	body := map[string]interface{}{
+		"action": action,
+		"query":  detectionRuleTagsKuery(tags),
+	}
+
+	var response struct {
+		Attributes struct {
+			Summary struct {
+				Total     int `json:"total"`
+				Succeeded int `json:"succeeded"`
+				Failed    int `json:"failed"`
+				Skipped   int `json:"skipped"`
+			} `json:"summary"`
+			Errors []struct {
+				Message string `json:"message"`
+				Rules   []struct {
+					Name string `json:"name"`
+				} `json:"rules"`
+			} `json:"errors"`
+		} `json:"attributes"`
+	}
+	if err := c.kibanaJSONRequest("POST", path, body, &response); err != nil {
+		return nil, err
+	}
+
+	summary := response.Attributes.Summary
+	result := &DetectionRuleBulkResult{Total: summary.Total, Succeeded: summary.Succeeded, Failed: summary.Failed}
+	for _, e := range response.Attributes.Errors {
+		var names []string
+		for _, r := range e.Rules {
+			names = append(names, r.Name)
+		}
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", e.Message, strings.Join(names, ", ")))
+	}
+
+	return result, nil
+}
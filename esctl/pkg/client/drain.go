@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -34,7 +35,7 @@ func (c *Client) GetClusterExcludeSettings() (*ClusterExcludeSettings, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -63,6 +64,126 @@ func (c *Client) GetClusterExcludeSettings() (*ClusterExcludeSettings, error) {
 	return excludeSettings, nil
 }
 
+// NodeHeadroom represents how much data a node can still take before reaching the high watermark
+type NodeHeadroom struct {
+	Name      string
+	DiskTotal int64
+	DiskUsed  int64
+	Headroom  int64
+}
+
+// DrainHeadroom represents whether the remaining data nodes can absorb the shards of a drained node
+type DrainHeadroom struct {
+	NodeName      string
+	BytesToMove   int64
+	ShardsToMove  int
+	HighWatermark string
+	Headroom      int64
+	Nodes         []NodeHeadroom
+}
+
+// Sufficient reports whether the remaining nodes have enough headroom for the drained data
+func (h *DrainHeadroom) Sufficient() bool {
+	return h.Headroom >= h.BytesToMove
+}
+
+// CheckDrainHeadroom computes whether the nodes left after draining nodeName have enough free
+// space below the high disk watermark to absorb its shards. Nodes already excluded by name are
+// not counted as receivers.
+func (c *Client) CheckDrainHeadroom(nodeName string) (*DrainHeadroom, error) {
+	allocations, err := c.GetDiskAllocations()
+	if err != nil {
+		return nil, err
+	}
+
+	excludeSettings, err := c.GetClusterExcludeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(excludeSettings.ExcludeName))
+	for _, name := range excludeSettings.ExcludeName {
+		excluded[name] = true
+	}
+
+	// Find the high watermark, falling back to the Elasticsearch default
+	watermark := "90%"
+	if value, _, err := c.GetSettingValue("cluster.routing.allocation.disk.watermark.high", true); err == nil {
+		if str, ok := value.(string); ok && str != "" {
+			watermark = str
+		}
+	}
+
+	headroom := &DrainHeadroom{
+		NodeName:      nodeName,
+		HighWatermark: watermark,
+	}
+
+	found := false
+	for _, alloc := range allocations {
+		if alloc.Node == nodeName {
+			found = true
+			headroom.BytesToMove = alloc.DiskIndices
+			headroom.ShardsToMove = alloc.Shards
+			continue
+		}
+
+		if excluded[alloc.Node] {
+			continue
+		}
+
+		limit, err := watermarkLimit(alloc.DiskTotal, watermark)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeHeadroom := limit - alloc.DiskUsed
+		if nodeHeadroom < 0 {
+			nodeHeadroom = 0
+		}
+
+		headroom.Nodes = append(headroom.Nodes, NodeHeadroom{
+			Name:      alloc.Node,
+			DiskTotal: alloc.DiskTotal,
+			DiskUsed:  alloc.DiskUsed,
+			Headroom:  nodeHeadroom,
+		})
+		headroom.Headroom += nodeHeadroom
+	}
+
+	if !found {
+		return nil, fmt.Errorf("node %s not found in allocation stats", nodeName)
+	}
+
+	return headroom, nil
+}
+
+// watermarkLimit converts a disk watermark setting into the maximum used bytes allowed on a disk
+// Watermarks can be a percentage ("90%"), a ratio ("0.9") or an absolute free space value ("50gb").
+// Only bare numbers up to 1 are ratios, larger ones are free space in bytes.
+func watermarkLimit(diskTotal int64, watermark string) (int64, error) {
+	value := strings.TrimSpace(watermark)
+
+	if strings.HasSuffix(value, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid watermark %q: %w", watermark, err)
+		}
+		return int64(float64(diskTotal) * pct / 100), nil
+	}
+
+	if ratio, err := strconv.ParseFloat(value, 64); err == nil && ratio <= 1 {
+		return int64(float64(diskTotal) * ratio), nil
+	}
+
+	minFree, err := ParseByteSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid watermark %q: %w", watermark, err)
+	}
+
+	return diskTotal - minFree, nil
+}
+
 // DrainServer adds a node to the cluster allocation exclude list
 func (c *Client) DrainServer(nodeName string) ([]string, error) {
 	// Get current exclude settings
@@ -108,7 +229,7 @@ func (c *Client) DrainServer(nodeName string) ([]string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	return newExcludeList, nil
@@ -164,7 +285,7 @@ func (c *Client) StopDrainServer(nodeName string) ([]string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	return newExcludeList, nil
@@ -206,7 +327,7 @@ func (c *Client) FillAll() (*ClusterExcludeSettings, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Get the updated settings
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// unixSocketHost is the placeholder host name given to unix socket addresses, numbered per socket
+const unixSocketHost = "unix-socket-%d"
+
+// configureEndpoints makes the transport reach addresses that plain HTTP cannot: unix:// socket
+// paths, which are rewritten to placeholder HTTP addresses, and addresses only reachable through
+// an SSH jump host, given as ssh://[user@]host[:port]. It returns the addresses to give the client.
+func configureEndpoints(transport *http.Transport, addresses []string, sshTunnel string) ([]string, error) {
+	sockets := make(map[string]string)
+	rewritten := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if !strings.HasPrefix(address, "unix://") {
+			rewritten = append(rewritten, address)
+			continue
+		}
+		path := strings.TrimPrefix(address, "unix://")
+		if path == "" {
+			return nil, fmt.Errorf("invalid address %q: no socket path", address)
+		}
+		host := fmt.Sprintf(unixSocketHost, len(sockets))
+		sockets[host] = path
+		rewritten = append(rewritten, "http://"+host)
+	}
+
+	var jumpHost *url.URL
+	if sshTunnel != "" {
+		u, err := url.Parse(sshTunnel)
+		if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+			return nil, fmt.Errorf("invalid SSH tunnel %q, expected ssh://[user@]host[:port]", sshTunnel)
+		}
+		jumpHost = u
+	}
+
+	if len(sockets) == 0 && jumpHost == nil {
+		return addresses, nil
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, _ := net.SplitHostPort(addr)
+		if path, ok := sockets[host]; ok {
+			return dial(ctx, "unix", path)
+		}
+		if jumpHost != nil {
+			return dialSSH(ctx, jumpHost, addr)
+		}
+		return dial(ctx, network, addr)
+	}
+
+	return rewritten, nil
+}
+
+// dialSSH opens a connection to addr through an SSH jump host, by running ssh -W and using its
+// standard input and output as the connection. The ssh client reads the user's SSH configuration
+// and agent, and exits when the connection is closed or this process ends.
+func dialSSH(ctx context.Context, jumpHost *url.URL, addr string) (net.Conn, error) {
+	args := []string{"-W", addr, "-o", "BatchMode=yes"}
+	if port := jumpHost.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	destination := jumpHost.Hostname()
+	if jumpHost.User != nil {
+		destination = jumpHost.User.Username() + "@" + destination
+	}
+	args = append(args, destination)
+
+	// The command must outlive the dial context, it carries the connection
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error starting SSH tunnel: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error starting SSH tunnel: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting SSH tunnel through %s: %w", jumpHost.Host, err)
+	}
+
+	conn := &sshConn{cmd: cmd, stdin: stdin, stdout: stdout, addr: addr}
+	if err := ctx.Err(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// sshConn is a connection carried over the standard input and output of an ssh -W process
+type sshConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	addr   string
+}
+
+// Read implements net.Conn
+func (c *sshConn) Read(b []byte) (int, error) {
+	return c.stdout.Read(b)
+}
+
+// Write implements net.Conn
+func (c *sshConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+// Close implements net.Conn, stopping the ssh process
+func (c *sshConn) Close() error {
+	c.stdin.Close()
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+	return nil
+}
+
+// LocalAddr implements net.Conn
+func (c *sshConn) LocalAddr() net.Addr {
+	return sshAddr("ssh")
+}
+
+// RemoteAddr implements net.Conn
+func (c *sshConn) RemoteAddr() net.Addr {
+	return sshAddr(c.addr)
+}
+
+// SetDeadline implements net.Conn. Deadlines are not supported, requests are bounded by their
+// context instead.
+func (c *sshConn) SetDeadline(t time.Time) error { return nil }
+
+// SetReadDeadline implements net.Conn
+func (c *sshConn) SetReadDeadline(t time.Time) error { return nil }
+
+// SetWriteDeadline implements net.Conn
+func (c *sshConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// sshAddr is the address of one end of an sshConn
+type sshAddr string
+
+// Network implements net.Addr
+func (a sshAddr) Network() string { return "ssh" }
+
+// String implements net.Addr
+func (a sshAddr) String() string { return string(a) }
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// Error classes returned by ErrorClass, for scripts that branch on the kind of failure
+const (
+	ErrorClassBadRequest   = "bad_request"
+	ErrorClassUnauthorized = "unauthorized"
+	ErrorClassForbidden    = "forbidden"
+	ErrorClassNotFound     = "not_found"
+	ErrorClassConflict     = "conflict"
+	ErrorClassRateLimited  = "rate_limited"
+	ErrorClassServer       = "server_error"
+	ErrorClassUnavailable  = "unavailable"
+	ErrorClassOther        = "error"
+)
+
+// exitCodes are the process exit codes for each error class. 1 is left for errors that did not
+// come from an API response, such as invalid flags or connection failures.
+var exitCodes = map[string]int{
+	ErrorClassBadRequest:   10,
+	ErrorClassUnauthorized: 11,
+	ErrorClassForbidden:    12,
+	ErrorClassNotFound:     13,
+	ErrorClassConflict:     14,
+	ErrorClassRateLimited:  15,
+	ErrorClassServer:       16,
+	ErrorClassUnavailable:  17,
+	ErrorClassOther:        1,
+}
+
+// ErrorCause is one cause reported by Elasticsearch for a failed request
+type ErrorCause struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// APIError is an error response from Elasticsearch or Kibana, with the reason given by the cluster
+type APIError struct {
+	StatusCode int
+	Type       string       // Elasticsearch error type, e.g. index_not_found_exception, or the Kibana error name
+	Reason     string       // reason or message given in the response
+	RootCauses []ErrorCause // Elasticsearch root causes, when different from the top level error
+	Body       string       // raw response body, when it could not be parsed
+}
+
+// Error implements the error interface for APIError
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "error response (status %d", e.StatusCode)
+	if e.Type != "" {
+		fmt.Fprintf(&b, ", %s", e.Type)
+	}
+	b.WriteString(")")
+
+	switch {
+	case e.Reason != "":
+		fmt.Fprintf(&b, ": %s", e.Reason)
+	case e.Body != "":
+		fmt.Fprintf(&b, ": %s", e.Body)
+	default:
+		fmt.Fprintf(&b, ": %s", http.StatusText(e.StatusCode))
+	}
+
+	for _, cause := range e.RootCauses {
+		if cause.Type == e.Type && cause.Reason == e.Reason {
+			continue
+		}
+		fmt.Fprintf(&b, "; caused by %s: %s", cause.Type, cause.Reason)
+	}
+	return b.String()
+}
+
+// Class returns the error class for the response status
+func (e *APIError) Class() string {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrorClassBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrorClassUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrorClassForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrorClassNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrorClassConflict
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == http.StatusGatewayTimeout:
+		return ErrorClassUnavailable
+	case e.StatusCode >= 500:
+		return ErrorClassServer
+	default:
+		return ErrorClassOther
+	}
+}
+
+// ErrorClass returns the class of an API error anywhere in the error chain, or "" if the error
+// did not come from an API response
+func ErrorClass(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class()
+	}
+	return ""
+}
+
+// IsNotFound reports whether the error is an API response with status 404
+func IsNotFound(err error) bool {
+	return ErrorClass(err) == ErrorClassNotFound
+}
+
+// ExitCode returns the process exit code for an error: a code per error class for API errors,
+// 1 for anything else
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code, ok := exitCodes[ErrorClass(err)]; ok {
+		return code
+	}
+	return 1
+}
+
+// newResponseError builds an APIError from an Elasticsearch error response
+func newResponseError(res *esapi.Response) error {
+	return parseErrorBody(res.StatusCode, res.Body)
+}
+
+// newHTTPError builds an APIError from a Kibana or Fleet error response
+func newHTTPError(resp *http.Response) error {
+	return parseErrorBody(resp.StatusCode, resp.Body)
+}
+
+// parseErrorBody reads an error response body in either the Elasticsearch or the Kibana format
+func parseErrorBody(statusCode int, body io.Reader) error {
+	apiErr := &APIError{StatusCode: statusCode}
+	if body == nil {
+		return apiErr
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil || len(data) == 0 {
+		return apiErr
+	}
+
+	// Elasticsearch: {"error": {"type": ..., "reason": ..., "root_cause": [...]}, "status": ...}
+	// Elasticsearch also uses {"error": "message"} for some APIs.
+	// Kibana: {"statusCode": ..., "error": "Bad Request", "message": ...}
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		apiErr.Body = strings.TrimSpace(string(data))
+		return apiErr
+	}
+
+	var esError struct {
+		ErrorCause
+		RootCause []ErrorCause `json:"root_cause"`
+	}
+	var errorName string
+	switch {
+	case json.Unmarshal(parsed.Error, &esError) == nil && esError.Type != "":
+		apiErr.Type = esError.Type
+		apiErr.Reason = esError.Reason
+		apiErr.RootCauses = esError.RootCause
+	case json.Unmarshal(parsed.Error, &errorName) == nil:
+		if parsed.Message != "" {
+			apiErr.Type = errorName
+			apiErr.Reason = parsed.Message
+		} else {
+			apiErr.Reason = errorName
+		}
+	case parsed.Message != "":
+		apiErr.Reason = parsed.Message
+	default:
+		apiErr.Body = strings.TrimSpace(string(data))
+	}
+
+	return apiErr
+}
@@ -2,12 +2,8 @@ package client
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v9"
@@ -17,7 +13,8 @@ import (
 
 // Client wraps the Elasticsearch client with custom methods
 type Client struct {
-	es *elasticsearch.Client
+	es    *elasticsearch.Client
+	cache *lookupCache
 }
 
 // New creates a new Elasticsearch client
@@ -28,35 +25,53 @@ func New(cfg *config.Config) (*Client, error) {
 		Password:  cfg.Elasticsearch.Password,
 	}
 
-	// Configure TLS options
-	// Create a custom transport for TLS configuration
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{},
+	// Configure TLS, compression and connection reuse
+	transport, err := newHTTPTransport(cfg.Elasticsearch.Transport, cfg.Elasticsearch.CACert, cfg.Elasticsearch.Insecure)
+	if err != nil {
+		return nil, err
 	}
 
-	// If insecure mode is enabled, skip certificate verification
-	if cfg.Elasticsearch.Insecure {
-		transport.TLSClientConfig.InsecureSkipVerify = true
+	// Reach unix sockets and addresses behind an SSH jump host
+	endpoints, err := configureEndpoints(transport, cfg.Elasticsearch.Addresses, cfg.Elasticsearch.SSHTunnel)
+	if err != nil {
+		return nil, err
 	}
 
-	// If CA cert is provided, use it for verification (unless insecure mode is enabled)
-	if cfg.Elasticsearch.CACert != "" && !cfg.Elasticsearch.Insecure {
-		caCert, err := ioutil.ReadFile(cfg.Elasticsearch.CACert)
-		if err != nil {
-			return nil, fmt.Errorf("reading CA cert: %w", err)
-		}
+	esCfg.Transport = transport
+	if cfg.Output.Timings {
+		esCfg.Transport = newTimingTransport(esCfg.Transport)
+	}
+	if cfg.Elasticsearch.Verbose {
+		esCfg.Transport = &verboseTransport{next: esCfg.Transport}
+	}
+	esCfg.CompressRequestBody = cfg.Elasticsearch.Transport.CompressRequests
+	esCfg.DisableRetry = cfg.Elasticsearch.DisableRetry
 
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM(caCert) {
-			return nil, fmt.Errorf("failed to parse CA certificate")
-		}
+	// Back off when the cluster rejects requests with 429
+	if !cfg.Elasticsearch.DisableRetry && cfg.Elasticsearch.Transport.Max429Retries > 0 {
+		esCfg.Transport = &retryTransport{next: esCfg.Transport, maxRetries: cfg.Elasticsearch.Transport.Max429Retries}
+	}
+
+	// Leave out addresses that do not answer, the client only fails over after a request times out
+	esCfg.Addresses = healthyAddresses(endpoints, transport, cfg.Elasticsearch.Verbose)
 
-		transport.TLSClientConfig.RootCAs = caCertPool
+	cache, err := newLookupCache(cfg.Cache, cfg.Elasticsearch.Addresses, cfg.Elasticsearch.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve node info, template and pipeline requests from the cache shared by the process
+	if cfg.Cache.Responses {
+		responses, err := sharedResponseCache(cfg.Cache, cfg.Elasticsearch.Addresses, cfg.Elasticsearch.Username)
+		if err != nil {
+			return nil, err
+		}
+		esCfg.Transport = &responseCacheTransport{next: esCfg.Transport, cache: responses}
 	}
 
-	// Set the transport if we've configured TLS options
-	if cfg.Elasticsearch.Insecure || cfg.Elasticsearch.CACert != "" {
-		esCfg.Transport = transport
+	// Keep index requests to the tenant's indices on shared clusters
+	if cfg.Elasticsearch.TenantPrefix != "" && !cfg.Elasticsearch.CrossTenant {
+		esCfg.Transport = &tenantTransport{next: esCfg.Transport, prefix: cfg.Elasticsearch.TenantPrefix}
 	}
 
 	es, err := elasticsearch.NewClient(esCfg)
@@ -64,7 +79,7 @@ func New(cfg *config.Config) (*Client, error) {
 		return nil, fmt.Errorf("error creating client: %w", err)
 	}
 
-	return &Client{es: es}, nil
+	return &Client{es: es, cache: cache}, nil
 }
 
 // Ping checks if the cluster is up
@@ -76,7 +91,7 @@ func (c *Client) Ping() (map[string]interface{}, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	var r map[string]interface{}
@@ -104,7 +119,7 @@ func (c *Client) CatHealth() ([][]string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	var health []struct {
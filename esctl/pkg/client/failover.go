@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// addressCheckTimeout is how long an address has to answer before it is treated as down
+const addressCheckTimeout = 2 * time.Second
+
+// AddressHealth is the outcome of checking whether an Elasticsearch address answers
+type AddressHealth struct {
+	Address string
+	Latency time.Duration
+	Err     error // nil if the address answered, whatever the status code
+}
+
+// CheckAddresses sends a request to every address at once and reports which answer. Any HTTP
+// response counts, an authentication failure still shows the node is up.
+func CheckAddresses(addresses []string, transport http.RoundTripper, timeout time.Duration) []AddressHealth {
+	results := make([]AddressHealth, len(addresses))
+	var wg sync.WaitGroup
+
+	for i, address := range addresses {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			results[i] = checkAddress(address, transport, timeout)
+		}(i, address)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// checkAddress sends a HEAD request to the root of an address
+func checkAddress(address string, transport http.RoundTripper, timeout time.Duration) AddressHealth {
+	health := AddressHealth{Address: address}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, strings.TrimSuffix(address, "/")+"/", nil)
+	if err != nil {
+		health.Err = fmt.Errorf("invalid address: %w", err)
+		return health
+	}
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	health.Latency = time.Since(start)
+	if err != nil {
+		health.Err = err
+		return health
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	return health
+}
+
+// healthyAddresses returns the addresses that answer, in the configured order, so a command does
+// not wait for connections to a dead node to time out. All addresses are returned if none answer,
+// so the usual connection error is reported.
+func healthyAddresses(addresses []string, transport http.RoundTripper, verbose bool) []string {
+	if len(addresses) < 2 {
+		return addresses
+	}
+
+	var healthy []string
+	for _, health := range CheckAddresses(addresses, transport, addressCheckTimeout) {
+		if health.Err != nil {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", health.Address, health.Err)
+			}
+			continue
+		}
+		healthy = append(healthy, health.Address)
+	}
+
+	if len(healthy) == 0 {
+		return addresses
+	}
+	return healthy
+}
+
+// verboseTransport prints the address that served each request, and how it went, to stderr
+type verboseTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	endpoint := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s%s failed after %s: %v\n", req.Method, endpoint, req.URL.Path, elapsed, err)
+		return resp, err
+	}
+	fmt.Fprintf(os.Stderr, "%s %s%s %d in %s\n", req.Method, endpoint, req.URL.Path, resp.StatusCode, elapsed)
+	return resp, nil
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FeatureState is a feature whose system indices are snapshotted and restored as a unit
+type FeatureState struct {
+	Name            string
+	Description     string
+	MigrationStatus string // upgrade status of the feature's system indices
+	Indices         []SystemIndex
+}
+
+// SystemIndex is a system index belonging to a feature
+type SystemIndex struct {
+	Name      string
+	Docs      int64
+	SizeBytes int64
+	Exists    bool // false if the index was not found in the index listing
+}
+
+// SizeBytes returns the total store size of the feature's system indices
+func (f FeatureState) SizeBytes() int64 {
+	var total int64
+	for _, index := range f.Indices {
+		total += index.SizeBytes
+	}
+	return total
+}
+
+// GetFeatureStates returns every feature state with its system indices and their sizes, sorted
+// by feature name. Features are listed by the features API; their indices come from the feature
+// upgrade status API, the only API that maps features to indices.
+func (c *Client) GetFeatureStates() ([]FeatureState, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.FeaturesGetFeatures(
+		c.es.FeaturesGetFeatures.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting features: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var featuresResponse struct {
+		Features []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&featuresResponse); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	// Map features to their system indices
+	statusRes, err := c.es.Migration.GetFeatureUpgradeStatus(
+		c.es.Migration.GetFeatureUpgradeStatus.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting feature indices: %w", err)
+	}
+	defer statusRes.Body.Close()
+
+	if statusRes.IsError() {
+		return nil, newResponseError(statusRes)
+	}
+
+	var statusResponse struct {
+		Features []struct {
+			FeatureName     string `json:"feature_name"`
+			MigrationStatus string `json:"migration_status"`
+			Indices         []struct {
+				Index string `json:"index"`
+			} `json:"indices"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(statusRes.Body).Decode(&statusResponse); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	sizes, err := c.getIndexStoreSizes(ctx, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]*FeatureState, len(featuresResponse.Features))
+	for _, f := range featuresResponse.Features {
+		features[f.Name] = &FeatureState{Name: f.Name, Description: f.Description}
+	}
+	for _, f := range statusResponse.Features {
+		feature, ok := features[f.FeatureName]
+		if !ok {
+			feature = &FeatureState{Name: f.FeatureName}
+			features[f.FeatureName] = feature
+		}
+		feature.MigrationStatus = f.MigrationStatus
+		for _, index := range f.Indices {
+			systemIndex := SystemIndex{Name: index.Index}
+			if size, ok := sizes[index.Index]; ok {
+				systemIndex.Docs = size.docs
+				systemIndex.SizeBytes = size.bytes
+				systemIndex.Exists = true
+			}
+			feature.Indices = append(feature.Indices, systemIndex)
+		}
+	}
+
+	result := make([]FeatureState, 0, len(features))
+	for _, feature := range features {
+		sort.Slice(feature.Indices, func(i, j int) bool {
+			return feature.Indices[i].Name < feature.Indices[j].Name
+		})
+		result = append(result, *feature)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// indexStoreSize is the document count and total store size of an index
+type indexStoreSize struct {
+	docs  int64
+	bytes int64
+}
+
+// getIndexStoreSizes returns the document count and store size of the indices matching a pattern,
+// including hidden and system indices
+func (c *Client) getIndexStoreSizes(ctx context.Context, pattern string) (map[string]indexStoreSize, error) {
+	// Execute request
+	res, err := c.es.Cat.Indices(
+		c.es.Cat.Indices.WithContext(ctx),
+		c.es.Cat.Indices.WithIndex(pattern),
+		c.es.Cat.Indices.WithExpandWildcards("all"),
+		c.es.Cat.Indices.WithFormat("json"),
+		c.es.Cat.Indices.WithH("index,docs.count,store.size"),
+		c.es.Cat.Indices.WithBytes("b"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index sizes: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var rows []struct {
+		Index     string `json:"index"`
+		DocsCount string `json:"docs.count"`
+		StoreSize string `json:"store.size"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	sizes := make(map[string]indexStoreSize, len(rows))
+	for _, row := range rows {
+		// Closed indices have no counts
+		docs, _ := strconv.ParseInt(row.DocsCount, 10, 64)
+		bytes, _ := strconv.ParseInt(row.StoreSize, 10, 64)
+		sizes[row.Index] = indexStoreSize{docs: docs, bytes: bytes}
+	}
+
+	return sizes, nil
+}
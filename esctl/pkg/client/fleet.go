@@ -4,10 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -16,22 +14,43 @@ import (
 // FleetClient extends KibanaClient with Fleet-specific methods
 type FleetClient struct {
 	*KibanaClient
+
+	perPage int // items requested per page by the list methods
+	limit   int // maximum number of items returned by the list methods, 0 for all
+	page    int // single page returned by the list methods, 0 for every page
+	total   int // total number of items reported by the last list request
 }
 
 // AgentPolicy represents a Fleet agent policy
 type AgentPolicy struct {
-	ID                string   `json:"id,omitempty"`
-	Name              string   `json:"name"`
-	Namespace         string   `json:"namespace"`
-	Description       string   `json:"description,omitempty"`
-	MonitoringEnabled []string `json:"monitoring_enabled,omitempty"`
-	Status            string   `json:"status,omitempty"`
-	Revision          int      `json:"revision,omitempty"`
-	UpdatedAt         string   `json:"updated_at,omitempty"`
-	UpdatedBy         string   `json:"updated_by,omitempty"`
-	IsDefault         bool     `json:"is_default,omitempty"`
-	IsManaged         bool     `json:"is_managed,omitempty"`
-	IsDeletable       bool     `json:"is_deletable,omitempty"`
+	ID                 string                 `json:"id,omitempty"`
+	Name               string                 `json:"name"`
+	Namespace          string                 `json:"namespace"`
+	Description        string                 `json:"description,omitempty"`
+	MonitoringEnabled  []string               `json:"monitoring_enabled,omitempty"`
+	FleetServerHostID  string                 `json:"fleet_server_host_id,omitempty"`
+	DataOutputID       string                 `json:"data_output_id,omitempty"`
+	MonitoringOutputID string                 `json:"monitoring_output_id,omitempty"`
+	DownloadSourceID   string                 `json:"download_source_id,omitempty"`
+	UnenrollTimeout    int                    `json:"unenroll_timeout,omitempty"`
+	InactivityTimeout  int                    `json:"inactivity_timeout,omitempty"`
+	AgentFeatures      []AgentFeature         `json:"agent_features,omitempty"`
+	IsProtected        bool                   `json:"is_protected,omitempty"`
+	HasFleetServer     bool                   `json:"has_fleet_server,omitempty"`
+	Overrides          map[string]interface{} `json:"overrides,omitempty"`
+	Status             string                 `json:"status,omitempty"`
+	Revision           int                    `json:"revision,omitempty"`
+	UpdatedAt          string                 `json:"updated_at,omitempty"`
+	UpdatedBy          string                 `json:"updated_by,omitempty"`
+	IsDefault          bool                   `json:"is_default,omitempty"`
+	IsManaged          bool                   `json:"is_managed,omitempty"`
+	IsDeletable        bool                   `json:"is_deletable,omitempty"`
+}
+
+// AgentFeature represents an agent feature toggle on an agent policy
+type AgentFeature struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
 }
 
 // AgentPolicyResponse represents the response from the Fleet API for agent policies
@@ -137,83 +156,20 @@ func NewFleet(cfg *config.Config) (*FleetClient, error) {
 		return nil, err
 	}
 
-	return &FleetClient{
+	fleetClient := &FleetClient{
 		KibanaClient: kibanaClient,
-	}, nil
-}
-
-// GetAgentPolicies retrieves all agent policies from Fleet
-func (c *FleetClient) GetAgentPolicies() ([]AgentPolicy, error) {
-	// Create request to Fleet API
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/fleet/agent_policies", c.baseURL), nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	// Add basic auth if credentials are provided
-	if c.username != "" && c.password != "" {
-		req.SetBasicAuth(c.username, c.password)
 	}
+	fleetClient.SetPerPage(cfg.Fleet.PerPage)
 
-	// Add required headers
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("kbn-xsrf", "true")
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result AgentPolicyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
-	}
-
-	return result.Items, nil
+	return fleetClient, nil
 }
 
-// PolicyIDError represents an error related to policy ID validation
-type PolicyIDError struct {
-	ID     string
-	Reason string
-}
-
-// Error implements the error interface for PolicyIDError
-func (e *PolicyIDError) Error() string {
-	return fmt.Sprintf("invalid policy ID '%s': %s", e.ID, e.Reason)
-}
-
-// ValidatePolicyID checks if a policy ID matches required format rules
-// Policy IDs must be lowercase alphanumeric with hyphens and underscores, 1-36 chars
-func ValidatePolicyID(id string) error {
-	// Empty ID is valid (system will generate one)
-	if id == "" {
-		return nil
-	}
-
-	// Check length
-	if len(id) > 36 {
-		return &PolicyIDError{ID: id, Reason: "exceeds maximum length of 36 characters"}
-	}
-
-	// Check pattern using regex
-	validPattern := regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
-	if !validPattern.MatchString(id) {
-		return &PolicyIDError{
-			ID:     id,
-			Reason: "must contain only lowercase letters, numbers, hyphens, and underscores, and start with a letter or number",
-		}
-	}
-
-	return nil
+// GetAgentPolicies retrieves all agent policies from Fleet, following pages up to the client limit.
+// The result is cached, as policy lookups by name or ID are repeated within a command.
+func (c *FleetClient) GetAgentPolicies() ([]AgentPolicy, error) {
+	return cachedLookup(c.cache, fmt.Sprintf("agent_policies:%d:%d:%d", c.limit, c.page, c.pageSize()), func() ([]AgentPolicy, error) {
+		return getAllFleetPages[AgentPolicy](c, "/api/fleet/agent_policies", nil)
+	})
 }
 
 // CheckPolicyIDExists checks if a policy ID already exists
@@ -286,8 +242,7 @@ func (c *FleetClient) CreateAgentPolicy(policy AgentPolicy) (*AgentPolicy, error
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse response
@@ -296,83 +251,19 @@ func (c *FleetClient) CreateAgentPolicy(policy AgentPolicy) (*AgentPolicy, error
 		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
+	c.cache.invalidate("agent_policies")
+
 	return &result.Item, nil
 }
 
-// GetEnrollmentTokens retrieves all enrollment tokens from Fleet
+// GetEnrollmentTokens retrieves all enrollment tokens from Fleet, following pages up to the client limit
 func (c *FleetClient) GetEnrollmentTokens() ([]EnrollmentToken, error) {
-	// Create request to Fleet API
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/fleet/enrollment_api_keys", c.baseURL), nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	// Add basic auth if credentials are provided
-	if c.username != "" && c.password != "" {
-		req.SetBasicAuth(c.username, c.password)
-	}
-
-	// Add required headers
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("kbn-xsrf", "true")
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result EnrollmentTokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
-	}
-
-	return result.Items, nil
+	return getAllFleetPages[EnrollmentToken](c, "/api/fleet/enrollment_api_keys", nil)
 }
 
-// GetPackagePolicies retrieves all package policies from Fleet
+// GetPackagePolicies retrieves all package policies from Fleet, following pages up to the client limit
 func (c *FleetClient) GetPackagePolicies() ([]PackagePolicy, error) {
-	// Create request to Fleet API
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/fleet/package_policies", c.baseURL), nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	// Add basic auth if credentials are provided
-	if c.username != "" && c.password != "" {
-		req.SetBasicAuth(c.username, c.password)
-	}
-
-	// Add required headers
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("kbn-xsrf", "true")
-
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result PackagePolicyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
-	}
-
-	return result.Items, nil
+	return getAllFleetPages[PackagePolicy](c, "/api/fleet/package_policies", nil)
 }
 
 // CheckPackagePolicyIDExists checks if a package policy ID already exists
@@ -445,8 +336,7 @@ func (c *FleetClient) CreatePackagePolicy(policy PackagePolicy) (*PackagePolicy,
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse response
@@ -581,7 +471,7 @@ func (c *FleetClient) GetAgents(kuery string, page int, perPage int) ([]Agent, i
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, 0, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, 0, newHTTPError(resp)
 	}
 
 	// Parse response
@@ -617,7 +507,7 @@ func (c *FleetClient) GetAgent(id string) (*Agent, error) {
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse response
@@ -670,7 +560,7 @@ func (c *FleetClient) UpdateAgent(id string, userMeta map[string]interface{}, ta
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return newHTTPError(resp)
 	}
 
 	return nil
@@ -706,7 +596,7 @@ func (c *FleetClient) DeleteAgent(id string, force bool) error {
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return newHTTPError(resp)
 	}
 
 	return nil
@@ -747,7 +637,7 @@ func (c *FleetClient) ReassignAgent(agentID string, policyID string) error {
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return newHTTPError(resp)
 	}
 
 	return nil
@@ -756,7 +646,7 @@ func (c *FleetClient) ReassignAgent(agentID string, policyID string) error {
 // GetAgentsFormatted returns agents formatted for display
 func (c *FleetClient) GetAgentsFormatted(kuery string) ([]string, [][]string, error) {
 	// Get agents with potential filtering
-	agents, _, err := c.GetAgents(kuery, 0, 0)
+	agents, err := c.GetAllAgents(kuery)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -815,7 +705,55 @@ func (c *FleetClient) UpdateAgentPolicy(id string, policy AgentPolicy) (*AgentPo
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse response
+	var result AgentPolicyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	c.cache.invalidate("agent_policies")
+
+	return &result.Item, nil
+}
+
+// CopyAgentPolicy copies an agent policy, including its integrations, under a new name
+func (c *FleetClient) CopyAgentPolicy(id, name, description string) (*AgentPolicy, error) {
+	// Marshal copy request to JSON
+	body := map[string]string{"name": name}
+	if description != "" {
+		body["description"] = description
+	}
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling copy request: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/fleet/agent_policies/%s/copy", c.baseURL, id), bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse response
@@ -824,30 +762,31 @@ func (c *FleetClient) UpdateAgentPolicy(id string, policy AgentPolicy) (*AgentPo
 		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
+	c.cache.invalidate("agent_policies")
+
 	return &result.Item, nil
 }
 
-// DeleteAgentPolicy deletes an agent policy
-func (c *FleetClient) DeleteAgentPolicy(id string, force bool) error {
+// DeleteAgentPolicy deletes an agent policy. With force, agents assigned to the policy are first
+// moved to the fallback policy, or to the default policy if no fallback is given.
+func (c *FleetClient) DeleteAgentPolicy(id string, force bool, fallbackPolicyID string, progress ReassignProgress) error {
 	// If force is true, we need to first find and reassign any agents using this policy
 	if force {
-		// 1. Find default policy ID to reassign to
-		defaultPolicyID, err := c.getDefaultPolicyID()
-		if err != nil {
-			return fmt.Errorf("finding default policy for reassignment: %w", err)
+		// 1. Find the policy to reassign to
+		if fallbackPolicyID == "" {
+			defaultPolicyID, err := c.getDefaultPolicyID()
+			if err != nil {
+				return fmt.Errorf("finding default policy for reassignment: %w", err)
+			}
+			fallbackPolicyID = defaultPolicyID
 		}
-
-		// 2. Find all agents assigned to this policy
-		agents, _, err := c.GetAgents(fmt.Sprintf("policy_id:%s", id), 1, 1000) // Get up to 1000 agents on page 1
-		if err != nil {
-			return fmt.Errorf("finding agents assigned to policy %s: %w", id, err)
+		if fallbackPolicyID == id {
+			return fmt.Errorf("cannot reassign agents to the policy being deleted (%s)", id)
 		}
 
-		// 3. Reassign all agents to the default policy
-		for _, agent := range agents {
-			if err := c.ReassignAgent(agent.ID, defaultPolicyID); err != nil {
-				return fmt.Errorf("reassigning agent %s to default policy: %w", agent.ID, err)
-			}
+		// 2. Reassign all agents in bulk
+		if _, err := c.ReassignPolicyAgents(id, fallbackPolicyID, progress); err != nil {
+			return err
 		}
 	}
 
@@ -873,10 +812,11 @@ func (c *FleetClient) DeleteAgentPolicy(id string, force bool) error {
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("policy deletion failed with status %d: %s", resp.StatusCode, string(body))
+		return newHTTPError(resp)
 	}
 
+	c.cache.invalidate("agent_policies")
+
 	return nil
 }
 
@@ -929,7 +869,7 @@ func (c *FleetClient) UpdatePackagePolicy(id string, policy PackagePolicy) (*Pac
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse response
@@ -971,8 +911,7 @@ func (c *FleetClient) DeletePackagePolicy(id string, force bool) error {
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("package policy deletion failed with status %d: %s", resp.StatusCode, string(body))
+		return newHTTPError(resp)
 	}
 
 	return nil
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// fleetUnenrollBatchSize is the number of agents sent in a single bulk unenroll request
+const fleetUnenrollBatchSize = 1000
+
+// InactiveAgent is an agent that has not checked in for longer than a threshold
+type InactiveAgent struct {
+	Agent          Agent
+	LastSeen       time.Time // last check-in, or enrollment time if the agent never checked in
+	OfflineFor     time.Duration
+	NeverCheckedIn bool // the agent never checked in
+}
+
+// GetOfflineAgents retrieves every agent Fleet reports as offline or inactive, narrowed by an
+// optional kuery
+func (c *FleetClient) GetOfflineAgents(kuery string) ([]Agent, error) {
+	statusKuery := "(status:offline or status:inactive)"
+	if kuery != "" {
+		statusKuery += " and (" + kuery + ")"
+	}
+
+	params := url.Values{}
+	params.Set("kuery", statusKuery)
+	// Inactive agents are hidden from the agent list unless asked for
+	params.Set("showInactive", "true")
+	return getAllFleetAgents[Agent](c, params)
+}
+
+// FindInactiveAgents returns the agents whose last check-in is older than the threshold, longest
+// offline first. Agents that never checked in are judged by their enrollment time.
+func FindInactiveAgents(agents []Agent, olderThan time.Duration, now time.Time) []InactiveAgent {
+	var result []InactiveAgent
+	for _, agent := range agents {
+		if agent.UnenrolledAt != "" {
+			continue
+		}
+
+		inactive := InactiveAgent{Agent: agent}
+		seen := agent.LastCheckin
+		if seen == "" {
+			seen = agent.EnrolledAt
+			inactive.NeverCheckedIn = true
+		}
+		lastSeen, err := time.Parse(time.RFC3339, seen)
+		if err != nil {
+			continue
+		}
+
+		inactive.LastSeen = lastSeen
+		inactive.OfflineFor = now.Sub(lastSeen)
+		if inactive.OfflineFor > olderThan {
+			result = append(result, inactive)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.Before(result[j].LastSeen)
+	})
+	return result
+}
+
+// BulkUnenrollAgents unenrolls the given agents and returns the IDs of the Fleet actions that
+// carry out the unenrollment. With revoke, the agents' API keys are revoked and the agents
+// removed at once, without waiting for them to acknowledge, which offline agents never do.
+func (c *FleetClient) BulkUnenrollAgents(ids []string, revoke bool) ([]string, error) {
+	var actionIDs []string
+	for start := 0; start < len(ids); start += fleetUnenrollBatchSize {
+		end := start + fleetUnenrollBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		actionID, err := c.bulkUnenroll(ids[start:end], revoke)
+		if err != nil {
+			return actionIDs, err
+		}
+		if actionID != "" {
+			actionIDs = append(actionIDs, actionID)
+		}
+	}
+	return actionIDs, nil
+}
+
+// bulkUnenroll sends a single bulk unenroll request
+func (c *FleetClient) bulkUnenroll(ids []string, revoke bool) (string, error) {
+	// Marshal bulk unenroll request to JSON
+	bodyJSON, err := json.Marshal(map[string]interface{}{
+		"agents": ids,
+		"revoke": revoke,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshaling bulk unenroll request: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/fleet/agents/bulk_unenroll", c.baseURL), bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", newHTTPError(resp)
+	}
+
+	// Parse response
+	var result struct {
+		ActionID string `json:"actionId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+
+	return result.ActionID, nil
+}
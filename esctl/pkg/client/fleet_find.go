@@ -0,0 +1,151 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// AgentSearch selects agents by the host metadata they report. Empty fields match any agent.
+type AgentSearch struct {
+	Hostname string // host name as the agent reports it, * matches any characters
+	IP       string // one of the host IP addresses
+	OS       string // part of the OS name, platform or full description, case-insensitive
+}
+
+// FindAgents returns the agents whose local metadata matches the search. Hostname and IP narrow
+// the listing in Fleet first; the exact match is done here, as Fleet stores host IPs with their
+// prefix length and host names in more than one field.
+func (c *FleetClient) FindAgents(search AgentSearch) ([]Agent, error) {
+	if search.Hostname == "" && search.IP == "" && search.OS == "" {
+		return nil, fmt.Errorf("at least one of hostname, IP or OS is required")
+	}
+
+	agents, err := c.GetAllAgents(search.kuery())
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []Agent
+	for _, agent := range agents {
+		if search.Matches(agent) {
+			matched = append(matched, agent)
+		}
+	}
+	return matched, nil
+}
+
+// kuery returns a KQL filter that selects at least the agents the search matches
+func (s AgentSearch) kuery() string {
+	var clauses []string
+	if s.Hostname != "" {
+		value := kqlValue(s.Hostname)
+		clauses = append(clauses, fmt.Sprintf("(local_metadata.host.hostname:%s or local_metadata.host.name:%s)", value, value))
+	}
+	if s.IP != "" {
+		// Stored as e.g. 10.2.3.4/24
+		clauses = append(clauses, fmt.Sprintf("local_metadata.host.ip:%s*", kqlEscape(s.IP)))
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// Matches reports whether the local metadata of an agent matches the search
+func (s AgentSearch) Matches(agent Agent) bool {
+	if s.Hostname != "" {
+		found := false
+		for _, name := range []string{AgentHostname(agent), agentMetadataString(agent, "host", "name")} {
+			if ok, _ := path.Match(s.Hostname, name); ok && name != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if s.IP != "" {
+		found := false
+		for _, ip := range AgentIPs(agent) {
+			if ip == s.IP {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if s.OS != "" {
+		want := strings.ToLower(s.OS)
+		found := false
+		for _, field := range []string{"name", "platform", "full"} {
+			if strings.Contains(strings.ToLower(agentMetadataString(agent, "os", field)), want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AgentIPs returns the IP addresses an agent reports, without their prefix length
+func AgentIPs(agent Agent) []string {
+	host, ok := agent.LocalMetadata["host"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	values, _ := host["ip"].([]interface{})
+	ips := make([]string, 0, len(values))
+	for _, value := range values {
+		if ip, ok := value.(string); ok {
+			ip, _, _ = strings.Cut(ip, "/")
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// AgentOS returns the full OS description an agent reports, or its OS name
+func AgentOS(agent Agent) string {
+	if full := agentMetadataString(agent, "os", "full"); full != "" {
+		return full
+	}
+	return agentMetadataString(agent, "os", "name")
+}
+
+// agentMetadataString returns a string field of a local metadata section, e.g. os.name
+func agentMetadataString(agent Agent, section, field string) string {
+	values, ok := agent.LocalMetadata[section].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	value, _ := values[field].(string)
+	return value
+}
+
+// kqlValue quotes a value for KQL, leaving it unquoted with escaped special characters when it
+// holds a * wildcard, as quoted values are matched literally
+func kqlValue(value string) string {
+	if strings.Contains(value, "*") {
+		return kqlEscape(value)
+	}
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// kqlEscape escapes the KQL special characters in an unquoted value, except *
+func kqlEscape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(`\():<>"{} `, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// PackagePolicyNamespace is the namespace a package policy writes its data streams into through
+// one of its agent policies
+type PackagePolicyNamespace struct {
+	PackagePolicyID   string
+	PackagePolicyName string
+	Package           string
+	AgentPolicyID     string
+	AgentPolicyName   string
+	Namespace         string
+	Inherited         bool     // the package policy sets no namespace and uses its agent policy's
+	Spaces            []string // spaces of the agent policy
+}
+
+// namespaceAgentPolicy is the part of an agent policy the namespace audit reads
+type namespaceAgentPolicy struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	SpaceIDs  []string `json:"space_ids"` // reported when Fleet keeps policies per space
+}
+
+// namespacePackagePolicy is the part of a package policy the namespace audit reads
+type namespacePackagePolicy struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	Namespace string               `json:"namespace"`
+	PolicyID  string               `json:"policy_id"`
+	PolicyIDs []string             `json:"policy_ids"` // agent policies sharing the package policy
+	Package   PackagePolicyPackage `json:"package"`
+}
+
+// GetPackagePolicyNamespaces returns the namespace each package policy writes into through each
+// of its agent policies, in the given spaces, sorted by package policy and agent policy name.
+// Package policies without a namespace of their own use their agent policy's.
+//
+// When Fleet keeps policies per space, an agent policy is reported with the spaces it belongs to.
+// Otherwise every space lists every policy, and a policy is reported with the given spaces it was
+// listed in.
+func (c *FleetClient) GetPackagePolicyNamespaces(spaces []string) ([]PackagePolicyNamespace, error) {
+	byKey := make(map[string]*PackagePolicyNamespace)
+	for _, space := range spaces {
+		sc := c.inSpace(space)
+
+		agentPolicies, err := getAllFleetPages[namespaceAgentPolicy](sc, "/api/fleet/agent_policies", nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting agent policies in space %s: %w", space, err)
+		}
+		byID := make(map[string]namespaceAgentPolicy, len(agentPolicies))
+		for _, policy := range agentPolicies {
+			byID[policy.ID] = policy
+		}
+
+		packagePolicies, err := getAllFleetPages[namespacePackagePolicy](sc, "/api/fleet/package_policies", nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting package policies in space %s: %w", space, err)
+		}
+
+		for _, pp := range packagePolicies {
+			policyIDs := pp.PolicyIDs
+			if len(policyIDs) == 0 && pp.PolicyID != "" {
+				policyIDs = []string{pp.PolicyID}
+			}
+			for _, policyID := range policyIDs {
+				key := pp.ID + "/" + policyID
+				if entry, ok := byKey[key]; ok {
+					if len(byID[policyID].SpaceIDs) == 0 {
+						entry.Spaces = append(entry.Spaces, space)
+					}
+					continue
+				}
+
+				agentPolicy := byID[policyID]
+				entry := &PackagePolicyNamespace{
+					PackagePolicyID:   pp.ID,
+					PackagePolicyName: pp.Name,
+					Package:           pp.Package.Name,
+					AgentPolicyID:     policyID,
+					AgentPolicyName:   agentPolicy.Name,
+					Namespace:         pp.Namespace,
+					Spaces:            agentPolicy.SpaceIDs,
+				}
+				if entry.Namespace == "" {
+					entry.Namespace = agentPolicy.Namespace
+					entry.Inherited = true
+				}
+				if len(entry.Spaces) == 0 {
+					entry.Spaces = []string{space}
+				}
+				byKey[key] = entry
+			}
+		}
+	}
+
+	result := make([]PackagePolicyNamespace, 0, len(byKey))
+	for _, entry := range byKey {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PackagePolicyName != result[j].PackagePolicyName {
+			return result[i].PackagePolicyName < result[j].PackagePolicyName
+		}
+		return result[i].AgentPolicyName < result[j].AgentPolicyName
+	})
+	return result, nil
+}
+
+// inSpace returns a copy of the client that sends its requests to a space, the configured space
+// if it is empty
+func (c *FleetClient) inSpace(space string) *FleetClient {
+	if space == "" {
+		return c
+	}
+	kibana := *c.KibanaClient
+	kibana.baseURL = kibana.address + spacePath(space)
+	scoped := *c
+	scoped.KibanaClient = &kibana
+	return &scoped
+}
+
+// NamespaceAllowed reports whether a namespace matches one of the allowed patterns, which may use
+// * wildcards
+func NamespaceAllowed(namespace string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
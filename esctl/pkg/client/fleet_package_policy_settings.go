@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// packagePolicyUpdateFields are the fields of a simplified package policy that the update API
+// accepts, the others are filled in by Fleet
+var packagePolicyUpdateFields = []string{
+	"name", "description", "namespace", "policy_id", "policy_ids", "output_id", "package", "vars", "inputs",
+}
+
+// GetPackagePolicyConfig returns a package policy in Fleet's simplified format, where inputs and
+// streams are objects keyed by name and variables are plain values, for editing with
+// SetPackagePolicyValue
+func (c *FleetClient) GetPackagePolicyConfig(id string) (map[string]interface{}, error) {
+	// Create request
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/fleet/package_policies/%s?format=simplified", c.baseURL, id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse response
+	var result struct {
+		Item map[string]interface{} `json:"item"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return result.Item, nil
+}
+
+// UpdatePackagePolicyConfig replaces a package policy with one in the simplified format returned
+// by GetPackagePolicyConfig. Fields the update API does not accept, such as the revision, are left
+// out of the request.
+func (c *FleetClient) UpdatePackagePolicyConfig(id string, policy map[string]interface{}) (*PackagePolicy, error) {
+	body := make(map[string]interface{}, len(packagePolicyUpdateFields))
+	for _, field := range packagePolicyUpdateFields {
+		if value, ok := policy[field]; ok && value != nil {
+			body[field] = value
+		}
+	}
+
+	// Marshal policy to JSON
+	policyJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling policy: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/api/fleet/package_policies/%s?format=simplified", c.baseURL, id), bytes.NewBuffer(policyJSON))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse response
+	var result PackagePolicyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return &result.Item, nil
+}
+
+// ParsePackagePolicySetting splits a path=value setting. The value is read as JSON, so that
+// false, 30 and ["/var/log/*.log"] keep their type, and as a string when it is not valid JSON.
+func ParsePackagePolicySetting(setting string) (string, interface{}, error) {
+	path, raw, ok := strings.Cut(setting, "=")
+	if !ok || path == "" {
+		return "", nil, fmt.Errorf("invalid setting %q, expected path=value", setting)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+	return path, value, nil
+}
+
+// SetPackagePolicyValue sets the value at a dotted path of a simplified package policy, such as
+// vars.paths, inputs.logfile.enabled or inputs.logfile.streams.syslog.vars.paths. An input may be
+// named by its key or by its type without the policy template prefix (logfile for
+// system-logfile), and a stream by its data set or by the data set without the package prefix
+// (syslog for system.syslog). Inputs and streams must exist in the policy; variables that are
+// not set yet are added.
+func SetPackagePolicyValue(policy map[string]interface{}, path string, value interface{}) error {
+	segments := strings.Split(path, ".")
+	object := policy
+	parent := ""
+	for len(segments) > 0 {
+		key, rest := packagePolicyKey(object, parent, segments)
+		if len(rest) == 0 {
+			object[key] = value
+			return nil
+		}
+
+		child, exists := object[key]
+		if !exists || child == nil {
+			switch parent {
+			case "inputs", "streams":
+				return fmt.Errorf("no %s %q in package policy, available: %s", strings.TrimSuffix(parent, "s"), key, strings.Join(sortedKeys(object), ", "))
+			}
+			if key != "vars" {
+				return fmt.Errorf("no %s in package policy", strings.Join(segments[:len(segments)-len(rest)], "."))
+			}
+			child = make(map[string]interface{})
+			object[key] = child
+		}
+
+		next, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not an object", path, strings.TrimSuffix(path, "."+strings.Join(rest, ".")))
+		}
+		object, parent, segments = next, key, rest
+	}
+	return nil
+}
+
+// packagePolicyKey returns the key of object that the leading path segments name and the
+// segments left over. Keys may hold dots, as data sets do, so the longest matching key is used.
+// Under inputs and streams a key may also be given by its suffix when it matches only one key.
+func packagePolicyKey(object map[string]interface{}, parent string, segments []string) (string, []string) {
+	for n := len(segments); n > 1; n-- {
+		if key := strings.Join(segments[:n], "."); object[key] != nil {
+			return key, segments[n:]
+		}
+	}
+	key := segments[0]
+	if _, ok := object[key]; ok {
+		return key, segments[1:]
+	}
+
+	separator := ""
+	switch parent {
+	case "inputs":
+		separator = "-"
+	case "streams":
+		separator = "."
+	default:
+		return key, segments[1:]
+	}
+	var matches []string
+	for name := range object {
+		if strings.HasSuffix(name, separator+key) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], segments[1:]
+	}
+	return key, segments[1:]
+}
+
+// sortedKeys returns the keys of an object in order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
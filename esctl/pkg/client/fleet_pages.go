@@ -0,0 +1,215 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// fleetDefaultPerPage is the number of items requested per page from the Fleet list APIs
+	fleetDefaultPerPage = 100
+	// fleetMaxPerPage is the largest page size sent to the Fleet list APIs
+	fleetMaxPerPage = 1000
+	// fleetAgentPitKeepAlive is how long the point in time used to page through agents is kept
+	// open between pages
+	fleetAgentPitKeepAlive = "5m"
+)
+
+// fleetPage is the paging envelope shared by the Fleet list APIs
+type fleetPage[T any] struct {
+	Items   []T `json:"items"`
+	Page    int `json:"page"`
+	PerPage int `json:"perPage"`
+	Total   int `json:"total"`
+}
+
+// SetLimit caps the number of items returned by the Fleet list methods. 0 means no limit.
+func (c *FleetClient) SetLimit(limit int) {
+	c.limit = limit
+}
+
+// SetPage makes the Fleet list methods return a single page of items instead of every page.
+// Pages are numbered from 1, 0 returns every page.
+func (c *FleetClient) SetPage(page int) {
+	c.page = page
+}
+
+// Total returns the number of items the last Fleet list request reported in total, which can be
+// more than were returned when reading a single page or up to a limit
+func (c *FleetClient) Total() int {
+	return c.total
+}
+
+// PageSummary describes which part of the items the last Fleet list request returned, e.g.
+// "Page 2 of 5 (showing 100 of 432 agents)"
+func (c *FleetClient) PageSummary(shown int, noun string) string {
+	total := c.total
+	if total < shown {
+		total = shown
+	}
+	if c.page > 0 {
+		perPage := c.pageSize()
+		pages := (total + perPage - 1) / perPage
+		return fmt.Sprintf("Page %d of %d (showing %d of %d %s)", c.page, pages, shown, total, noun)
+	}
+	if shown < total {
+		return fmt.Sprintf("Showing %d of %d %s", shown, total, noun)
+	}
+	return fmt.Sprintf("Showing all %d %s", total, noun)
+}
+
+// SetPerPage sets the number of items requested per page, clamped to the range the Fleet APIs accept.
+// 0 uses the default page size.
+func (c *FleetClient) SetPerPage(perPage int) {
+	if perPage > fleetMaxPerPage {
+		perPage = fleetMaxPerPage
+	}
+	c.perPage = perPage
+}
+
+// pageSize returns the page size to request, never asking for more items than the limit allows
+func (c *FleetClient) pageSize() int {
+	perPage := c.perPage
+	if perPage <= 0 {
+		perPage = fleetDefaultPerPage
+	}
+	if c.limit > 0 && c.limit < perPage {
+		perPage = c.limit
+	}
+	return perPage
+}
+
+// getAllFleetPages reads a Fleet list API page by page until every item, or the client's limit,
+// has been read. If the client is set to a single page, only that page is read.
+func getAllFleetPages[T any](c *FleetClient, path string, params url.Values) ([]T, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	perPage := c.pageSize()
+	params.Set("perPage", strconv.Itoa(perPage))
+
+	first := 1
+	if c.page > 0 {
+		first = c.page
+	}
+
+	var items []T
+	for page := first; ; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var result fleetPage[T]
+		if err := c.getFleetPage(fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode()), &result); err != nil {
+			return nil, err
+		}
+		items = append(items, result.Items...)
+		c.total = result.Total
+
+		if c.page > 0 {
+			return items, nil
+		}
+
+		if c.limit > 0 && len(items) >= c.limit {
+			return items[:c.limit], nil
+		}
+		// A short page is the last one, the total is only trusted when the API reports one
+		if len(result.Items) < perPage || (result.Total > 0 && len(items) >= result.Total) {
+			return items, nil
+		}
+	}
+}
+
+// fleetAgentPage is a page of the agent list API read with a point in time
+type fleetAgentPage[T any] struct {
+	Items           []T    `json:"items"`
+	Total           int    `json:"total"`
+	Pit             string `json:"pit"`
+	NextSearchAfter string `json:"nextSearchAfter"` // JSON encoded sort values of the last agent
+}
+
+// getAllFleetAgents reads the agent list API until every agent, or the client's limit, has been
+// read. Page numbers cannot reach past the 10,000 agent result window of .fleet-agents, so the
+// agents are read through a point in time with searchAfter instead. Kibana versions without
+// point in time support reject the parameters, and are read page by page. If the client is set
+// to a single page, only that page is read.
+func getAllFleetAgents[T any](c *FleetClient, params url.Values) ([]T, error) {
+	if c.page > 0 {
+		return getAllFleetPages[T](c, "/api/fleet/agents", params)
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+	perPage := c.pageSize()
+	params.Set("perPage", strconv.Itoa(perPage))
+	params.Set("openPit", "true")
+	params.Set("pitKeepAlive", fleetAgentPitKeepAlive)
+
+	var items []T
+	for {
+		var result fleetAgentPage[T]
+		err := c.getFleetPage(fmt.Sprintf("%s/api/fleet/agents?%s", c.baseURL, params.Encode()), &result)
+		if err != nil {
+			var apiErr *APIError
+			if len(items) == 0 && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+				params.Del("openPit")
+				params.Del("pitKeepAlive")
+				return getAllFleetPages[T](c, "/api/fleet/agents", params)
+			}
+			return nil, err
+		}
+		items = append(items, result.Items...)
+		c.total = result.Total
+
+		if c.limit > 0 && len(items) >= c.limit {
+			return items[:c.limit], nil
+		}
+		if len(result.Items) < perPage || result.NextSearchAfter == "" || (result.Total > 0 && len(items) >= result.Total) {
+			return items, nil
+		}
+
+		// Later pages continue from the last agent in the point in time the first page opened
+		params.Del("openPit")
+		if result.Pit != "" {
+			params.Set("pitId", result.Pit)
+		}
+		params.Set("searchAfter", result.NextSearchAfter)
+	}
+}
+
+// getFleetPage requests a single page from a Fleet list API
+func (c *FleetClient) getFleetPage(urlPath string, result interface{}) error {
+	// Create request
+	req, err := http.NewRequest("GET", urlPath, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return newHTTPError(resp)
+	}
+
+	// Parse response
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+
+	return nil
+}
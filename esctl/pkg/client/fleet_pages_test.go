@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestGetAllFleetPagesWithoutTotal(t *testing.T) {
+	const itemCount = 5
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+
+		// Pages of the items without a total, as some Fleet list APIs return them
+		items := []string{}
+		for i := (page - 1) * perPage; i < page*perPage && i < itemCount; i++ {
+			items = append(items, "item-"+strconv.Itoa(i))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "page": page, "perPage": perPage})
+	}))
+	defer server.Close()
+
+	c := &FleetClient{KibanaClient: &KibanaClient{httpClient: server.Client(), baseURL: server.URL}}
+	c.SetPerPage(2)
+
+	items, err := getAllFleetPages[string](c, "/api/fleet/items", nil)
+	if err != nil {
+		t.Fatalf("getAllFleetPages() error = %v", err)
+	}
+	if len(items) != itemCount {
+		t.Errorf("getAllFleetPages() returned %d items, want %d", len(items), itemCount)
+	}
+	if requests != 3 {
+		t.Errorf("getAllFleetPages() made %d requests, want 3", requests)
+	}
+}
+
+func TestGetAllFleetAgentsPastResultWindow(t *testing.T) {
+	const itemCount = 5
+	var pageRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("page") != "" {
+			pageRequests++
+		}
+		perPage, _ := strconv.Atoi(query.Get("perPage"))
+
+		// The first request opens a point in time, later ones continue after the last agent
+		start := 0
+		pit := "pit-1"
+		if after := query.Get("searchAfter"); after != "" {
+			var sort []int
+			if err := json.Unmarshal([]byte(after), &sort); err != nil {
+				t.Errorf("searchAfter %q is not a sort array: %v", after, err)
+			}
+			start = sort[0] + 1
+			if query.Get("pitId") != pit || query.Get("openPit") != "" {
+				t.Errorf("page after %d opened a new point in time instead of using %s", sort[0], pit)
+			}
+		} else if query.Get("openPit") != "true" {
+			t.Errorf("first page did not open a point in time")
+		}
+
+		items := []string{}
+		next := ""
+		for i := start; i < start+perPage && i < itemCount; i++ {
+			items = append(items, "agent-"+strconv.Itoa(i))
+			next = "[" + strconv.Itoa(i) + "]"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "total": itemCount, "pit": pit, "nextSearchAfter": next})
+	}))
+	defer server.Close()
+
+	c := &FleetClient{KibanaClient: &KibanaClient{httpClient: server.Client(), baseURL: server.URL}}
+	c.SetPerPage(2)
+
+	items, err := getAllFleetAgents[string](c, nil)
+	if err != nil {
+		t.Fatalf("getAllFleetAgents() error = %v", err)
+	}
+	if len(items) != itemCount || items[itemCount-1] != "agent-4" {
+		t.Errorf("getAllFleetAgents() = %v, want %d agents", items, itemCount)
+	}
+	if pageRequests != 0 {
+		t.Errorf("getAllFleetAgents() made %d page numbered requests, want 0", pageRequests)
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fleetPoliciesIndex is where Fleet Server keeps the compiled policy of every agent policy revision
+const fleetPoliciesIndex = ".fleet-policies"
+
+// PolicyRevision is one revision of an agent policy as it was sent to the agents
+type PolicyRevision struct {
+	Revision  int
+	Timestamp string                 // when the revision was written
+	Data      map[string]interface{} // compiled policy
+}
+
+// PolicyChange is a setting of the compiled policy that changed between two revisions. Old or New
+// is empty when the setting was added or removed.
+type PolicyChange struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// GetAgentPolicyRevisions returns up to last revisions of an agent policy, newest first, from the
+// .fleet-policies index. Reading the index needs a user with access to Fleet's system indices.
+func (c *Client) GetAgentPolicyRevisions(policyID string, last int) ([]PolicyRevision, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Each revision can be written once per coordinator index, keep the latest of each
+	body := map[string]interface{}{
+		"size":  last * 2,
+		"query": map[string]interface{}{"term": map[string]interface{}{"policy_id": policyID}},
+		"sort": []interface{}{
+			map[string]interface{}{"revision_idx": "desc"},
+			map[string]interface{}{"coordinator_idx": "desc"},
+		},
+		"_source": []string{"revision_idx", "@timestamp", "data"},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithIndex(fleetPoliciesIndex),
+		c.es.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error searching policy revisions: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					RevisionIdx int                    `json:"revision_idx"`
+					Timestamp   string                 `json:"@timestamp"`
+					Data        map[string]interface{} `json:"data"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var revisions []PolicyRevision
+	for _, hit := range response.Hits.Hits {
+		if len(revisions) > 0 && revisions[len(revisions)-1].Revision == hit.Source.RevisionIdx {
+			continue
+		}
+		if len(revisions) == last {
+			break
+		}
+		revisions = append(revisions, PolicyRevision{
+			Revision:  hit.Source.RevisionIdx,
+			Timestamp: hit.Source.Timestamp,
+			Data:      hit.Source.Data,
+		})
+	}
+	return revisions, nil
+}
+
+// DiffPolicyRevisions returns the settings that differ between two compiled policies, sorted by
+// path. The revision number and signature, which change with every revision, are left out.
+func DiffPolicyRevisions(previous, revision PolicyRevision) []PolicyChange {
+	before := make(map[string]string)
+	flattenPolicy("", previous.Data, before)
+	after := make(map[string]string)
+	flattenPolicy("", revision.Data, after)
+
+	var changes []PolicyChange
+	for path, value := range after {
+		if before[path] != value {
+			changes = append(changes, PolicyChange{Path: path, Old: before[path], New: value})
+		}
+	}
+	for path, value := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, PolicyChange{Path: path, Old: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// flattenPolicy adds the leaf values of a compiled policy to result by dotted path, with list
+// items by position, e.g. inputs[2].streams[0].paths[0]
+func flattenPolicy(prefix string, value interface{}, result map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if path == "revision" || path == "signed" || strings.HasPrefix(path, "signed.") {
+				continue
+			}
+			flattenPolicy(path, child, result)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenPolicy(fmt.Sprintf("%s[%d]", prefix, i), child, result)
+		}
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			encoded = []byte(fmt.Sprintf("%v", v))
+		}
+		result[prefix] = string(encoded)
+	}
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// bulkReassignBatchSize is the number of agents sent in each bulk reassign request
+	bulkReassignBatchSize = 500
+	// reassignWaitTimeout is how long to wait for Fleet to finish moving agents off a policy
+	reassignWaitTimeout = 5 * time.Minute
+	// reassignPollInterval is the delay between checks for agents still on a policy
+	reassignPollInterval = 2 * time.Second
+)
+
+// ReassignProgress is called after each bulk reassign batch with the number of agents sent so far
+type ReassignProgress func(reassigned, total int)
+
+// BulkReassignAgents moves agents to another agent policy with a single request and returns the
+// ID of the Fleet action that carries out the reassignment
+func (c *FleetClient) BulkReassignAgents(agentIDs []string, policyID string) (string, error) {
+	// Prepare payload
+	payload := map[string]interface{}{
+		"agents":    agentIDs,
+		"policy_id": policyID,
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshaling payload: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/fleet/agents/bulk_reassign", c.baseURL), bytes.NewBuffer(data))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", newHTTPError(resp)
+	}
+
+	// Parse response
+	var result struct {
+		ActionID string `json:"actionId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+
+	return result.ActionID, nil
+}
+
+// ReassignPolicyAgents moves every agent on one agent policy to another in batches of
+// bulkReassignBatchSize, then waits until Fleet reports no agents left on the old policy.
+// It returns the number of agents reassigned.
+func (c *FleetClient) ReassignPolicyAgents(fromPolicyID, toPolicyID string, progress ReassignProgress) (int, error) {
+	kuery := fmt.Sprintf("policy_id:%q", fromPolicyID)
+	agents, err := c.GetAllAgents(kuery)
+	if err != nil {
+		return 0, fmt.Errorf("finding agents assigned to policy %s: %w", fromPolicyID, err)
+	}
+	if len(agents) == 0 {
+		return 0, nil
+	}
+
+	for start := 0; start < len(agents); start += bulkReassignBatchSize {
+		end := start + bulkReassignBatchSize
+		if end > len(agents) {
+			end = len(agents)
+		}
+
+		ids := make([]string, 0, end-start)
+		for _, agent := range agents[start:end] {
+			ids = append(ids, agent.ID)
+		}
+
+		if _, err := c.BulkReassignAgents(ids, toPolicyID); err != nil {
+			return start, fmt.Errorf("reassigning agents %d-%d of %d to policy %s: %w", start+1, end, len(agents), toPolicyID, err)
+		}
+		if progress != nil {
+			progress(end, len(agents))
+		}
+	}
+
+	// Bulk actions are applied asynchronously, and the policy cannot be deleted while agents remain
+	deadline := time.Now().Add(reassignWaitTimeout)
+	for {
+		_, remaining, err := c.GetAgents(kuery, 1, 1)
+		if err != nil {
+			return len(agents), fmt.Errorf("checking agents remaining on policy %s: %w", fromPolicyID, err)
+		}
+		if remaining == 0 {
+			return len(agents), nil
+		}
+		if time.Now().After(deadline) {
+			return len(agents), fmt.Errorf("%d agents still assigned to policy %s after %s", remaining, fromPolicyID, reassignWaitTimeout)
+		}
+		time.Sleep(reassignPollInterval)
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// AgentTagCount is the number of agents carrying a tag
+type AgentTagCount struct {
+	Tag    string
+	Agents int
+}
+
+// AgentMissingTags describes an agent that lacks one or more required tags
+type AgentMissingTags struct {
+	Agent   Agent
+	Missing []string
+}
+
+// GetAllAgents retrieves every agent matching the kuery, following pages up to the client limit
+func (c *FleetClient) GetAllAgents(kuery string) ([]Agent, error) {
+	params := url.Values{}
+	if kuery != "" {
+		params.Set("kuery", kuery)
+	}
+	return getAllFleetAgents[Agent](c, params)
+}
+
+// CountAgentTags returns the tags in use across the agents with the number of agents carrying each,
+// most used first
+func CountAgentTags(agents []Agent) []AgentTagCount {
+	counts := make(map[string]int)
+	for _, agent := range agents {
+		seen := make(map[string]bool, len(agent.Tags))
+		for _, tag := range agent.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			counts[tag]++
+		}
+	}
+
+	result := make([]AgentTagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, AgentTagCount{Tag: tag, Agents: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Agents != result[j].Agents {
+			return result[i].Agents > result[j].Agents
+		}
+		return result[i].Tag < result[j].Tag
+	})
+
+	return result
+}
+
+// FindAgentsMissingTags returns the agents that do not carry every one of the required tags
+func FindAgentsMissingTags(agents []Agent, required []string) []AgentMissingTags {
+	var result []AgentMissingTags
+	for _, agent := range agents {
+		has := make(map[string]bool, len(agent.Tags))
+		for _, tag := range agent.Tags {
+			has[tag] = true
+		}
+
+		var missing []string
+		for _, tag := range required {
+			if !has[tag] {
+				missing = append(missing, tag)
+			}
+		}
+		if len(missing) > 0 {
+			result = append(result, AgentMissingTags{Agent: agent, Missing: missing})
+		}
+	}
+	return result
+}
+
+// AgentHostname returns the hostname reported in an agent's local metadata
+func AgentHostname(agent Agent) string {
+	host, ok := agent.LocalMetadata["host"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	hostname, _ := host["hostname"].(string)
+	return hostname
+}
+
+// BulkUpdateAgentTags adds and removes tags on every agent matching the kuery and returns the
+// ID of the Fleet action that applies the change
+func (c *FleetClient) BulkUpdateAgentTags(kuery string, tagsToAdd, tagsToRemove []string) (string, error) {
+	// Marshal bulk update request to JSON
+	body := map[string]interface{}{
+		"agents": kuery,
+	}
+	if len(tagsToAdd) > 0 {
+		body["tagsToAdd"] = tagsToAdd
+	}
+	if len(tagsToRemove) > 0 {
+		body["tagsToRemove"] = tagsToRemove
+	}
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("marshaling bulk tag update request: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/fleet/agents/bulk_update_agent_tags", c.baseURL), bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", newHTTPError(resp)
+	}
+
+	// Parse response
+	var result struct {
+		ActionID string `json:"actionId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+
+	return result.ActionID, nil
+}
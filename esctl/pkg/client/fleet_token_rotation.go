@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// GetPolicyEnrollmentTokens retrieves the enrollment tokens of a single agent policy
+func (c *FleetClient) GetPolicyEnrollmentTokens(policyID string) ([]EnrollmentToken, error) {
+	tokens, err := c.GetEnrollmentTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []EnrollmentToken
+	for _, token := range tokens {
+		if token.PolicyID == policyID {
+			result = append(result, token)
+		}
+	}
+	return result, nil
+}
+
+// CreateEnrollmentToken creates a new enrollment token for an agent policy. Fleet makes the name
+// unique by appending an ID to it, and uses a default name when name is empty. The returned
+// token carries its secret in APIKey.
+func (c *FleetClient) CreateEnrollmentToken(policyID string, name string) (*EnrollmentToken, error) {
+	body := map[string]string{"policy_id": policyID}
+	if name != "" {
+		body["name"] = name
+	}
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling enrollment token: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/fleet/enrollment_api_keys", c.baseURL), bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse response
+	var result struct {
+		Item EnrollmentToken `json:"item"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return &result.Item, nil
+}
+
+// RevokeEnrollmentToken revokes an enrollment token, so no further agents can enroll with it.
+// Agents already enrolled with the token are not affected.
+func (c *FleetClient) RevokeEnrollmentToken(id string) error {
+	// Create request
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/fleet/enrollment_api_keys/%s", c.baseURL, id), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	// Add auth and headers
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("kbn-xsrf", "true")
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPError(resp)
+	}
+
+	return nil
+}
+
+// FindTokensToRevoke returns the active tokens created longer than olderThan before now, oldest
+// first, leaving out the token with the keep ID. Tokens with an unreadable creation time are
+// kept, as their age is unknown.
+func FindTokensToRevoke(tokens []EnrollmentToken, olderThan time.Duration, now time.Time, keep string) []EnrollmentToken {
+	var result []EnrollmentToken
+	for _, token := range tokens {
+		if !token.Active || token.ID == keep {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, token.CreatedAt)
+		if err != nil {
+			continue
+		}
+		if now.Sub(created) >= olderThan {
+			result = append(result, token)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+	return result
+}
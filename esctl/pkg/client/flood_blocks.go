@@ -0,0 +1,124 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// floodBlock is the block Elasticsearch sets on indices with a shard on a node over the flood
+// stage disk watermark
+const floodBlock = "read_only_allow_delete"
+
+// floodUnblockBatchSize is the number of indices whose block is cleared in a single request
+const floodUnblockBatchSize = 100
+
+// FloodBlockedIndex is an index carrying the flood stage block, with the disk state of the nodes
+// holding its shards
+type FloodBlockedIndex struct {
+	Index     string
+	Nodes     []string // nodes holding a shard of the index
+	FullNodes []string // of those, nodes still over the high disk watermark
+}
+
+// Recovered reports whether every node holding a shard of the index is back under the high disk
+// watermark, so clearing the block will not have it set again straight away
+func (b FloodBlockedIndex) Recovered() bool {
+	return len(b.FullNodes) == 0
+}
+
+// GetFloodBlockedIndices returns the indices matching pattern that carry the read_only_allow_delete
+// block, sorted by name, with the nodes holding their shards that are still over the high disk
+// watermark
+func (c *Client) GetFloodBlockedIndices(pattern string) ([]FloodBlockedIndex, string, error) {
+	blocks, err := c.GetIndexBlocks(pattern)
+	if err != nil {
+		return nil, "", err
+	}
+
+	blocked := make(map[string]*FloodBlockedIndex)
+	var names []string
+	for _, entry := range blocks {
+		for _, block := range entry.Blocks {
+			if block == floodBlock {
+				blocked[entry.Index] = &FloodBlockedIndex{Index: entry.Index}
+				names = append(names, entry.Index)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, "", nil
+	}
+
+	// Find the high watermark, falling back to the Elasticsearch default
+	watermark := "90%"
+	if value, _, err := c.GetSettingValue("cluster.routing.allocation.disk.watermark.high", true); err == nil {
+		if str, ok := value.(string); ok && str != "" {
+			watermark = str
+		}
+	}
+
+	allocations, err := c.GetDiskAllocations()
+	if err != nil {
+		return nil, "", err
+	}
+	full := make(map[string]bool)
+	for _, alloc := range allocations {
+		limit, err := watermarkLimit(alloc.DiskTotal, watermark)
+		if err != nil {
+			return nil, "", err
+		}
+		if alloc.DiskUsed > limit {
+			full[alloc.Node] = true
+		}
+	}
+
+	// Nodes holding each blocked index, a relocating shard counts for both nodes
+	nodes := make(map[string]map[string]bool)
+	err = c.StreamShards(nil, func(shard ShardInfo) error {
+		if blocked[shard.Index] == nil || shard.Node == "" {
+			return nil
+		}
+		if nodes[shard.Index] == nil {
+			nodes[shard.Index] = make(map[string]bool)
+		}
+		fields := strings.Fields(shard.Node)
+		nodes[shard.Index][fields[0]] = true
+		if len(fields) > 1 {
+			nodes[shard.Index][fields[len(fields)-1]] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	sort.Strings(names)
+	result := make([]FloodBlockedIndex, 0, len(names))
+	for _, name := range names {
+		index := blocked[name]
+		for node := range nodes[name] {
+			index.Nodes = append(index.Nodes, node)
+			if full[node] {
+				index.FullNodes = append(index.FullNodes, node)
+			}
+		}
+		sort.Strings(index.Nodes)
+		sort.Strings(index.FullNodes)
+		result = append(result, *index)
+	}
+
+	return result, watermark, nil
+}
+
+// ClearFloodBlocks removes the read_only_allow_delete block from the given indices, in batches
+func (c *Client) ClearFloodBlocks(indices []string) error {
+	for start := 0; start < len(indices); start += floodUnblockBatchSize {
+		end := min(start+floodUnblockBatchSize, len(indices))
+		batch := strings.Join(indices[start:end], ",")
+		if err := c.UpdateIndexSettings(batch, map[string]interface{}{"index.blocks." + floodBlock: nil}); err != nil {
+			return fmt.Errorf("error clearing blocks on %d indices: %w", end-start, err)
+		}
+	}
+	return nil
+}
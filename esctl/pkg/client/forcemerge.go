@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// IndexSegments is the number and size of the segments of an index, from _cat/segments
+type IndexSegments struct {
+	Index       string
+	ShardCopies int   // primary and replica copies holding segments
+	Segments    int   // segments of every shard copy
+	MaxPerShard int   // segments of the shard copy with the most
+	Size        int64 // bytes of the segments of every shard copy
+}
+
+// ForceMergeResult is the outcome of a force merge task
+type ForceMergeResult struct {
+	Completed bool
+	Running   time.Duration
+	Failed    int    // shards the merge failed on
+	Error     string // reason the task failed, if it did
+}
+
+// GetIndexSegments returns the segments of the indices matching pattern, sorted by index.
+// Indices without segments, such as empty or closed ones, are left out.
+func (c *Client) GetIndexSegments(pattern string) ([]IndexSegments, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cat.Segments(
+		c.es.Cat.Segments.WithContext(ctx),
+		c.es.Cat.Segments.WithIndex(pattern),
+		c.es.Cat.Segments.WithFormat("json"),
+		c.es.Cat.Segments.WithBytes("b"),
+		c.es.Cat.Segments.WithH("index,shard,prirep,id,segment,size"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting segments: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var rows []struct {
+		Index   string `json:"index"`
+		Shard   string `json:"shard"`
+		PriRep  string `json:"prirep"`
+		NodeID  string `json:"id"`
+		Segment string `json:"segment"`
+		Size    string `json:"size"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	byIndex := make(map[string]*IndexSegments)
+	perShard := make(map[string]int)
+	for _, row := range rows {
+		segments, ok := byIndex[row.Index]
+		if !ok {
+			segments = &IndexSegments{Index: row.Index}
+			byIndex[row.Index] = segments
+		}
+		size, _ := strconv.ParseInt(row.Size, 10, 64)
+		segments.Segments++
+		segments.Size += size
+
+		// A shard copy is told apart from the other copies of the shard by its node
+		copyKey := row.Index + "/" + row.Shard + "/" + row.PriRep + "/" + row.NodeID
+		if perShard[copyKey] == 0 {
+			segments.ShardCopies++
+		}
+		perShard[copyKey]++
+		segments.MaxPerShard = max(segments.MaxPerShard, perShard[copyKey])
+	}
+
+	result := make([]IndexSegments, 0, len(byIndex))
+	for _, segments := range byIndex {
+		result = append(result, *segments)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
+
+// GetWriteActiveIndices returns why each of the indices matching pattern is being written to:
+// it is the write index of an alias or data stream, or documents were indexed into it within
+// window. Window 0 leaves out the indexing check.
+func (c *Client) GetWriteActiveIndices(pattern string, window time.Duration) (map[string]string, error) {
+	active := make(map[string]string)
+
+	aliases, err := c.GetAliases("")
+	if err != nil {
+		return nil, err
+	}
+	for alias, index := range AliasWriteIndices(aliases) {
+		active[index] = "write index of alias " + alias
+	}
+
+	dataStreams, err := c.getDataStreamWriteIndices()
+	if err != nil {
+		return nil, err
+	}
+	for name, index := range dataStreams {
+		active[index] = "write index of data stream " + name
+	}
+
+	if window > 0 {
+		traffic, err := c.GetIndexTraffic(pattern, window)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range traffic {
+			if index.Indexed > 0 {
+				if _, ok := active[index.Index]; !ok {
+					active[index.Index] = fmt.Sprintf("%d documents indexed in %s", index.Indexed, window)
+				}
+			}
+		}
+	}
+	return active, nil
+}
+
+// ForceMerge starts a force merge of the indices matching pattern down to at most maxNumSegments
+// segments per shard, 0 to let Elasticsearch decide, and returns the ID of its task. Merging
+// continues on the cluster if the command ends before it completes.
+func (c *Client) ForceMerge(pattern string, maxNumSegments int) (string, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	opts := []func(*esapi.IndicesForcemergeRequest){
+		c.es.Indices.Forcemerge.WithContext(ctx),
+		c.es.Indices.Forcemerge.WithIndex(pattern),
+		c.es.Indices.Forcemerge.WithWaitForCompletion(false),
+	}
+	if maxNumSegments > 0 {
+		opts = append(opts, c.es.Indices.Forcemerge.WithMaxNumSegments(maxNumSegments))
+	}
+	res, err := c.es.Indices.Forcemerge(opts...)
+	if err != nil {
+		return "", fmt.Errorf("error starting force merge: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+	if response.Task == "" {
+		return "", fmt.Errorf("no task returned for the force merge")
+	}
+	return response.Task, nil
+}
+
+// GetForceMergeResult returns the state of a force merge task
+func (c *Client) GetForceMergeResult(taskID string) (*ForceMergeResult, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Tasks.Get(
+		taskID,
+		c.es.Tasks.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting task: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Completed bool `json:"completed"`
+		Task      struct {
+			RunningTimeInNanos int64 `json:"running_time_in_nanos"`
+		} `json:"task"`
+		Response struct {
+			Shards struct {
+				Failed int `json:"failed"`
+			} `json:"_shards"`
+		} `json:"response"`
+		Error *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	result := &ForceMergeResult{
+		Completed: response.Completed,
+		Running:   time.Duration(response.Task.RunningTimeInNanos),
+		Failed:    response.Response.Shards.Failed,
+	}
+	if response.Error != nil {
+		result.Error = response.Error.Type + ": " + response.Error.Reason
+	}
+	return result, nil
+}
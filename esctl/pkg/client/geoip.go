@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GeoIPDatabaseMaxAge is how old a downloaded GeoIP database may get before Elasticsearch stops
+// using it and geoip processors tag documents with _geoip_expired_database
+const GeoIPDatabaseMaxAge = 30 * 24 * time.Hour
+
+// GeoIPDownloaderSetting is the cluster setting that turns the GeoIP database downloader on or off
+const GeoIPDownloaderSetting = "ingest.geoip.downloader.enabled"
+
+// GeoIPStatus is the state of the GeoIP database downloader and the databases on each node
+type GeoIPStatus struct {
+	Enabled             string // value of ingest.geoip.downloader.enabled
+	SuccessfulDownloads int64
+	FailedDownloads     int64
+	SkippedUpdates      int64
+	DatabasesCount      int64
+	ExpiredDatabases    int64
+	TotalDownloadTime   time.Duration
+	Databases           []GeoIPDatabase
+	Nodes               []GeoIPNode
+}
+
+// GeoIPDatabase is a database managed by the downloader
+type GeoIPDatabase struct {
+	Name       string
+	LastUpdate time.Time // zero if never downloaded
+	LastCheck  time.Time
+	Nodes      int // number of nodes that have loaded the database
+}
+
+// Age returns how long ago the database was last updated
+func (d *GeoIPDatabase) Age() time.Duration {
+	if d.LastUpdate.IsZero() {
+		return 0
+	}
+	return time.Since(d.LastUpdate)
+}
+
+// Expired reports whether the database is too old for Elasticsearch to use
+func (d *GeoIPDatabase) Expired() bool {
+	return !d.LastUpdate.IsZero() && d.Age() > GeoIPDatabaseMaxAge
+}
+
+// GeoIPNode lists the GeoIP databases loaded on a node
+type GeoIPNode struct {
+	ID          string
+	Name        string
+	Databases   []string
+	FilesInTemp []string
+}
+
+// GetGeoIPStatus returns the downloader statistics, the age of each downloaded database from the
+// downloader task state, and the databases each node has loaded
+func (c *Client) GetGeoIPStatus() (*GeoIPStatus, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Ingest.GeoIPStats(
+		c.es.Ingest.GeoIPStats.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting GeoIP stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Stats struct {
+			SuccessfulDownloads int64 `json:"successful_downloads"`
+			FailedDownloads     int64 `json:"failed_downloads"`
+			TotalDownloadTime   int64 `json:"total_download_time"`
+			DatabasesCount      int64 `json:"databases_count"`
+			SkippedUpdates      int64 `json:"skipped_updates"`
+			ExpiredDatabases    int64 `json:"expired_databases"`
+		} `json:"stats"`
+		Nodes map[string]struct {
+			Databases []struct {
+				Name string `json:"name"`
+			} `json:"databases"`
+			FilesInTemp []string `json:"files_in_temp"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	status := &GeoIPStatus{
+		SuccessfulDownloads: response.Stats.SuccessfulDownloads,
+		FailedDownloads:     response.Stats.FailedDownloads,
+		SkippedUpdates:      response.Stats.SkippedUpdates,
+		DatabasesCount:      response.Stats.DatabasesCount,
+		ExpiredDatabases:    response.Stats.ExpiredDatabases,
+		TotalDownloadTime:   time.Duration(response.Stats.TotalDownloadTime) * time.Millisecond,
+	}
+
+	// Setting, falling back to the default when it is not set explicitly
+	if value, _, err := c.GetSettingValue(GeoIPDownloaderSetting, true); err == nil {
+		status.Enabled = fmt.Sprintf("%v", value)
+	}
+
+	// Node names for the per-node listing
+	names := make(map[string]string)
+	if members, err := c.getClusterNodes(); err == nil {
+		for _, member := range members {
+			names[member.ID] = member.Name
+		}
+	}
+
+	// Databases loaded on each node
+	databases, err := c.getGeoIPDownloaderDatabases()
+	if err != nil {
+		return nil, err
+	}
+	for id, node := range response.Nodes {
+		geoNode := GeoIPNode{ID: id, Name: names[id], FilesInTemp: node.FilesInTemp}
+		if geoNode.Name == "" {
+			geoNode.Name = id
+		}
+		for _, db := range node.Databases {
+			geoNode.Databases = append(geoNode.Databases, db.Name)
+			if _, ok := databases[db.Name]; !ok {
+				databases[db.Name] = &GeoIPDatabase{Name: db.Name}
+			}
+			databases[db.Name].Nodes++
+		}
+		sort.Strings(geoNode.Databases)
+		status.Nodes = append(status.Nodes, geoNode)
+	}
+	sort.Slice(status.Nodes, func(i, j int) bool {
+		return status.Nodes[i].Name < status.Nodes[j].Name
+	})
+
+	for _, db := range databases {
+		status.Databases = append(status.Databases, *db)
+	}
+	sort.Slice(status.Databases, func(i, j int) bool {
+		return status.Databases[i].Name < status.Databases[j].Name
+	})
+
+	return status, nil
+}
+
+// getGeoIPDownloaderDatabases reads the databases and their update times from the state of the
+// geoip-downloader persistent task. There is no task, and so no databases, when the downloader
+// has never run.
+func (c *Client) getGeoIPDownloaderDatabases() (map[string]*GeoIPDatabase, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cluster.State(
+		c.es.Cluster.State.WithContext(ctx),
+		c.es.Cluster.State.WithMetric("metadata"),
+		c.es.Cluster.State.WithFilterPath("metadata.persistent_tasks.tasks"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster state: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Metadata struct {
+			PersistentTasks struct {
+				Tasks []struct {
+					ID   string `json:"id"`
+					Task map[string]struct {
+						State struct {
+							Databases map[string]struct {
+								LastUpdate int64 `json:"last_update"`
+								LastCheck  int64 `json:"last_check"`
+							} `json:"databases"`
+						} `json:"state"`
+					} `json:"task"`
+				} `json:"tasks"`
+			} `json:"persistent_tasks"`
+		} `json:"metadata"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	databases := make(map[string]*GeoIPDatabase)
+	for _, task := range response.Metadata.PersistentTasks.Tasks {
+		if !strings.HasSuffix(task.ID, "geoip-downloader") {
+			continue
+		}
+		for _, entry := range task.Task {
+			for name, db := range entry.State.Databases {
+				database := &GeoIPDatabase{Name: name}
+				if db.LastUpdate > 0 {
+					database.LastUpdate = time.UnixMilli(db.LastUpdate)
+				}
+				if db.LastCheck > 0 {
+					database.LastCheck = time.UnixMilli(db.LastCheck)
+				}
+				databases[name] = database
+			}
+		}
+	}
+
+	return databases, nil
+}
+
+// SetGeoIPDownloader turns the GeoIP database downloader on or off with a persistent cluster setting
+func (c *Client) SetGeoIPDownloader(enabled bool) error {
+	return c.UpdateClusterSettings("persistent", map[string]interface{}{
+		GeoIPDownloaderSetting: enabled,
+	})
+}
@@ -1,10 +1,9 @@
 package client
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"time"
+	"strconv"
+	"strings"
 )
 
 // NodeJVMStats represents the JVM stats for a node
@@ -24,79 +23,43 @@ type JVMStats struct {
 	NonHeapUsedBytes       int64
 }
 
-// GetNodeJVMStats returns the JVM stats for all nodes in the cluster
+// GetNodeJVMStats returns the JVM stats for all nodes in the cluster. Each node is asked for its
+// stats concurrently; if some nodes fail, the stats of the others are returned together with a
+// PartialNodeError.
 func (c *Client) GetNodeJVMStats() ([]NodeJVMStats, error) {
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Execute request
-	res, err := c.es.Nodes.Stats(
-		c.es.Nodes.Stats.WithContext(ctx),
-		c.es.Nodes.Stats.WithMetric("jvm"),
-	)
+	members, err := c.getClusterNodes()
 	if err != nil {
-		return nil, fmt.Errorf("error getting node JVM stats: %w", err)
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
-	}
-
-	// Parse response
-	var response map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("error parsing response: %w", err)
-	}
-
-	// Extract node stats
-	nodesData, ok := response["nodes"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("unexpected response format: nodes data not found")
+	stats, statsErr := c.getNodeStatsByNode(members, "jvm")
+	if stats == nil {
+		return nil, fmt.Errorf("error getting node JVM stats: %w", statsErr)
 	}
 
 	var nodeStats []NodeJVMStats
-	for nodeID, nodeData := range nodesData {
-		nodeInfo, ok := nodeData.(map[string]interface{})
+	for _, member := range members {
+		nodeInfo, ok := stats[member.ID]
 		if !ok {
 			continue
 		}
 
-		// Extract node name and role
-		name, _ := nodeInfo["name"].(string)
-		
 		// Determine node role
-		var role string
-		roles, ok := nodeInfo["roles"].([]interface{})
-		if ok && len(roles) > 0 {
-			role = fmt.Sprintf("%v", roles[0])
-		} else {
-			role = "unknown"
-		}
-
-		// Extract JVM stats
-		jvmData, ok := nodeInfo["jvm"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		// Extract heap stats
-		memData, ok := jvmData["mem"].(map[string]interface{})
-		if !ok {
-			continue
+		role := "unknown"
+		if len(member.Roles) > 0 {
+			role = member.Roles[0]
 		}
 
-		heapUsedBytes, _ := memData["heap_used_in_bytes"].(float64)
-		heapMaxBytes, _ := memData["heap_max_in_bytes"].(float64)
-		heapUsedPercent, _ := memData["heap_used_percent"].(float64)
-		nonHeapCommittedBytes, _ := memData["non_heap_committed_in_bytes"].(float64)
-		nonHeapUsedBytes, _ := memData["non_heap_used_in_bytes"].(float64)
+		heapUsedBytes, _ := nodeStatFloat(nodeInfo, "jvm", "mem", "heap_used_in_bytes")
+		heapMaxBytes, _ := nodeStatFloat(nodeInfo, "jvm", "mem", "heap_max_in_bytes")
+		heapUsedPercent, _ := nodeStatFloat(nodeInfo, "jvm", "mem", "heap_used_percent")
+		nonHeapCommittedBytes, _ := nodeStatFloat(nodeInfo, "jvm", "mem", "non_heap_committed_in_bytes")
+		nonHeapUsedBytes, _ := nodeStatFloat(nodeInfo, "jvm", "mem", "non_heap_used_in_bytes")
 
 		nodeStats = append(nodeStats, NodeJVMStats{
-			Name: name,
+			Name: member.Name,
 			Role: role,
-			ID:   nodeID,
+			ID:   member.ID,
 			JVMStats: JVMStats{
 				HeapUsedBytes:         int64(heapUsedBytes),
 				HeapMaxBytes:          int64(heapMaxBytes),
@@ -107,7 +70,7 @@ func (c *Client) GetNodeJVMStats() ([]NodeJVMStats, error) {
 		})
 	}
 
-	return nodeStats, nil
+	return nodeStats, statsErr
 }
 
 // ByteCountSI converts bytes to a human-readable string in SI format
@@ -124,3 +87,44 @@ func ByteCountSI(b int64) string {
 	return fmt.Sprintf("%.1f %cB",
 		float64(b)/float64(div), "kMGTPE"[exp])
 }
+
+// ParseByteSize converts an Elasticsearch style byte size (e.g. "500mb", "1.5gb", "1024") to bytes
+func ParseByteSize(size string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(size))
+	if s == "" {
+		return 0, fmt.Errorf("empty byte size")
+	}
+
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"pb", 1 << 50},
+		{"tb", 1 << 40},
+		{"gb", 1 << 30},
+		{"mb", 1 << 20},
+		{"kb", 1 << 10},
+		{"p", 1 << 50},
+		{"t", 1 << 40},
+		{"g", 1 << 30},
+		{"m", 1 << 20},
+		{"k", 1 << 10},
+		{"b", 1},
+	}
+
+	multiplier := float64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			multiplier = u.multiplier
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid byte size: %s", size)
+	}
+
+	return int64(value * multiplier), nil
+}
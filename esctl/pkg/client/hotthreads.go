@@ -24,7 +24,7 @@ func (c *Client) GetHotThreads() (string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return "", fmt.Errorf("error response: %s", res.String())
+		return "", newResponseError(res)
 	}
 
 	// Read the response body
@@ -53,7 +53,7 @@ func (c *Client) GetNodesHotThreads(nodeIDs []string) (string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return "", fmt.Errorf("error response: %s", res.String())
+		return "", newResponseError(res)
 	}
 
 	// Read the response body
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// hotThreadsNode matches the node header, e.g. ::: {node-1}{abc123}{...}
+	hotThreadsNode = regexp.MustCompile(`^:::\s*\{([^}]*)\}`)
+	// hotThreadsThread matches a thread line, e.g. 12.3% [cpu=12.3%, ...] cpu usage by thread 'name'
+	hotThreadsThread = regexp.MustCompile(`usage by thread '(.*)'`)
+	// hotThreadsShared matches the start of a stack shared by several snapshots
+	hotThreadsShared = regexp.MustCompile(`^(\d+)/\d+ snapshots sharing following \d+ elements`)
+	// hotThreadsPool extracts the thread pool from an Elasticsearch thread name, e.g. [search][T#3]
+	hotThreadsPool = regexp.MustCompile(`\[([^\[\]]+)\]\[T#\d+\]`)
+)
+
+// FoldHotThreads converts hot threads output into folded stacks, as read by flamegraph tools,
+// adding the number of snapshots that shared each stack to stacks. Each stack starts with the
+// node and the thread pool (or thread name), followed by the frames from outermost to innermost.
+// Calling it on several captures accumulates the samples.
+func FoldHotThreads(output string, stacks map[string]int) {
+	var node, thread string
+	var frames []string
+	count := 0
+
+	flush := func() {
+		if count > 0 && len(frames) > 0 {
+			parts := []string{node, thread}
+			// Hot threads lists the innermost frame first
+			for i := len(frames) - 1; i >= 0; i-- {
+				parts = append(parts, frames[i])
+			}
+			stacks[strings.Join(parts, ";")] += count
+		}
+		frames = nil
+		count = 0
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case hotThreadsNode.MatchString(trimmed):
+			flush()
+			node = foldFrameName(hotThreadsNode.FindStringSubmatch(trimmed)[1])
+		case hotThreadsThread.MatchString(trimmed):
+			flush()
+			thread = foldThreadName(hotThreadsThread.FindStringSubmatch(trimmed)[1])
+		case hotThreadsShared.MatchString(trimmed):
+			flush()
+			count, _ = strconv.Atoi(hotThreadsShared.FindStringSubmatch(trimmed)[1])
+		case strings.HasPrefix(trimmed, "unique snapshot"):
+			flush()
+			count = 1
+		case trimmed == "":
+			flush()
+		case count > 0:
+			frames = append(frames, foldFrame(trimmed))
+		}
+	}
+	flush()
+}
+
+// WriteFoldedStacks writes folded stacks one per line, sorted so repeated runs are comparable
+func WriteFoldedStacks(w io.Writer, stacks map[string]int) error {
+	keys := make([]string, 0, len(stacks))
+	for stack := range stacks {
+		keys = append(keys, stack)
+	}
+	sort.Strings(keys)
+
+	for _, stack := range keys {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, stacks[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foldFrame reduces a stack frame such as app//org.example.Foo.bar(Foo.java:12) to org.example.Foo.bar
+func foldFrame(frame string) string {
+	if i := strings.Index(frame, "("); i >= 0 {
+		frame = frame[:i]
+	}
+	// Drop the module or class loader prefix, e.g. java.base@21/ or app//
+	if i := strings.LastIndex(frame, "/"); i >= 0 {
+		frame = frame[i+1:]
+	}
+	return foldFrameName(frame)
+}
+
+// foldThreadName groups Elasticsearch threads by their pool, so the threads of a pool share a stack root
+func foldThreadName(name string) string {
+	if m := hotThreadsPool.FindStringSubmatch(name); m != nil {
+		return foldFrameName(m[1])
+	}
+	return foldFrameName(name)
+}
+
+// foldFrameName removes the characters the folded format uses as separators
+func foldFrameName(name string) string {
+	return strings.NewReplacer(";", ":", " ", "_").Replace(name)
+}
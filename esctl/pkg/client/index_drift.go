@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IndexDrift is how an index differs from what its index templates would give it if it were
+// created today
+type IndexDrift struct {
+	Index      string
+	DataStream string // the data stream the index backs, if any
+	Template   string // highest priority template matching the index, "" if none does
+	// Changes are the differing settings and mappings fields: added ones are only on the index,
+	// removed ones only in the template. Before holds the template value, After the index value.
+	Changes []FieldChange
+}
+
+// driftIgnoredSettings are settings Elasticsearch sets on each index when it is created, resized
+// or moved by ILM. Names ending in a dot cover every setting below them.
+var driftIgnoredSettings = []string{
+	"index.uuid",
+	"index.creation_date",
+	"index.provided_name",
+	"index.version.",
+	"index.history.uuid",
+	"index.resize.",
+	"index.shrink.",
+	"index.routing.allocation.initial_recovery.",
+	"index.lifecycle.indexing_complete",
+	"index.time_series.start_time",
+	"index.time_series.end_time",
+	"index.verified_before_close",
+}
+
+// driftDefaultSettings are settings every index carries; when the template does not set them,
+// the index only counts as drifted if it does not have the default value
+var driftDefaultSettings = map[string]string{
+	"index.number_of_shards":   "1",
+	"index.number_of_replicas": "1",
+}
+
+// GetIndexDrift compares the settings and mappings of the indices matching pattern, including
+// the backing indices of matching data streams, with what the index templates of the cluster
+// would give them today, sorted by index. Backing indices are compared with the templates of
+// their data stream.
+//
+// Settings Elasticsearch sets on each index are left out. Mapping fields only on the index are
+// usually added by dynamic mapping, so they are left out unless includeDynamic is set.
+func (c *Client) GetIndexDrift(pattern string, includeDynamic bool) ([]IndexDrift, error) {
+	templates, err := c.getIndexTemplatePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	dataStreams, err := c.getCoverageDataStreams(pattern, "open")
+	if err != nil {
+		return nil, err
+	}
+	backing := make(map[string]string)
+	for _, dataStream := range dataStreams {
+		for _, index := range dataStream.Indices {
+			backing[index.IndexName] = dataStream.Name
+		}
+	}
+
+	settings, mappings, err := c.getDriftSources(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	simulated := make(map[string]map[string]interface{})
+	var result []IndexDrift
+	for _, name := range names {
+		drift := IndexDrift{Index: name, DataStream: backing[name]}
+		target := name
+		if drift.DataStream != "" {
+			target = drift.DataStream
+		}
+		drift.Template = matchIndexTemplate(target, templates)
+		if drift.Template == "" {
+			result = append(result, drift)
+			continue
+		}
+
+		expected, ok := simulated[target]
+		if !ok {
+			response, err := c.SimulateTemplate(target, "", nil)
+			if err != nil {
+				return nil, fmt.Errorf("error simulating templates for %s: %w", target, err)
+			}
+			expected, _ = response["template"].(map[string]interface{})
+			simulated[target] = expected
+		}
+
+		expectedSettings, _ := expected["settings"].(map[string]interface{})
+		expectedMappings, _ := expected["mappings"].(map[string]interface{})
+		changes := DiffSource(
+			driftSource(expectedSettings, expectedMappings),
+			driftSource(settings[name], mappings[name]),
+		)
+		for _, change := range changes {
+			if !driftIgnored(change, drift.DataStream != "", includeDynamic) {
+				drift.Changes = append(drift.Changes, change)
+			}
+		}
+		result = append(result, drift)
+	}
+	return result, nil
+}
+
+// driftSource returns the settings and mappings of an index or template in the form they are
+// compared in, leaving out empty ones
+func driftSource(settings, mappings map[string]interface{}) map[string]interface{} {
+	source := make(map[string]interface{})
+	if normalized := normalizeSettings(settings); len(normalized) > 0 {
+		source["settings"] = normalized
+	}
+	if normalized := withoutEmpty(mappings); len(normalized) > 0 {
+		source["mappings"] = normalized
+	}
+	return source
+}
+
+// driftIgnored reports whether a difference between an index and its templates is left out of
+// the drift report
+func driftIgnored(change FieldChange, backingIndex, includeDynamic bool) bool {
+	if setting, ok := strings.CutPrefix(change.Field, "settings."); ok {
+		for _, ignored := range driftIgnoredSettings {
+			if setting == ignored || (strings.HasSuffix(ignored, ".") && strings.HasPrefix(setting, ignored)) {
+				return true
+			}
+		}
+		if change.Change != "added" {
+			return false
+		}
+		switch {
+		case setting == "index.routing.allocation.include._tier_preference":
+			// Elasticsearch picks a tier preference for each new index
+			return true
+		case setting == "index.hidden":
+			// Backing indices are hidden by Elasticsearch
+			return backingIndex
+		}
+		defaultValue, ok := driftDefaultSettings[setting]
+		return ok && change.After == strconv.Quote(defaultValue)
+	}
+
+	if change.Change != "added" {
+		return false
+	}
+	if strings.HasPrefix(change.Field, "mappings._data_stream_timestamp") {
+		return backingIndex
+	}
+	return !includeDynamic && strings.HasPrefix(change.Field, "mappings.properties.")
+}
+
+// getDriftSources returns the settings and mappings of the indices matching pattern, by index
+func (c *Client) getDriftSources(pattern string) (map[string]map[string]interface{}, map[string]map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex(pattern),
+		c.es.Indices.GetSettings.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil, newResponseError(res)
+	}
+
+	// Parse response
+	var settingsResponse map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&settingsResponse); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	// Execute request
+	mappingRes, err := c.es.Indices.GetMapping(
+		c.es.Indices.GetMapping.WithContext(ctx),
+		c.es.Indices.GetMapping.WithIndex(pattern),
+		c.es.Indices.GetMapping.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting index mappings: %w", err)
+	}
+	defer mappingRes.Body.Close()
+
+	if mappingRes.IsError() {
+		return nil, nil, newResponseError(mappingRes)
+	}
+
+	// Parse response
+	var mappingResponse map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.NewDecoder(mappingRes.Body).Decode(&mappingResponse); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	settings := make(map[string]map[string]interface{}, len(settingsResponse))
+	for name, index := range settingsResponse {
+		settings[name] = index.Settings
+	}
+	mappings := make(map[string]map[string]interface{}, len(mappingResponse))
+	for name, index := range mappingResponse {
+		mappings[name] = index.Mappings
+	}
+	return settings, mappings, nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"sort"
+	"sync"
+)
+
+// indexStateConcurrency is the default number of open or close requests in flight at once
+const indexStateConcurrency = 4
+
+// IndexStateResult is the outcome of opening or closing a single index
+type IndexStateResult struct {
+	Index string
+	Err   error
+}
+
+// GetIndicesToChangeState returns the indices matching a pattern that are not already in the
+// requested state, sorted by name
+func (c *Client) GetIndicesToChangeState(pattern string, open bool) ([]string, error) {
+	indices, err := c.GetIndices(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// _cat/indices reports "open" or "close"
+	target := "close"
+	if open {
+		target = "open"
+	}
+
+	names := make([]string, 0, len(indices))
+	for _, idx := range indices {
+		if idx.Status != target {
+			names = append(names, idx.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// SetIndicesState opens or closes each index with its own request, running up to concurrency
+// requests at once, and returns the result for every index in the order given. A failure on one
+// index does not stop the others.
+func (c *Client) SetIndicesState(indices []string, open bool, concurrency int) []IndexStateResult {
+	if concurrency <= 0 {
+		concurrency = indexStateConcurrency
+	}
+
+	results := make([]IndexStateResult, len(indices))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, index := range indices {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, index string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var err error
+			if open {
+				err = c.OpenIndex(index)
+			} else {
+				err = c.CloseIndex(index)
+			}
+			results[i] = IndexStateResult{Index: index, Err: err}
+		}(i, index)
+	}
+	wg.Wait()
+
+	return results
+}
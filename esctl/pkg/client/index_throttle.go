@@ -0,0 +1,150 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+)
+
+// throttleMetaKey is the key of the mapping _meta where the settings an index had before it was
+// throttled are recorded, so they can be restored exactly from any machine
+const throttleMetaKey = "esctl_throttle"
+
+// IndexThrottle is the change of the throttle settings of one index. A nil value means the
+// setting is not set on the index, so restoring it resets it to its default.
+type IndexThrottle struct {
+	Index    string
+	Previous map[string]interface{} // values before the change
+	Settings map[string]interface{} // values set by the change
+	Skipped  string                 // reason the index was left alone, if it was
+}
+
+// ThrottleIndices applies bulk-load settings to the indices matching name, after recording the
+// values they replace in the mapping _meta of each index. An index that is already throttled
+// keeps the values recorded the first time, so restoring returns it to the state before any
+// throttling. With dryRun nothing is changed.
+func (c *Client) ThrottleIndices(name string, settings map[string]interface{}, dryRun bool) ([]IndexThrottle, error) {
+	current, err := c.getThrottleState(name)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]IndexThrottle, 0, len(current))
+	for _, index := range current {
+		change := IndexThrottle{Index: index.name, Previous: make(map[string]interface{}, len(settings)), Settings: settings}
+		recorded := make(map[string]interface{}, len(settings))
+		for setting, value := range index.recorded {
+			recorded[setting] = value
+		}
+		// Settings not throttled before are recorded with their current values
+		record := false
+		for setting := range settings {
+			change.Previous[setting] = index.settings[setting]
+			if _, ok := recorded[setting]; !ok {
+				recorded[setting] = index.settings[setting]
+				record = true
+			}
+		}
+		if !dryRun {
+			if record {
+				if err := c.setThrottleMeta(index, recorded); err != nil {
+					return changes, fmt.Errorf("error recording settings of %s: %w", index.name, err)
+				}
+			}
+			if err := c.UpdateIndexSettings(index.name, settings); err != nil {
+				return changes, fmt.Errorf("error throttling %s: %w", index.name, err)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// RestoreThrottledIndices puts back the settings recorded when the indices matching name were
+// throttled and removes the record. Indices that were not throttled are reported as skipped.
+// With dryRun nothing is changed.
+func (c *Client) RestoreThrottledIndices(name string, dryRun bool) ([]IndexThrottle, error) {
+	current, err := c.getThrottleState(name)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]IndexThrottle, 0, len(current))
+	for _, index := range current {
+		change := IndexThrottle{Index: index.name, Settings: index.recorded}
+		if index.recorded == nil {
+			change.Skipped = "not throttled"
+			changes = append(changes, change)
+			continue
+		}
+		change.Previous = make(map[string]interface{}, len(index.recorded))
+		for setting := range index.recorded {
+			change.Previous[setting] = index.settings[setting]
+		}
+		if !dryRun {
+			if err := c.UpdateIndexSettings(index.name, index.recorded); err != nil {
+				return changes, fmt.Errorf("error restoring settings of %s: %w", index.name, err)
+			}
+			if err := c.setThrottleMeta(index, nil); err != nil {
+				return changes, fmt.Errorf("error removing recorded settings of %s: %w", index.name, err)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// throttleState is the current settings, mapping _meta and recorded throttle settings of an index
+type throttleState struct {
+	name     string
+	settings map[string]interface{}
+	meta     map[string]interface{}
+	recorded map[string]interface{} // nil when the index is not throttled
+}
+
+// getThrottleState returns the throttle state of the indices matching name, sorted by index
+func (c *Client) getThrottleState(name string) ([]throttleState, error) {
+	settings, err := c.GetIndexSettings(name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	mappings, err := c.GetIndexMappings(name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index mappings: %w", err)
+	}
+
+	states := make([]throttleState, 0, len(settings))
+	for index, value := range settings {
+		state := throttleState{name: index, settings: map[string]interface{}{}}
+		if entry, ok := value.(map[string]interface{}); ok {
+			if s, ok := entry["settings"].(map[string]interface{}); ok {
+				state.settings = s
+			}
+		}
+		if entry, ok := mappings[index].(map[string]interface{}); ok {
+			if m, ok := entry["mappings"].(map[string]interface{}); ok {
+				state.meta, _ = m["_meta"].(map[string]interface{})
+			}
+		}
+		state.recorded, _ = state.meta[throttleMetaKey].(map[string]interface{})
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].name < states[j].name
+	})
+	return states, nil
+}
+
+// setThrottleMeta records the settings to restore in the mapping _meta of an index, or removes
+// the record when previous is nil. The rest of the _meta is kept, as an update replaces it whole.
+func (c *Client) setThrottleMeta(index throttleState, previous map[string]interface{}) error {
+	meta := make(map[string]interface{}, len(index.meta)+1)
+	for key, value := range index.meta {
+		meta[key] = value
+	}
+	if previous == nil {
+		delete(meta, throttleMetaKey)
+	} else {
+		meta[throttleMetaKey] = previous
+	}
+	return c.PutIndexMapping(index.name, map[string]interface{}{"_meta": meta})
+}
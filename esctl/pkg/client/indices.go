@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -44,7 +45,7 @@ func (c *Client) GetIndices(pattern string) ([]IndexInfo, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -56,6 +57,24 @@ func (c *Client) GetIndices(pattern string) ([]IndexInfo, error) {
 	return indices, nil
 }
 
+// GetIndexNames returns the names of the indices matching a pattern, for completion and name
+// resolution. The list is cached, so repeated lookups in a command do not refetch it.
+func (c *Client) GetIndexNames(pattern string) ([]string, error) {
+	return cachedLookup(c.cache, "index_names:"+pattern, func() ([]string, error) {
+		indices, err := c.GetIndices(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(indices))
+		for _, idx := range indices {
+			names = append(names, idx.Name)
+		}
+		sort.Strings(names)
+		return names, nil
+	})
+}
+
 // DeleteIndex deletes an index from the cluster
 func (c *Client) DeleteIndex(indexName string) error {
 	// Create context with timeout
@@ -73,9 +92,36 @@ func (c *Client) DeleteIndex(indexName string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
+	}
+
+	c.cache.invalidate("index_names")
+
+	return nil
+}
+
+// DeleteDataStream deletes a data stream with its backing indices
+func (c *Client) DeleteDataStream(name string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.DeleteDataStream(
+		[]string{name},
+		c.es.Indices.DeleteDataStream.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting data stream: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
 	}
 
+	c.cache.invalidate("index_names")
+
 	return nil
 }
 
@@ -96,7 +142,7 @@ func (c *Client) OpenIndex(indexName string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -119,7 +165,7 @@ func (c *Client) CloseIndex(indexName string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -143,7 +189,7 @@ func (c *Client) GetIndexSettings(indexName string) (map[string]interface{}, err
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -179,7 +225,7 @@ func (c *Client) UpdateIndexSettings(indexName string, settings map[string]inter
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -0,0 +1,325 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PipelineReference represents an index, template or pipeline that references an ingest pipeline
+type PipelineReference struct {
+	SourceType string // index, index template, component template or pipeline
+	Source     string
+	Setting    string // default_pipeline, final_pipeline or pipeline processor
+	Pipeline   string
+}
+
+// PipelineUsage represents an ingest pipeline and everything that references it
+type PipelineUsage struct {
+	Name       string
+	References []PipelineReference
+}
+
+// IngestUsageReport cross-references ingest pipelines against their users
+type IngestUsageReport struct {
+	Pipelines []PipelineUsage
+	Missing   []PipelineReference // references to pipelines that do not exist
+}
+
+// GetPipelines returns the definitions of all ingest pipelines
+func (c *Client) GetPipelines() (map[string]map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Ingest.GetPipeline(
+		c.es.Ingest.GetPipeline.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pipelines: %w", err)
+	}
+	defer res.Body.Close()
+
+	// No pipelines defined
+	if res.StatusCode == 404 {
+		return map[string]map[string]interface{}{}, nil
+	}
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var pipelines map[string]map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&pipelines); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return pipelines, nil
+}
+
+// GetIngestUsage cross-references pipelines against index settings, index and component
+// templates and pipeline processors, reporting unused pipelines and missing references
+func (c *Client) GetIngestUsage() (*IngestUsageReport, error) {
+	pipelines, err := c.GetPipelines()
+	if err != nil {
+		return nil, err
+	}
+
+	var references []PipelineReference
+
+	// Pipelines calling other pipelines
+	for name, pipeline := range pipelines {
+		for _, target := range pipelineProcessorTargets(pipeline) {
+			references = append(references, PipelineReference{
+				SourceType: "pipeline",
+				Source:     name,
+				Setting:    "pipeline processor",
+				Pipeline:   target,
+			})
+		}
+	}
+
+	// Index settings
+	indexRefs, err := c.getIndexPipelineReferences()
+	if err != nil {
+		return nil, err
+	}
+	references = append(references, indexRefs...)
+
+	// Index and component templates
+	templateRefs, err := c.getTemplatePipelineReferences()
+	if err != nil {
+		return nil, err
+	}
+	references = append(references, templateRefs...)
+
+	componentRefs, err := c.getComponentTemplatePipelineReferences()
+	if err != nil {
+		return nil, err
+	}
+	references = append(references, componentRefs...)
+
+	// Group references by pipeline
+	report := &IngestUsageReport{}
+	byPipeline := make(map[string][]PipelineReference)
+	for _, ref := range references {
+		if _, ok := pipelines[ref.Pipeline]; !ok {
+			report.Missing = append(report.Missing, ref)
+			continue
+		}
+		byPipeline[ref.Pipeline] = append(byPipeline[ref.Pipeline], ref)
+	}
+
+	for name := range pipelines {
+		refs := byPipeline[name]
+		sort.Slice(refs, func(i, j int) bool {
+			return refs[i].Source < refs[j].Source
+		})
+		report.Pipelines = append(report.Pipelines, PipelineUsage{Name: name, References: refs})
+	}
+	sort.Slice(report.Pipelines, func(i, j int) bool {
+		return report.Pipelines[i].Name < report.Pipelines[j].Name
+	})
+	sort.Slice(report.Missing, func(i, j int) bool {
+		if report.Missing[i].Pipeline != report.Missing[j].Pipeline {
+			return report.Missing[i].Pipeline < report.Missing[j].Pipeline
+		}
+		return report.Missing[i].Source < report.Missing[j].Source
+	})
+
+	return report, nil
+}
+
+// getIndexPipelineReferences returns the default and final pipelines set on every index
+func (c *Client) getIndexPipelineReferences() ([]PipelineReference, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request, including hidden data stream backing indices
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex("*"),
+		c.es.Indices.GetSettings.WithName("index.default_pipeline", "index.final_pipeline"),
+		c.es.Indices.GetSettings.WithExpandWildcards("all"),
+		c.es.Indices.GetSettings.WithFlatSettings(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var references []PipelineReference
+	for index, settings := range response {
+		references = append(references, pipelineSettingReferences("index", index, settings.Settings)...)
+	}
+
+	return references, nil
+}
+
+// getTemplatePipelineReferences returns the default and final pipelines set by index templates
+func (c *Client) getTemplatePipelineReferences() ([]PipelineReference, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetIndexTemplate(
+		c.es.Indices.GetIndexTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index templates: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var indexTemplates struct {
+		IndexTemplates []struct {
+			Name          string `json:"name"`
+			IndexTemplate struct {
+				Template struct {
+					Settings map[string]interface{} `json:"settings"`
+				} `json:"template"`
+			} `json:"index_template"`
+		} `json:"index_templates"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&indexTemplates); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var references []PipelineReference
+	for _, tmpl := range indexTemplates.IndexTemplates {
+		references = append(references, pipelineSettingReferences("index template", tmpl.Name, tmpl.IndexTemplate.Template.Settings)...)
+	}
+
+	return references, nil
+}
+
+// getComponentTemplatePipelineReferences returns the default and final pipelines set by component templates
+func (c *Client) getComponentTemplatePipelineReferences() ([]PipelineReference, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cluster.GetComponentTemplate(
+		c.es.Cluster.GetComponentTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting component templates: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var componentTemplates struct {
+		ComponentTemplates []struct {
+			Name              string `json:"name"`
+			ComponentTemplate struct {
+				Template struct {
+					Settings map[string]interface{} `json:"settings"`
+				} `json:"template"`
+			} `json:"component_template"`
+		} `json:"component_templates"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&componentTemplates); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var references []PipelineReference
+	for _, tmpl := range componentTemplates.ComponentTemplates {
+		references = append(references, pipelineSettingReferences("component template", tmpl.Name, tmpl.ComponentTemplate.Template.Settings)...)
+	}
+
+	return references, nil
+}
+
+// pipelineSettingReferences extracts default_pipeline and final_pipeline from flat or nested settings
+func pipelineSettingReferences(sourceType, source string, settings map[string]interface{}) []PipelineReference {
+	var references []PipelineReference
+	for _, setting := range []string{"default_pipeline", "final_pipeline"} {
+		value, ok := settings["index."+setting]
+		if !ok {
+			if index, isMap := settings["index"].(map[string]interface{}); isMap {
+				value, ok = index[setting]
+			}
+		}
+
+		pipeline, _ := value.(string)
+		if !ok || pipeline == "" || pipeline == "_none" {
+			continue
+		}
+
+		references = append(references, PipelineReference{
+			SourceType: sourceType,
+			Source:     source,
+			Setting:    setting,
+			Pipeline:   pipeline,
+		})
+	}
+	return references
+}
+
+// pipelineProcessorTargets returns the names of pipelines invoked by pipeline processors,
+// including those nested in on_failure handlers
+func pipelineProcessorTargets(pipeline map[string]interface{}) []string {
+	var targets []string
+
+	var walk func(processors []interface{})
+	walk = func(processors []interface{}) {
+		for _, p := range processors {
+			processor, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for processorType, body := range processor {
+				config, ok := body.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if processorType == "pipeline" {
+					// Templated names are resolved per document and cannot be checked
+					if name, ok := config["name"].(string); ok && name != "" && !strings.Contains(name, "{{") {
+						targets = append(targets, name)
+					}
+				}
+				if onFailure, ok := config["on_failure"].([]interface{}); ok {
+					walk(onFailure)
+				}
+			}
+		}
+	}
+
+	if processors, ok := pipeline["processors"].([]interface{}); ok {
+		walk(processors)
+	}
+	if onFailure, ok := pipeline["on_failure"].([]interface{}); ok {
+		walk(onFailure)
+	}
+
+	return targets
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FieldChange is a field of a document that an ingest pipeline added, removed or changed
+type FieldChange struct {
+	Field  string
+	Change string // added, removed or changed
+	Before string // JSON encoded value before the pipeline, empty when added
+	After  string // JSON encoded value after the pipeline, empty when removed
+}
+
+// SimulatedDocument is the outcome of running a document through an ingest pipeline
+type SimulatedDocument struct {
+	Index   string
+	ID      string
+	Error   string // reason the pipeline failed on the document, empty on success
+	Dropped bool   // the pipeline dropped the document with a drop processor
+	Changes []FieldChange
+}
+
+// SimulatePipeline runs documents through an existing ingest pipeline with the simulate API,
+// without indexing them, and returns the fields the pipeline changed in each document. The
+// index a pipeline reroutes a document to shows as a change of _index.
+func (c *Client) SimulatePipeline(pipeline string, docs []SampleDocument) ([]SimulatedDocument, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"docs": docs}); err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Ingest.Simulate(
+		&buf,
+		c.es.Ingest.Simulate.WithContext(ctx),
+		c.es.Ingest.Simulate.WithPipelineID(pipeline),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error simulating pipeline: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response, a document the pipeline dropped is null
+	var response struct {
+		Docs []*struct {
+			Doc   *SampleDocument `json:"doc"`
+			Error *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"docs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(response.Docs) != len(docs) {
+		return nil, fmt.Errorf("simulate returned %d documents for %d sent", len(response.Docs), len(docs))
+	}
+
+	results := make([]SimulatedDocument, len(docs))
+	for i, doc := range docs {
+		result := SimulatedDocument{Index: doc.Index, ID: doc.ID}
+		simulated := response.Docs[i]
+
+		switch {
+		case simulated == nil || (simulated.Doc == nil && simulated.Error == nil):
+			result.Dropped = true
+		case simulated.Error != nil:
+			result.Error = simulated.Error.Type + ": " + simulated.Error.Reason
+		default:
+			result.Changes = DiffSource(doc.Source, simulated.Doc.Source)
+			if simulated.Doc.Index != "" && simulated.Doc.Index != doc.Index {
+				result.Changes = append([]FieldChange{{
+					Field:  "_index",
+					Change: "changed",
+					Before: fmt.Sprintf("%q", doc.Index),
+					After:  fmt.Sprintf("%q", simulated.Doc.Index),
+				}}, result.Changes...)
+			}
+		}
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+// DiffSource returns the fields that differ between two versions of a document source, sorted
+// by dotted path. Arrays are compared as a whole.
+func DiffSource(before, after map[string]interface{}) []FieldChange {
+	beforeFields := make(map[string]string)
+	flattenSource("", before, beforeFields)
+	afterFields := make(map[string]string)
+	flattenSource("", after, afterFields)
+
+	var changes []FieldChange
+	for field, value := range afterFields {
+		previous, ok := beforeFields[field]
+		switch {
+		case !ok:
+			changes = append(changes, FieldChange{Field: field, Change: "added", After: value})
+		case previous != value:
+			changes = append(changes, FieldChange{Field: field, Change: "changed", Before: previous, After: value})
+		}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, Change: "removed", Before: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flattenSource adds the leaf values of a document source to result by dotted path, JSON encoded
+func flattenSource(prefix string, value interface{}, result map[string]string) {
+	if object, ok := value.(map[string]interface{}); ok && (prefix == "" || len(object) > 0) {
+		for key, child := range object {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenSource(path, child, result)
+		}
+		return
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%v", value))
+	}
+	result[prefix] = string(encoded)
+}
@@ -0,0 +1,271 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+)
+
+// Kinds of JVM capture
+const (
+	CaptureHeapDump = "heap-dump"
+	CaptureJFR      = "jfr"
+)
+
+// Defaults for the diagnostics section of the configuration
+const (
+	defaultCaptureJcmd      = "/usr/share/elasticsearch/jdk/bin/jcmd"
+	defaultCaptureTempDir   = "/tmp"
+	defaultCaptureContainer = "elasticsearch"
+)
+
+// NodeProcess is a node with the host and process ID of its JVM
+type NodeProcess struct {
+	ID   string
+	Name string
+	Host string
+	IP   string
+	PID  int
+}
+
+// NodeExecutor runs a shell command on the host of a node, writing its standard output to stdout
+type NodeExecutor interface {
+	Run(node NodeProcess, command string, stdout io.Writer) error
+}
+
+// NewNodeExecutor returns the executor set in the diagnostics section of the configuration
+func NewNodeExecutor(cfg config.DiagnosticsConfig) (NodeExecutor, error) {
+	switch cfg.Executor {
+	case "ssh":
+		return &sshExecutor{user: cfg.SSHUser}, nil
+	case "kubectl":
+		container := cfg.Container
+		if container == "" {
+			container = defaultCaptureContainer
+		}
+		return &kubectlExecutor{namespace: cfg.Namespace, container: container}, nil
+	case "command":
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("diagnostics.command must be set for the command executor")
+		}
+		return &commandExecutor{args: cfg.Command}, nil
+	case "":
+		return nil, fmt.Errorf("no executor is configured to reach the nodes, set diagnostics.executor to ssh, kubectl or command")
+	default:
+		return nil, fmt.Errorf("unknown diagnostics executor %q, expected ssh, kubectl or command", cfg.Executor)
+	}
+}
+
+// sshExecutor runs commands over SSH on the publish host of the node, using the user's SSH
+// configuration and agent
+type sshExecutor struct {
+	user string
+}
+
+// Run implements NodeExecutor
+func (e *sshExecutor) Run(node NodeProcess, command string, stdout io.Writer) error {
+	destination := node.Host
+	if destination == "" {
+		destination = node.IP
+	}
+	if e.user != "" {
+		destination = e.user + "@" + destination
+	}
+	return runExecutor("ssh", []string{"-o", "BatchMode=yes", destination, command}, stdout)
+}
+
+// kubectlExecutor runs commands in the pod of the node, which ECK names after the node
+type kubectlExecutor struct {
+	namespace string
+	container string
+}
+
+// Run implements NodeExecutor
+func (e *kubectlExecutor) Run(node NodeProcess, command string, stdout io.Writer) error {
+	args := []string{"exec"}
+	if e.namespace != "" {
+		args = append(args, "--namespace", e.namespace)
+	}
+	args = append(args, node.Name, "--container", e.container, "--", "sh", "-c", command)
+	return runExecutor("kubectl", args, stdout)
+}
+
+// commandExecutor runs a configured program, such as a cloud provider's remote shell, with the
+// shell command as its last argument
+type commandExecutor struct {
+	args []string
+}
+
+// Run implements NodeExecutor
+func (e *commandExecutor) Run(node NodeProcess, command string, stdout io.Writer) error {
+	replacer := strings.NewReplacer("{node}", node.Name, "{host}", node.Host, "{ip}", node.IP)
+	args := make([]string, 0, len(e.args))
+	for _, arg := range e.args[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	return runExecutor(e.args[0], append(args, command), stdout)
+}
+
+// runExecutor runs a program, reporting its standard error if it fails
+func runExecutor(program string, args []string, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(program, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%s failed: %w: %s", program, err, message)
+		}
+		return fmt.Errorf("%s failed: %w", program, err)
+	}
+	return nil
+}
+
+// GetNodeProcess returns the host and JVM process ID of the node with the given ID or name
+func (c *Client) GetNodeProcess(node string) (*NodeProcess, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Nodes.Info(
+		c.es.Nodes.Info.WithContext(ctx),
+		c.es.Nodes.Info.WithNodeID(node),
+		c.es.Nodes.Info.WithMetric("jvm"),
+		c.es.Nodes.Info.WithFilterPath("nodes.*.name", "nodes.*.host", "nodes.*.ip", "nodes.*.jvm.pid"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node info: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]struct {
+			Name string `json:"name"`
+			Host string `json:"host"`
+			IP   string `json:"ip"`
+			JVM  struct {
+				PID int `json:"pid"`
+			} `json:"jvm"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	if len(response.Nodes) != 1 {
+		return nil, fmt.Errorf("%q matches %d nodes, give a single node ID or name", node, len(response.Nodes))
+	}
+	var process *NodeProcess
+	for id, info := range response.Nodes {
+		process = &NodeProcess{ID: id, Name: info.Name, Host: info.Host, IP: info.IP, PID: info.JVM.PID}
+	}
+	return process, nil
+}
+
+// JVMCapture collects heap dumps and flight recordings from the JVM of a node with jcmd, run on
+// the node's host through an executor
+type JVMCapture struct {
+	executor NodeExecutor
+	jcmd     string
+	tempDir  string
+	runAs    string
+}
+
+// NewJVMCapture returns a JVMCapture configured from the diagnostics section of the configuration
+func NewJVMCapture(cfg config.DiagnosticsConfig) (*JVMCapture, error) {
+	executor, err := NewNodeExecutor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	capture := &JVMCapture{executor: executor, jcmd: cfg.Jcmd, tempDir: cfg.TempDir, runAs: cfg.RunAs}
+	if capture.jcmd == "" {
+		capture.jcmd = defaultCaptureJcmd
+	}
+	if capture.tempDir == "" {
+		capture.tempDir = defaultCaptureTempDir
+	}
+	return capture, nil
+}
+
+// Capture takes a heap dump of the JVM of a node, or records it with Java Flight Recorder for
+// duration, and copies the file to out. The file is written to the temporary directory of the
+// node first and removed from it afterwards, whether the capture succeeded or not.
+func (j *JVMCapture) Capture(node NodeProcess, kind string, duration time.Duration, out io.Writer) error {
+	if node.PID == 0 {
+		return fmt.Errorf("node %s did not report its JVM process ID", node.Name)
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	var remote string
+	switch kind {
+	case CaptureHeapDump:
+		remote = path.Join(j.tempDir, fmt.Sprintf("esctl-%s-%s.hprof", node.Name, stamp))
+		defer j.run(node, "rm -f "+shellQuote(remote), io.Discard)
+		if err := j.jcmdRun(node, "Heap dump file created", "GC.heap_dump", remote); err != nil {
+			return fmt.Errorf("error taking heap dump: %w", err)
+		}
+	case CaptureJFR:
+		remote = path.Join(j.tempDir, fmt.Sprintf("esctl-%s-%s.jfr", node.Name, stamp))
+		defer j.run(node, "rm -f "+shellQuote(remote), io.Discard)
+		recording := "esctl-" + stamp
+		if err := j.jcmdRun(node, "Started recording", "JFR.start", "name="+recording, "settings=profile"); err != nil {
+			return fmt.Errorf("error starting flight recording: %w", err)
+		}
+		time.Sleep(duration)
+		if err := j.jcmdRun(node, "Stopped recording", "JFR.stop", "name="+recording, "filename="+remote); err != nil {
+			return fmt.Errorf("error stopping flight recording %s: %w", recording, err)
+		}
+	default:
+		return fmt.Errorf("unknown capture type %q, expected %s or %s", kind, CaptureHeapDump, CaptureJFR)
+	}
+
+	if err := j.run(node, "cat "+shellQuote(remote), out); err != nil {
+		return fmt.Errorf("error copying %s from %s: %w", remote, node.Name, err)
+	}
+	return nil
+}
+
+// jcmdRun runs a jcmd diagnostic command against the JVM of a node. jcmd exits successfully even
+// when the command fails, so its output is checked for the text a success prints.
+func (j *JVMCapture) jcmdRun(node NodeProcess, success string, args ...string) error {
+	command := shellQuote(j.jcmd) + " " + fmt.Sprintf("%d", node.PID)
+	for _, arg := range args {
+		command += " " + shellQuote(arg)
+	}
+
+	var output bytes.Buffer
+	if err := j.run(node, command, &output); err != nil {
+		return err
+	}
+	if !strings.Contains(output.String(), success) {
+		return fmt.Errorf("jcmd: %s", strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// run runs a shell command on the host of a node, as the configured user if there is one
+func (j *JVMCapture) run(node NodeProcess, command string, stdout io.Writer) error {
+	if j.runAs != "" {
+		command = "sudo -n -u " + shellQuote(j.runAs) + " sh -c " + shellQuote(command)
+	}
+	return j.executor.Run(node, command, stdout)
+}
+
+// shellQuote quotes a word for a POSIX shell
+func shellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
@@ -1,12 +1,10 @@
 package client
 
 import (
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
@@ -15,9 +13,11 @@ import (
 // KibanaClient wraps HTTP client with Kibana-specific methods
 type KibanaClient struct {
 	httpClient *http.Client
-	baseURL    string
+	baseURL    string // address scoped to the configured space
+	address    string // Kibana address, for requests that name their space explicitly
 	username   string
 	password   string
+	cache      *lookupCache
 }
 
 // NewKibana creates a new Kibana client
@@ -26,46 +26,49 @@ func NewKibana(cfg *config.Config) (*KibanaClient, error) {
 		return nil, fmt.Errorf("no Kibana addresses provided")
 	}
 
-	// Configure TLS
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{},
+	// Configure TLS, compression and connection reuse
+	transport, err := newHTTPTransport(cfg.Kibana.Transport, cfg.Kibana.CACert, cfg.Kibana.Insecure)
+	if err != nil {
+		return nil, err
 	}
 
-	// If insecure mode is enabled, skip certificate verification
-	if cfg.Kibana.Insecure {
-		transport.TLSClientConfig.InsecureSkipVerify = true
+	// Reach unix sockets and addresses behind an SSH jump host
+	endpoints, err := configureEndpoints(transport, cfg.Kibana.Addresses, cfg.Kibana.SSHTunnel)
+	if err != nil {
+		return nil, err
 	}
 
-	// If CA cert is provided, use it for verification (unless insecure mode is enabled)
-	if cfg.Kibana.CACert != "" && !cfg.Kibana.Insecure {
-		caCert, err := ioutil.ReadFile(cfg.Kibana.CACert)
-		if err != nil {
-			return nil, fmt.Errorf("reading CA cert: %w", err)
-		}
+	// Create HTTP client with timeout
+	httpClient := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	}
 
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM(caCert) {
-			return nil, fmt.Errorf("failed to parse CA certificate")
-		}
+	if cfg.Output.Timings {
+		httpClient.Transport = newTimingTransport(httpClient.Transport)
+	}
 
-		transport.TLSClientConfig.RootCAs = caCertPool
+	if cfg.Kibana.Transport.CompressRequests {
+		httpClient.Transport = &gzipRequestTransport{next: httpClient.Transport}
 	}
 
-	// Create HTTP client with timeout
-	httpClient := &http.Client{
-		Timeout: 10 * time.Second,
+	// Back off when Kibana rate limits requests with 429
+	if cfg.Kibana.Transport.Max429Retries > 0 {
+		httpClient.Transport = &retryTransport{next: httpClient.Transport, maxRetries: cfg.Kibana.Transport.Max429Retries}
 	}
 
-	// Set the transport if we've configured TLS options
-	if cfg.Kibana.Insecure || cfg.Kibana.CACert != "" {
-		httpClient.Transport = transport
+	cache, err := newLookupCache(cfg.Cache, cfg.Kibana.Addresses, cfg.Kibana.Username)
+	if err != nil {
+		return nil, err
 	}
 
 	return &KibanaClient{
 		httpClient: httpClient,
-		baseURL:    cfg.Kibana.Addresses[0],
+		baseURL:    strings.TrimSuffix(endpoints[0], "/") + spacePath(cfg.Kibana.Space),
+		address:    strings.TrimSuffix(endpoints[0], "/"),
 		username:   cfg.Kibana.Username,
 		password:   cfg.Kibana.Password,
+		cache:      cache,
 	}, nil
 }
 
@@ -91,7 +94,7 @@ func (c *KibanaClient) Ping() (map[string]interface{}, error) {
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse response
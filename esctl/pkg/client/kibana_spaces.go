@@ -0,0 +1,365 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SpaceExportTypes are the saved object types exported from a space when no types are given.
+// Types the Kibana version does not know or cannot export are dropped and the export retried.
+var SpaceExportTypes = []string{
+	"config", "config-global", "url", "index-pattern", "query", "tag",
+	"search", "visualization", "lens", "map", "dashboard", "links",
+	"canvas-workpad", "canvas-element", "graph-workspace", "event-annotation-group",
+	"action", "alert", "cases", "osquery-saved-query", "osquery-pack",
+	"infrastructure-ui-source", "metrics-explorer-view", "inventory-view",
+	"synthetics-monitor", "uptime-dynamic-settings",
+}
+
+// nonExportableTypes matches the error Kibana returns when an export asks for types it cannot export
+var nonExportableTypes = regexp.MustCompile(`(?i)non-exportable type\(s\):\s*(.+)$`)
+
+// KibanaSpace is a Kibana space definition, as returned and accepted by the spaces API
+type KibanaSpace struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	Color            string   `json:"color,omitempty"`
+	Initials         string   `json:"initials,omitempty"`
+	ImageURL         string   `json:"imageUrl,omitempty"`
+	DisabledFeatures []string `json:"disabledFeatures,omitempty"`
+	Solution         string   `json:"solution,omitempty"`
+}
+
+// SpaceExport is a full export of the saved objects in a space
+type SpaceExport struct {
+	NDJSON            []byte         // exported objects followed by the export details line
+	Types             []string       // types that were exported
+	SkippedTypes      []string       // requested types Kibana could not export
+	ObjectCounts      map[string]int // exported objects per type
+	ExportedCount     int
+	MissingReferences []ObjectReference
+}
+
+// SpaceImportResult is the outcome of importing saved objects into a space
+type SpaceImportResult struct {
+	Success      bool
+	SuccessCount int
+	Errors       []SpaceImportError
+}
+
+// SpaceImportError is an object that could not be imported
+type SpaceImportError struct {
+	ID    string
+	Type  string
+	Title string
+	Error string // error type, e.g. conflict or missing_references
+}
+
+// spacePath returns the URL prefix that scopes an API request to a space
+func spacePath(space string) string {
+	if space == "" || space == "default" {
+		return ""
+	}
+	return "/s/" + url.PathEscape(space)
+}
+
+// ListSpaces returns the spaces the user can see
+func (c *KibanaClient) ListSpaces() ([]KibanaSpace, error) {
+	var spaces []KibanaSpace
+	if err := c.kibanaURLRequest("GET", c.address+"/api/spaces/space", nil, &spaces); err != nil {
+		return nil, err
+	}
+	return spaces, nil
+}
+
+// GetSpace returns the definition of a space
+func (c *KibanaClient) GetSpace(id string) (*KibanaSpace, error) {
+	requestURL := fmt.Sprintf("%s/api/spaces/space/%s", c.address, url.PathEscape(id))
+
+	// Create the request
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse the response
+	var space KibanaSpace
+	if err := json.NewDecoder(resp.Body).Decode(&space); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return &space, nil
+}
+
+// CreateSpace creates a space from a definition
+func (c *KibanaClient) CreateSpace(space KibanaSpace) error {
+	bodyBytes, err := json.Marshal(space)
+	if err != nil {
+		return fmt.Errorf("error marshaling request body: %w", err)
+	}
+
+	// Create the request
+	req, err := http.NewRequest("POST", c.address+"/api/spaces/space", bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPError(resp)
+	}
+
+	return nil
+}
+
+// ExportSpace exports every saved object of the given types in a space, with the objects they
+// reference. Types Kibana reports as not exportable are skipped and listed in the result.
+func (c *KibanaClient) ExportSpace(space string, types []string) (*SpaceExport, error) {
+	export := &SpaceExport{Types: append([]string(nil), types...)}
+
+	for len(export.Types) > 0 {
+		data, err := c.exportSpaceTypes(space, export.Types)
+		if err == nil {
+			export.NDJSON = data
+			break
+		}
+
+		// Drop the types this Kibana cannot export and try again
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			return nil, err
+		}
+		m := nonExportableTypes.FindStringSubmatch(apiErr.Reason)
+		if m == nil {
+			return nil, err
+		}
+		skip := make(map[string]bool)
+		for _, t := range strings.Split(m[1], ",") {
+			skip[strings.TrimSpace(t)] = true
+		}
+		var remaining []string
+		for _, t := range export.Types {
+			if skip[t] {
+				export.SkippedTypes = append(export.SkippedTypes, t)
+			} else {
+				remaining = append(remaining, t)
+			}
+		}
+		if len(remaining) == len(export.Types) {
+			return nil, err
+		}
+		export.Types = remaining
+	}
+
+	if len(export.Types) == 0 {
+		return nil, fmt.Errorf("none of the requested types can be exported")
+	}
+
+	// Count the objects and read the export details from the last line
+	export.ObjectCounts = make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(export.NDJSON))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Type              string            `json:"type"`
+			ExportedCount     *int              `json:"exportedCount"`
+			MissingReferences []ObjectReference `json:"missingReferences"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("error parsing export: %w", err)
+		}
+		if line.ExportedCount != nil {
+			export.ExportedCount = *line.ExportedCount
+			export.MissingReferences = line.MissingReferences
+			continue
+		}
+		export.ObjectCounts[line.Type]++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading export: %w", err)
+	}
+
+	return export, nil
+}
+
+// exportSpaceTypes calls the saved objects export API of a space for the given types
+func (c *KibanaClient) exportSpaceTypes(space string, types []string) ([]byte, error) {
+	requestURL := fmt.Sprintf("%s%s/api/saved_objects/_export", c.address, spacePath(space))
+
+	requestBody := map[string]interface{}{
+		"type":                  types,
+		"includeReferencesDeep": true,
+		"excludeExportDetails":  false,
+	}
+
+	// Convert request body to JSON
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request body: %w", err)
+	}
+
+	// Create the request
+	req, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	return data, nil
+}
+
+// ImportSpace imports an NDJSON export into a space. With overwrite, existing objects with the
+// same IDs are replaced; with createNewCopies, every object is given a new ID instead.
+func (c *KibanaClient) ImportSpace(space string, ndjson []byte, overwrite, createNewCopies bool) (*SpaceImportResult, error) {
+	params := url.Values{}
+	if overwrite {
+		params.Add("overwrite", "true")
+	}
+	if createNewCopies {
+		params.Add("createNewCopies", "true")
+	}
+	requestURL := fmt.Sprintf("%s%s/api/saved_objects/_import", c.address, spacePath(space))
+	if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+
+	// The import API takes the export as a multipart file upload
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "export.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("error creating request body: %w", err)
+	}
+	if _, err := part.Write(ndjson); err != nil {
+		return nil, fmt.Errorf("error creating request body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error creating request body: %w", err)
+	}
+
+	// Create the request
+	req, err := http.NewRequest("POST", requestURL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse the response
+	var response struct {
+		Success      bool `json:"success"`
+		SuccessCount int  `json:"successCount"`
+		Errors       []struct {
+			ID    string `json:"id"`
+			Type  string `json:"type"`
+			Title string `json:"title"`
+			Meta  struct {
+				Title string `json:"title"`
+			} `json:"meta"`
+			Error struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	result := &SpaceImportResult{Success: response.Success, SuccessCount: response.SuccessCount}
+	for _, e := range response.Errors {
+		title := e.Title
+		if title == "" {
+			title = e.Meta.Title
+		}
+		result.Errors = append(result.Errors, SpaceImportError{ID: e.ID, Type: e.Type, Title: title, Error: e.Error.Type})
+	}
+
+	return result, nil
+}
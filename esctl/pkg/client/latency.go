@@ -0,0 +1,190 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+)
+
+// LatencySample represents the timings of a single request against an address
+type LatencySample struct {
+	Connect   time.Duration
+	TLS       time.Duration
+	RoundTrip time.Duration
+	Status    int
+	Err       error
+}
+
+// AddressLatency represents the collected latency samples for a single address
+type AddressLatency struct {
+	Address string
+	Samples []LatencySample
+}
+
+// Errors returns the number of failed samples
+func (a AddressLatency) Errors() int {
+	count := 0
+	for _, s := range a.Samples {
+		if s.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// LastStatus returns the HTTP status of the last successful sample, or the last error
+func (a AddressLatency) LastStatus() string {
+	for i := len(a.Samples) - 1; i >= 0; i-- {
+		if a.Samples[i].Err == nil {
+			return fmt.Sprintf("%d", a.Samples[i].Status)
+		}
+	}
+	if len(a.Samples) > 0 {
+		return a.Samples[len(a.Samples)-1].Err.Error()
+	}
+	return "-"
+}
+
+// Stats returns the min, average and max of the selected timing over the successful samples
+func (a AddressLatency) Stats(pick func(LatencySample) time.Duration) (time.Duration, time.Duration, time.Duration) {
+	var lowest, highest, total time.Duration
+	count := 0
+	for _, s := range a.Samples {
+		if s.Err != nil {
+			continue
+		}
+		d := pick(s)
+		if count == 0 || d < lowest {
+			lowest = d
+		}
+		if d > highest {
+			highest = d
+		}
+		total += d
+		count++
+	}
+	if count == 0 {
+		return 0, 0, 0
+	}
+	return lowest, total / time.Duration(count), highest
+}
+
+// MeasureLatency performs lightweight GET / requests against every address and records connection
+// establishment, TLS handshake and round-trip latencies. Requests go through the same transport as
+// the client's, so unix sockets and addresses behind an SSH jump host are measured too, though the
+// connect time is not recorded through a jump host. Keep-alives are disabled so that each sample
+// includes a fresh connection.
+func MeasureLatency(esCfg config.ElasticsearchConfig, samples int) ([]AddressLatency, error) {
+	transport, err := newHTTPTransport(esCfg.Transport, esCfg.CACert, esCfg.Insecure)
+	if err != nil {
+		return nil, err
+	}
+	transport.DisableKeepAlives = true
+
+	endpoints, err := configureEndpoints(transport, esCfg.Addresses, esCfg.SSHTunnel)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	}
+
+	results := make([]AddressLatency, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		result := AddressLatency{Address: esCfg.Addresses[i]}
+		for j := 0; j < samples; j++ {
+			result.Samples = append(result.Samples, measureOnce(httpClient, endpoint, esCfg.Username, esCfg.Password))
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// measureOnce performs a single traced request
+func measureOnce(httpClient *http.Client, address, username, password string) LatencySample {
+	var sample LatencySample
+	var connectStart, tlsStart time.Time
+
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) { connectStart = time.Now() },
+		ConnectDone: func(network, addr string, err error) {
+			if !connectStart.IsZero() {
+				sample.Connect = time.Since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if !tlsStart.IsZero() {
+				sample.TLS = time.Since(tlsStart)
+			}
+		},
+	}
+
+	req, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		sample.Err = fmt.Errorf("creating request: %w", err)
+		return sample
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	// Add basic auth if credentials are provided
+	if username != "" && password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		sample.Err = fmt.Errorf("executing request: %w", err)
+		return sample
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		sample.Err = fmt.Errorf("reading response: %w", err)
+		return sample
+	}
+
+	sample.RoundTrip = time.Since(start)
+	sample.Status = resp.StatusCode
+
+	return sample
+}
+
+// tlsConfigFor builds a TLS configuration from a CA certificate path and the insecure flag
+func tlsConfigFor(caCertPath string, insecure bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	// If insecure mode is enabled, skip certificate verification
+	if insecure {
+		tlsConfig.InsecureSkipVerify = true
+		return tlsConfig, nil
+	}
+
+	// If CA cert is provided, use it for verification
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA cert: %w", err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA certificate")
+		}
+
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	return tlsConfig, nil
+}
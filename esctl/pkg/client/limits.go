@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Default values used when a limit setting cannot be read from the cluster
+const (
+	defaultMaxShardsPerNode = 1000
+	defaultTotalFieldsLimit = 1000
+)
+
+// LimitCheck compares the actual usage of a resource against its limit
+type LimitCheck struct {
+	Resource string // shards per node, cluster shards, mapped fields or indices
+	Name     string // node or index name, or "cluster"
+	Actual   int64
+	Limit    int64
+}
+
+// UsedPercent returns the actual usage as a percentage of the limit
+func (l LimitCheck) UsedPercent() float64 {
+	if l.Limit <= 0 {
+		return 0
+	}
+	return float64(l.Actual) / float64(l.Limit) * 100
+}
+
+// Status returns EXCEEDED when the limit has been reached, WARNING when usage is within
+// thresholdPercent of the limit, and OK otherwise
+func (l LimitCheck) Status(thresholdPercent float64) string {
+	switch {
+	case l.Limit > 0 && l.Actual >= l.Limit:
+		return "EXCEEDED"
+	case l.UsedPercent() >= 100-thresholdPercent:
+		return "WARNING"
+	default:
+		return "OK"
+	}
+}
+
+// GetLimitChecks compares shard counts per node against cluster.max_shards_per_node, mapped
+// field counts per index against index.mapping.total_fields.limit, and the index count against
+// maxIndices, the recommended upper bound for cluster metadata size
+func (c *Client) GetLimitChecks(maxIndices int64) ([]LimitCheck, error) {
+	var checks []LimitCheck
+
+	// Shards per node
+	maxShardsPerNode := int64(defaultMaxShardsPerNode)
+	if value, _, err := c.GetSettingValue("cluster.max_shards_per_node", true); err == nil {
+		if parsed, err := strconv.ParseInt(fmt.Sprintf("%v", value), 10, 64); err == nil {
+			maxShardsPerNode = parsed
+		}
+	}
+
+	allocations, err := c.GetDiskAllocations()
+	if err != nil {
+		return nil, err
+	}
+
+	var totalShards int64
+	for _, alloc := range allocations {
+		totalShards += int64(alloc.Shards)
+		checks = append(checks, LimitCheck{
+			Resource: "shards per node",
+			Name:     alloc.Node,
+			Actual:   int64(alloc.Shards),
+			Limit:    maxShardsPerNode,
+		})
+	}
+
+	// The limit is enforced cluster wide as max_shards_per_node times the number of data nodes
+	checks = append(checks, LimitCheck{
+		Resource: "cluster shards",
+		Name:     "cluster",
+		Actual:   totalShards,
+		Limit:    maxShardsPerNode * int64(len(allocations)),
+	})
+
+	// Mapped fields per index
+	fieldLimits, err := c.getTotalFieldsLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := c.GetIndexMappings("*")
+	if err != nil {
+		return nil, err
+	}
+
+	indexNames := make([]string, 0, len(mappings))
+	for name := range mappings {
+		indexNames = append(indexNames, name)
+	}
+	sort.Strings(indexNames)
+
+	for _, name := range indexNames {
+		indexMapping, ok := mappings[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		mapping, _ := indexMapping["mappings"].(map[string]interface{})
+
+		limit, ok := fieldLimits[name]
+		if !ok {
+			limit = defaultTotalFieldsLimit
+		}
+
+		checks = append(checks, LimitCheck{
+			Resource: "mapped fields",
+			Name:     name,
+			Actual:   int64(CountMappedFields(mapping)),
+			Limit:    limit,
+		})
+	}
+
+	// Index count
+	checks = append(checks, LimitCheck{
+		Resource: "indices",
+		Name:     "cluster",
+		Actual:   int64(len(indexNames)),
+		Limit:    maxIndices,
+	})
+
+	return checks, nil
+}
+
+// getTotalFieldsLimits returns index.mapping.total_fields.limit for every index
+func (c *Client) getTotalFieldsLimits() (map[string]int64, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex("*"),
+		c.es.Indices.GetSettings.WithName("index.mapping.total_fields.limit"),
+		c.es.Indices.GetSettings.WithIncludeDefaults(true),
+		c.es.Indices.GetSettings.WithFlatSettings(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+		Defaults map[string]interface{} `json:"defaults"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	limits := make(map[string]int64, len(response))
+	for index, settings := range response {
+		value, ok := settings.Settings["index.mapping.total_fields.limit"]
+		if !ok {
+			value, ok = settings.Defaults["index.mapping.total_fields.limit"]
+		}
+		if !ok {
+			continue
+		}
+		if parsed, err := strconv.ParseInt(fmt.Sprintf("%v", value), 10, 64); err == nil {
+			limits[index] = parsed
+		}
+	}
+
+	return limits, nil
+}
+
+// CountMappedFields counts the fields of a mapping the way index.mapping.total_fields.limit
+// does: every object, field, multi-field and runtime field counts once
+func CountMappedFields(mapping map[string]interface{}) int {
+	if mapping == nil {
+		return 0
+	}
+
+	count := 0
+	if runtime, ok := mapping["runtime"].(map[string]interface{}); ok {
+		count += len(runtime)
+	}
+	if properties, ok := mapping["properties"].(map[string]interface{}); ok {
+		count += countProperties(properties)
+	}
+	return count
+}
+
+// countProperties counts the fields in a properties block recursively
+func countProperties(properties map[string]interface{}) int {
+	count := 0
+	for _, value := range properties {
+		field, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		count++
+
+		if subProperties, ok := field["properties"].(map[string]interface{}); ok {
+			count += countProperties(subProperties)
+		}
+		if multiFields, ok := field["fields"].(map[string]interface{}); ok {
+			count += countProperties(multiFields)
+		}
+	}
+	return count
+}
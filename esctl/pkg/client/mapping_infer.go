@@ -0,0 +1,460 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// textMinWords is the average number of words from which a string field is treated as text
+	textMinWords = 5
+	// textMinLength is the longest value from which a string field is treated as text
+	textMinLength = 256
+	// inferDistinctLimit caps the distinct values counted per field
+	inferDistinctLimit = 1000
+)
+
+// inferDateLayouts are the date formats recognised in string values, with the mapping format for
+// each. Layouts with an empty format are covered by the default strict_date_optional_time.
+var inferDateLayouts = []struct {
+	layout string
+	format string
+}{
+	{time.RFC3339Nano, ""},
+	{"2006-01-02T15:04:05.999999999", ""},
+	{"2006-01-02", ""},
+	{"2006-01-02 15:04:05", "yyyy-MM-dd HH:mm:ss"},
+	{"2006-01-02 15:04:05.000", "yyyy-MM-dd HH:mm:ss.SSS"},
+	{"2006/01/02 15:04:05", "yyyy/MM/dd HH:mm:ss"},
+	{"02/Jan/2006:15:04:05 -0700", "dd/MMM/yyyy:HH:mm:ss Z"},
+}
+
+// ecsFieldNames maps common field names to the ECS field carrying the same data
+var ecsFieldNames = map[string]string{
+	"timestamp":   "@timestamp",
+	"time":        "@timestamp",
+	"ts":          "@timestamp",
+	"datetime":    "@timestamp",
+	"msg":         "message",
+	"log":         "message",
+	"level":       "log.level",
+	"loglevel":    "log.level",
+	"log_level":   "log.level",
+	"severity":    "log.level",
+	"logger":      "log.logger",
+	"host":        "host.name",
+	"hostname":    "host.name",
+	"user":        "user.name",
+	"username":    "user.name",
+	"user_name":   "user.name",
+	"ip":          "source.ip",
+	"client_ip":   "client.ip",
+	"clientip":    "client.ip",
+	"remote_addr": "source.ip",
+	"src_ip":      "source.ip",
+	"source_ip":   "source.ip",
+	"src_port":    "source.port",
+	"dst_ip":      "destination.ip",
+	"dest_ip":     "destination.ip",
+	"dst_port":    "destination.port",
+	"dest_port":   "destination.port",
+	"method":      "http.request.method",
+	"http_method": "http.request.method",
+	"status":      "http.response.status_code",
+	"status_code": "http.response.status_code",
+	"url":         "url.original",
+	"uri":         "url.original",
+	"path":        "url.path",
+	"referrer":    "http.request.referrer",
+	"referer":     "http.request.referrer",
+	"user_agent":  "user_agent.original",
+	"useragent":   "user_agent.original",
+	"pid":         "process.pid",
+	"process":     "process.name",
+	"service":     "service.name",
+	"app":         "service.name",
+	"env":         "service.environment",
+	"environment": "service.environment",
+	"trace_id":    "trace.id",
+	"span_id":     "span.id",
+	"error":       "error.message",
+	"duration":    "event.duration",
+	"action":      "event.action",
+	"outcome":     "event.outcome",
+	"tags":        "tags",
+}
+
+// ecsFieldTypes are the mapping types of the ECS fields in ecsFieldNames
+var ecsFieldTypes = map[string]map[string]interface{}{
+	"@timestamp":                {"type": "date"},
+	"message":                   {"type": "match_only_text"},
+	"log.level":                 {"type": "keyword", "ignore_above": 1024},
+	"log.logger":                {"type": "keyword", "ignore_above": 1024},
+	"host.name":                 {"type": "keyword", "ignore_above": 1024},
+	"user.name":                 {"type": "keyword", "ignore_above": 1024},
+	"source.ip":                 {"type": "ip"},
+	"source.port":               {"type": "long"},
+	"client.ip":                 {"type": "ip"},
+	"destination.ip":            {"type": "ip"},
+	"destination.port":          {"type": "long"},
+	"http.request.method":       {"type": "keyword", "ignore_above": 1024},
+	"http.request.referrer":     {"type": "keyword", "ignore_above": 1024},
+	"http.response.status_code": {"type": "long"},
+	"url.original":              {"type": "wildcard"},
+	"url.path":                  {"type": "wildcard"},
+	"user_agent.original":       {"type": "keyword", "ignore_above": 1024},
+	"process.pid":               {"type": "long"},
+	"process.name":              {"type": "keyword", "ignore_above": 1024},
+	"service.name":              {"type": "keyword", "ignore_above": 1024},
+	"service.environment":       {"type": "keyword", "ignore_above": 1024},
+	"trace.id":                  {"type": "keyword", "ignore_above": 1024},
+	"span.id":                   {"type": "keyword", "ignore_above": 1024},
+	"error.message":             {"type": "match_only_text"},
+	"event.duration":            {"type": "long"},
+	"event.action":              {"type": "keyword", "ignore_above": 1024},
+	"event.outcome":             {"type": "keyword", "ignore_above": 1024},
+	"tags":                      {"type": "keyword", "ignore_above": 1024},
+}
+
+// InferredField is the mapping proposed for a field of the sample documents
+type InferredField struct {
+	Name       string                 // field path in the mapping
+	SourceName string                 // field path in the samples, if renamed to an ECS field
+	Mapping    map[string]interface{} // proposed field mapping
+	Seen       int                    // documents holding the field
+	Distinct   int                    // distinct values, capped at inferDistinctLimit
+	Range      string                 // smallest and largest value of numeric fields
+	Reason     string                 // why the type was chosen
+}
+
+// InferredMapping is the mapping proposed for a set of sample documents
+type InferredMapping struct {
+	Documents int
+	Fields    []InferredField
+	Conflicts []string // fields seen with incompatible types
+}
+
+// fieldSamples collects what was seen in the values of one field
+type fieldSamples struct {
+	docs      int
+	strings   int
+	bools     int
+	integers  int
+	floats    int
+	objects   int
+	min, max  float64
+	maxLength int
+	words     int
+	dates     map[string]int // matching date format per string value
+	ips       int
+	distinct  map[string]struct{}
+}
+
+// ReadSampleDocuments reads NDJSON sample documents. Search hits are unwrapped to their _source,
+// so the output of a search or an export can be used directly.
+func ReadSampleDocuments(r io.Reader) ([]map[string]interface{}, error) {
+	var docs []map[string]interface{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var doc map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if source, ok := doc["_source"].(map[string]interface{}); ok {
+			doc = source
+		}
+		docs = append(docs, doc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// InferMapping proposes a mapping for the sample documents. Strings become keyword, or text with
+// a keyword subfield when they read like prose; dates and IP addresses are detected from their
+// values, and numbers are sized by the range seen. With ecs, fields with a well-known name are
+// renamed to the matching ECS field and given its ECS type.
+func InferMapping(docs []map[string]interface{}, ecs bool) *InferredMapping {
+	samples := make(map[string]*fieldSamples)
+	for _, doc := range docs {
+		seen := make(map[string]bool)
+		collectSamples(doc, "", samples, seen)
+		for name := range seen {
+			samples[name].docs++
+		}
+	}
+
+	result := &InferredMapping{Documents: len(docs)}
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := samples[name]
+		if s.objects > 0 {
+			if s.strings+s.bools+s.integers+s.floats > 0 {
+				result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s is an object in some documents and a value in others", name))
+			}
+			continue
+		}
+
+		field := InferredField{Name: name, Seen: s.docs, Distinct: len(s.distinct)}
+		field.Mapping, field.Reason = inferFieldMapping(s)
+		if s.integers+s.floats > 0 {
+			field.Range = fmt.Sprintf("%s..%s", formatSampleNumber(s.min), formatSampleNumber(s.max))
+		}
+		if (s.strings > 0 && s.integers+s.floats+s.bools > 0) || (s.bools > 0 && s.integers+s.floats > 0) {
+			result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s holds values of more than one type, mapped as %s", name, field.Mapping["type"]))
+		}
+
+		if ecs {
+			renameToECS(&field, samples)
+		}
+		result.Fields = append(result.Fields, field)
+	}
+
+	// Two sample fields may name the same ECS field, keep the most common and report the rest
+	sort.SliceStable(result.Fields, func(i, j int) bool {
+		return result.Fields[i].Seen > result.Fields[j].Seen
+	})
+	used := make(map[string]string)
+	fields := result.Fields[:0]
+	for _, field := range result.Fields {
+		if other, ok := used[field.Name]; ok {
+			result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s and %s both map to %s, %s was left out", other, field.SourceName, field.Name, field.SourceName))
+			continue
+		}
+		used[field.Name] = field.SourceName
+		if field.SourceName == "" {
+			used[field.Name] = field.Name
+		}
+		fields = append(fields, field)
+	}
+	result.Fields = fields
+
+	sort.Slice(result.Fields, func(i, j int) bool {
+		return result.Fields[i].Name < result.Fields[j].Name
+	})
+	return result
+}
+
+// renameToECS renames a top-level field with a well-known name to its ECS field, if the values
+// seen fit the ECS field's type and no sample field already has the ECS name
+func renameToECS(field *InferredField, samples map[string]*fieldSamples) {
+	if strings.Contains(field.Name, ".") {
+		return
+	}
+	ecsName, ok := ecsFieldNames[strings.ToLower(field.Name)]
+	if !ok || (ecsName != field.Name && samples[ecsName] != nil) {
+		return
+	}
+	ecsMapping := ecsFieldTypes[ecsName]
+	if !ecsCompatible(field.Mapping["type"].(string), ecsMapping["type"].(string)) {
+		return
+	}
+
+	field.SourceName = field.Name
+	field.Name = ecsName
+	field.Reason = "ECS field " + ecsName
+	// Keep the inferred mapping when it has the ECS type, it may carry a date format
+	if field.Mapping["type"] != ecsMapping["type"] {
+		field.Mapping = ecsMapping
+	}
+}
+
+// ecsCompatible reports whether values inferred as one type can be indexed into an ECS field type
+func ecsCompatible(inferred, ecs string) bool {
+	switch ecs {
+	case "date", "ip":
+		return inferred == ecs
+	case "long":
+		return inferred == "byte" || inferred == "short" || inferred == "integer" || inferred == "long"
+	}
+	// keyword, wildcard and text fields take any string
+	return inferred == "keyword" || inferred == "text" || inferred == "ip"
+}
+
+// Properties returns the fields as a nested mapping properties object
+func (m *InferredMapping) Properties() map[string]interface{} {
+	properties := make(map[string]interface{})
+	for _, field := range m.Fields {
+		parts := strings.Split(field.Name, ".")
+		current := properties
+		for _, part := range parts[:len(parts)-1] {
+			parent, ok := current[part].(map[string]interface{})
+			if !ok {
+				parent = map[string]interface{}{"properties": map[string]interface{}{}}
+				current[part] = parent
+			}
+			// A parent mapped as a value cannot hold subfields, keep the value mapping
+			children, ok := parent["properties"].(map[string]interface{})
+			if !ok {
+				current = nil
+				break
+			}
+			current = children
+		}
+		leaf := parts[len(parts)-1]
+		if _, exists := current[leaf]; current != nil && !exists {
+			current[leaf] = field.Mapping
+		}
+	}
+	return properties
+}
+
+// collectSamples records the values of a document, flattening objects into dotted field paths.
+// Arrays are treated as multiple values of the same field.
+func collectSamples(value interface{}, path string, samples map[string]*fieldSamples, seen map[string]bool) {
+	if values, ok := value.([]interface{}); ok {
+		for _, v := range values {
+			collectSamples(v, path, samples, seen)
+		}
+		return
+	}
+	if value == nil {
+		return
+	}
+
+	if path != "" {
+		seen[path] = true
+		if samples[path] == nil {
+			samples[path] = &fieldSamples{dates: make(map[string]int), distinct: make(map[string]struct{}), min: math.Inf(1), max: math.Inf(-1)}
+		}
+	}
+	s := samples[path]
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s != nil {
+			s.objects++
+		}
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			collectSamples(child, childPath, samples, seen)
+		}
+	case bool:
+		s.bools++
+		s.addDistinct(fmt.Sprintf("%t", v))
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return
+		}
+		if _, err := v.Int64(); err == nil {
+			s.integers++
+		} else {
+			s.floats++
+		}
+		s.min = math.Min(s.min, f)
+		s.max = math.Max(s.max, f)
+		s.addDistinct(v.String())
+	case string:
+		s.strings++
+		s.addDistinct(v)
+		if len(v) > s.maxLength {
+			s.maxLength = len(v)
+		}
+		s.words += len(strings.Fields(v))
+		if format, ok := detectDateFormat(v); ok {
+			s.dates[format]++
+		}
+		if net.ParseIP(v) != nil {
+			s.ips++
+		}
+	}
+}
+
+// addDistinct counts a value towards the distinct values of a field, up to inferDistinctLimit
+func (s *fieldSamples) addDistinct(value string) {
+	if len(s.distinct) < inferDistinctLimit {
+		s.distinct[value] = struct{}{}
+	}
+}
+
+// inferFieldMapping chooses the mapping of a field from its samples
+func inferFieldMapping(s *fieldSamples) (map[string]interface{}, string) {
+	switch {
+	case s.strings > 0:
+		// Strings win over other types, as numbers and booleans index into keyword fields
+		for format, count := range s.dates {
+			if count == s.strings {
+				if format == "" {
+					return map[string]interface{}{"type": "date"}, "every value is an ISO 8601 date"
+				}
+				return map[string]interface{}{"type": "date", "format": format}, "every value is a date in " + format
+			}
+		}
+		if s.ips == s.strings {
+			return map[string]interface{}{"type": "ip"}, "every value is an IP address"
+		}
+		if s.maxLength >= textMinLength || s.words/s.strings >= textMinWords {
+			return map[string]interface{}{
+				"type": "text",
+				"fields": map[string]interface{}{
+					"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+				},
+			}, fmt.Sprintf("long or multi-word values (longest %d characters)", s.maxLength)
+		}
+		return map[string]interface{}{"type": "keyword", "ignore_above": 1024}, "short values"
+	case s.floats > 0:
+		if math.Abs(s.min) > math.MaxFloat32 || math.Abs(s.max) > math.MaxFloat32 {
+			return map[string]interface{}{"type": "double"}, "decimal values outside the float range"
+		}
+		return map[string]interface{}{"type": "float"}, "decimal values"
+	case s.integers > 0:
+		// Smaller types save little, and samples rarely show the full range of a field
+		if s.min >= math.MinInt32 && s.max <= math.MaxInt32 {
+			return map[string]interface{}{"type": "integer"}, "whole numbers within the integer range"
+		}
+		return map[string]interface{}{"type": "long"}, "whole numbers outside the integer range"
+	case s.bools > 0:
+		return map[string]interface{}{"type": "boolean"}, "true and false values"
+	}
+	return map[string]interface{}{"type": "keyword", "ignore_above": 1024}, "no values seen"
+}
+
+// detectDateFormat returns the mapping date format of a string value, if it is a date
+func detectDateFormat(value string) (string, bool) {
+	// Skip values that cannot be one of the layouts, most strings fail here
+	if len(value) < 10 || value[0] < '0' || value[0] > '9' {
+		return "", false
+	}
+	for _, d := range inferDateLayouts {
+		if _, err := time.Parse(d.layout, value); err == nil {
+			return d.format, true
+		}
+	}
+	return "", false
+}
+
+// formatSampleNumber formats a number without a trailing fraction for whole numbers
+func formatSampleNumber(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return fmt.Sprintf("%.0f", f)
+	}
+	return fmt.Sprintf("%g", f)
+}
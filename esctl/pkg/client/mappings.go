@@ -25,7 +25,7 @@ func (c *Client) GetIndexMappings(indexName string) (map[string]interface{}, err
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -77,7 +77,7 @@ func (c *Client) PutIndexMapping(indexName string, mapping map[string]interface{
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
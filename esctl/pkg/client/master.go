@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MasterStatus describes the elected master and the state of cluster coordination
+type MasterStatus struct {
+	MasterID      string
+	MasterName    string
+	StateVersion  int64
+	StateUUID     string
+	Term          int64
+	VotingConfig  []string
+	Eligible      []MasterEligibleNode
+	Stability     string
+	StabilityInfo string
+	RecentMasters []string
+}
+
+// MasterEligibleNode is a node that can be elected master
+type MasterEligibleNode struct {
+	ID         string
+	Name       string
+	Address    string
+	VotingOnly bool
+	Voting     bool
+	Elected    bool
+}
+
+// GetMasterStatus returns the elected master, the master-eligible nodes and the coordination
+// metadata from the cluster state. Where the cluster supports the master_is_stable health
+// indicator, its status and the recently elected masters are included as well.
+func (c *Client) GetMasterStatus() (*MasterStatus, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cluster.State(
+		c.es.Cluster.State.WithContext(ctx),
+		c.es.Cluster.State.WithMetric("master_node", "version", "metadata"),
+		c.es.Cluster.State.WithFilterPath("master_node", "version", "state_uuid", "metadata.cluster_coordination"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster state: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var state struct {
+		MasterNode string `json:"master_node"`
+		Version    int64  `json:"version"`
+		StateUUID  string `json:"state_uuid"`
+		Metadata   struct {
+			ClusterCoordination struct {
+				Term                int64    `json:"term"`
+				LastCommittedConfig []string `json:"last_committed_config"`
+			} `json:"cluster_coordination"`
+		} `json:"metadata"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	status := &MasterStatus{
+		MasterID:     state.MasterNode,
+		StateVersion: state.Version,
+		StateUUID:    state.StateUUID,
+		Term:         state.Metadata.ClusterCoordination.Term,
+		VotingConfig: state.Metadata.ClusterCoordination.LastCommittedConfig,
+	}
+
+	// Master-eligible nodes
+	nodes, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+	voting := make(map[string]bool, len(status.VotingConfig))
+	for _, id := range status.VotingConfig {
+		voting[id] = true
+	}
+	for _, node := range nodes {
+		if node.ID == status.MasterID {
+			status.MasterName = node.Name
+		}
+		if !hasRole(node.Roles, "master") {
+			continue
+		}
+		status.Eligible = append(status.Eligible, MasterEligibleNode{
+			ID:         node.ID,
+			Name:       node.Name,
+			Address:    node.TransportAddress,
+			VotingOnly: hasRole(node.Roles, "voting_only"),
+			Voting:     voting[node.ID],
+			Elected:    node.ID == status.MasterID,
+		})
+	}
+
+	// Master stability is only available from 8.4, so older clusters simply go without it
+	c.fillMasterStability(status)
+
+	return status, nil
+}
+
+// fillMasterStability adds the master_is_stable health indicator to a master status, leaving it
+// empty if the indicator cannot be read
+func (c *Client) fillMasterStability(status *MasterStatus) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.HealthReport(
+		c.es.HealthReport.WithContext(ctx),
+		c.es.HealthReport.WithFeature("master_is_stable"),
+		c.es.HealthReport.WithVerbose(true),
+	)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return
+	}
+
+	// Parse response
+	var report struct {
+		Indicators struct {
+			MasterIsStable struct {
+				Status  string `json:"status"`
+				Symptom string `json:"symptom"`
+				Details struct {
+					RecentMasters []struct {
+						NodeID string `json:"node_id"`
+						Name   string `json:"name"`
+					} `json:"recent_masters"`
+				} `json:"details"`
+			} `json:"master_is_stable"`
+		} `json:"indicators"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+		return
+	}
+
+	indicator := report.Indicators.MasterIsStable
+	status.Stability = indicator.Status
+	status.StabilityInfo = indicator.Symptom
+	for _, master := range indicator.Details.RecentMasters {
+		name := master.Name
+		if name == "" {
+			name = master.NodeID
+		}
+		status.RecentMasters = append(status.RecentMasters, name)
+	}
+}
+
+// hasRole reports whether a role list contains a role
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxNameBytes is the maximum length in bytes Elasticsearch accepts for index and alias names
+const maxNameBytes = 255
+
+// invalidNameChars are the characters Elasticsearch rejects in index, alias, repository and snapshot names
+const invalidNameChars = `\/*?"<>| ,#:`
+
+// NameError represents a resource name that Elasticsearch would reject
+type NameError struct {
+	Kind       string // index, alias, repository or snapshot
+	Name       string
+	Reason     string
+	Suggestion string // a corrected name, if one can be derived
+}
+
+// Error implements the error interface for NameError
+func (e *NameError) Error() string {
+	msg := fmt.Sprintf("invalid %s name '%s': %s", e.Kind, e.Name, e.Reason)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean '%s'?)", e.Suggestion)
+	}
+	return msg
+}
+
+// ValidateIndexName checks an index name against the Elasticsearch naming rules
+func ValidateIndexName(name string) error {
+	if err := validateIndexLikeName("index", name); err != nil {
+		return err
+	}
+	if name != strings.ToLower(name) {
+		return &NameError{Kind: "index", Name: name, Reason: "must be lowercase", Suggestion: strings.ToLower(name)}
+	}
+	return nil
+}
+
+// ValidateAliasName checks an alias name, which follows the same rules as index names except
+// that it may contain uppercase letters
+func ValidateAliasName(name string) error {
+	return validateIndexLikeName("alias", name)
+}
+
+// ValidateRepositoryName checks a snapshot repository name against the Elasticsearch naming rules
+func ValidateRepositoryName(name string) error {
+	return validateSnapshotLikeName("repository", name)
+}
+
+// ValidateSnapshotName checks a snapshot name against the Elasticsearch naming rules.
+// Date math names such as <nightly-{now/d}> are resolved by Elasticsearch and are not checked.
+func ValidateSnapshotName(name string) error {
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		return nil
+	}
+	if err := validateSnapshotLikeName("snapshot", name); err != nil {
+		return err
+	}
+	if name != strings.ToLower(name) {
+		return &NameError{Kind: "snapshot", Name: name, Reason: "must be lowercase", Suggestion: strings.ToLower(name)}
+	}
+	return nil
+}
+
+// validateIndexLikeName applies the rules shared by index and alias names, all but lowercase
+func validateIndexLikeName(kind, name string) error {
+	if name == "" {
+		return &NameError{Kind: kind, Name: name, Reason: "must not be empty"}
+	}
+	if name == "." || name == ".." {
+		return &NameError{Kind: kind, Name: name, Reason: "must not be '.' or '..'"}
+	}
+	if len(name) > maxNameBytes {
+		return &NameError{Kind: kind, Name: name, Reason: fmt.Sprintf("is %d bytes long, the maximum is %d", len(name), maxNameBytes)}
+	}
+	if strings.ContainsAny(name[:1], "-_+") {
+		return &NameError{
+			Kind:       kind,
+			Name:       name,
+			Reason:     fmt.Sprintf("must not start with '%s'", name[:1]),
+			Suggestion: strings.TrimLeft(name, "-_+"),
+		}
+	}
+	if i := strings.IndexAny(name, invalidNameChars); i >= 0 {
+		return &NameError{
+			Kind:   kind,
+			Name:   name,
+			Reason: fmt.Sprintf("must not contain '%c', invalid characters are: %s", name[i], describeInvalidChars()),
+		}
+	}
+	return nil
+}
+
+// validateSnapshotLikeName applies the rules shared by repository and snapshot names
+func validateSnapshotLikeName(kind, name string) error {
+	if name == "" {
+		return &NameError{Kind: kind, Name: name, Reason: "must not be empty"}
+	}
+	if strings.HasPrefix(name, "_") {
+		return &NameError{Kind: kind, Name: name, Reason: "must not start with '_'", Suggestion: strings.TrimLeft(name, "_")}
+	}
+	// A leading - is read as an exclusion by the APIs that accept several names
+	if strings.HasPrefix(name, "-") {
+		return &NameError{Kind: kind, Name: name, Reason: "must not start with '-'", Suggestion: strings.TrimLeft(name, "-")}
+	}
+	if i := strings.IndexAny(name, invalidNameChars); i >= 0 {
+		return &NameError{
+			Kind:   kind,
+			Name:   name,
+			Reason: fmt.Sprintf("must not contain '%c', invalid characters are: %s", name[i], describeInvalidChars()),
+		}
+	}
+	return nil
+}
+
+// describeInvalidChars lists the invalid name characters in a readable form
+func describeInvalidChars() string {
+	chars := make([]string, 0, len(invalidNameChars))
+	for _, c := range invalidNameChars {
+		if c == ' ' {
+			chars = append(chars, "space")
+			continue
+		}
+		chars = append(chars, string(c))
+	}
+	return strings.Join(chars, " ")
+}
+
+// PolicyIDError represents an error related to policy ID validation
+type PolicyIDError struct {
+	ID     string
+	Reason string
+}
+
+// Error implements the error interface for PolicyIDError
+func (e *PolicyIDError) Error() string {
+	return fmt.Sprintf("invalid policy ID '%s': %s", e.ID, e.Reason)
+}
+
+// ValidatePolicyID checks if a policy ID matches required format rules
+// Policy IDs must be lowercase alphanumeric with hyphens and underscores, 1-36 chars
+func ValidatePolicyID(id string) error {
+	// Empty ID is valid (system will generate one)
+	if id == "" {
+		return nil
+	}
+
+	// Check length
+	if len(id) > 36 {
+		return &PolicyIDError{ID: id, Reason: "exceeds maximum length of 36 characters"}
+	}
+
+	// Check pattern using regex
+	validPattern := regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
+	if !validPattern.MatchString(id) {
+		reason := "must contain only lowercase letters, numbers, hyphens, and underscores, and start with a letter or number"
+		if strings.HasPrefix(id, "-") || strings.HasPrefix(id, "_") {
+			reason = fmt.Sprintf("must start with a letter or number, not '%s'", id[:1])
+		}
+		return &PolicyIDError{ID: id, Reason: reason}
+	}
+
+	return nil
+}
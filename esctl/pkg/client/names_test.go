@@ -0,0 +1,38 @@
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateAliasName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"logs-write", false},
+		{"Logs-Current", false},
+		{".kibana", false},
+		{"", true},
+		{"_logs", true},
+		{"logs,metrics", true},
+		{"logs*", true},
+	}
+	for _, tt := range tests {
+		err := ValidateAliasName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAliasName(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateIndexNameRejectsUppercase(t *testing.T) {
+	err := ValidateIndexName("Logs-Current")
+	var nameErr *NameError
+	if !errors.As(err, &nameErr) {
+		t.Fatalf("ValidateIndexName(%q) error = %v, want a *NameError", "Logs-Current", err)
+	}
+	if nameErr.Suggestion != "logs-current" {
+		t.Errorf("suggestion = %q, want %q", nameErr.Suggestion, "logs-current")
+	}
+}
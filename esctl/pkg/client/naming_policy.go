@@ -0,0 +1,205 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+	"github.com/spf13/viper"
+)
+
+// Kinds of name a naming policy has rules for
+const (
+	NamingKindIndex    = "index"
+	NamingKindAlias    = "alias"
+	NamingKindTemplate = "template"
+)
+
+// NamingRules are the naming rules for one kind of name. A name must start with one of the
+// required prefixes and match one of the patterns, when any are given, and must not match any
+// forbidden pattern.
+type NamingRules struct {
+	RequiredPrefixes []string `mapstructure:"required_prefixes"`
+	Patterns         []string `mapstructure:"patterns"`  // regular expressions
+	Forbidden        []string `mapstructure:"forbidden"` // regular expressions
+
+	patterns  []*regexp.Regexp
+	forbidden []*regexp.Regexp
+}
+
+// NamingPolicy holds the naming rules checked by commands that create indices, aliases and templates
+type NamingPolicy struct {
+	Index    NamingRules `mapstructure:"index"`
+	Alias    NamingRules `mapstructure:"alias"`
+	Template NamingRules `mapstructure:"template"`
+}
+
+// NamingViolation is a name that breaks a naming policy rule
+type NamingViolation struct {
+	Kind string
+	Name string
+	Rule string
+}
+
+// NamingPolicyError is returned when names break an enforced naming policy
+type NamingPolicyError struct {
+	Violations []NamingViolation
+}
+
+// Error implements the error interface for NamingPolicyError
+func (e *NamingPolicyError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, fmt.Sprintf("%s '%s' %s", v.Kind, v.Name, v.Rule))
+	}
+	return "naming policy violated: " + strings.Join(parts, "; ")
+}
+
+// LoadNamingPolicy reads a naming policy file, in any format the config file supports:
+//
+//	index:
+//	  required_prefixes: ["logs-", "metrics-"]
+//	  patterns: ['^[a-z]+-[a-z0-9.-]+$']
+//	  forbidden: ['test', '^tmp']
+//	alias:
+//	  required_prefixes: ["alias-"]
+func LoadNamingPolicy(path string) (*NamingPolicy, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading naming policy: %w", err)
+	}
+
+	var policy NamingPolicy
+	if err := v.Unmarshal(&policy); err != nil {
+		return nil, fmt.Errorf("error parsing naming policy: %w", err)
+	}
+
+	for _, rules := range []*NamingRules{&policy.Index, &policy.Alias, &policy.Template} {
+		if err := rules.compile(); err != nil {
+			return nil, fmt.Errorf("error parsing naming policy %s: %w", path, err)
+		}
+	}
+
+	return &policy, nil
+}
+
+// compile compiles the patterns of a rule set
+func (r *NamingRules) compile() error {
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, p := range r.Forbidden {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid forbidden pattern '%s': %w", p, err)
+		}
+		r.forbidden = append(r.forbidden, re)
+	}
+	return nil
+}
+
+// Check returns the rules each name breaks. A nil policy allows every name.
+func (p *NamingPolicy) Check(kind string, names ...string) []NamingViolation {
+	if p == nil {
+		return nil
+	}
+
+	var rules *NamingRules
+	switch kind {
+	case NamingKindIndex:
+		rules = &p.Index
+	case NamingKindAlias:
+		rules = &p.Alias
+	case NamingKindTemplate:
+		rules = &p.Template
+	default:
+		return nil
+	}
+
+	var violations []NamingViolation
+	for _, name := range names {
+		for _, rule := range rules.check(name) {
+			violations = append(violations, NamingViolation{Kind: kind, Name: name, Rule: rule})
+		}
+	}
+	return violations
+}
+
+// check returns a description of each rule a name breaks
+func (r *NamingRules) check(name string) []string {
+	var broken []string
+
+	if len(r.RequiredPrefixes) > 0 {
+		hasPrefix := false
+		for _, prefix := range r.RequiredPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				hasPrefix = true
+				break
+			}
+		}
+		if !hasPrefix {
+			broken = append(broken, fmt.Sprintf("must start with one of: %s", strings.Join(r.RequiredPrefixes, ", ")))
+		}
+	}
+
+	if len(r.patterns) > 0 {
+		matched := false
+		for _, re := range r.patterns {
+			if re.MatchString(name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			broken = append(broken, fmt.Sprintf("must match one of: %s", strings.Join(r.Patterns, ", ")))
+		}
+	}
+
+	for i, re := range r.forbidden {
+		if re.MatchString(name) {
+			broken = append(broken, fmt.Sprintf("must not match '%s'", r.Forbidden[i]))
+		}
+	}
+
+	return broken
+}
+
+// CheckNamingPolicy checks names against the naming policy file, if one is configured. With
+// enforce set, violations are returned as a NamingPolicyError; otherwise they are returned for
+// the caller to warn about.
+func CheckNamingPolicy(policyFile string, enforce bool, kind string, names ...string) ([]NamingViolation, error) {
+	if policyFile == "" {
+		return nil, nil
+	}
+
+	policy, err := LoadNamingPolicy(policyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := policy.Check(kind, names...)
+	if len(violations) > 0 && enforce {
+		return violations, &NamingPolicyError{Violations: violations}
+	}
+	return violations, nil
+}
+
+// CheckNames checks names against the naming policy of cfg. Violations are printed as warnings,
+// or returned as a NamingPolicyError when the policy is enforced.
+func CheckNames(cfg *config.Config, kind string, names ...string) error {
+	violations, err := CheckNamingPolicy(cfg.Naming.PolicyFile, cfg.Naming.Enforce, kind, names...)
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "Warning: %s '%s' %s (naming policy)\n", v.Kind, v.Name, v.Rule)
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,6 +25,85 @@ type NodeAllocation struct {
 	DiskPercent  string
 	DiskIndices  string
 	Shards       string
+
+	// Raw values used for aggregation
+	Roles            []string
+	Attributes       map[string]string
+	DiskTotalBytes   int64
+	DiskUsedBytes    int64
+	DiskIndicesBytes int64
+	ShardCount       int
+}
+
+// DiskAllocation represents the raw disk usage of a data node as reported by _cat/allocation
+type DiskAllocation struct {
+	Node        string
+	Shards      int
+	DiskIndices int64
+	DiskUsed    int64
+	DiskAvail   int64
+	DiskTotal   int64
+}
+
+// GetDiskAllocations returns per node disk usage in bytes from _cat/allocation
+// The UNASSIGNED pseudo node is omitted
+func (c *Client) GetDiskAllocations() ([]DiskAllocation, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cat.Allocation(
+		c.es.Cat.Allocation.WithContext(ctx),
+		c.es.Cat.Allocation.WithFormat("json"),
+		c.es.Cat.Allocation.WithBytes("b"),
+		c.es.Cat.Allocation.WithH("node,shards,disk.indices,disk.used,disk.avail,disk.total"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting allocation: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var rows []struct {
+		Node        string `json:"node"`
+		Shards      string `json:"shards"`
+		DiskIndices string `json:"disk.indices"`
+		DiskUsed    string `json:"disk.used"`
+		DiskAvail   string `json:"disk.avail"`
+		DiskTotal   string `json:"disk.total"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	allocations := make([]DiskAllocation, 0, len(rows))
+	for _, row := range rows {
+		if row.Node == "UNASSIGNED" {
+			continue
+		}
+
+		shards, _ := strconv.Atoi(row.Shards)
+		indices, _ := strconv.ParseInt(row.DiskIndices, 10, 64)
+		used, _ := strconv.ParseInt(row.DiskUsed, 10, 64)
+		avail, _ := strconv.ParseInt(row.DiskAvail, 10, 64)
+		total, _ := strconv.ParseInt(row.DiskTotal, 10, 64)
+
+		allocations = append(allocations, DiskAllocation{
+			Node:        row.Node,
+			Shards:      shards,
+			DiskIndices: indices,
+			DiskUsed:    used,
+			DiskAvail:   avail,
+			DiskTotal:   total,
+		})
+	}
+
+	return allocations, nil
 }
 
 // GetNodeAllocations returns disk allocation information for all nodes in the cluster
@@ -128,7 +210,11 @@ func (c *Client) GetNodeAllocations() ([]NodeAllocation, error) {
 
 		// Determine node role
 		var role string
+		var roleNames []string
 		roles, ok := infoNode["roles"].([]interface{})
+		for _, r := range roles {
+			roleNames = append(roleNames, fmt.Sprintf("%v", r))
+		}
 		if ok && len(roles) > 0 {
 			role = fmt.Sprintf("%v", roles[0])
 		} else {
@@ -153,7 +239,17 @@ func (c *Client) GetNodeAllocations() ([]NodeAllocation, error) {
 			}
 		}
 
+		// Extract node attributes
+		attributes := make(map[string]string)
+		if attrs, ok := infoNode["attributes"].(map[string]interface{}); ok {
+			for key, value := range attrs {
+				attributes[key] = fmt.Sprintf("%v", value)
+			}
+		}
+
 		// Extract disk information
+		var totalBytes, usedBytes, indicesBytes int64
+		var shardCount int
 		diskTotal := "-"
 		diskUsed := "-"
 		diskAvail := "-"
@@ -167,7 +263,8 @@ func (c *Client) GetNodeAllocations() ([]NodeAllocation, error) {
 				var totalBytesVal float64
 				if val, ok := total["total_in_bytes"].(float64); ok {
 					totalBytesVal = val
-					diskTotal = ByteCountSI(int64(totalBytesVal))
+					totalBytes = int64(totalBytesVal)
+					diskTotal = ByteCountSI(totalBytes)
 				}
 				
 				if freeBytes, ok := total["free_in_bytes"].(float64); ok {
@@ -175,7 +272,8 @@ func (c *Client) GetNodeAllocations() ([]NodeAllocation, error) {
 				}
 				
 				if availableBytes, ok := total["available_in_bytes"].(float64); ok && totalBytesVal > 0 {
-					diskUsed = ByteCountSI(int64(totalBytesVal) - int64(availableBytes))
+					usedBytes = int64(totalBytesVal) - int64(availableBytes)
+					diskUsed = ByteCountSI(usedBytes)
 					usedPercent := (totalBytesVal - availableBytes) / totalBytesVal * 100
 					diskPercent = fmt.Sprintf("%.1f%%", usedPercent)
 				}
@@ -186,12 +284,14 @@ func (c *Client) GetNodeAllocations() ([]NodeAllocation, error) {
 		if indices, ok := statsNode["indices"].(map[string]interface{}); ok {
 			if store, ok := indices["store"].(map[string]interface{}); ok {
 				if sizeBytes, ok := store["size_in_bytes"].(float64); ok {
-					diskIndices = ByteCountSI(int64(sizeBytes))
+					indicesBytes = int64(sizeBytes)
+					diskIndices = ByteCountSI(indicesBytes)
 				}
 			}
 			if shardStats, ok := indices["shards_stats"].(map[string]interface{}); ok {
 				if count, ok := shardStats["count"].(float64); ok {
-					shards = fmt.Sprintf("%d", int(count))
+					shardCount = int(count)
+					shards = fmt.Sprintf("%d", shardCount)
 				}
 			}
 		}
@@ -210,8 +310,120 @@ func (c *Client) GetNodeAllocations() ([]NodeAllocation, error) {
 			DiskPercent: diskPercent,
 			DiskIndices: diskIndices,
 			Shards:      shards,
+
+			Roles:            roleNames,
+			Attributes:       attributes,
+			DiskTotalBytes:   totalBytes,
+			DiskUsedBytes:    usedBytes,
+			DiskIndicesBytes: indicesBytes,
+			ShardCount:       shardCount,
 		})
 	}
 
 	return nodeAllocations, nil
 }
+
+// tierRoles maps data tier roles to their tier names
+var tierRoles = []struct {
+	role string
+	tier string
+}{
+	{"data_hot", "hot"},
+	{"data_warm", "warm"},
+	{"data_cold", "cold"},
+	{"data_frozen", "frozen"},
+}
+
+// NodeTier derives the data tier of a node from its roles, falling back to the legacy
+// "data" or "box_type" node attributes used by hot/warm architectures before data tiers
+func NodeTier(roles []string, attributes map[string]string) string {
+	hasRole := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		hasRole[role] = true
+	}
+
+	var tiers []string
+	for _, tr := range tierRoles {
+		if hasRole[tr.role] {
+			tiers = append(tiers, tr.tier)
+		}
+	}
+	if len(tiers) > 0 {
+		return strings.Join(tiers, "/")
+	}
+
+	if hasRole["data"] {
+		for _, attr := range []string{"data", "box_type"} {
+			if value := attributes[attr]; value != "" {
+				return value
+			}
+		}
+		return "data"
+	}
+
+	if hasRole["data_content"] {
+		return "content"
+	}
+
+	return "non-data"
+}
+
+// TierAllocation represents the aggregated disk and shard usage of a data tier
+type TierAllocation struct {
+	Tier        string
+	Nodes       int
+	Shards      int
+	DiskIndices int64
+	DiskUsed    int64
+	DiskTotal   int64
+}
+
+// UsedPercent returns the disk usage of the tier as a percentage of its total disk
+func (t TierAllocation) UsedPercent() float64 {
+	if t.DiskTotal == 0 {
+		return 0
+	}
+	return float64(t.DiskUsed) / float64(t.DiskTotal) * 100
+}
+
+// GroupAllocationsByTier aggregates node allocations per data tier, ordered from hot to frozen
+func GroupAllocationsByTier(nodes []NodeAllocation) []TierAllocation {
+	byTier := make(map[string]*TierAllocation)
+	for _, node := range nodes {
+		tier := NodeTier(node.Roles, node.Attributes)
+		t, ok := byTier[tier]
+		if !ok {
+			t = &TierAllocation{Tier: tier}
+			byTier[tier] = t
+		}
+		t.Nodes++
+		t.Shards += node.ShardCount
+		t.DiskIndices += node.DiskIndicesBytes
+		t.DiskUsed += node.DiskUsedBytes
+		t.DiskTotal += node.DiskTotalBytes
+	}
+
+	tiers := make([]TierAllocation, 0, len(byTier))
+	for _, t := range byTier {
+		tiers = append(tiers, *t)
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		ri, rj := tierRank(tiers[i].Tier), tierRank(tiers[j].Tier)
+		if ri != rj {
+			return ri < rj
+		}
+		return tiers[i].Tier < tiers[j].Tier
+	})
+
+	return tiers
+}
+
+// tierRank orders tiers from hottest to coldest, with other groupings last
+func tierRank(tier string) int {
+	for i, tr := range tierRoles {
+		if strings.HasPrefix(tier, tr.tier) {
+			return i
+		}
+	}
+	return len(tierRoles)
+}
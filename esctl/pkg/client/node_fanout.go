@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// nodeFanOutConcurrency is the maximum number of per-node requests in flight at once
+const nodeFanOutConcurrency = 8
+
+// NodeError is a failure to collect data from a single node
+type NodeError struct {
+	NodeID   string
+	NodeName string
+	Err      error
+}
+
+// Error implements the error interface for NodeError
+func (e NodeError) Error() string {
+	return fmt.Sprintf("node %s (%s): %v", e.NodeName, e.NodeID, e.Err)
+}
+
+// PartialNodeError is returned together with the results of a per-node request when some
+// nodes could not be read. The results for the other nodes are still valid.
+type PartialNodeError struct {
+	Errors []NodeError
+	Total  int
+}
+
+// Error implements the error interface for PartialNodeError
+func (e *PartialNodeError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, nodeErr := range e.Errors {
+		messages = append(messages, nodeErr.Error())
+	}
+	return fmt.Sprintf("failed to collect data from %d of %d nodes: %s", len(e.Errors), e.Total, strings.Join(messages, "; "))
+}
+
+// PrintNodeErrors reports the nodes that could not be read on stderr, as a warning after the
+// results of the other nodes
+func PrintNodeErrors(partial *PartialNodeError) {
+	fmt.Fprintf(os.Stderr, "\nWarning: could not collect data from %d of %d nodes:\n", len(partial.Errors), partial.Total)
+	for _, nodeErr := range partial.Errors {
+		fmt.Fprintf(os.Stderr, "  %v\n", nodeErr)
+	}
+}
+
+// clusterNode is a node as listed in the cluster state
+type clusterNode struct {
+	ID               string   `json:"id,omitempty"`
+	Name             string   `json:"name"`
+	TransportAddress string   `json:"transport_address"`
+	Roles            []string `json:"roles"`
+}
+
+// getClusterNodes lists the nodes in the cluster from the cluster state. The cluster state is
+// served by the master, so a slow or unresponsive data node does not delay the listing.
+// The list is cached, as several lookups in a command map node IDs to names.
+func (c *Client) getClusterNodes() ([]clusterNode, error) {
+	return cachedLookup(c.cache, "cluster_nodes", c.fetchClusterNodes)
+}
+
+// fetchClusterNodes reads the node list from the cluster state
+func (c *Client) fetchClusterNodes() ([]clusterNode, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cluster.State(
+		c.es.Cluster.State.WithContext(ctx),
+		c.es.Cluster.State.WithMetric("nodes"),
+		c.es.Cluster.State.WithFilterPath("nodes"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster nodes: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]clusterNode `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	nodes := make([]clusterNode, 0, len(response.Nodes))
+	for id, node := range response.Nodes {
+		node.ID = id
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	return nodes, nil
+}
+
+// fanOutNodes calls fn for every node with at most nodeFanOutConcurrency calls running at once.
+// Failures are collected per node and returned as a PartialNodeError.
+func fanOutNodes(nodes []clusterNode, fn func(node clusterNode) error) error {
+	sem := make(chan struct{}, nodeFanOutConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var nodeErrors []NodeError
+
+	for _, node := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(node clusterNode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(node); err != nil {
+				mu.Lock()
+				nodeErrors = append(nodeErrors, NodeError{NodeID: node.ID, NodeName: node.Name, Err: err})
+				mu.Unlock()
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	if len(nodeErrors) == 0 {
+		return nil
+	}
+
+	sort.Slice(nodeErrors, func(i, j int) bool {
+		return nodeErrors[i].NodeName < nodeErrors[j].NodeName
+	})
+	return &PartialNodeError{Errors: nodeErrors, Total: len(nodes)}
+}
+
+// getNodeStatsByNode requests node stats from every node concurrently, one request per node, and
+// returns the stats keyed by node ID. If some nodes fail, the stats of the others are returned
+// together with a PartialNodeError; if every node fails only the error is returned.
+func (c *Client) getNodeStatsByNode(nodes []clusterNode, metrics ...string) (map[string]map[string]interface{}, error) {
+	var mu sync.Mutex
+	results := make(map[string]map[string]interface{}, len(nodes))
+
+	err := fanOutNodes(nodes, func(node clusterNode) error {
+		stats, err := c.getSingleNodeStats(node.ID, metrics)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		results[node.ID] = stats
+		mu.Unlock()
+		return nil
+	})
+	if err != nil && len(results) == 0 {
+		return nil, err
+	}
+
+	return results, err
+}
+
+// getSingleNodeStats returns the stats of a single node
+func (c *Client) getSingleNodeStats(nodeID string, metrics []string) (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Prepare options
+	opts := []func(*esapi.NodesStatsRequest){
+		c.es.Nodes.Stats.WithContext(ctx),
+		c.es.Nodes.Stats.WithNodeID(nodeID),
+	}
+	if len(metrics) > 0 {
+		opts = append(opts, c.es.Nodes.Stats.WithMetric(metrics...))
+	}
+
+	// Execute request
+	res, err := c.es.Nodes.Stats(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]map[string]interface{} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	stats, ok := response.Nodes[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("node did not return stats")
+	}
+
+	return stats, nil
+}
+
+// nodeStatFloat returns the number at a path in a node stats document
+func nodeStatFloat(stats map[string]interface{}, path ...string) (float64, bool) {
+	var current interface{} = stats
+	for _, key := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		current = m[key]
+	}
+	value, ok := current.(float64)
+	return value, ok
+}
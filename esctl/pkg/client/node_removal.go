@@ -0,0 +1,161 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IndexRedundancyImpact represents how an index is affected when a node leaves the cluster
+type IndexRedundancyImpact struct {
+	Index             string
+	ShardsOnNode      int
+	ReducedShards     int // shards that keep at least one started copy elsewhere
+	UnavailableShards int // shards whose only started copy lives on the node
+}
+
+// NodeDiskProjection represents the projected disk usage of a node after absorbing moved shards
+type NodeDiskProjection struct {
+	Name           string
+	DiskTotal      int64
+	DiskUsed       int64
+	ProjectedUsed  int64
+	ProjectedBytes int64
+}
+
+// ProjectedPercent returns the projected disk usage as a percentage of the total disk
+func (p NodeDiskProjection) ProjectedPercent() float64 {
+	if p.DiskTotal == 0 {
+		return 0
+	}
+	return float64(p.ProjectedUsed) / float64(p.DiskTotal) * 100
+}
+
+// NodeRemovalImpact summarises what would happen if a node stopped holding data
+type NodeRemovalImpact struct {
+	NodeName     string
+	ShardsToMove int
+	BytesToMove  int64
+	Indices      []IndexRedundancyImpact
+	Nodes        []NodeDiskProjection
+}
+
+// SimulateNodeRemoval reports, without changing anything, how many shards and bytes would have to
+// move off nodeName, which indices would temporarily lose redundancy, and the projected disk usage
+// of the remaining data nodes. Moved data is spread across the receiving nodes in proportion to
+// their free space, so projections are an estimate rather than a prediction of the allocator.
+func (c *Client) SimulateNodeRemoval(nodeName string) (*NodeRemovalImpact, error) {
+	shards, err := c.GetShards(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations, err := c.GetDiskAllocations()
+	if err != nil {
+		return nil, err
+	}
+
+	excludeSettings, err := c.GetClusterExcludeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(excludeSettings.ExcludeName))
+	for _, name := range excludeSettings.ExcludeName {
+		excluded[name] = true
+	}
+
+	impact := &NodeRemovalImpact{NodeName: nodeName}
+
+	// Count started copies of every shard held by other nodes
+	type shardKey struct {
+		index string
+		shard string
+	}
+	startedElsewhere := make(map[shardKey]int)
+	for _, shard := range shards {
+		if shard.Node != nodeName && shard.State == "STARTED" {
+			startedElsewhere[shardKey{shard.Index, shard.Shard}]++
+		}
+	}
+
+	// Work out the shards living on the node
+	byIndex := make(map[string]*IndexRedundancyImpact)
+	for _, shard := range shards {
+		if shard.Node != nodeName {
+			continue
+		}
+
+		impact.ShardsToMove++
+		if size, err := ParseByteSize(shard.Store); err == nil {
+			impact.BytesToMove += size
+		}
+
+		idx, ok := byIndex[shard.Index]
+		if !ok {
+			idx = &IndexRedundancyImpact{Index: shard.Index}
+			byIndex[shard.Index] = idx
+		}
+		idx.ShardsOnNode++
+
+		if startedElsewhere[shardKey{shard.Index, shard.Shard}] > 0 {
+			idx.ReducedShards++
+		} else {
+			idx.UnavailableShards++
+		}
+	}
+
+	if impact.ShardsToMove == 0 {
+		found := false
+		for _, alloc := range allocations {
+			if alloc.Node == nodeName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("node %s not found in allocation stats", nodeName)
+		}
+	}
+
+	for _, idx := range byIndex {
+		impact.Indices = append(impact.Indices, *idx)
+	}
+	sort.Slice(impact.Indices, func(i, j int) bool {
+		if impact.Indices[i].UnavailableShards != impact.Indices[j].UnavailableShards {
+			return impact.Indices[i].UnavailableShards > impact.Indices[j].UnavailableShards
+		}
+		return impact.Indices[i].Index < impact.Indices[j].Index
+	})
+
+	// Spread the moved bytes over the receiving nodes in proportion to their free space
+	var totalFree int64
+	for _, alloc := range allocations {
+		if alloc.Node != nodeName && !excluded[alloc.Node] {
+			totalFree += alloc.DiskAvail
+		}
+	}
+
+	for _, alloc := range allocations {
+		if alloc.Node == nodeName || excluded[alloc.Node] {
+			continue
+		}
+
+		var share int64
+		if totalFree > 0 {
+			share = int64(float64(impact.BytesToMove) * float64(alloc.DiskAvail) / float64(totalFree))
+		}
+
+		impact.Nodes = append(impact.Nodes, NodeDiskProjection{
+			Name:           alloc.Node,
+			DiskTotal:      alloc.DiskTotal,
+			DiskUsed:       alloc.DiskUsed,
+			ProjectedUsed:  alloc.DiskUsed + share,
+			ProjectedBytes: share,
+		})
+	}
+	sort.Slice(impact.Nodes, func(i, j int) bool {
+		return impact.Nodes[i].Name < impact.Nodes[j].Name
+	})
+
+	return impact, nil
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v9/esapi"
@@ -18,6 +20,7 @@ type NodeInfo struct {
 	Role            string `json:"role"`
 	HeapPercent     string `json:"heap.percent"`
 	RAMPercent      string `json:"ram.percent"`
+	RAMTotal        string `json:"ram.max"`
 	CPU             string `json:"cpu"`
 	Load1m          string `json:"load_1m"`
 	Load5m          string `json:"load_5m"`
@@ -26,41 +29,298 @@ type NodeInfo struct {
 	DiskTotal       string `json:"disk.total"`
 	DiskAvailable   string `json:"disk.avail"`
 	Uptime          string `json:"uptime"`
+	Version         string `json:"version,omitempty"`
+	JDK             string `json:"jdk,omitempty"`
+	OS              string `json:"os,omitempty"`
 }
 
-// GetNodes returns information about all nodes in the cluster
+// nodeRoleAbbreviations are the single letter role names used by the cat nodes API
+var nodeRoleAbbreviations = map[string]string{
+	"data":                  "d",
+	"data_cold":             "c",
+	"data_content":          "s",
+	"data_frozen":           "f",
+	"data_hot":              "h",
+	"data_warm":             "w",
+	"ingest":                "i",
+	"master":                "m",
+	"ml":                    "l",
+	"remote_cluster_client": "r",
+	"transform":             "t",
+	"voting_only":           "v",
+}
+
+// nodeRoleDescriptions describe the single letter role names, in the order they are listed in a legend
+var nodeRoleDescriptions = []struct {
+	letter      string
+	description string
+}{
+	{"m", "master-eligible"},
+	{"v", "voting only"},
+	{"d", "data (all tiers)"},
+	{"s", "data content"},
+	{"h", "data hot"},
+	{"w", "data warm"},
+	{"c", "data cold"},
+	{"f", "data frozen"},
+	{"i", "ingest"},
+	{"l", "machine learning"},
+	{"t", "transform"},
+	{"r", "remote cluster client"},
+}
+
+// GetNodes returns information about all nodes in the cluster. Stats are requested from each
+// node concurrently, so one slow node does not hold up the others. Nodes whose stats could not
+// be read are still listed, and the failures are returned as a PartialNodeError.
 func (c *Client) GetNodes() ([]NodeInfo, error) {
+	members, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, statsErr := c.getNodeStatsByNode(members, "os", "jvm", "fs")
+	if stats == nil {
+		return nil, statsErr
+	}
+
+	nodes := make([]NodeInfo, 0, len(members))
+	for _, member := range members {
+		node := NodeInfo{
+			ID:   member.ID,
+			Name: member.Name,
+			IP:   member.TransportAddress,
+			Role: abbreviateNodeRoles(member.Roles),
+		}
+		if host, _, found := strings.Cut(member.TransportAddress, ":"); found {
+			node.IP = host
+		}
+
+		if nodeStats, ok := stats[member.ID]; ok {
+			fillNodeInfoStats(&node, nodeStats)
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, statsErr
+}
+
+// FillNodeDetails sets the Elasticsearch version, JDK and operating system of each node from the
+// nodes info API. These are static, so they are only requested when asked for.
+func (c *Client) FillNodeDetails(nodes []NodeInfo) error {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Execute request
-	res, err := c.es.Cat.Nodes(
-		c.es.Cat.Nodes.WithContext(ctx),
-		c.es.Cat.Nodes.WithFormat("json"),
-		c.es.Cat.Nodes.WithH("id,name,ip,role,heap.percent,ram.percent,cpu,load_1m,load_5m,load_15m,disk.used_percent,disk.total,disk.avail,uptime"),
-		c.es.Cat.Nodes.WithFullID(true),
+	res, err := c.es.Nodes.Info(
+		c.es.Nodes.Info.WithContext(ctx),
+		c.es.Nodes.Info.WithMetric("jvm", "os"),
+		c.es.Nodes.Info.WithFilterPath("nodes.*.version", "nodes.*.jvm.version", "nodes.*.os.pretty_name", "nodes.*.os.arch"),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error getting response: %w", err)
+		return fmt.Errorf("error getting nodes info: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	// Parse response
-	var nodes []NodeInfo
-	if err := json.NewDecoder(res.Body).Decode(&nodes); err != nil {
-		return nil, fmt.Errorf("error parsing response: %w", err)
+	var response struct {
+		Nodes map[string]struct {
+			Version string `json:"version"`
+			JVM     struct {
+				Version string `json:"version"`
+			} `json:"jvm"`
+			OS struct {
+				PrettyName string `json:"pretty_name"`
+				Arch       string `json:"arch"`
+			} `json:"os"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	for i := range nodes {
+		info, ok := response.Nodes[nodes[i].ID]
+		if !ok {
+			continue
+		}
+		nodes[i].Version = info.Version
+		nodes[i].JDK = info.JVM.Version
+		nodes[i].OS = strings.TrimSpace(info.OS.PrettyName + " " + info.OS.Arch)
+	}
+
+	return nil
+}
+
+// fillNodeInfoStats sets the resource usage fields of a node from its stats, formatted the way
+// the cat nodes API formats them
+func fillNodeInfoStats(node *NodeInfo, stats map[string]interface{}) {
+	node.HeapPercent = formatNodeStat(stats, "%.0f", "jvm", "mem", "heap_used_percent")
+	node.RAMPercent = formatNodeStat(stats, "%.0f", "os", "mem", "used_percent")
+	if ram, ok := nodeStatFloat(stats, "os", "mem", "total_in_bytes"); ok {
+		node.RAMTotal = formatCatBytes(ram)
+	}
+	node.CPU = formatNodeStat(stats, "%.0f", "os", "cpu", "percent")
+	node.Load1m = formatNodeStat(stats, "%.2f", "os", "cpu", "load_average", "1m")
+	node.Load5m = formatNodeStat(stats, "%.2f", "os", "cpu", "load_average", "5m")
+	node.Load15m = formatNodeStat(stats, "%.2f", "os", "cpu", "load_average", "15m")
+
+	total, hasTotal := nodeStatFloat(stats, "fs", "total", "total_in_bytes")
+	available, hasAvailable := nodeStatFloat(stats, "fs", "total", "available_in_bytes")
+	if hasTotal && hasAvailable && total > 0 {
+		node.DiskTotal = formatCatBytes(total)
+		node.DiskAvailable = formatCatBytes(available)
+		node.DiskUsedPercent = fmt.Sprintf("%.2f", (total-available)/total*100)
+	}
+
+	if uptime, ok := nodeStatFloat(stats, "jvm", "uptime_in_millis"); ok {
+		node.Uptime = formatCatDuration(time.Duration(uptime) * time.Millisecond)
+	}
+}
+
+// formatNodeStat formats the number at a path in a node stats document, or returns "" if it is missing
+func formatNodeStat(stats map[string]interface{}, format string, path ...string) string {
+	value, ok := nodeStatFloat(stats, path...)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(format, value)
+}
+
+// formatCatBytes formats a byte count the way the cat APIs do, e.g. 99.5gb
+func formatCatBytes(bytes float64) string {
+	units := []string{"b", "kb", "mb", "gb", "tb", "pb"}
+	i := 0
+	for bytes >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", bytes), ".0") + units[i]
+}
+
+// formatCatDuration formats a duration the way the cat APIs do, e.g. 2.1d
+func formatCatDuration(d time.Duration) string {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	for _, unit := range units {
+		if d >= unit.size {
+			return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(d)/float64(unit.size)), ".0") + unit.suffix
+		}
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// abbreviateNodeRoles converts role names to the sorted single letter form used by the cat nodes API
+func abbreviateNodeRoles(roles []string) string {
+	var letters []string
+	for _, role := range roles {
+		if letter, ok := nodeRoleAbbreviations[role]; ok {
+			letters = append(letters, letter)
+		}
+	}
+	if len(letters) == 0 {
+		return "-"
+	}
+	sort.Strings(letters)
+	return strings.Join(letters, "")
+}
+
+// NodeRoleLegend explains the role letters used by a set of nodes, e.g. "m = master-eligible"
+func NodeRoleLegend(nodes []NodeInfo) []string {
+	used := make(map[string]bool)
+	for _, node := range nodes {
+		for _, letter := range node.Role {
+			used[string(letter)] = true
+		}
 	}
 
-	return nodes, nil
+	var legend []string
+	for _, role := range nodeRoleDescriptions {
+		if used[role.letter] {
+			legend = append(legend, fmt.Sprintf("%s = %s", role.letter, role.description))
+		}
+	}
+	return legend
 }
 
-// GetNodeStats returns detailed stats for a specific node
+// GetNodeStats returns detailed stats for the nodes matching a node filter. Node IDs, names and
+// wildcards are resolved locally and each node is asked for its stats concurrently; other node
+// filters (such as _local or attribute filters) are passed to Elasticsearch as a single request.
 func (c *Client) GetNodeStats(nodeID string) (map[string]interface{}, error) {
+	members, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	selected, resolved := selectNodes(members, nodeID)
+	if !resolved {
+		return c.getNodeStatsByFilter(nodeID)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no nodes match %s", nodeID)
+	}
+
+	stats, statsErr := c.getNodeStatsByNode(selected)
+	if stats == nil {
+		return nil, statsErr
+	}
+
+	nodes := make(map[string]interface{}, len(stats))
+	for id, nodeStats := range stats {
+		nodes[id] = nodeStats
+	}
+
+	return map[string]interface{}{
+		"_nodes": map[string]interface{}{
+			"total":      len(selected),
+			"successful": len(stats),
+			"failed":     len(selected) - len(stats),
+		},
+		"nodes": nodes,
+	}, statsErr
+}
+
+// selectNodes returns the nodes whose ID or name matches a comma-separated node filter. It reports
+// false if the filter uses selectors that can only be resolved by Elasticsearch.
+func selectNodes(nodes []clusterNode, filter string) ([]clusterNode, bool) {
+	if filter == "" || filter == "_all" {
+		return nodes, true
+	}
+
+	var patterns []string
+	for _, pattern := range strings.Split(filter, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if strings.HasPrefix(pattern, "_") || strings.Contains(pattern, ":") {
+			return nil, false
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	var selected []clusterNode
+	for _, node := range nodes {
+		for _, pattern := range patterns {
+			if wildcardMatch(pattern, node.ID) || wildcardMatch(pattern, node.Name) {
+				selected = append(selected, node)
+				break
+			}
+		}
+	}
+	return selected, true
+}
+
+// getNodeStatsByFilter returns node stats for a node filter with a single request
+func (c *Client) getNodeStatsByFilter(nodeID string) (map[string]interface{}, error) {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -76,7 +336,7 @@ func (c *Client) GetNodeStats(nodeID string) (map[string]interface{}, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -113,7 +373,7 @@ func (c *Client) GetNodeHotThreads(nodeID string) (string, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return "", fmt.Errorf("error response: %s", res.String())
+		return "", newResponseError(res)
 	}
 
 	// Read response body as string
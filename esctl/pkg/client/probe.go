@@ -0,0 +1,221 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+)
+
+// probeStepTimeout limits each step of a probe, a cluster that cannot answer in time is unavailable
+const probeStepTimeout = 10 * time.Second
+
+// probeIndex is the index probes write to when none is given, under the tenant prefix if one is set
+const probeIndex = "healthcheck-probe"
+
+// DefaultProbeIndex returns the index probes write to when none is given
+func DefaultProbeIndex(cfg *config.Config) string {
+	return cfg.Elasticsearch.TenantPrefix + probeIndex
+}
+
+// ProbeStep is the outcome of one step of an availability probe
+type ProbeStep struct {
+	Step     string // write, refresh, search or delete
+	Duration time.Duration
+	Err      error
+	Skipped  bool // not run because an earlier step failed
+}
+
+// ProbeError reports the steps of an availability probe that failed
+type ProbeError struct {
+	Steps []ProbeStep // failed steps, in the order they ran
+}
+
+// NewProbeError returns a ProbeError for the failed steps of a probe, or nil if none failed
+func NewProbeError(steps []ProbeStep) error {
+	var failed []ProbeStep
+	for _, step := range steps {
+		if step.Err != nil {
+			failed = append(failed, step)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &ProbeError{Steps: failed}
+}
+
+func (e *ProbeError) Error() string {
+	names := make([]string, len(e.Steps))
+	for i, step := range e.Steps {
+		names[i] = step.Step
+	}
+	return fmt.Sprintf("probe failed at %s: %v", strings.Join(names, ", "), e.Steps[0].Err)
+}
+
+// Unwrap returns the error of the first failed step, so ExitCode classifies the probe by it
+func (e *ProbeError) Unwrap() error {
+	return e.Steps[0].Err
+}
+
+// ProbeAvailability checks that the cluster accepts writes and serves reads end to end: it
+// indexes a document into index, refreshes the index, searches for the document and deletes it
+// again, timing each step. Steps after a failed one are skipped, except that a written document
+// is always deleted. The index is created on the first write if it does not exist and is left in
+// place afterwards, so it should not match a data stream template.
+func (c *Client) ProbeAvailability(index string) []ProbeStep {
+	hostname, _ := os.Hostname()
+	id := fmt.Sprintf("esctl-probe-%d", time.Now().UnixNano())
+	doc := map[string]interface{}{
+		"@timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+		"probe":      "esctl",
+		"host":       hostname,
+	}
+
+	steps := []ProbeStep{
+		c.probeStep("write", func(ctx context.Context) error { return c.probeWrite(ctx, index, id, doc) }),
+	}
+	written := steps[0].Err == nil
+
+	for _, step := range []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{"refresh", func(ctx context.Context) error { return c.probeRefresh(ctx, index) }},
+		{"search", func(ctx context.Context) error { return c.probeSearch(ctx, index, id) }},
+	} {
+		if steps[len(steps)-1].Err != nil || steps[len(steps)-1].Skipped {
+			steps = append(steps, ProbeStep{Step: step.name, Skipped: true})
+			continue
+		}
+		steps = append(steps, c.probeStep(step.name, step.run))
+	}
+
+	if written {
+		steps = append(steps, c.probeStep("delete", func(ctx context.Context) error { return c.probeDelete(ctx, index, id) }))
+	} else {
+		steps = append(steps, ProbeStep{Step: "delete", Skipped: true})
+	}
+	return steps
+}
+
+// probeStep runs and times a single probe step
+func (c *Client) probeStep(name string, run func(ctx context.Context) error) ProbeStep {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), probeStepTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := run(ctx)
+	return ProbeStep{Step: name, Duration: time.Since(start), Err: err}
+}
+
+// probeWrite indexes the probe document
+func (c *Client) probeWrite(ctx context.Context, index, id string, doc map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(doc); err != nil {
+		return fmt.Errorf("error encoding probe document: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Index(
+		index,
+		&buf,
+		c.es.Index.WithContext(ctx),
+		c.es.Index.WithDocumentID(id),
+		c.es.Index.WithOpType("create"),
+	)
+	if err != nil {
+		return fmt.Errorf("error indexing probe document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// probeRefresh refreshes the probe index so the document becomes searchable
+func (c *Client) probeRefresh(ctx context.Context, index string) error {
+	// Execute request
+	res, err := c.es.Indices.Refresh(
+		c.es.Indices.Refresh.WithContext(ctx),
+		c.es.Indices.Refresh.WithIndex(index),
+	)
+	if err != nil {
+		return fmt.Errorf("error refreshing probe index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// probeSearch searches for the probe document by ID
+func (c *Client) probeSearch(ctx context.Context, index, id string) error {
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"ids": map[string]interface{}{"values": []string{id}}},
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithIndex(index),
+		c.es.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return fmt.Errorf("error searching for probe document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(response.Hits.Hits) == 0 {
+		return fmt.Errorf("probe document %s not found after refresh", id)
+	}
+	return nil
+}
+
+// probeDelete deletes the probe document
+func (c *Client) probeDelete(ctx context.Context, index, id string) error {
+	// Execute request
+	res, err := c.es.Delete(
+		index,
+		id,
+		c.es.Delete.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting probe document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+)
+
+// RecoverySettings lists the cluster settings that control recovery throttling and concurrency
+var RecoverySettings = []string{
+	"indices.recovery.max_bytes_per_sec",
+	"indices.recovery.max_concurrent_file_chunks",
+	"indices.recovery.max_concurrent_operations",
+	"cluster.routing.allocation.node_concurrent_recoveries",
+	"cluster.routing.allocation.node_concurrent_incoming_recoveries",
+	"cluster.routing.allocation.node_concurrent_outgoing_recoveries",
+	"cluster.routing.allocation.node_initial_primaries_recoveries",
+}
+
+// RecoverySetting represents the current value of a recovery setting and where it comes from
+type RecoverySetting struct {
+	Name   string
+	Value  string
+	Source string // transient, persistent, default or unset
+}
+
+// GetRecoverySettings returns the effective value of every recovery setting
+func (c *Client) GetRecoverySettings() ([]RecoverySetting, error) {
+	settings, err := c.GetClusterSettings(true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]RecoverySetting, 0, len(RecoverySettings))
+	for _, name := range RecoverySettings {
+		setting := RecoverySetting{Name: name, Value: "-", Source: "unset"}
+
+		// Transient settings take precedence over persistent ones, which take precedence over defaults
+		for _, source := range []string{"transient", "persistent", "defaults"} {
+			if value, ok := settings[source][name]; ok && value != nil {
+				setting.Value = fmt.Sprintf("%v", value)
+				setting.Source = source
+				if source == "defaults" {
+					setting.Source = "default"
+				}
+				break
+			}
+		}
+
+		result = append(result, setting)
+	}
+
+	return result, nil
+}
+
+// SetRecoverySettings applies recovery settings together in a single cluster settings update
+// A nil value resets the setting to its default
+func (c *Client) SetRecoverySettings(settingType string, values map[string]*string) error {
+	settings := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		if value == nil {
+			settings[name] = nil
+		} else {
+			settings[name] = *value
+		}
+	}
+
+	return c.UpdateClusterSettings(settingType, settings)
+}
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportDimensions is the page size PNG reports are rendered at, as Kibana needs one for them
+var reportDimensions = map[string]interface{}{"width": 1920, "height": 1080}
+
+// ReportRequest describes a report to generate from a dashboard or a saved search
+type ReportRequest struct {
+	Format        string // pdf or png for a dashboard, csv for a saved search
+	DashboardID   string
+	SavedSearchID string
+	Title         string // title of the report, shown in Kibana's report listing
+	From          string // start of the time range, e.g. now-7d; the saved time range when empty
+	To            string // end of the time range, e.g. now
+}
+
+// ReportJob is a reporting job submitted to Kibana
+type ReportJob struct {
+	ID           string
+	DownloadPath string // path of the artifact, including the space
+}
+
+// GenerateReport submits a reporting job and returns it without waiting for the report
+func (c *KibanaClient) GenerateReport(request ReportRequest) (*ReportJob, error) {
+	jobType, jobParams, err := c.reportJobParams(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the request
+	requestURL := fmt.Sprintf("%s/api/reporting/generate/%s?jobParams=%s", c.baseURL, jobType, url.QueryEscape(encodeRison(jobParams)))
+	req, err := http.NewRequest("POST", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	// Parse the response
+	var response struct {
+		Path string `json:"path"`
+		Job  struct {
+			ID string `json:"id"`
+		} `json:"job"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return &ReportJob{ID: response.Job.ID, DownloadPath: response.Path}, nil
+}
+
+// WaitForReport polls a reporting job every interval until its report is ready and returns the
+// report. It fails when the job fails or is not done within timeout.
+func (c *KibanaClient) WaitForReport(job *ReportJob, interval, timeout time.Duration) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		content, done, err := c.downloadReport(job)
+		if err != nil {
+			return nil, fmt.Errorf("report job %s failed: %w", job.ID, err)
+		}
+		if done {
+			return content, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("report job %s was not done after %s", job.ID, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// downloadReport downloads the report of a job, reporting false while the job is pending or
+// being processed
+func (c *KibanaClient) downloadReport(job *ReportJob) ([]byte, bool, error) {
+	// Create the request
+	req, err := http.NewRequest("GET", c.address+job.DownloadPath, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Kibana answers 503 until the job is completed
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, newHTTPError(resp)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading report: %w", err)
+	}
+	return content, true, nil
+}
+
+// reportJobParams returns the export type and job parameters Kibana expects for a report
+func (c *KibanaClient) reportJobParams(request ReportRequest) (string, map[string]interface{}, error) {
+	status, err := c.Ping()
+	if err != nil {
+		return "", nil, fmt.Errorf("error getting Kibana version: %w", err)
+	}
+	version, _ := status["version"].(map[string]interface{})
+	number, _ := version["number"].(string)
+	if number == "" {
+		return "", nil, fmt.Errorf("no version in the Kibana status response")
+	}
+
+	params := map[string]interface{}{}
+	if request.From != "" || request.To != "" {
+		timeRange := map[string]interface{}{"from": request.From, "to": request.To}
+		if request.To == "" {
+			timeRange["to"] = "now"
+		}
+		params["timeRange"] = timeRange
+	}
+
+	jobParams := map[string]interface{}{
+		"browserTimezone": reportTimezone(),
+		"title":           request.Title,
+		"version":         number,
+	}
+
+	switch request.Format {
+	case "pdf", "png":
+		if request.DashboardID == "" {
+			return "", nil, fmt.Errorf("%s reports need a dashboard", request.Format)
+		}
+		params["dashboardId"] = request.DashboardID
+		params["preserveSavedFilters"] = true
+		params["useHash"] = false
+		params["viewMode"] = "view"
+		locator := map[string]interface{}{"id": "DASHBOARD_APP_LOCATOR", "params": params, "version": number}
+		jobParams["objectType"] = "dashboard"
+
+		if request.Format == "png" {
+			jobParams["layout"] = map[string]interface{}{"id": "preserve_layout", "dimensions": reportDimensions}
+			jobParams["locatorParams"] = locator
+			return "pngV2", jobParams, nil
+		}
+		jobParams["layout"] = map[string]interface{}{"id": "print"}
+		jobParams["locatorParams"] = []interface{}{locator}
+		return "printablePdfV2", jobParams, nil
+
+	case "csv":
+		if request.SavedSearchID == "" {
+			return "", nil, fmt.Errorf("csv reports need a saved search")
+		}
+		params["savedSearchId"] = request.SavedSearchID
+		jobParams["objectType"] = "search"
+		jobParams["locatorParams"] = []interface{}{
+			map[string]interface{}{"id": "DISCOVER_APP_LOCATOR", "params": params, "version": number},
+		}
+		return "csv_v2", jobParams, nil
+	}
+
+	return "", nil, fmt.Errorf("unknown report format %q, use pdf, png or csv", request.Format)
+}
+
+// reportTimezone returns the IANA name of the local time zone, which Kibana renders dates in
+func reportTimezone() string {
+	if name := time.Local.String(); name != "" && name != "Local" {
+		return name
+	}
+	return "UTC"
+}
+
+// encodeRison encodes a value as Rison, the URL-friendly JSON variant Kibana takes job parameters in
+func encodeRison(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "!n"
+	case bool:
+		if v {
+			return "!t"
+		}
+		return "!f"
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return risonString(v)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, encodeRison(item))
+		}
+		return "!(" + strings.Join(items, ",") + ")"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var b bytes.Buffer
+		b.WriteByte('(')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(risonString(key))
+			b.WriteByte(':')
+			b.WriteString(encodeRison(v[key]))
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+	return risonString(fmt.Sprintf("%v", value))
+}
+
+// risonString leaves a string bare when Rison allows it as an identifier and quotes it otherwise
+func risonString(s string) string {
+	if s != "" && !strings.ContainsAny(s[:1], "-0123456789") && !strings.ContainsAny(s, " '!:(),*@$") {
+		return s
+	}
+	return "'" + strings.NewReplacer("!", "!!", "'", "!'").Replace(s) + "'"
+}
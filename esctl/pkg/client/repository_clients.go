@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RepositoryClientError is returned when a repository client is not configured on every node
+type RepositoryClientError struct {
+	Type         string   // repository type, e.g. s3
+	Client       string   // client name, e.g. prod-backups
+	MissingNodes []string // names of the nodes without the client
+	TotalNodes   int
+}
+
+// Error implements the error interface for RepositoryClientError, explaining how to add the client
+func (e *RepositoryClientError) Error() string {
+	prefix := fmt.Sprintf("%s.client.%s", e.Type, e.Client)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s client '%s' is not configured on %d of %d nodes: %s\n",
+		e.Type, e.Client, len(e.MissingNodes), e.TotalNodes, strings.Join(e.MissingNodes, ", "))
+	fmt.Fprintf(&b, "To configure it, on each of those nodes:\n")
+	switch e.Type {
+	case "s3":
+		fmt.Fprintf(&b, "  bin/elasticsearch-keystore add %s.access_key\n", prefix)
+		fmt.Fprintf(&b, "  bin/elasticsearch-keystore add %s.secret_key\n", prefix)
+	case "azure":
+		fmt.Fprintf(&b, "  bin/elasticsearch-keystore add %s.account\n", prefix)
+		fmt.Fprintf(&b, "  bin/elasticsearch-keystore add %s.key\n", prefix)
+	case "gcs":
+		fmt.Fprintf(&b, "  bin/elasticsearch-keystore add-file %s.credentials_file service-account.json\n", prefix)
+	}
+	fmt.Fprintf(&b, "  add at least one %s.* setting (e.g. endpoint) to elasticsearch.yml and restart the node,\n", prefix)
+	fmt.Fprintf(&b, "  or, if only keystore entries changed, run POST _nodes/reload_secure_settings\n")
+	fmt.Fprintf(&b, "Use --skip-client-check if the client is configured through keystore entries only")
+	return b.String()
+}
+
+// CheckRepositoryClient checks, with the nodes info API, that every node has settings for the
+// named client of a repository type (e.g. s3.client.prod-backups.*). The default client is
+// always available. Keystore entries are not visible through the API, so a client configured
+// only through the keystore is reported as missing.
+func (c *Client) CheckRepositoryClient(repoType, clientName string) error {
+	if clientName == "" || clientName == "default" {
+		return nil
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prefix := fmt.Sprintf("%s.client.%s.", repoType, clientName)
+
+	// Execute request
+	res, err := c.es.Nodes.Info(
+		c.es.Nodes.Info.WithContext(ctx),
+		c.es.Nodes.Info.WithMetric("settings"),
+		c.es.Nodes.Info.WithFlatSettings(true),
+		c.es.Nodes.Info.WithFilterPath("nodes.*.name", "nodes.*.settings."+prefix+"*"),
+	)
+	if err != nil {
+		return fmt.Errorf("error getting node settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]struct {
+			Name     string                 `json:"name"`
+			Settings map[string]interface{} `json:"settings"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var missing []string
+	for _, node := range response.Nodes {
+		found := false
+		for key := range node.Settings {
+			if strings.HasPrefix(key, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, node.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return &RepositoryClientError{
+			Type:         repoType,
+			Client:       clientName,
+			MissingNodes: missing,
+			TotalNodes:   len(response.Nodes),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Reroute command types
+const (
+	RerouteMove                 = "move"
+	RerouteCancel               = "cancel"
+	RerouteAllocateStalePrimary = "allocate_stale_primary"
+	RerouteAllocateEmptyPrimary = "allocate_empty_primary"
+)
+
+// RerouteCommand is a single command of a cluster reroute request
+type RerouteCommand struct {
+	Type           string // one of the Reroute constants
+	Index          string
+	Shard          int
+	Node           string // node to cancel on or allocate to, or the node to move to
+	FromNode       string // move only, node the shard moves from
+	AllowPrimary   bool   // cancel only, allow cancelling the recovery of a primary
+	AcceptDataLoss bool   // allocate primary only, acknowledge that documents may be lost
+}
+
+// RerouteDecision is the decision of one allocation decider on a reroute command
+type RerouteDecision struct {
+	Decider     string
+	Decision    string // YES, NO or THROTTLE
+	Explanation string
+}
+
+// ShardCopy is a copy of a shard in the routing table
+type ShardCopy struct {
+	Primary        bool
+	State          string // STARTED, INITIALIZING, RELOCATING or UNASSIGNED
+	Node           string // node name, empty when unassigned
+	RelocatingNode string // node name the copy is relocating to, if any
+}
+
+// RerouteResult is the outcome of a reroute command
+type RerouteResult struct {
+	Decisions []RerouteDecision
+	Copies    []ShardCopy // copies of the shard in the routing table after the command
+}
+
+// Rejected reports whether a decider refused the command, in which case it was not applied
+func (r *RerouteResult) Rejected() bool {
+	for _, d := range r.Decisions {
+		if d.Decision == "NO" {
+			return true
+		}
+	}
+	return false
+}
+
+// Reroute runs a reroute command with explanations. With dryRun the command is only simulated and
+// the routing table shows where the shard copies would end up; otherwise the command is applied
+// unless a decider refuses it.
+func (c *Client) Reroute(command RerouteCommand, dryRun bool) (*RerouteResult, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	parameters := map[string]interface{}{
+		"index": command.Index,
+		"shard": command.Shard,
+	}
+	switch command.Type {
+	case RerouteMove:
+		parameters["from_node"] = command.FromNode
+		parameters["to_node"] = command.Node
+	case RerouteCancel:
+		parameters["node"] = command.Node
+		parameters["allow_primary"] = command.AllowPrimary
+	case RerouteAllocateStalePrimary, RerouteAllocateEmptyPrimary:
+		parameters["node"] = command.Node
+		parameters["accept_data_loss"] = command.AcceptDataLoss
+	default:
+		return nil, fmt.Errorf("invalid reroute command %q", command.Type)
+	}
+
+	body := map[string]interface{}{
+		"commands": []interface{}{map[string]interface{}{command.Type: parameters}},
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Cluster.Reroute(
+		c.es.Cluster.Reroute.WithContext(ctx),
+		c.es.Cluster.Reroute.WithBody(&buf),
+		c.es.Cluster.Reroute.WithDryRun(dryRun),
+		c.es.Cluster.Reroute.WithExplain(true),
+		c.es.Cluster.Reroute.WithMetric("nodes", "routing_table"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error rerouting shard: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		State struct {
+			Nodes map[string]struct {
+				Name string `json:"name"`
+			} `json:"nodes"`
+			RoutingTable struct {
+				Indices map[string]struct {
+					Shards map[string][]struct {
+						State          string `json:"state"`
+						Primary        bool   `json:"primary"`
+						Node           string `json:"node"`
+						RelocatingNode string `json:"relocating_node"`
+					} `json:"shards"`
+				} `json:"indices"`
+			} `json:"routing_table"`
+		} `json:"state"`
+		Explanations []struct {
+			Decisions []struct {
+				Decider     string `json:"decider"`
+				Decision    string `json:"decision"`
+				Explanation string `json:"explanation"`
+			} `json:"decisions"`
+		} `json:"explanations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	result := &RerouteResult{}
+	for _, explanation := range response.Explanations {
+		for _, d := range explanation.Decisions {
+			result.Decisions = append(result.Decisions, RerouteDecision{Decider: d.Decider, Decision: d.Decision, Explanation: d.Explanation})
+		}
+	}
+
+	nodeName := func(id string) string {
+		if node, ok := response.State.Nodes[id]; ok && node.Name != "" {
+			return node.Name
+		}
+		return id
+	}
+	for _, shard := range response.State.RoutingTable.Indices[command.Index].Shards[strconv.Itoa(command.Shard)] {
+		shardCopy := ShardCopy{Primary: shard.Primary, State: shard.State}
+		if shard.Node != "" {
+			shardCopy.Node = nodeName(shard.Node)
+		}
+		if shard.RelocatingNode != "" {
+			shardCopy.RelocatingNode = nodeName(shard.RelocatingNode)
+		}
+		result.Copies = append(result.Copies, shardCopy)
+	}
+	sort.SliceStable(result.Copies, func(i, j int) bool { return result.Copies[i].Primary && !result.Copies[j].Primary })
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of name a ResolvedIndex can come from
+const (
+	ResolvedKindIndex      = "index"
+	ResolvedKindAlias      = "alias"
+	ResolvedKindDataStream = "data stream"
+)
+
+// ResolvedIndex is the set of concrete indices an index name, alias, data stream or wildcard refers to
+type ResolvedIndex struct {
+	Name        string
+	Kind        string
+	Indices     []string
+	DataStreams []string // data streams the name refers to, whose backing indices are in Indices
+
+	backing map[string]bool // backing indices of DataStreams
+}
+
+// Indirect reports whether the name is not simply the one concrete index of the same name
+func (r *ResolvedIndex) Indirect() bool {
+	return len(r.Indices) != 1 || r.Indices[0] != r.Name
+}
+
+// StandaloneIndices returns the indices that do not back one of the data streams the name
+// refers to, which are removed with the data stream rather than on their own
+func (r *ResolvedIndex) StandaloneIndices() []string {
+	var indices []string
+	for _, index := range r.Indices {
+		if !r.backing[index] {
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
+
+// Target returns the concrete indices as a comma-separated list for use in a request
+func (r *ResolvedIndex) Target() string {
+	return strings.Join(r.Indices, ",")
+}
+
+// ResolveIndexName resolves an index name, alias, data stream or wildcard pattern to the concrete
+// indices it refers to, including closed indices. Wildcard patterns do not match hidden indices,
+// and aliases matching them are not expanded, so that only indices and data streams whose names
+// match are included.
+func (c *Client) ResolveIndexName(name string) (*ResolvedIndex, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.ResolveIndex(
+		[]string{name},
+		c.es.Indices.ResolveIndex.WithContext(ctx),
+		c.es.Indices.ResolveIndex.WithExpandWildcards("open,closed"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Indices []struct {
+			Name string `json:"name"`
+		} `json:"indices"`
+		Aliases []struct {
+			Name    string   `json:"name"`
+			Indices []string `json:"indices"`
+		} `json:"aliases"`
+		DataStreams []struct {
+			Name           string   `json:"name"`
+			BackingIndices []string `json:"backing_indices"`
+		} `json:"data_streams"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	resolved := &ResolvedIndex{Name: name, Kind: ResolvedKindIndex, backing: make(map[string]bool)}
+	seen := make(map[string]bool)
+	add := func(indices ...string) {
+		for _, index := range indices {
+			if !seen[index] {
+				seen[index] = true
+				resolved.Indices = append(resolved.Indices, index)
+			}
+		}
+	}
+
+	for _, index := range response.Indices {
+		add(index.Name)
+	}
+	if !strings.Contains(name, "*") {
+		for _, alias := range response.Aliases {
+			resolved.Kind = ResolvedKindAlias
+			add(alias.Indices...)
+		}
+	}
+	for _, stream := range response.DataStreams {
+		resolved.Kind = ResolvedKindDataStream
+		resolved.DataStreams = append(resolved.DataStreams, stream.Name)
+		for _, index := range stream.BackingIndices {
+			resolved.backing[index] = true
+		}
+		add(stream.BackingIndices...)
+	}
+
+	if len(resolved.Indices) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Type: "index_not_found_exception", Reason: fmt.Sprintf("no such index, alias or data stream [%s]", name)}
+	}
+	sort.Strings(resolved.Indices)
+
+	return resolved, nil
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+)
+
+// responseCaches are the response caches of the process by cluster and user, shared by every
+// client so that --watch loops, which create a client per refresh, reuse responses in memory
+var (
+	responseCachesMu sync.Mutex
+	responseCaches   = make(map[string]*lookupCache)
+)
+
+// cachedResponse is a GET response kept by responseCacheTransport
+type cachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Fresh      time.Time   `json:"fresh"` // served without asking the cluster until then
+}
+
+// sharedResponseCache returns the process-wide response cache for a cluster and user, creating
+// it on first use
+func sharedResponseCache(cc config.CacheConfig, addresses []string, username string) (*lookupCache, error) {
+	cache, err := newLookupCache(cc, addresses, username)
+	if err != nil {
+		return nil, err
+	}
+
+	responseCachesMu.Lock()
+	defer responseCachesMu.Unlock()
+	if shared, ok := responseCaches[cache.namespace]; ok {
+		return shared, nil
+	}
+	responseCaches[cache.namespace] = cache
+	return cache, nil
+}
+
+// responseCacheTransport caches GET responses of endpoints that rarely change, node info,
+// templates and ingest pipelines, so repeated commands do not load busy master nodes with the
+// same requests. A response is served from the cache for the cache TTL and fetched again after
+// that; Elasticsearch sends no ETag or other validator on these endpoints, so there is no
+// cheaper revalidation. Any other request to the same kind of endpoint, such as putting a
+// template, clears the cached responses of that kind.
+type responseCacheTransport struct {
+	next  http.RoundTripper
+	cache *lookupCache
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *responseCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	kind := cacheableResponseKind(req.URL.Path)
+	if kind == "" || req.Method == http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+	if req.Method != http.MethodGet {
+		t.cache.invalidate(kind)
+		return t.next.RoundTrip(req)
+	}
+
+	// Any node answers with the same content, leave the host out of the key
+	key := kind + ":" + req.URL.RequestURI()
+	if entry, ok := t.lookup(key); ok && time.Now().Before(entry.Fresh) {
+		return entry.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	entry := cachedResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body, Fresh: time.Now().Add(t.cache.ttl)}
+	t.cache.store(key, entry)
+	t.cache.writeDisk(key, entry)
+	return entry.response(req), nil
+}
+
+// lookup returns a cached response from memory or disk
+func (t *responseCacheTransport) lookup(key string) (cachedResponse, bool) {
+	t.cache.mu.Lock()
+	value, ok := t.cache.entries[key]
+	t.cache.mu.Unlock()
+	if ok {
+		return value.(cachedResponse), true
+	}
+
+	entry, ok := readDiskCache[cachedResponse](t.cache, key)
+	if ok {
+		t.cache.store(key, entry)
+	}
+	return entry, ok
+}
+
+// response builds an HTTP response for req from the cached response
+func (r cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// cacheableResponseKind returns the cache kind of the responses of an API path, or "" when its
+// responses are not cached. Node stats, usage and hot threads change all the time and are not
+// cached, and neither are the simulate APIs, which are reads sent as POST.
+func cacheableResponseKind(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch segments[0] {
+	case "_nodes":
+		for _, segment := range segments[1:] {
+			switch segment {
+			case "stats", "usage", "hot_threads", "hotthreads", "_repositories_metering":
+				return ""
+			}
+		}
+		return "response_nodes"
+	case "_index_template", "_component_template", "_template":
+		if len(segments) > 1 && strings.HasPrefix(segments[1], "_simulate") {
+			return ""
+		}
+		return "response" + segments[0]
+	case "_ingest":
+		if len(segments) > 1 && segments[1] == "pipeline" && segments[len(segments)-1] != "_simulate" {
+			return "response_ingest_pipeline"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RestorePreviewEntry describes what a restore would do with a single index from a snapshot
+type RestorePreviewEntry struct {
+	Index    string // index name in the snapshot
+	Selected bool   // whether the index matches the requested indices
+	Target   string // index name after rename_pattern/rename_replacement
+	Status   string // NEW, RENAMED, OVERWRITE, CONFLICT, DUPLICATE TARGET, INVALID NAME or SKIPPED
+	Detail   string
+}
+
+// javaGroupReference matches $1 style group references in a Java regex replacement
+var javaGroupReference = regexp.MustCompile(`\$(\d+)`)
+
+// GetSnapshot returns a single snapshot from a repository
+func (c *Client) GetSnapshot(repository, name string) (*SnapshotInfo, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Snapshot.Get(
+		repository,
+		[]string{name},
+		c.es.Snapshot.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting snapshot: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Snapshots []SnapshotInfo `json:"snapshots"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	if len(response.Snapshots) == 0 {
+		return nil, fmt.Errorf("snapshot %s not found in repository %s", name, repository)
+	}
+
+	return &response.Snapshots[0], nil
+}
+
+// PreviewRestore reports, without restoring anything, which indices in a snapshot would be
+// restored, what they would be called after renaming, and whether they would collide with
+// indices that already exist in the cluster. A closed index with the same name is overwritten
+// by a restore, so those entries are reported as OVERWRITE.
+func (c *Client) PreviewRestore(repository, name string, indices []string, renamePattern, renameReplacement string) ([]RestorePreviewEntry, error) {
+	snapshot, err := c.GetSnapshot(repository, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compile the rename rule the same way RestoreSnapshot applies it
+	var rename *regexp.Regexp
+	var replacement string
+	if renamePattern != "" && renameReplacement != "" {
+		rename, err = regexp.Compile(renamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("rename pattern %q cannot be previewed: %w", renamePattern, err)
+		}
+		replacement = javaGroupReference.ReplaceAllString(renameReplacement, "$${$1}")
+	}
+
+	// Existing indices and their state
+	existing, err := c.GetIndices("")
+	if err != nil {
+		return nil, err
+	}
+	existingStatus := make(map[string]string, len(existing))
+	for _, idx := range existing {
+		existingStatus[idx.Name] = idx.Status
+	}
+
+	entries := make([]RestorePreviewEntry, 0, len(snapshot.Indices))
+	targets := make(map[string]int)
+	for _, index := range snapshot.Indices {
+		entry := RestorePreviewEntry{Index: index, Target: index}
+		entry.Selected = MatchIndexPatterns(index, indices)
+		if !entry.Selected {
+			entry.Status = "SKIPPED"
+			entry.Target = "-"
+			entries = append(entries, entry)
+			continue
+		}
+
+		if rename != nil {
+			entry.Target = rename.ReplaceAllString(index, replacement)
+		}
+		targets[entry.Target]++
+		entries = append(entries, entry)
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if !entry.Selected {
+			continue
+		}
+
+		nameErr := ValidateIndexName(entry.Target)
+		switch {
+		case entry.Target != entry.Index && nameErr != nil:
+			entry.Status = "INVALID NAME"
+			entry.Detail = nameErr.Error()
+		case targets[entry.Target] > 1:
+			entry.Status = "DUPLICATE TARGET"
+			entry.Detail = fmt.Sprintf("%d snapshot indices would be restored as %s", targets[entry.Target], entry.Target)
+		case existingStatus[entry.Target] == "close":
+			entry.Status = "OVERWRITE"
+			entry.Detail = "closed index with this name exists and would be overwritten"
+		case existingStatus[entry.Target] != "":
+			entry.Status = "CONFLICT"
+			entry.Detail = "open index with this name exists, restore would fail"
+		case entry.Target != entry.Index:
+			entry.Status = "RENAMED"
+		default:
+			entry.Status = "NEW"
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Selected != entries[j].Selected {
+			return entries[i].Selected
+		}
+		return entries[i].Index < entries[j].Index
+	})
+
+	return entries, nil
+}
+
+// MatchIndexPatterns reports whether an index name matches a list of index expressions.
+// Expressions support * wildcards and a leading - to exclude previously matched names.
+// An empty list matches every index.
+func MatchIndexPatterns(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	matched := false
+	for _, pattern := range patterns {
+		for _, p := range strings.Split(pattern, ",") {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			if strings.HasPrefix(p, "-") {
+				if matched && wildcardMatch(strings.TrimPrefix(p, "-"), name) {
+					matched = false
+				}
+				continue
+			}
+			if wildcardMatch(p, name) {
+				matched = true
+			}
+		}
+	}
+
+	return matched
+}
+
+// wildcardMatch matches a name against a pattern where * matches any sequence of characters
+func wildcardMatch(pattern, name string) bool {
+	if pattern == "*" || pattern == "_all" {
+		return true
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
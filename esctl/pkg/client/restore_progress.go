@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ShardRestore is the progress of restoring a primary shard from a snapshot
+type ShardRestore struct {
+	Index          string // index name in the cluster, after any renaming
+	Shard          int
+	Stage          string // INIT, INDEX, VERIFY_INDEX, TRANSLOG, FINALIZE or DONE
+	Node           string
+	TotalBytes     int64 // bytes to copy from the repository
+	RecoveredBytes int64
+	Elapsed        time.Duration
+}
+
+// Percent returns the share of the bytes of the shard copied so far
+func (s ShardRestore) Percent() float64 {
+	if s.Stage == "DONE" {
+		return 100
+	}
+	if s.TotalBytes == 0 {
+		return 0
+	}
+	return 100 * float64(s.RecoveredBytes) / float64(s.TotalBytes)
+}
+
+// RestoreProgress is the progress of restoring a snapshot
+type RestoreProgress struct {
+	Shards  []ShardRestore // shards that started restoring, sorted by index and shard
+	Pending int            // primary shards of the restored indices that have not started yet
+}
+
+// Done reports whether every primary shard of the restored indices has been restored
+func (p *RestoreProgress) Done() bool {
+	if len(p.Shards) == 0 || p.Pending > 0 {
+		return false
+	}
+	for _, shard := range p.Shards {
+		if shard.Stage != "DONE" {
+			return false
+		}
+	}
+	return true
+}
+
+// Bytes returns the bytes copied so far and the bytes to copy, of the shards that have started
+func (p *RestoreProgress) Bytes() (recovered, total int64) {
+	for _, shard := range p.Shards {
+		recovered += shard.RecoveredBytes
+		total += shard.TotalBytes
+	}
+	return recovered, total
+}
+
+// DoneShards returns the number of shards that have been restored
+func (p *RestoreProgress) DoneShards() int {
+	done := 0
+	for _, shard := range p.Shards {
+		if shard.Stage == "DONE" {
+			done++
+		}
+	}
+	return done
+}
+
+// GetRestoreProgress returns the progress of restoring a snapshot, from the recoveries of the
+// shards restored from it. Replicas are copied from the restored primaries afterwards by peer
+// recoveries, which are not part of the restore. Recoveries are kept until the shard moves, so a
+// restore that completed a while ago is still reported.
+func (c *Client) GetRestoreProgress(repository, snapshot string) (*RestoreProgress, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.Recovery(
+		c.es.Indices.Recovery.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting recoveries: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Shards []struct {
+			ID                int    `json:"id"`
+			Type              string `json:"type"`
+			Stage             string `json:"stage"`
+			TotalTimeInMillis int64  `json:"total_time_in_millis"`
+			Source            struct {
+				Repository string `json:"repository"`
+				Snapshot   string `json:"snapshot"`
+			} `json:"source"`
+			Target struct {
+				Name string `json:"name"`
+			} `json:"target"`
+			Index struct {
+				Size struct {
+					TotalInBytes     int64 `json:"total_in_bytes"`
+					ReusedInBytes    int64 `json:"reused_in_bytes"`
+					RecoveredInBytes int64 `json:"recovered_in_bytes"`
+				} `json:"size"`
+			} `json:"index"`
+		} `json:"shards"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	progress := &RestoreProgress{}
+	var indices []string
+	for index, recoveries := range response {
+		restored := false
+		for _, shard := range recoveries.Shards {
+			if shard.Type != "SNAPSHOT" || shard.Source.Repository != repository || shard.Source.Snapshot != snapshot {
+				continue
+			}
+			restored = true
+			// Files already on the node are reused rather than copied
+			progress.Shards = append(progress.Shards, ShardRestore{
+				Index:          index,
+				Shard:          shard.ID,
+				Stage:          shard.Stage,
+				Node:           shard.Target.Name,
+				TotalBytes:     shard.Index.Size.TotalInBytes - shard.Index.Size.ReusedInBytes,
+				RecoveredBytes: shard.Index.Size.RecoveredInBytes,
+				Elapsed:        time.Duration(shard.TotalTimeInMillis) * time.Millisecond,
+			})
+		}
+		if restored {
+			indices = append(indices, index)
+		}
+	}
+	sort.Slice(progress.Shards, func(i, j int) bool {
+		if progress.Shards[i].Index != progress.Shards[j].Index {
+			return progress.Shards[i].Index < progress.Shards[j].Index
+		}
+		return progress.Shards[i].Shard < progress.Shards[j].Shard
+	})
+
+	// Primaries waiting for a recovery slot have no recovery yet
+	for start := 0; start < len(indices); start += tierSettingsBatchSize {
+		end := start + tierSettingsBatchSize
+		if end > len(indices) {
+			end = len(indices)
+		}
+		pending, err := c.countUnassignedPrimaries(ctx, strings.Join(indices[start:end], ","))
+		if err != nil {
+			return nil, err
+		}
+		progress.Pending += pending
+	}
+
+	return progress, nil
+}
+
+// countUnassignedPrimaries returns the number of primary shards of the indices that are not
+// assigned to a node
+func (c *Client) countUnassignedPrimaries(ctx context.Context, indices string) (int, error) {
+	// Execute request
+	res, err := c.es.Cat.Shards(
+		c.es.Cat.Shards.WithContext(ctx),
+		c.es.Cat.Shards.WithIndex(indices),
+		c.es.Cat.Shards.WithFormat("json"),
+		c.es.Cat.Shards.WithH("index,shard,prirep,state"),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error getting shards: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, newResponseError(res)
+	}
+
+	// Parse response
+	var shards []ShardInfo
+	if err := json.NewDecoder(res.Body).Decode(&shards); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	unassigned := 0
+	for _, shard := range shards {
+		if shard.PrimaryOrReplica == "p" && shard.State == "UNASSIGNED" {
+			unassigned++
+		}
+	}
+	return unassigned, nil
+}
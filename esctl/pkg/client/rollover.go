@@ -0,0 +1,425 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ilmExplainBatchSize limits the number of indices explained per ILM explain request
+const ilmExplainBatchSize = 50
+
+// RolloverConditions represents the rollover action conditions of an ILM policy
+type RolloverConditions struct {
+	MaxAge              string `json:"max_age,omitempty"`
+	MaxDocs             int64  `json:"max_docs,omitempty"`
+	MaxSize             string `json:"max_size,omitempty"`
+	MaxPrimaryShardSize string `json:"max_primary_shard_size,omitempty"`
+	MaxPrimaryShardDocs int64  `json:"max_primary_shard_docs,omitempty"`
+}
+
+// String returns the conditions in a compact human-readable form
+func (r *RolloverConditions) String() string {
+	if r == nil {
+		return "-"
+	}
+	var parts []string
+	if r.MaxAge != "" {
+		parts = append(parts, "age>"+r.MaxAge)
+	}
+	if r.MaxDocs > 0 {
+		parts = append(parts, fmt.Sprintf("docs>%d", r.MaxDocs))
+	}
+	if r.MaxSize != "" {
+		parts = append(parts, "size>"+r.MaxSize)
+	}
+	if r.MaxPrimaryShardSize != "" {
+		parts = append(parts, "pri_shard>"+r.MaxPrimaryShardSize)
+	}
+	if r.MaxPrimaryShardDocs > 0 {
+		parts = append(parts, fmt.Sprintf("pri_shard_docs>%d", r.MaxPrimaryShardDocs))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RolloverStatus represents a write index and how it compares to its rollover conditions
+type RolloverStatus struct {
+	Index          string
+	Target         string // alias or data stream name
+	TargetType     string // alias or data stream
+	Policy         string
+	Phase          string
+	Step           string
+	Age            time.Duration
+	Docs           int64
+	Size           int64
+	LargestPrimary int64
+	Conditions     *RolloverConditions
+	Status         string // OK, OVERDUE, ILM ERROR, NOT MANAGED or NO ROLLOVER
+	Reason         string
+}
+
+// ilmExplain represents the parts of an ILM explain entry used by the rollover report
+type ilmExplain struct {
+	Managed             bool                   `json:"managed"`
+	Policy              string                 `json:"policy"`
+	Phase               string                 `json:"phase"`
+	Action              string                 `json:"action"`
+	Step                string                 `json:"step"`
+	FailedStep          string                 `json:"failed_step"`
+	LifecycleDateMillis int64                  `json:"lifecycle_date_millis"`
+	StepInfo            map[string]interface{} `json:"step_info"`
+}
+
+// GetRolloverStatus lists the write indices behind aliases and data streams with their age,
+// size and document count compared against the rollover conditions of their ILM policy
+func (c *Client) GetRolloverStatus() ([]RolloverStatus, error) {
+	// Write indices behind aliases
+	aliases, err := c.GetAliases("")
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []RolloverStatus
+	for alias, index := range AliasWriteIndices(aliases) {
+		statuses = append(statuses, RolloverStatus{Index: index, Target: alias, TargetType: "alias"})
+	}
+
+	// Write indices behind data streams
+	dataStreams, err := c.getDataStreamWriteIndices()
+	if err != nil {
+		return nil, err
+	}
+	for name, index := range dataStreams {
+		statuses = append(statuses, RolloverStatus{Index: index, Target: name, TargetType: "data stream"})
+	}
+
+	if len(statuses) == 0 {
+		return statuses, nil
+	}
+
+	// Gather ILM state, policies and sizes
+	indexNames := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		indexNames = append(indexNames, s.Index)
+	}
+
+	explains, err := c.explainLifecycle(indexNames)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, err := c.getRolloverConditions()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := c.getIndexSizes()
+	if err != nil {
+		return nil, err
+	}
+
+	largestPrimaries, err := c.getLargestPrimaryShards()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range statuses {
+		s := &statuses[i]
+		s.Docs = sizes[s.Index].docs
+		s.Size = sizes[s.Index].size
+		s.LargestPrimary = largestPrimaries[s.Index]
+		if created := sizes[s.Index].created; created > 0 {
+			s.Age = now.Sub(time.UnixMilli(created))
+		}
+
+		explain, ok := explains[s.Index]
+		if !ok || !explain.Managed {
+			s.Status = "NOT MANAGED"
+			s.Reason = "index is not managed by ILM"
+			continue
+		}
+
+		s.Policy = explain.Policy
+		s.Phase = explain.Phase
+		s.Step = explain.Step
+		if explain.LifecycleDateMillis > 0 {
+			s.Age = now.Sub(time.UnixMilli(explain.LifecycleDateMillis))
+		}
+		s.Conditions = conditions[explain.Policy]
+
+		switch {
+		case explain.Step == "ERROR":
+			s.Status = "ILM ERROR"
+			s.Reason = fmt.Sprintf("failed step %s", explain.FailedStep)
+			if reason, ok := explain.StepInfo["reason"].(string); ok {
+				s.Reason += ": " + reason
+			}
+		case s.Conditions == nil:
+			s.Status = "NO ROLLOVER"
+			s.Reason = fmt.Sprintf("policy %s has no rollover action", explain.Policy)
+		default:
+			if exceeded := s.exceededConditions(); len(exceeded) > 0 {
+				s.Status = "OVERDUE"
+				s.Reason = fmt.Sprintf("exceeds %s (ILM at %s/%s/%s)", strings.Join(exceeded, ", "), explain.Phase, explain.Action, explain.Step)
+			} else {
+				s.Status = "OK"
+			}
+		}
+	}
+
+	// Problems first, then by target name
+	rank := map[string]int{"ILM ERROR": 0, "OVERDUE": 1, "NO ROLLOVER": 2, "NOT MANAGED": 3, "OK": 4}
+	sort.Slice(statuses, func(i, j int) bool {
+		if rank[statuses[i].Status] != rank[statuses[j].Status] {
+			return rank[statuses[i].Status] < rank[statuses[j].Status]
+		}
+		return statuses[i].Target < statuses[j].Target
+	})
+
+	return statuses, nil
+}
+
+// exceededConditions returns the rollover conditions the write index already exceeds
+func (s *RolloverStatus) exceededConditions() []string {
+	var exceeded []string
+	if s.Conditions.MaxAge != "" {
+		if maxAge, err := ParseTimeValue(s.Conditions.MaxAge); err == nil && s.Age > maxAge {
+			exceeded = append(exceeded, "max_age "+s.Conditions.MaxAge)
+		}
+	}
+	if s.Conditions.MaxDocs > 0 && s.Docs > s.Conditions.MaxDocs {
+		exceeded = append(exceeded, fmt.Sprintf("max_docs %d", s.Conditions.MaxDocs))
+	}
+	if s.Conditions.MaxSize != "" {
+		if maxSize, err := ParseByteSize(s.Conditions.MaxSize); err == nil && s.Size > maxSize {
+			exceeded = append(exceeded, "max_size "+s.Conditions.MaxSize)
+		}
+	}
+	if s.Conditions.MaxPrimaryShardSize != "" {
+		if maxSize, err := ParseByteSize(s.Conditions.MaxPrimaryShardSize); err == nil && s.LargestPrimary > maxSize {
+			exceeded = append(exceeded, "max_primary_shard_size "+s.Conditions.MaxPrimaryShardSize)
+		}
+	}
+	return exceeded
+}
+
+// getDataStreamWriteIndices returns the current write index of every data stream
+func (c *Client) getDataStreamWriteIndices() (map[string]string, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetDataStream(
+		c.es.Indices.GetDataStream.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting data streams: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		DataStreams []struct {
+			Name    string `json:"name"`
+			Indices []struct {
+				IndexName string `json:"index_name"`
+			} `json:"indices"`
+		} `json:"data_streams"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	// The write index is the last backing index
+	writeIndices := make(map[string]string, len(response.DataStreams))
+	for _, ds := range response.DataStreams {
+		if len(ds.Indices) > 0 {
+			writeIndices[ds.Name] = ds.Indices[len(ds.Indices)-1].IndexName
+		}
+	}
+
+	return writeIndices, nil
+}
+
+// explainLifecycle returns the ILM state of the given indices
+func (c *Client) explainLifecycle(indices []string) (map[string]ilmExplain, error) {
+	explains := make(map[string]ilmExplain, len(indices))
+
+	for start := 0; start < len(indices); start += ilmExplainBatchSize {
+		end := start + ilmExplainBatchSize
+		if end > len(indices) {
+			end = len(indices)
+		}
+
+		if err := c.explainLifecycleBatch(indices[start:end], explains); err != nil {
+			return nil, err
+		}
+	}
+
+	return explains, nil
+}
+
+// explainLifecycleBatch adds the ILM state of a batch of indices to explains
+func (c *Client) explainLifecycleBatch(indices []string, explains map[string]ilmExplain) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.ILM.ExplainLifecycle(
+		strings.Join(indices, ","),
+		c.es.ILM.ExplainLifecycle.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error explaining lifecycle: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Indices map[string]ilmExplain `json:"indices"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	for index, explain := range response.Indices {
+		explains[index] = explain
+	}
+
+	return nil
+}
+
+// getRolloverConditions returns the hot phase rollover conditions of every ILM policy that has one
+func (c *Client) getRolloverConditions() (map[string]*RolloverConditions, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.ILM.GetLifecycle(
+		c.es.ILM.GetLifecycle.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting lifecycle policies: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Policy struct {
+			Phases map[string]struct {
+				Actions struct {
+					Rollover *RolloverConditions `json:"rollover"`
+				} `json:"actions"`
+			} `json:"phases"`
+		} `json:"policy"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	conditions := make(map[string]*RolloverConditions, len(response))
+	for name, policy := range response {
+		if hot, ok := policy.Policy.Phases["hot"]; ok && hot.Actions.Rollover != nil {
+			conditions[name] = hot.Actions.Rollover
+		}
+	}
+
+	return conditions, nil
+}
+
+// indexSize represents the document count, primary store size and creation time of an index
+type indexSize struct {
+	docs    int64
+	size    int64
+	created int64
+}
+
+// getIndexSizes returns primary document counts and store sizes in bytes for every index
+func (c *Client) getIndexSizes() (map[string]indexSize, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request, including hidden data stream backing indices
+	res, err := c.es.Cat.Indices(
+		c.es.Cat.Indices.WithContext(ctx),
+		c.es.Cat.Indices.WithFormat("json"),
+		c.es.Cat.Indices.WithBytes("b"),
+		c.es.Cat.Indices.WithH("index,docs.count,pri.store.size,creation.date"),
+		c.es.Cat.Indices.WithPri(true),
+		c.es.Cat.Indices.WithExpandWildcards("all"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting response: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var rows []struct {
+		Index        string `json:"index"`
+		DocsCount    string `json:"docs.count"`
+		PriStoreSize string `json:"pri.store.size"`
+		CreationDate string `json:"creation.date"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	sizes := make(map[string]indexSize, len(rows))
+	for _, row := range rows {
+		docs, _ := strconv.ParseInt(row.DocsCount, 10, 64)
+		size, _ := strconv.ParseInt(row.PriStoreSize, 10, 64)
+		created, _ := strconv.ParseInt(row.CreationDate, 10, 64)
+		sizes[row.Index] = indexSize{docs: docs, size: size, created: created}
+	}
+
+	return sizes, nil
+}
+
+// getLargestPrimaryShards returns the size in bytes of the largest primary shard of every index
+func (c *Client) getLargestPrimaryShards() (map[string]int64, error) {
+	shards, err := c.GetShards(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	largest := make(map[string]int64)
+	for _, shard := range shards {
+		if shard.PrimaryOrReplica != "p" {
+			continue
+		}
+		if size, err := ParseByteSize(shard.Store); err == nil && size > largest[shard.Index] {
+			largest[shard.Index] = size
+		}
+	}
+
+	return largest, nil
+}
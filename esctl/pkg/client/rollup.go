@@ -0,0 +1,297 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RollupJob is a legacy rollup job with its status and statistics
+type RollupJob struct {
+	ID           string
+	IndexPattern string
+	RollupIndex  string
+	Cron         string
+	DateField    string
+	Interval     string // fixed or calendar interval of the date histogram
+	Calendar     bool   // the interval is a calendar interval
+	Delay        string
+	TimeZone     string
+	Terms        []string            // fields grouped by terms
+	Histograms   []string            // fields grouped by histogram
+	Metrics      map[string][]string // metric field to the metrics kept for it
+	State        string              // started, indexing, stopping, stopped or aborting
+	Documents    int64               // documents processed
+	Rollups      int64               // rollup documents indexed
+	Triggers     int64
+}
+
+// rollupJobResponse is a job in the get rollup jobs response
+type rollupJobResponse struct {
+	Config struct {
+		ID           string `json:"id"`
+		IndexPattern string `json:"index_pattern"`
+		RollupIndex  string `json:"rollup_index"`
+		Cron         string `json:"cron"`
+		Groups       struct {
+			DateHistogram struct {
+				Field            string `json:"field"`
+				Interval         string `json:"interval"`
+				FixedInterval    string `json:"fixed_interval"`
+				CalendarInterval string `json:"calendar_interval"`
+				Delay            string `json:"delay"`
+				TimeZone         string `json:"time_zone"`
+			} `json:"date_histogram"`
+			Terms struct {
+				Fields []string `json:"fields"`
+			} `json:"terms"`
+			Histogram struct {
+				Fields []string `json:"fields"`
+			} `json:"histogram"`
+		} `json:"groups"`
+		Metrics []struct {
+			Field   string   `json:"field"`
+			Metrics []string `json:"metrics"`
+		} `json:"metrics"`
+	} `json:"config"`
+	Status struct {
+		JobState string `json:"job_state"`
+	} `json:"status"`
+	Stats struct {
+		DocumentsProcessed int64 `json:"documents_processed"`
+		RollupsIndexed     int64 `json:"rollups_indexed"`
+		TriggerCount       int64 `json:"trigger_count"`
+	} `json:"stats"`
+}
+
+// GetRollupJobs returns every legacy rollup job, sorted by ID
+func (c *Client) GetRollupJobs() ([]RollupJob, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Rollup.GetJobs(
+		c.es.Rollup.GetJobs.WithContext(ctx),
+		c.es.Rollup.GetJobs.WithJobID("_all"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting rollup jobs: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Jobs []rollupJobResponse `json:"jobs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	jobs := make([]RollupJob, 0, len(response.Jobs))
+	for _, j := range response.Jobs {
+		histogram := j.Config.Groups.DateHistogram
+		job := RollupJob{
+			ID:           j.Config.ID,
+			IndexPattern: j.Config.IndexPattern,
+			RollupIndex:  j.Config.RollupIndex,
+			Cron:         j.Config.Cron,
+			DateField:    histogram.Field,
+			Interval:     histogram.FixedInterval,
+			Delay:        histogram.Delay,
+			TimeZone:     histogram.TimeZone,
+			Terms:        j.Config.Groups.Terms.Fields,
+			Histograms:   j.Config.Groups.Histogram.Fields,
+			Metrics:      make(map[string][]string),
+			State:        j.Status.JobState,
+			Documents:    j.Stats.DocumentsProcessed,
+			Rollups:      j.Stats.RollupsIndexed,
+			Triggers:     j.Stats.TriggerCount,
+		}
+		switch {
+		case histogram.CalendarInterval != "":
+			job.Interval, job.Calendar = histogram.CalendarInterval, true
+		case job.Interval == "":
+			// Jobs created before 7.2 have a single interval
+			job.Interval = histogram.Interval
+		}
+		for _, m := range j.Config.Metrics {
+			job.Metrics[m.Field] = append(job.Metrics[m.Field], m.Metrics...)
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs, nil
+}
+
+// StopRollupJob stops a rollup job, waiting for it to stop when wait is set
+func (c *Client) StopRollupJob(id string, wait bool) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Rollup.StopJob(
+		id,
+		c.es.Rollup.StopJob.WithContext(ctx),
+		c.es.Rollup.StopJob.WithWaitForCompletion(wait),
+		c.es.Rollup.StopJob.WithTimeout(time.Minute),
+	)
+	if err != nil {
+		return fmt.Errorf("error stopping rollup job: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// DeleteRollupJob deletes a stopped rollup job. The rollup index and the data in it are kept.
+func (c *Client) DeleteRollupJob(id string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Rollup.DeleteJob(
+		id,
+		c.es.Rollup.DeleteJob.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting rollup job: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// DownsampleSuggestion is the downsampling setup that replaces a rollup job: an ILM policy that
+// downsamples after rollover and an index template that makes the source a time series data
+// stream, which downsampling needs
+type DownsampleSuggestion struct {
+	JobID         string
+	PolicyName    string
+	Policy        map[string]interface{}
+	TemplateName  string
+	Template      map[string]interface{}
+	FixedInterval string
+	Notes         []string // differences from the rollup job that need a decision
+}
+
+// calendarToFixed maps the calendar intervals that have a fixed equivalent
+var calendarToFixed = map[string]string{
+	"1m": "1m", "minute": "1m",
+	"1h": "1h", "hour": "1h",
+	"1d": "1d", "day": "1d",
+	"1w": "7d", "week": "7d",
+}
+
+// SuggestDownsampling returns the ILM policy and index template that give the same data reduction
+// as a rollup job with downsampling, and notes on what downsampling does differently
+func SuggestDownsampling(job RollupJob) DownsampleSuggestion {
+	name := job.ID + "-downsample"
+	suggestion := DownsampleSuggestion{JobID: job.ID, PolicyName: name, TemplateName: name}
+
+	// Downsampling only takes fixed intervals
+	suggestion.FixedInterval = job.Interval
+	if job.Calendar {
+		if fixed, ok := calendarToFixed[strings.ToLower(job.Interval)]; ok {
+			suggestion.FixedInterval = fixed
+		} else {
+			suggestion.FixedInterval = "1d"
+			suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("Calendar interval %s has no fixed equivalent, 1d is suggested; aggregate the downsampled data further at query time", job.Interval))
+		}
+	}
+
+	// Rollups wait for the delay before rolling a bucket up; downsampling waits for the phase
+	minAge := job.Delay
+	if minAge == "" {
+		minAge = "1d"
+	}
+	suggestion.Policy = map[string]interface{}{
+		"policy": map[string]interface{}{
+			"_meta": map[string]interface{}{"description": fmt.Sprintf("Replaces rollup job %s", job.ID)},
+			"phases": map[string]interface{}{
+				"hot": map[string]interface{}{
+					"actions": map[string]interface{}{
+						"rollover": map[string]interface{}{"max_age": "1d", "max_primary_shard_size": "50gb"},
+					},
+				},
+				"warm": map[string]interface{}{
+					"min_age": minAge,
+					"actions": map[string]interface{}{
+						"downsample": map[string]interface{}{"fixed_interval": suggestion.FixedInterval},
+					},
+				},
+			},
+		},
+	}
+
+	// Terms fields become the dimensions of the time series, metrics become gauges
+	properties := map[string]interface{}{
+		"@timestamp": map[string]interface{}{"type": "date"},
+	}
+	for _, field := range job.Terms {
+		properties[field] = map[string]interface{}{"type": "keyword", "time_series_dimension": true}
+	}
+	metricFields := make([]string, 0, len(job.Metrics))
+	for field := range job.Metrics {
+		metricFields = append(metricFields, field)
+	}
+	sort.Strings(metricFields)
+	for _, field := range metricFields {
+		properties[field] = map[string]interface{}{"type": "double", "time_series_metric": "gauge"}
+	}
+
+	settings := map[string]interface{}{
+		"index.mode":           "time_series",
+		"index.lifecycle.name": name,
+	}
+	if len(job.Terms) > 0 {
+		settings["index.routing_path"] = job.Terms
+	}
+	suggestion.Template = map[string]interface{}{
+		"index_patterns": []string{job.IndexPattern},
+		"data_stream":    map[string]interface{}{},
+		"priority":       200,
+		"template": map[string]interface{}{
+			"settings": settings,
+			"mappings": map[string]interface{}{"properties": properties},
+		},
+	}
+
+	if job.DateField != "" && job.DateField != "@timestamp" {
+		suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("Time series data streams use @timestamp, the job groups on %s; map or rename it at ingest", job.DateField))
+	}
+	if len(job.Terms) == 0 {
+		suggestion.Notes = append(suggestion.Notes, "The job groups on no terms fields; a time series data stream needs at least one keyword dimension")
+	} else {
+		suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("Terms fields %s become dimensions; other fields that identify a series must be dimensions too", strings.Join(job.Terms, ", ")))
+	}
+	if len(job.Histograms) > 0 {
+		suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("Histogram groups on %s have no downsampling equivalent; use a histogram aggregation at query time", strings.Join(job.Histograms, ", ")))
+	}
+	if job.TimeZone != "" && !strings.EqualFold(job.TimeZone, "UTC") {
+		suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("Downsampling buckets in UTC, the job uses %s", job.TimeZone))
+	}
+	suggestion.Notes = append(suggestion.Notes,
+		"Downsampled gauges keep min, max, sum and value_count, which covers every rollup metric including avg",
+		fmt.Sprintf("Data already in %s is not migrated; keep the rollup index until it ages out, it is searched with _rollup_search", job.RollupIndex),
+		fmt.Sprintf("The template must win over any template matching %s, raise its priority if needed", job.IndexPattern),
+	)
+
+	return suggestion
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SampleDocument is a document returned by a sample search
+type SampleDocument struct {
+	Index  string                 `json:"_index"`
+	ID     string                 `json:"_id"`
+	Source map[string]interface{} `json:"_source"`
+}
+
+// SampleDocuments returns up to size documents from the indices matching a pattern. Without
+// random the first documents in index order are returned, which is the cheapest search; with
+// random they are scored with random_score, so each call returns a different sample. When fields
+// are given only those fields of the source are returned.
+func (c *Client) SampleDocuments(pattern string, size int, random bool, fields []string) ([]SampleDocument, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := map[string]interface{}{"match_all": map[string]interface{}{}}
+	if random {
+		query = map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": query,
+				"random_score": map[string]interface{}{
+					"seed":  time.Now().UnixNano(),
+					"field": "_seq_no",
+				},
+				"boost_mode": "replace",
+			},
+		}
+	}
+
+	body := map[string]interface{}{
+		"size":  size,
+		"query": query,
+	}
+	if !random {
+		body["sort"] = []string{"_doc"}
+	}
+	if len(fields) > 0 {
+		body["_source"] = fields
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithIndex(pattern),
+		c.es.Search.WithBody(&buf),
+		c.es.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error searching documents: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Hits struct {
+			Hits []SampleDocument `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return response.Hits.Hits, nil
+}
+
+// SourceValue returns the value of a dotted field path in a document source. Both nested objects
+// and field names containing dots are followed.
+func SourceValue(source map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := source[path]; ok {
+		return value, true
+	}
+
+	// Try each split of the path into an object name and the rest
+	for i := strings.Index(path, "."); i >= 0; i = nextDot(path, i) {
+		child, ok := source[path[:i]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := SourceValue(child, path[i+1:]); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// nextDot returns the index of the next dot in a path after position i, or -1
+func nextDot(path string, i int) int {
+	next := strings.Index(path[i+1:], ".")
+	if next < 0 {
+		return -1
+	}
+	return i + 1 + next
+}
@@ -82,13 +82,7 @@ func (c *KibanaClient) SearchSavedObjects(searchTerm string, types []string, inc
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil {
-			if errMsg, ok := errorResp["message"].(string); ok {
-				return nil, fmt.Errorf("error from Kibana API: %s", errMsg)
-			}
-		}
-		return nil, fmt.Errorf("error from Kibana API: %s", resp.Status)
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse the response
@@ -100,6 +94,26 @@ func (c *KibanaClient) SearchSavedObjects(searchTerm string, types []string, inc
 	return &response, nil
 }
 
+// SearchAllSavedObjects searches for saved objects in Kibana, reading every page of results. The
+// response holds all objects found, with the page set to 0.
+func (c *KibanaClient) SearchAllSavedObjects(searchTerm string, types []string, includeDependencies bool, perPage int) (*SavedObjectSearchResponse, error) {
+	all := &SavedObjectSearchResponse{PerPage: perPage}
+	for page := 1; ; page++ {
+		response, err := c.SearchSavedObjects(searchTerm, types, includeDependencies, perPage, page)
+		if err != nil {
+			return nil, err
+		}
+		all.PerPage = response.PerPage
+		all.Total = response.Total
+		all.SavedObjects = append(all.SavedObjects, response.SavedObjects...)
+
+		// A short page is the last one
+		if len(response.SavedObjects) == 0 || len(response.SavedObjects) < response.PerPage || len(all.SavedObjects) >= response.Total {
+			return all, nil
+		}
+	}
+}
+
 // GetSavedObject retrieves a specific saved object by ID and type
 func (c *KibanaClient) GetSavedObject(id, objectType string, includeDependencies bool) (*SavedObject, error) {
 	// Build the query parameters
@@ -134,13 +148,7 @@ func (c *KibanaClient) GetSavedObject(id, objectType string, includeDependencies
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil {
-			if errMsg, ok := errorResp["message"].(string); ok {
-				return nil, fmt.Errorf("error from Kibana API: %s", errMsg)
-			}
-		}
-		return nil, fmt.Errorf("error from Kibana API: %s", resp.Status)
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse the response
@@ -177,13 +185,7 @@ func (c *KibanaClient) GetSavedObjectsTypes() ([]string, error) {
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil {
-			if errMsg, ok := errorResp["message"].(string); ok {
-				return nil, fmt.Errorf("error from Kibana API: %s", errMsg)
-			}
-		}
-		return nil, fmt.Errorf("error from Kibana API: %s", resp.Status)
+		return nil, newHTTPError(resp)
 	}
 
 	// Parse the response
@@ -244,13 +246,7 @@ func (c *KibanaClient) ExportSavedObject(id, objectType string, includeDependenc
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err == nil {
-			if errMsg, ok := errorResp["message"].(string); ok {
-				return nil, fmt.Errorf("error from Kibana API: %s", errMsg)
-			}
-		}
-		return nil, fmt.Errorf("error from Kibana API: %s", resp.Status)
+		return nil, newHTTPError(resp)
 	}
 
 	// Read the response body into a buffer
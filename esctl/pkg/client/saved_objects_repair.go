@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+const (
+	// savedObjectsPageSize is the number of saved objects requested per page when scanning a type
+	savedObjectsPageSize = 100
+	// savedObjectsBulkSize is the number of objects sent in a single bulk get or bulk update request
+	savedObjectsBulkSize = 100
+)
+
+// BrokenReference is a reference from a saved object to an object that no longer exists
+type BrokenReference struct {
+	ObjectType string
+	ObjectID   string
+	Title      string
+	Reference  ObjectReference
+}
+
+// savedObjectError is the per-object error returned by the saved objects bulk APIs
+type savedObjectError struct {
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+}
+
+// bulkSavedObject is a single object in a saved objects bulk API response
+type bulkSavedObject struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	References []ObjectReference      `json:"references,omitempty"`
+	Error      *savedObjectError      `json:"error,omitempty"`
+}
+
+// SavedObjectTitle returns the title, name or description of a saved object
+func SavedObjectTitle(obj SavedObject) string {
+	for _, key := range []string{"title", "name", "description"} {
+		if value, ok := obj.Attributes[key]; ok {
+			return fmt.Sprintf("%v", value)
+		}
+	}
+	return ""
+}
+
+// GetAllSavedObjects retrieves every saved object of the given type, following pages until all are read
+func (c *KibanaClient) GetAllSavedObjects(objectType string) ([]SavedObject, error) {
+	var objects []SavedObject
+	for page := 1; ; page++ {
+		response, err := c.SearchSavedObjects("", []string{objectType}, false, savedObjectsPageSize, page)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, response.SavedObjects...)
+		if len(response.SavedObjects) == 0 || len(objects) >= response.Total {
+			break
+		}
+	}
+	return objects, nil
+}
+
+// FindBrokenReferences returns the references of the given saved objects that point at objects
+// which do not exist, such as deleted index patterns
+func (c *KibanaClient) FindBrokenReferences(objects []SavedObject) ([]BrokenReference, error) {
+	// Collect the distinct referenced objects
+	seen := make(map[string]bool)
+	var refs []ObjectReference
+	for _, obj := range objects {
+		for _, ref := range obj.References {
+			key := ref.Type + "/" + ref.ID
+			if !seen[key] {
+				seen[key] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	// Check which referenced objects are missing
+	missing := make(map[string]bool)
+	for start := 0; start < len(refs); start += savedObjectsBulkSize {
+		end := start + savedObjectsBulkSize
+		if end > len(refs) {
+			end = len(refs)
+		}
+
+		results, err := c.bulkGetSavedObjects(refs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if result.Error != nil && result.Error.StatusCode == http.StatusNotFound {
+				missing[result.Type+"/"+result.ID] = true
+			}
+		}
+	}
+
+	var broken []BrokenReference
+	for _, obj := range objects {
+		for _, ref := range obj.References {
+			if missing[ref.Type+"/"+ref.ID] {
+				broken = append(broken, BrokenReference{
+					ObjectType: obj.Type,
+					ObjectID:   obj.ID,
+					Title:      SavedObjectTitle(obj),
+					Reference:  ref,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		return broken[i].Title < broken[j].Title
+	})
+
+	return broken, nil
+}
+
+// RepointReferences rewrites the references of the given objects so that every reference to
+// brokenID of the broken reference's type points at replacementID instead. It returns the objects
+// with their updated references; nothing is written to Kibana.
+func RepointReferences(objects []SavedObject, refType, brokenID, replacementID string) []SavedObject {
+	var updated []SavedObject
+	for _, obj := range objects {
+		changed := false
+		refs := make([]ObjectReference, len(obj.References))
+		for i, ref := range obj.References {
+			if ref.Type == refType && ref.ID == brokenID {
+				ref.ID = replacementID
+				changed = true
+			}
+			refs[i] = ref
+		}
+		if changed {
+			obj.References = refs
+			updated = append(updated, obj)
+		}
+	}
+	return updated
+}
+
+// BulkUpdateSavedObjectReferences replaces the references of the given saved objects in batches.
+// The object version is sent so that objects modified since they were read are not overwritten.
+func (c *KibanaClient) BulkUpdateSavedObjectReferences(objects []SavedObject) error {
+	for start := 0; start < len(objects); start += savedObjectsBulkSize {
+		end := start + savedObjectsBulkSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		updates := make([]map[string]interface{}, 0, end-start)
+		for _, obj := range objects[start:end] {
+			update := map[string]interface{}{
+				"type":       obj.Type,
+				"id":         obj.ID,
+				"attributes": map[string]interface{}{},
+				"references": obj.References,
+			}
+			if obj.Version != "" {
+				update["version"] = obj.Version
+			}
+			updates = append(updates, update)
+		}
+
+		var response struct {
+			SavedObjects []bulkSavedObject `json:"saved_objects"`
+		}
+		if err := c.postSavedObjects("_bulk_update", updates, &response); err != nil {
+			return err
+		}
+
+		for _, result := range response.SavedObjects {
+			if result.Error != nil {
+				return fmt.Errorf("error updating %s %s: %s", result.Type, result.ID, result.Error.Message)
+			}
+		}
+	}
+
+	return nil
+}
+
+// bulkGetSavedObjects retrieves a batch of saved objects, reporting missing ones through their error
+func (c *KibanaClient) bulkGetSavedObjects(refs []ObjectReference) ([]bulkSavedObject, error) {
+	objects := make([]map[string]string, 0, len(refs))
+	for _, ref := range refs {
+		objects = append(objects, map[string]string{"type": ref.Type, "id": ref.ID})
+	}
+
+	var response struct {
+		SavedObjects []bulkSavedObject `json:"saved_objects"`
+	}
+	if err := c.postSavedObjects("_bulk_get", objects, &response); err != nil {
+		return nil, err
+	}
+
+	return response.SavedObjects, nil
+}
+
+// postSavedObjects sends a request body to a saved objects bulk endpoint and decodes the response
+func (c *KibanaClient) postSavedObjects(endpoint string, body interface{}, response interface{}) error {
+	// Convert request body to JSON
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshaling request body: %w", err)
+	}
+
+	// Create the request
+	requestURL := fmt.Sprintf("%s/api/saved_objects/%s", c.baseURL, endpoint)
+	req, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set content type and the header Kibana requires for write requests
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("kbn-xsrf", "true")
+
+	// Add authentication if configured
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	// Execute the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for errors
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPError(resp)
+	}
+
+	// Parse the response
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return nil
+}
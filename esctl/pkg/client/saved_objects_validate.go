@@ -0,0 +1,196 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Problems found by ValidateSavedObjects
+const (
+	ProblemMissingObject   = "missing object"
+	ProblemDeprecatedVis   = "deprecated visualization"
+	ProblemMissingDataView = "missing data view"
+	ProblemDataViewNoIndex = "data view matches no indices"
+	ProblemDataViewUnknown = "data view not checked"
+)
+
+// deprecatedVisualizationTypes are the legacy visualization types Kibana has deprecated, with what
+// replaces each
+var deprecatedVisualizationTypes = map[string]string{
+	"input_control_vis": "use dashboard controls",
+	"tile_map":          "use Maps",
+	"region_map":        "use Maps",
+	"timelion":          "use Lens or TSVB",
+}
+
+// SavedObjectProblem is a problem found in a saved object, such as a dashboard panel whose
+// visualization was deleted
+type SavedObjectProblem struct {
+	ObjectID string
+	Title    string
+	Problem  string
+	Detail   string
+}
+
+// IndexExistsFunc reports whether an index pattern matches any index, alias or data stream
+type IndexExistsFunc func(pattern string) (bool, error)
+
+// ValidateSavedObjects checks the given saved objects, usually dashboards, for panels referencing
+// missing objects, panels using deprecated visualization types, and data views, referenced by
+// the objects or their panels, that are missing or match no indices. The data view indices are
+// only checked when indexExists is not nil.
+func (c *KibanaClient) ValidateSavedObjects(objects []SavedObject, indexExists IndexExistsFunc) ([]SavedObjectProblem, error) {
+	// Objects referenced by the objects, then the data views those reference
+	var refs []ObjectReference
+	for _, obj := range objects {
+		refs = append(refs, obj.References...)
+	}
+	found, err := c.getReferencedObjects(refs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var panelRefs []ObjectReference
+	for _, obj := range found {
+		if obj.Error == nil {
+			for _, ref := range obj.References {
+				if ref.Type == "index-pattern" {
+					panelRefs = append(panelRefs, ref)
+				}
+			}
+		}
+	}
+	if _, err := c.getReferencedObjects(panelRefs, found); err != nil {
+		return nil, err
+	}
+
+	var problems []SavedObjectProblem
+	checked := make(map[string]string) // data view pattern to problem, "" when it matches indices
+	for _, obj := range objects {
+		title := SavedObjectTitle(obj)
+		add := func(problem, detail string) {
+			problems = append(problems, SavedObjectProblem{ObjectID: obj.ID, Title: title, Problem: problem, Detail: detail})
+		}
+
+		dataViews := make(map[string]bool)
+		for _, ref := range obj.References {
+			target := found[ref.Type+"/"+ref.ID]
+			if target.Error != nil {
+				add(ProblemMissingObject, fmt.Sprintf("%s %s (%s)", ref.Type, ref.ID, ref.Name))
+				continue
+			}
+
+			if ref.Type == "index-pattern" {
+				dataViews[ref.ID] = true
+				continue
+			}
+			if ref.Type == "visualization" {
+				if visType := visualizationType(target); deprecatedVisualizationTypes[visType] != "" {
+					add(ProblemDeprecatedVis, fmt.Sprintf("%s: %s, %s", bulkObjectTitle(target), visType, deprecatedVisualizationTypes[visType]))
+				}
+			}
+			for _, panelRef := range target.References {
+				if panelRef.Type != "index-pattern" {
+					continue
+				}
+				if found["index-pattern/"+panelRef.ID].Error != nil {
+					add(ProblemMissingDataView, fmt.Sprintf("%s %s uses data view %s", ref.Type, bulkObjectTitle(target), panelRef.ID))
+					continue
+				}
+				dataViews[panelRef.ID] = true
+			}
+		}
+
+		if indexExists == nil {
+			continue
+		}
+		ids := make([]string, 0, len(dataViews))
+		for id := range dataViews {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			pattern, _ := found["index-pattern/"+id].Attributes["title"].(string)
+			if pattern == "" {
+				continue
+			}
+			problem, ok := checked[pattern]
+			if !ok {
+				exists, err := indexExists(pattern)
+				switch {
+				case err != nil:
+					problem = ProblemDataViewUnknown
+				case !exists:
+					problem = ProblemDataViewNoIndex
+				}
+				checked[pattern] = problem
+			}
+			if problem != "" {
+				add(problem, fmt.Sprintf("%s (%s)", pattern, id))
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Title < problems[j].Title
+	})
+
+	return problems, nil
+}
+
+// getReferencedObjects fetches the referenced objects not already in found, adding them to it by
+// type/id. Missing objects are kept with their error. A nil found starts a new map.
+func (c *KibanaClient) getReferencedObjects(refs []ObjectReference, found map[string]bulkSavedObject) (map[string]bulkSavedObject, error) {
+	if found == nil {
+		found = make(map[string]bulkSavedObject)
+	}
+
+	var wanted []ObjectReference
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		key := ref.Type + "/" + ref.ID
+		if _, ok := found[key]; ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		wanted = append(wanted, ref)
+	}
+
+	for start := 0; start < len(wanted); start += savedObjectsBulkSize {
+		end := min(start+savedObjectsBulkSize, len(wanted))
+		results, err := c.bulkGetSavedObjects(wanted[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			// Errors other than not found, such as forbidden, are not reported as missing
+			if result.Error != nil && result.Error.StatusCode != http.StatusNotFound {
+				result.Error = nil
+			}
+			found[result.Type+"/"+result.ID] = result
+		}
+	}
+	return found, nil
+}
+
+// visualizationType returns the type of a legacy visualization from its visState
+func visualizationType(obj bulkSavedObject) string {
+	visState, _ := obj.Attributes["visState"].(string)
+	var state struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(visState), &state); err != nil {
+		return ""
+	}
+	return state.Type
+}
+
+// bulkObjectTitle returns the title of an object from a bulk get, or its ID when it has none
+func bulkObjectTitle(obj bulkSavedObject) string {
+	if title, ok := obj.Attributes["title"].(string); ok && title != "" {
+		return title
+	}
+	return obj.ID
+}
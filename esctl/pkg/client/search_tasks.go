@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// searchTaskActions are the task actions of searches, including their shard-level child tasks
+// (indices:data/read/search[phase/query]) and async, scroll and multi searches
+var searchTaskActions = []string{"*search*"}
+
+// taskDescriptionIndices finds the indices of a search in its task description, as in
+// "indices[logs-*], search_type[QUERY_THEN_FETCH], source[...]"
+var taskDescriptionIndices = regexp.MustCompile(`indices\[([^\]]*)\]`)
+
+// SearchTask is a running search, with the shard-level tasks it started rolled up into it
+type SearchTask struct {
+	ID          string // node ID and task number, as in oTUltX4IQMOUUVeiohTt8A:12345
+	Node        string // name of the node coordinating the search
+	Action      string
+	Description string
+	Indices     []string
+	OpaqueID    string // X-Opaque-Id header the client sent, if any
+	Started     time.Time
+	Running     time.Duration
+	Cancellable bool
+	Cancelled   bool
+	ParentID    string // task the search was started by, such as an async search submit
+	Children    int    // tasks started by the search, at any depth
+	ChildNodes  int    // nodes the child tasks run on
+}
+
+// searchTaskInfo is a task in the Tasks API response
+type searchTaskInfo struct {
+	Node               string            `json:"node"`
+	ID                 int64             `json:"id"`
+	Action             string            `json:"action"`
+	Description        string            `json:"description"`
+	StartTimeInMillis  int64             `json:"start_time_in_millis"`
+	RunningTimeInNanos int64             `json:"running_time_in_nanos"`
+	Cancellable        bool              `json:"cancellable"`
+	Cancelled          bool              `json:"cancelled"`
+	ParentTaskID       string            `json:"parent_task_id"`
+	Headers            map[string]string `json:"headers"`
+}
+
+// GetSearchTasks returns the searches that have been running for at least minRunning, longest
+// first. Shard-level and other child tasks are not listed themselves but counted in the search
+// that started them, so each search appears once however many shards it fans out to.
+func (c *Client) GetSearchTasks(minRunning time.Duration) ([]SearchTask, error) {
+	tasks, nodeNames, err := c.listSearchTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []SearchTask
+	byID := make(map[string]*SearchTask)
+	childNodes := make(map[string]map[string]bool)
+
+	// Top-level searches are the tasks whose parent is not itself a search task
+	for id, task := range tasks {
+		if _, ok := tasks[task.ParentTaskID]; ok {
+			continue
+		}
+		result = append(result, SearchTask{
+			ID:          id,
+			Node:        nodeNames[task.Node],
+			Action:      task.Action,
+			Description: task.Description,
+			Indices:     searchTaskIndices(task.Description),
+			OpaqueID:    task.Headers["X-Opaque-Id"],
+			Started:     time.UnixMilli(task.StartTimeInMillis),
+			Running:     time.Duration(task.RunningTimeInNanos),
+			Cancellable: task.Cancellable,
+			Cancelled:   task.Cancelled,
+			ParentID:    task.ParentTaskID,
+		})
+	}
+	for i := range result {
+		byID[result[i].ID] = &result[i]
+	}
+
+	// Roll each child task up into its top-level search
+	for id, task := range tasks {
+		if _, ok := byID[id]; ok {
+			continue
+		}
+		root := rootSearchTask(id, tasks)
+		search, ok := byID[root]
+		if !ok {
+			continue
+		}
+		search.Children++
+		if childNodes[root] == nil {
+			childNodes[root] = make(map[string]bool)
+		}
+		childNodes[root][task.Node] = true
+	}
+
+	filtered := result[:0]
+	for _, search := range result {
+		if search.Running < minRunning {
+			continue
+		}
+		search.ChildNodes = len(childNodes[search.ID])
+		filtered = append(filtered, search)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].Running != filtered[j].Running {
+			return filtered[i].Running > filtered[j].Running
+		}
+		return filtered[i].ID < filtered[j].ID
+	})
+
+	return filtered, nil
+}
+
+// CancelSearchTask cancels a running search. When id is a shard-level or other child task, the
+// search that started it is cancelled instead, which cancels all of its child tasks; cancelling
+// only the child would leave the search running with partial results. Returns the ID of the
+// task that was cancelled.
+func (c *Client) CancelSearchTask(id string) (string, error) {
+	if !isTaskID(id) {
+		return "", fmt.Errorf("invalid task ID format: %s (expected node_id:task_number)", id)
+	}
+
+	tasks, _, err := c.listSearchTasks()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := tasks[id]; !ok {
+		return "", fmt.Errorf("task %s is not a running search", id)
+	}
+
+	root := rootSearchTask(id, tasks)
+	if !tasks[root].Cancellable {
+		return "", fmt.Errorf("task %s (%s) cannot be cancelled", root, tasks[root].Action)
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Tasks.Cancel(
+		c.es.Tasks.Cancel.WithContext(ctx),
+		c.es.Tasks.Cancel.WithTaskID(root),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error cancelling task: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", newResponseError(res)
+	}
+
+	// Cancel reports failures per node in a successful response
+	var response struct {
+		NodeFailures []struct {
+			Reason string `json:"reason"`
+		} `json:"node_failures"`
+		TaskFailures []struct {
+			Reason struct {
+				Reason string `json:"reason"`
+			} `json:"reason"`
+		} `json:"task_failures"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(response.TaskFailures) > 0 {
+		return "", fmt.Errorf("error cancelling task %s: %s", root, response.TaskFailures[0].Reason.Reason)
+	}
+	if len(response.NodeFailures) > 0 {
+		return "", fmt.Errorf("error cancelling task %s: %s", root, response.NodeFailures[0].Reason)
+	}
+
+	return root, nil
+}
+
+// listSearchTasks returns the running search tasks of the cluster by task ID, and the names of
+// the nodes by node ID
+func (c *Client) listSearchTasks() (map[string]searchTaskInfo, map[string]string, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Tasks.List(
+		c.es.Tasks.List.WithContext(ctx),
+		c.es.Tasks.List.WithActions(searchTaskActions...),
+		c.es.Tasks.List.WithDetailed(true),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing tasks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil, newResponseError(res)
+	}
+
+	// Parse response, grouped by node
+	var response struct {
+		Nodes map[string]struct {
+			Name  string                    `json:"name"`
+			Tasks map[string]searchTaskInfo `json:"tasks"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	tasks := make(map[string]searchTaskInfo)
+	nodeNames := make(map[string]string, len(response.Nodes))
+	for nodeID, node := range response.Nodes {
+		nodeNames[nodeID] = node.Name
+		for id, task := range node.Tasks {
+			tasks[id] = task
+		}
+	}
+	return tasks, nodeNames, nil
+}
+
+// rootSearchTask follows the parents of a task up to the first one that is not started by
+// another search task
+func rootSearchTask(id string, tasks map[string]searchTaskInfo) string {
+	// Guard against cycles in case the listing caught tasks mid-update
+	for range len(tasks) {
+		parent := tasks[id].ParentTaskID
+		if _, ok := tasks[parent]; !ok {
+			break
+		}
+		id = parent
+	}
+	return id
+}
+
+// searchTaskIndices returns the indices named in a search task description
+func searchTaskIndices(description string) []string {
+	match := taskDescriptionIndices.FindStringSubmatch(description)
+	if match == nil || match[1] == "" {
+		return nil
+	}
+	return strings.Split(match[1], ",")
+}
+
+// isTaskID reports whether id has the node_id:task_number form of task IDs
+func isTaskID(id string) bool {
+	node, number, ok := strings.Cut(id, ":")
+	if !ok || node == "" || number == "" {
+		return false
+	}
+	return strings.Trim(number, "0123456789") == ""
+}
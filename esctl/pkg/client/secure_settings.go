@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// secureSettingGroupPatterns match the node settings of the components that take their
+// credentials from the keystore. The first submatch names the group the settings belong to.
+var secureSettingGroupPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^((?:s3|azure|gcs)\.client\.[^.]+)\.`),
+	regexp.MustCompile(`^(xpack\.notification\.[^.]+\.account\.[^.]+)\.`),
+	regexp.MustCompile(`^(xpack\.security\.authc\.realms\.[^.]+\.[^.]+)\.`),
+	regexp.MustCompile(`^(xpack\.monitoring\.exporters\.[^.]+)\.`),
+}
+
+// SecureSettingGroup is a client, account, realm or exporter configured in node settings, the
+// components whose credentials are kept in the keystore
+type SecureSettingGroup struct {
+	Name         string   // e.g. s3.client.default or xpack.security.authc.realms.ldap.ldap1
+	Nodes        []string // names of the nodes the group is configured on
+	MissingNodes []string // names of the nodes it is not configured on
+}
+
+// SecureSettingsReload is the outcome of reloading the keystore on a node
+type SecureSettingsReload struct {
+	NodeID string
+	Name   string
+	Error  string // reload exception, empty on success
+}
+
+// GetSecureSettingGroups returns the clients, accounts, realms and exporters configured in the
+// settings of each node, and the nodes each is missing from. The nodes info API never returns
+// secure settings, so the keystore entries themselves cannot be listed; these groups are the
+// components that read them.
+func (c *Client) GetSecureSettingGroups() ([]SecureSettingGroup, int, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Nodes.Info(
+		c.es.Nodes.Info.WithContext(ctx),
+		c.es.Nodes.Info.WithMetric("settings"),
+		c.es.Nodes.Info.WithFlatSettings(true),
+		c.es.Nodes.Info.WithFilterPath("nodes.*.name", "nodes.*.settings"),
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting node settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, 0, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]struct {
+			Name     string                 `json:"name"`
+			Settings map[string]interface{} `json:"settings"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var nodeNames []string
+	groupNodes := make(map[string]map[string]bool)
+	for _, node := range response.Nodes {
+		nodeNames = append(nodeNames, node.Name)
+		for setting := range node.Settings {
+			for _, pattern := range secureSettingGroupPatterns {
+				match := pattern.FindStringSubmatch(setting)
+				if match == nil {
+					continue
+				}
+				if groupNodes[match[1]] == nil {
+					groupNodes[match[1]] = make(map[string]bool)
+				}
+				groupNodes[match[1]][node.Name] = true
+			}
+		}
+	}
+	sort.Strings(nodeNames)
+
+	groups := make([]SecureSettingGroup, 0, len(groupNodes))
+	for name, nodes := range groupNodes {
+		group := SecureSettingGroup{Name: name}
+		for _, node := range nodeNames {
+			if nodes[node] {
+				group.Nodes = append(group.Nodes, node)
+			} else {
+				group.MissingNodes = append(group.MissingNodes, node)
+			}
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, len(nodeNames), nil
+}
+
+// ReloadSecureSettings reloads the keystore on every node, decrypting it with the password if
+// one is given. Elasticsearch only accepts a password over TLS.
+func (c *Client) ReloadSecureSettings(password string) ([]SecureSettingsReload, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if password != "" {
+		body := map[string]interface{}{
+			"secure_settings_password": password,
+		}
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, fmt.Errorf("error encoding request body: %w", err)
+		}
+	}
+
+	// Execute request
+	res, err := c.es.Nodes.ReloadSecureSettings(
+		c.es.Nodes.ReloadSecureSettings.WithContext(ctx),
+		c.es.Nodes.ReloadSecureSettings.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error reloading secure settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Nodes map[string]struct {
+			Name            string `json:"name"`
+			ReloadException *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"reload_exception"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	results := make([]SecureSettingsReload, 0, len(response.Nodes))
+	for id, node := range response.Nodes {
+		result := SecureSettingsReload{NodeID: id, Name: node.Name}
+		if node.ReloadException != nil {
+			result.Error = fmt.Sprintf("%s: %s", node.ReloadException.Type, node.ReloadException.Reason)
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	return results, nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SetUserPassword changes the password of a native or built-in user
+func (c *Client) SetUserPassword(username, password string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Prepare request body
+	body, err := json.Marshal(map[string]string{"password": password})
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Security.ChangePassword(
+		bytes.NewReader(body),
+		c.es.Security.ChangePassword.WithContext(ctx),
+		c.es.Security.ChangePassword.WithUsername(username),
+	)
+	if err != nil {
+		return fmt.Errorf("error changing password: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kinds of security objects in a security dump
+const (
+	SecurityKindRoles        = "roles"
+	SecurityKindRoleMappings = "role_mappings"
+	SecurityKindUsers        = "users"
+	SecurityKindAPIKeys      = "api_keys"
+)
+
+// GetSecurityConfig returns the roles, role mappings, native users and API keys of the cluster.
+// Users are returned as the security API reports them, which never includes password hashes, and
+// API keys only with their metadata, as their secrets cannot be read back. Without
+// includeReserved, the built-in roles and users are skipped.
+func (c *Client) GetSecurityConfig(includeReserved bool) ([]ConfigObjects, error) {
+	roles, err := c.getRoleDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles: %w", err)
+	}
+	mappings, err := c.getRoleMappingDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting role mappings: %w", err)
+	}
+	users, err := c.getUserDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting users: %w", err)
+	}
+	apiKeys, err := c.getAPIKeyDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting API keys: %w", err)
+	}
+
+	result := []ConfigObjects{
+		{Kind: SecurityKindRoles, Objects: roles},
+		{Kind: SecurityKindRoleMappings, Objects: mappings},
+		{Kind: SecurityKindUsers, Objects: users},
+		{Kind: SecurityKindAPIKeys, Objects: apiKeys},
+	}
+	if !includeReserved {
+		for _, objects := range result {
+			for name, definition := range objects.Objects {
+				if isReservedDefinition(definition) {
+					delete(objects.Objects, name)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// getRoleDefinitions returns the roles by name, without their transient metadata, which
+// Elasticsearch sets itself and rejects when the role is created
+func (c *Client) getRoleDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetRole(
+		c.es.Security.GetRole.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles map[string]interface{}
+	if err := decodeConfigResponse(res, &roles); err != nil {
+		return nil, err
+	}
+	for _, definition := range roles {
+		if role, ok := definition.(map[string]interface{}); ok {
+			delete(role, "transient_metadata")
+		}
+	}
+	return roles, nil
+}
+
+// getRoleMappingDefinitions returns the role mappings by name
+func (c *Client) getRoleMappingDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetRoleMapping(
+		c.es.Security.GetRoleMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings map[string]interface{}
+	if err := decodeConfigResponse(res, &mappings); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// getUserDefinitions returns the users of the native realm by username
+func (c *Client) getUserDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetUser(
+		c.es.Security.GetUser.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var users map[string]interface{}
+	if err := decodeConfigResponse(res, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// getAPIKeyDefinitions returns the metadata of the API keys by ID, as names need not be unique
+func (c *Client) getAPIKeyDefinitions() (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetAPIKey(
+		c.es.Security.GetAPIKey.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		APIKeys []map[string]interface{} `json:"api_keys"`
+	}
+	if err := decodeConfigResponse(res, &response); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(response.APIKeys))
+	for _, key := range response.APIKeys {
+		id, _ := key["id"].(string)
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// PutRole creates or replaces a role from its definition as returned by GetSecurityConfig
+func (c *Client) PutRole(name string, definition interface{}) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(definition)
+	if err != nil {
+		return fmt.Errorf("error encoding role: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Security.PutRole(
+		name,
+		bytes.NewReader(body),
+		c.es.Security.PutRole.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error putting role: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// PutRoleMapping creates or replaces a role mapping from its definition as returned by
+// GetSecurityConfig
+func (c *Client) PutRoleMapping(name string, definition interface{}) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(definition)
+	if err != nil {
+		return fmt.Errorf("error encoding role mapping: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Security.PutRoleMapping(
+		name,
+		bytes.NewReader(body),
+		c.es.Security.PutRoleMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error putting role mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// isReservedDefinition reports whether a security object is built in, as marked in its metadata
+func isReservedDefinition(definition interface{}) bool {
+	object, ok := definition.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	reserved, _ := metadata["_reserved"].(bool)
+	return reserved
+}
@@ -0,0 +1,418 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// SecurityUser is a user of the native realm, or a built-in user
+type SecurityUser struct {
+	Username string                 `json:"username"`
+	Roles    []string               `json:"roles"`
+	FullName string                 `json:"full_name,omitempty"`
+	Email    string                 `json:"email,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Enabled  bool                   `json:"enabled"`
+}
+
+// Reserved reports whether the user is built in
+func (u *SecurityUser) Reserved() bool {
+	reserved, _ := u.Metadata["_reserved"].(bool)
+	return reserved
+}
+
+// RoleIndexPrivileges are the privileges a role grants on a set of indices
+type RoleIndexPrivileges struct {
+	Names      []string `json:"names"`
+	Privileges []string `json:"privileges"`
+}
+
+// SecurityRole is a role, with its full definition as returned by the security API
+type SecurityRole struct {
+	Name       string
+	Cluster    []string
+	Indices    []RoleIndexPrivileges
+	RunAs      []string
+	Reserved   bool
+	Definition map[string]interface{}
+}
+
+// SecurityRoleMapping maps users of external realms to roles by rules on their attributes
+type SecurityRoleMapping struct {
+	Name     string                 `json:"-"`
+	Enabled  bool                   `json:"enabled"`
+	Roles    []string               `json:"roles,omitempty"`
+	Rules    map[string]interface{} `json:"rules"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// RoleTemplates derive role names from user attributes, in place of Roles
+	RoleTemplates []interface{} `json:"role_templates,omitempty"`
+}
+
+// GetUsers returns the native and built-in users, sorted by username
+func (c *Client) GetUsers() ([]SecurityUser, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetUser(
+		c.es.Security.GetUser.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting users: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]SecurityUser
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	users := make([]SecurityUser, 0, len(response))
+	for _, user := range response {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+	return users, nil
+}
+
+// GetUser returns a user by username
+func (c *Client) GetUser(username string) (*SecurityUser, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetUser(
+		c.es.Security.GetUser.WithContext(ctx),
+		c.es.Security.GetUser.WithUsername(username),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]SecurityUser
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	user, ok := response[username]
+	if !ok {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+	return &user, nil
+}
+
+// PutUser creates a native user, or updates one. An empty password leaves the password of an
+// existing user unchanged; new users need one.
+func (c *Client) PutUser(user SecurityUser, password string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Prepare request body
+	body := map[string]interface{}{
+		"roles":   user.Roles,
+		"enabled": user.Enabled,
+	}
+	if user.Roles == nil {
+		body["roles"] = []string{}
+	}
+	if user.FullName != "" {
+		body["full_name"] = user.FullName
+	}
+	if user.Email != "" {
+		body["email"] = user.Email
+	}
+	if user.Metadata != nil {
+		body["metadata"] = user.Metadata
+	}
+	if password != "" {
+		body["password"] = password
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Security.PutUser(
+		user.Username,
+		bytes.NewReader(data),
+		c.es.Security.PutUser.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error putting user: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// DeleteUser deletes a native user
+func (c *Client) DeleteUser(username string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.DeleteUser(
+		username,
+		c.es.Security.DeleteUser.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// SetUserEnabled enables or disables a native or built-in user. A disabled user cannot log in,
+// but keeps its password and roles.
+func (c *Client) SetUserEnabled(username string, enabled bool) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	var res *esapi.Response
+	var err error
+	if enabled {
+		res, err = c.es.Security.EnableUser(
+			username,
+			c.es.Security.EnableUser.WithContext(ctx),
+		)
+	} else {
+		res, err = c.es.Security.DisableUser(
+			username,
+			c.es.Security.DisableUser.WithContext(ctx),
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("error changing user: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// GetRoles returns the roles, sorted by name
+func (c *Client) GetRoles() ([]SecurityRole, error) {
+	definitions, err := c.getRoleDefinitions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles: %w", err)
+	}
+
+	roles := make([]SecurityRole, 0, len(definitions))
+	for name, definition := range definitions {
+		role, err := newSecurityRole(name, definition)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// GetRole returns a role by name
+func (c *Client) GetRole(name string) (*SecurityRole, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetRole(
+		c.es.Security.GetRole.WithContext(ctx),
+		c.es.Security.GetRole.WithName(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting role: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	definition, ok := response[name]
+	if !ok {
+		return nil, fmt.Errorf("role %s not found", name)
+	}
+	if role, ok := definition.(map[string]interface{}); ok {
+		delete(role, "transient_metadata")
+	}
+	return newSecurityRole(name, definition)
+}
+
+// newSecurityRole reads the parts of a role definition shown in listings
+func newSecurityRole(name string, definition interface{}) (*SecurityRole, error) {
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding role %s: %w", name, err)
+	}
+	var parsed struct {
+		Cluster []string              `json:"cluster"`
+		Indices []RoleIndexPrivileges `json:"indices"`
+		RunAs   []string              `json:"run_as"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("error parsing role %s: %w", name, err)
+	}
+
+	object, _ := definition.(map[string]interface{})
+	return &SecurityRole{
+		Name:       name,
+		Cluster:    parsed.Cluster,
+		Indices:    parsed.Indices,
+		RunAs:      parsed.RunAs,
+		Reserved:   isReservedDefinition(definition),
+		Definition: object,
+	}, nil
+}
+
+// DeleteRole deletes a role
+func (c *Client) DeleteRole(name string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.DeleteRole(
+		name,
+		c.es.Security.DeleteRole.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting role: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// GetRoleMappings returns the role mappings, sorted by name
+func (c *Client) GetRoleMappings() ([]SecurityRoleMapping, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetRoleMapping(
+		c.es.Security.GetRoleMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting role mappings: %w", err)
+	}
+
+	// No role mappings is a 404
+	var response map[string]SecurityRoleMapping
+	if err := decodeConfigResponse(res, &response); err != nil {
+		return nil, err
+	}
+
+	mappings := make([]SecurityRoleMapping, 0, len(response))
+	for name, mapping := range response {
+		mapping.Name = name
+		mappings = append(mappings, mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].Name < mappings[j].Name
+	})
+	return mappings, nil
+}
+
+// GetRoleMapping returns a role mapping by name
+func (c *Client) GetRoleMapping(name string) (*SecurityRoleMapping, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.GetRoleMapping(
+		c.es.Security.GetRoleMapping.WithContext(ctx),
+		c.es.Security.GetRoleMapping.WithName(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting role mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]SecurityRoleMapping
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	mapping, ok := response[name]
+	if !ok {
+		return nil, fmt.Errorf("role mapping %s not found", name)
+	}
+	mapping.Name = name
+	return &mapping, nil
+}
+
+// DeleteRoleMapping deletes a role mapping
+func (c *Client) DeleteRoleMapping(name string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Security.DeleteRoleMapping(
+		name,
+		c.es.Security.DeleteRoleMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting role mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
@@ -26,7 +26,7 @@ func (c *Client) GetClusterSettings(includeDefaults bool) (map[string]map[string
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -70,7 +70,7 @@ func (c *Client) UpdateClusterSettings(settingType string, settings map[string]i
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -110,7 +110,7 @@ func (c *Client) ResetClusterSetting(settingType, settingName string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
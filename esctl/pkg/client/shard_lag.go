@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ShardLag represents the sequence number and translog state of a single shard copy
+type ShardLag struct {
+	Index                  string
+	Shard                  string
+	Primary                bool
+	State                  string
+	Node                   string
+	MaxSeqNo               int64
+	LocalCheckpoint        int64
+	GlobalCheckpoint       int64
+	CheckpointLag          int64 // max_seq_no minus global_checkpoint for this copy
+	BehindPrimary          int64 // primary max_seq_no minus this copy's local checkpoint (replicas only)
+	TranslogOperations     int64
+	TranslogSize           int64
+	TranslogUncommitted    int64
+	TranslogUncommittedOps int64
+}
+
+// shardStatsResponse mirrors the parts of _stats?level=shards used by GetShardLag
+type shardStatsResponse struct {
+	Indices map[string]struct {
+		Shards map[string][]struct {
+			Routing struct {
+				State   string `json:"state"`
+				Primary bool   `json:"primary"`
+				Node    string `json:"node"`
+			} `json:"routing"`
+			SeqNo struct {
+				MaxSeqNo         int64 `json:"max_seq_no"`
+				LocalCheckpoint  int64 `json:"local_checkpoint"`
+				GlobalCheckpoint int64 `json:"global_checkpoint"`
+			} `json:"seq_no"`
+			Translog struct {
+				Operations             int64 `json:"operations"`
+				SizeInBytes            int64 `json:"size_in_bytes"`
+				UncommittedOperations  int64 `json:"uncommitted_operations"`
+				UncommittedSizeInBytes int64 `json:"uncommitted_size_in_bytes"`
+			} `json:"translog"`
+		} `json:"shards"`
+	} `json:"indices"`
+}
+
+// GetShardLag returns the checkpoint and translog state of every shard copy, sorted so that
+// the copies furthest behind their primary come first
+func (c *Client) GetShardLag(indices []string) ([]ShardLag, error) {
+	// Resolve node IDs to names, shard level stats only report node IDs
+	nodes, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		nodeNames[node.ID] = node.Name
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.Stats(
+		c.es.Indices.Stats.WithContext(ctx),
+		c.es.Indices.Stats.WithIndex(indices...),
+		c.es.Indices.Stats.WithMetric("translog"),
+		c.es.Indices.Stats.WithLevel("shards"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting shard stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response shardStatsResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var result []ShardLag
+	for index, indexStats := range response.Indices {
+		for shardID, copies := range indexStats.Shards {
+			// Find the primary's max sequence number first
+			var primaryMaxSeqNo int64 = -1
+			for _, shardCopy := range copies {
+				if shardCopy.Routing.Primary {
+					primaryMaxSeqNo = shardCopy.SeqNo.MaxSeqNo
+				}
+			}
+
+			for _, shardCopy := range copies {
+				node := nodeNames[shardCopy.Routing.Node]
+				if node == "" {
+					node = shardCopy.Routing.Node
+				}
+
+				lag := ShardLag{
+					Index:                  index,
+					Shard:                  shardID,
+					Primary:                shardCopy.Routing.Primary,
+					State:                  shardCopy.Routing.State,
+					Node:                   node,
+					MaxSeqNo:               shardCopy.SeqNo.MaxSeqNo,
+					LocalCheckpoint:        shardCopy.SeqNo.LocalCheckpoint,
+					GlobalCheckpoint:       shardCopy.SeqNo.GlobalCheckpoint,
+					CheckpointLag:          shardCopy.SeqNo.MaxSeqNo - shardCopy.SeqNo.GlobalCheckpoint,
+					TranslogOperations:     shardCopy.Translog.Operations,
+					TranslogSize:           shardCopy.Translog.SizeInBytes,
+					TranslogUncommitted:    shardCopy.Translog.UncommittedSizeInBytes,
+					TranslogUncommittedOps: shardCopy.Translog.UncommittedOperations,
+				}
+				if !shardCopy.Routing.Primary && primaryMaxSeqNo >= 0 {
+					lag.BehindPrimary = primaryMaxSeqNo - shardCopy.SeqNo.LocalCheckpoint
+				}
+
+				result = append(result, lag)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BehindPrimary != result[j].BehindPrimary {
+			return result[i].BehindPrimary > result[j].BehindPrimary
+		}
+		if result[i].CheckpointLag != result[j].CheckpointLag {
+			return result[i].CheckpointLag > result[j].CheckpointLag
+		}
+		if result[i].Index != result[j].Index {
+			return result[i].Index < result[j].Index
+		}
+		si, _ := strconv.Atoi(result[i].Shard)
+		sj, _ := strconv.Atoi(result[j].Shard)
+		if si != sj {
+			return si < sj
+		}
+		return result[i].Primary && !result[j].Primary
+	})
+
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ShardSizeThresholds are the primary shard sizes outside which shards are flagged, and the size
+// suggested shard counts aim for
+type ShardSizeThresholds struct {
+	Min    int64
+	Max    int64
+	Target int64
+}
+
+// IndexShardSizes describes the primary shards of an index with shards outside the thresholds
+type IndexShardSizes struct {
+	Index      string
+	Primaries  int
+	TotalBytes int64 // primary store size
+	Smallest   int64
+	Largest    int64
+	TooLarge   int // primaries larger than the maximum
+	TooSmall   int // primaries smaller than the minimum
+	Suggested  int // primary count that keeps shards at or below the target size
+}
+
+// Status summarises whether the shards of the index are too large, too small or both
+func (s IndexShardSizes) Status() string {
+	switch {
+	case s.TooLarge > 0 && s.TooSmall > 0:
+		return "UNEVEN"
+	case s.TooLarge > 0:
+		return "TOO LARGE"
+	default:
+		return "TOO SMALL"
+	}
+}
+
+// GetShardSizeOutliers returns the indices matching pattern that have a started primary shard
+// outside the thresholds, largest index first. Replicas are left out, they are the same size as
+// their primary, and so are indices with a single small shard.
+func (c *Client) GetShardSizeOutliers(pattern string, thresholds ShardSizeThresholds) ([]IndexShardSizes, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Cat.Shards(
+		c.es.Cat.Shards.WithContext(ctx),
+		c.es.Cat.Shards.WithIndex(pattern),
+		c.es.Cat.Shards.WithFormat("json"),
+		c.es.Cat.Shards.WithBytes("b"),
+		c.es.Cat.Shards.WithH("index,shard,prirep,state,store"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting response: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var shards []struct {
+		Index  string `json:"index"`
+		PriRep string `json:"prirep"`
+		State  string `json:"state"`
+		Store  string `json:"store"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&shards); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	indices := make(map[string]*IndexShardSizes)
+	for _, shard := range shards {
+		if shard.PriRep != "p" || shard.State != "STARTED" {
+			continue
+		}
+		size, err := strconv.ParseInt(shard.Store, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		index, ok := indices[shard.Index]
+		if !ok {
+			index = &IndexShardSizes{Index: shard.Index, Smallest: size, Largest: size}
+			indices[shard.Index] = index
+		}
+		index.Primaries++
+		index.TotalBytes += size
+		if size < index.Smallest {
+			index.Smallest = size
+		}
+		if size > index.Largest {
+			index.Largest = size
+		}
+		if thresholds.Max > 0 && size > thresholds.Max {
+			index.TooLarge++
+		}
+		if size < thresholds.Min {
+			index.TooSmall++
+		}
+	}
+
+	var result []IndexShardSizes
+	for _, index := range indices {
+		// A small index with one shard cannot be resharded to fewer
+		if index.TooLarge == 0 && (index.TooSmall == 0 || index.Primaries == 1) {
+			continue
+		}
+		index.Suggested = SuggestedShardCount(index.TotalBytes, thresholds.Target)
+		result = append(result, *index)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalBytes != result[j].TotalBytes {
+			return result[i].TotalBytes > result[j].TotalBytes
+		}
+		return result[i].Index < result[j].Index
+	})
+
+	return result, nil
+}
+
+// SuggestedShardCount returns the number of primary shards that keeps each shard at or below the
+// target size, at least one
+func SuggestedShardCount(totalBytes, target int64) int {
+	if target <= 0 || totalBytes <= target {
+		return 1
+	}
+	return int((totalBytes + target - 1) / target)
+}
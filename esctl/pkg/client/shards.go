@@ -29,44 +29,57 @@ type ShardInfo struct {
 
 // GetShards returns information about all shards in the cluster
 func (c *Client) GetShards(nodes []string) ([]ShardInfo, error) {
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Prepare the request
-	req := map[string]interface{}{
-		"format": "json",
-		"h": "index,shard,prirep,state,docs,store,ip,node,unassigned.reason,unassigned.at," +
-			"unassigned.details,unassigned.for,recovery_source,recovery_stage,recovery_type,recovery_time_millis",
+	var shards []ShardInfo
+	err := c.StreamShards(nodes, func(shard ShardInfo) error {
+		shards = append(shards, shard)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Add node filter if specified
-	if len(nodes) > 0 {
-		req["nodes"] = nodes
-	}
+	return shards, nil
+}
+
+// StreamShards calls fn for every shard in the cluster as it is decoded from the response,
+// so that very large listings do not have to be held in memory
+func (c *Client) StreamShards(nodes []string, fn func(ShardInfo) error) error {
+	// Create context with timeout (longer, the body is read while rows are processed)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
 
 	// Execute request
 	res, err := c.es.Cat.Shards(
 		c.es.Cat.Shards.WithContext(ctx),
 		c.es.Cat.Shards.WithFormat("json"),
-		c.es.Cat.Shards.WithH(req["h"].(string)),
+		c.es.Cat.Shards.WithH("index,shard,prirep,state,docs,store,ip,node,unassigned.reason,unassigned.at,"+
+			"unassigned.details,unassigned.for,recovery_source,recovery_stage,recovery_type,recovery_time_millis"),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error getting response: %w", err)
+		return fmt.Errorf("error getting response: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
-	// Parse response
-	var shards []ShardInfo
-	if err := json.NewDecoder(res.Body).Decode(&shards); err != nil {
-		return nil, fmt.Errorf("error parsing response: %w", err)
+	// Parse response one shard at a time
+	decoder := json.NewDecoder(res.Body)
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	for decoder.More() {
+		var shard ShardInfo
+		if err := decoder.Decode(&shard); err != nil {
+			return fmt.Errorf("error parsing response: %w", err)
+		}
+		if err := fn(shard); err != nil {
+			return err
+		}
 	}
 
-	return shards, nil
+	return nil
 }
 
 // GetShardsByNode organizes shards by node name
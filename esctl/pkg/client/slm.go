@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SLM policy statuses reported by VerifySLMPolicy
+const (
+	SLMStatusOK     = "OK"
+	SLMStatusFailed = "FAILED" // the last run failed
+	SLMStatusMissed = "MISSED" // no run succeeded since the last scheduled run that should have completed
+)
+
+// SLMInvocation is a run of a snapshot lifecycle policy
+type SLMInvocation struct {
+	SnapshotName string `json:"snapshot_name"`
+	StartTime    int64  `json:"start_time"` // milliseconds, 0 from clusters that do not report it
+	Time         int64  `json:"time"`       // milliseconds, when the run ended
+	Details      string `json:"details"`
+}
+
+// At returns the time the run ended
+func (i *SLMInvocation) At() time.Time {
+	return time.UnixMilli(i.Time).UTC()
+}
+
+// SLMPolicy is a snapshot lifecycle policy with the outcome of its last runs
+type SLMPolicy struct {
+	ID                  string `json:"-"`
+	Version             int    `json:"version"`
+	ModifiedDateMillis  int64  `json:"modified_date_millis"`
+	NextExecutionMillis int64  `json:"next_execution_millis"`
+	Policy              struct {
+		Name       string `json:"name"`
+		Schedule   string `json:"schedule"`
+		Repository string `json:"repository"`
+	} `json:"policy"`
+	LastSuccess *SLMInvocation `json:"last_success"`
+	LastFailure *SLMInvocation `json:"last_failure"`
+	InProgress  *struct {
+		Name            string `json:"name"`
+		State           string `json:"state"`
+		StartTimeMillis int64  `json:"start_time_millis"`
+	} `json:"in_progress"`
+}
+
+// SLMVerification is the health of a snapshot lifecycle policy
+type SLMVerification struct {
+	Status string
+	Detail string
+	Due    time.Time // the last scheduled run that should have completed, zero if the schedule was not checked
+}
+
+// GetSLMPolicies returns every snapshot lifecycle policy, sorted by ID
+func (c *Client) GetSLMPolicies() ([]SLMPolicy, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.SlmGetLifecycle(
+		c.es.SlmGetLifecycle.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting snapshot lifecycle policies: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]SLMPolicy
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	policies := make([]SLMPolicy, 0, len(response))
+	for id, policy := range response {
+		policy.ID = id
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ID < policies[j].ID
+	})
+	return policies, nil
+}
+
+// VerifySLMPolicy checks the last runs of a policy against its schedule. A policy is missed when
+// no run succeeded since the last scheduled run, ignoring runs scheduled within grace of now so a
+// snapshot has time to complete. Runs scheduled before the policy was last modified are not
+// expected. A policy whose last run failed is failed, unless it is also missed. Schedules that
+// cannot be evaluated are reported in the detail and only the last run is checked.
+func VerifySLMPolicy(policy SLMPolicy, now time.Time, grace time.Duration) SLMVerification {
+	var verification SLMVerification
+
+	schedule, err := ParseSLMSchedule(policy.Policy.Schedule)
+	if err != nil {
+		verification.Detail = fmt.Sprintf("schedule not checked: %v", err)
+	} else if due, ok := schedule.Previous(now.Add(-grace)); ok && due.After(time.UnixMilli(policy.ModifiedDateMillis)) {
+		verification.Due = due.UTC()
+		if policy.LastSuccess == nil || policy.LastSuccess.At().Before(due) {
+			verification.Status = SLMStatusMissed
+			verification.Detail = fmt.Sprintf("no successful run since the run scheduled at %s", due.UTC().Format(time.RFC3339))
+		}
+	}
+
+	failed := policy.LastFailure != nil && (policy.LastSuccess == nil || policy.LastFailure.Time > policy.LastSuccess.Time)
+	switch {
+	case verification.Status == SLMStatusMissed:
+		if failed {
+			verification.Detail += ", last run failed: " + policy.LastFailure.Details
+		}
+	case failed:
+		verification.Status = SLMStatusFailed
+		verification.Detail = joinDetail(verification.Detail, "last run failed: "+policy.LastFailure.Details)
+	default:
+		verification.Status = SLMStatusOK
+	}
+	return verification
+}
+
+// joinDetail joins two parts of a detail with a comma, either of which may be empty
+func joinDetail(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + ", " + b
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField is the range of values of a field of an Elasticsearch cron expression, with the
+// names it accepts for them
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names of the values from min, if the field has any
+}
+
+// cronFields are the fields of an Elasticsearch cron expression, in order. The year is optional.
+var cronFields = []cronField{
+	{name: "seconds", min: 0, max: 59},
+	{name: "minutes", min: 0, max: 59},
+	{name: "hours", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "day of week", min: 1, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+	{name: "year", min: 1970, max: 2199},
+}
+
+// cronSearchDays is how far back Previous looks for a scheduled run, long enough for a schedule
+// on the 29th of February
+const cronSearchDays = 8 * 366
+
+// SLMSchedule is the schedule of a snapshot lifecycle policy: a cron expression, evaluated in
+// UTC, or an interval
+type SLMSchedule struct {
+	interval time.Duration
+	fields   [][]bool // values allowed by each cron field, indexed by value
+}
+
+// ParseSLMSchedule parses the schedule of a snapshot lifecycle policy. Cron expressions may use
+// values, names, ranges, steps, lists, * and ?. The L, W and # forms are not supported.
+func ParseSLMSchedule(schedule string) (*SLMSchedule, error) {
+	parts := strings.Fields(schedule)
+	if len(parts) == 1 {
+		interval, err := ParseTimeValue(parts[0])
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q", schedule)
+		}
+		return &SLMSchedule{interval: interval}, nil
+	}
+	if len(parts) < 6 || len(parts) > 7 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 6 or 7 cron fields", schedule)
+	}
+
+	s := &SLMSchedule{}
+	for i, field := range cronFields {
+		expression := "*"
+		if i < len(parts) {
+			expression = parts[i]
+		}
+		allowed, err := field.parse(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+		s.fields = append(s.fields, allowed)
+	}
+	return s, nil
+}
+
+// parse returns the values a cron field expression allows
+func (f cronField) parse(expression string) ([]bool, error) {
+	allowed := make([]bool, f.max+1)
+	for _, item := range strings.Split(strings.ToUpper(expression), ",") {
+		// Names such as JUL and WED contain the letters of the unsupported forms
+		bare := item
+		for _, name := range f.names {
+			bare = strings.ReplaceAll(bare, name, "")
+		}
+		if strings.ContainsAny(bare, "LW#") {
+			return nil, fmt.Errorf("%s %q uses a form that is not supported", f.name, item)
+		}
+
+		step := 1
+		if base, stepText, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %s %q", f.name, item)
+			}
+			item, step = base, n
+		}
+
+		from, to := f.min, f.max
+		switch {
+		case item == "*" || item == "?":
+		case strings.Contains(item, "-"):
+			low, high, _ := strings.Cut(item, "-")
+			from, to = f.value(low), f.value(high)
+			if from < 0 || to < 0 || from > to {
+				return nil, fmt.Errorf("invalid range in %s %q", f.name, item)
+			}
+		default:
+			from = f.value(item)
+			if from < 0 {
+				return nil, fmt.Errorf("invalid %s %q", f.name, item)
+			}
+			// A start with a step runs to the end of the range, a single value is only itself
+			if step == 1 {
+				to = from
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			allowed[v] = true
+		}
+	}
+	return allowed, nil
+}
+
+// value returns the value of a number or name in the field, or -1 if it is neither
+func (f cronField) value(text string) int {
+	for i, name := range f.names {
+		if text == name {
+			return f.min + i
+		}
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return -1
+	}
+	return n
+}
+
+// Previous returns the last time at or before t the policy was scheduled to run, and false if
+// there was none. For an interval schedule it is one interval before t, the latest a run can
+// have been scheduled whenever the interval started.
+func (s *SLMSchedule) Previous(t time.Time) (time.Time, bool) {
+	t = t.UTC().Truncate(time.Second)
+	if s.interval > 0 {
+		return t.Add(-s.interval), true
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 0; i < cronSearchDays; i++ {
+		if s.matchesDay(day) {
+			for hour := 23; hour >= 0; hour-- {
+				if !s.fields[2][hour] {
+					continue
+				}
+				for minute := 59; minute >= 0; minute-- {
+					if !s.fields[1][minute] {
+						continue
+					}
+					for second := 59; second >= 0; second-- {
+						run := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second)
+						if s.fields[0][second] && !run.After(t) {
+							return run, true
+						}
+					}
+				}
+			}
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+	return time.Time{}, false
+}
+
+// matchesDay reports whether the cron expression allows runs on a day
+func (s *SLMSchedule) matchesDay(day time.Time) bool {
+	year := day.Year()
+	if year < cronFields[6].min || year > cronFields[6].max {
+		return false
+	}
+	return s.fields[3][day.Day()] && s.fields[4][int(day.Month())] && s.fields[5][int(day.Weekday())+1] && s.fields[6][year]
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSlowlogIndexPattern matches the search slow log as shipped by the Elasticsearch
+// integration of Elastic Agent and by the Filebeat elasticsearch module
+const DefaultSlowlogIndexPattern = "logs-elasticsearch.slowlog-*,filebeat-*"
+
+// Fields of the slow log entries as the Elasticsearch integration and Filebeat module map them
+const (
+	slowlogIndexField      = "elasticsearch.index.name"
+	slowlogDurationField   = "event.duration"
+	slowlogSearchTypeField = "elasticsearch.slowlog.search_type"
+)
+
+// slowlogSourceFields are the fields holding the query of a slow log entry, newest mapping first
+var slowlogSourceFields = []string{"elasticsearch.slowlog.source", "elasticsearch.slowlog.source_query"}
+
+// SlowQuery is a search recorded in the slow log
+type SlowQuery struct {
+	Timestamp   time.Time
+	Node        string
+	Index       string
+	Took        time.Duration
+	Level       string // slow log threshold the search crossed: warn, info, debug or trace
+	SearchType  string
+	TotalShards int
+	User        string // only recorded when index.search.slowlog.include.user is set
+	OpaqueID    string // X-Opaque-Id header the client sent, if any
+	Source      string
+}
+
+// GetSlowQueries returns up to size searches from the slow log in the indices matching pattern
+// over a period, most recent first, or slowest first with bySlowest. When index is given only
+// searches on indices matching it are returned. It also returns the number of slow searches
+// found, which is zero if the slow log is not enabled or not shipped to the cluster.
+func (c *Client) GetSlowQueries(pattern string, period TimeRange, index string, size int, bySlowest bool) ([]SlowQuery, int64, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	filter := []interface{}{
+		map[string]interface{}{"term": map[string]interface{}{"event.dataset": "elasticsearch.slowlog"}},
+		map[string]interface{}{"exists": map[string]interface{}{"field": slowlogSearchTypeField}},
+		period.Query("@timestamp"),
+	}
+	if index != "" {
+		filter = append(filter, map[string]interface{}{
+			"wildcard": map[string]interface{}{slowlogIndexField: index},
+		})
+	}
+
+	sortField := "@timestamp"
+	if bySlowest {
+		sortField = slowlogDurationField
+	}
+	body := map[string]interface{}{
+		"size":             size,
+		"track_total_hits": true,
+		"query":            map[string]interface{}{"bool": map[string]interface{}{"filter": filter}},
+		"sort": []interface{}{
+			map[string]interface{}{sortField: map[string]interface{}{"order": "desc", "unmapped_type": "long"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, 0, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	// Execute request
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithIndex(pattern),
+		c.es.Search.WithBody(&buf),
+		c.es.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching the slow log: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, 0, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source map[string]interface{} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	queries := make([]SlowQuery, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		queries = append(queries, slowQueryFromSource(hit.Source))
+	}
+	return queries, response.Hits.Total.Value, nil
+}
+
+// slowQueryFromSource reads a slow log entry
+func slowQueryFromSource(source map[string]interface{}) SlowQuery {
+	str := func(path string) string {
+		if value, ok := SourceValue(source, path); ok && value != nil {
+			return fmt.Sprint(value)
+		}
+		return ""
+	}
+	num := func(path string) float64 {
+		value, _ := SourceValue(source, path)
+		number, _ := value.(float64)
+		return number
+	}
+
+	q := SlowQuery{
+		Node:        str("elasticsearch.node.name"),
+		Index:       str(slowlogIndexField),
+		Took:        time.Duration(num(slowlogDurationField)),
+		Level:       strings.ToLower(str("log.level")),
+		SearchType:  str(slowlogSearchTypeField),
+		TotalShards: int(num("elasticsearch.slowlog.total_shards")),
+		User:        str("user.name"),
+		OpaqueID:    str("elasticsearch.slowlog.id"),
+	}
+	if t, err := time.Parse(time.RFC3339Nano, str("@timestamp")); err == nil {
+		q.Timestamp = t
+	}
+	if q.Took == 0 {
+		q.Took = time.Duration(num("elasticsearch.slowlog.took_millis")) * time.Millisecond
+	}
+	for _, field := range slowlogSourceFields {
+		if q.Source = str(field); q.Source != "" {
+			break
+		}
+	}
+	return q
+}
+
+// SlowQueryPattern is a group of slow searches with the same query shape on the same indices
+type SlowQueryPattern struct {
+	Shape       string // query with its values replaced by ?
+	Index       string
+	Count       int
+	TotalTook   time.Duration
+	MaxTook     time.Duration
+	Users       []string
+	Suggestions []string
+}
+
+// AvgTook returns the average duration of the searches in the pattern
+func (p SlowQueryPattern) AvgTook() time.Duration {
+	if p.Count == 0 {
+		return 0
+	}
+	return p.TotalTook / time.Duration(p.Count)
+}
+
+// AnalyzeSlowQueries groups slow searches by query shape and index, most total time first, and
+// suggests how each pattern could be made faster
+func AnalyzeSlowQueries(queries []SlowQuery) []SlowQueryPattern {
+	byKey := make(map[string]*SlowQueryPattern)
+	users := make(map[string]map[string]bool)
+	var keys []string
+
+	for _, q := range queries {
+		shape := QueryShape(q.Source)
+		key := q.Index + "\x00" + shape
+		p, ok := byKey[key]
+		if !ok {
+			p = &SlowQueryPattern{Shape: shape, Index: q.Index, Suggestions: slowQuerySuggestions(q)}
+			byKey[key] = p
+			users[key] = make(map[string]bool)
+			keys = append(keys, key)
+		}
+		p.Count++
+		p.TotalTook += q.Took
+		if q.Took > p.MaxTook {
+			p.MaxTook = q.Took
+		}
+		if q.User != "" && !users[key][q.User] {
+			users[key][q.User] = true
+			p.Users = append(p.Users, q.User)
+		}
+	}
+
+	result := make([]SlowQueryPattern, 0, len(keys))
+	for _, key := range keys {
+		sort.Strings(byKey[key].Users)
+		result = append(result, *byKey[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalTook > result[j].TotalTook
+	})
+	return result
+}
+
+// QueryShape returns a query with every value replaced by ?, so searches that differ only in
+// the values they look for have the same shape. Sources that are not valid JSON, as when the
+// slow log truncated them, are returned as they are.
+func QueryShape(source string) string {
+	var query interface{}
+	if err := json.Unmarshal([]byte(source), &query); err != nil {
+		return source
+	}
+	shape, err := json.Marshal(replaceQueryValues(query))
+	if err != nil {
+		return source
+	}
+	return string(shape)
+}
+
+// replaceQueryValues replaces the scalar values of a decoded query with ?
+func replaceQueryValues(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[key] = replaceQueryValues(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, child := range v {
+			result[i] = replaceQueryValues(child)
+		}
+		return result
+	default:
+		return "?"
+	}
+}
+
+// Patterns in the source of a slow search that make it expensive
+var (
+	leadingWildcard = regexp.MustCompile(`"(wildcard|query_string|query)"\s*:\s*\{[^}]*"\*|"(value|wildcard|query)"\s*:\s*"\*`)
+	deepFrom        = regexp.MustCompile(`"from"\s*:\s*(\d+)`)
+	largeSize       = regexp.MustCompile(`"size"\s*:\s*(\d+)`)
+)
+
+// slowQuerySuggestions returns ways to make a slow search faster, from the shape of its query
+func slowQuerySuggestions(q SlowQuery) []string {
+	var suggestions []string
+	source := q.Source
+
+	if leadingWildcard.MatchString(source) {
+		suggestions = append(suggestions, "leading wildcard: use a wildcard or ngram field instead")
+	}
+	if strings.Contains(source, `"regexp"`) {
+		suggestions = append(suggestions, "regexp query: anchor the pattern or match on a keyword prefix")
+	}
+	if strings.Contains(source, `"script"`) {
+		suggestions = append(suggestions, "script: compute the value at index time or with a runtime field on fewer documents")
+	}
+	if m := deepFrom.FindStringSubmatch(source); m != nil && atoi(m[1]) >= 10000 {
+		suggestions = append(suggestions, "deep pagination: use search_after with a point in time")
+	}
+	if m := largeSize.FindStringSubmatch(source); m != nil && atoi(m[1]) > 1000 {
+		suggestions = append(suggestions, "large size: page through the results or use a point in time")
+	}
+	if q.TotalShards > 100 {
+		suggestions = append(suggestions, fmt.Sprintf("searches %d shards: narrow the index pattern or filter on a time range", q.TotalShards))
+	}
+	return suggestions
+}
+
+// atoi converts a number found in a query to an int, or 0 when it does not fit
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
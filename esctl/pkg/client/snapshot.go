@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -23,16 +24,87 @@ type SnapshotInfo struct {
 	EndTime           string `json:"end_time"`
 	EndTimeInMillis   int64  `json:"end_time_in_millis"`
 	DurationInMillis  int64  `json:"duration_in_millis"`
-	Failures          []interface{} `json:"failures"`
+	Failures          []SnapshotShardFailure `json:"failures"`
 	Shards            map[string]int `json:"shards"`
 }
 
+// SnapshotShardFailure is a shard that could not be copied into a snapshot
+type SnapshotShardFailure struct {
+	Index     string `json:"index"`
+	IndexUUID string `json:"index_uuid"`
+	ShardID   int    `json:"shard_id"`
+	NodeID    string `json:"node_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+// FailedIndices returns the indices with at least one failed shard, sorted by name
+func (s *SnapshotInfo) FailedIndices() []string {
+	seen := make(map[string]bool)
+	var failed []string
+	for _, f := range s.Failures {
+		if !seen[f.Index] {
+			seen[f.Index] = true
+			failed = append(failed, f.Index)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
 // RepositoryInfo represents information about a snapshot repository
 type RepositoryInfo struct {
 	Type     string                 `json:"type"`
 	Settings map[string]interface{} `json:"settings"`
 }
 
+// requiredRepositorySettings lists the settings each repository type cannot be registered without
+var requiredRepositorySettings = map[string][]string{
+	"fs":    {"location"},
+	"url":   {"url"},
+	"s3":    {"bucket"},
+	"gcs":   {"bucket"},
+	"azure": {},
+	"hdfs":  {"uri", "path"},
+}
+
+// RepositorySettingsError represents an error related to repository settings validation
+type RepositorySettingsError struct {
+	Type   string
+	Reason string
+}
+
+// Error implements the error interface for RepositorySettingsError
+func (e *RepositorySettingsError) Error() string {
+	return fmt.Sprintf("invalid settings for %s repository: %s", e.Type, e.Reason)
+}
+
+// ValidateRepositorySettings checks that the settings required by a repository type are present
+// Unknown repository types (e.g. provided by third party plugins) are not validated
+func ValidateRepositorySettings(repoType string, settings map[string]interface{}) error {
+	required, ok := requiredRepositorySettings[repoType]
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, key := range required {
+		value, ok := settings[key]
+		if !ok || value == nil || fmt.Sprintf("%v", value) == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &RepositorySettingsError{
+			Type:   repoType,
+			Reason: fmt.Sprintf("missing required setting(s): %s", strings.Join(missing, ", ")),
+		}
+	}
+
+	return nil
+}
+
 // GetRepositories returns all snapshot repositories
 func (c *Client) GetRepositories() (map[string]RepositoryInfo, error) {
 	// Create context with timeout
@@ -49,7 +121,7 @@ func (c *Client) GetRepositories() (map[string]RepositoryInfo, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -61,8 +133,114 @@ func (c *Client) GetRepositories() (map[string]RepositoryInfo, error) {
 	return repositories, nil
 }
 
+// RepositoryUsage represents snapshot usage statistics for a repository
+type RepositoryUsage struct {
+	SnapshotCount int
+	Oldest        time.Time
+	Newest        time.Time
+	SizeInBytes   int64
+}
+
+// snapshotStatusBatchSize limits how many snapshots are requested per _status call
+const snapshotStatusBatchSize = 50
+
+// snapshotWaitTimeout is how long CreateSnapshot waits for a snapshot to complete
+const snapshotWaitTimeout = 12 * time.Hour
+
+// GetRepositoryUsage returns snapshot count, oldest/newest snapshot start times and the total data
+// size of a repository. The size is the sum of the incremental size of every snapshot, i.e. the
+// files each snapshot added to the repository.
+func (c *Client) GetRepositoryUsage(name string) (*RepositoryUsage, error) {
+	snapshots, err := c.GetSnapshots(name)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &RepositoryUsage{SnapshotCount: len(snapshots)}
+	names := make([]string, 0, len(snapshots))
+	for _, snap := range snapshots {
+		names = append(names, snap.Snapshot)
+
+		started := time.UnixMilli(snap.StartTimeInMillis)
+		if usage.Oldest.IsZero() || started.Before(usage.Oldest) {
+			usage.Oldest = started
+		}
+		if started.After(usage.Newest) {
+			usage.Newest = started
+		}
+	}
+
+	// Fetch snapshot status in batches to sum up incremental sizes
+	for start := 0; start < len(names); start += snapshotStatusBatchSize {
+		end := start + snapshotStatusBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		size, err := c.getSnapshotsIncrementalSize(name, names[start:end])
+		if err != nil {
+			return nil, err
+		}
+		usage.SizeInBytes += size
+	}
+
+	return usage, nil
+}
+
+// getSnapshotsIncrementalSize returns the summed incremental size of the given snapshots
+func (c *Client) getSnapshotsIncrementalSize(repository string, snapshots []string) (int64, error) {
+	// Create context with timeout (status can be slow on large repositories)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Snapshot.Status(
+		c.es.Snapshot.Status.WithContext(ctx),
+		c.es.Snapshot.Status.WithRepository(repository),
+		c.es.Snapshot.Status.WithSnapshot(snapshots...),
+		c.es.Snapshot.Status.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error getting snapshot status: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Snapshots []struct {
+			Stats struct {
+				Incremental struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"incremental"`
+			} `json:"stats"`
+		} `json:"snapshots"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var total int64
+	for _, snap := range response.Snapshots {
+		total += snap.Stats.Incremental.SizeInBytes
+	}
+
+	return total, nil
+}
+
 // CreateRepository creates a new snapshot repository
 func (c *Client) CreateRepository(name string, repoType string, settings map[string]interface{}, verify bool) error {
+	// Validate name and settings before sending anything to the cluster
+	if err := ValidateRepositoryName(name); err != nil {
+		return err
+	}
+	if err := ValidateRepositorySettings(repoType, settings); err != nil {
+		return err
+	}
+
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -91,7 +269,7 @@ func (c *Client) CreateRepository(name string, repoType string, settings map[str
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -114,7 +292,7 @@ func (c *Client) DeleteRepository(name string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -138,7 +316,7 @@ func (c *Client) GetSnapshots(repository string) ([]SnapshotInfo, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// Parse response
@@ -154,8 +332,21 @@ func (c *Client) GetSnapshots(repository string) ([]SnapshotInfo, error) {
 
 // CreateSnapshot creates a new snapshot
 func (c *Client) CreateSnapshot(repository, name string, indices []string, includeGlobalState bool, waitForCompletion bool) (*SnapshotInfo, error) {
-	// Create context with timeout (longer for snapshot creation)
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	// Validate names before sending anything to the cluster
+	if err := ValidateRepositoryName(repository); err != nil {
+		return nil, err
+	}
+	if err := ValidateSnapshotName(name); err != nil {
+		return nil, err
+	}
+
+	// Create context with timeout (longer for snapshot creation, and for as long as a snapshot
+	// can reasonably take when waiting for it)
+	timeout := 60 * time.Second
+	if waitForCompletion {
+		timeout = snapshotWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Prepare the request body
@@ -183,7 +374,7 @@ func (c *Client) CreateSnapshot(repository, name string, indices []string, inclu
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res.String())
+		return nil, newResponseError(res)
 	}
 
 	// If wait for completion is false, just return nil
@@ -192,12 +383,14 @@ func (c *Client) CreateSnapshot(repository, name string, indices []string, inclu
 	}
 
 	// Parse response
-	var snapshot SnapshotInfo
-	if err := json.NewDecoder(res.Body).Decode(&snapshot); err != nil {
+	var response struct {
+		Snapshot SnapshotInfo `json:"snapshot"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
 		return nil, fmt.Errorf("error parsing response: %w", err)
 	}
 
-	return &snapshot, nil
+	return &response.Snapshot, nil
 }
 
 // VerifyRepository verifies that a repository is properly configured on all nodes
@@ -217,7 +410,7 @@ func (c *Client) VerifyRepository(name string) (bool, error) {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return false, fmt.Errorf("error response: %s", res.String())
+		return false, newResponseError(res)
 	}
 
 	// Parse response
@@ -254,28 +447,52 @@ func (c *Client) DeleteSnapshot(repository, name string) error {
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
 }
 
-// RestoreSnapshot restores a snapshot
-func (c *Client) RestoreSnapshot(repository, name string, indices []string, renamePattern, renameReplacement string, waitForCompletion bool) error {
+// RestoreOptions selects what a snapshot restore brings back
+type RestoreOptions struct {
+	Indices            []string
+	RenamePattern      string
+	RenameReplacement  string
+	IncludeGlobalState bool                   // restore the cluster state: templates, pipelines, ILM policies and persistent settings
+	FeatureStates      []string               // feature states to restore, nil for the default and ["none"] for none
+	ExcludeAliases     bool                   // do not restore the aliases of the indices
+	IndexSettings      map[string]interface{} // settings overriding those of the restored indices
+	WaitForCompletion  bool
+}
+
+// RestoreSnapshot restores a snapshot. Without feature states given, every feature state in the
+// snapshot is restored with the global state and none without it.
+func (c *Client) RestoreSnapshot(repository, name string, options RestoreOptions) error {
 	// Create context with timeout (longer for restore)
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
 	// Prepare the request body
-	body := map[string]interface{}{}
-	
-	if len(indices) > 0 {
-		body["indices"] = strings.Join(indices, ",")
+	body := map[string]interface{}{
+		"include_global_state": options.IncludeGlobalState,
+		"include_aliases":      !options.ExcludeAliases,
 	}
-	
-	if renamePattern != "" && renameReplacement != "" {
-		body["rename_pattern"] = renamePattern
-		body["rename_replacement"] = renameReplacement
+
+	if len(options.Indices) > 0 {
+		body["indices"] = strings.Join(options.Indices, ",")
+	}
+
+	if options.RenamePattern != "" && options.RenameReplacement != "" {
+		body["rename_pattern"] = options.RenamePattern
+		body["rename_replacement"] = options.RenameReplacement
+	}
+
+	if options.FeatureStates != nil {
+		body["feature_states"] = options.FeatureStates
+	}
+
+	if len(options.IndexSettings) > 0 {
+		body["index_settings"] = options.IndexSettings
 	}
 
 	var buf bytes.Buffer
@@ -289,7 +506,7 @@ func (c *Client) RestoreSnapshot(repository, name string, indices []string, rena
 		name,
 		c.es.Snapshot.Restore.WithBody(&buf),
 		c.es.Snapshot.Restore.WithContext(ctx),
-		c.es.Snapshot.Restore.WithWaitForCompletion(waitForCompletion),
+		c.es.Snapshot.Restore.WithWaitForCompletion(options.WaitForCompletion),
 	)
 	if err != nil {
 		return fmt.Errorf("error restoring snapshot: %w", err)
@@ -297,7 +514,7 @@ func (c *Client) RestoreSnapshot(repository, name string, indices []string, rena
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("error response: %s", res.String())
+		return newResponseError(res)
 	}
 
 	return nil
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SnapshotIndex describes an index stored in a snapshot
+type SnapshotIndex struct {
+	Name        string
+	Shards      int
+	SizeInBytes int64 // total size of the index files referenced by the snapshot
+	FileCount   int64
+	Status      string // state of the index in the cluster: open, close, or empty if it no longer exists
+}
+
+// Exists reports whether an index with the same name exists in the cluster
+func (i *SnapshotIndex) Exists() bool {
+	return i.Status != ""
+}
+
+// GetSnapshotContents lists the indices in a snapshot matching the given index expressions (all
+// indices if none are given), with their shard counts and sizes, and whether each index still
+// exists in the cluster
+func (c *Client) GetSnapshotContents(repository, name string, patterns []string) ([]SnapshotIndex, error) {
+	// Create context with timeout (status reads shard metadata from the repository and can be slow)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Snapshot.Status(
+		c.es.Snapshot.Status.WithContext(ctx),
+		c.es.Snapshot.Status.WithRepository(repository),
+		c.es.Snapshot.Status.WithSnapshot(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting snapshot status: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Snapshots []struct {
+			Indices map[string]struct {
+				ShardsStats struct {
+					Total int `json:"total"`
+				} `json:"shards_stats"`
+				Stats struct {
+					Total struct {
+						FileCount   int64 `json:"file_count"`
+						SizeInBytes int64 `json:"size_in_bytes"`
+					} `json:"total"`
+				} `json:"stats"`
+			} `json:"indices"`
+		} `json:"snapshots"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	if len(response.Snapshots) == 0 {
+		return nil, fmt.Errorf("snapshot %s not found in repository %s", name, repository)
+	}
+
+	// Existing indices and their state
+	existing, err := c.GetIndices("")
+	if err != nil {
+		return nil, err
+	}
+	existingStatus := make(map[string]string, len(existing))
+	for _, idx := range existing {
+		existingStatus[idx.Name] = idx.Status
+	}
+
+	contents := make([]SnapshotIndex, 0, len(response.Snapshots[0].Indices))
+	for index, stats := range response.Snapshots[0].Indices {
+		if !MatchIndexPatterns(index, patterns) {
+			continue
+		}
+		contents = append(contents, SnapshotIndex{
+			Name:        index,
+			Shards:      stats.ShardsStats.Total,
+			SizeInBytes: stats.Stats.Total.SizeInBytes,
+			FileCount:   stats.Stats.Total.FileCount,
+			Status:      existingStatus[index],
+		})
+	}
+
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].Name < contents[j].Name
+	})
+
+	return contents, nil
+}
@@ -0,0 +1,361 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// Template kinds
+const (
+	IndexTemplateKind     = "index"
+	ComponentTemplateKind = "component"
+)
+
+// Template is a composable index template or a component template
+type Template struct {
+	Name          string
+	Kind          string   // index or component
+	IndexPatterns []string // index templates only
+	Priority      int      // index templates only
+	ComposedOf    []string // index templates only
+	DataStream    bool     // index templates only, the template creates data streams
+	Version       int64
+	Managed       bool                   // _meta.managed is set, the template belongs to Elasticsearch or an integration
+	Body          map[string]interface{} // the template as given to the put API
+}
+
+// GetTemplates returns the templates of the given kind whose name matches name, which may hold
+// wildcards, sorted by name. No templates are returned when none match.
+func (c *Client) GetTemplates(kind, name string) ([]Template, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var templates []Template
+	switch kind {
+	case IndexTemplateKind:
+		// Execute request
+		res, err := c.es.Indices.GetIndexTemplate(
+			c.es.Indices.GetIndexTemplate.WithContext(ctx),
+			c.es.Indices.GetIndexTemplate.WithName(name),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting index templates: %w", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode == 404 {
+			return nil, nil
+		}
+		if res.IsError() {
+			return nil, newResponseError(res)
+		}
+
+		// Parse response
+		var response struct {
+			IndexTemplates []struct {
+				Name          string                 `json:"name"`
+				IndexTemplate map[string]interface{} `json:"index_template"`
+			} `json:"index_templates"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("error parsing response: %w", err)
+		}
+		for _, t := range response.IndexTemplates {
+			templates = append(templates, newTemplate(t.Name, kind, t.IndexTemplate))
+		}
+
+	case ComponentTemplateKind:
+		// Execute request
+		res, err := c.es.Cluster.GetComponentTemplate(
+			c.es.Cluster.GetComponentTemplate.WithContext(ctx),
+			c.es.Cluster.GetComponentTemplate.WithName(name),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting component templates: %w", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode == 404 {
+			return nil, nil
+		}
+		if res.IsError() {
+			return nil, newResponseError(res)
+		}
+
+		// Parse response
+		var response struct {
+			ComponentTemplates []struct {
+				Name              string                 `json:"name"`
+				ComponentTemplate map[string]interface{} `json:"component_template"`
+			} `json:"component_templates"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("error parsing response: %w", err)
+		}
+		for _, t := range response.ComponentTemplates {
+			templates = append(templates, newTemplate(t.Name, kind, t.ComponentTemplate))
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid template kind %q (use %s or %s)", kind, IndexTemplateKind, ComponentTemplateKind)
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// newTemplate builds a Template from its body in a get templates response
+func newTemplate(name, kind string, body map[string]interface{}) Template {
+	t := Template{Name: name, Kind: kind, Body: body}
+	t.IndexPatterns = stringList(body["index_patterns"])
+	t.ComposedOf = stringList(body["composed_of"])
+	if priority, ok := body["priority"].(float64); ok {
+		t.Priority = int(priority)
+	}
+	if version, ok := body["version"].(float64); ok {
+		t.Version = int64(version)
+	}
+	_, t.DataStream = body["data_stream"]
+	if meta, ok := body["_meta"].(map[string]interface{}); ok {
+		t.Managed, _ = meta["managed"].(bool)
+	}
+	return t
+}
+
+// stringList converts a JSON array of strings, or a single string, to a slice
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			list = append(list, fmt.Sprintf("%v", item))
+		}
+		return list
+	}
+	return nil
+}
+
+// PutTemplate creates or updates a template from its JSON body. With create set, an existing
+// template of the same name is an error rather than replaced.
+func (c *Client) PutTemplate(kind, name string, body []byte, create bool) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var res *esapi.Response
+	var err error
+	switch kind {
+	case IndexTemplateKind:
+		res, err = c.es.Indices.PutIndexTemplate(
+			name,
+			bytes.NewReader(body),
+			c.es.Indices.PutIndexTemplate.WithContext(ctx),
+			c.es.Indices.PutIndexTemplate.WithCreate(create),
+		)
+	case ComponentTemplateKind:
+		res, err = c.es.Cluster.PutComponentTemplate(
+			name,
+			bytes.NewReader(body),
+			c.es.Cluster.PutComponentTemplate.WithContext(ctx),
+			c.es.Cluster.PutComponentTemplate.WithCreate(create),
+		)
+	default:
+		return fmt.Errorf("invalid template kind %q (use %s or %s)", kind, IndexTemplateKind, ComponentTemplateKind)
+	}
+	if err != nil {
+		return fmt.Errorf("error putting %s template: %w", kind, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// DeleteTemplate deletes a template. Elasticsearch refuses to delete a component template that
+// an index template is composed of.
+func (c *Client) DeleteTemplate(kind, name string) error {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var res *esapi.Response
+	var err error
+	switch kind {
+	case IndexTemplateKind:
+		res, err = c.es.Indices.DeleteIndexTemplate(
+			name,
+			c.es.Indices.DeleteIndexTemplate.WithContext(ctx),
+		)
+	case ComponentTemplateKind:
+		res, err = c.es.Cluster.DeleteComponentTemplate(
+			name,
+			c.es.Cluster.DeleteComponentTemplate.WithContext(ctx),
+		)
+	default:
+		return fmt.Errorf("invalid template kind %q (use %s or %s)", kind, IndexTemplateKind, ComponentTemplateKind)
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting %s template: %w", kind, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+	return nil
+}
+
+// SimulateTemplate returns the settings, mappings and aliases an index would get. With index
+// set, the templates of the cluster are applied to that index name; otherwise the existing index
+// template name is resolved with its component templates, or body is when given, to check a
+// template before putting it. The response also lists the templates that overlap and would be
+// overridden.
+func (c *Client) SimulateTemplate(index, name string, body []byte) (map[string]interface{}, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var res *esapi.Response
+	var err error
+	if index != "" {
+		res, err = c.es.Indices.SimulateIndexTemplate(
+			index,
+			c.es.Indices.SimulateIndexTemplate.WithContext(ctx),
+		)
+	} else {
+		opts := []func(*esapi.IndicesSimulateTemplateRequest){c.es.Indices.SimulateTemplate.WithContext(ctx)}
+		if name != "" {
+			opts = append(opts, c.es.Indices.SimulateTemplate.WithName(name))
+		}
+		if body != nil {
+			opts = append(opts, c.es.Indices.SimulateTemplate.WithBody(bytes.NewReader(body)))
+		}
+		res, err = c.es.Indices.SimulateTemplate(opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error simulating template: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	return response, nil
+}
+
+// DiffTemplate returns the fields that differ between a template in the cluster and a local
+// template body, sorted by path: added fields are only in the local body, removed ones only in
+// the cluster. Settings are compared in the form Elasticsearch stores them, with the index.
+// prefix and string values, so number_of_shards: 1 and "index.number_of_shards": "1" are equal.
+// Empty lists and objects, and the false data_stream options Elasticsearch fills in, count as
+// not set.
+func DiffTemplate(remote, local map[string]interface{}) []FieldChange {
+	return DiffSource(normalizeTemplate(remote), normalizeTemplate(local))
+}
+
+// normalizeTemplate returns a copy of a template body with empty values left out and its
+// settings flattened, prefixed with index. and converted to strings
+func normalizeTemplate(body map[string]interface{}) map[string]interface{} {
+	result := withoutEmpty(body)
+
+	// An empty data_stream object makes the template create data streams, so it is kept
+	if dataStream, ok := body["data_stream"].(map[string]interface{}); ok {
+		options := make(map[string]interface{}, len(dataStream))
+		for key, value := range dataStream {
+			if value != false {
+				options[key] = value
+			}
+		}
+		result["data_stream"] = options
+	}
+
+	tmpl, ok := result["template"].(map[string]interface{})
+	if !ok {
+		return result
+	}
+	tmpl = withoutEmpty(tmpl)
+	result["template"] = tmpl
+
+	settings, ok := tmpl["settings"].(map[string]interface{})
+	if !ok {
+		return result
+	}
+	tmpl["settings"] = normalizeSettings(settings)
+	return result
+}
+
+// normalizeSettings returns index settings flattened, prefixed with index. and converted to
+// strings, the form Elasticsearch stores them in
+func normalizeSettings(settings map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]string)
+	flattenSettings("", settings, flat)
+	normalized := make(map[string]interface{}, len(flat))
+	for key, value := range flat {
+		if !strings.HasPrefix(key, "index.") {
+			key = "index." + key
+		}
+		normalized[key] = value
+	}
+	return normalized
+}
+
+// withoutEmpty returns a copy of an object without its empty list and object values
+func withoutEmpty(object map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		switch v := value.(type) {
+		case []interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		case map[string]interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// flattenSettings adds the settings to result by dotted name, with their values as strings
+func flattenSettings(prefix string, settings map[string]interface{}, result map[string]string) {
+	for key, value := range settings {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenSettings(name, v, result)
+		case string:
+			result[name] = v
+		case float64:
+			result[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case []interface{}:
+			encoded, _ := json.Marshal(stringList(v))
+			result[name] = string(encoded)
+		default:
+			result[name] = fmt.Sprintf("%v", v)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// tenantListings are APIs that take an index expression after their path, by that path. Those
+// that list every index when it is left out are narrowed to the tenant's indices.
+var tenantListings = map[string]bool{
+	"_cat/indices":    true,
+	"_cat/shards":     true,
+	"_cat/segments":   true,
+	"_cat/count":      true,
+	"_cat/recovery":   true,
+	"_cat/aliases":    true,
+	"_data_stream":    true,
+	"_resolve/index":  false,
+	"_cluster/health": false,
+}
+
+// tenantAllIndexAPIs are APIs that take an index expression before their path and act on every
+// index when it is left out. They are narrowed to the tenant's indices.
+var tenantAllIndexAPIs = map[string]bool{
+	"_search": true, "_count": true, "_stats": true, "_mapping": true, "_settings": true,
+	"_refresh": true, "_flush": true, "_forcemerge": true, "_cache": true, "_segments": true,
+	"_recovery": true, "_alias": true, "_field_caps": true, "_shard_stores": true, "_validate": true,
+}
+
+// tenantTransport keeps requests within the indices of a tenant, those whose names start with the
+// tenant prefix, on clusters shared by several teams. Requests naming other indices, data streams
+// or aliases are refused with a 403 response before they are sent, and requests that would act on
+// every index are narrowed to the tenant's. It guards against mistakes and is not access
+// control: APIs that name indices only in their body, other than alias updates and snapshot
+// restores, are not checked. Use roles to keep tenants apart.
+type tenantTransport struct {
+	next   http.RoundTripper
+	prefix string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tenantTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	first := segments[0]
+
+	// An index expression first, or every index when the API is called without one
+	if first != "" && (!strings.HasPrefix(first, "_") || first == "_all") {
+		if name, ok := t.outside(first); !ok {
+			return tenantRefusal(req, name, t.prefix), nil
+		}
+		// Aliases added to the tenant's indices must be the tenant's too
+		if len(segments) > 2 && (segments[1] == "_alias" || segments[1] == "_aliases") {
+			if name, ok := t.outside(segments[2]); !ok {
+				return tenantRefusal(req, name, t.prefix), nil
+			}
+		}
+		return t.next.RoundTrip(req)
+	}
+	if tenantAllIndexAPIs[first] {
+		return t.next.RoundTrip(t.withPath(req, "/"+t.prefix+"*"+req.URL.Path))
+	}
+
+	// An index expression after the path of the API
+	for api, narrow := range tenantListings {
+		parts := strings.Count(api, "/") + 1
+		if len(segments) < parts || strings.Join(segments[:parts], "/") != api {
+			continue
+		}
+		if len(segments) > parts && !strings.HasPrefix(segments[parts], "_") {
+			if name, ok := t.outside(segments[parts]); !ok {
+				return tenantRefusal(req, name, t.prefix), nil
+			}
+		} else if len(segments) == parts && narrow {
+			return t.next.RoundTrip(t.withPath(req, "/"+api+"/"+t.prefix+"*"))
+		}
+		return t.next.RoundTrip(req)
+	}
+
+	// Alias updates and snapshot restores name their indices in the body
+	if (first == "_aliases" && req.Method != http.MethodGet) || (first == "_snapshot" && segments[len(segments)-1] == "_restore") {
+		names, err := tenantBodyNames(req, first == "_aliases")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if bad, ok := t.outside(name); !ok {
+				return tenantRefusal(req, bad, t.prefix), nil
+			}
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// outside checks every name of an index expression against the tenant prefix, returning the first
+// name outside it and false if there is one. Exclusions cannot widen an expression, so they pass.
+func (t *tenantTransport) outside(expression string) (string, bool) {
+	for _, name := range strings.Split(expression, ",") {
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		// Date math names, such as <logs-{now/d}>, are checked by their static part
+		if !strings.HasPrefix(strings.TrimPrefix(name, "<"), t.prefix) {
+			return name, false
+		}
+	}
+	return "", true
+}
+
+// withPath returns a copy of the request with a new path
+func (t *tenantTransport) withPath(req *http.Request, path string) *http.Request {
+	narrowed := req.Clone(req.Context())
+	narrowed.URL.Path = path
+	narrowed.URL.RawPath = ""
+	return narrowed
+}
+
+// tenantBodyNames returns the index and alias names of an alias update, or the indices and the
+// rename replacement of a snapshot restore. The body is put back so the request can be sent.
+// A restore of every index returns "*", which is outside any tenant.
+func tenantBodyNames(req *http.Request, aliases bool) ([]string, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+
+	// Bodies are compressed when compress_requests is set
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+		if data, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+
+	var body map[string]interface{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			return nil, fmt.Errorf("error parsing request body: %w", err)
+		}
+	}
+
+	var names []string
+	if aliases {
+		actions, _ := body["actions"].([]interface{})
+		for _, action := range actions {
+			action, _ := action.(map[string]interface{})
+			for _, detail := range action {
+				detail, _ := detail.(map[string]interface{})
+				for _, key := range []string{"index", "indices", "alias", "aliases"} {
+					names = append(names, stringOrList(detail[key])...)
+				}
+			}
+		}
+		return names, nil
+	}
+
+	indices := stringOrList(body["indices"])
+	if len(indices) == 0 {
+		indices = []string{"*"}
+	}
+	if replacement, ok := body["rename_replacement"].(string); ok {
+		// Restored indices are named by the replacement, so only it has to be the tenant's
+		return []string{replacement}, nil
+	}
+	return indices, nil
+}
+
+// stringOrList returns a JSON value that is a string, a comma-separated string or a list of
+// strings as a list
+func stringOrList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Split(v, ",")
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// tenantRefusal returns the 403 response for a request outside the tenant, in the form of an
+// Elasticsearch error so it is reported like one
+func tenantRefusal(req *http.Request, name, prefix string) *http.Response {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":   "tenant_scope_exception",
+			"reason": fmt.Sprintf("%s is outside the tenant prefix %q, use --cross-tenant to allow it", name, prefix),
+		},
+		"status": http.StatusForbidden,
+	})
+	return &http.Response{
+		Status:        "403 Forbidden",
+		StatusCode:    http.StatusForbidden,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}, "X-Elastic-Product": {"Elasticsearch"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
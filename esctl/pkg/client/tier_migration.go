@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// tierPreferenceSetting is the index setting that routes an index to a data tier
+	tierPreferenceSetting = "index.routing.allocation.include._tier_preference"
+	// tierSettingsBatchSize is the number of indices named in each settings update
+	tierSettingsBatchSize = 100
+)
+
+// tierPreferences are the tier preference values used for each tier, matching the ones ILM sets
+// when it migrates an index, so an index falls back to a warmer tier if its own has no nodes
+var tierPreferences = map[string]string{
+	"hot":     "data_hot",
+	"warm":    "data_warm,data_hot",
+	"cold":    "data_cold,data_warm,data_hot",
+	"frozen":  "data_frozen",
+	"content": "data_content",
+}
+
+// DataTiers are the tiers an index can be moved to
+var DataTiers = []string{"content", "hot", "warm", "cold", "frozen"}
+
+// TierMoveIndex is an index selected for a move to another data tier
+type TierMoveIndex struct {
+	Name              string
+	CurrentPreference string
+	StoreBytes        int64 // total store size of the index, replicas included
+}
+
+// TierMoveProgress is the state of the shards of the moved indices while they relocate
+type TierMoveProgress struct {
+	Shards     int   // started shard copies of the indices
+	OnTier     int   // started shard copies on nodes of the target tier
+	Moving     int   // shard copies relocating or initializing
+	Unassigned int   // shard copies that cannot be allocated, e.g. replicas on a single node tier
+	MovedBytes int64 // bytes copied by recoveries that started since the move
+}
+
+// Done reports whether every started shard copy is on a node of the target tier and none are
+// still moving. Unassigned copies do not hold up a move.
+func (p TierMoveProgress) Done() bool {
+	return p.Moving == 0 && p.OnTier == p.Shards
+}
+
+// TierPreference returns the tier preference value for a tier
+func TierPreference(tier string) (string, error) {
+	preference, ok := tierPreferences[tier]
+	if !ok {
+		return "", fmt.Errorf("unknown tier %q, expected one of: %s", tier, strings.Join(DataTiers, ", "))
+	}
+	return preference, nil
+}
+
+// GetTierMoveIndices returns the indices matching a pattern whose tier preference differs from
+// the one for the tier, sorted by name
+func (c *Client) GetTierMoveIndices(pattern, tier string) ([]TierMoveIndex, error) {
+	preference, err := TierPreference(tier)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex(pattern),
+		c.es.Indices.GetSettings.WithName(tierPreferenceSetting),
+		c.es.Indices.GetSettings.WithFlatSettings(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Settings map[string]string `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	sizes, err := c.getIndexStoreSizes(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]TierMoveIndex, 0, len(response))
+	for name, index := range response {
+		current := index.Settings[tierPreferenceSetting]
+		if current == preference {
+			continue
+		}
+		indices = append(indices, TierMoveIndex{
+			Name:              name,
+			CurrentPreference: current,
+			StoreBytes:        sizes[name].bytes,
+		})
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].Name < indices[j].Name
+	})
+
+	return indices, nil
+}
+
+// SetTierPreference sets the tier preference of the indices to the one for the tier. Indices are
+// updated in batches, so long lists do not exceed the URL length limit.
+func (c *Client) SetTierPreference(indices []string, tier string) error {
+	preference, err := TierPreference(tier)
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]interface{}{
+		tierPreferenceSetting: preference,
+	}
+	for start := 0; start < len(indices); start += tierSettingsBatchSize {
+		end := start + tierSettingsBatchSize
+		if end > len(indices) {
+			end = len(indices)
+		}
+		if err := c.UpdateIndexSettings(strings.Join(indices[start:end], ","), settings); err != nil {
+			return fmt.Errorf("error setting tier preference on indices %d-%d of %d: %w", start+1, end, len(indices), err)
+		}
+	}
+
+	return nil
+}
+
+// GetTierMoveProgress returns how far the shards of the indices have moved to nodes of the tier.
+// Nodes with the generic data role belong to every tier. Only recoveries started at or after
+// since are counted in the bytes moved.
+func (c *Client) GetTierMoveProgress(indices []string, tier string, since time.Time) (*TierMoveProgress, error) {
+	nodes, err := c.getClusterNodes()
+	if err != nil {
+		return nil, err
+	}
+	tierNodes := make(map[string]bool)
+	for _, node := range nodes {
+		for _, role := range node.Roles {
+			if role == "data" || role == "data_"+tier {
+				tierNodes[node.Name] = true
+			}
+		}
+	}
+	if len(tierNodes) == 0 {
+		return nil, fmt.Errorf("no nodes have the data_%s role, the indices would stay on their current tier", tier)
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	progress := &TierMoveProgress{}
+	for start := 0; start < len(indices); start += tierSettingsBatchSize {
+		end := start + tierSettingsBatchSize
+		if end > len(indices) {
+			end = len(indices)
+		}
+		batch := strings.Join(indices[start:end], ",")
+
+		if err := c.addTierShardProgress(ctx, batch, tierNodes, progress); err != nil {
+			return nil, err
+		}
+		moved, err := c.getRecoveredBytes(ctx, batch, since)
+		if err != nil {
+			return nil, err
+		}
+		progress.MovedBytes += moved
+	}
+
+	return progress, nil
+}
+
+// addTierShardProgress counts the shard copies of the indices by state and location
+func (c *Client) addTierShardProgress(ctx context.Context, indices string, tierNodes map[string]bool, progress *TierMoveProgress) error {
+	// Execute request
+	res, err := c.es.Cat.Shards(
+		c.es.Cat.Shards.WithContext(ctx),
+		c.es.Cat.Shards.WithIndex(indices),
+		c.es.Cat.Shards.WithFormat("json"),
+		c.es.Cat.Shards.WithH("index,shard,prirep,state,node"),
+	)
+	if err != nil {
+		return fmt.Errorf("error getting shards: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return newResponseError(res)
+	}
+
+	// Parse response
+	var shards []ShardInfo
+	if err := json.NewDecoder(res.Body).Decode(&shards); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	for _, shard := range shards {
+		switch shard.State {
+		case "STARTED":
+			progress.Shards++
+			if tierNodes[shard.Node] {
+				progress.OnTier++
+			}
+		case "RELOCATING", "INITIALIZING":
+			progress.Moving++
+		case "UNASSIGNED":
+			progress.Unassigned++
+		}
+	}
+
+	return nil
+}
+
+// getRecoveredBytes returns the bytes copied by peer recoveries of the indices that started at or
+// after since. A relocation is a peer recovery onto the new node.
+func (c *Client) getRecoveredBytes(ctx context.Context, indices string, since time.Time) (int64, error) {
+	// Execute request
+	res, err := c.es.Indices.Recovery(
+		c.es.Indices.Recovery.WithContext(ctx),
+		c.es.Indices.Recovery.WithIndex(indices),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error getting recoveries: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, newResponseError(res)
+	}
+
+	// Parse response
+	var response map[string]struct {
+		Shards []struct {
+			Type              string `json:"type"`
+			StartTimeInMillis int64  `json:"start_time_in_millis"`
+			Index             struct {
+				Size struct {
+					RecoveredInBytes int64 `json:"recovered_in_bytes"`
+				} `json:"size"`
+			} `json:"index"`
+		} `json:"shards"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var moved int64
+	for _, index := range response {
+		for _, shard := range index.Shards {
+			if shard.Type != "PEER" || shard.StartTimeInMillis < since.UnixMilli() {
+				continue
+			}
+			moved += shard.Index.Size.RecoveredInBytes
+		}
+	}
+
+	return moved, nil
+}
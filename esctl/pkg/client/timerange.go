@@ -0,0 +1,164 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeUnits are the units ParseTimeValue accepts, those of Elasticsearch time values
+var timeUnits = map[string]time.Duration{
+	"nanos":  time.Nanosecond,
+	"micros": time.Microsecond,
+	"ms":     time.Millisecond,
+	"s":      time.Second,
+	"m":      time.Minute,
+	"h":      time.Hour,
+	"d":      24 * time.Hour,
+}
+
+// absoluteTimeLayouts are the layouts ParseTime accepts for absolute times, tried in order. Times
+// without a zone are local.
+var absoluteTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// TimeRange is a period between two times, as given with --last or --from and --to
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParseTimeValue converts an Elasticsearch style time value (e.g. "30d", "12h", "500ms") to a
+// duration. Values may combine units, largest first or not, as in "1h30m" or "1d12h".
+func ParseTimeValue(value string) (time.Duration, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s == "" {
+		return 0, fmt.Errorf("invalid time value: %s", value)
+	}
+
+	var total time.Duration
+	for s != "" {
+		// A number followed by its unit
+		end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if end <= 0 {
+			return 0, fmt.Errorf("invalid time value: %s", value)
+		}
+		number, err := strconv.ParseFloat(s[:end], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time value: %s", value)
+		}
+		s = s[end:]
+
+		unitEnd := strings.IndexFunc(s, func(r rune) bool { return r < 'a' || r > 'z' })
+		if unitEnd < 0 {
+			unitEnd = len(s)
+		}
+		multiplier, ok := timeUnits[s[:unitEnd]]
+		if !ok {
+			return 0, fmt.Errorf("invalid time value: %s", value)
+		}
+		s = s[unitEnd:]
+
+		total += time.Duration(number * float64(multiplier))
+	}
+
+	return total, nil
+}
+
+// ParseTime converts a time given on the command line to a time, relative to now:
+//   - now, or date math on it such as now-7d or now-1h30m
+//   - a time value, meaning that long ago, such as 7d
+//   - an absolute time such as 2024-05-01T00:00, 2024-05-01 or 2024-05-01T00:00:00Z
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	s := strings.TrimSpace(value)
+
+	if rest, ok := strings.CutPrefix(strings.ToLower(s), "now"); ok {
+		if rest == "" {
+			return now, nil
+		}
+		sign := rest[0]
+		if sign != '-' && sign != '+' {
+			return time.Time{}, fmt.Errorf("invalid time: %s", value)
+		}
+		d, err := ParseTimeValue(rest[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time: %s", value)
+		}
+		if sign == '-' {
+			d = -d
+		}
+		return now.Add(d), nil
+	}
+
+	if d, err := ParseTimeValue(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range absoluteTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: %s (use e.g. 7d, now-1h30m or 2024-05-01T00:00)", value)
+}
+
+// ParseTimeRange returns the period given by the --last, --from and --to flags of a command. --from
+// takes precedence over --last; --to defaults to now.
+func ParseTimeRange(last, from, to string, now time.Time) (TimeRange, error) {
+	r := TimeRange{To: now}
+
+	switch {
+	case from != "":
+		t, err := ParseTime(from, now)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("invalid --from: %w", err)
+		}
+		r.From = t
+	case last != "":
+		d, err := ParseTimeValue(last)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("invalid --last: %w", err)
+		}
+		r.From = now.Add(-d)
+	default:
+		return TimeRange{}, fmt.Errorf("one of --last or --from is required")
+	}
+
+	if to != "" {
+		t, err := ParseTime(to, now)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("invalid --to: %w", err)
+		}
+		r.To = t
+	}
+
+	if !r.From.Before(r.To) {
+		return TimeRange{}, fmt.Errorf("start of the time range %s is not before its end %s", r.From.Format(time.RFC3339), r.To.Format(time.RFC3339))
+	}
+	return r, nil
+}
+
+// Query returns a range query matching documents whose field falls in the period
+func (r TimeRange) Query(field string) map[string]interface{} {
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			field: map[string]interface{}{
+				"gte": r.From.UTC().Format(time.RFC3339),
+				"lte": r.To.UTC().Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+// String returns the period as "from to to", in RFC 3339
+func (r TimeRange) String() string {
+	return r.From.Format(time.RFC3339) + " to " + r.To.Format(time.RFC3339)
+}
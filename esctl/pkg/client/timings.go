@@ -0,0 +1,143 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+// APICall is a request made by the command, as recorded with --timings
+type APICall struct {
+	Method   string
+	URL      string // scheme, host and path, without the query
+	Status   int    // 0 if no response was received
+	Duration time.Duration
+	Sent     int64 // bytes of the request body, after compression
+	Received int64 // bytes of the response body read by the command
+	Err      error
+}
+
+var (
+	// timedCalls holds the requests of every client of the process, in the order they were made
+	timedCalls   []*APICall
+	timedCallsMu sync.Mutex
+	timingsOnce  sync.Once
+)
+
+// timingTransport records every request in timedCalls, and prints them all when the command ends,
+// or after each run in watch mode.
+// It wraps the transport below retries, so each attempt of a retried request is a call of its own,
+// and below the response cache, so responses served from the cache are not calls.
+type timingTransport struct {
+	next http.RoundTripper
+}
+
+// newTimingTransport returns a timing transport, arranging for the summary to be printed once
+// the command has run, whether it succeeded or not, and after each run in watch mode
+func newTimingTransport(next http.RoundTripper) *timingTransport {
+	timingsOnce.Do(func() {
+		cobra.OnFinalize(func() { PrintTimings(os.Stderr) })
+		format.OnWatchRun(func() { flushTimings(os.Stderr) })
+	})
+	return &timingTransport{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := &APICall{
+		Method: req.Method,
+		URL:    fmt.Sprintf("%s://%s%s", req.URL.Scheme, req.URL.Host, req.URL.Path),
+	}
+	if req.ContentLength > 0 {
+		call.Sent = req.ContentLength
+	}
+
+	timedCallsMu.Lock()
+	timedCalls = append(timedCalls, call)
+	timedCallsMu.Unlock()
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	timedCallsMu.Lock()
+	defer timedCallsMu.Unlock()
+	call.Duration = time.Since(start)
+	if err != nil {
+		call.Err = err
+		return resp, err
+	}
+	call.Status = resp.StatusCode
+
+	// The call lasts until its body is read, large responses take most of their time there
+	resp.Body = &timedBody{ReadCloser: resp.Body, call: call, start: start}
+	return resp, nil
+}
+
+// timedBody counts the bytes read from a response body and the time until it is closed
+type timedBody struct {
+	io.ReadCloser
+	call  *APICall
+	start time.Time
+}
+
+// Read implements io.Reader
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	timedCallsMu.Lock()
+	b.call.Received += int64(n)
+	b.call.Duration = time.Since(b.start)
+	timedCallsMu.Unlock()
+	return n, err
+}
+
+// PrintTimings writes each API call made so far, with its duration and payload sizes, followed by
+// the totals. Nothing is written if no call was made.
+func PrintTimings(w io.Writer) {
+	timedCallsMu.Lock()
+	defer timedCallsMu.Unlock()
+	writeTimings(w)
+}
+
+// flushTimings writes the API calls made so far, like PrintTimings, and forgets them, so each run
+// in watch mode reports only its own calls
+func flushTimings(w io.Writer) {
+	timedCallsMu.Lock()
+	defer timedCallsMu.Unlock()
+	writeTimings(w)
+	timedCalls = nil
+}
+
+// writeTimings writes the recorded API calls and their totals; timedCallsMu must be held
+func writeTimings(w io.Writer) {
+	if len(timedCalls) == 0 {
+		return
+	}
+
+	var total time.Duration
+	var sent, received int64
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tSTATUS\tDURATION\tSENT\tRECEIVED\tURL")
+	for _, call := range timedCalls {
+		status := fmt.Sprintf("%d", call.Status)
+		if call.Err != nil {
+			status = "failed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", call.Method, status, call.Duration.Round(time.Millisecond),
+			formatCatBytes(float64(call.Sent)), formatCatBytes(float64(call.Received)), call.URL)
+		total += call.Duration
+		sent += call.Sent
+		received += call.Received
+	}
+	tw.Flush()
+
+	// Calls made in parallel overlap, so the total can exceed the run time of the command
+	fmt.Fprintf(w, "%d API calls, %s in total, %s sent, %s received\n", len(timedCalls),
+		total.Round(time.Millisecond), formatCatBytes(float64(sent)), formatCatBytes(float64(received)))
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// IndexTraffic is the indexing and search activity of an index over a sampling interval
+type IndexTraffic struct {
+	Index      string
+	Indexed    int64   // documents indexed into primaries during the interval
+	Searches   int64   // search queries run on any shard copy during the interval
+	IndexRate  float64 // documents per second
+	SearchRate float64 // queries per second
+}
+
+// indexCounters are the cumulative indexing and search counters of an index
+type indexCounters struct {
+	indexed  int64
+	searches int64
+}
+
+// GetIndexTraffic samples the index stats of the indices matching pattern, waits for interval and
+// samples them again, returning the indices that were written to or searched in between, busiest
+// first. Indices created in between count from zero; deleted ones are left out.
+func (c *Client) GetIndexTraffic(pattern string, interval time.Duration) ([]IndexTraffic, error) {
+	before, err := c.getIndexCounters(pattern)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	after, err := c.getIndexCounters(pattern)
+	if err != nil {
+		return nil, err
+	}
+	seconds := time.Since(start).Seconds()
+
+	var traffic []IndexTraffic
+	for index, counters := range after {
+		previous := before[index]
+		// Counters drop when shard copies move, which would show as negative traffic
+		indexed := max(counters.indexed-previous.indexed, 0)
+		searches := max(counters.searches-previous.searches, 0)
+		if indexed == 0 && searches == 0 {
+			continue
+		}
+		traffic = append(traffic, IndexTraffic{
+			Index:      index,
+			Indexed:    indexed,
+			Searches:   searches,
+			IndexRate:  float64(indexed) / seconds,
+			SearchRate: float64(searches) / seconds,
+		})
+	}
+
+	sort.Slice(traffic, func(i, j int) bool {
+		a, b := traffic[i].IndexRate+traffic[i].SearchRate, traffic[j].IndexRate+traffic[j].SearchRate
+		if a != b {
+			return a > b
+		}
+		return traffic[i].Index < traffic[j].Index
+	})
+
+	return traffic, nil
+}
+
+// getIndexCounters returns the primary indexing and total search query counters of each index
+func (c *Client) getIndexCounters(pattern string) (map[string]indexCounters, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Execute request
+	res, err := c.es.Indices.Stats(
+		c.es.Indices.Stats.WithContext(ctx),
+		c.es.Indices.Stats.WithIndex(pattern),
+		c.es.Indices.Stats.WithMetric("indexing", "search"),
+		c.es.Indices.Stats.WithFilterPath(
+			"indices.*.primaries.indexing.index_total",
+			"indices.*.total.search.query_total",
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting index stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, newResponseError(res)
+	}
+
+	// Parse response
+	var response struct {
+		Indices map[string]struct {
+			Primaries struct {
+				Indexing struct {
+					IndexTotal int64 `json:"index_total"`
+				} `json:"indexing"`
+			} `json:"primaries"`
+			Total struct {
+				Search struct {
+					QueryTotal int64 `json:"query_total"`
+				} `json:"search"`
+			} `json:"total"`
+		} `json:"indices"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	counters := make(map[string]indexCounters, len(response.Indices))
+	for name, index := range response.Indices {
+		counters[name] = indexCounters{
+			indexed:  index.Primaries.Indexing.IndexTotal,
+			searches: index.Total.Search.QueryTotal,
+		}
+	}
+	return counters, nil
+}
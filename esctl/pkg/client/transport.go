@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/config"
+)
+
+// newHTTPTransport creates the HTTP transport shared by the Elasticsearch and Kibana clients.
+// It starts from the net/http defaults and applies the TLS, compression and connection pool settings.
+func newHTTPTransport(tc config.TransportConfig, caCertPath string, insecure bool) (*http.Transport, error) {
+	tlsConfig, err := tlsConfigFor(caCertPath, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	// Go asks for gzip and decompresses transparently unless compression is disabled
+	transport.DisableCompression = tc.DisableResponseCompression
+	transport.DisableKeepAlives = tc.DisableKeepAlives
+
+	if tc.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+		if transport.MaxIdleConns < tc.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = tc.MaxIdleConnsPerHost
+		}
+	}
+
+	if tc.IdleConnTimeout != "" {
+		timeout, err := time.ParseDuration(tc.IdleConnTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid idle_conn_timeout %q: %w", tc.IdleConnTimeout, err)
+		}
+		transport.IdleConnTimeout = timeout
+	}
+
+	return transport, nil
+}
+
+// gzipRequestTransport compresses request bodies before passing them to the wrapped transport.
+// The Elasticsearch client does this itself, so it is only used for Kibana.
+type gzipRequestTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *gzipRequestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("compressing request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compressing request body: %w", err)
+	}
+
+	// RoundTrippers must not modify the original request
+	compressed := req.Clone(req.Context())
+	compressed.Header.Set("Content-Encoding", "gzip")
+	compressed.ContentLength = int64(buf.Len())
+	data := buf.Bytes()
+	compressed.Body = ioutil.NopCloser(bytes.NewReader(data))
+	compressed.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return t.next.RoundTrip(compressed)
+}
+
+const (
+	// defaultRetryAfter is the wait before retrying a 429 response without a Retry-After header
+	defaultRetryAfter = time.Second
+	// maxRetryAfter caps the wait asked for by a Retry-After header
+	maxRetryAfter = 30 * time.Second
+)
+
+// retryTransport retries requests rejected with 429 Too Many Requests, waiting as long as the
+// Retry-After header asks, so batch operations slow down under backpressure instead of failing
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body must be re-readable to send the request again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		// RoundTrippers must not modify the original request
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		wait := retryAfter(resp.Header.Get("Retry-After"), attempt)
+
+		// Give up if the request would time out while waiting, and report the 429 instead
+		if deadline, ok := req.Context().Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying, from a Retry-After header given in seconds
+// or as an HTTP date, or doubling from defaultRetryAfter when there is none
+func retryAfter(header string, attempt int) time.Duration {
+	wait := defaultRetryAfter << uint(attempt)
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = time.Until(date)
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait
+}
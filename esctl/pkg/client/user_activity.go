@@ -0,0 +1,122 @@
+package client
+
+import "time"
+
+// DefaultAuditIndexPattern matches the security audit log as shipped by the Elasticsearch
+// integration of Elastic Agent and by the Filebeat elasticsearch module
+const DefaultAuditIndexPattern = "logs-elasticsearch.audit-*,filebeat-*"
+
+// Fields of the audit events as the Elasticsearch integration and Filebeat module map them
+const (
+	auditUserField   = "user.name"
+	auditEventField  = "event.action"
+	auditActionField = "elasticsearch.audit.action"
+)
+
+// UserActivity is the audit events of a single user over a period, by type of action
+type UserActivity struct {
+	User     string
+	Events   int64 // every audit event of the user
+	Searches int64 // granted read actions
+	Writes   int64 // granted write actions
+	Admin    int64 // granted index and cluster administration actions
+	Denied   int64 // denied actions and failed authentications
+	LastSeen time.Time
+}
+
+// auditCategories are the filters counting the audit events of a user by type of action
+var auditCategories = map[string]interface{}{
+	"searches": grantedAction("indices:data/read/"),
+	"writes":   grantedAction("indices:data/write/"),
+	"admin":    grantedAction("indices:admin/", "cluster:admin/"),
+	"denied": map[string]interface{}{
+		"terms": map[string]interface{}{
+			auditEventField: []string{"access_denied", "authentication_failed", "run_as_denied"},
+		},
+	},
+}
+
+// grantedAction returns a query for granted actions whose name starts with one of the prefixes
+func grantedAction(prefixes ...string) map[string]interface{} {
+	should := make([]interface{}, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		should = append(should, map[string]interface{}{
+			"prefix": map[string]interface{}{auditActionField: prefix},
+		})
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{auditEventField: "access_granted"}},
+				map[string]interface{}{"bool": map[string]interface{}{"should": should, "minimum_should_match": 1}},
+			},
+		},
+	}
+}
+
+// GetUserActivity aggregates the security audit events in the indices matching a pattern over a
+// period per user, for the users with the most events. It returns the activity and the
+// number of audit events found, which is zero if audit logging is not enabled or not shipped to
+// the cluster.
+func (c *Client) GetUserActivity(pattern string, period TimeRange, size int) ([]UserActivity, int64, error) {
+	query := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{"event.dataset": "elasticsearch.audit"}},
+				period.Query("@timestamp"),
+			},
+		},
+	}
+
+	aggregation := map[string]interface{}{
+		"terms": map[string]interface{}{
+			"field": auditUserField,
+			"size":  size,
+		},
+		"aggs": map[string]interface{}{
+			"categories": map[string]interface{}{
+				"filters": map[string]interface{}{
+					"filters": auditCategories,
+				},
+			},
+			"last_seen": map[string]interface{}{
+				"max": map[string]interface{}{"field": "@timestamp"},
+			},
+		},
+	}
+
+	var agg struct {
+		Buckets []struct {
+			Key        string `json:"key"`
+			DocCount   int64  `json:"doc_count"`
+			Categories struct {
+				Buckets map[string]struct {
+					DocCount int64 `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"categories"`
+			LastSeen struct {
+				Value float64 `json:"value"`
+			} `json:"last_seen"`
+		} `json:"buckets"`
+	}
+	total, err := c.runAggregation(pattern, query, aggregation, &agg)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	activity := make([]UserActivity, 0, len(agg.Buckets))
+	for _, b := range agg.Buckets {
+		categories := b.Categories.Buckets
+		activity = append(activity, UserActivity{
+			User:     b.Key,
+			Events:   b.DocCount,
+			Searches: categories["searches"].DocCount,
+			Writes:   categories["writes"].DocCount,
+			Admin:    categories["admin"].DocCount,
+			Denied:   categories["denied"].DocCount,
+			LastSeen: time.UnixMilli(int64(b.LastSeen.Value)),
+		})
+	}
+
+	return activity, total, nil
+}
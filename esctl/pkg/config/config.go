@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/matthew-hollick/elasticsearch-cli/pkg/format"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -21,7 +22,15 @@ const (
 type Config struct {
 	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch" mapstructure:"elasticsearch"`
 	Kibana        KibanaConfig        `yaml:"kibana" mapstructure:"kibana"`
+	Fleet         FleetConfig         `yaml:"fleet" mapstructure:"fleet"`
 	Output        OutputConfig        `yaml:"output" mapstructure:"output"`
+	Cache         CacheConfig         `yaml:"cache" mapstructure:"cache"`
+	Naming        NamingConfig        `yaml:"naming" mapstructure:"naming"`
+	Repository    RepositoryConfig    `yaml:"repository" mapstructure:"repository"`
+	Diagnostics   DiagnosticsConfig   `yaml:"diagnostics" mapstructure:"diagnostics"`
+
+	Contexts       map[string]ContextConfig `yaml:"contexts" mapstructure:"contexts"`               // Named clusters, selected with --context
+	CurrentContext string                   `yaml:"current_context" mapstructure:"current_context"` // Context used when --context is not given
 }
 
 // ElasticsearchConfig holds Elasticsearch specific configuration
@@ -32,6 +41,12 @@ type ElasticsearchConfig struct {
 	CACert       string   `yaml:"ca_cert" mapstructure:"ca_cert"`
 	Insecure     bool     `yaml:"insecure" mapstructure:"insecure"`
 	DisableRetry bool     `yaml:"disable_retry" mapstructure:"disable_retry"`
+	Verbose      bool     `yaml:"verbose" mapstructure:"verbose"`             // print the address that served each request
+	SSHTunnel    string   `yaml:"ssh_tunnel" mapstructure:"ssh_tunnel"`       // ssh://[user@]host[:port] jump host to connect through
+	TenantPrefix string   `yaml:"tenant_prefix" mapstructure:"tenant_prefix"` // keep index requests to names with this prefix
+	CrossTenant  bool     `yaml:"cross_tenant" mapstructure:"cross_tenant"`   // allow requests outside tenant_prefix, set by --cross-tenant
+
+	Transport TransportConfig `yaml:"transport" mapstructure:"transport"`
 }
 
 // KibanaConfig holds Kibana specific configuration
@@ -41,12 +56,86 @@ type KibanaConfig struct {
 	Password  string   `yaml:"password" mapstructure:"password"`
 	CACert    string   `yaml:"ca_cert" mapstructure:"ca_cert"`
 	Insecure  bool     `yaml:"insecure" mapstructure:"insecure"`
+	Space     string   `yaml:"space" mapstructure:"space"`           // space API requests are scoped to, default space if empty
+	SSHTunnel string   `yaml:"ssh_tunnel" mapstructure:"ssh_tunnel"` // ssh://[user@]host[:port] jump host to connect through
+
+	Transport TransportConfig `yaml:"transport" mapstructure:"transport"`
+}
+
+// TransportConfig holds HTTP compression and connection reuse settings
+type TransportConfig struct {
+	CompressRequests           bool   `yaml:"compress_requests" mapstructure:"compress_requests"`                       // gzip request bodies
+	DisableResponseCompression bool   `yaml:"disable_response_compression" mapstructure:"disable_response_compression"` // do not ask for gzip responses
+	MaxIdleConnsPerHost        int    `yaml:"max_idle_conns_per_host" mapstructure:"max_idle_conns_per_host"`           // default 10
+	IdleConnTimeout            string `yaml:"idle_conn_timeout" mapstructure:"idle_conn_timeout"`                       // default 90s
+	Max429Retries              int    `yaml:"max_429_retries" mapstructure:"max_429_retries"`                           // default 3
+	DisableKeepAlives          bool   `yaml:"disable_keep_alives" mapstructure:"disable_keep_alives"`
+}
+
+// FleetConfig holds Fleet conventions checked by the Fleet commands
+type FleetConfig struct {
+	RequiredTags     []string `yaml:"required_tags" mapstructure:"required_tags"`           // Tags every agent is expected to carry
+	PerPage          int      `yaml:"per_page" mapstructure:"per_page"`                     // Items requested per page from the Fleet list APIs
+	FallbackPolicyID string   `yaml:"fallback_policy_id" mapstructure:"fallback_policy_id"` // Policy agents are moved to when a policy is force deleted
+
+	AllowedNamespaces []string            `yaml:"allowed_namespaces" mapstructure:"allowed_namespaces"` // Namespaces package policies may write into, * wildcards allowed
+	SpaceNamespaces   map[string][]string `yaml:"space_namespaces" mapstructure:"space_namespaces"`     // Allowed namespaces per Kibana space, in place of AllowedNamespaces
+}
+
+// CacheConfig holds the on-disk cache settings for metadata lookups
+type CacheConfig struct {
+	Enabled   bool   `yaml:"enabled" mapstructure:"enabled"`     // Reuse lookups across runs
+	Dir       string `yaml:"dir" mapstructure:"dir"`             // default is the user cache directory
+	TTL       string `yaml:"ttl" mapstructure:"ttl"`             // default 1m
+	Responses bool   `yaml:"responses" mapstructure:"responses"` // Cache node info, template and pipeline responses
+}
+
+// NamingConfig holds the naming policy checked by commands that create indices, aliases and templates
+type NamingConfig struct {
+	PolicyFile string `yaml:"policy_file" mapstructure:"policy_file"` // Rules file, no policy is checked if empty
+	Enforce    bool   `yaml:"enforce" mapstructure:"enforce"`         // Fail instead of warning on violations
+}
+
+// DiagnosticsConfig holds how commands reach the hosts of the nodes to collect JVM diagnostics,
+// such as heap dumps, that the Elasticsearch API cannot provide
+type DiagnosticsConfig struct {
+	Executor  string   `yaml:"executor" mapstructure:"executor"`   // ssh, kubectl or command; captures are disabled if empty
+	SSHUser   string   `yaml:"ssh_user" mapstructure:"ssh_user"`   // ssh: user to log in as, default is the SSH configuration
+	Namespace string   `yaml:"namespace" mapstructure:"namespace"` // kubectl: namespace of the Elasticsearch pods
+	Container string   `yaml:"container" mapstructure:"container"` // kubectl: container running Elasticsearch, default elasticsearch
+	Command   []string `yaml:"command" mapstructure:"command"`     // command: program and arguments, {node}, {host} and {ip} are replaced
+	RunAs     string   `yaml:"run_as" mapstructure:"run_as"`       // user to run jcmd as with sudo, jcmd must run as the user of the JVM
+	Jcmd      string   `yaml:"jcmd" mapstructure:"jcmd"`           // path of jcmd on the nodes, default /usr/share/elasticsearch/jdk/bin/jcmd
+	TempDir   string   `yaml:"temp_dir" mapstructure:"temp_dir"`   // directory on the nodes for capture files, default /tmp
+}
+
+// RepositoryConfig holds named repository client profiles used when registering snapshot repositories
+type RepositoryConfig struct {
+	Clients map[string]RepositoryClientConfig `yaml:"clients" mapstructure:"clients"`
+}
+
+// RepositoryClientConfig describes a client configured on the nodes, such as s3.client.<name>.*,
+// and the repository settings that go with it
+type RepositoryClientConfig struct {
+	Type     string            `yaml:"type" mapstructure:"type"`         // s3, azure or gcs; checked against --type if set
+	Client   string            `yaml:"client" mapstructure:"client"`     // client name on the nodes, default is the profile name
+	Settings map[string]string `yaml:"settings" mapstructure:"settings"` // repository settings, e.g. bucket, base_path
 }
 
 // OutputConfig holds output formatting configuration
 type OutputConfig struct {
 	Format string `yaml:"format" mapstructure:"format"` // plain, json, csv
 	Style  string `yaml:"style" mapstructure:"style"`  // Style for fancy output format
+
+	Redact         bool     `yaml:"redact" mapstructure:"redact"`                   // Replace sensitive values with tokens
+	RedactPatterns []string `yaml:"redact_patterns" mapstructure:"redact_patterns"` // Extra regular expressions to redact
+
+	Truncate    bool `yaml:"truncate" mapstructure:"truncate"`           // Shorten table cells wider than their limit, default true
+	MaxColWidth int  `yaml:"max_col_width" mapstructure:"max_col_width"` // Width limit for every table column, 0 for none
+
+	TimeFormat string `yaml:"time_format" mapstructure:"time_format"` // iso8601, epoch, relative or a strftime pattern
+
+	Timings bool `yaml:"timings" mapstructure:"timings"` // Print each API call made, with its duration and sizes, when the command ends
 }
 
 // Context key for viper instance
@@ -90,6 +179,13 @@ func Load(ctx ...context.Context) (*Config, error) {
 		v.SetDefault("kibana.addresses", []string{"http://localhost:5601"})
 		v.SetDefault("output.format", "fancy")
 		v.SetDefault("output.style", "dark") // Default style for fancy output
+		v.SetDefault("elasticsearch.transport.max_idle_conns_per_host", 10)
+		v.SetDefault("elasticsearch.transport.idle_conn_timeout", "90s")
+		v.SetDefault("kibana.transport.max_idle_conns_per_host", 10)
+		v.SetDefault("kibana.transport.idle_conn_timeout", "90s")
+		v.SetDefault("elasticsearch.transport.max_429_retries", 3)
+		v.SetDefault("kibana.transport.max_429_retries", 3)
+		v.SetDefault("output.truncate", true)
 
 		// Read config file if it exists
 		if err := v.ReadInConfig(); err != nil {
@@ -117,6 +213,7 @@ func Load(ctx ...context.Context) (*Config, error) {
 func (c *Config) Save(path string) error {
 	v := viper.New()
 	v.Set("elasticsearch", c.Elasticsearch)
+	v.Set("kibana", c.Kibana)
 	v.Set("output", c.Output)
 
 	// Create directory if it doesn't exist
@@ -164,57 +261,150 @@ func initializeConfigInternal(cmd *cobra.Command, configFile string,
 	v.SetDefault("elasticsearch.addresses", []string{"http://localhost:9200"})
 	v.SetDefault("kibana.addresses", []string{"http://localhost:5601"})
 	v.SetDefault("output.format", "plain")
+	v.SetDefault("elasticsearch.transport.max_idle_conns_per_host", 10)
+	v.SetDefault("elasticsearch.transport.idle_conn_timeout", "90s")
+	v.SetDefault("kibana.transport.max_idle_conns_per_host", 10)
+	v.SetDefault("kibana.transport.idle_conn_timeout", "90s")
+	v.SetDefault("elasticsearch.transport.max_429_retries", 3)
+	v.SetDefault("kibana.transport.max_429_retries", 3)
+	v.SetDefault("output.truncate", true)
 
 	// Read config file if it exists
 	if err := v.ReadInConfig(); err == nil {
 		fmt.Printf("Using config file: %s\n", v.ConfigFileUsed())
 	}
 
+	// Flag defaults for this command from the config file
+	defaulted, err := applyFlagDefaults(cmd, v)
+	if err != nil {
+		return err
+	}
+	// A flag set from the defaults section overrides the config file like a flag on the command line
+	given := func(name string) bool {
+		return cmd.Flags().Changed(name) || defaulted[name]
+	}
+
 	// Enable environment variable binding
 	v.SetEnvPrefix("ESCTL")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// The selected context replaces the connection settings of the config file
+	if err := applyContext(cmd, v, given); err != nil {
+		return err
+	}
+
 	// Bind flags to viper
 	// Elasticsearch flags
-	if cmd.Flags().Changed("es-addresses") && esAddresses != nil {
+	if given("es-addresses") && esAddresses != nil {
 		v.Set("elasticsearch.addresses", esAddresses)
 	}
-	if cmd.Flags().Changed("es-username") && esUsername != "" {
+	if given("es-username") && esUsername != "" {
 		v.Set("elasticsearch.username", esUsername)
 	}
-	if cmd.Flags().Changed("es-password") && esPassword != "" {
+	if given("es-password") && esPassword != "" {
 		v.Set("elasticsearch.password", esPassword)
 	}
-	if cmd.Flags().Changed("es-ca-cert") && esCaCert != "" {
+	if given("es-ca-cert") && esCaCert != "" {
 		v.Set("elasticsearch.ca_cert", esCaCert)
 	}
-	if cmd.Flags().Changed("es-insecure") {
+	if given("es-insecure") {
 		v.Set("elasticsearch.insecure", esInsecure)
 	}
-	if cmd.Flags().Changed("es-disable-retry") {
+	if given("es-disable-retry") {
 		v.Set("elasticsearch.disable_retry", esDisableRetry)
 	}
 	
 	// Kibana flags
-	if cmd.Flags().Changed("kb-addresses") && kbAddresses != nil {
+	if given("kb-addresses") && kbAddresses != nil {
 		v.Set("kibana.addresses", kbAddresses)
 	}
-	if cmd.Flags().Changed("kb-username") && kbUsername != "" {
+	if given("kb-username") && kbUsername != "" {
 		v.Set("kibana.username", kbUsername)
 	}
-	if cmd.Flags().Changed("kb-password") && kbPassword != "" {
+	if given("kb-password") && kbPassword != "" {
 		v.Set("kibana.password", kbPassword)
 	}
-	if cmd.Flags().Changed("kb-ca-cert") && kbCaCert != "" {
+	if given("kb-ca-cert") && kbCaCert != "" {
 		v.Set("kibana.ca_cert", kbCaCert)
 	}
-	if cmd.Flags().Changed("kb-insecure") {
+	if given("kb-insecure") {
 		v.Set("kibana.insecure", kbInsecure)
 	}
-	if cmd.Flags().Changed("format") {
+	if given("space") {
+		space, _ := cmd.Flags().GetString("space")
+		v.Set("kibana.space", space)
+	}
+	if given("format") {
+		// Read back from the flag, which may have been set from the defaults section
+		outputFormat, _ = cmd.Flags().GetString("format")
 		v.Set("output.format", outputFormat)
 	}
+	if given("style") {
+		style, _ := cmd.Flags().GetString("style")
+		v.Set("output.style", style)
+	}
+	if given("max-429-retries") {
+		retries, _ := cmd.Flags().GetInt("max-429-retries")
+		v.Set("elasticsearch.transport.max_429_retries", retries)
+		v.Set("kibana.transport.max_429_retries", retries)
+	}
+	if given("enforce") {
+		enforce, _ := cmd.Flags().GetBool("enforce")
+		v.Set("naming.enforce", enforce)
+	}
+	if given("local-port-forward") {
+		tunnel, _ := cmd.Flags().GetString("local-port-forward")
+		v.Set("elasticsearch.ssh_tunnel", tunnel)
+		v.Set("kibana.ssh_tunnel", tunnel)
+	}
+	if given("verbose") {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		v.Set("elasticsearch.verbose", verbose)
+	}
+	if given("max-col-width") {
+		width, _ := cmd.Flags().GetInt("max-col-width")
+		v.Set("output.max_col_width", width)
+	}
+	if given("truncate") {
+		truncate, _ := cmd.Flags().GetBool("truncate")
+		v.Set("output.truncate", truncate)
+	}
+	if given("no-truncate") {
+		noTruncate, _ := cmd.Flags().GetBool("no-truncate")
+		v.Set("output.truncate", !noTruncate)
+	}
+	if given("time-format") {
+		timeFormat, _ := cmd.Flags().GetString("time-format")
+		v.Set("output.time_format", timeFormat)
+	}
+	if given("redact") {
+		redact, _ := cmd.Flags().GetBool("redact")
+		v.Set("output.redact", redact)
+	}
+	if given("cross-tenant") {
+		crossTenant, _ := cmd.Flags().GetBool("cross-tenant")
+		v.Set("elasticsearch.cross_tenant", crossTenant)
+	}
+	if given("timings") {
+		timings, _ := cmd.Flags().GetBool("timings")
+		v.Set("output.timings", timings)
+	}
+
+	// Redaction applies to every formatter the command creates
+	if v.GetBool("output.redact") {
+		if err := format.EnableRedaction(v.GetStringSlice("output.redact_patterns")); err != nil {
+			return err
+		}
+	}
+
+	// Truncation applies to every formatter the command creates
+	format.SetTruncation(v.GetBool("output.truncate"), v.GetInt("output.max_col_width"))
+
+	// The time format applies to every formatter the command creates
+	if err := format.SetTimeFormat(v.GetString("output.time_format")); err != nil {
+		return err
+	}
 
 	// Store the viper instance in the context for later use
 	cmd.SetContext(WithViper(cmd.Context(), v))
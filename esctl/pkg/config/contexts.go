@@ -0,0 +1,414 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ContextConfig is a named cluster in the contexts section of the config file:
+//
+//	current_context: prod
+//	contexts:
+//	  prod:
+//	    elasticsearch:
+//	      addresses: ["https://es.prod.example.com:9200"]
+//	    kibana:
+//	      addresses: ["https://kibana.prod.example.com:5601"]
+//
+// Commands with a --context flag connect to the given context, or to current_context when the
+// flag is not passed. Context names are case-insensitive.
+type ContextConfig struct {
+	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch" mapstructure:"elasticsearch"`
+	Kibana        KibanaConfig        `yaml:"kibana" mapstructure:"kibana"`
+}
+
+// ContextSummary describes a context of the config file for listing
+type ContextSummary struct {
+	Name          string
+	Current       bool
+	Elasticsearch []string // Elasticsearch addresses
+	Kibana        []string // Kibana addresses
+}
+
+// connectionSettings are the settings of a section that identify and authenticate against a
+// cluster, with their unset values. A context that has the section replaces all of them, so
+// credentials of one cluster are never sent to another; other settings such as transport are
+// inherited.
+var connectionSettings = map[string]map[string]interface{}{
+	"elasticsearch": {
+		"addresses": []string{}, "username": "", "password": "", "ca_cert": "", "insecure": false, "ssh_tunnel": "",
+	},
+	"kibana": {
+		"addresses": []string{}, "username": "", "password": "", "ca_cert": "", "insecure": false, "ssh_tunnel": "", "space": "",
+	},
+}
+
+// currentContextKey is the top-level setting holding the current context
+const currentContextKey = "current_context"
+
+// applyContext copies the settings of the selected context over the elasticsearch and kibana
+// sections. Only commands with a --context flag use contexts. given reports whether a flag was
+// passed or set from the defaults section.
+func applyContext(cmd *cobra.Command, v *viper.Viper, given func(name string) bool) error {
+	if cmd.Flags().Lookup("context") == nil {
+		return nil
+	}
+	name := v.GetString(currentContextKey)
+	if given("context") {
+		name, _ = cmd.Flags().GetString("context")
+	}
+	if name == "" {
+		return nil
+	}
+
+	// Viper lower-cases map keys read from the config file
+	prefix := "contexts." + strings.ToLower(name) + "."
+	settings := make(map[string]interface{})
+	for _, key := range v.AllKeys() {
+		if strings.HasPrefix(key, prefix) {
+			settings[strings.TrimPrefix(key, prefix)] = v.Get(key)
+		}
+	}
+	if len(settings) == 0 {
+		return fmt.Errorf("context %q is not defined in the contexts section of the config file", name)
+	}
+
+	for section, defaults := range connectionSettings {
+		if !hasSection(settings, section) {
+			continue
+		}
+		for key, value := range defaults {
+			v.Set(section+"."+key, value)
+		}
+	}
+	for key, value := range settings {
+		v.Set(key, value)
+	}
+	return nil
+}
+
+// hasSection reports whether any setting belongs to the section
+func hasSection(settings map[string]interface{}, section string) bool {
+	for key := range settings {
+		if strings.HasPrefix(key, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ForContext returns a copy of the configuration that connects to the named context. Transport
+// settings and the tenant prefix the context leaves unset, verbose and cross-tenant are taken
+// from the elasticsearch and kibana sections.
+func (c *Config) ForContext(name string) (*Config, error) {
+	// Viper lower-cases map keys read from the config file
+	ctxCfg, ok := c.Contexts[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("context %q is not defined in the contexts section of the config file", name)
+	}
+	if len(ctxCfg.Elasticsearch.Addresses) == 0 {
+		return nil, fmt.Errorf("context %q has no Elasticsearch addresses", name)
+	}
+
+	esCfg := ctxCfg.Elasticsearch
+	if esCfg.Transport == (TransportConfig{}) {
+		esCfg.Transport = c.Elasticsearch.Transport
+	}
+	esCfg.Verbose = esCfg.Verbose || c.Elasticsearch.Verbose
+	if esCfg.TenantPrefix == "" {
+		esCfg.TenantPrefix = c.Elasticsearch.TenantPrefix
+	}
+	esCfg.CrossTenant = esCfg.CrossTenant || c.Elasticsearch.CrossTenant
+
+	contextCfg := *c
+	contextCfg.Elasticsearch = esCfg
+	if len(ctxCfg.Kibana.Addresses) > 0 {
+		kbCfg := ctxCfg.Kibana
+		if kbCfg.Transport == (TransportConfig{}) {
+			kbCfg.Transport = c.Kibana.Transport
+		}
+		contextCfg.Kibana = kbCfg
+	}
+	return &contextCfg, nil
+}
+
+// CurrentContext returns the current context of the config file, or "" if none is set
+func CurrentContext(configFile string) (string, error) {
+	v, err := readConfigFile(configFile)
+	if err != nil {
+		return "", err
+	}
+	return v.GetString(currentContextKey), nil
+}
+
+// ListContexts returns the contexts of the config file, sorted by name
+func ListContexts(configFile string) ([]ContextSummary, error) {
+	v, err := readConfigFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+
+	current := strings.ToLower(cfg.CurrentContext)
+	contexts := make([]ContextSummary, 0, len(cfg.Contexts))
+	for name, ctxCfg := range cfg.Contexts {
+		contexts = append(contexts, ContextSummary{
+			Name:          name,
+			Current:       name == current,
+			Elasticsearch: ctxCfg.Elasticsearch.Addresses,
+			Kibana:        ctxCfg.Kibana.Addresses,
+		})
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// SetContext makes name the current context of the config file, or clears the current context if
+// name is empty. Only the current_context line of the file is changed, comments and layout are
+// kept.
+func SetContext(configFile, name string) error {
+	v, err := readConfigFile(configFile)
+	if err != nil {
+		return err
+	}
+	if name != "" && !v.IsSet("contexts."+strings.ToLower(name)) {
+		return fmt.Errorf("context %q is not defined in the contexts section of %s", name, v.ConfigFileUsed())
+	}
+
+	path := v.ConfigFileUsed()
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	line := fmt.Sprintf("%s: %q", currentContextKey, strings.ToLower(name))
+	lines := strings.Split(string(data), "\n")
+	found := false
+	for i, l := range lines {
+		// Only a top-level key, not one nested in a section
+		if strings.HasPrefix(l, currentContextKey+":") {
+			lines[i] = line
+			found = true
+		}
+	}
+	if !found {
+		if len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		lines = append(lines, line, "")
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return nil
+}
+
+// savedConnection is the section of a context written by SaveContext, unset settings are left out
+type savedConnection struct {
+	Addresses []string `yaml:"addresses,omitempty"`
+	Username  string   `yaml:"username,omitempty"`
+	Password  string   `yaml:"password,omitempty"`
+	CACert    string   `yaml:"ca_cert,omitempty"`
+	Insecure  bool     `yaml:"insecure,omitempty"`
+	SSHTunnel string   `yaml:"ssh_tunnel,omitempty"`
+	Space     string   `yaml:"space,omitempty"`
+}
+
+// savedContext is a context as written by SaveContext
+type savedContext struct {
+	Elasticsearch *savedConnection `yaml:"elasticsearch,omitempty"`
+	Kibana        *savedConnection `yaml:"kibana,omitempty"`
+}
+
+// SaveContext adds the named context to the contexts section of the config file, replacing a
+// context of the same name. The file is created in ~/.config/esctl if no config file is found.
+// Comments of the file are kept.
+func SaveContext(configFile, name string, ctxCfg ContextConfig) error {
+	saved := savedContext{}
+	if len(ctxCfg.Elasticsearch.Addresses) > 0 {
+		es := ctxCfg.Elasticsearch
+		saved.Elasticsearch = &savedConnection{
+			Addresses: es.Addresses, Username: es.Username, Password: es.Password,
+			CACert: es.CACert, Insecure: es.Insecure, SSHTunnel: es.SSHTunnel,
+		}
+	}
+	if len(ctxCfg.Kibana.Addresses) > 0 {
+		kb := ctxCfg.Kibana
+		saved.Kibana = &savedConnection{
+			Addresses: kb.Addresses, Username: kb.Username, Password: kb.Password,
+			CACert: kb.CACert, Insecure: kb.Insecure, SSHTunnel: kb.SSHTunnel, Space: kb.Space,
+		}
+	}
+	var value yaml.Node
+	if err := value.Encode(saved); err != nil {
+		return fmt.Errorf("error encoding context: %w", err)
+	}
+
+	return editConfigFile(configFile, true, func(root *yaml.Node) error {
+		contexts := mappingValue(root, "contexts")
+		if contexts == nil || contexts.Kind != yaml.MappingNode {
+			contexts = &yaml.Node{Kind: yaml.MappingNode}
+			setMappingValue(root, "contexts", contexts)
+		}
+		setMappingValue(contexts, strings.ToLower(name), &value)
+		return nil
+	})
+}
+
+// RemoveContext removes the named context from the contexts section of the config file and
+// clears current_context if it is the current context. It returns whether the context was found.
+func RemoveContext(configFile, name string) (bool, error) {
+	name = strings.ToLower(name)
+	found := false
+	err := editConfigFile(configFile, false, func(root *yaml.Node) error {
+		if contexts := mappingValue(root, "contexts"); contexts != nil {
+			found = deleteMappingValue(contexts, name)
+		}
+		if current := mappingValue(root, currentContextKey); current != nil && strings.ToLower(current.Value) == name {
+			deleteMappingValue(root, currentContextKey)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// editConfigFile applies edit to the top-level mapping of the config file and writes it back.
+// With create, a missing file is created, in ~/.config/esctl when no config file is found;
+// without it a missing file is left alone.
+func editConfigFile(configFile string, create bool, edit func(root *yaml.Node) error) error {
+	path := configFile
+	if path == "" {
+		v, err := readConfigFile("")
+		var parseErr viper.ConfigParseError
+		switch {
+		case err == nil:
+			path = v.ConfigFileUsed()
+		case errors.As(err, &parseErr):
+			return err
+		case !create:
+			return nil
+		default:
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to find home directory: %w", err)
+			}
+			path = filepath.Join(home, ".config", "esctl", defaultConfigName+"."+defaultConfigType)
+		}
+	}
+
+	// The file may hold credentials, so a new one is only readable by the user
+	mode := os.FileMode(0600)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	case errors.Is(err, os.ErrNotExist) && !create:
+		return nil
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("error creating config directory: %w", err)
+		}
+	default:
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("error encoding config file: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(buf.String()), mode); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a YAML mapping, or nil if it is not set
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key in a YAML mapping, replacing its value if it is already set
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+// deleteMappingValue removes key from a YAML mapping and reports whether it was set
+func deleteMappingValue(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// readConfigFile reads the given config file, or the first one found in the default locations
+func readConfigFile(configFile string) (*viper.Viper, error) {
+	v := viper.New()
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName(defaultConfigName)
+		v.SetConfigType(defaultConfigType)
+		v.AddConfigPath(".")                   // Current directory
+		v.AddConfigPath("$HOME/.config/esctl") // User config directory
+		v.AddConfigPath("/etc/esctl")          // System config directory
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		var configFileNotFoundError viper.ConfigFileNotFoundError
+		if errors.As(err, &configFileNotFoundError) {
+			return nil, fmt.Errorf("no config file found in ., ~/.config/esctl or /etc/esctl")
+		}
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// applyFlagDefaults sets flags the user did not pass from the defaults section of the config file.
+// The section is keyed by command name, with nested sections for subcommands:
+//
+//	defaults:
+//	  es_indices:
+//	    pattern: "logs-*"
+//	    open:
+//	      concurrency: 8
+//
+// A subcommand inherits the defaults of its parents and may override them. Defaults for flags a
+// command does not have are ignored, so a default can be shared by only some subcommands. Flags
+// given on the command line always win.
+//
+// A default replaces the flag's default value rather than counting as given, so the flag is not
+// marked as changed and commands can still tell what the user typed. Cobra checks required flags
+// after this runs, so a default also satisfies a required flag. It returns the names of the flags
+// that were set.
+func applyFlagDefaults(cmd *cobra.Command, v *viper.Viper) (map[string]bool, error) {
+	defaulted := make(map[string]bool)
+	section := v.GetStringMap("defaults")
+	if len(section) == 0 {
+		return defaulted, nil
+	}
+
+	for name, value := range collectFlagDefaults(section, strings.Fields(cmd.CommandPath())) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		if err := flag.Value.Set(value); err != nil {
+			return nil, fmt.Errorf("invalid default for --%s of %s in config: %w", name, cmd.CommandPath(), err)
+		}
+		flag.DefValue = flag.Value.String()
+		if _, required := flag.Annotations[cobra.BashCompOneRequiredFlag]; required {
+			flag.Annotations[cobra.BashCompOneRequiredFlag] = []string{"false"}
+		}
+		defaulted[name] = true
+	}
+
+	return defaulted, nil
+}
+
+// collectFlagDefaults walks the defaults section along a command path and returns the flag values
+// that apply, with values for subcommands overriding those of their parents
+func collectFlagDefaults(section map[string]interface{}, path []string) map[string]string {
+	values := make(map[string]string)
+
+	level := section
+	for _, name := range path {
+		entry, ok := level[name].(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, value := range entry {
+			// Nested sections belong to subcommands
+			if _, isSection := value.(map[string]interface{}); isSection {
+				continue
+			}
+			values[key] = formatFlagValue(value)
+		}
+		level = entry
+	}
+
+	return values
+}
+
+// formatFlagValue converts a config value to the string form a flag accepts, joining lists with
+// commas as slice flags expect
+func formatFlagValue(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		parts := make([]string, 0, len(list))
+		for _, item := range list {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// favoritesFile is the name of the favorites file in the user config directory
+const favoritesFile = "favorites.json"
+
+// favoritePlaceholder matches a {{name}} placeholder in the arguments of a favorite
+var favoritePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Favorite is a saved command line that can be run again by name
+type Favorite struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`           // program and arguments, which may hold {{name}} placeholders
+	Context string   `json:"context,omitempty"` // context the command connects to, passed as --context
+}
+
+// Placeholders returns the names of the placeholders in the command, in order of first use
+func (f Favorite) Placeholders() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, arg := range f.Command {
+		for _, match := range favoritePlaceholder.FindAllStringSubmatch(arg, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// Expand returns the command with its placeholders replaced by the given values, and --context
+// added when the favorite has a context and the command does not choose one itself. Every
+// placeholder must have a value.
+func (f Favorite) Expand(values map[string]string) ([]string, error) {
+	var missing []string
+	for _, name := range f.Placeholders() {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no value for placeholders: %s", strings.Join(missing, ", "))
+	}
+
+	args := make([]string, len(f.Command))
+	hasContext := false
+	for i, arg := range f.Command {
+		args[i] = favoritePlaceholder.ReplaceAllStringFunc(arg, func(placeholder string) string {
+			return values[favoritePlaceholder.FindStringSubmatch(placeholder)[1]]
+		})
+		if arg == "--context" || strings.HasPrefix(arg, "--context=") {
+			hasContext = true
+		}
+	}
+	if f.Context != "" && !hasContext {
+		args = append(args, "--context", f.Context)
+	}
+	return args, nil
+}
+
+// FavoritesPath returns the path of the favorites file, ~/.config/esctl/favorites.json
+func FavoritesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "esctl", favoritesFile), nil
+}
+
+// LoadFavorites returns the saved favorites sorted by name, or none if the file does not exist
+func LoadFavorites(path string) ([]Favorite, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading favorites: %w", err)
+	}
+
+	var favorites []Favorite
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return nil, fmt.Errorf("error parsing favorites file %s: %w", path, err)
+	}
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].Name < favorites[j].Name })
+	return favorites, nil
+}
+
+// GetFavorite returns the saved favorite with the given name
+func GetFavorite(path, name string) (*Favorite, error) {
+	favorites, err := LoadFavorites(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range favorites {
+		if f.Name == name {
+			return &f, nil
+		}
+	}
+	return nil, fmt.Errorf("no favorite named %q", name)
+}
+
+// SaveFavorite adds a favorite to the file, replacing one of the same name only if replace is set
+func SaveFavorite(path string, favorite Favorite, replace bool) error {
+	if favorite.Name == "" || strings.ContainsAny(favorite.Name, " \t\n") {
+		return fmt.Errorf("invalid favorite name %q", favorite.Name)
+	}
+	if len(favorite.Command) == 0 {
+		return fmt.Errorf("favorite %q has no command", favorite.Name)
+	}
+
+	favorites, err := LoadFavorites(path)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, f := range favorites {
+		if f.Name == favorite.Name {
+			if !replace {
+				return fmt.Errorf("favorite %q already exists, use --force to replace it", favorite.Name)
+			}
+			favorites[i] = favorite
+			found = true
+		}
+	}
+	if !found {
+		favorites = append(favorites, favorite)
+	}
+	return writeFavorites(path, favorites)
+}
+
+// RemoveFavorite deletes the favorite with the given name from the file
+func RemoveFavorite(path, name string) error {
+	favorites, err := LoadFavorites(path)
+	if err != nil {
+		return err
+	}
+
+	kept := favorites[:0]
+	for _, f := range favorites {
+		if f.Name != name {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == len(favorites) {
+		return fmt.Errorf("no favorite named %q", name)
+	}
+	return writeFavorites(path, kept)
+}
+
+// writeFavorites writes the favorites file, creating its directory if needed. The file is only
+// readable by the user as command lines may hold credentials.
+func writeFavorites(path string, favorites []Favorite) error {
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].Name < favorites[j].Name })
+
+	data, err := json.MarshalIndent(favorites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding favorites: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing favorites: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package format
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	flameGraphWidth       = 1200
+	flameGraphFrameHeight = 16
+	flameGraphPadding     = 10
+	flameGraphTitleHeight = 30
+	flameGraphCharWidth   = 7 // approximate width of a character at the font size used
+)
+
+// flameFrame is a node of the call tree built from folded stacks
+type flameFrame struct {
+	name     string
+	count    int
+	children map[string]*flameFrame
+}
+
+// child returns the child frame with the given name, creating it if needed
+func (f *flameFrame) child(name string) *flameFrame {
+	if f.children == nil {
+		f.children = make(map[string]*flameFrame)
+	}
+	c, ok := f.children[name]
+	if !ok {
+		c = &flameFrame{name: name}
+		f.children[name] = c
+	}
+	return c
+}
+
+// sortedChildren returns the children in name order, as flamegraph tools lay them out
+func (f *flameFrame) sortedChildren() []*flameFrame {
+	children := make([]*flameFrame, 0, len(f.children))
+	for _, c := range f.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].name < children[j].name
+	})
+	return children
+}
+
+// depth returns the number of frame levels below and including f
+func (f *flameFrame) depth() int {
+	deepest := 0
+	for _, c := range f.children {
+		if d := c.depth(); d > deepest {
+			deepest = d
+		}
+	}
+	return deepest + 1
+}
+
+// WriteFlameGraph renders folded stacks, keyed by the frames joined with ';' and valued by sample
+// count, as a standalone SVG flame graph. Hovering over a frame shows its name and sample count.
+func WriteFlameGraph(w io.Writer, stacks map[string]int, title string) error {
+	root := &flameFrame{name: "all"}
+	for stack, count := range stacks {
+		root.count += count
+		frame := root
+		for _, name := range strings.Split(stack, ";") {
+			frame = frame.child(name)
+			frame.count += count
+		}
+	}
+
+	levels := root.depth()
+	height := flameGraphTitleHeight + levels*flameGraphFrameHeight + 2*flameGraphPadding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" standalone="no"?>`+"\n")
+	fmt.Fprintf(&b, `<svg version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`+"\n",
+		flameGraphWidth, height, flameGraphWidth, height)
+	fmt.Fprintf(&b, `<rect x="0" y="0" width="100%%" height="100%%" fill="#f8f8f8"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="Verdana" font-size="17" text-anchor="middle">%s</text>`+"\n",
+		flameGraphWidth/2, flameGraphTitleHeight-8, html.EscapeString(title))
+
+	if root.count > 0 {
+		scale := float64(flameGraphWidth-2*flameGraphPadding) / float64(root.count)
+		// The root sits at the bottom, callees stack upwards
+		bottom := height - flameGraphPadding - flameGraphFrameHeight
+		writeFlameFrame(&b, root, root.count, float64(flameGraphPadding), bottom, scale)
+	}
+
+	fmt.Fprintf(&b, "</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFlameFrame writes the rectangle and label of a frame, then its children above it
+func writeFlameFrame(b *strings.Builder, f *flameFrame, total int, x float64, y int, scale float64) {
+	width := float64(f.count) * scale
+	if width < 0.1 {
+		return
+	}
+
+	name := html.EscapeString(f.name)
+	fmt.Fprintf(b, `<g><title>%s (%d samples, %.2f%%)</title>`, name, f.count, float64(f.count)/float64(total)*100)
+	fmt.Fprintf(b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2" ry="2"/>`,
+		x, y, width, flameGraphFrameHeight-1, flameColor(f.name))
+
+	// Label the frame with as much of its name as fits
+	if chars := int(width-6) / flameGraphCharWidth; chars >= 3 {
+		label := f.name
+		if len(label) > chars {
+			label = label[:chars-2] + ".."
+		}
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-family="Verdana" font-size="12">%s</text>`,
+			x+3, y+flameGraphFrameHeight-4, html.EscapeString(label))
+	}
+	fmt.Fprintf(b, "</g>\n")
+
+	for _, c := range f.sortedChildren() {
+		writeFlameFrame(b, c, total, x, y-flameGraphFrameHeight, scale)
+		x += float64(c.count) * scale
+	}
+}
+
+// flameColor picks a warm colour for a frame, derived from its name so it is stable between renders
+func flameColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%150, (v>>16)%55)
+}
@@ -18,23 +18,33 @@ type Formatter struct {
 	format string
 	writer io.Writer
 	style  string // For fancy format style customization
+
+	redactor   *Redactor   // Redacts sensitive values before writing, nil when disabled
+	truncation Truncation  // Limits cell widths in the table formats
+	timeFormat *TimeFormat // Rewrites timestamp columns, nil to keep them as returned
 }
 
 // New creates a new Formatter
 func New(format string) *Formatter {
 	return &Formatter{
-		format: format,
-		writer: os.Stdout,
-		style:  "dark", // Default style
+		format:     format,
+		writer:     os.Stdout,
+		style:      "dark", // Default style
+		redactor:   defaultRedactor,
+		truncation: defaultTruncation,
+		timeFormat: defaultTimeFormat,
 	}
 }
 
 // NewWithStyle creates a new Formatter with a specific style for fancy output
 func NewWithStyle(format string, style string) *Formatter {
 	return &Formatter{
-		format: format,
-		writer: os.Stdout,
-		style:  style,
+		format:     format,
+		writer:     os.Stdout,
+		style:      style,
+		redactor:   defaultRedactor,
+		truncation: defaultTruncation,
+		timeFormat: defaultTimeFormat,
 	}
 }
 
@@ -43,8 +53,24 @@ func (f *Formatter) SetWriter(w io.Writer) {
 	f.writer = w
 }
 
+// SetRedactor sets the redactor applied to rows before they are written, nil disables redaction
+func (f *Formatter) SetRedactor(r *Redactor) {
+	f.redactor = r
+}
+
 // Write writes the data with the specified format
 func (f *Formatter) Write(headers []string, rows [][]string) error {
+	if f.redactor != nil {
+		rows = f.redactor.Rows(headers, rows)
+	}
+	if f.timeFormat != nil {
+		rows = f.timeFormat.Rows(headers, rows)
+	}
+
+	if text, ok := templateText(f.format); ok {
+		return f.writeTemplate(text, headers, rows)
+	}
+
 	switch f.format {
 	case "json":
 		return f.writeJSON(headers, rows)
@@ -59,6 +85,8 @@ func (f *Formatter) Write(headers []string, rows [][]string) error {
 
 // writeFancy writes the data in a fancy table format using go-pretty
 func (f *Formatter) writeFancy(headers []string, rows [][]string) error {
+	rows = f.truncation.Rows(headers, rows)
+
 	t := table.NewWriter()
 	t.SetOutputMirror(f.writer)
 
@@ -122,6 +150,9 @@ func (f *Formatter) writeFancy(headers []string, rows [][]string) error {
 		if i > 0 {
 			maxWidth = 30
 		}
+		if f.truncation.MaxColWidth > 0 {
+			maxWidth = f.truncation.MaxColWidth
+		}
 		configs = append(configs, table.ColumnConfig{
 			Number:    i + 1,
 			AutoMerge: false,
@@ -147,6 +178,8 @@ func (f *Formatter) writeFancy(headers []string, rows [][]string) error {
 }
 
 func (f *Formatter) writePlain(headers []string, rows [][]string) error {
+	rows = f.truncation.Rows(headers, rows)
+
 	w := tabwriter.NewWriter(f.writer, 0, 0, 1, ' ', 0)
 	
 	// Write headers
@@ -0,0 +1,149 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redactPattern is a regular expression whose matches are replaced by tokens with the given prefix
+type redactPattern struct {
+	prefix string
+	re     *regexp.Regexp
+}
+
+// defaultRedactPatterns match sensitive values that can appear anywhere in a cell
+var defaultRedactPatterns = []redactPattern{
+	{"ip", regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+	{"ip", regexp.MustCompile(`\b(?:[0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b`)},
+	{"ip", regexp.MustCompile(`\b(?:[0-9a-fA-F]{1,4}:){1,6}:(?:[0-9a-fA-F]{1,4}(?::[0-9a-fA-F]{1,4})*)?`)},
+	{"email", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+}
+
+// sensitiveColumns maps normalised column headers whose whole value is sensitive to a token prefix
+var sensitiveColumns = map[string]string{
+	"user":              "user",
+	"users":             "user",
+	"username":          "user",
+	"user name":         "user",
+	"principal":         "user",
+	"run as":            "user",
+	"api key":           "key",
+	"api key id":        "key",
+	"api key ids":       "key",
+	"opaque id":         "opaque",
+	"key id":            "key",
+	"token":             "key",
+	"host":              "host",
+	"hostname":          "host",
+	"ip":                "ip",
+	"ip address":        "ip",
+	"address":           "ip",
+	"transport address": "ip",
+	"publish address":   "ip",
+	"http address":      "ip",
+}
+
+// defaultRedactor is applied to every formatter created after redaction is enabled
+var defaultRedactor *Redactor
+
+// Redactor replaces usernames, IP addresses, API key IDs and other sensitive values with
+// tokens numbered in the order the values are first seen, such as ip-1 and user-2, so that
+// output can be shared without losing which rows refer to the same value. The tokens carry
+// nothing of the value, so they cannot be reversed by trying likely values, and they are only
+// stable within one run.
+type Redactor struct {
+	patterns []redactPattern
+
+	mu     sync.Mutex
+	tokens map[string]string // token by prefix and value
+	counts map[string]int    // tokens handed out by prefix
+}
+
+// NewRedactor creates a Redactor using the built in patterns plus any additional regular expressions
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		patterns: append([]redactPattern{}, defaultRedactPatterns...),
+		tokens:   make(map[string]string),
+		counts:   make(map[string]int),
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, redactPattern{"redacted", re})
+	}
+	return r, nil
+}
+
+// EnableRedaction makes every formatter created from now on redact its output
+func EnableRedaction(patterns []string) error {
+	r, err := NewRedactor(patterns)
+	if err != nil {
+		return err
+	}
+	defaultRedactor = r
+	return nil
+}
+
+// DefaultRedactor returns the redactor enabled with EnableRedaction, nil when redaction is off
+func DefaultRedactor() *Redactor {
+	return defaultRedactor
+}
+
+// String redacts every sensitive value found in a string
+func (r *Redactor) String(s string) string {
+	for _, p := range r.patterns {
+		prefix := p.prefix
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			return r.Token(prefix, match)
+		})
+	}
+	return s
+}
+
+// Rows returns a redacted copy of the rows. Cells in sensitive columns are replaced entirely, each
+// entry separately when the cell lists several; other cells have sensitive values replaced where
+// they appear.
+func (r *Redactor) Rows(headers []string, rows [][]string) [][]string {
+	prefixes := make([]string, len(headers))
+	for i, h := range headers {
+		name := strings.ToLower(strings.NewReplacer("_", " ", "-", " ", ".", " ").Replace(h))
+		prefixes[i] = sensitiveColumns[strings.TrimSpace(name)]
+	}
+
+	result := make([][]string, len(rows))
+	for i, row := range rows {
+		redacted := make([]string, len(row))
+		for j, cell := range row {
+			if j < len(prefixes) && prefixes[j] != "" && cell != "" && cell != "-" {
+				entries := strings.Split(cell, ", ")
+				for k, entry := range entries {
+					entries[k] = r.Token(prefixes[j], entry)
+				}
+				redacted[j] = strings.Join(entries, ", ")
+				continue
+			}
+			redacted[j] = r.String(cell)
+		}
+		result[i] = redacted
+	}
+	return result
+}
+
+// Token returns the token for a value, the same one each time the value is seen
+func (r *Redactor) Token(prefix, value string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := prefix + "\x00" + value
+	if token, ok := r.tokens[key]; ok {
+		return token
+	}
+	r.counts[prefix]++
+	token := fmt.Sprintf("%s-%d", prefix, r.counts[prefix])
+	r.tokens[key] = token
+	return token
+}
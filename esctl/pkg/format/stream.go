@@ -0,0 +1,155 @@
+package format
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultHeaderEvery is the number of rows between repeated headers in a stream
+const DefaultHeaderEvery = 50
+
+// StreamWriter writes rows as they arrive instead of buffering the whole listing.
+// Table formats are rendered in blocks of headerEvery rows, each with its own header,
+// so memory use is bounded by the block size rather than the number of rows.
+type StreamWriter struct {
+	formatter   *Formatter
+	headers     []string
+	headerEvery int
+
+	block   [][]string    // pending rows for the plain and fancy formats, every row for go-template
+	rows    int           // rows written so far
+	csv     *csv.Writer   // csv output, created on the first row
+	encoder *json.Encoder // json output, created on the first row
+}
+
+// NewStream creates a StreamWriter for the formatter's format. Headers are repeated every
+// headerEvery rows for the plain and fancy formats; a value of 0 or less uses DefaultHeaderEvery.
+func (f *Formatter) NewStream(headers []string, headerEvery int) *StreamWriter {
+	if headerEvery <= 0 {
+		headerEvery = DefaultHeaderEvery
+	}
+	return &StreamWriter{
+		formatter:   f,
+		headers:     headers,
+		headerEvery: headerEvery,
+	}
+}
+
+// WriteRow writes a single row, rendering a block of table output when it is full
+func (s *StreamWriter) WriteRow(row []string) error {
+	if s.formatter.redactor != nil {
+		row = s.formatter.redactor.Rows(s.headers, [][]string{row})[0]
+	}
+	if s.formatter.timeFormat != nil {
+		row = s.formatter.timeFormat.Rows(s.headers, [][]string{row})[0]
+	}
+
+	// A template ranges over every row, so they are all kept until the stream is closed
+	if _, ok := templateText(s.formatter.format); ok {
+		s.block = append(s.block, row)
+		s.rows++
+		return nil
+	}
+
+	switch s.formatter.format {
+	case "json":
+		return s.writeJSONRow(row)
+	case "csv":
+		return s.writeCSVRow(row)
+	default:
+		s.block = append(s.block, row)
+		s.rows++
+		if len(s.block) >= s.headerEvery {
+			return s.flushBlock()
+		}
+		return nil
+	}
+}
+
+// Close writes any pending rows and terminates the output
+func (s *StreamWriter) Close() error {
+	if text, ok := templateText(s.formatter.format); ok {
+		return s.formatter.writeTemplate(text, s.headers, s.block)
+	}
+
+	switch s.formatter.format {
+	case "json":
+		if s.encoder == nil {
+			_, err := fmt.Fprintln(s.formatter.writer, "[]")
+			return err
+		}
+		_, err := fmt.Fprintln(s.formatter.writer, "]")
+		return err
+	case "csv":
+		if s.csv == nil {
+			return s.formatter.writeCSV(s.headers, nil)
+		}
+		s.csv.Flush()
+		return s.csv.Error()
+	default:
+		return s.flushBlock()
+	}
+}
+
+// Rows returns the number of rows written so far
+func (s *StreamWriter) Rows() int {
+	return s.rows
+}
+
+// flushBlock renders the pending rows as a table with its own header
+func (s *StreamWriter) flushBlock() error {
+	if len(s.block) == 0 {
+		return nil
+	}
+
+	var err error
+	if s.formatter.format == "fancy" {
+		err = s.formatter.writeFancy(s.headers, s.block)
+	} else {
+		err = s.formatter.writePlain(s.headers, s.block)
+	}
+
+	s.block = s.block[:0]
+	return err
+}
+
+// writeCSVRow writes a csv row, writing the header before the first one
+func (s *StreamWriter) writeCSVRow(row []string) error {
+	if s.csv == nil {
+		s.csv = csv.NewWriter(s.formatter.writer)
+		if err := s.csv.Write(s.headers); err != nil {
+			return err
+		}
+	}
+	if err := s.csv.Write(row); err != nil {
+		return err
+	}
+	s.rows++
+	if s.rows%s.headerEvery == 0 {
+		s.csv.Flush()
+		return s.csv.Error()
+	}
+	return nil
+}
+
+// writeJSONRow writes a row as an element of a JSON array, opening the array before the first one
+func (s *StreamWriter) writeJSONRow(row []string) error {
+	separator := ","
+	if s.encoder == nil {
+		s.encoder = json.NewEncoder(s.formatter.writer)
+		separator = "["
+	}
+	if _, err := fmt.Fprint(s.formatter.writer, separator); err != nil {
+		return err
+	}
+
+	item := make(map[string]string, len(s.headers))
+	for i, h := range s.headers {
+		if i < len(row) {
+			item[h] = row[i]
+		}
+	}
+	s.rows++
+	return s.encoder.Encode(item)
+}
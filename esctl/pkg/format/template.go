@@ -0,0 +1,65 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// goTemplatePrefix starts a format that renders the rows with a Go template instead of a table,
+// e.g. -f go-template='{{range .}}{{.Name}}{{"\n"}}{{end}}'
+const goTemplatePrefix = "go-template="
+
+// templateText returns the template of a go-template format, and whether the format is one
+func templateText(format string) (string, bool) {
+	if !strings.HasPrefix(format, goTemplatePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(format, goTemplatePrefix), true
+}
+
+// writeTemplate executes a Go template over the rows. The template is given a list with a map per
+// row, keyed by the column headers and by the headers without spaces and punctuation, so a
+// "Doc Count" column is both {{index . "Doc Count"}} and {{.DocCount}}. Cells are not truncated.
+// A field that is not a column is reported as an error rather than rendered empty.
+func (f *Formatter) writeTemplate(text string, headers []string, rows [][]string) error {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid go-template: %w", err)
+	}
+
+	keys := make([]string, len(headers))
+	for i, h := range headers {
+		keys[i] = templateKey(h)
+	}
+
+	data := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		item := make(map[string]string, 2*len(headers))
+		for i, h := range headers {
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			item[h] = cell
+			item[keys[i]] = cell
+		}
+		data = append(data, item)
+	}
+
+	if err := tmpl.Execute(f.writer, data); err != nil {
+		return fmt.Errorf("failed to execute go-template: %w", err)
+	}
+	return nil
+}
+
+// templateKey returns a header as a template field name, keeping only its letters and digits
+func templateKey(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, header)
+}
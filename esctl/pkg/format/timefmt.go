@@ -0,0 +1,184 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFormat rewrites timestamp cells in a consistent format
+type TimeFormat struct {
+	kind   string // iso8601, epoch, relative or layout
+	layout string // Go time layout when kind is layout
+	now    func() time.Time
+}
+
+// defaultTimeFormat is applied to every formatter created after the time format is configured,
+// nil leaves timestamps as the API returned them
+var defaultTimeFormat *TimeFormat
+
+// timestampHeaders are the header words that mark a column as holding timestamps
+var timestampHeaders = []string{
+	"updated", "created", "check-in", "checkin", "seen", "started", "enrolled", "expires",
+	"last run", "last update", "last check", "oldest", "newest", "time", "date",
+}
+
+// timestampLayouts are the layouts tried, in order, when reading a timestamp cell
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// strftimeDirectives maps strftime directives to Go time layout elements
+var strftimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'h': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'Z': "MST", 'z': "-0700", 'F': "2006-01-02", 'T': "15:04:05", '%': "%",
+}
+
+// ParseTimeFormat parses a --time-format value: iso8601, epoch, relative, or a strftime pattern
+// such as "%Y-%m-%d %H:%M". An empty value leaves timestamps unchanged and returns nil.
+func ParseTimeFormat(spec string) (*TimeFormat, error) {
+	switch spec {
+	case "":
+		return nil, nil
+	case "iso8601", "epoch", "relative":
+		return &TimeFormat{kind: spec, now: time.Now}, nil
+	}
+	if !strings.Contains(spec, "%") {
+		return nil, fmt.Errorf("invalid time format %q: use iso8601, epoch, relative or a strftime pattern", spec)
+	}
+
+	var layout strings.Builder
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			layout.WriteByte(spec[i])
+			continue
+		}
+		if i+1 == len(spec) {
+			return nil, fmt.Errorf("invalid time format %q: pattern ends with %%", spec)
+		}
+		i++
+		element, ok := strftimeDirectives[spec[i]]
+		if !ok {
+			return nil, fmt.Errorf("invalid time format %q: unsupported directive %%%c", spec, spec[i])
+		}
+		layout.WriteString(element)
+	}
+	return &TimeFormat{kind: "layout", layout: layout.String(), now: time.Now}, nil
+}
+
+// SetTimeFormat sets the timestamp format applied by every formatter created from now on
+func SetTimeFormat(spec string) error {
+	tf, err := ParseTimeFormat(spec)
+	if err != nil {
+		return err
+	}
+	defaultTimeFormat = tf
+	return nil
+}
+
+// Rows returns a copy of the rows with the cells of timestamp columns rewritten. Cells that do
+// not parse as a timestamp, such as "-" or "never", are kept as they are.
+func (tf *TimeFormat) Rows(headers []string, rows [][]string) [][]string {
+	columns := make([]bool, len(headers))
+	found := false
+	for i, h := range headers {
+		columns[i] = isTimestampHeader(h)
+		found = found || columns[i]
+	}
+	if !found {
+		return rows
+	}
+
+	result := make([][]string, len(rows))
+	for i, row := range rows {
+		formatted := make([]string, len(row))
+		for j, cell := range row {
+			if j < len(columns) && columns[j] {
+				if t, ok := parseTimestamp(cell); ok {
+					cell = tf.format(t)
+				}
+			}
+			formatted[j] = cell
+		}
+		result[i] = formatted
+	}
+	return result
+}
+
+// format renders a timestamp in the local time zone, which follows TZ
+func (tf *TimeFormat) format(t time.Time) string {
+	switch tf.kind {
+	case "epoch":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "relative":
+		return relativeTime(tf.now().Sub(t))
+	case "layout":
+		return t.Local().Format(tf.layout)
+	default:
+		return t.Local().Format(time.RFC3339)
+	}
+}
+
+// isTimestampHeader reports whether a column header names a timestamp column
+func isTimestampHeader(header string) bool {
+	h := strings.ToLower(header)
+	if strings.HasSuffix(h, " at") {
+		return true
+	}
+	for _, word := range timestampHeaders {
+		if strings.Contains(h, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimestamp reads a cell as a date string or epoch milliseconds
+func parseTimestamp(cell string) (time.Time, bool) {
+	cell = strings.TrimSpace(cell)
+	if len(cell) == 13 {
+		if ms, err := strconv.ParseInt(cell, 10, 64); err == nil {
+			return time.UnixMilli(ms), true
+		}
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, cell); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// relativeTime renders an age as "5m ago", or "in 5m" for times in the future
+func relativeTime(d time.Duration) string {
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var s string
+	switch {
+	case d < time.Minute:
+		s = fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		s = fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		s = fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
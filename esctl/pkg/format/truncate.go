@@ -0,0 +1,103 @@
+package format
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ellipsis marks where a truncated cell was shortened
+const ellipsis = "..."
+
+// Truncation limits the width of table cells in the plain and fancy formats
+type Truncation struct {
+	Enabled     bool           // shorten cells wider than their column limit; when false they are kept whole
+	MaxColWidth int            // width limit for every column, 0 for none
+	columns     map[string]int // width limits asked for by a command, by column header
+}
+
+// defaultTruncation is applied to every formatter created after truncation is configured
+var defaultTruncation = Truncation{Enabled: true}
+
+// SetTruncation sets the truncation applied by every formatter created from now on. A
+// maxColWidth of 0 leaves columns unlimited, except those a command limits itself.
+func SetTruncation(enabled bool, maxColWidth int) {
+	defaultTruncation = Truncation{Enabled: enabled, MaxColWidth: maxColWidth}
+}
+
+// SetColumnWidth limits the width of a column when truncation is enabled. A width given with
+// --max-col-width takes precedence.
+func (f *Formatter) SetColumnWidth(header string, width int) {
+	if f.truncation.columns == nil {
+		f.truncation.columns = make(map[string]int)
+	}
+	f.truncation.columns[header] = width
+}
+
+// width returns the width limit of a column, 0 for none
+func (t Truncation) width(header string) int {
+	if t.MaxColWidth > 0 {
+		return t.MaxColWidth
+	}
+	return t.columns[header]
+}
+
+// Rows returns a copy of the rows with cells wider than their column limit shortened
+func (t Truncation) Rows(headers []string, rows [][]string) [][]string {
+	if !t.Enabled {
+		return rows
+	}
+
+	widths := make([]int, len(headers))
+	limited := false
+	for i, h := range headers {
+		widths[i] = t.width(h)
+		limited = limited || widths[i] > 0
+	}
+	if !limited {
+		return rows
+	}
+
+	result := make([][]string, len(rows))
+	for i, row := range rows {
+		truncated := make([]string, len(row))
+		for j, cell := range row {
+			if j < len(widths) && widths[j] > 0 {
+				cell = truncateCell(cell, widths[j], isIdentifier(headers[j], cell))
+			}
+			truncated[j] = cell
+		}
+		result[i] = truncated
+	}
+	return result
+}
+
+// isIdentifier reports whether a cell holds an ID, hash or similar single token, whose start and
+// end both matter when it is shortened
+func isIdentifier(header, cell string) bool {
+	h := strings.ToLower(header)
+	if h == "id" || strings.HasSuffix(h, " id") || strings.HasSuffix(h, "_id") {
+		return true
+	}
+	return !strings.ContainsAny(cell, " \t\n")
+}
+
+// truncateCell shortens a cell to width characters. Identifiers keep their start and end with the
+// ellipsis in the middle, other text keeps its start.
+func truncateCell(cell string, width int, middle bool) string {
+	length := utf8.RuneCountInString(cell)
+	if length <= width {
+		return cell
+	}
+	if width <= len(ellipsis) {
+		return string([]rune(cell)[:width])
+	}
+
+	runes := []rune(cell)
+	keep := width - len(ellipsis)
+	if !middle {
+		return string(runes[:keep]) + ellipsis
+	}
+	head := (keep + 1) / 2
+	tail := keep - head
+	return string(runes[:head]) + ellipsis + string(runes[length-tail:])
+}
@@ -0,0 +1,100 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultWatchInterval is the time between runs in watch mode
+const DefaultWatchInterval = 5 * time.Second
+
+var (
+	// watchRunHooks are called after each run in watch mode, in the order they were registered
+	watchRunHooks   []func()
+	watchRunHooksMu sync.Mutex
+)
+
+// OnWatchRun registers a function to call after each run in watch mode, for output that
+// cobra.OnFinalize would otherwise only give once the command ends, which it never does
+func OnWatchRun(f func()) {
+	watchRunHooksMu.Lock()
+	defer watchRunHooksMu.Unlock()
+	watchRunHooks = append(watchRunHooks, f)
+}
+
+// Watch calls render every interval until the process is interrupted, like watch(1). On a
+// terminal the screen is cleared before each run and a line with the command and the time is
+// shown above the output; when the output is redirected the runs follow each other and the line
+// goes to stderr, so the output stays parseable. A failed run is reported and the next one still
+// happens.
+func Watch(interval time.Duration, title string, render func() error) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be above zero, got %s", interval)
+	}
+
+	interactive := false
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		interactive = true
+	}
+
+	for {
+		heading := fmt.Sprintf("Every %s: %s    %s", interval, title, time.Now().Format("2006-01-02 15:04:05"))
+		if interactive {
+			// Move the cursor home and clear the screen
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("%s\n\n", heading)
+		} else {
+			fmt.Fprintln(os.Stderr, heading)
+		}
+
+		if err := render(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+
+		watchRunHooksMu.Lock()
+		hooks := watchRunHooks
+		watchRunHooksMu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Watcher holds the --watch and --interval flags of a command and runs the command once, or
+// again every interval with --watch
+type Watcher struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// NewWatcher creates a Watcher, whose flags are added to commands with AddFlags
+func NewWatcher() *Watcher {
+	return &Watcher{Interval: DefaultWatchInterval}
+}
+
+// AddFlags registers --watch and --interval once and adds them to every given command, for a
+// root command whose list subcommand runs the same way
+func (w *Watcher) AddFlags(cmds ...*cobra.Command) {
+	flags := cmds[0].Flags()
+	flags.BoolVar(&w.Enabled, "watch", false, "Clear the screen and show the output again every --interval until interrupted")
+	flags.DurationVar(&w.Interval, "interval", DefaultWatchInterval, "Time between refreshes with --watch, such as 5s or 1m")
+	for _, cmd := range cmds[1:] {
+		cmd.Flags().AddFlag(flags.Lookup("watch"))
+		cmd.Flags().AddFlag(flags.Lookup("interval"))
+	}
+}
+
+// Wrap returns a RunE that runs run once, or again every --interval until interrupted with --watch
+func (w *Watcher) Wrap(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if !w.Enabled {
+			return run(cmd, args)
+		}
+		return Watch(w.Interval, cmd.CommandPath(), func() error { return run(cmd, args) })
+	}
+}